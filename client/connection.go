@@ -1,6 +1,7 @@
 package lxd
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"net/http"
@@ -40,6 +41,9 @@ type ConnectionArgs struct {
 	// Authentication interactor
 	AuthInteractor []httpbakery.Interactor
 
+	// OpenID Connect tokens, updated in place when renewed (for the "oidc" authentication type)
+	OIDCTokens *OIDCTokens
+
 	// Custom proxy
 	Proxy func(*http.Request) (*url.URL, error)
 
@@ -58,6 +62,16 @@ type ConnectionArgs struct {
 	// Caching support for image servers
 	CachePath   string
 	CacheExpiry time.Duration
+
+	// Number of times idempotent requests are retried on connection errors or when the server is
+	// unavailable, failing over to the other cluster members if any (0 disables retries)
+	RetryCount int
+
+	// Delay before the first retry, doubled after each attempt (defaults to 500ms)
+	RetryBackoff time.Duration
+
+	// Reuse connections between requests rather than opening a new one for each request
+	KeepAlive bool
 }
 
 // ConnectLXD lets you connect to a remote LXD daemon over HTTPs.
@@ -68,16 +82,29 @@ type ConnectionArgs struct {
 //
 // Unless the remote server is trusted by the system CA, the remote certificate must be provided (TLSServerCert).
 func ConnectLXD(url string, args *ConnectionArgs) (InstanceServer, error) {
+	return ConnectLXDWithContext(context.Background(), url, args)
+}
+
+// ConnectLXDWithContext lets you connect to a remote LXD daemon over HTTPs with context.Context.
+//
+// The context is used for the initial connection as well as for all the requests, websockets and
+// operation waits of the returned client (see InstanceServer.WithContext to change it afterwards).
+func ConnectLXDWithContext(ctx context.Context, url string, args *ConnectionArgs) (InstanceServer, error) {
 	logger.Debugf("Connecting to a remote LXD over HTTPs")
 
 	// Cleanup URL
 	url = strings.TrimSuffix(url, "/")
 
-	return httpsLXD(url, args)
+	return httpsLXD(ctx, url, args)
 }
 
 // ConnectLXDHTTP lets you connect to a VM agent over a VM socket.
 func ConnectLXDHTTP(args *ConnectionArgs, client *http.Client) (InstanceServer, error) {
+	return ConnectLXDHTTPWithContext(context.Background(), args, client)
+}
+
+// ConnectLXDHTTPWithContext lets you connect to a VM agent over a VM socket with context.Context.
+func ConnectLXDHTTPWithContext(ctx context.Context, args *ConnectionArgs, client *http.Client) (InstanceServer, error) {
 	logger.Debugf("Connecting to a VM agent over a VM socket")
 
 	// Use empty args if not specified
@@ -87,10 +114,13 @@ func ConnectLXDHTTP(args *ConnectionArgs, client *http.Client) (InstanceServer,
 
 	// Initialize the client struct
 	server := ProtocolLXD{
+		ctx:           ctx,
 		httpHost:      "https://custom.socket",
 		httpProtocol:  "custom",
 		httpUserAgent: args.UserAgent,
 		chConnected:   make(chan struct{}, 1),
+		retryCount:    args.RetryCount,
+		retryBackoff:  args.RetryBackoff,
 	}
 
 	// Setup the HTTP client
@@ -116,6 +146,11 @@ func ConnectLXDHTTP(args *ConnectionArgs, client *http.Client) (InstanceServer,
 // unset $LXD_DIR/unix.socket will be used and if that one isn't set
 // either, then the path will default to /var/lib/lxd/unix.socket.
 func ConnectLXDUnix(path string, args *ConnectionArgs) (InstanceServer, error) {
+	return ConnectLXDUnixWithContext(context.Background(), path, args)
+}
+
+// ConnectLXDUnixWithContext lets you connect to a remote LXD daemon over a local unix socket with context.Context.
+func ConnectLXDUnixWithContext(ctx context.Context, path string, args *ConnectionArgs) (InstanceServer, error) {
 	logger.Debugf("Connecting to a local LXD over a Unix socket")
 
 	// Use empty args if not specified
@@ -125,11 +160,14 @@ func ConnectLXDUnix(path string, args *ConnectionArgs) (InstanceServer, error) {
 
 	// Initialize the client struct
 	server := ProtocolLXD{
+		ctx:           ctx,
 		httpHost:      "http://unix.socket",
 		httpUnixPath:  path,
 		httpProtocol:  "unix",
 		httpUserAgent: args.UserAgent,
 		chConnected:   make(chan struct{}, 1),
+		retryCount:    args.RetryCount,
+		retryBackoff:  args.RetryBackoff,
 	}
 
 	// Determine the socket path
@@ -152,6 +190,11 @@ func ConnectLXDUnix(path string, args *ConnectionArgs) (InstanceServer, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	if args.KeepAlive {
+		enableKeepAlive(httpClient)
+	}
+
 	server.http = httpClient
 
 	// Test the connection and seed the server information
@@ -172,12 +215,17 @@ func ConnectLXDUnix(path string, args *ConnectionArgs) (InstanceServer, error) {
 //
 // Unless the remote server is trusted by the system CA, the remote certificate must be provided (TLSServerCert).
 func ConnectPublicLXD(url string, args *ConnectionArgs) (ImageServer, error) {
+	return ConnectPublicLXDWithContext(context.Background(), url, args)
+}
+
+// ConnectPublicLXDWithContext lets you connect to a remote public LXD daemon over HTTPs with context.Context.
+func ConnectPublicLXDWithContext(ctx context.Context, url string, args *ConnectionArgs) (ImageServer, error) {
 	logger.Debugf("Connecting to a remote public LXD over HTTPs")
 
 	// Cleanup URL
 	url = strings.TrimSuffix(url, "/")
 
-	return httpsLXD(url, args)
+	return httpsLXD(ctx, url, args)
 }
 
 // ConnectSimpleStreams lets you connect to a remote SimpleStreams image server over HTTPs.
@@ -239,8 +287,8 @@ func ConnectSimpleStreams(url string, args *ConnectionArgs) (ImageServer, error)
 	return &server, nil
 }
 
-// Internal function called by ConnectLXD and ConnectPublicLXD
-func httpsLXD(url string, args *ConnectionArgs) (InstanceServer, error) {
+// Internal function called by ConnectLXDWithContext and ConnectPublicLXDWithContext
+func httpsLXD(ctx context.Context, url string, args *ConnectionArgs) (InstanceServer, error) {
 	// Use empty args if not specified
 	if args == nil {
 		args = &ConnectionArgs{}
@@ -248,12 +296,15 @@ func httpsLXD(url string, args *ConnectionArgs) (InstanceServer, error) {
 
 	// Initialize the client struct
 	server := ProtocolLXD{
+		ctx:              ctx,
 		httpCertificate:  args.TLSServerCert,
 		httpHost:         url,
 		httpProtocol:     "https",
 		httpUserAgent:    args.UserAgent,
 		bakeryInteractor: args.AuthInteractor,
 		chConnected:      make(chan struct{}, 1),
+		retryCount:       args.RetryCount,
+		retryBackoff:     args.RetryBackoff,
 	}
 
 	if args.AuthType == "candid" {
@@ -270,11 +321,20 @@ func httpsLXD(url string, args *ConnectionArgs) (InstanceServer, error) {
 		httpClient.Jar = args.CookieJar
 	}
 
+	if args.KeepAlive {
+		enableKeepAlive(httpClient)
+	}
+
 	server.http = httpClient
 	if args.AuthType == "candid" {
 		server.setupBakeryClient()
 	}
 
+	if args.AuthType == "oidc" {
+		server.RequireAuthenticated(true)
+		server.oidcClient = newOIDCClient(httpClient, args.OIDCTokens)
+	}
+
 	// Test the connection and seed the server information
 	if !args.SkipGetServer {
 		_, _, err := server.GetServer()
@@ -142,4 +142,34 @@
 //  if err != nil {
 //    return err
 //  }
+//
+// Example - timeouts and cancellation
+//
+// This stops a container, giving up on the request after 30s
+//
+//  // Connect to LXD over the Unix socket
+//  c, err := lxd.ConnectLXDUnix("", nil)
+//  if err != nil {
+//    return err
+//  }
+//
+//  ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//  defer cancel()
+//
+//  // Requests, websockets and operation waits of the returned client use the context
+//  reqState := api.InstanceStatePut{
+//    Action:  "stop",
+//    Timeout: -1,
+//  }
+//
+//  op, err := c.WithContext(ctx).UpdateInstanceState("my-container", reqState, "")
+//  if err != nil {
+//    return err
+//  }
+//
+//  // Wait for the operation to complete, or for the context to be done
+//  err = op.WaitContext(ctx)
+//  if err != nil {
+//    return err
+//  }
 package lxd
@@ -1,12 +1,24 @@
 package lxd
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync"
 
+	"github.com/gorilla/websocket"
+
 	"github.com/lxc/lxd/shared/api"
 )
 
+// The EventFilter struct is used to restrict the events sent by the server to an EventListener
+type EventFilter struct {
+	// Types of events to receive (e.g. "lifecycle", "operation" or "logging"), defaults to all
+	Types []string
+
+	// Project to receive events from, defaults to the project of the client
+	Project string
+}
+
 // The EventListener struct is used to interact with a LXD event stream
 type EventListener struct {
 	r            *ProtocolLXD
@@ -14,6 +26,9 @@ type EventListener struct {
 	disconnected bool
 	err          error
 
+	// Dedicated connection of listeners created through GetEventsWithFilter
+	conn *websocket.Conn
+
 	targets     []*EventTarget
 	targetsLock sync.Mutex
 }
@@ -46,6 +61,57 @@ func (e *EventListener) AddHandler(types []string, function func(api.Event)) (*E
 	return &target, nil
 }
 
+// AddLifecycleHandler adds a function to be called whenever a lifecycle event is received
+func (e *EventListener) AddLifecycleHandler(function func(api.EventLifecycle)) (*EventTarget, error) {
+	if function == nil {
+		return nil, fmt.Errorf("A valid function must be provided")
+	}
+
+	return e.AddHandler([]string{"lifecycle"}, func(event api.Event) {
+		lifecycle := api.EventLifecycle{}
+		err := json.Unmarshal(event.Metadata, &lifecycle)
+		if err != nil {
+			return
+		}
+
+		function(lifecycle)
+	})
+}
+
+// AddOperationHandler adds a function to be called whenever an operation event is received
+func (e *EventListener) AddOperationHandler(function func(api.Operation)) (*EventTarget, error) {
+	if function == nil {
+		return nil, fmt.Errorf("A valid function must be provided")
+	}
+
+	return e.AddHandler([]string{"operation"}, func(event api.Event) {
+		op := api.Operation{}
+		err := json.Unmarshal(event.Metadata, &op)
+		if err != nil {
+			return
+		}
+
+		function(op)
+	})
+}
+
+// AddLoggingHandler adds a function to be called whenever a logging event is received
+func (e *EventListener) AddLoggingHandler(function func(api.EventLogging)) (*EventTarget, error) {
+	if function == nil {
+		return nil, fmt.Errorf("A valid function must be provided")
+	}
+
+	return e.AddHandler([]string{"logging"}, func(event api.Event) {
+		logEntry := api.EventLogging{}
+		err := json.Unmarshal(event.Metadata, &logEntry)
+		if err != nil {
+			return
+		}
+
+		function(logEntry)
+	})
+}
+
 // RemoveHandler removes a function to be called whenever an event is received
 func (e *EventListener) RemoveHandler(target *EventTarget) error {
 	if target == nil {
@@ -79,6 +145,11 @@ func (e *EventListener) Disconnect() {
 	e.r.eventListenersLock.Lock()
 	defer e.r.eventListenersLock.Unlock()
 
+	if e.conn != nil {
+		// Dedicated connection, no other listener depends on it
+		e.conn.Close()
+	}
+
 	// Locate and remove it from the global list
 	for i, listener := range e.r.eventListeners {
 		if listener == e {
@@ -1,8 +1,10 @@
 package lxd
 
 import (
+	"context"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/websocket"
 
@@ -14,20 +16,24 @@ import (
 // The Operation type represents a currently running operation.
 type Operation interface {
 	AddHandler(function func(api.Operation)) (target *EventTarget, err error)
+	AddProgressHandler(function func(ioprogress.ProgressData)) (target *EventTarget, err error)
 	Cancel() (err error)
 	Get() (op api.Operation)
 	GetWebsocket(secret string) (conn *websocket.Conn, err error)
 	RemoveHandler(target *EventTarget) (err error)
 	Refresh() (err error)
 	Wait() (err error)
+	WaitContext(ctx context.Context) (err error)
 }
 
 // The RemoteOperation type represents an Operation that may be using multiple servers.
 type RemoteOperation interface {
 	AddHandler(function func(api.Operation)) (target *EventTarget, err error)
+	AddProgressHandler(function func(ioprogress.ProgressData)) (target *EventTarget, err error)
 	CancelTarget() (err error)
 	GetTarget() (op *api.Operation, err error)
 	Wait() (err error)
+	WaitContext(ctx context.Context) (err error)
 }
 
 // The Server type represents a generic read-only server.
@@ -75,12 +81,14 @@ type InstanceServer interface {
 	IsClustered() (clustered bool)
 	UseTarget(name string) (client InstanceServer)
 	UseProject(name string) (client InstanceServer)
+	WithContext(ctx context.Context) (client InstanceServer)
 
 	// Certificate functions
 	GetCertificateFingerprints() (fingerprints []string, err error)
 	GetCertificates() (certificates []api.Certificate, err error)
 	GetCertificate(fingerprint string) (certificate *api.Certificate, ETag string, err error)
 	CreateCertificate(certificate api.CertificatesPost) (err error)
+	CreateCertificateToken(certificate api.CertificatesPost) (op Operation, err error)
 	UpdateCertificate(fingerprint string, certificate api.CertificatePut, ETag string) (err error)
 	DeleteCertificate(fingerprint string) (err error)
 
@@ -144,12 +152,17 @@ type InstanceServer interface {
 	// Instance functions.
 	GetInstanceNames(instanceType api.InstanceType) (names []string, err error)
 	GetInstances(instanceType api.InstanceType) (instances []api.Instance, err error)
+	GetInstancesPage(instanceType api.InstanceType, offset int, limit int) (instances []api.Instance, err error)
 	GetInstancesFull(instanceType api.InstanceType) (instances []api.InstanceFull, err error)
+	GetInstancesWithFilter(instanceType api.InstanceType, filter string) (instances []api.Instance, err error)
+	GetInstancesFullWithFilter(instanceType api.InstanceType, filter string) (instances []api.InstanceFull, err error)
 	GetInstance(name string) (instance *api.Instance, ETag string, err error)
 	CreateInstance(instance api.InstancesPost) (op Operation, err error)
 	CreateInstanceFromImage(source ImageServer, image api.Image, req api.InstancesPost) (op RemoteOperation, err error)
 	CopyInstance(source InstanceServer, instance api.Instance, args *InstanceCopyArgs) (op RemoteOperation, err error)
 	UpdateInstance(name string, instance api.InstancePut, ETag string) (op Operation, err error)
+	RebuildInstance(name string, instance api.InstanceRebuildPost) (op Operation, err error)
+	RebuildInstanceFromImage(source ImageServer, image api.Image, name string, req api.InstanceRebuildPost) (op RemoteOperation, err error)
 	RenameInstance(name string, instance api.InstancePost) (op Operation, err error)
 	MigrateInstance(name string, instance api.InstancePost) (op Operation, err error)
 	DeleteInstance(name string) (op Operation, err error)
@@ -175,17 +188,20 @@ type InstanceServer interface {
 	DeleteInstanceSnapshot(instanceName string, name string) (op Operation, err error)
 	UpdateInstanceSnapshot(instanceName string, name string, instance api.InstanceSnapshotPut, ETag string) (op Operation, err error)
 
+	GetBackups() (backups []api.InstanceBackup, err error)
 	GetInstanceBackupNames(instanceName string) (names []string, err error)
 	GetInstanceBackups(instanceName string) (backups []api.InstanceBackup, err error)
 	GetInstanceBackup(instanceName string, name string) (backup *api.InstanceBackup, ETag string, err error)
 	CreateInstanceBackup(instanceName string, backup api.InstanceBackupsPost) (op Operation, err error)
 	RenameInstanceBackup(instanceName string, name string, backup api.InstanceBackupPost) (op Operation, err error)
 	DeleteInstanceBackup(instanceName string, name string) (op Operation, err error)
+	VerifyInstanceBackup(instanceName string, name string) (op Operation, err error)
 	GetInstanceBackupFile(instanceName string, name string, req *BackupFileRequest) (resp *BackupFileResponse, err error)
 	CreateInstanceFromBackup(args InstanceBackupArgs) (op Operation, err error)
 
 	GetInstanceState(name string) (state *api.InstanceState, ETag string, err error)
 	UpdateInstanceState(name string, state api.InstanceStatePut, ETag string) (op Operation, err error)
+	UpdateInstances(state api.InstancesPut, ETag string) (op Operation, err error)
 
 	GetInstanceLogfiles(name string) (logfiles []string, err error)
 	GetInstanceLogfile(name string, filename string) (content io.ReadCloser, err error)
@@ -202,8 +218,12 @@ type InstanceServer interface {
 
 	// Event handling functions
 	GetEvents() (listener *EventListener, err error)
+	GetEventsWithFilter(filter EventFilter) (listener *EventListener, err error)
 
 	// Image functions
+	GetImagesPage(offset int, limit int) (images []api.Image, err error)
+	GetImagesWithFilter(filter string) (images []api.Image, err error)
+	GetImageFilePart(fingerprint string, part string, offset int64, target io.Writer) (size int64, err error)
 	CreateImage(image api.ImagesPost, args *ImageCreateArgs) (op Operation, err error)
 	CopyImage(source ImageServer, image api.Image, args *ImageCopyArgs) (op RemoteOperation, err error)
 	UpdateImage(fingerprint string, image api.ImagePut, ETag string) (err error)
@@ -218,17 +238,62 @@ type InstanceServer interface {
 	// Network functions ("network" API extension)
 	GetNetworkNames() (names []string, err error)
 	GetNetworks() (networks []api.Network, err error)
+	GetNetworksPage(offset int, limit int) (networks []api.Network, err error)
+	GetNetworksWithFilter(filter string) (networks []api.Network, err error)
+	GetNetworksFull() (networks []api.NetworkFull, err error)
 	GetNetwork(name string) (network *api.Network, ETag string, err error)
 	GetNetworkLeases(name string) (leases []api.NetworkLease, err error)
+	GetNetworkMetrics(name string) (metrics *api.NetworkMetrics, err error)
 	GetNetworkState(name string) (state *api.NetworkState, err error)
+	GetNetworkStateHistory(name string, period time.Duration) (state *api.NetworkState, err error)
 	CreateNetwork(network api.NetworksPost) (err error)
 	UpdateNetwork(name string, network api.NetworkPut, ETag string) (err error)
 	RenameNetwork(name string, network api.NetworkPost) (err error)
 	DeleteNetwork(name string) (err error)
 
+	// Network forward functions ("network_forward" API extension)
+	GetNetworkForwardAddresses(networkName string) (addresses []string, err error)
+	GetNetworkForwards(networkName string) (forwards []api.NetworkForward, err error)
+	GetNetworkForward(networkName string, listenAddress string) (forward *api.NetworkForward, ETag string, err error)
+	CreateNetworkForward(networkName string, forward api.NetworkForwardsPost) (err error)
+	UpdateNetworkForward(networkName string, listenAddress string, forward api.NetworkForwardPut, ETag string) (err error)
+	DeleteNetworkForward(networkName string, listenAddress string) (err error)
+
+	// Network peer functions ("network_peer" API extension)
+	GetNetworkPeerNames(networkName string) (names []string, err error)
+	GetNetworkPeers(networkName string) (peers []api.NetworkPeer, err error)
+	GetNetworkPeer(networkName string, peerName string) (peer *api.NetworkPeer, ETag string, err error)
+	CreateNetworkPeer(networkName string, peer api.NetworkPeersPost) (err error)
+	UpdateNetworkPeer(networkName string, peerName string, peer api.NetworkPeerPut, ETag string) (err error)
+	DeleteNetworkPeer(networkName string, peerName string) (err error)
+
+	// Network DHCP reservation functions ("network_reservations" API extension)
+	GetNetworkReservations(networkName string) (reservations []api.NetworkReservation, err error)
+	GetNetworkReservation(networkName string, hwaddr string) (reservation *api.NetworkReservation, err error)
+	CreateNetworkReservation(networkName string, reservation api.NetworkReservation) (err error)
+	DeleteNetworkReservation(networkName string, hwaddr string) (err error)
+
+	// Network ACL functions ("network_acl" API extension)
+	GetNetworkACLNames() (names []string, err error)
+	GetNetworkACLs() (acls []api.NetworkACL, err error)
+	GetNetworkACL(name string) (acl *api.NetworkACL, ETag string, err error)
+	CreateNetworkACL(acl api.NetworkACLsPost) (err error)
+	UpdateNetworkACL(name string, acl api.NetworkACLPut, ETag string) (err error)
+	RenameNetworkACL(name string, acl api.NetworkACLPost) (err error)
+	DeleteNetworkACL(name string) (err error)
+
+	// Network zone functions ("network_dns" API extension)
+	GetNetworkZoneNames() (names []string, err error)
+	GetNetworkZones() (zones []api.NetworkZone, err error)
+	GetNetworkZone(name string) (zone *api.NetworkZone, ETag string, err error)
+	CreateNetworkZone(zone api.NetworkZonesPost) (err error)
+	UpdateNetworkZone(name string, zone api.NetworkZonePut, ETag string) (err error)
+	DeleteNetworkZone(name string) (err error)
+
 	// Operation functions
 	GetOperationUUIDs() (uuids []string, err error)
 	GetOperations() (operations []api.Operation, err error)
+	GetOperationsAllProjects() (operations []api.Operation, err error)
 	GetOperation(uuid string) (op *api.Operation, ETag string, err error)
 	GetOperationWait(uuid string, timeout int) (op *api.Operation, ETag string, err error)
 	GetOperationWaitSecret(uuid string, secret string, timeout int) (op *api.Operation, ETag string, err error)
@@ -256,6 +321,7 @@ type InstanceServer interface {
 	// Storage pool functions ("storage" API extension)
 	GetStoragePoolNames() (names []string, err error)
 	GetStoragePools() (pools []api.StoragePool, err error)
+	GetStoragePoolsFull() (pools []api.StoragePoolFull, err error)
 	GetStoragePool(name string) (pool *api.StoragePool, ETag string, err error)
 	GetStoragePoolResources(name string) (resources *api.ResourcesStoragePool, err error)
 	CreateStoragePool(pool api.StoragePoolsPost) (err error)
@@ -265,6 +331,7 @@ type InstanceServer interface {
 	// Storage volume functions ("storage" API extension)
 	GetStoragePoolVolumeNames(pool string) (names []string, err error)
 	GetStoragePoolVolumes(pool string) (volumes []api.StorageVolume, err error)
+	GetStoragePoolVolumesWithFilter(pool string, filter string) (volumes []api.StorageVolume, err error)
 	GetStoragePoolVolume(pool string, volType string, name string) (volume *api.StorageVolume, ETag string, err error)
 	CreateStoragePoolVolume(pool string, volume api.StorageVolumesPost) (err error)
 	UpdateStoragePoolVolume(pool string, volType string, name string, volume api.StorageVolumePut, ETag string) (err error)
@@ -283,15 +350,47 @@ type InstanceServer interface {
 	RenameStoragePoolVolumeSnapshot(pool string, volumeType string, volumeName string, snapshotName string, snapshot api.StorageVolumeSnapshotPost) (op Operation, err error)
 	UpdateStoragePoolVolumeSnapshot(pool string, volumeType string, volumeName string, snapshotName string, volume api.StorageVolumeSnapshotPut, ETag string) (err error)
 
+	// Storage volume backup functions ("custom_volume_backup" API extension)
+	GetStoragePoolVolumeBackupNames(pool string, volName string) (names []string, err error)
+	GetStoragePoolVolumeBackups(pool string, volName string) (backups []api.StoragePoolVolumeBackup, err error)
+	GetStoragePoolVolumeBackup(pool string, volName string, name string) (backup *api.StoragePoolVolumeBackup, ETag string, err error)
+	CreateStoragePoolVolumeBackup(pool string, volName string, backup api.StoragePoolVolumeBackupsPost) (op Operation, err error)
+	RenameStoragePoolVolumeBackup(pool string, volName string, name string, backup api.StoragePoolVolumeBackupPost) (op Operation, err error)
+	DeleteStoragePoolVolumeBackup(pool string, volName string, name string) (op Operation, err error)
+	GetStoragePoolVolumeBackupFile(pool string, volName string, name string, req *BackupFileRequest) (resp *BackupFileResponse, err error)
+	CreateStoragePoolVolumeFromBackup(pool string, args StoragePoolVolumeBackupArgs) (op Operation, err error)
+
 	// Cluster functions ("cluster" API extensions)
 	GetCluster() (cluster *api.Cluster, ETag string, err error)
 	UpdateCluster(cluster api.ClusterPut, ETag string) (op Operation, err error)
+	CreateClusterMember(member api.ClusterMembersPost) (op Operation, err error)
 	DeleteClusterMember(name string, force bool) (err error)
 	GetClusterMemberNames() (names []string, err error)
 	GetClusterMembers() (members []api.ClusterMember, err error)
 	GetClusterMember(name string) (member *api.ClusterMember, ETag string, err error)
 	UpdateClusterMember(name string, member api.ClusterMemberPut, ETag string) (err error)
 	RenameClusterMember(name string, member api.ClusterMemberPost) (err error)
+	UpdateClusterMemberState(name string, state api.ClusterMemberStatePost) (op Operation, err error)
+	GetClusterGroupNames() (names []string, err error)
+	GetClusterGroups() (groups []api.ClusterGroup, err error)
+	GetClusterGroup(name string) (group *api.ClusterGroup, ETag string, err error)
+	CreateClusterGroup(group api.ClusterGroupsPost) (err error)
+	UpdateClusterGroup(name string, group api.ClusterGroupPut, ETag string) (err error)
+	RenameClusterGroup(name string, group api.ClusterGroupPost) (err error)
+	DeleteClusterGroup(name string) (err error)
+
+	// Search functions ("search" API extension)
+	Search(query string, types []string) (results []api.SearchResult, err error)
+
+	// Batch functions ("batch" API extension)
+	ExecuteBatch(batch api.BatchPost) (op Operation, err error)
+
+	// Warning functions ("warnings" API extension)
+	GetWarningUUIDs() (uuids []string, err error)
+	GetWarnings() (warnings []api.Warning, err error)
+	GetWarning(UUID string) (warning *api.Warning, ETag string, err error)
+	UpdateWarning(UUID string, warning api.WarningPut, ETag string) (err error)
+	DeleteWarning(UUID string) (err error)
 
 	// Internal functions (for internal use)
 	RawQuery(method string, path string, data interface{}, queryETag string) (resp *api.Response, ETag string, err error)
@@ -422,6 +521,19 @@ type StoragePoolVolumeMoveArgs struct {
 	StoragePoolVolumeCopyArgs
 }
 
+// The StoragePoolVolumeBackupArgs struct is used when creating a storage volume from a backup.
+// API extension: custom_volume_backup
+type StoragePoolVolumeBackupArgs struct {
+	// The backup file
+	BackupFile io.Reader
+
+	// Name to import backup as
+	Name string
+
+	// Progress handler (called whenever some progress is made)
+	ProgressHandler func(progress ioprogress.ProgressData)
+}
+
 // The InstanceBackupArgs struct is used when creating a instance from a backup.
 type InstanceBackupArgs struct {
 	// The backup file
@@ -429,6 +541,9 @@ type InstanceBackupArgs struct {
 
 	// Storage pool to use
 	PoolName string
+
+	// Progress handler (called whenever some progress is made)
+	ProgressHandler func(progress ioprogress.ProgressData)
 }
 
 // The InstanceCopyArgs struct is used to pass additional options during instance copy.
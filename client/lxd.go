@@ -2,12 +2,14 @@ package lxd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"gopkg.in/macaroon-bakery.v2/bakery"
@@ -20,8 +22,18 @@ import (
 	neturl "net/url"
 )
 
+// Methods of the requests which can safely be sent again when retrying.
+var idempotentMethods = []string{"GET", "HEAD", "OPTIONS", "PUT"}
+
+// Status codes of the responses after which requests are retried.
+var retryStatusCodes = []int64{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// Default delay before retrying a request, doubled after each attempt.
+const defaultRetryBackoff = 500 * time.Millisecond
+
 // ProtocolLXD represents a LXD API server
 type ProtocolLXD struct {
+	ctx         context.Context
 	server      *api.Server
 	chConnected chan struct{}
 
@@ -39,8 +51,13 @@ type ProtocolLXD struct {
 	bakeryInteractor     []httpbakery.Interactor
 	requireAuthenticated bool
 
+	oidcClient *oidcClient
+
 	clusterTarget string
 	project       string
+
+	retryCount   int
+	retryBackoff time.Duration
 }
 
 // Disconnect gets rid of any background goroutines
@@ -93,13 +110,29 @@ func (r *ProtocolLXD) GetHTTPClient() (*http.Client, error) {
 	return r.http, nil
 }
 
-// Do performs a Request, using macaroon authentication if set.
+// getContext returns the context applied to the requests of the connection.
+func (r *ProtocolLXD) getContext() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+
+	return r.ctx
+}
+
+// Do performs a Request, using macaroon or OpenID Connect authentication if set.
 func (r *ProtocolLXD) do(req *http.Request) (*http.Response, error) {
+	// Apply the connection's context for timeouts and cancellation.
+	req = req.WithContext(r.getContext())
+
 	if r.bakeryClient != nil {
 		r.addMacaroonHeaders(req)
 		return r.bakeryClient.Do(req)
 	}
 
+	if r.oidcClient != nil {
+		return r.oidcClient.do(req)
+	}
+
 	return r.http.Do(req)
 }
 
@@ -167,8 +200,9 @@ func lxdParseResponse(resp *http.Response) (*api.Response, string, error) {
 }
 
 func (r *ProtocolLXD) rawQuery(method string, url string, data interface{}, ETag string) (*api.Response, string, error) {
-	var req *http.Request
-	var err error
+	var body []byte
+	var bodyReader io.Reader
+	contentType := ""
 
 	// Log the request
 	logger.Debug("Sending request to LXD",
@@ -177,18 +211,15 @@ func (r *ProtocolLXD) rawQuery(method string, url string, data interface{}, ETag
 		"etag", ETag,
 	)
 
-	// Get a new HTTP request setup
+	// Prepare the data to be sent along with the request
 	if data != nil {
 		switch data.(type) {
 		case io.Reader:
 			// Some data to be sent along with the request
-			req, err = http.NewRequest(method, url, data.(io.Reader))
-			if err != nil {
-				return nil, "", err
-			}
+			bodyReader = data.(io.Reader)
 
 			// Set the encoding accordingly
-			req.Header.Set("Content-Type", "application/octet-stream")
+			contentType = "application/octet-stream"
 		default:
 			// Encode the provided data
 			buf := bytes.Buffer{}
@@ -197,50 +228,115 @@ func (r *ProtocolLXD) rawQuery(method string, url string, data interface{}, ETag
 				return nil, "", err
 			}
 
-			// Some data to be sent along with the request
-			// Use a reader since the request body needs to be seekable
-			req, err = http.NewRequest(method, url, bytes.NewReader(buf.Bytes()))
-			if err != nil {
-				return nil, "", err
-			}
+			body = buf.Bytes()
 
 			// Set the encoding accordingly
-			req.Header.Set("Content-Type", "application/json")
+			contentType = "application/json"
 
 			// Log the data
 			logger.Debugf(logger.Pretty(data))
 		}
-	} else {
-		// No data to be sent along with the request
-		req, err = http.NewRequest(method, url, nil)
+	}
+
+	// Streamed data can't be sent again so only retry requests with no or encoded data.
+	retry := r.retryCount > 0 && bodyReader == nil && shared.StringInSlice(method, idempotentMethods)
+	urls := []string{url}
+	if retry {
+		urls = r.failoverURLs(url)
+	}
+
+	backoff := r.retryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		// Use a reader since the request body needs to be seekable
+		if body != nil {
+			bodyReader = bytes.NewReader(body)
+		}
+
+		// Get a new HTTP request setup
+		req, err := http.NewRequest(method, urls[0], bodyReader)
 		if err != nil {
 			return nil, "", err
 		}
-	}
 
-	// Set the user agent
-	if r.httpUserAgent != "" {
-		req.Header.Set("User-Agent", r.httpUserAgent)
-	}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		// Set the user agent
+		if r.httpUserAgent != "" {
+			req.Header.Set("User-Agent", r.httpUserAgent)
+		}
+
+		// Set the ETag
+		if ETag != "" {
+			req.Header.Set("If-Match", ETag)
+		}
+
+		// Set the authentication header
+		if r.requireAuthenticated {
+			req.Header.Set("X-LXD-authenticated", "true")
+		}
+
+		// Send the request
+		resp, err := r.do(req)
+		if !retry || attempt >= r.retryCount || r.getContext().Err() != nil || (err == nil && !shared.Int64InSlice(int64(resp.StatusCode), retryStatusCodes)) {
+			if err != nil {
+				return nil, "", err
+			}
+			defer resp.Body.Close()
+
+			return lxdParseResponse(resp)
+		}
+
+		if err == nil {
+			resp.Body.Close()
+			logger.Debug("Retrying request to LXD", "url", urls[0], "status", resp.Status, "delay", backoff)
+		} else {
+			// Fail over to the next address of the server on connection errors.
+			logger.Debug("Retrying request to LXD", "url", urls[0], "err", err, "delay", backoff)
+			urls = append(urls[1:], urls[0])
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-r.getContext().Done():
+			return nil, "", r.getContext().Err()
+		}
 
-	// Set the ETag
-	if ETag != "" {
-		req.Header.Set("If-Match", ETag)
+		backoff *= 2
 	}
+}
 
-	// Set the authentication header
-	if r.requireAuthenticated {
-		req.Header.Set("X-LXD-authenticated", "true")
+// failoverURLs returns the given URL followed by its equivalents on the other addresses of the
+// server (e.g. the other members of a cluster) for requests to be retried against.
+func (r *ProtocolLXD) failoverURLs(url string) []string {
+	urls := []string{url}
+
+	if r.httpProtocol != "https" || r.server == nil || !strings.HasPrefix(url, r.httpHost) {
+		return urls
 	}
 
-	// Send the request
-	resp, err := r.do(req)
-	if err != nil {
-		return nil, "", err
+	for _, addr := range r.server.Environment.Addresses {
+		if strings.HasPrefix(addr, ":") {
+			continue
+		}
+
+		host := fmt.Sprintf("https://%s", addr)
+		if host == r.httpHost {
+			continue
+		}
+
+		alternate := host + strings.TrimPrefix(url, r.httpHost)
+		if !shared.StringInSlice(alternate, urls) {
+			urls = append(urls, alternate)
+		}
 	}
-	defer resp.Body.Close()
 
-	return lxdParseResponse(resp)
+	return urls
 }
 
 func (r *ProtocolLXD) setQueryAttributes(uri string) (string, error) {
@@ -373,8 +469,13 @@ func (r *ProtocolLXD) rawWebsocket(url string) (*websocket.Conn, error) {
 		r.addMacaroonHeaders(req)
 	}
 
+	// Set OIDC bearer token if needed
+	if r.oidcClient != nil {
+		r.oidcClient.setHeaders(headers)
+	}
+
 	// Establish the connection
-	conn, _, err := dialer.Dial(url, headers)
+	conn, _, err := dialer.DialContext(r.getContext(), url, headers)
 	if err != nil {
 		return nil, err
 	}
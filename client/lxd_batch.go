@@ -0,0 +1,24 @@
+package lxd
+
+import (
+	"fmt"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Batch handling functions
+
+// ExecuteBatch runs the provided list of requests server-side as a single background operation
+func (r *ProtocolLXD) ExecuteBatch(batch api.BatchPost) (Operation, error) {
+	if !r.HasExtension("batch") {
+		return nil, fmt.Errorf("The server is missing the required \"batch\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", "/batch", batch, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
@@ -67,6 +67,25 @@ func (r *ProtocolLXD) CreateCertificate(certificate api.CertificatesPost) error
 	return nil
 }
 
+// CreateCertificateToken requests a single-use token allowing a new client to add its certificate
+func (r *ProtocolLXD) CreateCertificateToken(certificate api.CertificatesPost) (Operation, error) {
+	if !r.HasExtension("certificate_token") {
+		return nil, fmt.Errorf("The server is missing the required \"certificate_token\" API extension")
+	}
+
+	if !certificate.Token {
+		return nil, fmt.Errorf("Token needs to be true if requesting a token")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", "/certificates", certificate, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
 // UpdateCertificate updates the certificate definition
 func (r *ProtocolLXD) UpdateCertificate(fingerprint string, certificate api.CertificatePut, ETag string) error {
 	if !r.HasExtension("certificate_update") {
@@ -108,6 +108,20 @@ func (r *ProtocolLXD) GetClusterMember(name string) (*api.ClusterMember, string,
 	return &member, etag, nil
 }
 
+// CreateClusterMember requests a single-use join token for a new cluster member
+func (r *ProtocolLXD) CreateClusterMember(member api.ClusterMembersPost) (Operation, error) {
+	if !r.HasExtension("clustering_join_token") {
+		return nil, fmt.Errorf("The server is missing the required \"clustering_join_token\" API extension")
+	}
+
+	op, _, err := r.queryOperation("POST", "/cluster/members", member, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
 // UpdateClusterMember updates information about the given member
 func (r *ProtocolLXD) UpdateClusterMember(name string, member api.ClusterMemberPut, ETag string) error {
 	if !r.HasExtension("clustering_edit_roles") {
@@ -118,6 +132,11 @@ func (r *ProtocolLXD) UpdateClusterMember(name string, member api.ClusterMemberP
 			return fmt.Errorf("The server is missing the required \"clustering_failure_domains\" API extension")
 		}
 	}
+	if len(member.Groups) > 0 {
+		if !r.HasExtension("clustering_groups") {
+			return fmt.Errorf("The server is missing the required \"clustering_groups\" API extension")
+		}
+	}
 
 	// Send the request
 	_, _, err := r.query("PUT", fmt.Sprintf("/cluster/members/%s", name), member, ETag)
@@ -141,3 +160,17 @@ func (r *ProtocolLXD) RenameClusterMember(name string, member api.ClusterMemberP
 
 	return nil
 }
+
+// UpdateClusterMemberState evacuates or restores a cluster member
+func (r *ProtocolLXD) UpdateClusterMemberState(name string, state api.ClusterMemberStatePost) (Operation, error) {
+	if !r.HasExtension("clustering_evacuation") {
+		return nil, fmt.Errorf("The server is missing the required \"clustering_evacuation\" API extension")
+	}
+
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/cluster/members/%s/state", name), state, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
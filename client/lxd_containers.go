@@ -14,7 +14,6 @@ import (
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/cancel"
 	"github.com/lxc/lxd/shared/ioprogress"
-	"github.com/lxc/lxd/shared/units"
 )
 
 // Container handling functions
@@ -1810,10 +1809,8 @@ func (r *ProtocolLXD) GetContainerBackupFile(containerName string, name string,
 		body = &ioprogress.ProgressReader{
 			ReadCloser: response.Body,
 			Tracker: &ioprogress.ProgressTracker{
-				Length: response.ContentLength,
-				Handler: func(percent int64, speed int64) {
-					req.ProgressHandler(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
-				},
+				Length:      response.ContentLength,
+				DataHandler: req.ProgressHandler,
 			},
 		}
 	}
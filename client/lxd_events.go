@@ -2,6 +2,9 @@ package lxd
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/lxc/lxd/shared"
@@ -123,3 +126,75 @@ func (r *ProtocolLXD) GetEvents() (*EventListener, error) {
 
 	return &listener, nil
 }
+
+// GetEventsWithFilter connects to the LXD monitoring interface, having the server only send the
+// events matching filter. Unlike GetEvents, each call uses its own connection.
+func (r *ProtocolLXD) GetEventsWithFilter(filter EventFilter) (*EventListener, error) {
+	server := r
+	if filter.Project != "" {
+		server = r.UseProject(filter.Project).(*ProtocolLXD)
+	}
+
+	// Setup a new connection with LXD
+	path := "/events"
+	if len(filter.Types) > 0 {
+		path = fmt.Sprintf("/events?type=%s", url.QueryEscape(strings.Join(filter.Types, ",")))
+	}
+
+	uri, err := server.setQueryAttributes(path)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := server.websocket(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	listener := EventListener{
+		r:        r,
+		chActive: make(chan bool),
+		conn:     conn,
+	}
+
+	// Spawn the listener
+	go func() {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				r.eventListenersLock.Lock()
+				defer r.eventListenersLock.Unlock()
+
+				// Tell the listener about the failure unless it disconnected itself
+				if !listener.disconnected {
+					listener.err = err
+					listener.disconnected = true
+					close(listener.chActive)
+				}
+
+				conn.Close()
+				return
+			}
+
+			// Attempt to unpack the message
+			event := api.Event{}
+			err = json.Unmarshal(data, &event)
+			if err != nil || event.Type == "" {
+				continue
+			}
+
+			// Send the message to all handlers
+			listener.targetsLock.Lock()
+			for _, target := range listener.targets {
+				if target.types != nil && !shared.StringInSlice(event.Type, target.types) {
+					continue
+				}
+
+				go target.function(event)
+			}
+			listener.targetsLock.Unlock()
+		}
+	}()
+
+	return &listener, nil
+}
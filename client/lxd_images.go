@@ -16,7 +16,6 @@ import (
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/cancel"
 	"github.com/lxc/lxd/shared/ioprogress"
-	"github.com/lxc/lxd/shared/units"
 )
 
 // Image handling functions
@@ -33,6 +32,43 @@ func (r *ProtocolLXD) GetImages() ([]api.Image, error) {
 	return images, nil
 }
 
+// GetImagesPage returns a window of at most limit Image structs, starting at offset
+func (r *ProtocolLXD) GetImagesPage(offset int, limit int) ([]api.Image, error) {
+	if !r.HasExtension("api_pagination") {
+		return nil, fmt.Errorf("The server is missing the required \"api_pagination\" API extension")
+	}
+
+	images := []api.Image{}
+
+	_, err := r.queryStruct("GET", fmt.Sprintf("/images?recursion=1&offset=%d&limit=%d", offset, limit), nil, "", &images)
+	if err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// GetImagesWithFilter returns a list of available images matching the given filter expression as Image structs
+func (r *ProtocolLXD) GetImagesWithFilter(filter string) ([]api.Image, error) {
+	if !r.HasExtension("api_filtering") {
+		return nil, fmt.Errorf("The server is missing the required \"api_filtering\" API extension")
+	}
+
+	query, err := filterQueryParam(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	images := []api.Image{}
+
+	_, err = r.queryStruct("GET", fmt.Sprintf("/images?recursion=1&%s", query), nil, "", &images)
+	if err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
 // GetImageFingerprints returns a list of available image fingerprints
 func (r *ProtocolLXD) GetImageFingerprints() ([]string, error) {
 	urls := []string{}
@@ -143,6 +179,61 @@ func (r *ProtocolLXD) GetPrivateImageFile(fingerprint string, secret string, req
 	return lxdDownloadImage(fingerprint, uri, r.httpUserAgent, r.http, req)
 }
 
+// GetImageFilePart downloads a single part ("metadata" or "rootfs") of a split image, requesting the metadata of
+// a unified image returns its only file. The download starts at the given offset, so that an interrupted transfer
+// can be resumed, and the number of bytes written to the target is returned.
+func (r *ProtocolLXD) GetImageFilePart(fingerprint string, part string, offset int64, target io.Writer) (int64, error) {
+	if !r.HasExtension("image_export_ranges") {
+		return 0, fmt.Errorf("The server is missing the required \"image_export_ranges\" API extension")
+	}
+
+	uri, err := r.setQueryAttributes(fmt.Sprintf("/1.0/images/%s/export", url.PathEscape(fingerprint)))
+	if err != nil {
+		return 0, err
+	}
+
+	uri, err = setQueryParam(fmt.Sprintf("%s%s", r.httpHost, uri), "part", part)
+	if err != nil {
+		return 0, err
+	}
+
+	request, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	if r.httpUserAgent != "" {
+		request.Header.Set("User-Agent", r.httpUserAgent)
+	}
+
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	response, err := r.http.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		if offset > 0 {
+			return 0, fmt.Errorf("The server didn't accept the requested range")
+		}
+	default:
+		_, _, err := lxdParseResponse(response)
+		if err != nil {
+			return 0, err
+		}
+
+		return 0, fmt.Errorf("Unexpected response status %q", response.Status)
+	}
+
+	return io.Copy(target, response.Body)
+}
+
 func lxdDownloadImage(fingerprint string, uri string, userAgent string, client *http.Client, req ImageFileRequest) (*ImageFileResponse, error) {
 	// Prepare the response
 	resp := ImageFileResponse{}
@@ -180,25 +271,13 @@ func lxdDownloadImage(fingerprint string, uri string, userAgent string, client *
 	// Handle the data
 	body := response.Body
 	if req.ProgressHandler != nil {
-		reader := &ioprogress.ProgressReader{
+		body = &ioprogress.ProgressReader{
 			ReadCloser: response.Body,
 			Tracker: &ioprogress.ProgressTracker{
-				Length: response.ContentLength,
+				Length:      response.ContentLength,
+				DataHandler: req.ProgressHandler,
 			},
 		}
-
-		if response.ContentLength > 0 {
-			reader.Tracker.Handler = func(percent int64, speed int64) {
-				req.ProgressHandler(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
-			}
-		} else {
-			reader.Tracker.Handler = func(received int64, speed int64) {
-				req.ProgressHandler(ioprogress.ProgressData{Text: fmt.Sprintf("%s (%s/s)", units.GetByteSizeString(received, 2), units.GetByteSizeString(speed, 2))})
-
-			}
-		}
-
-		body = reader
 	}
 
 	// Hashing
@@ -451,10 +530,8 @@ func (r *ProtocolLXD) CreateImage(image api.ImagesPost, args *ImageCreateArgs) (
 			body = &ioprogress.ProgressReader{
 				ReadCloser: tmpfile,
 				Tracker: &ioprogress.ProgressTracker{
-					Length: size,
-					Handler: func(percent int64, speed int64) {
-						args.ProgressHandler(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
-					},
+					Length:      size,
+					DataHandler: args.ProgressHandler,
 				},
 			}
 		} else {
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/websocket"
@@ -14,7 +16,6 @@ import (
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/cancel"
 	"github.com/lxc/lxd/shared/ioprogress"
-	"github.com/lxc/lxd/shared/units"
 )
 
 // Instance handling functions.
@@ -91,6 +92,32 @@ func (r *ProtocolLXD) GetInstances(instanceType api.InstanceType) ([]api.Instanc
 	return instances, nil
 }
 
+// GetInstancesPage returns a window of at most limit instances, starting at offset.
+func (r *ProtocolLXD) GetInstancesPage(instanceType api.InstanceType, offset int, limit int) ([]api.Instance, error) {
+	if !r.HasExtension("api_pagination") {
+		return nil, fmt.Errorf("The server is missing the required \"api_pagination\" API extension")
+	}
+
+	instances := []api.Instance{}
+
+	path, v, err := r.instanceTypeToPath(instanceType)
+	if err != nil {
+		return nil, err
+	}
+
+	v.Set("recursion", "1")
+	v.Set("offset", strconv.Itoa(offset))
+	v.Set("limit", strconv.Itoa(limit))
+
+	// Fetch the raw value
+	_, err = r.queryStruct("GET", fmt.Sprintf("%s?%s", path, v.Encode()), nil, "", &instances)
+	if err != nil {
+		return nil, err
+	}
+
+	return instances, nil
+}
+
 // GetInstancesFull returns a list of instances including snapshots, backups and state.
 func (r *ProtocolLXD) GetInstancesFull(instanceType api.InstanceType) ([]api.InstanceFull, error) {
 	instances := []api.InstanceFull{}
@@ -115,6 +142,69 @@ func (r *ProtocolLXD) GetInstancesFull(instanceType api.InstanceType) ([]api.Ins
 	return instances, nil
 }
 
+// GetInstancesWithFilter returns a list of instances matching the given filter expression.
+func (r *ProtocolLXD) GetInstancesWithFilter(instanceType api.InstanceType, filter string) ([]api.Instance, error) {
+	if !r.HasExtension("api_filtering") {
+		return nil, fmt.Errorf("The server is missing the required \"api_filtering\" API extension")
+	}
+
+	instances := []api.Instance{}
+
+	path, v, err := r.instanceTypeToPath(instanceType)
+	if err != nil {
+		return nil, err
+	}
+
+	v.Set("recursion", "1")
+
+	query, err := filterQueryParam(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch the raw value
+	_, err = r.queryStruct("GET", fmt.Sprintf("%s?%s&%s", path, v.Encode(), query), nil, "", &instances)
+	if err != nil {
+		return nil, err
+	}
+
+	return instances, nil
+}
+
+// GetInstancesFullWithFilter returns a list of instances matching the given filter expression, including
+// snapshots, backups and state.
+func (r *ProtocolLXD) GetInstancesFullWithFilter(instanceType api.InstanceType, filter string) ([]api.InstanceFull, error) {
+	if !r.HasExtension("container_full") {
+		return nil, fmt.Errorf("The server is missing the required \"container_full\" API extension")
+	}
+
+	if !r.HasExtension("api_filtering") {
+		return nil, fmt.Errorf("The server is missing the required \"api_filtering\" API extension")
+	}
+
+	instances := []api.InstanceFull{}
+
+	path, v, err := r.instanceTypeToPath(instanceType)
+	if err != nil {
+		return nil, err
+	}
+
+	v.Set("recursion", "2")
+
+	query, err := filterQueryParam(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch the raw value
+	_, err = r.queryStruct("GET", fmt.Sprintf("%s?%s&%s", path, v.Encode(), query), nil, "", &instances)
+	if err != nil {
+		return nil, err
+	}
+
+	return instances, nil
+}
+
 // GetInstance returns the instance entry for the provided name.
 func (r *ProtocolLXD) GetInstance(name string) (*api.Instance, string, error) {
 	instance := api.Instance{}
@@ -145,9 +235,20 @@ func (r *ProtocolLXD) CreateInstanceFromBackup(args InstanceBackupArgs) (Operati
 		return nil, err
 	}
 
+	// Report the upload progress
+	backupFile := args.BackupFile
+	if args.ProgressHandler != nil {
+		backupFile = &ioprogress.ProgressReader{
+			ReadCloser: ioutil.NopCloser(args.BackupFile),
+			Tracker: &ioprogress.ProgressTracker{
+				DataHandler: args.ProgressHandler,
+			},
+		}
+	}
+
 	if args.PoolName == "" {
 		// Send the request
-		op, _, err := r.queryOperation("POST", path, args.BackupFile, "")
+		op, _, err := r.queryOperation("POST", path, backupFile, "")
 		if err != nil {
 			return nil, err
 		}
@@ -165,7 +266,7 @@ func (r *ProtocolLXD) CreateInstanceFromBackup(args InstanceBackupArgs) (Operati
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", reqURL, args.BackupFile)
+	req, err := http.NewRequest("POST", reqURL, backupFile)
 	if err != nil {
 		return nil, err
 	}
@@ -220,6 +321,16 @@ func (r *ProtocolLXD) CreateInstance(instance api.InstancesPost) (Operation, err
 		}
 	}
 
+	if instance.Source.Type == "backup" {
+		if !r.HasExtension("backup_cluster") {
+			return nil, fmt.Errorf("The server is missing the required \"backup_cluster\" API extension")
+		}
+
+		if instance.Source.URL != "" && !r.HasExtension("backup_url_import") {
+			return nil, fmt.Errorf("The server is missing the required \"backup_url_import\" API extension")
+		}
+	}
+
 	// Send the request
 	op, _, err := r.queryOperation("POST", path, instance, "")
 	if err != nil {
@@ -538,22 +649,13 @@ func (r *ProtocolLXD) CopyInstance(source InstanceServer, instance api.Instance,
 		// Launch the relay
 		err = r.proxyMigration(targetOp.(*operation), targetSecrets, source, op.(*operation), sourceSecrets)
 		if err != nil {
+			// Neither server would otherwise notice that the relay never started.
+			targetOp.Cancel()
+			op.Cancel()
 			return nil, err
 		}
 
-		// Prepare a tracking operation
-		rop := remoteOperation{
-			targetOp: targetOp,
-			chDone:   make(chan bool),
-		}
-
-		// Forward targetOp to remote op
-		go func() {
-			rop.err = rop.targetOp.Wait()
-			close(rop.chDone)
-		}()
-
-		return &rop, nil
+		return relayRemoteOperation(targetOp, op), nil
 	}
 
 	// Pull mode migration
@@ -582,6 +684,130 @@ func (r *ProtocolLXD) UpdateInstance(name string, instance api.InstancePut, ETag
 	return op, nil
 }
 
+// RebuildInstance requests that LXD rebuilds the instance from an image.
+func (r *ProtocolLXD) RebuildInstance(name string, instance api.InstanceRebuildPost) (Operation, error) {
+	if !r.HasExtension("instance_rebuild") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_rebuild\" API extension")
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("%s/%s/rebuild", path, url.PathEscape(name)), instance, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// RebuildInstanceFromImage is a convenience function to make it easier to rebuild an instance from an existing image.
+func (r *ProtocolLXD) RebuildInstanceFromImage(source ImageServer, image api.Image, name string, req api.InstanceRebuildPost) (RemoteOperation, error) {
+	// Set the minimal source fields
+	req.Source.Type = "image"
+
+	rop := remoteOperation{
+		chDone: make(chan bool),
+	}
+
+	// Optimization for the local image case
+	if r == source {
+		// Always use fingerprints for local case
+		req.Source.Fingerprint = image.Fingerprint
+		req.Source.Alias = ""
+
+		op, err := r.RebuildInstance(name, req)
+		if err != nil {
+			return nil, err
+		}
+
+		rop.targetOp = op
+
+		// Forward targetOp to remote op
+		go func() {
+			rop.err = rop.targetOp.Wait()
+			close(rop.chDone)
+		}()
+
+		return &rop, nil
+	}
+
+	// Minimal source fields for remote image
+	req.Source.Mode = "pull"
+
+	// If we have an alias and the image is public, use that
+	if req.Source.Alias != "" && image.Public {
+		req.Source.Fingerprint = ""
+	} else {
+		req.Source.Fingerprint = image.Fingerprint
+		req.Source.Alias = ""
+	}
+
+	// Get source server connection information
+	info, err := source.GetConnectionInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(info.Addresses) == 0 {
+		return nil, fmt.Errorf("The source server isn't listening on the network")
+	}
+
+	req.Source.Protocol = info.Protocol
+	req.Source.Certificate = info.Certificate
+
+	// Generate secret token if needed
+	if !image.Public {
+		secret, err := source.GetImageSecret(image.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Source.Secret = secret
+	}
+
+	// Forward targetOp to remote op
+	go func() {
+		success := false
+		errors := map[string]error{}
+		for _, serverURL := range info.Addresses {
+			req.Source.Server = serverURL
+
+			op, err := r.RebuildInstance(name, req)
+			if err != nil {
+				errors[serverURL] = err
+				continue
+			}
+
+			rop.targetOp = op
+
+			for _, handler := range rop.handlers {
+				rop.targetOp.AddHandler(handler)
+			}
+
+			err = rop.targetOp.Wait()
+			if err != nil {
+				errors[serverURL] = err
+				continue
+			}
+
+			success = true
+			break
+		}
+
+		if !success {
+			rop.err = remoteOperationError("Failed instance rebuild", errors)
+		}
+
+		close(rop.chDone)
+	}()
+
+	return &rop, nil
+}
+
 // RenameInstance requests that LXD renames the instance.
 func (r *ProtocolLXD) RenameInstance(name string, instance api.InstancePost) (Operation, error) {
 	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
@@ -1324,22 +1550,13 @@ func (r *ProtocolLXD) CopyInstanceSnapshot(source InstanceServer, instanceName s
 		// Launch the relay
 		err = r.proxyMigration(targetOp.(*operation), targetSecrets, source, op.(*operation), sourceSecrets)
 		if err != nil {
+			// Neither server would otherwise notice that the relay never started.
+			targetOp.Cancel()
+			op.Cancel()
 			return nil, err
 		}
 
-		// Prepare a tracking operation
-		rop := remoteOperation{
-			targetOp: targetOp,
-			chDone:   make(chan bool),
-		}
-
-		// Forward targetOp to remote op
-		go func() {
-			rop.err = rop.targetOp.Wait()
-			close(rop.chDone)
-		}()
-
-		return &rop, nil
+		return relayRemoteOperation(targetOp, op), nil
 	}
 
 	// Pull mode migration
@@ -1522,6 +1739,26 @@ func (r *ProtocolLXD) UpdateInstanceState(name string, state api.InstanceStatePu
 	return op, nil
 }
 
+// UpdateInstances updates the state of several instances at once, using a single operation.
+func (r *ProtocolLXD) UpdateInstances(state api.InstancesPut, ETag string) (Operation, error) {
+	if !r.HasExtension("instances_bulk_state") {
+		return nil, fmt.Errorf("The server is missing the required \"instances_bulk_state\" API extension")
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("PUT", path, state, ETag)
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
 // GetInstanceLogfiles returns a list of logfiles for the instance.
 func (r *ProtocolLXD) GetInstanceLogfiles(name string) ([]string, error) {
 	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
@@ -2019,6 +2256,23 @@ func (r *ProtocolLXD) DeleteInstanceConsoleLog(instanceName string, args *Instan
 	return nil
 }
 
+// GetBackups returns a list of the backups of all instances in the project, across all cluster members.
+func (r *ProtocolLXD) GetBackups() ([]api.InstanceBackup, error) {
+	if !r.HasExtension("backup_cluster") {
+		return nil, fmt.Errorf("The server is missing the required \"backup_cluster\" API extension")
+	}
+
+	// Fetch the raw value
+	backups := []api.InstanceBackup{}
+
+	_, err := r.queryStruct("GET", "/backups?recursion=1", nil, "", &backups)
+	if err != nil {
+		return nil, err
+	}
+
+	return backups, nil
+}
+
 // GetInstanceBackupNames returns a list of backup names for the instance.
 func (r *ProtocolLXD) GetInstanceBackupNames(instanceName string) ([]string, error) {
 	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
@@ -2101,6 +2355,26 @@ func (r *ProtocolLXD) CreateInstanceBackup(instanceName string, backup api.Insta
 		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
 	}
 
+	if backup.Target != nil && !r.HasExtension("backup_s3_upload") {
+		return nil, fmt.Errorf("The server is missing the required \"backup_s3_upload\" API extension")
+	}
+
+	if backup.IncrementalFrom != "" && !r.HasExtension("backup_incremental") {
+		return nil, fmt.Errorf("The server is missing the required \"backup_incremental\" API extension")
+	}
+
+	if backup.Encryption != nil && !r.HasExtension("backup_encryption") {
+		return nil, fmt.Errorf("The server is missing the required \"backup_encryption\" API extension")
+	}
+
+	if (len(backup.Snapshots) > 0 || backup.SnapshotsMaxAge != "") && !r.HasExtension("backup_snapshot_selection") {
+		return nil, fmt.Errorf("The server is missing the required \"backup_snapshot_selection\" API extension")
+	}
+
+	if backup.Format != "" && !r.HasExtension("backup_vm_export") {
+		return nil, fmt.Errorf("The server is missing the required \"backup_vm_export\" API extension")
+	}
+
 	// Send the request
 	op, _, err := r.queryOperation("POST", fmt.Sprintf("%s/%s/backups", path, url.PathEscape(instanceName)), backup, "")
 	if err != nil {
@@ -2150,6 +2424,26 @@ func (r *ProtocolLXD) DeleteInstanceBackup(instanceName string, name string) (Op
 	return op, nil
 }
 
+// VerifyInstanceBackup checks the integrity of a stored instance backup without restoring it.
+func (r *ProtocolLXD) VerifyInstanceBackup(instanceName string, name string) (Operation, error) {
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.HasExtension("backup_verify") {
+		return nil, fmt.Errorf("The server is missing the required \"backup_verify\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("%s/%s/backups/%s/verify", path, url.PathEscape(instanceName), url.PathEscape(name)), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
 // GetInstanceBackupFile requests the instance backup content.
 func (r *ProtocolLXD) GetInstanceBackupFile(instanceName string, name string, req *BackupFileRequest) (*BackupFileResponse, error) {
 	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
@@ -2198,10 +2492,8 @@ func (r *ProtocolLXD) GetInstanceBackupFile(instanceName string, name string, re
 		body = &ioprogress.ProgressReader{
 			ReadCloser: response.Body,
 			Tracker: &ioprogress.ProgressTracker{
-				Length: response.ContentLength,
-				Handler: func(percent int64, speed int64) {
-					req.ProgressHandler(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
-				},
+				Length:      response.ContentLength,
+				DataHandler: req.ProgressHandler,
 			},
 		}
 	}
@@ -2301,3 +2593,31 @@ func (r *ProtocolLXD) proxyMigration(targetOp *operation, targetSecrets map[stri
 
 	return nil
 }
+
+// relayRemoteOperation returns a remote operation tracking a migration relayed by the client.
+// As the source and target servers can't see each other, the source operation gets cancelled when the target
+// fails so that it doesn't keep waiting on the relay, and the error of the source is reported when it's the one
+// which failed.
+func relayRemoteOperation(targetOp Operation, sourceOp Operation) *remoteOperation {
+	rop := remoteOperation{
+		targetOp: targetOp,
+		chDone:   make(chan bool),
+	}
+
+	// Forward targetOp to remote op
+	go func() {
+		rop.err = rop.targetOp.Wait()
+		if rop.err != nil && sourceOp.Refresh() == nil {
+			source := sourceOp.Get()
+			if source.StatusCode == api.Failure && source.Err != "" {
+				rop.err = fmt.Errorf("Migration source failed: %s (target: %v)", source.Err, rop.err)
+			} else if !source.StatusCode.IsFinal() {
+				sourceOp.Cancel()
+			}
+		}
+
+		close(rop.chDone)
+	}()
+
+	return &rop
+}
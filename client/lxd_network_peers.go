@@ -0,0 +1,112 @@
+package lxd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkPeerNames returns a list of network peering names
+func (r *ProtocolLXD) GetNetworkPeerNames(networkName string) ([]string, error) {
+	if !r.HasExtension("network_peer") {
+		return nil, fmt.Errorf("The server is missing the required \"network_peer\" API extension")
+	}
+
+	urls := []string{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/peers", url.PathEscape(networkName)), nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it
+	names := []string{}
+	for _, url := range urls {
+		fields := strings.Split(url, "/peers/")
+		names = append(names, fields[len(fields)-1])
+	}
+
+	return names, nil
+}
+
+// GetNetworkPeers returns a list of network peering structs
+func (r *ProtocolLXD) GetNetworkPeers(networkName string) ([]api.NetworkPeer, error) {
+	if !r.HasExtension("network_peer") {
+		return nil, fmt.Errorf("The server is missing the required \"network_peer\" API extension")
+	}
+
+	peers := []api.NetworkPeer{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/peers?recursion=1", url.PathEscape(networkName)), nil, "", &peers)
+	if err != nil {
+		return nil, err
+	}
+
+	return peers, nil
+}
+
+// GetNetworkPeer returns a network peering entry for the provided network and peering name
+func (r *ProtocolLXD) GetNetworkPeer(networkName string, peerName string) (*api.NetworkPeer, string, error) {
+	if !r.HasExtension("network_peer") {
+		return nil, "", fmt.Errorf("The server is missing the required \"network_peer\" API extension")
+	}
+
+	peer := api.NetworkPeer{}
+
+	// Fetch the raw value
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/peers/%s", url.PathEscape(networkName), url.PathEscape(peerName)), nil, "", &peer)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &peer, etag, nil
+}
+
+// CreateNetworkPeer defines a new network peering using the provided struct
+func (r *ProtocolLXD) CreateNetworkPeer(networkName string, peer api.NetworkPeersPost) error {
+	if !r.HasExtension("network_peer") {
+		return fmt.Errorf("The server is missing the required \"network_peer\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("POST", fmt.Sprintf("/networks/%s/peers", url.PathEscape(networkName)), peer, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateNetworkPeer updates the network peering to match the provided struct
+func (r *ProtocolLXD) UpdateNetworkPeer(networkName string, peerName string, peer api.NetworkPeerPut, ETag string) error {
+	if !r.HasExtension("network_peer") {
+		return fmt.Errorf("The server is missing the required \"network_peer\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("PUT", fmt.Sprintf("/networks/%s/peers/%s", url.PathEscape(networkName), url.PathEscape(peerName)), peer, ETag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteNetworkPeer deletes an existing network peering
+func (r *ProtocolLXD) DeleteNetworkPeer(networkName string, peerName string) error {
+	if !r.HasExtension("network_peer") {
+		return fmt.Errorf("The server is missing the required \"network_peer\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("DELETE", fmt.Sprintf("/networks/%s/peers/%s", url.PathEscape(networkName), url.PathEscape(peerName)), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/lxc/lxd/shared/api"
 )
@@ -49,6 +50,63 @@ func (r *ProtocolLXD) GetNetworks() ([]api.Network, error) {
 	return networks, nil
 }
 
+// GetNetworksWithFilter returns a list of Network struct matching the given filter expression
+func (r *ProtocolLXD) GetNetworksWithFilter(filter string) ([]api.Network, error) {
+	if !r.HasExtension("api_filtering_extended") {
+		return nil, fmt.Errorf("The server is missing the required \"api_filtering_extended\" API extension")
+	}
+
+	query, err := filterQueryParam(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	networks := []api.Network{}
+
+	// Fetch the raw value
+	_, err = r.queryStruct("GET", fmt.Sprintf("/networks?recursion=1&%s", query), nil, "", &networks)
+	if err != nil {
+		return nil, err
+	}
+
+	return networks, nil
+}
+
+// GetNetworksFull returns a list of Network struct along with their state on the cluster members and a summary
+// of their leases
+func (r *ProtocolLXD) GetNetworksFull() ([]api.NetworkFull, error) {
+	if !r.HasExtension("network_full") {
+		return nil, fmt.Errorf("The server is missing the required \"network_full\" API extension")
+	}
+
+	networks := []api.NetworkFull{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", "/networks?recursion=2", nil, "", &networks)
+	if err != nil {
+		return nil, err
+	}
+
+	return networks, nil
+}
+
+// GetNetworksPage returns a window of at most limit Network structs, starting at offset
+func (r *ProtocolLXD) GetNetworksPage(offset int, limit int) ([]api.Network, error) {
+	if !r.HasExtension("api_pagination") {
+		return nil, fmt.Errorf("The server is missing the required \"api_pagination\" API extension")
+	}
+
+	networks := []api.Network{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/networks?recursion=1&offset=%d&limit=%d", offset, limit), nil, "", &networks)
+	if err != nil {
+		return nil, err
+	}
+
+	return networks, nil
+}
+
 // GetNetwork returns a Network entry for the provided name
 func (r *ProtocolLXD) GetNetwork(name string) (*api.Network, string, error) {
 	if !r.HasExtension("network") {
@@ -83,6 +141,23 @@ func (r *ProtocolLXD) GetNetworkLeases(name string) ([]api.NetworkLease, error)
 	return leases, nil
 }
 
+// GetNetworkMetrics returns usage metrics for the network
+func (r *ProtocolLXD) GetNetworkMetrics(name string) (*api.NetworkMetrics, error) {
+	if !r.HasExtension("network_metrics") {
+		return nil, fmt.Errorf("The server is missing the required \"network_metrics\" API extension")
+	}
+
+	metrics := api.NetworkMetrics{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/metrics", url.PathEscape(name)), nil, "", &metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	return &metrics, nil
+}
+
 // GetNetworkState returns metrics and information on the running network
 func (r *ProtocolLXD) GetNetworkState(name string) (*api.NetworkState, error) {
 	if !r.HasExtension("network_state") {
@@ -100,6 +175,24 @@ func (r *ProtocolLXD) GetNetworkState(name string) (*api.NetworkState, error) {
 	return &state, nil
 }
 
+// GetNetworkStateHistory returns metrics and information on the running network, along with the counter samples
+// taken during the given period.
+func (r *ProtocolLXD) GetNetworkStateHistory(name string, period time.Duration) (*api.NetworkState, error) {
+	if !r.HasExtension("network_state_history") {
+		return nil, fmt.Errorf("The server is missing the required \"network_state_history\" API extension")
+	}
+
+	state := api.NetworkState{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/state?period=%s", url.PathEscape(name), url.QueryEscape(period.String())), nil, "", &state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
 // CreateNetwork defines a new network using the provided Network struct
 func (r *ProtocolLXD) CreateNetwork(network api.NetworksPost) error {
 	if !r.HasExtension("network") {
@@ -0,0 +1,34 @@
+package lxd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Search handling functions
+
+// Search returns the entities matching the query across all projects, optionally restricted to some entity types
+func (r *ProtocolLXD) Search(query string, types []string) ([]api.SearchResult, error) {
+	if !r.HasExtension("search") {
+		return nil, fmt.Errorf("The server is missing the required \"search\" API extension")
+	}
+
+	values := url.Values{}
+	values.Set("q", query)
+	if len(types) > 0 {
+		values.Set("type", strings.Join(types, ","))
+	}
+
+	results := []api.SearchResult{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/search?%s", values.Encode()), nil, "", &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
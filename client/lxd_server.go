@@ -1,6 +1,7 @@
 package lxd
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/lxc/lxd/shared"
@@ -92,6 +93,7 @@ func (r *ProtocolLXD) GetServerResources() (*api.Resources, error) {
 // UseProject returns a client that will use a specific project.
 func (r *ProtocolLXD) UseProject(name string) InstanceServer {
 	return &ProtocolLXD{
+		ctx:                  r.ctx,
 		server:               r.server,
 		http:                 r.http,
 		httpCertificate:      r.httpCertificate,
@@ -100,17 +102,43 @@ func (r *ProtocolLXD) UseProject(name string) InstanceServer {
 		httpUserAgent:        r.httpUserAgent,
 		bakeryClient:         r.bakeryClient,
 		bakeryInteractor:     r.bakeryInteractor,
+		oidcClient:           r.oidcClient,
 		requireAuthenticated: r.requireAuthenticated,
+		retryCount:           r.retryCount,
+		retryBackoff:         r.retryBackoff,
 		clusterTarget:        r.clusterTarget,
 		project:              name,
 	}
 }
 
+// WithContext returns a client that will use the given context for its requests, websockets and
+// operation waits, allowing callers to apply timeouts and cancellation.
+func (r *ProtocolLXD) WithContext(ctx context.Context) InstanceServer {
+	return &ProtocolLXD{
+		ctx:                  ctx,
+		server:               r.server,
+		http:                 r.http,
+		httpCertificate:      r.httpCertificate,
+		httpHost:             r.httpHost,
+		httpProtocol:         r.httpProtocol,
+		httpUserAgent:        r.httpUserAgent,
+		bakeryClient:         r.bakeryClient,
+		bakeryInteractor:     r.bakeryInteractor,
+		oidcClient:           r.oidcClient,
+		requireAuthenticated: r.requireAuthenticated,
+		retryCount:           r.retryCount,
+		retryBackoff:         r.retryBackoff,
+		project:              r.project,
+		clusterTarget:        r.clusterTarget,
+	}
+}
+
 // UseTarget returns a client that will target a specific cluster member.
 // Use this member-specific operations such as specific container
 // placement, preparing a new storage pool or network, ...
 func (r *ProtocolLXD) UseTarget(name string) InstanceServer {
 	return &ProtocolLXD{
+		ctx:                  r.ctx,
 		server:               r.server,
 		http:                 r.http,
 		httpCertificate:      r.httpCertificate,
@@ -119,7 +147,10 @@ func (r *ProtocolLXD) UseTarget(name string) InstanceServer {
 		httpUserAgent:        r.httpUserAgent,
 		bakeryClient:         r.bakeryClient,
 		bakeryInteractor:     r.bakeryInteractor,
+		oidcClient:           r.oidcClient,
 		requireAuthenticated: r.requireAuthenticated,
+		retryCount:           r.retryCount,
+		retryBackoff:         r.retryBackoff,
 		project:              r.project,
 		clusterTarget:        name,
 	}
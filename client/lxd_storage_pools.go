@@ -51,6 +51,23 @@ func (r *ProtocolLXD) GetStoragePools() ([]api.StoragePool, error) {
 	return pools, nil
 }
 
+// GetStoragePoolsFull returns a list of StoragePool entries along with their resources on the cluster members
+func (r *ProtocolLXD) GetStoragePoolsFull() ([]api.StoragePoolFull, error) {
+	if !r.HasExtension("storage_pool_full") {
+		return nil, fmt.Errorf("The server is missing the required \"storage_pool_full\" API extension")
+	}
+
+	pools := []api.StoragePoolFull{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", "/storage-pools?recursion=2", nil, "", &pools)
+	if err != nil {
+		return nil, err
+	}
+
+	return pools, nil
+}
+
 // GetStoragePool returns a StoragePool entry for the provided pool name
 func (r *ProtocolLXD) GetStoragePool(name string) (*api.StoragePool, string, error) {
 	if !r.HasExtension("storage") {
@@ -0,0 +1,270 @@
+package lxd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OIDCTokens represents the tokens obtained from an OpenID Connect provider.
+type OIDCTokens struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// oidcClient performs requests with an OpenID Connect bearer token. When the server asks for authentication,
+// the token is refreshed or a new one is obtained through the device authorization flow.
+type oidcClient struct {
+	http   *http.Client
+	tokens *OIDCTokens
+}
+
+func newOIDCClient(httpClient *http.Client, tokens *OIDCTokens) *oidcClient {
+	if tokens == nil {
+		tokens = &OIDCTokens{}
+	}
+
+	return &oidcClient{
+		http:   httpClient,
+		tokens: tokens,
+	}
+}
+
+// setHeaders adds the bearer token to the request and tells the server that OIDC is used.
+func (o *oidcClient) setHeaders(header http.Header) {
+	header.Set("X-LXD-oidc", "true")
+
+	if o.tokens.AccessToken != "" {
+		header.Set("Authorization", fmt.Sprintf("Bearer %s", o.tokens.AccessToken))
+	}
+}
+
+func (o *oidcClient) do(req *http.Request) (*http.Response, error) {
+	o.setHeaders(req.Header)
+
+	resp, err := o.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	issuer := resp.Header.Get("X-LXD-OIDC-issuer")
+	clientID := resp.Header.Get("X-LXD-OIDC-clientid")
+	audience := resp.Header.Get("X-LXD-OIDC-audience")
+	if issuer == "" || clientID == "" {
+		return resp, nil
+	}
+
+	// The request can only be sent again if its body can be replayed.
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	resp.Body.Close()
+
+	err = o.authenticate(issuer, clientID, audience)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetBody != nil {
+		req.Body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	o.setHeaders(req.Header)
+
+	return o.http.Do(req)
+}
+
+// oidcProvider holds the endpoints of an OpenID Connect provider, from its discovery document.
+type oidcProvider struct {
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+}
+
+// oidcTokenResponse is the response of the token endpoint.
+type oidcTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	Error        string `json:"error"`
+}
+
+// authenticate gets a new token from the provider, refreshing the current one if possible.
+func (o *oidcClient) authenticate(issuer string, clientID string, audience string) error {
+	provider := oidcProvider{}
+	err := oidcGetJSON(fmt.Sprintf("%s/.well-known/openid-configuration", strings.TrimSuffix(issuer, "/")), &provider)
+	if err != nil {
+		return fmt.Errorf("Failed to get the OpenID Connect discovery document: %v", err)
+	}
+
+	if o.tokens.RefreshToken != "" {
+		resp, err := oidcPostForm(provider.TokenEndpoint, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {o.tokens.RefreshToken},
+			"client_id":     {clientID},
+		})
+		if err == nil && resp.Error == "" {
+			o.setTokens(resp, audience)
+			return nil
+		}
+	}
+
+	return o.deviceFlow(provider, clientID, audience)
+}
+
+// deviceFlow runs the OAuth 2.0 device authorization flow, asking the user to log in with a browser.
+func (o *oidcClient) deviceFlow(provider oidcProvider, clientID string, audience string) error {
+	if provider.DeviceAuthorizationEndpoint == "" {
+		return fmt.Errorf("The OpenID Connect provider doesn't support the device authorization flow")
+	}
+
+	values := url.Values{
+		"client_id": {clientID},
+		"scope":     {"openid email offline_access"},
+	}
+
+	if audience != "" {
+		values.Set("audience", audience)
+	}
+
+	resp, err := http.PostForm(provider.DeviceAuthorizationEndpoint, values)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	device := struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int64  `json:"expires_in"`
+		Interval                int64  `json:"interval"`
+	}{}
+
+	err = json.NewDecoder(resp.Body).Decode(&device)
+	if err != nil {
+		return err
+	}
+
+	if device.DeviceCode == "" {
+		return fmt.Errorf("Failed to start the device authorization flow")
+	}
+
+	verificationURI := device.VerificationURIComplete
+	if verificationURI == "" {
+		verificationURI = device.VerificationURI
+	}
+
+	fmt.Printf("URL: %s\n", verificationURI)
+	fmt.Printf("Code: %s\n\n", device.UserCode)
+
+	interval := time.Duration(device.Interval) * time.Second
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+
+	deadline := time.Now().Add(time.Duration(device.ExpiresIn) * time.Second)
+	for device.ExpiresIn == 0 || time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		tokens, err := oidcPostForm(provider.TokenEndpoint, url.Values{
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+			"device_code": {device.DeviceCode},
+			"client_id":   {clientID},
+		})
+		if err != nil {
+			return err
+		}
+
+		switch tokens.Error {
+		case "":
+			o.setTokens(tokens, audience)
+			return nil
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += 5 * time.Second
+			continue
+		default:
+			return fmt.Errorf("Failed OpenID Connect authentication: %s", tokens.Error)
+		}
+	}
+
+	return fmt.Errorf("The device authorization request has expired")
+}
+
+// setTokens stores the tokens of a token endpoint response. Without an audience, the server expects the ID
+// token, which is intended for the client ID.
+func (o *oidcClient) setTokens(resp *oidcTokenResponse, audience string) {
+	o.tokens.AccessToken = resp.AccessToken
+	if audience == "" && resp.IDToken != "" {
+		o.tokens.AccessToken = resp.IDToken
+	}
+
+	if resp.RefreshToken != "" {
+		o.tokens.RefreshToken = resp.RefreshToken
+	}
+
+	o.tokens.Expiry = time.Time{}
+	if resp.ExpiresIn > 0 {
+		o.tokens.Expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+}
+
+func oidcGetJSON(uri string, target interface{}) error {
+	resp, err := http.Get(uri)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected status code %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+// oidcPostForm sends a request to the token endpoint. Errors returned by the endpoint are reported in the
+// response rather than as an error.
+func oidcPostForm(uri string, values url.Values) (*oidcTokenResponse, error) {
+	resp, err := http.PostForm(uri, values)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := oidcTokenResponse{}
+	err = json.Unmarshal(data, &tokens)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid token endpoint response (status code %d)", resp.StatusCode)
+	}
+
+	if tokens.Error == "" && resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status code %d", resp.StatusCode)
+	}
+
+	return &tokens, nil
+}
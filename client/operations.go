@@ -1,13 +1,17 @@
 package lxd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/gorilla/websocket"
 
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/ioprogress"
 )
 
 // The Operation type represents an ongoing LXD operation (asynchronous processing)
@@ -53,6 +57,16 @@ func (op *operation) AddHandler(function func(api.Operation)) (*EventTarget, err
 	return op.listener.AddHandler([]string{"operation"}, wrapped)
 }
 
+// AddProgressHandler adds a function to be called whenever progress is reported by the operation
+func (op *operation) AddProgressHandler(function func(ioprogress.ProgressData)) (*EventTarget, error) {
+	return op.AddHandler(func(newOp api.Operation) {
+		progress := operationProgress(newOp)
+		if progress != nil {
+			function(*progress)
+		}
+	})
+}
+
 // Cancel will request that LXD cancels the operation (if supported)
 func (op *operation) Cancel() error {
 	return op.r.DeleteOperation(op.ID)
@@ -98,6 +112,12 @@ func (op *operation) Refresh() error {
 
 // Wait lets you wait until the operation reaches a final state
 func (op *operation) Wait() error {
+	return op.WaitContext(context.Background())
+}
+
+// WaitContext lets you wait until the operation reaches a final state with context.Context.
+// The operation keeps running on the server if the context is done first.
+func (op *operation) WaitContext(ctx context.Context) error {
 	// Check if not done already
 	if op.StatusCode.IsFinal() {
 		if op.Err != "" {
@@ -113,7 +133,11 @@ func (op *operation) Wait() error {
 		return err
 	}
 
-	<-op.chActive
+	select {
+	case <-op.chActive:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 
 	// We're done, parse the result
 	if op.Err != "" {
@@ -281,6 +305,16 @@ func (op *remoteOperation) AddHandler(function func(api.Operation)) (*EventTarge
 	return target, nil
 }
 
+// AddProgressHandler adds a function to be called whenever progress is reported by the operation
+func (op *remoteOperation) AddProgressHandler(function func(ioprogress.ProgressData)) (*EventTarget, error) {
+	return op.AddHandler(func(newOp api.Operation) {
+		progress := operationProgress(newOp)
+		if progress != nil {
+			function(*progress)
+		}
+	})
+}
+
 // CancelTarget attempts to cancel the target operation
 func (op *remoteOperation) CancelTarget() error {
 	if op.targetOp == nil {
@@ -302,11 +336,69 @@ func (op *remoteOperation) GetTarget() (*api.Operation, error) {
 
 // Wait lets you wait until the operation reaches a final state
 func (op *remoteOperation) Wait() error {
-	<-op.chDone
+	return op.WaitContext(context.Background())
+}
+
+// WaitContext lets you wait until the operation reaches a final state with context.Context.
+// The operation keeps running on the servers if the context is done first.
+func (op *remoteOperation) WaitContext(ctx context.Context) error {
+	select {
+	case <-op.chDone:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 
 	if op.chPost != nil {
-		<-op.chPost
+		select {
+		case <-op.chPost:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 
 	return op.err
 }
+
+// operationProgress extracts the progress information from the metadata of an operation.
+// It returns nil if the operation doesn't report any progress.
+func operationProgress(op api.Operation) *ioprogress.ProgressData {
+	if op.Metadata == nil {
+		return nil
+	}
+
+	// Structured progress information.
+	values, ok := op.Metadata["progress"].(map[string]interface{})
+	if ok {
+		progress := ioprogress.ProgressData{}
+		progress.Stage, _ = values["stage"].(string)
+
+		parseInt := func(key string) int64 {
+			value, _ := values[key].(string)
+			result, _ := strconv.ParseInt(value, 10, 64)
+			return result
+		}
+
+		progress.Percentage = int(parseInt("percent"))
+		progress.TransferredBytes = parseInt("processed")
+		progress.TotalBytes = parseInt("total")
+		progress.BytesPerSecond = parseInt("speed")
+		progress.Text, _ = op.Metadata[fmt.Sprintf("%s_progress", progress.Stage)].(string)
+
+		return &progress
+	}
+
+	// Servers without structured progress only provide a description of the current stage.
+	for key, value := range op.Metadata {
+		text, ok := value.(string)
+		if !ok || !strings.HasSuffix(key, "_progress") {
+			continue
+		}
+
+		return &ioprogress.ProgressData{
+			Stage: strings.TrimSuffix(key, "_progress"),
+			Text:  text,
+		}
+	}
+
+	return nil
+}
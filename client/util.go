@@ -8,8 +8,10 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/filter"
 )
 
 func tlsHTTPClient(client *http.Client, tlsClientCert string, tlsClientKey string, tlsCA string, tlsServerCert string, insecureSkipVerify bool, proxy func(req *http.Request) (*url.URL, error)) (*http.Client, error) {
@@ -136,6 +138,18 @@ func unixHTTPClient(client *http.Client, path string) (*http.Client, error) {
 	return client, nil
 }
 
+// enableKeepAlive lets the client reuse its connections between requests.
+func enableKeepAlive(client *http.Client) {
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		return
+	}
+
+	transport.DisableKeepAlives = false
+	transport.MaxIdleConnsPerHost = 4
+	transport.IdleConnTimeout = 90 * time.Second
+}
+
 func remoteOperationError(msg string, errors map[string]error) error {
 	// Check if empty
 	if len(errors) == 0 {
@@ -179,3 +193,14 @@ func setQueryParam(uri, param, value string) (string, error) {
 
 	return fields.String(), nil
 }
+
+// Validate the given filter expression with the parser used by the server and return the matching "filter"
+// query parameter.
+func filterQueryParam(expr string) (string, error) {
+	_, err := filter.Parse(expr)
+	if err != nil {
+		return "", fmt.Errorf("Invalid filter: %v", err)
+	}
+
+	return url.Values{"filter": []string{expr}}.Encode(), nil
+}
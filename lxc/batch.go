@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type cmdBatch struct {
+	global *cmdGlobal
+
+	flagStopOnError bool
+}
+
+func (c *cmdBatch) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("batch [<file>]")
+	cmd.Short = i18n.G("Run a sequence of commands")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Run a sequence of commands
+
+Commands are read from the file, or from stdin if no file (or "-") is given,
+one per line and without the leading "lxc". Empty lines and lines starting with
+"#" are ignored. Arguments can be quoted with single or double quotes.
+
+All the commands are run, unless --stop-on-error is passed, and the failures
+are listed at the end.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc batch commands.txt
+    Run the commands listed in commands.txt.
+
+printf "launch images:alpine/edge c1\nexec c1 -- apk update\n" | lxc batch --stop-on-error
+    Create an instance and update it, stopping at the first failure.`))
+
+	cmd.RunE = c.Run
+	cmd.Flags().BoolVar(&c.flagStopOnError, "stop-on-error", false, i18n.G("Stop at the first failed command"))
+
+	return cmd
+}
+
+func (c *cmdBatch) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 0, 1)
+	if exit {
+		return err
+	}
+
+	// Open the input
+	var input io.Reader = os.Stdin
+	fromStdin := true
+	if len(args) > 0 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		input = f
+		fromStdin = false
+	}
+
+	// Commands are run by new instances of the client
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	failures := []string{}
+	count := 0
+	scanner := bufio.NewScanner(input)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		count++
+		cmdArgs, err := batchSplitLine(line)
+		if err == nil {
+			err = c.runCommand(executable, cmdArgs, fromStdin)
+		}
+
+		if err != nil {
+			failures = append(failures, fmt.Sprintf(i18n.G("Line %d (%s): %v"), lineNumber, line, err))
+			if c.flagStopOnError {
+				break
+			}
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return err
+	}
+
+	if len(failures) > 0 {
+		fmt.Fprintf(os.Stderr, i18n.G("Failed commands:")+"\n")
+		for _, failure := range failures {
+			fmt.Fprintf(os.Stderr, "  %s\n", failure)
+		}
+
+		return fmt.Errorf(i18n.G("%d of %d commands failed"), len(failures), count)
+	}
+
+	return nil
+}
+
+// runCommand runs a single batch command, passing along the global flags of the batch.
+func (c *cmdBatch) runCommand(executable string, args []string, fromStdin bool) error {
+	if len(args) > 0 && args[0] == "batch" {
+		return fmt.Errorf(i18n.G("Batches can't be nested"))
+	}
+
+	globalArgs := []string{}
+	if c.global.flagForceLocal {
+		globalArgs = append(globalArgs, "--force-local")
+	}
+
+	if c.global.flagProject != "" {
+		globalArgs = append(globalArgs, "--project", c.global.flagProject)
+	}
+
+	if c.global.flagQuiet {
+		globalArgs = append(globalArgs, "--quiet")
+	}
+
+	if c.global.flagLogDebug {
+		globalArgs = append(globalArgs, "--debug")
+	}
+
+	if c.global.flagLogVerbose {
+		globalArgs = append(globalArgs, "--verbose")
+	}
+
+	cmd := exec.Command(executable, append(globalArgs, args...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// The commands themselves can't read stdin when it holds the batch.
+	if !fromStdin {
+		cmd.Stdin = os.Stdin
+	}
+
+	return cmd.Run()
+}
+
+// batchSplitLine splits a batch line into arguments, honoring single and double quotes as well
+// as backslash escapes.
+func batchSplitLine(line string) ([]string, error) {
+	args := []string{}
+	current := strings.Builder{}
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if escaped || quote != 0 {
+		return nil, fmt.Errorf(i18n.G("Unterminated quote or escape"))
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
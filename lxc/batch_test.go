@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchSplitLine(t *testing.T) {
+	tests := map[string][]string{
+		"list":                                    {"list"},
+		"  launch   images:alpine/edge c1 ":       {"launch", "images:alpine/edge", "c1"},
+		`config set c1 user.note "hello world"`:   {"config", "set", "c1", "user.note", "hello world"},
+		`exec c1 -- sh -c 'echo "$HOME"'`:         {"exec", "c1", "--", "sh", "-c", `echo "$HOME"`},
+		`config set c1 user.note hello\ world ""`: {"config", "set", "c1", "user.note", "hello world", ""},
+	}
+
+	for line, expected := range tests {
+		args, err := batchSplitLine(line)
+		assert.NoError(t, err, line)
+		assert.Equal(t, expected, args, line)
+	}
+
+	for _, line := range []string{`exec c1 -- echo "foo`, `list \`} {
+		_, err := batchSplitLine(line)
+		assert.Error(t, err, line)
+	}
+}
@@ -31,6 +31,10 @@ func (c *cmdCluster) Command() *cobra.Command {
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`Manage cluster members`))
 
+	// Add
+	clusterAddCmd := cmdClusterAdd{global: c.global, cluster: c}
+	cmd.AddCommand(clusterAddCmd.Command())
+
 	// List
 	clusterListCmd := cmdClusterList{global: c.global, cluster: c}
 	cmd.AddCommand(clusterListCmd.Command())
@@ -43,6 +47,10 @@ func (c *cmdCluster) Command() *cobra.Command {
 	clusterRemoveCmd := cmdClusterRemove{global: c.global, cluster: c}
 	cmd.AddCommand(clusterRemoveCmd.Command())
 
+	// Role
+	clusterRoleCmd := cmdClusterRole{global: c.global, cluster: c}
+	cmd.AddCommand(clusterRoleCmd.Command())
+
 	// Show
 	clusterShowCmd := cmdClusterShow{global: c.global, cluster: c}
 	cmd.AddCommand(clusterShowCmd.Command())
@@ -55,6 +63,18 @@ func (c *cmdCluster) Command() *cobra.Command {
 	clusterEditCmd := cmdClusterEdit{global: c.global, cluster: c}
 	cmd.AddCommand(clusterEditCmd.Command())
 
+	// Group
+	clusterGroupCmd := cmdClusterGroup{global: c.global, cluster: c}
+	cmd.AddCommand(clusterGroupCmd.Command())
+
+	// Evacuate
+	clusterEvacuateCmd := cmdClusterEvacuate{global: c.global, cluster: c, action: "evacuate"}
+	cmd.AddCommand(clusterEvacuateCmd.Command())
+
+	// Restore
+	clusterRestoreCmd := cmdClusterEvacuate{global: c.global, cluster: c, action: "restore"}
+	cmd.AddCommand(clusterRestoreCmd.Command())
+
 	return cmd
 }
 
@@ -141,6 +161,65 @@ func (c *cmdClusterList) Run(cmd *cobra.Command, args []string) error {
 	return utils.RenderTable(c.flagFormat, header, data, members)
 }
 
+// Add
+type cmdClusterAdd struct {
+	global  *cmdGlobal
+	cluster *cmdCluster
+}
+
+func (c *cmdClusterAdd) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("add [<remote>:]<name>")
+	cmd.Short = i18n.G("Request a join token for adding a cluster member")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Request a join token for adding a cluster member
+
+The token is single-use and must be provided to "lxd init" on the new member.`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdClusterAdd) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing cluster member name"))
+	}
+
+	// Request the join token
+	op, err := resource.server.CreateClusterMember(api.ClusterMembersPost{ServerName: resource.name})
+	if err != nil {
+		return err
+	}
+
+	token, ok := op.Get().Metadata["token"].(string)
+	if !ok {
+		return fmt.Errorf(i18n.G("Failed to get the token from the server"))
+	}
+
+	if c.global.flagQuiet {
+		fmt.Println(token)
+		return nil
+	}
+
+	fmt.Printf(i18n.G("Member %s join token: %s")+"\n", resource.name, token)
+	return nil
+}
+
 // Show
 type cmdClusterShow struct {
 	global  *cmdGlobal
@@ -519,3 +598,89 @@ func (c *cmdClusterEdit) Run(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// Evacuate and restore
+type cmdClusterEvacuate struct {
+	global  *cmdGlobal
+	cluster *cmdCluster
+	action  string
+
+	flagForce bool
+}
+
+func (c *cmdClusterEvacuate) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	if c.action == "evacuate" {
+		cmd.Use = i18n.G("evacuate [<remote>:]<member>")
+		cmd.Short = i18n.G("Evacuate cluster member")
+		cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+			`Evacuate cluster member
+
+  All the instances of the member are moved to other members, or stopped
+  depending on their cluster.evacuate configuration.`))
+	} else {
+		cmd.Use = i18n.G("restore [<remote>:]<member>")
+		cmd.Short = i18n.G("Restore cluster member")
+		cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+			`Restore cluster member
+
+  The instances evacuated from the member are moved back to it and started
+  again if they were running.`))
+	}
+
+	cmd.Flags().BoolVar(&c.flagForce, "force", false, i18n.G("Don't require user confirmation"))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdClusterEvacuate) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing cluster member name"))
+	}
+
+	if !c.flagForce {
+		if !cli.AskBool(fmt.Sprintf(i18n.G("Are you sure you want to %s cluster member %q? (yes/no) [default=no]: "), c.action, resource.name), "no") {
+			return nil
+		}
+	}
+
+	op, err := resource.server.UpdateClusterMemberState(resource.name, api.ClusterMemberStatePost{Action: c.action})
+	if err != nil {
+		return err
+	}
+
+	progress := utils.ProgressRenderer{
+		Quiet: c.global.flagQuiet,
+	}
+
+	_, err = op.AddHandler(progress.UpdateOp)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	err = utils.CancelableWait(op, &progress)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	progress.Done("")
+	return nil
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/lxc/utils"
+	"github.com/lxc/lxd/shared/api"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type cmdClusterGroup struct {
+	global  *cmdGlobal
+	cluster *cmdCluster
+}
+
+func (c *cmdClusterGroup) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("group")
+	cmd.Short = i18n.G("Manage cluster groups")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage cluster groups`))
+
+	// Assign
+	clusterGroupAssignCmd := cmdClusterGroupAssign{global: c.global, cluster: c.cluster}
+	cmd.AddCommand(clusterGroupAssignCmd.Command())
+
+	// Create
+	clusterGroupCreateCmd := cmdClusterGroupCreate{global: c.global, cluster: c.cluster}
+	cmd.AddCommand(clusterGroupCreateCmd.Command())
+
+	// Delete
+	clusterGroupDeleteCmd := cmdClusterGroupDelete{global: c.global, cluster: c.cluster}
+	cmd.AddCommand(clusterGroupDeleteCmd.Command())
+
+	// List
+	clusterGroupListCmd := cmdClusterGroupList{global: c.global, cluster: c.cluster}
+	cmd.AddCommand(clusterGroupListCmd.Command())
+
+	// Rename
+	clusterGroupRenameCmd := cmdClusterGroupRename{global: c.global, cluster: c.cluster}
+	cmd.AddCommand(clusterGroupRenameCmd.Command())
+
+	// Show
+	clusterGroupShowCmd := cmdClusterGroupShow{global: c.global, cluster: c.cluster}
+	cmd.AddCommand(clusterGroupShowCmd.Command())
+
+	return cmd
+}
+
+// Assign
+type cmdClusterGroupAssign struct {
+	global  *cmdGlobal
+	cluster *cmdCluster
+}
+
+func (c *cmdClusterGroupAssign) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("assign [<remote>:]<member> <group>[,<group>...]")
+	cmd.Short = i18n.G("Assign sets of groups to cluster members")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Assign sets of groups to cluster members`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc cluster group assign foo rack1,ssd
+    Set the groups of member "foo" to "rack1" and "ssd".`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdClusterGroupAssign) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing cluster member name"))
+	}
+
+	member, etag, err := resource.server.GetClusterMember(resource.name)
+	if err != nil {
+		return err
+	}
+
+	member.Groups = strings.Split(args[1], ",")
+
+	return resource.server.UpdateClusterMember(resource.name, member.Writable(), etag)
+}
+
+// Create
+type cmdClusterGroupCreate struct {
+	global  *cmdGlobal
+	cluster *cmdCluster
+
+	flagDescription string
+}
+
+func (c *cmdClusterGroupCreate) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("create [<remote>:]<group> [<member>...]")
+	cmd.Short = i18n.G("Create a cluster group")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Create a cluster group`))
+
+	cmd.Flags().StringVar(&c.flagDescription, "description", "", i18n.G("Cluster group description")+"``")
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdClusterGroupCreate) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, -1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing cluster group name"))
+	}
+
+	group := api.ClusterGroupsPost{Name: resource.name}
+	group.Description = c.flagDescription
+	group.Members = args[1:]
+
+	err = resource.server.CreateClusterGroup(group)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Cluster group %s created")+"\n", resource.name)
+	}
+
+	return nil
+}
+
+// Delete
+type cmdClusterGroupDelete struct {
+	global  *cmdGlobal
+	cluster *cmdCluster
+}
+
+func (c *cmdClusterGroupDelete) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("delete [<remote>:]<group>")
+	cmd.Aliases = []string{"rm"}
+	cmd.Short = i18n.G("Delete a cluster group")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Delete a cluster group`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdClusterGroupDelete) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing cluster group name"))
+	}
+
+	err = resource.server.DeleteClusterGroup(resource.name)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Cluster group %s deleted")+"\n", resource.name)
+	}
+
+	return nil
+}
+
+// List
+type cmdClusterGroupList struct {
+	global  *cmdGlobal
+	cluster *cmdCluster
+
+	flagFormat string
+}
+
+func (c *cmdClusterGroupList) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("list [<remote>:]")
+	cmd.Aliases = []string{"ls"}
+	cmd.Short = i18n.G("List all the cluster groups")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`List all the cluster groups`))
+
+	cmd.Flags().StringVar(&c.flagFormat, "format", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdClusterGroupList) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 0, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	remote := ""
+	if len(args) == 1 {
+		remote = args[0]
+	}
+
+	resources, err := c.global.ParseServers(remote)
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	groups, err := resource.server.GetClusterGroups()
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	for _, group := range groups {
+		data = append(data, []string{group.Name, group.Description, strings.Join(group.Members, "\n")})
+	}
+	sort.Sort(byName(data))
+
+	header := []string{
+		i18n.G("NAME"),
+		i18n.G("DESCRIPTION"),
+		i18n.G("MEMBERS"),
+	}
+
+	return utils.RenderTable(c.flagFormat, header, data, groups)
+}
+
+// Rename
+type cmdClusterGroupRename struct {
+	global  *cmdGlobal
+	cluster *cmdCluster
+}
+
+func (c *cmdClusterGroupRename) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("rename [<remote>:]<group> <new-name>")
+	cmd.Aliases = []string{"mv"}
+	cmd.Short = i18n.G("Rename a cluster group")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Rename a cluster group`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdClusterGroupRename) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing cluster group name"))
+	}
+
+	err = resource.server.RenameClusterGroup(resource.name, api.ClusterGroupPost{Name: args[1]})
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Cluster group %s renamed to %s")+"\n", resource.name, args[1])
+	}
+
+	return nil
+}
+
+// Show
+type cmdClusterGroupShow struct {
+	global  *cmdGlobal
+	cluster *cmdCluster
+}
+
+func (c *cmdClusterGroupShow) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("show [<remote>:]<group>")
+	cmd.Short = i18n.G("Show cluster group configurations")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show cluster group configurations`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdClusterGroupShow) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing cluster group name"))
+	}
+
+	group, _, err := resource.server.GetClusterGroup(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&group)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lxc/lxd/shared"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type cmdClusterRole struct {
+	global  *cmdGlobal
+	cluster *cmdCluster
+}
+
+func (c *cmdClusterRole) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("role")
+	cmd.Short = i18n.G("Manage cluster roles")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage cluster roles`))
+
+	// Add
+	clusterRoleAddCmd := cmdClusterRoleAdd{global: c.global, cluster: c.cluster}
+	cmd.AddCommand(clusterRoleAddCmd.Command())
+
+	// Remove
+	clusterRoleRemoveCmd := cmdClusterRoleRemove{global: c.global, cluster: c.cluster}
+	cmd.AddCommand(clusterRoleRemoveCmd.Command())
+
+	return cmd
+}
+
+// clusterRolesRemove returns the given roles without the given one.
+func clusterRolesRemove(roles []string, role string) []string {
+	result := []string{}
+	for _, r := range roles {
+		if r != role {
+			result = append(result, r)
+		}
+	}
+
+	return result
+}
+
+// Add
+type cmdClusterRoleAdd struct {
+	global  *cmdGlobal
+	cluster *cmdCluster
+}
+
+func (c *cmdClusterRoleAdd) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("add [<remote>:]<member> <role>[,<role>...]")
+	cmd.Short = i18n.G("Add roles to a cluster member")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Add roles to a cluster member
+
+Adding the "database" or "database-standby" role promotes the member in the
+database cluster, demoting another member if needed.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc cluster role add foo database
+    Make member "foo" a database voter.`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdClusterRoleAdd) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing cluster member name"))
+	}
+
+	member, etag, err := resource.server.GetClusterMember(resource.name)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range strings.Split(args[1], ",") {
+		if shared.StringInSlice(role, member.Roles) {
+			return fmt.Errorf(i18n.G("Member %q already has role %q"), resource.name, role)
+		}
+
+		// A member is either a database voter or a stand-by.
+		if role == "database" {
+			member.Roles = clusterRolesRemove(member.Roles, "database-standby")
+		} else if role == "database-standby" {
+			member.Roles = clusterRolesRemove(member.Roles, "database")
+		}
+
+		member.Roles = append(member.Roles, role)
+	}
+
+	return resource.server.UpdateClusterMember(resource.name, member.Writable(), etag)
+}
+
+// Remove
+type cmdClusterRoleRemove struct {
+	global  *cmdGlobal
+	cluster *cmdCluster
+}
+
+func (c *cmdClusterRoleRemove) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("remove [<remote>:]<member> <role>[,<role>...]")
+	cmd.Short = i18n.G("Remove roles from a cluster member")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Remove roles from a cluster member
+
+Removing the "database" or "database-standby" role demotes the member in the
+database cluster.`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdClusterRoleRemove) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing cluster member name"))
+	}
+
+	member, etag, err := resource.server.GetClusterMember(resource.name)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range strings.Split(args[1], ",") {
+		if !shared.StringInSlice(role, member.Roles) {
+			return fmt.Errorf(i18n.G("Member %q doesn't have role %q"), resource.name, role)
+		}
+
+		member.Roles = clusterRolesRemove(member.Roles, role)
+	}
+
+	return resource.server.UpdateClusterMember(resource.name, member.Writable(), etag)
+}
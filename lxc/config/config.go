@@ -1,10 +1,15 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 
 	"github.com/juju/persistent-cookiejar"
+
+	"github.com/lxc/lxd/client"
 )
 
 // Config holds settings to be used by a client or daemon
@@ -34,6 +39,9 @@ type Config struct {
 
 	// Cookie jars
 	cookieJars map[string]*cookiejar.Jar
+
+	// OpenID Connect tokens
+	oidcTokens map[string]*lxd.OIDCTokens
 }
 
 // ConfigPath returns a joined path of the configuration directory and passed arguments
@@ -49,6 +57,11 @@ func (c *Config) CookiesPath(remote string) string {
 	return c.ConfigPath("jars", remote)
 }
 
+// OIDCTokenPath returns the path for the remote's OpenID Connect tokens
+func (c *Config) OIDCTokenPath(remote string) string {
+	return c.ConfigPath("oidctokens", fmt.Sprintf("%s.json", remote))
+}
+
 // ServerCertPath returns the path for the remote's server certificate
 func (c *Config) ServerCertPath(remote string) string {
 	return c.ConfigPath("servercerts", fmt.Sprintf("%s.crt", remote))
@@ -61,6 +74,23 @@ func (c *Config) SaveCookies() {
 	}
 }
 
+// SaveOIDCTokens saves the OpenID Connect tokens to file
+func (c *Config) SaveOIDCTokens() {
+	for remote, tokens := range c.oidcTokens {
+		data, err := json.Marshal(tokens)
+		if err != nil {
+			continue
+		}
+
+		err = os.MkdirAll(c.ConfigPath("oidctokens"), 0700)
+		if err != nil {
+			continue
+		}
+
+		ioutil.WriteFile(c.OIDCTokenPath(remote), data, 0600)
+	}
+}
+
 // NewConfig returns a Config, optionally using default remotes.
 func NewConfig(configDir string, defaults bool) *Config {
 	config := &Config{ConfigDir: configDir}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"io/ioutil"
@@ -93,7 +94,7 @@ func (c *Config) GetInstanceServer(name string) (lxd.InstanceServer, error) {
 	}
 
 	// HTTPs
-	if remote.AuthType != "candid" && (args.TLSClientCert == "" || args.TLSClientKey == "") {
+	if remote.AuthType != "candid" && remote.AuthType != "oidc" && (args.TLSClientCert == "" || args.TLSClientKey == "") {
 		return nil, fmt.Errorf("Missing TLS client certificate and key")
 	}
 
@@ -244,6 +245,31 @@ func (c *Config) getConnectionArgs(name string) (*lxd.ConnectionArgs, error) {
 		args.CookieJar = c.cookieJars[name]
 	}
 
+	if args.AuthType == "oidc" {
+		if c.oidcTokens == nil || c.oidcTokens[name] == nil {
+			tokens := lxd.OIDCTokens{}
+
+			if shared.PathExists(c.OIDCTokenPath(name)) {
+				content, err := ioutil.ReadFile(c.OIDCTokenPath(name))
+				if err != nil {
+					return nil, err
+				}
+
+				err = json.Unmarshal(content, &tokens)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if c.oidcTokens == nil {
+				c.oidcTokens = map[string]*lxd.OIDCTokens{}
+			}
+			c.oidcTokens[name] = &tokens
+		}
+
+		args.OIDCTokens = c.oidcTokens[name]
+	}
+
 	// Stop here if no TLS involved
 	if strings.HasPrefix(remote.Addr, "unix:") {
 		return &args, nil
@@ -260,7 +286,7 @@ func (c *Config) getConnectionArgs(name string) (*lxd.ConnectionArgs, error) {
 	}
 
 	// Stop here if no client certificate involved
-	if remote.Protocol == "simplestreams" || remote.AuthType == "candid" {
+	if remote.Protocol == "simplestreams" || remote.AuthType == "candid" || remote.AuthType == "oidc" {
 		return &args, nil
 	}
 
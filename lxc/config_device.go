@@ -2,11 +2,13 @@ package main
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
+	"github.com/lxc/lxd/lxc/utils"
 	cli "github.com/lxc/lxd/shared/cmd"
 	"github.com/lxc/lxd/shared/i18n"
 )
@@ -251,6 +253,8 @@ type cmdConfigDeviceList struct {
 	config       *cmdConfig
 	configDevice *cmdConfigDevice
 	profile      *cmdProfile
+
+	flagFormat string
 }
 
 func (c *cmdConfigDeviceList) Command() *cobra.Command {
@@ -262,6 +266,7 @@ func (c *cmdConfigDeviceList) Command() *cobra.Command {
 		`List instance devices`))
 
 	cmd.RunE = c.Run
+	cmd.Flags().StringVar(&c.flagFormat, "format", "", i18n.G("Format (csv|json|table|yaml)")+"``")
 
 	return cmd
 }
@@ -287,24 +292,40 @@ func (c *cmdConfigDeviceList) Run(cmd *cobra.Command, args []string) error {
 
 	// List the devices
 	var devices []string
+	var rawDevices map[string]map[string]string
 	if c.profile != nil {
 		profile, _, err := resource.server.GetProfile(resource.name)
 		if err != nil {
 			return err
 		}
 
-		for k := range profile.Devices {
-			devices = append(devices, k)
-		}
+		rawDevices = profile.Devices
 	} else {
 		inst, _, err := resource.server.GetInstance(resource.name)
 		if err != nil {
 			return err
 		}
 
-		for k := range inst.Devices {
-			devices = append(devices, k)
+		rawDevices = inst.Devices
+	}
+
+	for k := range rawDevices {
+		devices = append(devices, k)
+	}
+
+	if c.flagFormat != "" {
+		data := [][]string{}
+		for k, v := range rawDevices {
+			data = append(data, []string{k, v["type"]})
+		}
+		sort.Sort(byName(data))
+
+		header := []string{
+			i18n.G("NAME"),
+			i18n.G("TYPE"),
 		}
+
+		return utils.RenderTable(c.flagFormat, header, data, rawDevices)
 	}
 
 	fmt.Printf("%s\n", strings.Join(devices, "\n"))
@@ -6,6 +6,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -48,14 +49,34 @@ type cmdConfigTrustAdd struct {
 	global      *cmdGlobal
 	config      *cmdConfig
 	configTrust *cmdConfigTrust
+
+	flagType  string
+	flagRoles []string
+	flagName  string
 }
 
 func (c *cmdConfigTrustAdd) Command() *cobra.Command {
 	cmd := &cobra.Command{}
-	cmd.Use = i18n.G("add [<remote>:] <cert>")
+	cmd.Use = i18n.G("add [<remote>:] [<cert>]")
 	cmd.Short = i18n.G("Add new trusted clients")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
-		`Add new trusted clients`))
+		`Add new trusted clients
+
+Metrics certificates only grant access to the /1.0/metrics endpoint.
+
+Client certificates given roles (viewer, operator or admin) are restricted to the projects of those roles.
+
+When no certificate is provided, a single-use token is issued for the client named with --name.
+The client can then add the server with "lxc remote add <name> <token>".`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc config trust add --role foo=viewer --role bar=admin client.crt
+    Trust client.crt with read-only access to project "foo" and full access to project "bar".
+
+lxc config trust add --name laptop
+    Issue a token for a new client named "laptop".`))
+	cmd.Flags().StringVar(&c.flagType, "type", "client", i18n.G("Type of certificate (client|metrics)")+"``")
+	cmd.Flags().StringArrayVar(&c.flagRoles, "role", nil, i18n.G("Role of the certificate on a project (PROJECT=ROLE)")+"``")
+	cmd.Flags().StringVar(&c.flagName, "name", "", i18n.G("Name of the new client, to issue a token instead of adding a certificate")+"``")
 
 	cmd.RunE = c.Run
 
@@ -64,14 +85,20 @@ func (c *cmdConfigTrustAdd) Command() *cobra.Command {
 
 func (c *cmdConfigTrustAdd) Run(cmd *cobra.Command, args []string) error {
 	// Sanity checks
-	exit, err := c.global.CheckArgs(cmd, args, 1, 2)
+	exit, err := c.global.CheckArgs(cmd, args, 0, 2)
 	if exit {
 		return err
 	}
 
+	// Without a certificate, a token is requested for the named client.
+	tokenMode := len(args) == 0 || (len(args) == 1 && strings.HasSuffix(args[0], ":"))
+	if tokenMode && c.flagName == "" {
+		return fmt.Errorf(i18n.G("A certificate or a client name (--name) must be provided"))
+	}
+
 	// Parse remote
 	remote := ""
-	if len(args) > 0 {
+	if len(args) > 1 || (len(args) == 1 && tokenMode) {
 		remote = args[0]
 	}
 
@@ -82,6 +109,39 @@ func (c *cmdConfigTrustAdd) Run(cmd *cobra.Command, args []string) error {
 
 	resource := resources[0]
 
+	cert := api.CertificatesPost{}
+	cert.Type = c.flagType
+
+	if len(c.flagRoles) > 0 {
+		cert.Roles = map[string]string{}
+		for _, entry := range c.flagRoles {
+			fields := strings.SplitN(entry, "=", 2)
+			if len(fields) != 2 || fields[0] == "" || fields[1] == "" {
+				return fmt.Errorf(i18n.G("Bad role syntax, expecting <project>=<role>: %s"), entry)
+			}
+
+			cert.Roles[fields[0]] = fields[1]
+		}
+	}
+
+	if tokenMode {
+		cert.Name = c.flagName
+		cert.Token = true
+
+		op, err := resource.server.CreateCertificateToken(cert)
+		if err != nil {
+			return err
+		}
+
+		token, ok := op.Get().Metadata["token"].(string)
+		if !ok {
+			return fmt.Errorf(i18n.G("Failed to get the token from the server"))
+		}
+
+		fmt.Printf(i18n.G("Client %s certificate add token: %s")+"\n", c.flagName, token)
+		return nil
+	}
+
 	// Add trust relationship
 	fname := args[len(args)-1]
 	x509Cert, err := shared.ReadCert(shared.HostPath(fname))
@@ -89,11 +149,12 @@ func (c *cmdConfigTrustAdd) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	name, _ := shared.SplitExt(fname)
+	if c.flagName != "" {
+		name = c.flagName
+	}
 
-	cert := api.CertificatesPost{}
 	cert.Certificate = base64.StdEncoding.EncodeToString(x509Cert.Raw)
 	cert.Name = name
-	cert.Type = "client"
 
 	return resource.server.CreateCertificate(cert)
 }
@@ -150,6 +211,13 @@ func (c *cmdConfigTrustList) Run(cmd *cobra.Command, args []string) error {
 	data := [][]string{}
 	for _, cert := range trust {
 		fp := cert.Fingerprint[0:12]
+		certType := cert.Type
+
+		roles := []string{}
+		for project, role := range cert.Roles {
+			roles = append(roles, fmt.Sprintf("%s=%s", project, role))
+		}
+		sort.Strings(roles)
 
 		certBlock, _ := pem.Decode([]byte(cert.Certificate))
 		if certBlock == nil {
@@ -164,13 +232,15 @@ func (c *cmdConfigTrustList) Run(cmd *cobra.Command, args []string) error {
 		const layout = "Jan 2, 2006 at 3:04pm (MST)"
 		issue := cert.NotBefore.Format(layout)
 		expiry := cert.NotAfter.Format(layout)
-		data = append(data, []string{fp, cert.Subject.CommonName, issue, expiry})
+		data = append(data, []string{certType, fp, cert.Subject.CommonName, strings.Join(roles, "\n"), issue, expiry})
 	}
 	sort.Sort(stringList(data))
 
 	header := []string{
+		i18n.G("TYPE"),
 		i18n.G("FINGERPRINT"),
 		i18n.G("COMMON NAME"),
+		i18n.G("ROLES"),
 		i18n.G("ISSUE DATE"),
 		i18n.G("EXPIRY DATE"),
 	}
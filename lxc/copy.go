@@ -75,6 +75,11 @@ func (c *cmdCopy) copyInstance(conf *config.Config, sourceResource string, destR
 		return fmt.Errorf(i18n.G("You must specify a source instance name"))
 	}
 
+	// Make sure the transfer mode is valid, relay being needed when the servers can't reach each other
+	if !shared.StringInSlice(mode, []string{"pull", "push", "relay"}) {
+		return fmt.Errorf(i18n.G("Invalid transfer mode %q, must be one of pull, push or relay"), mode)
+	}
+
 	// Check that a destination instance was specified, if --target is passed.
 	if destName == "" && c.flagTarget != "" {
 		return fmt.Errorf(i18n.G("You must specify a destination instance name when using --target"))
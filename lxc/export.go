@@ -2,6 +2,7 @@ package main
 
 import (
 	"io"
+	"io/ioutil"
 	"os"
 	"strings"
 	"time"
@@ -23,6 +24,12 @@ type cmdExport struct {
 	flagInstanceOnly         bool
 	flagOptimizedStorage     bool
 	flagCompressionAlgorithm string
+	flagIncrementalFrom      string
+	flagEncryption           string
+	flagRecipients           []string
+	flagSnapshots            []string
+	flagSnapshotsMaxAge      string
+	flagFormat               string
 }
 
 func (c *cmdExport) Command() *cobra.Command {
@@ -33,7 +40,10 @@ func (c *cmdExport) Command() *cobra.Command {
 		`Export instances as backup tarballs.`))
 	cmd.Example = cli.FormatSection("", i18n.G(
 		`lxc export u1 backup0.tar.gz
-    Download a backup tarball of the u1 instance.`))
+    Download a backup tarball of the u1 instance.
+
+lxc export u1 backup1.tar.gz --optimized-storage --incremental-from snap0
+    Download an optimized backup of the u1 instance only containing the changes since snapshot snap0.`))
 
 	cmd.RunE = c.Run
 	cmd.Flags().BoolVar(&c.flagInstanceOnly, "instance-only", false,
@@ -41,6 +51,12 @@ func (c *cmdExport) Command() *cobra.Command {
 	cmd.Flags().BoolVar(&c.flagOptimizedStorage, "optimized-storage", false,
 		i18n.G("Use storage driver optimized format (can only be restored on a similar pool)"))
 	cmd.Flags().StringVar(&c.flagCompressionAlgorithm, "compression", "", i18n.G("Define a compression algorithm: for backup or none")+"``")
+	cmd.Flags().StringVar(&c.flagIncrementalFrom, "incremental-from", "", i18n.G("Only export changes since the given snapshot (requires --optimized-storage)")+"``")
+	cmd.Flags().StringVar(&c.flagEncryption, "encryption", "", i18n.G("Encrypt the backup on the server (age or gpg)")+"``")
+	cmd.Flags().StringArrayVar(&c.flagRecipients, "recipient", nil, i18n.G("Encryption recipient (age public key or path to an armored GPG public key)")+"``")
+	cmd.Flags().StringArrayVar(&c.flagSnapshots, "snapshot", nil, i18n.G("Only include the snapshots matching this name or pattern")+"``")
+	cmd.Flags().StringVar(&c.flagSnapshotsMaxAge, "snapshots-max-age", "", i18n.G("Only include the snapshots created within this period (e.g. 7d)")+"``")
+	cmd.Flags().StringVar(&c.flagFormat, "format", "", i18n.G("Export a virtual machine's disk for other hypervisors (qcow2 or ova)")+"``")
 
 	return cmd
 }
@@ -74,6 +90,28 @@ func (c *cmdExport) Run(cmd *cobra.Command, args []string) error {
 		InstanceOnly:         instanceOnly,
 		OptimizedStorage:     c.flagOptimizedStorage,
 		CompressionAlgorithm: c.flagCompressionAlgorithm,
+		IncrementalFrom:      c.flagIncrementalFrom,
+		Snapshots:            c.flagSnapshots,
+		SnapshotsMaxAge:      c.flagSnapshotsMaxAge,
+		Format:               c.flagFormat,
+	}
+
+	if c.flagEncryption != "" {
+		req.Encryption = &api.BackupEncryption{Method: c.flagEncryption}
+
+		for _, recipient := range c.flagRecipients {
+			// GPG public keys are read from files.
+			if c.flagEncryption == "gpg" {
+				content, err := ioutil.ReadFile(recipient)
+				if err != nil {
+					return err
+				}
+
+				recipient = string(content)
+			}
+
+			req.Encryption.Recipients = append(req.Encryption.Recipients, recipient)
+		}
 	}
 
 	op, err := d.CreateInstanceBackup(name, req)
@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"os/signal"
 	"path"
 	"path/filepath"
@@ -88,6 +89,10 @@ func (c *cmdFile) Command() *cobra.Command {
 	fileEditCmd := cmdFileEdit{global: c.global, file: c, filePull: &filePullCmd, filePush: &filePushCmd}
 	cmd.AddCommand(fileEditCmd.Command())
 
+	// Mount
+	fileMountCmd := cmdFileMount{global: c.global, file: c}
+	cmd.AddCommand(fileMountCmd.Command())
+
 	return cmd
 }
 
@@ -204,6 +209,129 @@ func (c *cmdFileEdit) Run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// Mount
+type cmdFileMount struct {
+	global *cmdGlobal
+	file   *cmdFile
+}
+
+func (c *cmdFileMount) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("mount [<remote>:]<instance>[/<path>] <target path>")
+	cmd.Short = i18n.G("Mount files from instances")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Mount files from instances
+
+The instance's filesystem (or the given path in it) is mounted on the local
+target path using sshfs, until interrupted. This requires sshfs to be installed.
+
+Files are transferred whole through the file API, so this is best suited to
+editing source or configuration files.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc file mount foo/root ~/foo
+    Mount /root from instance "foo" onto ~/foo.`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdFileMount) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	sshfsPath, err := exec.LookPath("sshfs")
+	if err != nil {
+		return fmt.Errorf(i18n.G("sshfs is required to mount instance files"))
+	}
+
+	// Determine the target
+	target := shared.HostPathFollow(filepath.Clean(args[1]))
+	sb, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+
+	if !sb.IsDir() {
+		return fmt.Errorf(i18n.G("Target path %q isn't a directory"), args[1])
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+	pathSpec := strings.SplitN(resource.name, "/", 2)
+	if pathSpec[0] == "" {
+		return fmt.Errorf(i18n.G("Missing instance name"))
+	}
+
+	root := "/"
+	if len(pathSpec) == 2 {
+		root = path.Join("/", pathSpec[1])
+	}
+
+	// Check that the source is a directory
+	_, resp, err := resource.server.GetInstanceFile(pathSpec[0], root)
+	if err != nil {
+		return err
+	}
+
+	if resp.Type != "directory" {
+		return fmt.Errorf(i18n.G("Source path %q isn't a directory"), root)
+	}
+
+	// Have sshfs talk SFTP to us over its stdin and stdout
+	sshfsCmd := exec.Command(sshfsPath, "-f", "-o", "slave", "-o", "idmap=user", fmt.Sprintf("%s:%s", pathSpec[0], root), target)
+	sshfsCmd.Stderr = os.Stderr
+
+	stdin, err := sshfsCmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	stdout, err := sshfsCmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	err = sshfsCmd.Start()
+	if err != nil {
+		return err
+	}
+
+	server := newFileSFTPServer(resource.server, pathSpec[0], root, &fileSFTPConn{Reader: stdout, WriteCloser: stdin})
+	go func() {
+		server.Serve()
+		server.Close()
+	}()
+
+	fmt.Printf(i18n.G("Mounted %s on %s, interrupt to unmount")+"\n", resource.name, target)
+
+	// sshfs unmounts the target when interrupted
+	err = sshfsCmd.Wait()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() < 0 {
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// fileSFTPConn joins the stdout and stdin of sshfs into a single connection.
+type fileSFTPConn struct {
+	io.Reader
+	io.WriteCloser
+}
+
 // Pull
 type cmdFilePull struct {
 	global *cmdGlobal
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+
+	"github.com/lxc/lxd/client"
+)
+
+// fileSFTPHandlers implements an SFTP server on top of the instance file API.
+//
+// The file API only transfers whole files, so reads fetch the entire file and writes are buffered
+// until the file is closed. The size of files is only known once they've been fetched and the
+// modification times aren't exposed at all.
+type fileSFTPHandlers struct {
+	server   lxd.InstanceServer
+	instance string
+	root     string
+}
+
+// newFileSFTPServer returns an SFTP server exposing root in the instance over rwc.
+func newFileSFTPServer(server lxd.InstanceServer, instance string, root string, rwc io.ReadWriteCloser) *sftp.RequestServer {
+	h := &fileSFTPHandlers{
+		server:   server,
+		instance: instance,
+		root:     root,
+	}
+
+	return sftp.NewRequestServer(rwc, sftp.Handlers{
+		FileGet:  h,
+		FilePut:  h,
+		FileCmd:  h,
+		FileList: h,
+	})
+}
+
+// instancePath converts an SFTP path to a path in the instance.
+func (h *fileSFTPHandlers) instancePath(p string) string {
+	return path.Join(h.root, path.Clean("/"+p))
+}
+
+// get fetches a file (or the target of a symlink) from the instance.
+func (h *fileSFTPHandlers) get(p string) ([]byte, *lxd.InstanceFileResponse, error) {
+	buf, resp, err := h.server.GetInstanceFile(h.instance, h.instancePath(p))
+	if err != nil {
+		return nil, nil, os.ErrNotExist
+	}
+
+	if buf == nil {
+		return nil, resp, nil
+	}
+	defer buf.Close()
+
+	content, err := ioutil.ReadAll(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return content, resp, nil
+}
+
+// put uploads a file to the instance.
+func (h *fileSFTPHandlers) put(p string, content []byte, uid int64, gid int64, mode int) error {
+	return h.server.CreateInstanceFile(h.instance, h.instancePath(p), lxd.InstanceFileArgs{
+		Content:   bytes.NewReader(content),
+		UID:       uid,
+		GID:       gid,
+		Mode:      mode,
+		Type:      "file",
+		WriteMode: "overwrite",
+	})
+}
+
+// stat builds the file information of a path in the instance.
+func (h *fileSFTPHandlers) stat(p string) (os.FileInfo, error) {
+	content, resp, err := h.get(p)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &fileSFTPInfo{
+		name: path.Base(p),
+		size: int64(len(content)),
+		mode: os.FileMode(resp.Mode) & os.ModePerm,
+		uid:  resp.UID,
+		gid:  resp.GID,
+	}
+
+	switch resp.Type {
+	case "directory":
+		info.mode |= os.ModeDir
+		info.size = 0
+	case "symlink":
+		info.mode |= os.ModeSymlink
+	}
+
+	return info, nil
+}
+
+// Fileread implements sftp.FileReader.
+func (h *fileSFTPHandlers) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	content, resp, err := h.get(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Type == "directory" {
+		return nil, fmt.Errorf("%q is a directory", r.Filepath)
+	}
+
+	return bytes.NewReader(content), nil
+}
+
+// Filewrite implements sftp.FileWriter.
+func (h *fileSFTPHandlers) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	f := &fileSFTPWriter{
+		h:    h,
+		path: r.Filepath,
+		mode: 0644,
+	}
+
+	content, resp, err := h.get(r.Filepath)
+	if err == nil {
+		if resp.Type == "directory" {
+			return nil, fmt.Errorf("%q is a directory", r.Filepath)
+		}
+
+		f.uid = resp.UID
+		f.gid = resp.GID
+		f.mode = resp.Mode
+
+		if !r.Pflags().Trunc {
+			f.content = content
+		}
+	} else if !r.Pflags().Creat {
+		return nil, err
+	}
+
+	// Create the file right away so it can be stat'ed while being written.
+	err = h.put(f.path, f.content, f.uid, f.gid, f.mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Filecmd implements sftp.FileCmder.
+func (h *fileSFTPHandlers) Filecmd(r *sftp.Request) error {
+	switch r.Method {
+	case "Setstat":
+		content, resp, err := h.get(r.Filepath)
+		if err != nil {
+			return err
+		}
+
+		// Only files can be updated through the file API.
+		if resp.Type != "file" {
+			return nil
+		}
+
+		attrs := r.Attributes()
+		uid, gid, mode := resp.UID, resp.GID, resp.Mode
+		if r.AttrFlags().UidGid {
+			uid = int64(attrs.UID)
+			gid = int64(attrs.GID)
+		}
+
+		if r.AttrFlags().Permissions {
+			mode = int(attrs.FileMode() & os.ModePerm)
+		}
+
+		if r.AttrFlags().Size {
+			size := int(attrs.Size)
+			if size < len(content) {
+				content = content[:size]
+			} else {
+				content = append(content, make([]byte, size-len(content))...)
+			}
+		}
+
+		return h.put(r.Filepath, content, uid, gid, mode)
+	case "Rename":
+		content, resp, err := h.get(r.Filepath)
+		if err != nil {
+			return err
+		}
+
+		if resp.Type != "file" {
+			return fmt.Errorf("Only files can be renamed")
+		}
+
+		err = h.put(r.Target, content, resp.UID, resp.GID, resp.Mode)
+		if err != nil {
+			return err
+		}
+
+		return h.server.DeleteInstanceFile(h.instance, h.instancePath(r.Filepath))
+	case "Rmdir", "Remove":
+		return h.server.DeleteInstanceFile(h.instance, h.instancePath(r.Filepath))
+	case "Mkdir":
+		return h.server.CreateInstanceFile(h.instance, h.instancePath(r.Filepath), lxd.InstanceFileArgs{
+			Mode: 0755,
+			Type: "directory",
+		})
+	case "Symlink":
+		// The SFTP request holds the link target in Filepath and the link path in Target.
+		return h.server.CreateInstanceFile(h.instance, h.instancePath(r.Target), lxd.InstanceFileArgs{
+			Content: bytes.NewReader([]byte(r.Filepath)),
+			Type:    "symlink",
+		})
+	}
+
+	return sftp.ErrSSHFxOpUnsupported
+}
+
+// Filelist implements sftp.FileLister.
+func (h *fileSFTPHandlers) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	switch r.Method {
+	case "List":
+		_, resp, err := h.get(r.Filepath)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.Type != "directory" {
+			return nil, fmt.Errorf("%q isn't a directory", r.Filepath)
+		}
+
+		infos := fileSFTPLister{}
+		for _, entry := range resp.Entries {
+			info, err := h.stat(path.Join(r.Filepath, entry))
+			if err != nil {
+				continue
+			}
+
+			infos = append(infos, info)
+		}
+
+		return infos, nil
+	case "Stat":
+		info, err := h.stat(r.Filepath)
+		if err != nil {
+			return nil, err
+		}
+
+		return fileSFTPLister{info}, nil
+	case "Readlink":
+		content, resp, err := h.get(r.Filepath)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.Type != "symlink" {
+			return nil, fmt.Errorf("%q isn't a symlink", r.Filepath)
+		}
+
+		return fileSFTPLister{&fileSFTPInfo{name: string(content)}}, nil
+	}
+
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+// fileSFTPWriter buffers writes to a file until it gets closed.
+type fileSFTPWriter struct {
+	h       *fileSFTPHandlers
+	path    string
+	content []byte
+	uid     int64
+	gid     int64
+	mode    int
+
+	mu sync.Mutex
+}
+
+// WriteAt implements io.WriterAt.
+func (f *fileSFTPWriter) WriteAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	end := int(off) + len(p)
+	if end > len(f.content) {
+		f.content = append(f.content, make([]byte, end-len(f.content))...)
+	}
+
+	copy(f.content[off:], p)
+
+	return len(p), nil
+}
+
+// Close uploads the file to the instance.
+func (f *fileSFTPWriter) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.h.put(f.path, f.content, f.uid, f.gid, f.mode)
+}
+
+// fileSFTPLister implements sftp.ListerAt.
+type fileSFTPLister []os.FileInfo
+
+// ListAt implements sftp.ListerAt.
+func (l fileSFTPLister) ListAt(infos []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+
+	n := copy(infos, l[offset:])
+	if n < len(infos) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// fileSFTPInfo implements os.FileInfo for files in instances.
+type fileSFTPInfo struct {
+	name string
+	size int64
+	mode os.FileMode
+	uid  int64
+	gid  int64
+}
+
+func (i *fileSFTPInfo) Name() string       { return i.name }
+func (i *fileSFTPInfo) Size() int64        { return i.size }
+func (i *fileSFTPInfo) Mode() os.FileMode  { return i.mode }
+func (i *fileSFTPInfo) ModTime() time.Time { return time.Unix(0, 0) }
+func (i *fileSFTPInfo) IsDir() bool        { return i.mode.IsDir() }
+
+// Sys returns the ownership of the file in the form used by the SFTP server.
+func (i *fileSFTPInfo) Sys() interface{} {
+	return &sftp.FileStat{
+		UID: uint32(i.uid),
+		GID: uint32(i.gid),
+	}
+}
@@ -830,7 +830,8 @@ type cmdImageInfo struct {
 	global *cmdGlobal
 	image  *cmdImage
 
-	flagVM bool
+	flagVM     bool
+	flagFormat string
 }
 
 func (c *cmdImageInfo) Command() *cobra.Command {
@@ -841,6 +842,7 @@ func (c *cmdImageInfo) Command() *cobra.Command {
 		`Show useful information about images`))
 
 	cmd.Flags().BoolVar(&c.flagVM, "vm", false, i18n.G("Query virtual machine images"))
+	cmd.Flags().StringVar(&c.flagFormat, "format", "", i18n.G("Format (json|yaml)")+"``")
 	cmd.RunE = c.Run
 
 	return cmd
@@ -876,6 +878,10 @@ func (c *cmdImageInfo) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if c.flagFormat != "" {
+		return utils.RenderData(c.flagFormat, info)
+	}
+
 	public := i18n.G("no")
 	if info.Public {
 		public = i18n.G("yes")
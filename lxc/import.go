@@ -3,12 +3,14 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxc/utils"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 	cli "github.com/lxc/lxd/shared/cmd"
 	"github.com/lxc/lxd/shared/i18n"
 	"github.com/lxc/lxd/shared/ioprogress"
@@ -23,13 +25,19 @@ type cmdImport struct {
 
 func (c *cmdImport) Command() *cobra.Command {
 	cmd := &cobra.Command{}
-	cmd.Use = i18n.G("import [<remote>:] <backup file>")
+	cmd.Use = i18n.G("import [<remote>:] <backup file or URL>")
 	cmd.Short = i18n.G("Import instance backups")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
-		`Import backups of instances including their snapshots.`))
+		`Import backups of instances including their snapshots.
+
+When given an http or https URL (e.g. a presigned URL of an object storage bucket),
+the backup is downloaded directly by the server.`))
 	cmd.Example = cli.FormatSection("", i18n.G(
 		`lxc import backup0.tar.gz
-    Create a new instance using backup0.tar.gz as the source.`))
+    Create a new instance using backup0.tar.gz as the source.
+
+lxc import https://s3.example.com/backups/c1.tar.gz?X-Amz-Signature=...
+    Create a new instance from a backup downloaded by the server.`))
 
 	cmd.RunE = c.Run
 	cmd.Flags().StringVarP(&c.flagStorage, "storage", "s", "", i18n.G("Storage pool name")+"``")
@@ -57,6 +65,11 @@ func (c *cmdImport) Run(cmd *cobra.Command, args []string) error {
 
 	resource := resources[0]
 
+	source := args[len(args)-1]
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return c.importURL(resource.server, source)
+	}
+
 	file, err := os.Open(shared.HostPath(args[len(args)-1]))
 	if err != nil {
 		return err
@@ -102,3 +115,49 @@ func (c *cmdImport) Run(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// importURL has the server restore the backup found at the given URL.
+func (c *cmdImport) importURL(server lxd.InstanceServer, source string) error {
+	req := api.InstancesPost{
+		Source: api.InstanceSource{
+			Type: "backup",
+			URL:  source,
+		},
+	}
+
+	if c.flagStorage != "" {
+		req.Devices = map[string]map[string]string{
+			"root": {
+				"type": "disk",
+				"path": "/",
+				"pool": c.flagStorage,
+			},
+		}
+	}
+
+	op, err := server.CreateInstance(req)
+	if err != nil {
+		return err
+	}
+
+	progress := utils.ProgressRenderer{
+		Format: i18n.G("Importing instance: %s"),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	_, err = op.AddHandler(progress.UpdateOp)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	err = utils.CancelableWait(op, &progress)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	progress.Done("")
+
+	return nil
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/filter"
 	"github.com/lxc/lxd/shared/i18n"
 	"github.com/lxc/lxd/shared/units"
 )
@@ -35,6 +36,7 @@ type cmdList struct {
 	flagColumns string
 	flagFast    bool
 	flagFormat  string
+	flagFilter  string
 }
 
 func (c *cmdList) Command() *cobra.Command {
@@ -65,6 +67,11 @@ A regular expression matching a configuration item or its value. (e.g. volatile.
 When multiple filters are passed, they are added one on top of the other,
 selecting instances which satisfy them all.
 
+The --filter option takes an expression evaluated by the server instead,
+e.g. 'status eq Running and config.image.os eq Ubuntu'. Clauses compare a
+field (name, status, config.<key>, expanded_devices.<device>.<key>, ...)
+using "eq" or "ne" and are combined with "and", "or" and "not".
+
 == Columns ==
 The -c option takes a comma separated list of arguments that control
 which instance attributes to output when displaying in table or csv
@@ -117,6 +124,7 @@ lxc list -c ns,user.comment:comment
 	cmd.Flags().StringVarP(&c.flagColumns, "columns", "c", defaultColumns, i18n.G("Columns")+"``")
 	cmd.Flags().StringVar(&c.flagFormat, "format", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
 	cmd.Flags().BoolVar(&c.flagFast, "fast", false, i18n.G("Fast mode (same as --columns=nsacPt)"))
+	cmd.Flags().StringVar(&c.flagFilter, "filter", "", i18n.G("Server-side filter expression")+"``")
 
 	return cmd
 }
@@ -389,9 +397,23 @@ func (c *cmdList) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Validate the server-side filter before querying the server.
+	if c.flagFilter != "" {
+		_, err := filter.Parse(c.flagFilter)
+		if err != nil {
+			return fmt.Errorf(i18n.G("Invalid filter: %v"), err)
+		}
+	}
+
 	if len(filters) == 0 && needsData && d.HasExtension("container_full") {
 		// Using the GetInstancesFull shortcut
-		cts, err := d.GetInstancesFull(api.InstanceTypeAny)
+		var cts []api.InstanceFull
+		if c.flagFilter != "" {
+			cts, err = d.GetInstancesFullWithFilter(api.InstanceTypeAny, c.flagFilter)
+		} else {
+			cts, err = d.GetInstancesFull(api.InstanceTypeAny)
+		}
+
 		if err != nil {
 			return err
 		}
@@ -401,7 +423,13 @@ func (c *cmdList) Run(cmd *cobra.Command, args []string) error {
 
 	// Get the list of instances
 	var cts []api.Instance
-	ctslist, err := d.GetInstances(api.InstanceTypeAny)
+	var ctslist []api.Instance
+	if c.flagFilter != "" {
+		ctslist, err = d.GetInstancesWithFilter(api.InstanceTypeAny, c.flagFilter)
+	} else {
+		ctslist, err = d.GetInstances(api.InstanceTypeAny)
+	}
+
 	if err != nil {
 		return err
 	}
@@ -77,6 +77,10 @@ For help with any of those, simply call them with --help.`))
 	aliasCmd := cmdAlias{global: &globalCmd}
 	app.AddCommand(aliasCmd.Command())
 
+	// batch sub-command
+	batchCmd := cmdBatch{global: &globalCmd}
+	app.AddCommand(batchCmd.Command())
+
 	// cluster sub-command
 	clusterCmd := cmdCluster{global: &globalCmd}
 	app.AddCommand(clusterCmd.Command())
@@ -173,6 +177,10 @@ For help with any of those, simply call them with --help.`))
 	queryCmd := cmdQuery{global: &globalCmd}
 	app.AddCommand(queryCmd.Command())
 
+	// rebuild sub-command
+	rebuildCmd := cmdRebuild{global: &globalCmd}
+	app.AddCommand(rebuildCmd.Command())
+
 	// rename sub-command
 	renameCmd := cmdRename{global: &globalCmd}
 	app.AddCommand(renameCmd.Command())
@@ -205,6 +213,10 @@ For help with any of those, simply call them with --help.`))
 	stopCmd := cmdStop{global: &globalCmd}
 	app.AddCommand(stopCmd.Command())
 
+	// top sub-command
+	topCmd := cmdTop{global: &globalCmd}
+	app.AddCommand(topCmd.Command())
+
 	// version sub-command
 	versionCmd := cmdVersion{global: &globalCmd}
 	app.AddCommand(versionCmd.Command())
@@ -364,6 +376,9 @@ func (c *cmdGlobal) PostRun(cmd *cobra.Command, args []string) error {
 	if c.conf != nil && shared.PathExists(c.confPath) {
 		// Save cookies on exit
 		c.conf.SaveCookies()
+
+		// Save OpenID Connect tokens on exit
+		c.conf.SaveOIDCTokens()
 	}
 
 	return nil
@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
+	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	cli "github.com/lxc/lxd/shared/cmd"
@@ -79,7 +80,8 @@ func (c *cmdMonitor) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	listener, err := d.GetEvents()
+	// Have the server filter the events
+	listener, err := d.GetEventsWithFilter(lxd.EventFilter{Types: c.flagType})
 	if err != nil {
 		return err
 	}
@@ -157,7 +159,7 @@ func (c *cmdMonitor) Run(cmd *cobra.Command, args []string) error {
 		fmt.Printf("%s\n\n", render)
 	}
 
-	_, err = listener.AddHandler(c.flagType, handler)
+	_, err = listener.AddHandler(nil, handler)
 	if err != nil {
 		return err
 	}
@@ -242,8 +242,9 @@ func moveClusterInstance(conf *config.Config, sourceResource, destResource, targ
 	}
 
 	// The migrate API will do the right thing when passed a target.
+	// Running virtual-machines get live moved.
 	source = source.UseTarget(target)
-	req := api.InstancePost{Name: destName, Migration: true}
+	req := api.InstancePost{Name: destName, Migration: true, Live: true}
 	op, err := source.MigrateInstance(sourceName, req)
 	if err != nil {
 		return errors.Wrap(err, i18n.G("Migration API failure"))
@@ -33,6 +33,10 @@ func (c *cmdNetwork) Command() *cobra.Command {
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`Manage and attach instances to networks`))
 
+	// ACL
+	networkACLCmd := cmdNetworkACL{global: c.global}
+	cmd.AddCommand(networkACLCmd.Command())
+
 	// Attach
 	networkAttachCmd := cmdNetworkAttach{global: c.global, network: c}
 	cmd.AddCommand(networkAttachCmd.Command())
@@ -61,6 +65,10 @@ func (c *cmdNetwork) Command() *cobra.Command {
 	networkEditCmd := cmdNetworkEdit{global: c.global, network: c}
 	cmd.AddCommand(networkEditCmd.Command())
 
+	// Forward
+	networkForwardCmd := cmdNetworkForward{global: c.global}
+	cmd.AddCommand(networkForwardCmd.Command())
+
 	// Get
 	networkGetCmd := cmdNetworkGet{global: c.global, network: c}
 	cmd.AddCommand(networkGetCmd.Command())
@@ -77,10 +85,18 @@ func (c *cmdNetwork) Command() *cobra.Command {
 	networkListLeasesCmd := cmdNetworkListLeases{global: c.global, network: c}
 	cmd.AddCommand(networkListLeasesCmd.Command())
 
+	// Peer
+	networkPeerCmd := cmdNetworkPeer{global: c.global}
+	cmd.AddCommand(networkPeerCmd.Command())
+
 	// Rename
 	networkRenameCmd := cmdNetworkRename{global: c.global, network: c}
 	cmd.AddCommand(networkRenameCmd.Command())
 
+	// Reservation
+	networkReservationCmd := cmdNetworkReservation{global: c.global}
+	cmd.AddCommand(networkReservationCmd.Command())
+
 	// Set
 	networkSetCmd := cmdNetworkSet{global: c.global, network: c}
 	cmd.AddCommand(networkSetCmd.Command())
@@ -93,6 +109,10 @@ func (c *cmdNetwork) Command() *cobra.Command {
 	networkUnsetCmd := cmdNetworkUnset{global: c.global, network: c, networkSet: &networkSetCmd}
 	cmd.AddCommand(networkUnsetCmd.Command())
 
+	// Zone
+	networkZoneCmd := cmdNetworkZone{global: c.global}
+	cmd.AddCommand(networkZoneCmd.Command())
+
 	return cmd
 }
 
@@ -721,6 +741,8 @@ func (c *cmdNetworkGet) Run(cmd *cobra.Command, args []string) error {
 type cmdNetworkInfo struct {
 	global  *cmdGlobal
 	network *cmdNetwork
+
+	flagFormat string
 }
 
 func (c *cmdNetworkInfo) Command() *cobra.Command {
@@ -731,6 +753,7 @@ func (c *cmdNetworkInfo) Command() *cobra.Command {
 		`Get runtime information on networks`))
 
 	cmd.Flags().StringVar(&c.network.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
+	cmd.Flags().StringVar(&c.flagFormat, "format", "", i18n.G("Format (json|yaml)")+"``")
 	cmd.RunE = c.Run
 
 	return cmd
@@ -770,6 +793,10 @@ func (c *cmdNetworkInfo) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if c.flagFormat != "" {
+		return utils.RenderData(c.flagFormat, state)
+	}
+
 	// Interface information
 	fmt.Printf(i18n.G("Name: %s")+"\n", resource.name)
 	fmt.Printf(i18n.G("MAC address: %s")+"\n", state.Hwaddr)
@@ -783,6 +810,54 @@ func (c *cmdNetworkInfo) Run(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  %s\t%s\n", addr.Family, addr.Address)
 	}
 
+	// Bond information
+	if state.Bond != nil {
+		fmt.Println("")
+		fmt.Println(i18n.G("Bond:"))
+		fmt.Printf("  %s: %s\n", i18n.G("Mode"), state.Bond.Mode)
+		fmt.Printf("  %s: %s\n", i18n.G("MII state"), state.Bond.MIIState)
+		fmt.Printf("  %s:\n", i18n.G("Lower devices"))
+		for _, lowerDevice := range state.Bond.LowerDevices {
+			fmt.Printf("    %s\t%s\n", lowerDevice, state.Bond.LowerDevicesMIIState[lowerDevice])
+		}
+	}
+
+	// Bridge information
+	if state.Bridge != nil {
+		fmt.Println("")
+		fmt.Println(i18n.G("Bridge:"))
+		fmt.Printf("  %s: %s\n", i18n.G("ID"), state.Bridge.ID)
+		fmt.Printf("  %s: %v\n", i18n.G("STP"), state.Bridge.STP)
+		fmt.Printf("  %s: %v\n", i18n.G("VLAN filtering"), state.Bridge.VLANFiltering)
+		fmt.Printf("  %s: %v\n", i18n.G("Multicast snooping"), state.Bridge.MulticastSnooping)
+		fmt.Printf("  %s:\n", i18n.G("Upper devices"))
+		for _, upperDevice := range state.Bridge.UpperDevices {
+			vlans := []string{}
+			for _, vlan := range state.Bridge.UpperDevicesVLANs[upperDevice] {
+				vlans = append(vlans, fmt.Sprintf("%d", vlan.ID))
+			}
+
+			fmt.Printf("    %s\t%s\n", upperDevice, strings.Join(vlans, ","))
+		}
+	}
+
+	// VLAN information
+	if state.VLAN != nil {
+		fmt.Println("")
+		fmt.Println(i18n.G("VLAN:"))
+		fmt.Printf("  %s: %s\n", i18n.G("Lower device"), state.VLAN.LowerDevice)
+		fmt.Printf("  %s: %d\n", i18n.G("VLAN ID"), state.VLAN.VID)
+	}
+
+	// Open vSwitch information
+	if state.OVS != nil {
+		fmt.Println("")
+		fmt.Println(i18n.G("Open vSwitch ports:"))
+		for _, port := range state.OVS.Ports {
+			fmt.Printf("  %s\t%d\n", port.Name, port.VLANTag)
+		}
+	}
+
 	// Network usage
 	fmt.Println("")
 	fmt.Println(i18n.G("Network usage:"))
@@ -800,6 +875,7 @@ type cmdNetworkList struct {
 	network *cmdNetwork
 
 	flagFormat string
+	flagFull   bool
 }
 
 func (c *cmdNetworkList) Command() *cobra.Command {
@@ -812,6 +888,7 @@ func (c *cmdNetworkList) Command() *cobra.Command {
 
 	cmd.RunE = c.Run
 	cmd.Flags().StringVar(&c.flagFormat, "format", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+	cmd.Flags().BoolVar(&c.flagFull, "full", false, i18n.G("Show the state and leases of the networks"))
 
 	return cmd
 }
@@ -841,13 +918,26 @@ func (c *cmdNetworkList) Run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf(i18n.G("Filtering isn't supported yet"))
 	}
 
-	networks, err := resource.server.GetNetworks()
-	if err != nil {
-		return err
+	var networks []api.Network
+	var networksFull []api.NetworkFull
+	if c.flagFull {
+		networksFull, err = resource.server.GetNetworksFull()
+		if err != nil {
+			return err
+		}
+
+		for _, network := range networksFull {
+			networks = append(networks, network.Network)
+		}
+	} else {
+		networks, err = resource.server.GetNetworks()
+		if err != nil {
+			return err
+		}
 	}
 
 	data := [][]string{}
-	for _, network := range networks {
+	for i, network := range networks {
 		if shared.StringInSlice(network.Type, []string{"loopback", "unknown"}) {
 			continue
 		}
@@ -870,6 +960,19 @@ func (c *cmdNetworkList) Run(cmd *cobra.Command, args []string) error {
 		if resource.server.IsClustered() {
 			details = append(details, strings.ToUpper(network.Status))
 		}
+
+		if c.flagFull {
+			up := 0
+			for _, state := range networksFull[i].State {
+				if state.State == "up" {
+					up++
+				}
+			}
+
+			leases := networksFull[i].Leases
+			details = append(details, fmt.Sprintf("%d/%d", up, len(networksFull[i].State)), fmt.Sprintf("%d", leases.Static+leases.Dynamic))
+		}
+
 		data = append(data, details)
 	}
 	sort.Sort(byName(data))
@@ -887,6 +990,12 @@ func (c *cmdNetworkList) Run(cmd *cobra.Command, args []string) error {
 		header = append(header, i18n.G("STATE"))
 	}
 
+	if c.flagFull {
+		header = append(header, i18n.G("UP"), i18n.G("LEASES"))
+
+		return utils.RenderTable(c.flagFormat, header, data, networksFull)
+	}
+
 	return utils.RenderTable(c.flagFormat, header, data, networks)
 }
 
@@ -936,9 +1045,16 @@ func (c *cmdNetworkListLeases) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	const layout = "2006/01/02 15:04 UTC"
+
 	data := [][]string{}
 	for _, lease := range leases {
-		entry := []string{lease.Hostname, lease.Hwaddr, lease.Address, strings.ToUpper(lease.Type)}
+		expiry := ""
+		if !lease.ExpiresAt.IsZero() {
+			expiry = lease.ExpiresAt.UTC().Format(layout)
+		}
+
+		entry := []string{lease.Hostname, lease.Hwaddr, lease.Address, strings.ToUpper(lease.Type), expiry}
 		if resource.server.IsClustered() {
 			entry = append(entry, lease.Location)
 		}
@@ -952,6 +1068,7 @@ func (c *cmdNetworkListLeases) Run(cmd *cobra.Command, args []string) error {
 		i18n.G("MAC ADDRESS"),
 		i18n.G("IP ADDRESS"),
 		i18n.G("TYPE"),
+		i18n.G("EXPIRES"),
 	}
 	if resource.server.IsClustered() {
 		header = append(header, i18n.G("LOCATION"))
@@ -0,0 +1,852 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/lxc/utils"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+	"github.com/lxc/lxd/shared/termios"
+)
+
+type cmdNetworkACL struct {
+	global *cmdGlobal
+}
+
+func (c *cmdNetworkACL) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("acl")
+	cmd.Short = i18n.G("Manage network ACLs")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage network ACLs`))
+
+	// Create
+	networkACLCreateCmd := cmdNetworkACLCreate{global: c.global, networkACL: c}
+	cmd.AddCommand(networkACLCreateCmd.Command())
+
+	// Delete
+	networkACLDeleteCmd := cmdNetworkACLDelete{global: c.global, networkACL: c}
+	cmd.AddCommand(networkACLDeleteCmd.Command())
+
+	// Edit
+	networkACLEditCmd := cmdNetworkACLEdit{global: c.global, networkACL: c}
+	cmd.AddCommand(networkACLEditCmd.Command())
+
+	// Get
+	networkACLGetCmd := cmdNetworkACLGet{global: c.global, networkACL: c}
+	cmd.AddCommand(networkACLGetCmd.Command())
+
+	// List
+	networkACLListCmd := cmdNetworkACLList{global: c.global, networkACL: c}
+	cmd.AddCommand(networkACLListCmd.Command())
+
+	// Rename
+	networkACLRenameCmd := cmdNetworkACLRename{global: c.global, networkACL: c}
+	cmd.AddCommand(networkACLRenameCmd.Command())
+
+	// Rule
+	networkACLRuleCmd := cmdNetworkACLRule{global: c.global, networkACL: c}
+	cmd.AddCommand(networkACLRuleCmd.Command())
+
+	// Set
+	networkACLSetCmd := cmdNetworkACLSet{global: c.global, networkACL: c}
+	cmd.AddCommand(networkACLSetCmd.Command())
+
+	// Show
+	networkACLShowCmd := cmdNetworkACLShow{global: c.global, networkACL: c}
+	cmd.AddCommand(networkACLShowCmd.Command())
+
+	// Unset
+	networkACLUnsetCmd := cmdNetworkACLUnset{global: c.global, networkACL: c, networkACLSet: &networkACLSetCmd}
+	cmd.AddCommand(networkACLUnsetCmd.Command())
+
+	return cmd
+}
+
+// parseArgs parses the remote and ACL name.
+func (c *cmdNetworkACL) parseArgs(args []string) (*remoteResource, error) {
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return nil, fmt.Errorf(i18n.G("Missing network ACL name"))
+	}
+
+	return &resource, nil
+}
+
+// List
+type cmdNetworkACLList struct {
+	global     *cmdGlobal
+	networkACL *cmdNetworkACL
+
+	flagFormat string
+}
+
+func (c *cmdNetworkACLList) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("list [<remote>:]")
+	cmd.Aliases = []string{"ls"}
+	cmd.Short = i18n.G("List available network ACLs")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`List available network ACLs`))
+
+	cmd.RunE = c.Run
+	cmd.Flags().StringVar(&c.flagFormat, "format", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+
+	return cmd
+}
+
+func (c *cmdNetworkACLList) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 0, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	remote := ""
+	if len(args) > 0 {
+		remote = args[0]
+	}
+
+	resources, err := c.global.ParseServers(remote)
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name != "" {
+		return fmt.Errorf(i18n.G("Filtering isn't supported yet"))
+	}
+
+	acls, err := resource.server.GetNetworkACLs()
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	for _, acl := range acls {
+		details := []string{
+			acl.Name,
+			acl.Description,
+			fmt.Sprintf("%d", len(acl.Ingress)),
+			fmt.Sprintf("%d", len(acl.Egress)),
+			fmt.Sprintf("%d", len(acl.UsedBy)),
+		}
+
+		data = append(data, details)
+	}
+	sort.Sort(byName(data))
+
+	header := []string{
+		i18n.G("NAME"),
+		i18n.G("DESCRIPTION"),
+		i18n.G("INGRESS RULES"),
+		i18n.G("EGRESS RULES"),
+		i18n.G("USED BY"),
+	}
+
+	return utils.RenderTable(c.flagFormat, header, data, acls)
+}
+
+// Show
+type cmdNetworkACLShow struct {
+	global     *cmdGlobal
+	networkACL *cmdNetworkACL
+}
+
+func (c *cmdNetworkACLShow) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("show [<remote>:]<ACL>")
+	cmd.Short = i18n.G("Show network ACL configurations")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show network ACL configurations`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkACLShow) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	resource, err := c.networkACL.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	acl, _, err := resource.server.GetNetworkACL(resource.name)
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(acl.UsedBy)
+
+	data, err := yaml.Marshal(&acl)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+
+	return nil
+}
+
+// Create
+type cmdNetworkACLCreate struct {
+	global     *cmdGlobal
+	networkACL *cmdNetworkACL
+}
+
+func (c *cmdNetworkACLCreate) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("create [<remote>:]<ACL> [key=value...]")
+	cmd.Short = i18n.G("Create new network ACLs")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Create new network ACLs`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc network acl create a1
+
+lxc network acl create a1 < config.yaml
+    Create network acl with configuration from config.yaml`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkACLCreate) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, -1)
+	if exit {
+		return err
+	}
+
+	resource, err := c.networkACL.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// Create the network ACL
+	acl := api.NetworkACLsPost{
+		NetworkACLPost: api.NetworkACLPost{
+			Name: resource.name,
+		},
+	}
+
+	// If stdin isn't a terminal, read the ACL definition from it
+	if !termios.IsTerminal(getStdinFd()) {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		err = yaml.Unmarshal(contents, &acl.NetworkACLPut)
+		if err != nil {
+			return err
+		}
+	}
+
+	if acl.Config == nil {
+		acl.Config = map[string]string{}
+	}
+
+	for i := 1; i < len(args); i++ {
+		entry := strings.SplitN(args[i], "=", 2)
+		if len(entry) < 2 {
+			return fmt.Errorf(i18n.G("Bad key/value pair: %s"), args[i])
+		}
+
+		acl.Config[entry[0]] = entry[1]
+	}
+
+	err = resource.server.CreateNetworkACL(acl)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Network ACL %s created")+"\n", resource.name)
+	}
+
+	return nil
+}
+
+// Get
+type cmdNetworkACLGet struct {
+	global     *cmdGlobal
+	networkACL *cmdNetworkACL
+}
+
+func (c *cmdNetworkACLGet) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("get [<remote>:]<ACL> <key>")
+	cmd.Short = i18n.G("Get values for network ACL configuration keys")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Get values for network ACL configuration keys`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkACLGet) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	resource, err := c.networkACL.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	acl, _, err := resource.server.GetNetworkACL(resource.name)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range acl.Config {
+		if k == args[1] {
+			fmt.Printf("%s\n", v)
+		}
+	}
+
+	return nil
+}
+
+// Set
+type cmdNetworkACLSet struct {
+	global     *cmdGlobal
+	networkACL *cmdNetworkACL
+}
+
+func (c *cmdNetworkACLSet) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("set [<remote>:]<ACL> <key>=<value>...")
+	cmd.Short = i18n.G("Set network ACL configuration keys")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Set network ACL configuration keys`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkACLSet) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, -1)
+	if exit {
+		return err
+	}
+
+	resource, err := c.networkACL.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// Get the network ACL
+	acl, etag, err := resource.server.GetNetworkACL(resource.name)
+	if err != nil {
+		return err
+	}
+
+	// Set the keys
+	keys, err := getConfig(args[1:]...)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range keys {
+		acl.Config[k] = v
+	}
+
+	return resource.server.UpdateNetworkACL(resource.name, acl.Writable(), etag)
+}
+
+// Unset
+type cmdNetworkACLUnset struct {
+	global        *cmdGlobal
+	networkACL    *cmdNetworkACL
+	networkACLSet *cmdNetworkACLSet
+}
+
+func (c *cmdNetworkACLUnset) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("unset [<remote>:]<ACL> <key>")
+	cmd.Short = i18n.G("Unset network ACL configuration keys")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Unset network ACL configuration keys`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkACLUnset) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	args = append(args, "")
+	return c.networkACLSet.Run(cmd, args)
+}
+
+// Edit
+type cmdNetworkACLEdit struct {
+	global     *cmdGlobal
+	networkACL *cmdNetworkACL
+}
+
+func (c *cmdNetworkACLEdit) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("edit [<remote>:]<ACL>")
+	cmd.Short = i18n.G("Edit network ACL configurations as YAML")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Edit network ACL configurations as YAML`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkACLEdit) helpTemplate() string {
+	return i18n.G(
+		`### This is a YAML representation of the network ACL.
+### Any line starting with a '# will be ignored.
+###
+### A network ACL consists of a set of rules and configuration items.
+###
+### An example would look like:
+### name: allow-all-inbound
+### description: test desc
+### egress: []
+### ingress:
+### - action: allow
+###   state: enabled
+###   protocol: tcp
+###   source: 192.0.2.0/24
+###   destination_port: 80,443
+###   description: test desc
+### config:
+###   user.foo: bah
+###
+### Note that only the ingress and egress rules, description and configuration keys can be changed.`)
+}
+
+func (c *cmdNetworkACLEdit) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	resource, err := c.networkACL.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// If stdin isn't a terminal, read text from it
+	if !termios.IsTerminal(getStdinFd()) {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		newdata := api.NetworkACLPut{}
+		err = yaml.Unmarshal(contents, &newdata)
+		if err != nil {
+			return err
+		}
+
+		return resource.server.UpdateNetworkACL(resource.name, newdata, "")
+	}
+
+	// Extract the current value
+	acl, etag, err := resource.server.GetNetworkACL(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&acl)
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor
+	content, err := shared.TextEditor("", []byte(c.helpTemplate()+"\n\n"+string(data)))
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Parse the text received from the editor
+		newdata := api.NetworkACLPut{}
+		err = yaml.Unmarshal(content, &newdata)
+		if err == nil {
+			err = resource.server.UpdateNetworkACL(resource.name, newdata, etag)
+		}
+
+		// Respawn the editor
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Config parsing error: %s")+"\n", err)
+			fmt.Println(i18n.G("Press enter to open the editor again"))
+
+			_, err := os.Stdin.Read(make([]byte, 1))
+			if err != nil {
+				return err
+			}
+
+			content, err = shared.TextEditor("", content)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		break
+	}
+	return nil
+}
+
+// Rename
+type cmdNetworkACLRename struct {
+	global     *cmdGlobal
+	networkACL *cmdNetworkACL
+}
+
+func (c *cmdNetworkACLRename) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("rename [<remote>:]<ACL> <new-name>")
+	cmd.Aliases = []string{"mv"}
+	cmd.Short = i18n.G("Rename network ACLs")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Rename network ACLs`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkACLRename) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	resource, err := c.networkACL.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	err = resource.server.RenameNetworkACL(resource.name, api.NetworkACLPost{Name: args[1]})
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Network ACL %s renamed to %s")+"\n", resource.name, args[1])
+	}
+
+	return nil
+}
+
+// Delete
+type cmdNetworkACLDelete struct {
+	global     *cmdGlobal
+	networkACL *cmdNetworkACL
+}
+
+func (c *cmdNetworkACLDelete) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("delete [<remote>:]<ACL>")
+	cmd.Aliases = []string{"rm"}
+	cmd.Short = i18n.G("Delete network ACLs")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Delete network ACLs`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkACLDelete) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	resource, err := c.networkACL.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	err = resource.server.DeleteNetworkACL(resource.name)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Network ACL %s deleted")+"\n", resource.name)
+	}
+
+	return nil
+}
+
+// Rule
+type cmdNetworkACLRule struct {
+	global     *cmdGlobal
+	networkACL *cmdNetworkACL
+}
+
+func (c *cmdNetworkACLRule) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("rule")
+	cmd.Short = i18n.G("Manage network ACL rules")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage network ACL rules`))
+
+	// Rule Add
+	networkACLRuleAddCmd := cmdNetworkACLRuleAdd{global: c.global, networkACL: c.networkACL, networkACLRule: c}
+	cmd.AddCommand(networkACLRuleAddCmd.Command())
+
+	// Rule Remove
+	networkACLRuleRemoveCmd := cmdNetworkACLRuleRemove{global: c.global, networkACL: c.networkACL, networkACLRule: c}
+	cmd.AddCommand(networkACLRuleRemoveCmd.Command())
+
+	return cmd
+}
+
+// parseConfigToRule converts the key=value arguments into a rule struct.
+func (c *cmdNetworkACLRule) parseConfigToRule(args []string) (*api.NetworkACLRule, error) {
+	keys, err := getConfig(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := api.NetworkACLRule{}
+	for k, v := range keys {
+		switch k {
+		case "action":
+			rule.Action = v
+		case "source":
+			rule.Source = v
+		case "destination":
+			rule.Destination = v
+		case "protocol":
+			rule.Protocol = v
+		case "source_port":
+			rule.SourcePort = v
+		case "destination_port":
+			rule.DestinationPort = v
+		case "icmp_type":
+			rule.ICMPType = v
+		case "icmp_code":
+			rule.ICMPCode = v
+		case "description":
+			rule.Description = v
+		case "state":
+			rule.State = v
+		default:
+			return nil, fmt.Errorf(i18n.G("Unknown key: %s"), k)
+		}
+	}
+
+	return &rule, nil
+}
+
+// rules returns a pointer to the rules of the given direction.
+func (c *cmdNetworkACLRule) rules(acl *api.NetworkACL, direction string) (*[]api.NetworkACLRule, error) {
+	switch direction {
+	case "ingress":
+		return &acl.Ingress, nil
+	case "egress":
+		return &acl.Egress, nil
+	}
+
+	return nil, fmt.Errorf(i18n.G("The direction argument must be one of: ingress, egress"))
+}
+
+// Rule Add
+type cmdNetworkACLRuleAdd struct {
+	global         *cmdGlobal
+	networkACL     *cmdNetworkACL
+	networkACLRule *cmdNetworkACLRule
+}
+
+func (c *cmdNetworkACLRuleAdd) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("add [<remote>:]<ACL> <direction> <key>=<value>...")
+	cmd.Short = i18n.G("Add rules to an ACL")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Add rules to an ACL`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc network acl rule add web ingress action=allow protocol=tcp destination_port=80,443
+    Allow inbound HTTP and HTTPS traffic.`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkACLRuleAdd) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 3, -1)
+	if exit {
+		return err
+	}
+
+	resource, err := c.networkACL.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// Get the network ACL
+	acl, etag, err := resource.server.GetNetworkACL(resource.name)
+	if err != nil {
+		return err
+	}
+
+	rules, err := c.networkACLRule.rules(acl, args[1])
+	if err != nil {
+		return err
+	}
+
+	rule, err := c.networkACLRule.parseConfigToRule(args[2:])
+	if err != nil {
+		return err
+	}
+
+	if rule.State == "" {
+		rule.State = "enabled"
+	}
+
+	*rules = append(*rules, *rule)
+
+	return resource.server.UpdateNetworkACL(resource.name, acl.Writable(), etag)
+}
+
+// Rule Remove
+type cmdNetworkACLRuleRemove struct {
+	global         *cmdGlobal
+	networkACL     *cmdNetworkACL
+	networkACLRule *cmdNetworkACLRule
+
+	flagRemoveForce bool
+}
+
+func (c *cmdNetworkACLRuleRemove) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("remove [<remote>:]<ACL> <direction> [<key>=<value>...]")
+	cmd.Short = i18n.G("Remove rules from an ACL")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Remove rules from an ACL`))
+
+	cmd.Flags().BoolVar(&c.flagRemoveForce, "force", false, i18n.G("Remove all rules that match"))
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkACLRuleRemove) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, -1)
+	if exit {
+		return err
+	}
+
+	resource, err := c.networkACL.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// Get the network ACL
+	acl, etag, err := resource.server.GetNetworkACL(resource.name)
+	if err != nil {
+		return err
+	}
+
+	rules, err := c.networkACLRule.rules(acl, args[1])
+	if err != nil {
+		return err
+	}
+
+	filters, err := getConfig(args[2:]...)
+	if err != nil {
+		return err
+	}
+
+	// Validate the filter keys.
+	_, err = c.networkACLRule.parseConfigToRule(args[2:])
+	if err != nil {
+		return err
+	}
+
+	// Keep the rules which don't match the filters.
+	removed := 0
+	keep := []api.NetworkACLRule{}
+	for _, rule := range *rules {
+		ruleValues := map[string]string{
+			"action":           rule.Action,
+			"source":           rule.Source,
+			"destination":      rule.Destination,
+			"protocol":         rule.Protocol,
+			"source_port":      rule.SourcePort,
+			"destination_port": rule.DestinationPort,
+			"icmp_type":        rule.ICMPType,
+			"icmp_code":        rule.ICMPCode,
+			"description":      rule.Description,
+			"state":            rule.State,
+		}
+
+		match := true
+		for k, v := range filters {
+			if ruleValues[k] != v {
+				match = false
+				break
+			}
+		}
+
+		if !match {
+			keep = append(keep, rule)
+			continue
+		}
+
+		removed++
+	}
+
+	if removed == 0 {
+		return fmt.Errorf(i18n.G("No matching rule(s) found"))
+	}
+
+	if removed > 1 && !c.flagRemoveForce {
+		return fmt.Errorf(i18n.G("Multiple rules match. Use --force to remove them all"))
+	}
+
+	*rules = keep
+
+	return resource.server.UpdateNetworkACL(resource.name, acl.Writable(), etag)
+}
@@ -0,0 +1,707 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/lxc/utils"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+	"github.com/lxc/lxd/shared/termios"
+)
+
+type cmdNetworkForward struct {
+	global *cmdGlobal
+}
+
+func (c *cmdNetworkForward) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("forward")
+	cmd.Short = i18n.G("Manage network forwards")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage network forwards`))
+
+	// Create
+	networkForwardCreateCmd := cmdNetworkForwardCreate{global: c.global, networkForward: c}
+	cmd.AddCommand(networkForwardCreateCmd.Command())
+
+	// Delete
+	networkForwardDeleteCmd := cmdNetworkForwardDelete{global: c.global, networkForward: c}
+	cmd.AddCommand(networkForwardDeleteCmd.Command())
+
+	// Edit
+	networkForwardEditCmd := cmdNetworkForwardEdit{global: c.global, networkForward: c}
+	cmd.AddCommand(networkForwardEditCmd.Command())
+
+	// Get
+	networkForwardGetCmd := cmdNetworkForwardGet{global: c.global, networkForward: c}
+	cmd.AddCommand(networkForwardGetCmd.Command())
+
+	// List
+	networkForwardListCmd := cmdNetworkForwardList{global: c.global, networkForward: c}
+	cmd.AddCommand(networkForwardListCmd.Command())
+
+	// Port
+	networkForwardPortCmd := cmdNetworkForwardPort{global: c.global, networkForward: c}
+	cmd.AddCommand(networkForwardPortCmd.Command())
+
+	// Set
+	networkForwardSetCmd := cmdNetworkForwardSet{global: c.global, networkForward: c}
+	cmd.AddCommand(networkForwardSetCmd.Command())
+
+	// Show
+	networkForwardShowCmd := cmdNetworkForwardShow{global: c.global, networkForward: c}
+	cmd.AddCommand(networkForwardShowCmd.Command())
+
+	// Unset
+	networkForwardUnsetCmd := cmdNetworkForwardUnset{global: c.global, networkForward: c, networkForwardSet: &networkForwardSetCmd}
+	cmd.AddCommand(networkForwardUnsetCmd.Command())
+
+	return cmd
+}
+
+// parseArgs parses the remote and network name as well as the listen address.
+func (c *cmdNetworkForward) parseArgs(args []string) (*remoteResource, string, error) {
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return nil, "", err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return nil, "", fmt.Errorf(i18n.G("Missing network name"))
+	}
+
+	if len(args) < 2 || args[1] == "" {
+		return &resource, "", fmt.Errorf(i18n.G("Missing listen address"))
+	}
+
+	return &resource, args[1], nil
+}
+
+// List
+type cmdNetworkForwardList struct {
+	global         *cmdGlobal
+	networkForward *cmdNetworkForward
+
+	flagFormat string
+}
+
+func (c *cmdNetworkForwardList) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("list [<remote>:]<network>")
+	cmd.Aliases = []string{"ls"}
+	cmd.Short = i18n.G("List available network forwards")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`List available network forwards`))
+
+	cmd.RunE = c.Run
+	cmd.Flags().StringVar(&c.flagFormat, "format", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+
+	return cmd
+}
+
+func (c *cmdNetworkForwardList) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing network name"))
+	}
+
+	forwards, err := resource.server.GetNetworkForwards(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	for _, forward := range forwards {
+		details := []string{
+			forward.ListenAddress,
+			forward.Description,
+			forward.Config["target_address"],
+			fmt.Sprintf("%d", len(forward.Ports)),
+		}
+
+		data = append(data, details)
+	}
+	sort.Sort(byName(data))
+
+	header := []string{
+		i18n.G("LISTEN ADDRESS"),
+		i18n.G("DESCRIPTION"),
+		i18n.G("DEFAULT TARGET ADDRESS"),
+		i18n.G("PORTS"),
+	}
+
+	return utils.RenderTable(c.flagFormat, header, data, forwards)
+}
+
+// Show
+type cmdNetworkForwardShow struct {
+	global         *cmdGlobal
+	networkForward *cmdNetworkForward
+}
+
+func (c *cmdNetworkForwardShow) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("show [<remote>:]<network> <listen_address>")
+	cmd.Short = i18n.G("Show network forward configurations")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show network forward configurations`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkForwardShow) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	resource, listenAddress, err := c.networkForward.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	forward, _, err := resource.server.GetNetworkForward(resource.name, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&forward)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+
+	return nil
+}
+
+// Create
+type cmdNetworkForwardCreate struct {
+	global         *cmdGlobal
+	networkForward *cmdNetworkForward
+}
+
+func (c *cmdNetworkForwardCreate) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("create [<remote>:]<network> <listen_address> [key=value...]")
+	cmd.Short = i18n.G("Create new network forwards")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Create new network forwards`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc network forward create lxdbr0 192.0.2.1 target_address=10.0.0.2
+    Forward all the traffic of 192.0.2.1 to 10.0.0.2.`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkForwardCreate) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, -1)
+	if exit {
+		return err
+	}
+
+	resource, listenAddress, err := c.networkForward.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// Create the network forward
+	forward := api.NetworkForwardsPost{
+		ListenAddress: listenAddress,
+	}
+
+	// If stdin isn't a terminal, read the forward definition from it
+	if !termios.IsTerminal(getStdinFd()) {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		err = yaml.Unmarshal(contents, &forward.NetworkForwardPut)
+		if err != nil {
+			return err
+		}
+	}
+
+	if forward.Config == nil {
+		forward.Config = map[string]string{}
+	}
+
+	for i := 2; i < len(args); i++ {
+		entry := strings.SplitN(args[i], "=", 2)
+		if len(entry) < 2 {
+			return fmt.Errorf(i18n.G("Bad key/value pair: %s"), args[i])
+		}
+
+		forward.Config[entry[0]] = entry[1]
+	}
+
+	err = resource.server.CreateNetworkForward(resource.name, forward)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Network forward %s created")+"\n", listenAddress)
+	}
+
+	return nil
+}
+
+// Get
+type cmdNetworkForwardGet struct {
+	global         *cmdGlobal
+	networkForward *cmdNetworkForward
+}
+
+func (c *cmdNetworkForwardGet) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("get [<remote>:]<network> <listen_address> <key>")
+	cmd.Short = i18n.G("Get values for network forward configuration keys")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Get values for network forward configuration keys`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkForwardGet) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 3, 3)
+	if exit {
+		return err
+	}
+
+	resource, listenAddress, err := c.networkForward.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	forward, _, err := resource.server.GetNetworkForward(resource.name, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range forward.Config {
+		if k == args[2] {
+			fmt.Printf("%s\n", v)
+		}
+	}
+
+	return nil
+}
+
+// Set
+type cmdNetworkForwardSet struct {
+	global         *cmdGlobal
+	networkForward *cmdNetworkForward
+}
+
+func (c *cmdNetworkForwardSet) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("set [<remote>:]<network> <listen_address> <key>=<value>...")
+	cmd.Short = i18n.G("Set network forward keys")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Set network forward keys`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkForwardSet) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 3, -1)
+	if exit {
+		return err
+	}
+
+	resource, listenAddress, err := c.networkForward.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// Get the network forward
+	forward, etag, err := resource.server.GetNetworkForward(resource.name, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	// Set the keys
+	keys, err := getConfig(args[2:]...)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range keys {
+		forward.Config[k] = v
+	}
+
+	return resource.server.UpdateNetworkForward(resource.name, listenAddress, forward.Writable(), etag)
+}
+
+// Unset
+type cmdNetworkForwardUnset struct {
+	global            *cmdGlobal
+	networkForward    *cmdNetworkForward
+	networkForwardSet *cmdNetworkForwardSet
+}
+
+func (c *cmdNetworkForwardUnset) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("unset [<remote>:]<network> <listen_address> <key>")
+	cmd.Short = i18n.G("Unset network forward keys")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Unset network forward keys`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkForwardUnset) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 3, 3)
+	if exit {
+		return err
+	}
+
+	args = append(args, "")
+	return c.networkForwardSet.Run(cmd, args)
+}
+
+// Edit
+type cmdNetworkForwardEdit struct {
+	global         *cmdGlobal
+	networkForward *cmdNetworkForward
+}
+
+func (c *cmdNetworkForwardEdit) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("edit [<remote>:]<network> <listen_address>")
+	cmd.Short = i18n.G("Edit network forward configurations as YAML")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Edit network forward configurations as YAML`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkForwardEdit) helpTemplate() string {
+	return i18n.G(
+		`### This is a YAML representation of the network forward.
+### Any line starting with a '# will be ignored.
+###
+### A network forward consists of a default target address and optional set of port forwards for a listen address.
+###
+### An example would look like:
+### listen_address: 192.0.2.1
+### config:
+###   target_address: 10.0.0.2
+### description: test desc
+### ports:
+### - description: port forward
+###   protocol: tcp
+###   listen_port: 80,81,8080-8090
+###   target_address: 10.0.0.3
+###   target_port: 80,81,8080-8090
+###
+### Note that the listen_address cannot be changed.`)
+}
+
+func (c *cmdNetworkForwardEdit) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	resource, listenAddress, err := c.networkForward.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// If stdin isn't a terminal, read text from it
+	if !termios.IsTerminal(getStdinFd()) {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		newdata := api.NetworkForwardPut{}
+		err = yaml.Unmarshal(contents, &newdata)
+		if err != nil {
+			return err
+		}
+
+		return resource.server.UpdateNetworkForward(resource.name, listenAddress, newdata, "")
+	}
+
+	// Extract the current value
+	forward, etag, err := resource.server.GetNetworkForward(resource.name, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&forward)
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor
+	content, err := shared.TextEditor("", []byte(c.helpTemplate()+"\n\n"+string(data)))
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Parse the text received from the editor
+		newdata := api.NetworkForwardPut{}
+		err = yaml.Unmarshal(content, &newdata)
+		if err == nil {
+			err = resource.server.UpdateNetworkForward(resource.name, listenAddress, newdata, etag)
+		}
+
+		// Respawn the editor
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Config parsing error: %s")+"\n", err)
+			fmt.Println(i18n.G("Press enter to open the editor again"))
+
+			_, err := os.Stdin.Read(make([]byte, 1))
+			if err != nil {
+				return err
+			}
+
+			content, err = shared.TextEditor("", content)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		break
+	}
+	return nil
+}
+
+// Delete
+type cmdNetworkForwardDelete struct {
+	global         *cmdGlobal
+	networkForward *cmdNetworkForward
+}
+
+func (c *cmdNetworkForwardDelete) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("delete [<remote>:]<network> <listen_address>")
+	cmd.Aliases = []string{"rm"}
+	cmd.Short = i18n.G("Delete network forwards")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Delete network forwards`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkForwardDelete) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	resource, listenAddress, err := c.networkForward.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	err = resource.server.DeleteNetworkForward(resource.name, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Network forward %s deleted")+"\n", listenAddress)
+	}
+
+	return nil
+}
+
+// Port
+type cmdNetworkForwardPort struct {
+	global         *cmdGlobal
+	networkForward *cmdNetworkForward
+}
+
+func (c *cmdNetworkForwardPort) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("port")
+	cmd.Short = i18n.G("Manage network forward ports")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage network forward ports`))
+
+	// Port Add
+	networkForwardPortAddCmd := cmdNetworkForwardPortAdd{global: c.global, networkForward: c.networkForward}
+	cmd.AddCommand(networkForwardPortAddCmd.Command())
+
+	// Port Remove
+	networkForwardPortRemoveCmd := cmdNetworkForwardPortRemove{global: c.global, networkForward: c.networkForward}
+	cmd.AddCommand(networkForwardPortRemoveCmd.Command())
+
+	return cmd
+}
+
+// Port Add
+type cmdNetworkForwardPortAdd struct {
+	global         *cmdGlobal
+	networkForward *cmdNetworkForward
+
+	flagDescription string
+}
+
+func (c *cmdNetworkForwardPortAdd) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("add [<remote>:]<network> <listen_address> <protocol> <listen_port(s)> <target_address> [<target_port(s)>]")
+	cmd.Short = i18n.G("Add ports to a forward")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Add ports to a forward`))
+
+	cmd.Flags().StringVar(&c.flagDescription, "description", "", i18n.G("Port description")+"``")
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkForwardPortAdd) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 5, 6)
+	if exit {
+		return err
+	}
+
+	resource, listenAddress, err := c.networkForward.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// Get the network forward
+	forward, etag, err := resource.server.GetNetworkForward(resource.name, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	port := api.NetworkForwardPort{
+		Description:   c.flagDescription,
+		Protocol:      args[2],
+		ListenPort:    args[3],
+		TargetAddress: args[4],
+	}
+
+	if len(args) > 5 {
+		port.TargetPort = args[5]
+	}
+
+	forward.Ports = append(forward.Ports, port)
+
+	return resource.server.UpdateNetworkForward(resource.name, listenAddress, forward.Writable(), etag)
+}
+
+// Port Remove
+type cmdNetworkForwardPortRemove struct {
+	global         *cmdGlobal
+	networkForward *cmdNetworkForward
+
+	flagRemoveForce bool
+}
+
+func (c *cmdNetworkForwardPortRemove) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("remove [<remote>:]<network> <listen_address> [<protocol>] [<listen_port(s)>]")
+	cmd.Short = i18n.G("Remove ports from a forward")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Remove ports from a forward`))
+
+	cmd.Flags().BoolVar(&c.flagRemoveForce, "force", false, i18n.G("Remove all ports that match"))
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkForwardPortRemove) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 4)
+	if exit {
+		return err
+	}
+
+	resource, listenAddress, err := c.networkForward.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// Get the network forward
+	forward, etag, err := resource.server.GetNetworkForward(resource.name, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	// Keep the ports which don't match the filters.
+	removed := 0
+	ports := []api.NetworkForwardPort{}
+	for _, port := range forward.Ports {
+		if len(args) > 2 && port.Protocol != args[2] {
+			ports = append(ports, port)
+			continue
+		}
+
+		if len(args) > 3 && port.ListenPort != args[3] {
+			ports = append(ports, port)
+			continue
+		}
+
+		removed++
+	}
+
+	if removed == 0 {
+		return fmt.Errorf(i18n.G("No matching port(s) found"))
+	}
+
+	if removed > 1 && !c.flagRemoveForce {
+		return fmt.Errorf(i18n.G("Multiple ports match. Use --force to remove them all"))
+	}
+
+	forward.Ports = ports
+
+	return resource.server.UpdateNetworkForward(resource.name, listenAddress, forward.Writable(), etag)
+}
@@ -0,0 +1,554 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/lxc/utils"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+	"github.com/lxc/lxd/shared/termios"
+)
+
+type cmdNetworkPeer struct {
+	global *cmdGlobal
+}
+
+func (c *cmdNetworkPeer) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("peer")
+	cmd.Short = i18n.G("Manage network peerings")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage network peerings`))
+
+	// Create
+	networkPeerCreateCmd := cmdNetworkPeerCreate{global: c.global, networkPeer: c}
+	cmd.AddCommand(networkPeerCreateCmd.Command())
+
+	// Delete
+	networkPeerDeleteCmd := cmdNetworkPeerDelete{global: c.global, networkPeer: c}
+	cmd.AddCommand(networkPeerDeleteCmd.Command())
+
+	// Edit
+	networkPeerEditCmd := cmdNetworkPeerEdit{global: c.global, networkPeer: c}
+	cmd.AddCommand(networkPeerEditCmd.Command())
+
+	// Get
+	networkPeerGetCmd := cmdNetworkPeerGet{global: c.global, networkPeer: c}
+	cmd.AddCommand(networkPeerGetCmd.Command())
+
+	// List
+	networkPeerListCmd := cmdNetworkPeerList{global: c.global, networkPeer: c}
+	cmd.AddCommand(networkPeerListCmd.Command())
+
+	// Set
+	networkPeerSetCmd := cmdNetworkPeerSet{global: c.global, networkPeer: c}
+	cmd.AddCommand(networkPeerSetCmd.Command())
+
+	// Show
+	networkPeerShowCmd := cmdNetworkPeerShow{global: c.global, networkPeer: c}
+	cmd.AddCommand(networkPeerShowCmd.Command())
+
+	// Unset
+	networkPeerUnsetCmd := cmdNetworkPeerUnset{global: c.global, networkPeer: c, networkPeerSet: &networkPeerSetCmd}
+	cmd.AddCommand(networkPeerUnsetCmd.Command())
+
+	return cmd
+}
+
+// parseArgs parses the remote and network name as well as the peering name.
+func (c *cmdNetworkPeer) parseArgs(args []string) (*remoteResource, string, error) {
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return nil, "", err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return nil, "", fmt.Errorf(i18n.G("Missing network name"))
+	}
+
+	if len(args) < 2 || args[1] == "" {
+		return &resource, "", fmt.Errorf(i18n.G("Missing listen address"))
+	}
+
+	return &resource, args[1], nil
+}
+
+// List
+type cmdNetworkPeerList struct {
+	global      *cmdGlobal
+	networkPeer *cmdNetworkPeer
+
+	flagFormat string
+}
+
+func (c *cmdNetworkPeerList) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("list [<remote>:]<network>")
+	cmd.Aliases = []string{"ls"}
+	cmd.Short = i18n.G("List available network peerings")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`List available network peerings`))
+
+	cmd.RunE = c.Run
+	cmd.Flags().StringVar(&c.flagFormat, "format", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+
+	return cmd
+}
+
+func (c *cmdNetworkPeerList) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing network name"))
+	}
+
+	peers, err := resource.server.GetNetworkPeers(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	for _, peer := range peers {
+		details := []string{
+			peer.Name,
+			peer.Description,
+			peer.TargetNetwork,
+			peer.Status,
+		}
+
+		data = append(data, details)
+	}
+	sort.Sort(byName(data))
+
+	header := []string{
+		i18n.G("NAME"),
+		i18n.G("DESCRIPTION"),
+		i18n.G("TARGET NETWORK"),
+		i18n.G("STATE"),
+	}
+
+	return utils.RenderTable(c.flagFormat, header, data, peers)
+}
+
+// Show
+type cmdNetworkPeerShow struct {
+	global      *cmdGlobal
+	networkPeer *cmdNetworkPeer
+}
+
+func (c *cmdNetworkPeerShow) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("show [<remote>:]<network> <peer_name>")
+	cmd.Short = i18n.G("Show network peering configurations")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show network peering configurations`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkPeerShow) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	resource, peerName, err := c.networkPeer.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	peer, _, err := resource.server.GetNetworkPeer(resource.name, peerName)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&peer)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+
+	return nil
+}
+
+// Create
+type cmdNetworkPeerCreate struct {
+	global      *cmdGlobal
+	networkPeer *cmdNetworkPeer
+}
+
+func (c *cmdNetworkPeerCreate) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("create [<remote>:]<network> <peer_name> <target_network> [key=value...]")
+	cmd.Short = i18n.G("Create new network peerings")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Create new network peerings
+
+The peering stays pending until the target network has a peering back to this network.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc network peer create ovn1 to-ovn2 ovn2
+lxc network peer create ovn2 to-ovn1 ovn1
+    Peer the ovn1 and ovn2 networks with each other.`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkPeerCreate) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 3, -1)
+	if exit {
+		return err
+	}
+
+	resource, peerName, err := c.networkPeer.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if args[2] == "" {
+		return fmt.Errorf(i18n.G("Missing target network"))
+	}
+
+	// Create the network peering
+	peer := api.NetworkPeersPost{
+		Name:          peerName,
+		TargetNetwork: args[2],
+	}
+
+	// If stdin isn't a terminal, read the peering definition from it
+	if !termios.IsTerminal(getStdinFd()) {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		err = yaml.Unmarshal(contents, &peer.NetworkPeerPut)
+		if err != nil {
+			return err
+		}
+	}
+
+	if peer.Config == nil {
+		peer.Config = map[string]string{}
+	}
+
+	for i := 3; i < len(args); i++ {
+		entry := strings.SplitN(args[i], "=", 2)
+		if len(entry) < 2 {
+			return fmt.Errorf(i18n.G("Bad key/value pair: %s"), args[i])
+		}
+
+		peer.Config[entry[0]] = entry[1]
+	}
+
+	err = resource.server.CreateNetworkPeer(resource.name, peer)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Network peer %s created")+"\n", peerName)
+	}
+
+	return nil
+}
+
+// Get
+type cmdNetworkPeerGet struct {
+	global      *cmdGlobal
+	networkPeer *cmdNetworkPeer
+}
+
+func (c *cmdNetworkPeerGet) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("get [<remote>:]<network> <peer_name> <key>")
+	cmd.Short = i18n.G("Get values for network peering configuration keys")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Get values for network peering configuration keys`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkPeerGet) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 3, 3)
+	if exit {
+		return err
+	}
+
+	resource, peerName, err := c.networkPeer.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	peer, _, err := resource.server.GetNetworkPeer(resource.name, peerName)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range peer.Config {
+		if k == args[2] {
+			fmt.Printf("%s\n", v)
+		}
+	}
+
+	return nil
+}
+
+// Set
+type cmdNetworkPeerSet struct {
+	global      *cmdGlobal
+	networkPeer *cmdNetworkPeer
+}
+
+func (c *cmdNetworkPeerSet) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("set [<remote>:]<network> <peer_name> <key>=<value>...")
+	cmd.Short = i18n.G("Set network peering keys")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Set network peering keys`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkPeerSet) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 3, -1)
+	if exit {
+		return err
+	}
+
+	resource, peerName, err := c.networkPeer.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// Get the network peering
+	peer, etag, err := resource.server.GetNetworkPeer(resource.name, peerName)
+	if err != nil {
+		return err
+	}
+
+	// Set the keys
+	keys, err := getConfig(args[2:]...)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range keys {
+		peer.Config[k] = v
+	}
+
+	return resource.server.UpdateNetworkPeer(resource.name, peerName, peer.Writable(), etag)
+}
+
+// Unset
+type cmdNetworkPeerUnset struct {
+	global         *cmdGlobal
+	networkPeer    *cmdNetworkPeer
+	networkPeerSet *cmdNetworkPeerSet
+}
+
+func (c *cmdNetworkPeerUnset) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("unset [<remote>:]<network> <peer_name> <key>")
+	cmd.Short = i18n.G("Unset network peering keys")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Unset network peering keys`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkPeerUnset) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 3, 3)
+	if exit {
+		return err
+	}
+
+	args = append(args, "")
+	return c.networkPeerSet.Run(cmd, args)
+}
+
+// Edit
+type cmdNetworkPeerEdit struct {
+	global      *cmdGlobal
+	networkPeer *cmdNetworkPeer
+}
+
+func (c *cmdNetworkPeerEdit) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("edit [<remote>:]<network> <peer_name>")
+	cmd.Short = i18n.G("Edit network peering configurations as YAML")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Edit network peering configurations as YAML`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkPeerEdit) helpTemplate() string {
+	return i18n.G(
+		`### This is a YAML representation of the network peering.
+### Any line starting with a '# will be ignored.
+###
+### An example would look like:
+### description: test desc
+### config:
+###   user.foo: bar
+###
+### Note that the name, target_network and status fields cannot be changed.`)
+}
+
+func (c *cmdNetworkPeerEdit) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	resource, peerName, err := c.networkPeer.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// If stdin isn't a terminal, read text from it
+	if !termios.IsTerminal(getStdinFd()) {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		newdata := api.NetworkPeerPut{}
+		err = yaml.Unmarshal(contents, &newdata)
+		if err != nil {
+			return err
+		}
+
+		return resource.server.UpdateNetworkPeer(resource.name, peerName, newdata, "")
+	}
+
+	// Extract the current value
+	peer, etag, err := resource.server.GetNetworkPeer(resource.name, peerName)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&peer)
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor
+	content, err := shared.TextEditor("", []byte(c.helpTemplate()+"\n\n"+string(data)))
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Parse the text received from the editor
+		newdata := api.NetworkPeerPut{}
+		err = yaml.Unmarshal(content, &newdata)
+		if err == nil {
+			err = resource.server.UpdateNetworkPeer(resource.name, peerName, newdata, etag)
+		}
+
+		// Respawn the editor
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Config parsing error: %s")+"\n", err)
+			fmt.Println(i18n.G("Press enter to open the editor again"))
+
+			_, err := os.Stdin.Read(make([]byte, 1))
+			if err != nil {
+				return err
+			}
+
+			content, err = shared.TextEditor("", content)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		break
+	}
+	return nil
+}
+
+// Delete
+type cmdNetworkPeerDelete struct {
+	global      *cmdGlobal
+	networkPeer *cmdNetworkPeer
+}
+
+func (c *cmdNetworkPeerDelete) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("delete [<remote>:]<network> <peer_name>")
+	cmd.Aliases = []string{"rm"}
+	cmd.Short = i18n.G("Delete network peerings")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Delete network peerings`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkPeerDelete) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	resource, peerName, err := c.networkPeer.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	err = resource.server.DeleteNetworkPeer(resource.name, peerName)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Network peer %s deleted")+"\n", peerName)
+	}
+
+	return nil
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/lxc/utils"
+	"github.com/lxc/lxd/shared/api"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type cmdNetworkReservation struct {
+	global *cmdGlobal
+}
+
+func (c *cmdNetworkReservation) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("reservation")
+	cmd.Short = i18n.G("Manage network DHCP reservations")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage network DHCP reservations`))
+
+	// Create
+	networkReservationCreateCmd := cmdNetworkReservationCreate{global: c.global, networkReservation: c}
+	cmd.AddCommand(networkReservationCreateCmd.Command())
+
+	// Delete
+	networkReservationDeleteCmd := cmdNetworkReservationDelete{global: c.global, networkReservation: c}
+	cmd.AddCommand(networkReservationDeleteCmd.Command())
+
+	// List
+	networkReservationListCmd := cmdNetworkReservationList{global: c.global, networkReservation: c}
+	cmd.AddCommand(networkReservationListCmd.Command())
+
+	// Show
+	networkReservationShowCmd := cmdNetworkReservationShow{global: c.global, networkReservation: c}
+	cmd.AddCommand(networkReservationShowCmd.Command())
+
+	return cmd
+}
+
+// parseArgs parses the remote and network name as well as the MAC address.
+func (c *cmdNetworkReservation) parseArgs(args []string) (*remoteResource, string, error) {
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return nil, "", err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return nil, "", fmt.Errorf(i18n.G("Missing network name"))
+	}
+
+	if len(args) < 2 || args[1] == "" {
+		return &resource, "", fmt.Errorf(i18n.G("Missing MAC address"))
+	}
+
+	return &resource, args[1], nil
+}
+
+// List
+type cmdNetworkReservationList struct {
+	global             *cmdGlobal
+	networkReservation *cmdNetworkReservation
+
+	flagFormat string
+}
+
+func (c *cmdNetworkReservationList) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("list [<remote>:]<network>")
+	cmd.Aliases = []string{"ls"}
+	cmd.Short = i18n.G("List available network DHCP reservations")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`List available network DHCP reservations`))
+
+	cmd.RunE = c.Run
+	cmd.Flags().StringVar(&c.flagFormat, "format", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+
+	return cmd
+}
+
+func (c *cmdNetworkReservationList) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing network name"))
+	}
+
+	reservations, err := resource.server.GetNetworkReservations(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	for _, reservation := range reservations {
+		details := []string{
+			reservation.MAC,
+			reservation.IPv4Address,
+			reservation.IPv6Address,
+			reservation.Description,
+		}
+
+		data = append(data, details)
+	}
+	sort.Sort(byName(data))
+
+	header := []string{
+		i18n.G("MAC ADDRESS"),
+		i18n.G("IPV4"),
+		i18n.G("IPV6"),
+		i18n.G("DESCRIPTION"),
+	}
+
+	return utils.RenderTable(c.flagFormat, header, data, reservations)
+}
+
+// Show
+type cmdNetworkReservationShow struct {
+	global             *cmdGlobal
+	networkReservation *cmdNetworkReservation
+}
+
+func (c *cmdNetworkReservationShow) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("show [<remote>:]<network> <MAC>")
+	cmd.Short = i18n.G("Show network DHCP reservations")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show network DHCP reservations`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkReservationShow) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	resource, hwaddr, err := c.networkReservation.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	reservation, err := resource.server.GetNetworkReservation(resource.name, hwaddr)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&reservation)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+
+	return nil
+}
+
+// Create
+type cmdNetworkReservationCreate struct {
+	global             *cmdGlobal
+	networkReservation *cmdNetworkReservation
+
+	flagDescription string
+}
+
+func (c *cmdNetworkReservationCreate) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("create [<remote>:]<network> <MAC> <address> [<address>]")
+	cmd.Short = i18n.G("Create new network DHCP reservations")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Create new network DHCP reservations
+
+Up to one IPv4 and one IPv6 address can be reserved for the MAC address.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc network reservation create lxdbr0 00:16:3e:00:00:01 10.0.0.2 --description=router
+    Always hand out 10.0.0.2 to 00:16:3e:00:00:01.`))
+
+	cmd.RunE = c.Run
+	cmd.Flags().StringVar(&c.flagDescription, "description", "", i18n.G("Reservation description")+"``")
+
+	return cmd
+}
+
+func (c *cmdNetworkReservationCreate) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 3, 4)
+	if exit {
+		return err
+	}
+
+	resource, hwaddr, err := c.networkReservation.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// Create the network DHCP reservation
+	reservation := api.NetworkReservation{
+		Description: c.flagDescription,
+		MAC:         hwaddr,
+	}
+
+	for _, address := range args[2:] {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			return fmt.Errorf(i18n.G("Invalid IP address: %s"), address)
+		}
+
+		if ip.To4() != nil {
+			if reservation.IPv4Address != "" {
+				return fmt.Errorf(i18n.G("Only one IPv4 address can be reserved"))
+			}
+
+			reservation.IPv4Address = address
+		} else {
+			if reservation.IPv6Address != "" {
+				return fmt.Errorf(i18n.G("Only one IPv6 address can be reserved"))
+			}
+
+			reservation.IPv6Address = address
+		}
+	}
+
+	err = resource.server.CreateNetworkReservation(resource.name, reservation)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Network DHCP reservation %s created")+"\n", hwaddr)
+	}
+
+	return nil
+}
+
+// Delete
+type cmdNetworkReservationDelete struct {
+	global             *cmdGlobal
+	networkReservation *cmdNetworkReservation
+}
+
+func (c *cmdNetworkReservationDelete) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("delete [<remote>:]<network> <MAC>")
+	cmd.Aliases = []string{"rm"}
+	cmd.Short = i18n.G("Delete network DHCP reservations")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Delete network DHCP reservations`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkReservationDelete) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	resource, hwaddr, err := c.networkReservation.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	err = resource.server.DeleteNetworkReservation(resource.name, hwaddr)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Network DHCP reservation %s deleted")+"\n", hwaddr)
+	}
+
+	return nil
+}
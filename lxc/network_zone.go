@@ -0,0 +1,554 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/lxc/utils"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+	"github.com/lxc/lxd/shared/termios"
+)
+
+type cmdNetworkZone struct {
+	global *cmdGlobal
+}
+
+func (c *cmdNetworkZone) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("zone")
+	cmd.Short = i18n.G("Manage network zones")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage network zones`))
+
+	// Create
+	networkZoneCreateCmd := cmdNetworkZoneCreate{global: c.global, networkZone: c}
+	cmd.AddCommand(networkZoneCreateCmd.Command())
+
+	// Delete
+	networkZoneDeleteCmd := cmdNetworkZoneDelete{global: c.global, networkZone: c}
+	cmd.AddCommand(networkZoneDeleteCmd.Command())
+
+	// Edit
+	networkZoneEditCmd := cmdNetworkZoneEdit{global: c.global, networkZone: c}
+	cmd.AddCommand(networkZoneEditCmd.Command())
+
+	// Get
+	networkZoneGetCmd := cmdNetworkZoneGet{global: c.global, networkZone: c}
+	cmd.AddCommand(networkZoneGetCmd.Command())
+
+	// List
+	networkZoneListCmd := cmdNetworkZoneList{global: c.global, networkZone: c}
+	cmd.AddCommand(networkZoneListCmd.Command())
+
+	// Set
+	networkZoneSetCmd := cmdNetworkZoneSet{global: c.global, networkZone: c}
+	cmd.AddCommand(networkZoneSetCmd.Command())
+
+	// Show
+	networkZoneShowCmd := cmdNetworkZoneShow{global: c.global, networkZone: c}
+	cmd.AddCommand(networkZoneShowCmd.Command())
+
+	// Unset
+	networkZoneUnsetCmd := cmdNetworkZoneUnset{global: c.global, networkZone: c, networkZoneSet: &networkZoneSetCmd}
+	cmd.AddCommand(networkZoneUnsetCmd.Command())
+
+	return cmd
+}
+
+// parseArgs parses the remote and zone name.
+func (c *cmdNetworkZone) parseArgs(args []string) (*remoteResource, error) {
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return nil, fmt.Errorf(i18n.G("Missing network zone name"))
+	}
+
+	return &resource, nil
+}
+
+// List
+type cmdNetworkZoneList struct {
+	global      *cmdGlobal
+	networkZone *cmdNetworkZone
+
+	flagFormat string
+}
+
+func (c *cmdNetworkZoneList) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("list [<remote>:]")
+	cmd.Aliases = []string{"ls"}
+	cmd.Short = i18n.G("List available network zones")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`List available network zones`))
+
+	cmd.RunE = c.Run
+	cmd.Flags().StringVar(&c.flagFormat, "format", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+
+	return cmd
+}
+
+func (c *cmdNetworkZoneList) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 0, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	remote := ""
+	if len(args) > 0 {
+		remote = args[0]
+	}
+
+	resources, err := c.global.ParseServers(remote)
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name != "" {
+		return fmt.Errorf(i18n.G("Filtering isn't supported yet"))
+	}
+
+	zones, err := resource.server.GetNetworkZones()
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	for _, zone := range zones {
+		details := []string{
+			zone.Name,
+			zone.Description,
+			fmt.Sprintf("%d", len(zone.UsedBy)),
+		}
+
+		data = append(data, details)
+	}
+	sort.Sort(byName(data))
+
+	header := []string{
+		i18n.G("NAME"),
+		i18n.G("DESCRIPTION"),
+		i18n.G("USED BY"),
+	}
+
+	return utils.RenderTable(c.flagFormat, header, data, zones)
+}
+
+// Show
+type cmdNetworkZoneShow struct {
+	global      *cmdGlobal
+	networkZone *cmdNetworkZone
+}
+
+func (c *cmdNetworkZoneShow) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("show [<remote>:]<Zone>")
+	cmd.Short = i18n.G("Show network zone configurations")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show network zone configurations`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkZoneShow) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	resource, err := c.networkZone.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	zone, _, err := resource.server.GetNetworkZone(resource.name)
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(zone.UsedBy)
+
+	data, err := yaml.Marshal(&zone)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+
+	return nil
+}
+
+// Create
+type cmdNetworkZoneCreate struct {
+	global      *cmdGlobal
+	networkZone *cmdNetworkZone
+}
+
+func (c *cmdNetworkZoneCreate) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("create [<remote>:]<Zone> [key=value...]")
+	cmd.Short = i18n.G("Create new network zones")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Create new network zones`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc network zone create z1
+
+lxc network zone create z1 < config.yaml
+    Create network zone with configuration from config.yaml`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkZoneCreate) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, -1)
+	if exit {
+		return err
+	}
+
+	resource, err := c.networkZone.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// Create the network zone
+	zone := api.NetworkZonesPost{
+		Name: resource.name,
+	}
+
+	// If stdin isn't a terminal, read the zone definition from it
+	if !termios.IsTerminal(getStdinFd()) {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		err = yaml.Unmarshal(contents, &zone.NetworkZonePut)
+		if err != nil {
+			return err
+		}
+	}
+
+	if zone.Config == nil {
+		zone.Config = map[string]string{}
+	}
+
+	for i := 1; i < len(args); i++ {
+		entry := strings.SplitN(args[i], "=", 2)
+		if len(entry) < 2 {
+			return fmt.Errorf(i18n.G("Bad key/value pair: %s"), args[i])
+		}
+
+		zone.Config[entry[0]] = entry[1]
+	}
+
+	err = resource.server.CreateNetworkZone(zone)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Network zone %s created")+"\n", resource.name)
+	}
+
+	return nil
+}
+
+// Get
+type cmdNetworkZoneGet struct {
+	global      *cmdGlobal
+	networkZone *cmdNetworkZone
+}
+
+func (c *cmdNetworkZoneGet) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("get [<remote>:]<Zone> <key>")
+	cmd.Short = i18n.G("Get values for network zone configuration keys")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Get values for network zone configuration keys`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkZoneGet) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	resource, err := c.networkZone.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	zone, _, err := resource.server.GetNetworkZone(resource.name)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range zone.Config {
+		if k == args[1] {
+			fmt.Printf("%s\n", v)
+		}
+	}
+
+	return nil
+}
+
+// Set
+type cmdNetworkZoneSet struct {
+	global      *cmdGlobal
+	networkZone *cmdNetworkZone
+}
+
+func (c *cmdNetworkZoneSet) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("set [<remote>:]<Zone> <key>=<value>...")
+	cmd.Short = i18n.G("Set network zone configuration keys")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Set network zone configuration keys`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkZoneSet) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, -1)
+	if exit {
+		return err
+	}
+
+	resource, err := c.networkZone.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// Get the network zone
+	zone, etag, err := resource.server.GetNetworkZone(resource.name)
+	if err != nil {
+		return err
+	}
+
+	// Set the keys
+	keys, err := getConfig(args[1:]...)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range keys {
+		zone.Config[k] = v
+	}
+
+	return resource.server.UpdateNetworkZone(resource.name, zone.Writable(), etag)
+}
+
+// Unset
+type cmdNetworkZoneUnset struct {
+	global         *cmdGlobal
+	networkZone    *cmdNetworkZone
+	networkZoneSet *cmdNetworkZoneSet
+}
+
+func (c *cmdNetworkZoneUnset) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("unset [<remote>:]<Zone> <key>")
+	cmd.Short = i18n.G("Unset network zone configuration keys")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Unset network zone configuration keys`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkZoneUnset) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	args = append(args, "")
+	return c.networkZoneSet.Run(cmd, args)
+}
+
+// Edit
+type cmdNetworkZoneEdit struct {
+	global      *cmdGlobal
+	networkZone *cmdNetworkZone
+}
+
+func (c *cmdNetworkZoneEdit) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("edit [<remote>:]<Zone>")
+	cmd.Short = i18n.G("Edit network zone configurations as YAML")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Edit network zone configurations as YAML`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkZoneEdit) helpTemplate() string {
+	return i18n.G(
+		`### This is a YAML representation of the network zone.
+### Any line starting with a '# will be ignored.
+###
+### A network zone consists of a set of configuration items.
+###
+### An example would look like:
+### name: lxd.example.net
+### description: test desc
+### config:
+###   dns.nameservers: ns1.example.net
+###   peers.ns1.address: 192.0.2.1
+###   peers.ns1.key: c2VjcmV0
+###
+### Note that only the description and configuration keys can be changed.`)
+}
+
+func (c *cmdNetworkZoneEdit) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	resource, err := c.networkZone.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// If stdin isn't a terminal, read text from it
+	if !termios.IsTerminal(getStdinFd()) {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		newdata := api.NetworkZonePut{}
+		err = yaml.Unmarshal(contents, &newdata)
+		if err != nil {
+			return err
+		}
+
+		return resource.server.UpdateNetworkZone(resource.name, newdata, "")
+	}
+
+	// Extract the current value
+	zone, etag, err := resource.server.GetNetworkZone(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&zone)
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor
+	content, err := shared.TextEditor("", []byte(c.helpTemplate()+"\n\n"+string(data)))
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Parse the text received from the editor
+		newdata := api.NetworkZonePut{}
+		err = yaml.Unmarshal(content, &newdata)
+		if err == nil {
+			err = resource.server.UpdateNetworkZone(resource.name, newdata, etag)
+		}
+
+		// Respawn the editor
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Config parsing error: %s")+"\n", err)
+			fmt.Println(i18n.G("Press enter to open the editor again"))
+
+			_, err := os.Stdin.Read(make([]byte, 1))
+			if err != nil {
+				return err
+			}
+
+			content, err = shared.TextEditor("", content)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		break
+	}
+	return nil
+}
+
+// Delete
+type cmdNetworkZoneDelete struct {
+	global      *cmdGlobal
+	networkZone *cmdNetworkZone
+}
+
+func (c *cmdNetworkZoneDelete) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("delete [<remote>:]<Zone>")
+	cmd.Aliases = []string{"rm"}
+	cmd.Short = i18n.G("Delete network zones")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Delete network zones`))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkZoneDelete) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	resource, err := c.networkZone.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	err = resource.server.DeleteNetworkZone(resource.name)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Network zone %s deleted")+"\n", resource.name)
+	}
+
+	return nil
+}
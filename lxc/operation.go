@@ -9,6 +9,7 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/lxc/lxd/lxc/utils"
+	"github.com/lxc/lxd/shared/api"
 	cli "github.com/lxc/lxd/shared/cmd"
 	"github.com/lxc/lxd/shared/i18n"
 )
@@ -91,7 +92,8 @@ type cmdOperationList struct {
 	global    *cmdGlobal
 	operation *cmdOperation
 
-	flagFormat string
+	flagFormat      string
+	flagAllProjects bool
 }
 
 func (c *cmdOperationList) Command() *cobra.Command {
@@ -102,6 +104,7 @@ func (c *cmdOperationList) Command() *cobra.Command {
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`List background operations`))
 	cmd.Flags().StringVar(&c.flagFormat, "format", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+	cmd.Flags().BoolVar(&c.flagAllProjects, "all-projects", false, i18n.G("List operations from all projects"))
 
 	cmd.RunE = c.Run
 
@@ -132,7 +135,12 @@ func (c *cmdOperationList) Run(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get operations
-	operations, err := resource.server.GetOperations()
+	var operations []api.Operation
+	if c.flagAllProjects {
+		operations, err = resource.server.GetOperationsAllProjects()
+	} else {
+		operations, err = resource.server.GetOperations()
+	}
 	if err != nil {
 		return err
 	}
@@ -146,6 +154,10 @@ func (c *cmdOperationList) Run(cmd *cobra.Command, args []string) error {
 		}
 
 		entry := []string{op.ID, strings.ToUpper(op.Class), op.Description, strings.ToUpper(op.Status), cancelable, op.CreatedAt.UTC().Format("2006/01/02 15:04 UTC")}
+		if c.flagAllProjects {
+			entry = append([]string{op.Project}, entry...)
+		}
+
 		if resource.server.IsClustered() {
 			entry = append(entry, op.Location)
 		}
@@ -161,6 +173,10 @@ func (c *cmdOperationList) Run(cmd *cobra.Command, args []string) error {
 		i18n.G("STATUS"),
 		i18n.G("CANCELABLE"),
 		i18n.G("CREATED")}
+	if c.flagAllProjects {
+		header = append([]string{i18n.G("PROJECT")}, header...)
+	}
+
 	if resource.server.IsClustered() {
 		header = append(header, i18n.G("LOCATION"))
 	}
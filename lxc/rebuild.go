@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxc/utils"
+	"github.com/lxc/lxd/shared/api"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type cmdRebuild struct {
+	global *cmdGlobal
+
+	flagForce bool
+}
+
+func (c *cmdRebuild) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("rebuild [<remote>:]<instance> [<remote>:]<image>")
+	cmd.Short = i18n.G("Rebuild instances from images")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Rebuild instances from images
+
+The root disk of the instance is replaced by a fresh one created from the image,
+the instance's configuration, devices and profiles are kept.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc rebuild u1 ubuntu:20.04
+    Rebuild the stopped instance "u1" from the ubuntu:20.04 image.
+
+lxc rebuild u1 ubuntu:20.04 --force
+    Rebuild the instance "u1" even if it's running, restarting it.`))
+
+	cmd.RunE = c.Run
+	cmd.Flags().BoolVarP(&c.flagForce, "force", "f", false, i18n.G("Stop the instance if running and start it again once rebuilt"))
+
+	return cmd
+}
+
+func (c *cmdRebuild) Run(cmd *cobra.Command, args []string) error {
+	conf := c.global.conf
+
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Connect to LXD
+	remote, name, err := conf.ParseRemote(args[0])
+	if err != nil {
+		return err
+	}
+
+	d, err := conf.GetInstanceServer(remote)
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		return fmt.Errorf(i18n.G("Missing instance name"))
+	}
+
+	// Get the image server and image info
+	iremote, image, err := conf.ParseRemote(args[1])
+	if err != nil {
+		return err
+	}
+
+	initCmd := cmdInit{global: c.global}
+	iremote, image = initCmd.guessImage(conf, d, remote, iremote, image)
+
+	var imgRemote lxd.ImageServer
+	if iremote == remote {
+		imgRemote = d
+	} else {
+		imgRemote, err = conf.GetImageServer(iremote)
+		if err != nil {
+			return err
+		}
+	}
+
+	req := api.InstanceRebuildPost{
+		Force: c.flagForce,
+	}
+
+	var imgInfo *api.Image
+	if conf.Remotes[iremote].Protocol == "simplestreams" {
+		// Optimisation for simplestreams
+		imgInfo = &api.Image{}
+		imgInfo.Fingerprint = image
+		imgInfo.Public = true
+		req.Source.Alias = image
+	} else {
+		// Attempt to resolve an image alias
+		alias, _, err := imgRemote.GetImageAlias(image)
+		if err == nil {
+			req.Source.Alias = image
+			image = alias.Target
+		}
+
+		// Get the image info
+		imgInfo, _, err = imgRemote.GetImage(image)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Rebuild the instance
+	op, err := d.RebuildInstanceFromImage(imgRemote, *imgInfo, name, req)
+	if err != nil {
+		return err
+	}
+
+	// Watch the background operation
+	progress := utils.ProgressRenderer{
+		Format: i18n.G("Retrieving image: %s"),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	_, err = op.AddHandler(progress.UpdateOp)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	err = utils.CancelableWait(op, &progress)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	progress.Done("")
+
+	return nil
+}
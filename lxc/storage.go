@@ -378,7 +378,8 @@ type cmdStorageInfo struct {
 	global  *cmdGlobal
 	storage *cmdStorage
 
-	flagBytes bool
+	flagBytes  bool
+	flagFormat string
 }
 
 func (c *cmdStorageInfo) Command() *cobra.Command {
@@ -390,6 +391,7 @@ func (c *cmdStorageInfo) Command() *cobra.Command {
 
 	cmd.Flags().BoolVar(&c.flagBytes, "bytes", false, i18n.G("Show the used and free space in bytes"))
 	cmd.Flags().StringVar(&c.storage.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
+	cmd.Flags().StringVar(&c.flagFormat, "format", "", i18n.G("Format (json|yaml)")+"``")
 	cmd.RunE = c.Run
 
 	return cmd
@@ -434,6 +436,13 @@ func (c *cmdStorageInfo) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if c.flagFormat != "" {
+		return utils.RenderData(c.flagFormat, map[string]interface{}{
+			"pool":      pool,
+			"resources": res,
+		})
+	}
+
 	// Declare the poolinfo map of maps in order to build up the yaml
 	poolinfo := make(map[string]map[string]string)
 	poolusedby := make(map[string]map[string][]string)
@@ -498,6 +507,7 @@ type cmdStorageList struct {
 	storage *cmdStorage
 
 	flagFormat string
+	flagFull   bool
 }
 
 func (c *cmdStorageList) Command() *cobra.Command {
@@ -508,6 +518,7 @@ func (c *cmdStorageList) Command() *cobra.Command {
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`List available storage pools`))
 	cmd.Flags().StringVar(&c.flagFormat, "format", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+	cmd.Flags().BoolVar(&c.flagFull, "full", false, i18n.G("Show the space used by the storage pools"))
 
 	cmd.RunE = c.Run
 
@@ -535,13 +546,26 @@ func (c *cmdStorageList) Run(cmd *cobra.Command, args []string) error {
 	resource := resources[0]
 
 	// Get the storage pools
-	pools, err := resource.server.GetStoragePools()
-	if err != nil {
-		return err
+	var pools []api.StoragePool
+	var poolsFull []api.StoragePoolFull
+	if c.flagFull {
+		poolsFull, err = resource.server.GetStoragePoolsFull()
+		if err != nil {
+			return err
+		}
+
+		for _, pool := range poolsFull {
+			pools = append(pools, pool.StoragePool)
+		}
+	} else {
+		pools, err = resource.server.GetStoragePools()
+		if err != nil {
+			return err
+		}
 	}
 
 	data := [][]string{}
-	for _, pool := range pools {
+	for i, pool := range pools {
 		usedby := strconv.Itoa(len(pool.UsedBy))
 		details := []string{pool.Name, pool.Description, pool.Driver}
 		if resource.server.IsClustered() {
@@ -550,6 +574,19 @@ func (c *cmdStorageList) Run(cmd *cobra.Command, args []string) error {
 			details = append(details, pool.Config["source"])
 		}
 		details = append(details, usedby)
+
+		if c.flagFull {
+			used := ""
+			total := ""
+			res := poolsFull[i].Resources
+			if res != nil {
+				used = units.GetByteSizeString(int64(res.Space.Used), 2)
+				total = units.GetByteSizeString(int64(res.Space.Total), 2)
+			}
+
+			details = append(details, used, total)
+		}
+
 		data = append(data, details)
 	}
 	sort.Sort(byName(data))
@@ -566,6 +603,12 @@ func (c *cmdStorageList) Run(cmd *cobra.Command, args []string) error {
 	}
 	header = append(header, i18n.G("USED BY"))
 
+	if c.flagFull {
+		header = append(header, i18n.G("USED"), i18n.G("TOTAL"))
+
+		return utils.RenderTable(c.flagFormat, header, data, poolsFull)
+	}
+
 	return utils.RenderTable(c.flagFormat, header, data, pools)
 }
 
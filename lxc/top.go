@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxc/utils"
+	"github.com/lxc/lxd/shared/api"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+	"github.com/lxc/lxd/shared/units"
+)
+
+type cmdTop struct {
+	global *cmdGlobal
+
+	flagAllProjects bool
+	flagInterval    int
+	flagSort        string
+}
+
+// topSample holds the counters of an instance at a point in time.
+type topSample struct {
+	time    time.Time
+	cpu     int64
+	netRecv int64
+	netSent int64
+}
+
+// topInstance is an instance along with the project it belongs to.
+type topInstance struct {
+	project string
+	api.InstanceFull
+}
+
+func (c *cmdTop) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = i18n.G("top [<remote>:]")
+	cmd.Short = i18n.G("Show live resource usage of instances")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show live resource usage of instances
+
+The CPU, memory, disk and network usage of the running instances is refreshed
+in place until interrupted. On clusters, the instances of all members are shown.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc top --all-projects --sort=memory
+    Show the instances of all projects, using the most memory first.`))
+
+	cmd.RunE = c.Run
+	cmd.Flags().BoolVar(&c.flagAllProjects, "all-projects", false, i18n.G("Show instances from all projects"))
+	cmd.Flags().IntVar(&c.flagInterval, "interval", 2, i18n.G("Refresh interval in seconds")+"``")
+	cmd.Flags().StringVar(&c.flagSort, "sort", "cpu", i18n.G("Sort column (cpu|memory|disk|name)")+"``")
+
+	return cmd
+}
+
+func (c *cmdTop) Run(cmd *cobra.Command, args []string) error {
+	// Sanity checks
+	exit, err := c.global.CheckArgs(cmd, args, 0, 1)
+	if exit {
+		return err
+	}
+
+	if c.flagInterval < 1 {
+		return fmt.Errorf(i18n.G("The refresh interval must be at least one second"))
+	}
+
+	if !map[string]bool{"cpu": true, "memory": true, "disk": true, "name": true}[c.flagSort] {
+		return fmt.Errorf(i18n.G("Invalid sort column %q"), c.flagSort)
+	}
+
+	// Parse remote
+	remote := ""
+	if len(args) > 0 {
+		remote = args[0]
+	}
+
+	resources, err := c.global.ParseServers(remote)
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+	if resource.name != "" {
+		return fmt.Errorf(i18n.G("Filtering isn't supported yet"))
+	}
+
+	previous := map[string]topSample{}
+	for {
+		instances, err := c.getInstances(resource.server)
+		if err != nil {
+			return err
+		}
+
+		current := map[string]topSample{}
+		data := [][]string{}
+		type row struct {
+			cells  []string
+			cpu    float64
+			memory int64
+			disk   int64
+		}
+
+		rows := []row{}
+		for _, inst := range instances {
+			if inst.State == nil || inst.StatusCode != api.Running {
+				continue
+			}
+
+			key := inst.project + "/" + inst.Name
+			sample := topSample{
+				time: time.Now(),
+				cpu:  inst.State.CPU.Usage,
+			}
+
+			for _, net := range inst.State.Network {
+				if net.Type == "loopback" {
+					continue
+				}
+
+				sample.netRecv += net.Counters.BytesReceived
+				sample.netSent += net.Counters.BytesSent
+			}
+
+			current[key] = sample
+
+			// Rates are computed from the previous refresh.
+			cpuPercent := 0.0
+			netRecvRate := int64(0)
+			netSentRate := int64(0)
+			prev, ok := previous[key]
+			if ok {
+				elapsed := sample.time.Sub(prev.time)
+				if elapsed > 0 {
+					cpuPercent = float64(sample.cpu-prev.cpu) / float64(elapsed.Nanoseconds()) * 100
+					netRecvRate = int64(float64(sample.netRecv-prev.netRecv) / elapsed.Seconds())
+					netSentRate = int64(float64(sample.netSent-prev.netSent) / elapsed.Seconds())
+				}
+			}
+
+			disk := inst.State.Disk["root"].Usage
+
+			cells := []string{inst.Name}
+			if c.flagAllProjects {
+				cells = append(cells, inst.project)
+			}
+
+			if resource.server.IsClustered() {
+				cells = append(cells, inst.Location)
+			}
+
+			cells = append(cells,
+				fmt.Sprintf("%.1f%%", cpuPercent),
+				units.GetByteSizeString(inst.State.Memory.Usage, 2),
+				units.GetByteSizeString(disk, 2),
+				units.GetByteSizeString(netRecvRate, 2)+"/s",
+				units.GetByteSizeString(netSentRate, 2)+"/s",
+				fmt.Sprintf("%d", inst.State.Processes),
+			)
+
+			rows = append(rows, row{cells: cells, cpu: cpuPercent, memory: inst.State.Memory.Usage, disk: disk})
+		}
+
+		sort.SliceStable(rows, func(i, j int) bool {
+			switch c.flagSort {
+			case "cpu":
+				return rows[i].cpu > rows[j].cpu
+			case "memory":
+				return rows[i].memory > rows[j].memory
+			case "disk":
+				return rows[i].disk > rows[j].disk
+			}
+
+			return rows[i].cells[0] < rows[j].cells[0]
+		})
+
+		for _, r := range rows {
+			data = append(data, r.cells)
+		}
+
+		header := []string{i18n.G("NAME")}
+		if c.flagAllProjects {
+			header = append(header, i18n.G("PROJECT"))
+		}
+
+		if resource.server.IsClustered() {
+			header = append(header, i18n.G("LOCATION"))
+		}
+
+		header = append(header,
+			i18n.G("CPU"),
+			i18n.G("MEMORY"),
+			i18n.G("DISK"),
+			i18n.G("NET RX"),
+			i18n.G("NET TX"),
+			i18n.G("PROCESSES"),
+		)
+
+		// Clear the screen and redraw in place
+		fmt.Printf("\033[H\033[2J")
+		fmt.Printf(i18n.G("Running instances: %d (refreshed every %ds)")+"\n", len(data), c.flagInterval)
+
+		err = utils.RenderTable(utils.TableFormatTable, header, data, nil)
+		if err != nil {
+			return err
+		}
+
+		previous = current
+		time.Sleep(time.Duration(c.flagInterval) * time.Second)
+	}
+}
+
+// getInstances returns the instances along with their state and project, from all projects if requested.
+func (c *cmdTop) getInstances(d lxd.InstanceServer) ([]topInstance, error) {
+	projects := []string{""}
+	if c.flagAllProjects {
+		var err error
+		projects, err = d.GetProjectNames()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	instances := []topInstance{}
+	for _, project := range projects {
+		server := d
+		if project != "" {
+			server = d.UseProject(project)
+		}
+
+		projectInstances, err := server.GetInstancesFull(api.InstanceTypeAny)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, inst := range projectInstances {
+			instances = append(instances, topInstance{project: project, InstanceFull: inst})
+		}
+	}
+
+	return instances, nil
+}
@@ -58,3 +58,27 @@ func RenderTable(format string, header []string, data [][]string, raw interface{
 
 	return nil
 }
+
+// RenderData renders non-tabular data as json or yaml.
+func RenderData(format string, raw interface{}) error {
+	switch format {
+	case TableFormatJSON:
+		enc := json.NewEncoder(os.Stdout)
+
+		err := enc.Encode(raw)
+		if err != nil {
+			return err
+		}
+	case TableFormatYAML:
+		out, err := yaml.Marshal(raw)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s", out)
+	default:
+		return fmt.Errorf(i18n.G("Invalid format %q"), format)
+	}
+
+	return nil
+}
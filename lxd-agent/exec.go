@@ -54,10 +54,9 @@ func execPost(d *Daemon, r *http.Request) response.Response {
 	_, ok := env["PATH"]
 	if !ok {
 		env["PATH"] = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
-	}
-
-	if shared.PathExists("/snap/bin") {
-		env["PATH"] = fmt.Sprintf("%s:/snap/bin", env["PATH"])
+		if shared.PathExists("/snap/bin") {
+			env["PATH"] = fmt.Sprintf("%s:/snap/bin", env["PATH"])
+		}
 	}
 
 	// If running as root, set some env variables
@@ -59,7 +59,8 @@ func fileGet(path string, r *http.Request) response.Response {
 		files := make([]response.FileResponseEntry, 1)
 		files[0].Identifier = filepath.Base(path)
 
-		f, err := ioutil.TempFile(filepath.Dir(path), "lxd_getfile_")
+		// Use a temporary file outside of the requested path so that read-only directories can be pulled from.
+		f, err := ioutil.TempFile("", "lxd_getfile_")
 		if err != nil {
 			return response.SmartError(err)
 		}
@@ -203,25 +204,42 @@ func getFileInfo(path string) (int64, int64, os.FileMode, string, []string, erro
 		}
 	}
 
-	// 0xFFF = 0b7777
-	return int64(stat.Uid), int64(stat.Gid), fi.Mode() & 0xFFF, fType, dirEnts, nil
+	// Report the permission bits along with the setuid, setgid and sticky bits (07777).
+	return int64(stat.Uid), int64(stat.Gid), os.FileMode(stat.Mode & 07777), fType, dirEnts, nil
 }
 
+// filePush creates or updates a file, symlink or directory with the same semantics as the forkfile helper used
+// for containers: new entries default to root ownership and 0640 (files) or 0750 (directories) permissions,
+// while existing entries only get the ownership and mode which were explicitly requested.
 func filePush(fType string, srcpath string, dstpath string, uid int64, gid int64, mode int, write string) error {
-	switch fType {
-	case "file":
-		if !shared.PathExists(dstpath) {
-			if uid == -1 {
-				uid = 0
-			}
+	defaultMode := 0640
+	if fType == "directory" {
+		defaultMode = 0750
+	}
 
-			if gid == -1 {
-				gid = 0
-			}
+	exists := shared.PathExists(dstpath)
+	if !exists {
+		if uid == -1 {
+			uid = 0
+		}
 
-			if mode == -1 {
-				mode = 0
-			}
+		if gid == -1 {
+			gid = 0
+		}
+
+		if mode == -1 {
+			mode = defaultMode
+		}
+	}
+
+	// Apply the requested permissions as is.
+	oldMask := unix.Umask(0)
+	defer unix.Umask(oldMask)
+
+	switch fType {
+	case "file":
+		if exists && shared.IsDir(dstpath) {
+			return fmt.Errorf("Path already exists as a directory")
 		}
 
 		flags := os.O_CREATE | os.O_WRONLY
@@ -232,7 +250,7 @@ func filePush(fType string, srcpath string, dstpath string, uid int64, gid int64
 			flags |= os.O_APPEND
 		}
 
-		dst, err := os.OpenFile(dstpath, flags, os.FileMode(mode))
+		dst, err := os.OpenFile(dstpath, flags, 0)
 		if err != nil {
 			return err
 		}
@@ -249,23 +267,23 @@ func filePush(fType string, srcpath string, dstpath string, uid int64, gid int64
 			return err
 		}
 
-		err = os.Chown(dst.Name(), int(uid), int(gid))
+		// Change ownership first as it clears the setuid and setgid bits.
+		err = unix.Fchown(int(dst.Fd()), int(uid), int(gid))
 		if err != nil {
 			return err
 		}
 
-		return nil
-	case "symlink":
-		if uid == -1 {
-			uid = 0
-		}
-
-		if gid == -1 {
-			gid = 0
+		if mode != -1 {
+			err = unix.Fchmod(int(dst.Fd()), uint32(mode))
+			if err != nil {
+				return err
+			}
 		}
 
+		return nil
+	case "symlink":
 		err := os.Symlink(srcpath, dstpath)
-		if err != nil {
+		if err != nil && !os.IsExist(err) {
 			return err
 		}
 
@@ -276,26 +294,27 @@ func filePush(fType string, srcpath string, dstpath string, uid int64, gid int64
 
 		return nil
 	case "directory":
-		if uid == -1 {
-			uid = 0
+		if exists && !shared.IsDir(dstpath) {
+			return fmt.Errorf("Path already exists and isn't a directory")
 		}
 
-		if gid == -1 {
-			gid = 0
-		}
-
-		if mode == -1 {
-			mode = 0
+		if !exists {
+			err := unix.Mkdir(dstpath, uint32(mode))
+			if err != nil {
+				return err
+			}
 		}
 
-		err := os.MkdirAll(dstpath, os.FileMode(mode))
+		err := os.Chown(dstpath, int(uid), int(gid))
 		if err != nil {
 			return err
 		}
 
-		err = os.Chown(dstpath, int(uid), int(gid))
-		if err != nil {
-			return err
+		if mode != -1 {
+			err = unix.Chmod(dstpath, uint32(mode))
+			if err != nil {
+				return err
+			}
 		}
 
 		return nil
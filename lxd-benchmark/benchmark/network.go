@@ -0,0 +1,207 @@
+package benchmark
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// iperfInstall installs iperf3 in the containers when it's missing.
+const iperfInstall = "command -v iperf3 >/dev/null || apk add iperf3 || (apt-get update && apt-get install -y iperf3) || dnf install -y iperf3"
+
+// NetworkThroughput measures the TCP throughput between two containers attached to the managed
+// network, using iperf3. It returns the duration of the measurement and the throughput metrics.
+func NetworkThroughput(c lxd.ContainerServer, network string, image string, seconds int) (time.Duration, map[string]float64, error) {
+	var duration time.Duration
+
+	net, _, err := c.GetNetwork(network)
+	if err != nil {
+		return duration, nil, err
+	}
+
+	if !net.Managed {
+		return duration, nil, fmt.Errorf("Network %q isn't managed by LXD", network)
+	}
+
+	fmt.Printf("Test variables:\n")
+	fmt.Printf("  Network: %s\n", network)
+	fmt.Printf("  Image: %s\n", image)
+	fmt.Printf("  Duration: %ds\n", seconds)
+	fmt.Printf("\n")
+
+	fingerprint, err := ensureImage(c, image)
+	if err != nil {
+		return duration, nil, err
+	}
+
+	names := []string{"benchmark-net-server", "benchmark-net-client"}
+	defer func() {
+		for _, name := range names {
+			err := stopContainer(c, name)
+			if err != nil {
+				logf("Failed to stop container '%s': %s", name, err)
+			}
+
+			err = deleteContainer(c, name)
+			if err != nil {
+				logf("Failed to delete container '%s': %s", name, err)
+			}
+		}
+	}()
+
+	for _, name := range names {
+		logf("Creating container '%s' on network '%s'", name, network)
+
+		err := createNetworkContainer(c, fingerprint, name, network)
+		if err != nil {
+			return duration, nil, err
+		}
+
+		err = startContainer(c, name)
+		if err != nil {
+			return duration, nil, err
+		}
+	}
+
+	serverAddress, err := waitContainerAddress(c, names[0], time.Minute)
+	if err != nil {
+		return duration, nil, err
+	}
+
+	for _, name := range names {
+		_, err := execContainer(c, name, []string{"sh", "-c", iperfInstall})
+		if err != nil {
+			return duration, nil, fmt.Errorf("Failed to install iperf3 in container '%s': %v", name, err)
+		}
+	}
+
+	// Start a one-off iperf3 server in the background.
+	_, err = execContainer(c, names[0], []string{"iperf3", "-s", "-1", "-D"})
+	if err != nil {
+		return duration, nil, err
+	}
+
+	logf("Measuring throughput to %s for %ds", serverAddress, seconds)
+	timeStart := time.Now()
+	out, err := execContainer(c, names[1], []string{"iperf3", "-c", serverAddress, "-J", "-t", fmt.Sprintf("%d", seconds)})
+	if err != nil {
+		return duration, nil, err
+	}
+	duration = time.Since(timeStart)
+
+	result := struct {
+		End struct {
+			SumSent struct {
+				BitsPerSecond float64 `json:"bits_per_second"`
+				Retransmits   float64 `json:"retransmits"`
+			} `json:"sum_sent"`
+			SumReceived struct {
+				BitsPerSecond float64 `json:"bits_per_second"`
+			} `json:"sum_received"`
+		} `json:"end"`
+	}{}
+
+	err = json.Unmarshal(out, &result)
+	if err != nil {
+		return duration, nil, fmt.Errorf("Failed to parse iperf3 output: %v", err)
+	}
+
+	metrics := map[string]float64{
+		"sent_bits_per_second":     result.End.SumSent.BitsPerSecond,
+		"received_bits_per_second": result.End.SumReceived.BitsPerSecond,
+		"retransmits":              result.End.SumSent.Retransmits,
+	}
+
+	logf("Throughput: %.2f Mbit/s sent, %.2f Mbit/s received", metrics["sent_bits_per_second"]/1000000, metrics["received_bits_per_second"]/1000000)
+
+	return duration, metrics, nil
+}
+
+func createNetworkContainer(c lxd.ContainerServer, fingerprint string, name string, network string) error {
+	req := api.ContainersPost{
+		Name: name,
+		Source: api.ContainerSource{
+			Type:        "image",
+			Fingerprint: fingerprint,
+		},
+	}
+	req.Config = map[string]string{userConfigKey: "true"}
+	req.Devices = map[string]map[string]string{
+		"eth0": {
+			"type":    "nic",
+			"name":    "eth0",
+			"network": network,
+		},
+	}
+
+	op, err := c.CreateContainer(req)
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
+
+// waitContainerAddress waits for the container to get a global IPv4 address on eth0.
+func waitContainerAddress(c lxd.ContainerServer, name string, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		state, _, err := c.GetContainerState(name)
+		if err != nil {
+			return "", err
+		}
+
+		for _, addr := range state.Network["eth0"].Addresses {
+			if addr.Family == "inet" && addr.Scope == "global" {
+				return addr.Address, nil
+			}
+		}
+
+		time.Sleep(time.Second)
+	}
+
+	return "", fmt.Errorf("Container '%s' didn't get an IPv4 address", name)
+}
+
+// bufferCloser turns a bytes.Buffer into an io.WriteCloser.
+type bufferCloser struct {
+	bytes.Buffer
+}
+
+func (b *bufferCloser) Close() error {
+	return nil
+}
+
+// execContainer runs a command in the container, returning its standard output.
+func execContainer(c lxd.ContainerServer, name string, command []string) ([]byte, error) {
+	stdout := &bufferCloser{}
+	stderr := &bufferCloser{}
+	dataDone := make(chan bool)
+
+	op, err := c.ExecContainer(name, api.ContainerExecPost{Command: command, WaitForWS: true}, &lxd.ContainerExecArgs{
+		Stdout:   stdout,
+		Stderr:   stderr,
+		DataDone: dataDone,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = op.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	<-dataDone
+
+	ret, ok := op.Get().Metadata["return"].(float64)
+	if !ok || ret != 0 {
+		return nil, fmt.Errorf("Command %q failed: %s", command, stderr.String())
+	}
+
+	return stdout.Bytes(), nil
+}
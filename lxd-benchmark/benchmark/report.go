@@ -2,12 +2,20 @@ package benchmark
 
 import (
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"time"
 )
 
+// Report is implemented by the supported report formats.
+type Report interface {
+	Load() error
+	Write() error
+	AddRecord(label string, elapsed time.Duration, metrics map[string]float64) error
+}
+
 // Subset of JMeter CSV log format that are required by Jenkins performance
 // plugin
 // (see http://jmeter.apache.org/usermanual/listeners.html#csvlogformat)
@@ -70,8 +78,8 @@ func (r *CSVReport) Write() error {
 	return nil
 }
 
-// AddRecord adds a record to the report.
-func (r *CSVReport) AddRecord(label string, elapsed time.Duration) error {
+// AddRecord adds a record to the report, the CSV format has no room for additional metrics.
+func (r *CSVReport) AddRecord(label string, elapsed time.Duration, metrics map[string]float64) error {
 	if len(r.records) == 0 {
 		r.addRecord(csvFields)
 	}
@@ -93,3 +101,66 @@ func (r *CSVReport) addRecord(record []string) error {
 	r.records = append(r.records, record)
 	return nil
 }
+
+// JSONRecord is a single benchmark run in a JSON report.
+type JSONRecord struct {
+	Timestamp time.Time          `json:"timestamp"`
+	Label     string             `json:"label"`
+	Elapsed   float64            `json:"elapsed"`
+	Metrics   map[string]float64 `json:"metrics,omitempty"`
+}
+
+// JSONReport reads/writes a JSON report file, keeping all the metrics of each run.
+type JSONReport struct {
+	Filename string
+
+	records []JSONRecord
+}
+
+// Load reads current content of the filename and loads records.
+func (r *JSONReport) Load() error {
+	file, err := os.Open(r.Filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = json.NewDecoder(file).Decode(&r.records)
+	if err != nil {
+		return err
+	}
+
+	logf("Loaded report file %s", r.Filename)
+	return nil
+}
+
+// Write writes current records to file.
+func (r *JSONReport) Write() error {
+	file, err := os.OpenFile(r.Filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(r.records)
+	if err != nil {
+		return err
+	}
+
+	logf("Written report file %s", r.Filename)
+	return nil
+}
+
+// AddRecord adds a record to the report.
+func (r *JSONReport) AddRecord(label string, elapsed time.Duration, metrics map[string]float64) error {
+	r.records = append(r.records, JSONRecord{
+		Timestamp: time.Now().UTC(),
+		Label:     label,
+		Elapsed:   elapsed.Seconds(),
+		Metrics:   metrics,
+	})
+
+	return nil
+}
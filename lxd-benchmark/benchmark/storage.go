@@ -0,0 +1,135 @@
+package benchmark
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// VolumeOperations creates, snapshots, clones and deletes custom volumes on the pool, returning the
+// total duration and the duration of each phase in seconds.
+func VolumeOperations(c lxd.ContainerServer, pool string, count int, parallel int, size string) (time.Duration, map[string]float64, error) {
+	var duration time.Duration
+
+	batchSize, err := getBatchSize(parallel)
+	if err != nil {
+		return duration, nil, err
+	}
+
+	_, _, err = c.GetStoragePool(pool)
+	if err != nil {
+		return duration, nil, err
+	}
+
+	fmt.Printf("Test variables:\n")
+	fmt.Printf("  Storage pool: %s\n", pool)
+	fmt.Printf("  Volume count: %d\n", count)
+	fmt.Printf("  Volume size: %s\n", size)
+	fmt.Printf("  Batch size: %d\n", batchSize)
+	fmt.Printf("\n")
+
+	failed := false
+	failedLock := sync.Mutex{}
+	fail := func(format string, args ...interface{}) {
+		logf(format, args...)
+
+		failedLock.Lock()
+		failed = true
+		failedLock.Unlock()
+	}
+
+	phases := []struct {
+		name    string
+		process func(name string) error
+	}{
+		{"create", func(name string) error {
+			req := api.StorageVolumesPost{
+				Name: name,
+				Type: "custom",
+			}
+			req.Config = map[string]string{}
+			if size != "" {
+				req.Config["size"] = size
+			}
+
+			return c.CreateStoragePoolVolume(pool, req)
+		}},
+		{"snapshot", func(name string) error {
+			op, err := c.CreateStoragePoolVolumeSnapshot(pool, "custom", name, api.StorageVolumeSnapshotsPost{Name: "snap0"})
+			if err != nil {
+				return err
+			}
+
+			return op.Wait()
+		}},
+		{"copy", func(name string) error {
+			vol, _, err := c.GetStoragePoolVolume(pool, "custom", name)
+			if err != nil {
+				return err
+			}
+
+			op, err := c.CopyStoragePoolVolume(pool, c, pool, *vol, &lxd.StoragePoolVolumeCopyArgs{Name: name + "-copy"})
+			if err != nil {
+				return err
+			}
+
+			return op.Wait()
+		}},
+		{"delete", func(name string) error {
+			for _, volName := range []string{name + "-copy", name} {
+				snapshots, err := c.GetStoragePoolVolumeSnapshotNames(pool, "custom", volName)
+				if err != nil {
+					return err
+				}
+
+				for _, snapshot := range snapshots {
+					op, err := c.DeleteStoragePoolVolumeSnapshot(pool, "custom", volName, snapshot)
+					if err != nil {
+						return err
+					}
+
+					err = op.Wait()
+					if err != nil {
+						return err
+					}
+				}
+
+				err = c.DeleteStoragePoolVolume(pool, "custom", volName)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}},
+	}
+
+	metrics := map[string]float64{}
+	for _, phase := range phases {
+		logf("Running volume %s phase", phase.name)
+
+		phase := phase
+		phaseDuration := processBatch(count, batchSize, func(index int, wg *sync.WaitGroup) {
+			defer wg.Done()
+
+			name := getVolumeName(count, index)
+			err := phase.process(name)
+			if err != nil {
+				fail("Failed to %s volume '%s': %s", phase.name, name, err)
+			}
+		})
+
+		duration += phaseDuration
+		metrics[fmt.Sprintf("%s_seconds", phase.name)] = phaseDuration.Seconds()
+		metrics[fmt.Sprintf("%s_per_second", phase.name)] = float64(count) / phaseDuration.Seconds()
+	}
+
+	if failed {
+		return duration, metrics, fmt.Errorf("Some volume operations failed")
+	}
+
+	return duration, metrics, nil
+}
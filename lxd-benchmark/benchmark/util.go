@@ -10,6 +10,11 @@ func getContainerName(count int, index int) string {
 	return fmt.Sprintf(nameFormat, index+1)
 }
 
+func getVolumeName(count int, index int) string {
+	nameFormat := "benchmark-vol-%." + fmt.Sprintf("%d", len(fmt.Sprintf("%d", count))) + "d"
+	return fmt.Sprintf(nameFormat, index+1)
+}
+
 func logf(format string, args ...interface{}) {
 	fmt.Printf(fmt.Sprintf("[%s] %s\n", time.Now().Format(time.StampMilli), format), args...)
 }
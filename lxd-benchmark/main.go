@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"time"
 
@@ -13,15 +14,17 @@ import (
 )
 
 type cmdGlobal struct {
-	flagHelp        bool
-	flagParallel    int
-	flagReportFile  string
-	flagReportLabel string
-	flagVersion     bool
+	flagHelp         bool
+	flagParallel     int
+	flagReportFile   string
+	flagReportFormat string
+	flagReportLabel  string
+	flagVersion      bool
 
 	srv            lxd.ContainerServer
-	report         *benchmark.CSVReport
+	report         benchmark.Report
 	reportDuration time.Duration
+	reportMetrics  map[string]float64
 }
 
 func (c *cmdGlobal) Run(cmd *cobra.Command, args []string) error {
@@ -37,7 +40,15 @@ func (c *cmdGlobal) Run(cmd *cobra.Command, args []string) error {
 
 	// Setup report handling
 	if c.flagReportFile != "" {
-		c.report = &benchmark.CSVReport{Filename: c.flagReportFile}
+		switch c.flagReportFormat {
+		case "csv":
+			c.report = &benchmark.CSVReport{Filename: c.flagReportFile}
+		case "json":
+			c.report = &benchmark.JSONReport{Filename: c.flagReportFile}
+		default:
+			return fmt.Errorf("Invalid report format %q", c.flagReportFormat)
+		}
+
 		if shared.PathExists(c.flagReportFile) {
 			err := c.report.Load()
 			if err != nil {
@@ -60,9 +71,12 @@ func (c *cmdGlobal) Teardown(cmd *cobra.Command, args []string) error {
 		label = c.flagReportLabel
 	}
 
-	c.report.AddRecord(label, c.reportDuration)
+	err := c.report.AddRecord(label, c.reportDuration, c.reportMetrics)
+	if err != nil {
+		return err
+	}
 
-	err := c.report.Write()
+	err = c.report.Write()
 	if err != nil {
 		return err
 	}
@@ -83,7 +97,8 @@ func main() {
   compare performance on different servers or for performance tracking
   when doing changes to the LXD codebase.
 
-  A CSV report can be produced to be consumed by graphing software.
+  A CSV report can be produced to be consumed by graphing software, or
+  a JSON report including all the measured metrics for regression tracking.
 `
 	app.Example = `  # Spawn 20 Ubuntu containers in batches of 4
   lxd-benchmark launch --count 20 --parallel 4
@@ -92,7 +107,13 @@ func main() {
   lxd-benchmark init --count 50 --parallel 10 images:alpine/edge
 
   # Delete all test containers using dynamic batch size
-  lxd-benchmark delete`
+  lxd-benchmark delete
+
+  # Measure volume operations on the "default" pool, recording the results
+  lxd-benchmark storage --count 20 --report-file results.json --report-format json default
+
+  # Measure the throughput between two containers on "lxdbr0"
+  lxd-benchmark network lxdbr0`
 	app.SilenceUsage = true
 
 	// Global flags
@@ -102,7 +123,8 @@ func main() {
 	app.PersistentFlags().BoolVar(&globalCmd.flagVersion, "version", false, "Print version number")
 	app.PersistentFlags().BoolVarP(&globalCmd.flagHelp, "help", "h", false, "Print help")
 	app.PersistentFlags().IntVarP(&globalCmd.flagParallel, "parallel", "P", -1, "Number of threads to use"+"``")
-	app.PersistentFlags().StringVar(&globalCmd.flagReportFile, "report-file", "", "Path to the CSV report file"+"``")
+	app.PersistentFlags().StringVar(&globalCmd.flagReportFile, "report-file", "", "Path to the report file"+"``")
+	app.PersistentFlags().StringVar(&globalCmd.flagReportFormat, "report-format", "csv", "Format of the report file (csv or json)"+"``")
 	app.PersistentFlags().StringVar(&globalCmd.flagReportLabel, "report-label", "", "Label for the new entry in the report [default=ACTION]"+"``")
 
 	// Version handling
@@ -129,6 +151,14 @@ func main() {
 	deleteCmd := cmdDelete{global: &globalCmd}
 	app.AddCommand(deleteCmd.Command())
 
+	// storage sub-command
+	storageCmd := cmdStorage{global: &globalCmd}
+	app.AddCommand(storageCmd.Command())
+
+	// network sub-command
+	networkCmd := cmdNetwork{global: &globalCmd}
+	app.AddCommand(networkCmd.Command())
+
 	// Run the main command and handle errors
 	err := app.Execute()
 	if err != nil {
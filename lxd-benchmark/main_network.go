@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/lxc/lxd/lxd-benchmark/benchmark"
+)
+
+type cmdNetwork struct {
+	global *cmdGlobal
+
+	flagImage    string
+	flagDuration int
+}
+
+func (c *cmdNetwork) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "network [<network>]"
+	cmd.Short = "Measure the throughput between containers on a managed network"
+	cmd.RunE = c.Run
+	cmd.Flags().StringVar(&c.flagImage, "image", "images:alpine/edge", "Image to use for the containers"+"``")
+	cmd.Flags().IntVar(&c.flagDuration, "duration", 10, "Duration of the measurement in seconds"+"``")
+
+	return cmd
+}
+
+func (c *cmdNetwork) Run(cmd *cobra.Command, args []string) error {
+	// Choose the network
+	network := "lxdbr0"
+	if len(args) > 0 {
+		network = args[0]
+	}
+
+	// Run the test
+	duration, metrics, err := benchmark.NetworkThroughput(c.global.srv, network, c.flagImage, c.flagDuration)
+	if err != nil {
+		return err
+	}
+
+	c.global.reportDuration = duration
+	c.global.reportMetrics = metrics
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/lxc/lxd/lxd-benchmark/benchmark"
+)
+
+type cmdStorage struct {
+	global *cmdGlobal
+
+	flagCount int
+	flagSize  string
+}
+
+func (c *cmdStorage) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "storage [<pool>]"
+	cmd.Short = "Create, snapshot, copy and delete storage volumes"
+	cmd.RunE = c.Run
+	cmd.Flags().IntVarP(&c.flagCount, "count", "C", 10, "Number of volumes to create"+"``")
+	cmd.Flags().StringVar(&c.flagSize, "size", "", "Size of the volumes"+"``")
+
+	return cmd
+}
+
+func (c *cmdStorage) Run(cmd *cobra.Command, args []string) error {
+	// Choose the pool
+	pool := "default"
+	if len(args) > 0 {
+		pool = args[0]
+	}
+
+	// Run the test
+	duration, metrics, err := benchmark.VolumeOperations(c.global.srv, pool, c.flagCount, c.global.flagParallel, c.flagSize)
+	if err != nil {
+		return err
+	}
+
+	c.global.reportDuration = duration
+	c.global.reportMetrics = metrics
+
+	return nil
+}
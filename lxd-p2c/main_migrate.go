@@ -29,14 +29,15 @@ type cmdMigrate struct {
 	flagType        string
 	flagRsyncArgs   string
 	flagNoProfiles  bool
+	flagVM          bool
 }
 
 func (c *cmdMigrate) Command() *cobra.Command {
 	cmd := &cobra.Command{}
-	cmd.Use = "lxd-p2c <target URL> <container name> <filesystem root> [<filesystem mounts>...]"
-	cmd.Short = "Physical to container migration tool"
+	cmd.Use = "lxd-p2c <target URL> <instance name> <filesystem root> [<filesystem mounts>...]"
+	cmd.Short = "Physical to instance migration tool"
 	cmd.Long = `Description:
-  Physical to container migration tool
+  Physical to instance migration tool
 
   This tool lets you turn any Linux filesystem (including your current one)
   into a LXD container on a remote LXD host.
@@ -45,6 +46,13 @@ func (c *cmdMigrate) Command() *cobra.Command {
   additional mount you list, then transfer this through LXD's migration
   API to create a new container from it.
 
+  With --vm, the source is instead a whole disk, either a block device or a
+  disk image of an existing virtual machine, which is streamed through the
+  migration API to create a new virtual machine booting from it. Disk images
+  which aren't raw are first converted using qemu-img. The disk shouldn't be
+  in use during the transfer, so physical hosts are best migrated from a live
+  environment.
+
   The same set of options as ` + "`lxc launch`" + ` are also supported.
 `
 	cmd.RunE = c.Run
@@ -56,6 +64,7 @@ func (c *cmdMigrate) Command() *cobra.Command {
 	cmd.Flags().StringVarP(&c.flagType, "type", "t", "", "Instance type to use for the container"+"``")
 	cmd.Flags().StringVar(&c.flagRsyncArgs, "rsync-args", "", "Extra arguments to pass to rsync"+"``")
 	cmd.Flags().BoolVar(&c.flagNoProfiles, "no-profiles", false, "Create the container with no profiles applied")
+	cmd.Flags().BoolVar(&c.flagVM, "vm", false, "Create a virtual machine from a disk instead of a container")
 
 	return cmd
 }
@@ -90,10 +99,19 @@ func (c *cmdMigrate) Run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("Missing required arguments")
 	}
 
+	if c.flagVM && len(args) > 3 {
+		return fmt.Errorf("Virtual machines can only be created from a single disk")
+	}
+
 	// Get and sort the mounts
 	mounts := args[2:]
 	sort.Strings(mounts)
 
+	instanceType := api.InstanceTypeContainer
+	if c.flagVM {
+		instanceType = api.InstanceTypeVM
+	}
+
 	// Create the mount namespace and ensure we're not moved around
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
@@ -122,17 +140,41 @@ func (c *cmdMigrate) Run(cmd *cobra.Command, args []string) error {
 		os.Remove(path)
 	}(path)
 
-	// Create the rootfs directory
-	fullPath := fmt.Sprintf("%s/rootfs", path)
-	err = os.Mkdir(fullPath, 0755)
-	if err != nil {
-		return err
-	}
+	var fullPath string
+	var diskPath string
+	var diskSize int64
+	if c.flagVM {
+		// Create the (empty) config filesystem directory
+		fullPath = fmt.Sprintf("%s/config", path)
+		err = os.Mkdir(fullPath, 0755)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(fullPath)
 
-	// Setup the source (mounts)
-	err = setupSource(fullPath, mounts)
-	if err != nil {
-		return fmt.Errorf("Failed to setup the source: %v", err)
+		// Setup the source (disk)
+		diskPath, diskSize, err = setupDisk(args[2], path)
+		if err != nil {
+			return fmt.Errorf("Failed to setup the source: %v", err)
+		}
+
+		// Remove the converted disk image on exit
+		if diskPath != args[2] {
+			defer os.Remove(diskPath)
+		}
+	} else {
+		// Create the rootfs directory
+		fullPath = fmt.Sprintf("%s/rootfs", path)
+		err = os.Mkdir(fullPath, 0755)
+		if err != nil {
+			return err
+		}
+
+		// Setup the source (mounts)
+		err = setupSource(fullPath, mounts)
+		if err != nil {
+			return fmt.Errorf("Failed to setup the source: %v", err)
+		}
 	}
 
 	URL, err := parseURL(args[0])
@@ -146,10 +188,11 @@ func (c *cmdMigrate) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Container creation request
-	apiArgs := api.ContainersPost{}
+	// Instance creation request
+	apiArgs := api.InstancesPost{}
 	apiArgs.Name = args[1]
-	apiArgs.Source = api.ContainerSource{
+	apiArgs.Type = instanceType
+	apiArgs.Source = api.InstanceSource{
 		Type: "migration",
 		Mode: "push",
 	}
@@ -211,38 +254,42 @@ func (c *cmdMigrate) Run(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Check if the container already exists
-	_, _, err = dst.GetContainer(apiArgs.Name)
+	// Check if the instance already exists
+	_, _, err = dst.GetInstance(apiArgs.Name)
 	if err == nil {
-		return fmt.Errorf("Container '%s' already exists", apiArgs.Name)
+		return fmt.Errorf("Instance '%s' already exists", apiArgs.Name)
 	}
 
-	// Create the container
+	// Create the instance
 	success := false
-	op, err := dst.CreateContainer(apiArgs)
+	op, err := dst.CreateInstance(apiArgs)
 	if err != nil {
 		return err
 	}
 
 	defer func() {
 		if !success {
-			dst.DeleteContainer(apiArgs.Name)
+			dst.DeleteInstance(apiArgs.Name)
 		}
 	}()
 
-	progress := utils.ProgressRenderer{Format: "Transferring container: %s"}
+	progress := utils.ProgressRenderer{Format: "Transferring instance: %s"}
 	_, err = op.AddHandler(progress.UpdateOp)
 	if err != nil {
 		progress.Done("")
 		return err
 	}
 
-	err = transferRootfs(dst, op, fullPath, c.flagRsyncArgs)
+	if c.flagVM {
+		err = transferDisk(dst, op, fullPath, diskPath, diskSize, c.flagRsyncArgs)
+	} else {
+		err = transferRootfs(dst, op, fullPath, c.flagRsyncArgs)
+	}
 	if err != nil {
 		return err
 	}
 
-	progress.Done(fmt.Sprintf("Container %s successfully created", apiArgs.Name))
+	progress.Done(fmt.Sprintf("Instance %s successfully created", apiArgs.Name))
 	success = true
 
 	return nil
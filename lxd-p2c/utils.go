@@ -2,11 +2,17 @@ package main
 
 import (
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"io"
 	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 
+	"github.com/gorilla/websocket"
 	"golang.org/x/crypto/ssh/terminal"
 	"golang.org/x/sys/unix"
 
@@ -18,6 +24,47 @@ import (
 )
 
 func transferRootfs(dst lxd.ContainerServer, op lxd.Operation, rootfs string, rsyncArgs string) error {
+	fs := migration.MigrationFSType_RSYNC
+	rsyncHasFeature := true
+	header := migration.MigrationHeader{
+		RsyncFeatures: &migration.RsyncFeatures{
+			Xattrs:   &rsyncHasFeature,
+			Delete:   &rsyncHasFeature,
+			Compress: &rsyncHasFeature,
+		},
+		Fs: &fs,
+	}
+
+	return transfer(op, &header, func(wsFs *websocket.Conn) error {
+		return rsyncSend(wsFs, rootfs, rsyncArgs)
+	})
+}
+
+func transferDisk(dst lxd.ContainerServer, op lxd.Operation, configfs string, disk string, diskSize int64, rsyncArgs string) error {
+	fs := migration.MigrationFSType_BLOCK_AND_RSYNC
+	rsyncHasFeature := true
+	header := migration.MigrationHeader{
+		RsyncFeatures: &migration.RsyncFeatures{
+			Xattrs:   &rsyncHasFeature,
+			Delete:   &rsyncHasFeature,
+			Compress: &rsyncHasFeature,
+		},
+		Fs:         &fs,
+		VolumeSize: &diskSize,
+	}
+
+	return transfer(op, &header, func(wsFs *websocket.Conn) error {
+		// The config filesystem is sent first, followed by the disk itself.
+		err := rsyncSend(wsFs, shared.AddSlash(configfs), rsyncArgs)
+		if err != nil {
+			return err
+		}
+
+		return blockSend(wsFs, disk)
+	})
+}
+
+func transfer(op lxd.Operation, header *migration.MigrationHeader, send func(wsFs *websocket.Conn) error) error {
 	opAPI := op.Get()
 
 	// Connect to the websockets
@@ -31,25 +78,13 @@ func transferRootfs(dst lxd.ContainerServer, op lxd.Operation, rootfs string, rs
 		return err
 	}
 
-	// Setup control struct
-	fs := migration.MigrationFSType_RSYNC
-	rsyncHasFeature := true
-	header := migration.MigrationHeader{
-		RsyncFeatures: &migration.RsyncFeatures{
-			Xattrs:   &rsyncHasFeature,
-			Delete:   &rsyncHasFeature,
-			Compress: &rsyncHasFeature,
-		},
-		Fs: &fs,
-	}
-
-	err = migration.ProtoSend(wsControl, &header)
+	err = migration.ProtoSend(wsControl, header)
 	if err != nil {
 		protoSendError(wsControl, err)
 		return err
 	}
 
-	err = migration.ProtoRecv(wsControl, &header)
+	err = migration.ProtoRecv(wsControl, header)
 	if err != nil {
 		protoSendError(wsControl, err)
 		return err
@@ -61,7 +96,7 @@ func transferRootfs(dst lxd.ContainerServer, op lxd.Operation, rootfs string, rs
 		return err
 	}
 
-	err = rsyncSend(wsFs, rootfs, rsyncArgs)
+	err = send(wsFs)
 	if err != nil {
 		return abort(err)
 	}
@@ -189,6 +224,53 @@ func setupSource(path string, mounts []string) error {
 	return nil
 }
 
+func setupDisk(source string, tmpPath string) (string, int64, error) {
+	path := source
+
+	// Convert disk images which aren't raw
+	_, err := exec.LookPath("qemu-img")
+	if err == nil {
+		out, err := shared.RunCommand("qemu-img", "info", "--output=json", source)
+		if err != nil {
+			return "", -1, fmt.Errorf("Failed to inspect %s: %v", source, err)
+		}
+
+		info := struct {
+			Format string `json:"format"`
+		}{}
+
+		err = json.Unmarshal([]byte(out), &info)
+		if err != nil {
+			return "", -1, fmt.Errorf("Failed to parse information about %s: %v", source, err)
+		}
+
+		if info.Format != "raw" {
+			path = filepath.Join(tmpPath, "root.img")
+
+			fmt.Printf("Converting %s disk image to raw format\n", info.Format)
+			_, err = shared.RunCommand("qemu-img", "convert", "-f", info.Format, "-O", "raw", source, path)
+			if err != nil {
+				os.Remove(path)
+				return "", -1, fmt.Errorf("Failed to convert %s: %v", source, err)
+			}
+		}
+	}
+
+	// Get the size of the disk (works for both files and block devices)
+	f, err := os.Open(path)
+	if err != nil {
+		return "", -1, err
+	}
+	defer f.Close()
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return "", -1, fmt.Errorf("Failed to get the size of %s: %v", path, err)
+	}
+
+	return path, size, nil
+}
+
 func parseURL(URL string) (string, error) {
 	u, err := url.Parse(URL)
 	if err != nil {
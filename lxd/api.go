@@ -49,12 +49,18 @@ func restServer(d *Daemon) *http.Server {
 		d.createCmd(mux, "internal", c)
 	}
 
+	for _, c := range apiStreams {
+		d.createCmd(mux, "streams/v1", c)
+	}
+
 	mux.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		logger.Info("Sending top level 404", log.Ctx{"url": r.URL})
 		w.Header().Set("Content-Type", "application/json")
 		response.NotFound(nil).Render(w)
 	})
 
+	d.router = mux
+
 	return &http.Server{Handler: &lxdHttpServer{r: mux, d: d}}
 }
 
@@ -65,6 +71,7 @@ type lxdHttpServer struct {
 
 func (s *lxdHttpServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	// Set CORS headers, unless this is an internal request.
+	compress := false
 	if !strings.HasPrefix(req.URL.Path, "/internal") {
 		<-s.d.setupChan
 		err := s.d.cluster.Transaction(func(tx *db.ClusterTx) error {
@@ -73,6 +80,7 @@ func (s *lxdHttpServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 				return err
 			}
 			setCORSHeaders(rw, req, config)
+			compress = config.HTTPSCompression()
 			return nil
 		})
 		if err != nil {
@@ -87,6 +95,16 @@ func (s *lxdHttpServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// Compress JSON responses if enabled and supported by the client.
+	if compress {
+		encoding := response.CompressionEncoding(req)
+		if encoding != "" {
+			w, finish := response.NewCompressedWriter(rw, encoding)
+			defer finish()
+			rw = w
+		}
+	}
+
 	// Call the original server
 	s.r.ServeHTTP(rw, req)
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"strings"
@@ -33,14 +34,20 @@ var api10Cmd = APIEndpoint{
 var api10 = []APIEndpoint{
 	api10Cmd,
 	api10ResourcesCmd,
+	backupsCmd,
+	batchCmd,
 	certificateCmd,
 	certificatesCmd,
 	clusterCmd,
+	clusterGroupCmd,
+	clusterGroupsCmd,
 	clusterNodeCmd,
 	clusterNodesCmd,
+	clusterNodeStateCmd,
 	instanceBackupCmd,
 	instanceBackupExportCmd,
 	instanceBackupsCmd,
+	instanceBackupVerifyCmd,
 	instanceCmd,
 	instanceConsoleCmd,
 	instanceExecCmd,
@@ -49,6 +56,7 @@ var api10 = []APIEndpoint{
 	instanceLogsCmd,
 	instanceMetadataCmd,
 	instanceMetadataTemplatesCmd,
+	instanceRebuildCmd,
 	instancesCmd,
 	instanceSnapshotCmd,
 	instanceSnapshotsCmd,
@@ -61,29 +69,48 @@ var api10 = []APIEndpoint{
 	imageRefreshCmd,
 	imagesCmd,
 	imageSecretCmd,
+	metricsCmd,
+	networkACLCmd,
+	networkACLsCmd,
 	networkCmd,
+	networkForwardCmd,
+	networkForwardsCmd,
 	networkLeasesCmd,
+	networkMetricsCmd,
+	networkPeerCmd,
+	networkPeersCmd,
+	networkReservationCmd,
+	networkReservationsCmd,
 	networksCmd,
 	networkStateCmd,
+	networkZoneCmd,
+	networkZonesCmd,
 	operationCmd,
 	operationsCmd,
 	operationWait,
 	operationWebsocket,
+	preseedCmd,
 	profileCmd,
 	profilesCmd,
 	projectCmd,
 	projectsCmd,
+	searchCmd,
 	storagePoolCmd,
 	storagePoolResourcesCmd,
 	storagePoolsCmd,
 	storagePoolVolumesCmd,
 	storagePoolVolumeSnapshotsTypeCmd,
 	storagePoolVolumeSnapshotTypeCmd,
+	storagePoolVolumeTypeCustomBackupsCmd,
+	storagePoolVolumeTypeCustomBackupCmd,
+	storagePoolVolumeTypeCustomBackupExportCmd,
 	storagePoolVolumesTypeCmd,
 	storagePoolVolumeTypeContainerCmd,
 	storagePoolVolumeTypeCustomCmd,
 	storagePoolVolumeTypeImageCmd,
 	storagePoolVolumeTypeVMCmd,
+	warningCmd,
+	warningsCmd,
 }
 
 func api10Get(d *Daemon, r *http.Request) response.Response {
@@ -100,6 +127,11 @@ func api10Get(d *Daemon, r *http.Request) response.Response {
 			authMethods = append(authMethods, "candid")
 		}
 
+		oidcIssuer, oidcClientID, _ := config.OIDCServer()
+		if oidcIssuer != "" && oidcClientID != "" {
+			authMethods = append(authMethods, "oidc")
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -433,7 +465,21 @@ func doApi10Update(d *Daemon, req api.ServerPut, patch bool) response.Response {
 		} else {
 			clusterChanged, err = newClusterConfig.Replace(req.Config)
 		}
-		return err
+		if err != nil {
+			return err
+		}
+
+		// External services can't be reached in offline mode
+		if newClusterConfig.Offline() {
+			maasURL, _ := newClusterConfig.MAASController()
+			candidURL, _, _, _ := newClusterConfig.CandidServer()
+			rbacURL, _, _, _, _, _, _ := newClusterConfig.RBACServer()
+			if maasURL != "" || candidURL != "" || rbacURL != "" {
+				return config.ErrorList{&config.Error{Name: "core.offline", Value: true, Reason: "MAAS, Candid and RBAC can't be used in offline mode"}}
+			}
+		}
+
+		return nil
 	})
 	if err != nil {
 		switch err.(type) {
@@ -481,15 +527,33 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 	maasChanged := false
 	candidChanged := false
 	rbacChanged := false
+	oidcChanged := false
+	webhookChanged := false
+	bgpChanged := false
 
 	for key := range clusterChanged {
 		switch key {
+		case "core.bgp_asn":
+			bgpChanged = true
 		case "core.proxy_http":
 			fallthrough
 		case "core.proxy_https":
 			fallthrough
 		case "core.proxy_ignore_hosts":
 			daemonConfigSetProxy(d, clusterConfig)
+			webhookChanged = true
+		case "core.offline":
+			fallthrough
+		case "events.webhook.url":
+			fallthrough
+		case "events.webhook.secret":
+			fallthrough
+		case "events.webhook.types":
+			fallthrough
+		case "events.webhook.retries":
+			fallthrough
+		case "events.webhook.retry_interval":
+			webhookChanged = true
 		case "maas.api.url":
 			fallthrough
 		case "maas.api.key":
@@ -502,6 +566,12 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 			fallthrough
 		case "candid.api.url":
 			candidChanged = true
+		case "oidc.audience":
+			fallthrough
+		case "oidc.client.id":
+			fallthrough
+		case "oidc.issuer":
+			oidcChanged = true
 		case "images.auto_update_interval":
 			if !d.os.MockMode {
 				d.taskAutoUpdate.Reset()
@@ -510,6 +580,10 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 			if !d.os.MockMode {
 				d.taskPruneImages.Reset()
 			}
+		case "network.stats.interval":
+			if !d.os.MockMode {
+				d.taskNetworkStats.Reset()
+			}
 		case "rbac.agent.url":
 			fallthrough
 		case "rbac.agent.username":
@@ -537,6 +611,11 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 		maasChanged = true
 	}
 
+	_, ok = nodeChanged["core.syslog_events"]
+	if ok {
+		d.events.SetSyslogTypes(nodeConfig.SyslogEvents())
+	}
+
 	value, ok := nodeChanged["core.https_address"]
 	if ok {
 		err := d.endpoints.NetworkUpdateAddress(value)
@@ -561,6 +640,31 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 		}
 	}
 
+	_, ok = nodeChanged["core.bgp_address"]
+	if ok {
+		bgpChanged = true
+	}
+
+	_, ok = nodeChanged["core.bgp_routerid"]
+	if ok {
+		bgpChanged = true
+	}
+
+	if bgpChanged {
+		err := d.bgp.Reconfigure(nodeConfig.BGPAddress(), uint32(clusterConfig.BGPASN()), net.ParseIP(nodeConfig.BGPRouterID()))
+		if err != nil {
+			return errors.Wrap(err, "Failed reconfiguring BGP server")
+		}
+	}
+
+	value, ok = nodeChanged["core.dns_address"]
+	if ok {
+		err := d.dns.Reconfigure(value)
+		if err != nil {
+			return err
+		}
+	}
+
 	value, ok = nodeChanged["storage.backups_volume"]
 	if ok {
 		err := daemonStorageMove(s, "backups", value)
@@ -594,6 +698,25 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 		}
 	}
 
+	if oidcChanged {
+		issuer, clientID, audience := clusterConfig.OIDCServer()
+		d.setupOIDC(issuer, clientID, audience)
+	}
+
+	if webhookChanged {
+		var serverName string
+		err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			var err error
+			serverName, err = tx.GetLocalNodeName()
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		daemonConfigSetWebhook(d, clusterConfig, serverName)
+	}
+
 	if rbacChanged {
 		apiURL, apiKey, apiExpiry, agentURL, agentUsername, agentPrivateKey, agentPublicKey := clusterConfig.RBACServer()
 
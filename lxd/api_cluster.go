@@ -17,8 +17,11 @@ import (
 	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/node"
 	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
@@ -38,7 +41,8 @@ var clusterCmd = APIEndpoint{
 var clusterNodesCmd = APIEndpoint{
 	Path: "cluster/members",
 
-	Get: APIEndpointAction{Handler: clusterNodesGet, AccessHandler: allowAuthenticated},
+	Get:  APIEndpointAction{Handler: clusterNodesGet, AccessHandler: allowAuthenticated},
+	Post: APIEndpointAction{Handler: clusterNodesPost},
 }
 
 var clusterNodeCmd = APIEndpoint{
@@ -51,6 +55,12 @@ var clusterNodeCmd = APIEndpoint{
 	Post:   APIEndpointAction{Handler: clusterNodePost},
 }
 
+var clusterNodeStateCmd = APIEndpoint{
+	Path: "cluster/members/{name}/state",
+
+	Post: APIEndpointAction{Handler: clusterNodeStatePost},
+}
+
 var internalClusterAcceptCmd = APIEndpoint{
 	Path: "cluster/accept",
 
@@ -417,14 +427,16 @@ func clusterPutJoin(d *Daemon, req api.ClusterPut) response.Response {
 			pools = append(pools, *pool)
 		}
 
+		// Only the networks of the default project are compared, as the joining member mustn't have any
+		// network in other projects.
 		networks := []api.Network{}
-		networkNames, err := d.cluster.GetNetworks()
+		networkNames, err := d.cluster.GetNetworks(project.Default)
 		if err != nil && err != db.ErrNoSuchObject {
 			return err
 		}
 
 		for _, name := range networkNames {
-			_, network, err := d.cluster.GetNetworkInAnyState(name)
+			_, network, err := d.cluster.GetNetworkInAnyState(project.Default, name)
 			if err != nil {
 				return err
 			}
@@ -860,6 +872,103 @@ func clusterNodesGet(d *Daemon, r *http.Request) response.Response {
 	return response.SyncResponse(true, result)
 }
 
+// clusterNodesPost issues a single-use join token for a new cluster member with the given name.
+func clusterNodesPost(d *Daemon, r *http.Request) response.Response {
+	req := api.ClusterMembersPost{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.ServerName == "" {
+		return response.BadRequest(fmt.Errorf("The cluster member name must be provided"))
+	}
+
+	clustered, err := cluster.Enabled(d.db)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !clustered {
+		return response.BadRequest(fmt.Errorf("This server is not clustered"))
+	}
+
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		_, err := tx.GetNodeByName(req.ServerName)
+		if err == nil {
+			return fmt.Errorf("The cluster already has a member with name: %s", req.ServerName)
+		}
+
+		if err != db.ErrNoSuchObject {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Only allow one pending join token per member name.
+	for _, op := range operations.Clone() {
+		if op.Type() != db.OperationClusterJoinToken || op.Status() != api.Running {
+			continue
+		}
+
+		if op.Metadata()["serverName"] == req.ServerName {
+			return response.BadRequest(fmt.Errorf("A join token already exists for the name: %s", req.ServerName))
+		}
+	}
+
+	var config *cluster.Config
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		config, err = cluster.ConfigLoad(tx)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	address, err := node.ClusterAddress(d.db)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	addresses, err := util.ListenAddresses(address)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	secret, err := shared.RandomCryptoString()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	token := api.ClusterMemberJoinToken{
+		ServerName:  req.ServerName,
+		Fingerprint: d.endpoints.NetworkCert().Fingerprint(),
+		Addresses:   addresses,
+		Secret:      secret,
+	}
+
+	meta := map[string]interface{}{
+		"secret":      secret,
+		"fingerprint": token.Fingerprint,
+		"addresses":   token.Addresses,
+		"serverName":  req.ServerName,
+		"token":       token.String(),
+	}
+
+	op, err := tokenCreate(d, db.OperationClusterJoinToken, config.JoinTokenExpiry(), meta)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
 func clusterNodeGet(d *Daemon, r *http.Request) response.Response {
 	name := mux.Vars(r)["name"]
 
@@ -918,12 +1027,46 @@ func clusterNodePut(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// Validate the request
-	if shared.StringInSlice(string(db.ClusterRoleDatabase), current.Roles) && !shared.StringInSlice(string(db.ClusterRoleDatabase), req.Roles) {
-		return response.BadRequest(fmt.Errorf("The '%s' role cannot be dropped at this time", db.ClusterRoleDatabase))
+	if shared.StringInSlice(string(db.ClusterRoleDatabaseLeader), current.Roles) != shared.StringInSlice(string(db.ClusterRoleDatabaseLeader), req.Roles) {
+		return response.BadRequest(fmt.Errorf("The '%s' role cannot be changed", db.ClusterRoleDatabaseLeader))
 	}
 
-	if !shared.StringInSlice(string(db.ClusterRoleDatabase), current.Roles) && shared.StringInSlice(string(db.ClusterRoleDatabase), req.Roles) {
-		return response.BadRequest(fmt.Errorf("The '%s' role cannot be added at this time", db.ClusterRoleDatabase))
+	if shared.StringInSlice(string(db.ClusterRoleDatabase), req.Roles) && shared.StringInSlice(string(db.ClusterRoleDatabaseStandBy), req.Roles) {
+		return response.BadRequest(fmt.Errorf("The '%s' and '%s' roles are mutually exclusive", db.ClusterRoleDatabase, db.ClusterRoleDatabaseStandBy))
+	}
+
+	// Apply database role changes to the raft configuration.
+	role := clusterMemberRaftRole(req.Roles)
+	if role != clusterMemberRaftRole(current.Roles) {
+		address, err := node.ClusterAddress(d.db)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		leader, err := d.gateway.LeaderAddress()
+		if err != nil {
+			return response.InternalError(err)
+		}
+
+		// Only the leader can change the raft configuration, forward the request to it.
+		if address != leader {
+			client, err := cluster.Connect(leader, d.endpoints.NetworkCert(), true)
+			if err != nil {
+				return response.SmartError(err)
+			}
+
+			err = client.UpdateClusterMember(name, req, "")
+			if err != nil {
+				return response.SmartError(err)
+			}
+
+			return response.EmptySyncResponse
+		}
+
+		err = changeMemberRaftRole(d, strings.TrimPrefix(current.URL, "https://"), role)
+		if err != nil {
+			return response.SmartError(err)
+		}
 	}
 
 	// Update the database
@@ -948,6 +1091,11 @@ func clusterNodePut(d *Daemon, r *http.Request) response.Response {
 			return errors.Wrap(err, "Update failure domain")
 		}
 
+		err = tx.UpdateNodeClusterGroups(nodeInfo.ID, req.Groups)
+		if err != nil {
+			return errors.Wrap(err, "Update cluster groups")
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -1031,15 +1179,17 @@ func clusterNodeDelete(d *Daemon, r *http.Request) response.Response {
 			return response.SmartError(err)
 		}
 
-		networks, err := d.cluster.GetNetworks()
+		projectNetworks, err := d.cluster.GetNetworksAllProjects()
 		if err != nil {
 			return response.SmartError(err)
 		}
 
-		for _, name := range networks {
-			err := client.DeleteNetwork(name)
-			if err != nil {
-				return response.SmartError(err)
+		for projectName, networks := range projectNetworks {
+			for _, name := range networks {
+				err := client.UseProject(projectName).DeleteNetwork(name)
+				if err != nil {
+					return response.SmartError(err)
+				}
 			}
 		}
 
@@ -1087,6 +1237,357 @@ func clusterNodeDelete(d *Daemon, r *http.Request) response.Response {
 	return response.EmptySyncResponse
 }
 
+// Evacuate or restore a cluster member.
+func clusterNodeStatePost(d *Daemon, r *http.Request) response.Response {
+	name := mux.Vars(r)["name"]
+
+	// Forward the request to the member being evacuated or restored.
+	address, err := cluster.ResolveTarget(d.cluster, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if address != "" {
+		client, err := cluster.Connect(address, d.endpoints.NetworkCert(), false)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return response.ForwardedResponse(client, r)
+	}
+
+	req := api.ClusterMemberStatePost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	switch req.Action {
+	case "evacuate":
+		return clusterNodeEvacuate(d, name)
+	case "restore":
+		return clusterNodeRestore(d, name)
+	}
+
+	return response.BadRequest(fmt.Errorf("Unknown action %q", req.Action))
+}
+
+// clusterNodeEvacuateMode returns how the given instance should be evacuated, resolving the "auto"
+// value of its cluster.evacuate config key.
+func clusterNodeEvacuateMode(inst instance.Instance) string {
+	mode := inst.ExpandedConfig()["cluster.evacuate"]
+	if mode != "" && mode != "auto" {
+		return mode
+	}
+
+	// Instances relying on host specific devices can't be moved.
+	for _, dev := range inst.ExpandedDevices() {
+		if shared.StringInSlice(dev["type"], []string{"gpu", "usb", "pci", "unix-char", "unix-block", "infiniband"}) {
+			return "stop"
+		}
+
+		if dev["type"] == "disk" && dev["path"] != "/" && dev["pool"] == "" {
+			return "stop"
+		}
+	}
+
+	return "migrate"
+}
+
+// clusterNodeEvacuate marks the local member as evacuated and moves its instances to other members.
+func clusterNodeEvacuate(d *Daemon, name string) response.Response {
+	var nodeID int64
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		member, err := tx.GetNodeByName(name)
+		if err != nil {
+			return err
+		}
+
+		if member.State == db.ClusterMemberStateEvacuated {
+			return fmt.Errorf("Member %q is already evacuated", name)
+		}
+
+		nodeID = member.ID
+		return nil
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	instances, err := instance.LoadNodeAll(d.State(), instancetype.Any)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	run := func(op *operations.Operation) error {
+		// Mark the member as evacuated first, so that it's not picked as a target.
+		err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			return tx.UpdateNodeStatus(nodeID, db.ClusterMemberStateEvacuated)
+		})
+		if err != nil {
+			return errors.Wrap(err, "Failed to update member state")
+		}
+
+		localAddress, err := node.ClusterAddress(d.db)
+		if err != nil {
+			return err
+		}
+
+		source, err := cluster.Connect(localAddress, d.endpoints.NetworkCert(), true)
+		if err != nil {
+			return errors.Wrap(err, "Failed to connect to local member")
+		}
+
+		for i, inst := range instances {
+			mode := clusterNodeEvacuateMode(inst)
+			running := inst.IsRunning()
+
+			err := op.UpdateMetadata(map[string]interface{}{
+				"evacuate_progress": fmt.Sprintf("Evacuating instance %q (%d/%d)", inst.Name(), i+1, len(instances)),
+			})
+			if err != nil {
+				return err
+			}
+
+			client := source.UseProject(inst.Project())
+
+			live := mode == "live-migrate" && running && inst.Type() == instancetype.VM
+			if running && !live {
+				stopOp, err := client.UpdateInstanceState(inst.Name(), api.InstanceStatePut{Action: "stop", Timeout: -1}, "")
+				if err != nil {
+					return errors.Wrapf(err, "Failed to stop instance %q", inst.Name())
+				}
+
+				err = stopOp.Wait()
+				if err != nil {
+					return errors.Wrapf(err, "Failed to stop instance %q", inst.Name())
+				}
+			}
+
+			// Remember where the instance comes from and whether it was running.
+			evacuateState := map[string]string{"volatile.evacuate.origin": name}
+			if running {
+				evacuateState["volatile.evacuate.running"] = "true"
+			}
+
+			if mode == "stop" {
+				err = clusterNodeUpdateEvacuateState(d, inst.Project(), inst.Name(), evacuateState)
+				if err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			var targetNode string
+			err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+				targetNode, err = tx.GetNodeWithLeastInstances([]int{inst.Architecture()}, "")
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			if targetNode == "" {
+				return fmt.Errorf("No cluster member available to move instance %q to", inst.Name())
+			}
+
+			err = clusterNodeMoveInstance(client, inst.Name(), targetNode, live)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to migrate instance %q to %q", inst.Name(), targetNode)
+			}
+
+			err = clusterNodeUpdateEvacuateState(d, inst.Project(), inst.Name(), evacuateState)
+			if err != nil {
+				return err
+			}
+
+			// Start the instance again on its new member.
+			if running && !live {
+				startOp, err := client.UpdateInstanceState(inst.Name(), api.InstanceStatePut{Action: "start", Timeout: -1}, "")
+				if err != nil {
+					return errors.Wrapf(err, "Failed to start instance %q", inst.Name())
+				}
+
+				err = startOp.Wait()
+				if err != nil {
+					return errors.Wrapf(err, "Failed to start instance %q", inst.Name())
+				}
+			}
+		}
+
+		return nil
+	}
+
+	op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationClusterMemberEvacuate, nil, nil, run, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// clusterNodeRestore moves the instances evacuated from the local member back to it and starts the
+// ones which were running.
+func clusterNodeRestore(d *Daemon, name string) response.Response {
+	var nodeID int64
+	var instances []db.Instance
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		member, err := tx.GetNodeByName(name)
+		if err != nil {
+			return err
+		}
+
+		if member.State != db.ClusterMemberStateEvacuated {
+			return fmt.Errorf("Member %q is not evacuated", name)
+		}
+
+		nodeID = member.ID
+
+		all, err := tx.GetInstances(db.InstanceFilter{Type: instancetype.Any})
+		if err != nil {
+			return err
+		}
+
+		for _, inst := range all {
+			if inst.Config["volatile.evacuate.origin"] == name {
+				instances = append(instances, inst)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	run := func(op *operations.Operation) error {
+		// Mark the member as available again, so that it can be used as a target.
+		err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			return tx.UpdateNodeStatus(nodeID, db.ClusterMemberStateCreated)
+		})
+		if err != nil {
+			return errors.Wrap(err, "Failed to update member state")
+		}
+
+		localAddress, err := node.ClusterAddress(d.db)
+		if err != nil {
+			return err
+		}
+
+		source, err := cluster.Connect(localAddress, d.endpoints.NetworkCert(), true)
+		if err != nil {
+			return errors.Wrap(err, "Failed to connect to local member")
+		}
+
+		for i, inst := range instances {
+			err := op.UpdateMetadata(map[string]interface{}{
+				"evacuate_progress": fmt.Sprintf("Restoring instance %q (%d/%d)", inst.Name, i+1, len(instances)),
+			})
+			if err != nil {
+				return err
+			}
+
+			client := source.UseProject(inst.Project)
+			running := shared.IsTrue(inst.Config["volatile.evacuate.running"])
+
+			if inst.Node != name {
+				entry, _, err := client.GetInstance(inst.Name)
+				if err != nil {
+					return err
+				}
+
+				live := entry.StatusCode == api.Running && entry.Type == instancetype.VM.String()
+				if entry.StatusCode == api.Running && !live {
+					stopOp, err := client.UpdateInstanceState(inst.Name, api.InstanceStatePut{Action: "stop", Timeout: -1}, "")
+					if err != nil {
+						return errors.Wrapf(err, "Failed to stop instance %q", inst.Name)
+					}
+
+					err = stopOp.Wait()
+					if err != nil {
+						return errors.Wrapf(err, "Failed to stop instance %q", inst.Name)
+					}
+				}
+
+				err = clusterNodeMoveInstance(client, inst.Name, name, live)
+				if err != nil {
+					return errors.Wrapf(err, "Failed to migrate instance %q back to %q", inst.Name, name)
+				}
+
+				if live {
+					running = false
+				}
+			}
+
+			if running {
+				startOp, err := client.UpdateInstanceState(inst.Name, api.InstanceStatePut{Action: "start", Timeout: -1}, "")
+				if err != nil {
+					return errors.Wrapf(err, "Failed to start instance %q", inst.Name)
+				}
+
+				err = startOp.Wait()
+				if err != nil {
+					return errors.Wrapf(err, "Failed to start instance %q", inst.Name)
+				}
+			}
+
+			// Forget about the evacuation.
+			err = clusterNodeUpdateEvacuateState(d, inst.Project, inst.Name, map[string]string{
+				"volatile.evacuate.origin":  "",
+				"volatile.evacuate.running": "",
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationClusterMemberRestore, nil, nil, run, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// clusterNodeUpdateEvacuateState records (or clears, for empty values) the evacuation state of an
+// instance in its volatile config. This is done through the database since moving an instance
+// re-creates it on the target member.
+func clusterNodeUpdateEvacuateState(d *Daemon, project string, name string, values map[string]string) error {
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		id, err := tx.GetInstanceID(project, name)
+		if err != nil {
+			return err
+		}
+
+		return tx.UpdateInstanceConfig(int(id), values)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Failed to update evacuation state of instance %q", name)
+	}
+
+	return nil
+}
+
+// clusterNodeMoveInstance moves an instance to the given cluster member and waits for it to be done.
+func clusterNodeMoveInstance(client lxd.InstanceServer, name string, targetNode string, live bool) error {
+	req := api.InstancePost{
+		Name:      name,
+		Migration: true,
+		Live:      live,
+	}
+
+	op, err := client.UseTarget(targetNode).MigrateInstance(name, req)
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
+
 func internalClusterPostAccept(d *Daemon, r *http.Request) response.Response {
 	d.clusterMembershipMutex.Lock()
 	defer d.clusterMembershipMutex.Unlock()
@@ -1250,6 +1751,72 @@ again:
 	goto again
 }
 
+// Return the raft role matching the database roles in the given list.
+func clusterMemberRaftRole(roles []string) db.RaftRole {
+	if shared.StringInSlice(string(db.ClusterRoleDatabase), roles) {
+		return db.RaftVoter
+	}
+
+	if shared.StringInSlice(string(db.ClusterRoleDatabaseStandBy), roles) {
+		return db.RaftStandBy
+	}
+
+	return db.RaftSpare
+}
+
+// Force the raft role of the member with the given address, demoting another
+// member if needed to stay within cluster.max_voters and cluster.max_standby.
+//
+// This must be called by the leader.
+func changeMemberRaftRole(d *Daemon, address string, role db.RaftRole) error {
+	d.clusterMembershipMutex.Lock()
+	defer d.clusterMembershipMutex.Unlock()
+
+	nodes, demoted, err := cluster.ChangeRole(d.State(), d.gateway, address, role)
+	if err != nil {
+		return err
+	}
+
+	// Promote or demote the member first, then demote the member it replaces.
+	if role == db.RaftSpare && !cluster.HasConnectivity(d.endpoints.NetworkCert(), address) {
+		for _, node := range nodes {
+			if node.Address == address {
+				err = d.gateway.DemoteOfflineNode(node.ID)
+				if err != nil {
+					return errors.Wrapf(err, "Demote offline node %s", address)
+				}
+			}
+		}
+	} else {
+		err = changeMemberRole(d, address, nodes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if demoted == "" {
+		return nil
+	}
+
+	for _, node := range nodes {
+		if node.Address != demoted {
+			continue
+		}
+
+		if cluster.HasConnectivity(d.endpoints.NetworkCert(), demoted) {
+			return changeMemberRole(d, demoted, nodes)
+		}
+
+		// Offline members can only be demoted to spare.
+		err = d.gateway.DemoteOfflineNode(node.ID)
+		if err != nil {
+			return errors.Wrapf(err, "Demote offline node %s", demoted)
+		}
+	}
+
+	return nil
+}
+
 // Post a change role request to the member with the given address. The nodes
 // slice contains details about all members, including the one being changed.
 func changeMemberRole(d *Daemon, address string, nodes []db.RaftNode) error {
@@ -1489,18 +2056,20 @@ func clusterCheckStoragePoolsMatch(cluster *db.Cluster, reqPools []api.StoragePo
 }
 
 func clusterCheckNetworksMatch(cluster *db.Cluster, reqNetworks []api.Network) error {
-	networkNames, err := cluster.GetNonPendingNetworks()
+	projectNetworks, err := cluster.GetNonPendingNetworks()
 	if err != nil && err != db.ErrNoSuchObject {
 		return err
 	}
-	for _, name := range networkNames {
+
+	// Only the networks of the default project exist on the joining member.
+	for _, name := range projectNetworks[project.Default] {
 		found := false
 		for _, reqNetwork := range reqNetworks {
 			if reqNetwork.Name != name {
 				continue
 			}
 			found = true
-			_, network, err := cluster.GetNetworkInAnyState(name)
+			_, network, err := cluster.GetNetworkInAnyState(project.Default, name)
 			if err != nil {
 				return err
 			}
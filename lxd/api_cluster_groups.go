@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var clusterGroupsCmd = APIEndpoint{
+	Path: "cluster/groups",
+
+	Get:  APIEndpointAction{Handler: clusterGroupsGet, AccessHandler: allowAuthenticated},
+	Post: APIEndpointAction{Handler: clusterGroupsPost},
+}
+
+var clusterGroupCmd = APIEndpoint{
+	Path: "cluster/groups/{name}",
+
+	Delete: APIEndpointAction{Handler: clusterGroupDelete},
+	Get:    APIEndpointAction{Handler: clusterGroupGet, AccessHandler: allowAuthenticated},
+	Patch:  APIEndpointAction{Handler: clusterGroupPatch},
+	Post:   APIEndpointAction{Handler: clusterGroupPost},
+	Put:    APIEndpointAction{Handler: clusterGroupPut},
+}
+
+// API endpoints
+func clusterGroupsGet(d *Daemon, r *http.Request) response.Response {
+	recursion := util.IsRecursionRequest(r)
+
+	groups := []*api.ClusterGroup{}
+	names := []string{}
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+
+		names, err = tx.GetClusterGroupNames()
+		if err != nil {
+			return err
+		}
+
+		if !recursion {
+			return nil
+		}
+
+		for _, name := range names {
+			_, group, err := tx.GetClusterGroup(name)
+			if err != nil {
+				return err
+			}
+
+			groups = append(groups, group)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !recursion {
+		urls := make([]string, 0, len(names))
+		for _, name := range names {
+			urls = append(urls, fmt.Sprintf("/%s/cluster/groups/%s", version.APIVersion, name))
+		}
+
+		return response.SyncResponse(true, urls)
+	}
+
+	return response.SyncResponse(true, groups)
+}
+
+func clusterGroupsPost(d *Daemon, r *http.Request) response.Response {
+	req := api.ClusterGroupsPost{}
+
+	// Parse the request.
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Sanity checks.
+	if req.Name == "" {
+		return response.BadRequest(fmt.Errorf("No name provided"))
+	}
+
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		_, err := tx.CreateClusterGroup(req.Name, req.Description, req.Members)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	url := fmt.Sprintf("/%s/cluster/groups/%s", version.APIVersion, req.Name)
+	return response.SyncResponseLocation(true, nil, url)
+}
+
+func clusterGroupGet(d *Daemon, r *http.Request) response.Response {
+	name := mux.Vars(r)["name"]
+
+	var group *api.ClusterGroup
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		_, group, err = tx.GetClusterGroup(name)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseETag(true, group, group.Writable())
+}
+
+func clusterGroupPatch(d *Daemon, r *http.Request) response.Response {
+	return clusterGroupUpdate(d, r, true)
+}
+
+func clusterGroupPut(d *Daemon, r *http.Request) response.Response {
+	return clusterGroupUpdate(d, r, false)
+}
+
+// clusterGroupUpdate updates a cluster group, only replacing the provided fields when patching.
+func clusterGroupUpdate(d *Daemon, r *http.Request, patch bool) response.Response {
+	name := mux.Vars(r)["name"]
+
+	var group *api.ClusterGroup
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		_, group, err = tx.GetClusterGroup(name)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	err = util.EtagCheck(r, group.Writable())
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	req := api.ClusterGroupPut{}
+	if patch {
+		req = group.Writable()
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		return tx.UpdateClusterGroup(name, req.Description, req.Members)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+func clusterGroupPost(d *Daemon, r *http.Request) response.Response {
+	name := mux.Vars(r)["name"]
+
+	req := api.ClusterGroupPost{}
+
+	// Parse the request.
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.Name == "" {
+		return response.BadRequest(fmt.Errorf("No name provided"))
+	}
+
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		return tx.RenameClusterGroup(name, req.Name)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	url := fmt.Sprintf("/%s/cluster/groups/%s", version.APIVersion, req.Name)
+	return response.SyncResponseLocation(true, nil, url)
+}
+
+func clusterGroupDelete(d *Daemon, r *http.Request) response.Response {
+	name := mux.Vars(r)["name"]
+
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		return tx.DeleteClusterGroup(name)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
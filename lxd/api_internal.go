@@ -46,10 +46,14 @@ var apiInternal = []APIEndpoint{
 	internalClusterRebalanceCmd,
 	internalClusterAssignCmd,
 	internalClusterContainerMovedCmd,
+	internalClusterInstanceMovedLiveCmd,
 	internalGarbageCollectorCmd,
 	internalRAFTSnapshotCmd,
 	internalClusterHandoverCmd,
 	internalClusterRaftNodeCmd,
+	internalDatabaseBackupCmd,
+	internalDatabaseRestoreCmd,
+	internalDatabaseQueryCmd,
 }
 
 var internalShutdownCmd = APIEndpoint{
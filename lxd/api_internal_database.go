@@ -0,0 +1,314 @@
+package main
+
+import (
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
+	"github.com/lxc/lxd/lxd/db/node"
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+var internalDatabaseBackupCmd = APIEndpoint{
+	Path: "database/backup",
+
+	Post: APIEndpointAction{Handler: internalDatabaseBackup},
+}
+
+var internalDatabaseRestoreCmd = APIEndpoint{
+	Path: "database/restore",
+
+	Post: APIEndpointAction{Handler: internalDatabaseRestore},
+}
+
+var internalDatabaseQueryCmd = APIEndpoint{
+	Path: "database/query",
+
+	Post: APIEndpointAction{Handler: internalDatabaseQuery},
+}
+
+// Maximum number of rows returned by a single database query.
+const internalDatabaseQueryMaxLimit = 1000
+
+type internalDatabaseDump struct {
+	Global map[string]query.Table `json:"global" yaml:"global"`
+	Local  map[string]query.Table `json:"local" yaml:"local"`
+}
+
+type internalDatabasePath struct {
+	Path string `json:"path" yaml:"path"`
+}
+
+type internalDatabaseQueryPost struct {
+	Database string `json:"database" yaml:"database"`
+	Query    string `json:"query" yaml:"query"`
+	Limit    int    `json:"limit" yaml:"limit"`
+	Offset   int    `json:"offset" yaml:"offset"`
+}
+
+type internalDatabaseQueryResult struct {
+	Columns []string        `json:"columns" yaml:"columns"`
+	Rows    [][]interface{} `json:"rows" yaml:"rows"`
+	More    bool            `json:"more" yaml:"more"`
+}
+
+// Write a consistent backup of the local and global databases to a file.
+func internalDatabaseBackup(d *Daemon, r *http.Request) response.Response {
+	req := internalDatabasePath{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.Path == "" {
+		req.Path = shared.VarPath("backups", fmt.Sprintf("database-%s.json.gz", time.Now().UTC().Format("20060102150405")))
+	}
+
+	if !filepath.IsAbs(req.Path) {
+		return response.BadRequest(fmt.Errorf("The backup path must be absolute"))
+	}
+
+	dump := internalDatabaseDump{}
+
+	dump.Global, err = internalDatabaseBackupTables(d.cluster.DB(), dbCluster.FreshSchema())
+	if err != nil {
+		return response.SmartError(errors.Wrap(err, "Failed to backup global database"))
+	}
+
+	dump.Local, err = internalDatabaseBackupTables(d.db.DB(), node.FreshSchema())
+	if err != nil {
+		return response.SmartError(errors.Wrap(err, "Failed to backup local database"))
+	}
+
+	// The database holds secrets, only root may read the backup.
+	f, err := os.OpenFile(req.Path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return response.SmartError(err)
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	err = json.NewEncoder(gw).Encode(dump)
+	if err != nil {
+		os.Remove(req.Path)
+		return response.SmartError(errors.Wrap(err, "Failed to write backup"))
+	}
+
+	err = gw.Close()
+	if err != nil {
+		os.Remove(req.Path)
+		return response.SmartError(errors.Wrap(err, "Failed to write backup"))
+	}
+
+	logger.Infof("Backed up database to %s", req.Path)
+
+	return response.SyncResponse(true, req)
+}
+
+// Backup all tables of a database within a single transaction.
+func internalDatabaseBackupTables(db *sql.DB, schema string) (map[string]query.Table, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	return query.Backup(tx, schema)
+}
+
+// Restore the local and global databases from a backup. This is only allowed on a fresh,
+// non-clustered daemon which doesn't have any instance yet.
+func internalDatabaseRestore(d *Daemon, r *http.Request) response.Response {
+	req := internalDatabasePath{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.Path == "" {
+		return response.BadRequest(fmt.Errorf("No backup path provided"))
+	}
+
+	clustered, err := cluster.Enabled(d.db)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if clustered {
+		return response.BadRequest(fmt.Errorf("The database of a clustered LXD can't be restored"))
+	}
+
+	// Load the backup
+	f, err := os.Open(req.Path)
+	if err != nil {
+		return response.SmartError(err)
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return response.BadRequest(errors.Wrap(err, "Invalid backup"))
+	}
+	defer gr.Close()
+
+	decoder := json.NewDecoder(gr)
+	decoder.UseNumber()
+
+	dump := internalDatabaseDump{}
+	err = decoder.Decode(&dump)
+	if err != nil {
+		return response.BadRequest(errors.Wrap(err, "Invalid backup"))
+	}
+
+	// Restore the global database, keeping the schema table as is.
+	err = internalDatabaseRestoreTables(d.cluster.DB(), dump.Global, []string{"schema"}, true)
+	if err != nil {
+		return response.SmartError(errors.Wrap(err, "Failed to restore global database"))
+	}
+
+	// Restore the local database, keeping the member's own database role.
+	err = internalDatabaseRestoreTables(d.db.DB(), dump.Local, []string{"schema", "raft_nodes"}, false)
+	if err != nil {
+		return response.SmartError(errors.Wrap(err, "Failed to restore local database"))
+	}
+
+	logger.Infof("Restored database from %s", req.Path)
+
+	return response.EmptySyncResponse
+}
+
+// Restore the tables of a database within a single transaction, after checking that the backup
+// uses the same schema version.
+func internalDatabaseRestoreTables(db *sql.DB, tables map[string]query.Table, skip []string, checkEmpty bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "Failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	var version int64
+	err = tx.QueryRow("SELECT MAX(version) FROM schema").Scan(&version)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get schema version")
+	}
+
+	backupVersion := int64(-1)
+	schema := tables["schema"]
+	for i, column := range schema.Columns {
+		if column != "version" {
+			continue
+		}
+
+		for _, row := range schema.Rows {
+			number, ok := row[i].(json.Number)
+			if !ok {
+				continue
+			}
+
+			v, err := number.Int64()
+			if err == nil && v > backupVersion {
+				backupVersion = v
+			}
+		}
+	}
+
+	if backupVersion != version {
+		return fmt.Errorf("The backup uses schema version %d instead of %d", backupVersion, version)
+	}
+
+	if checkEmpty {
+		count, err := query.Count(tx, "instances", "")
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return fmt.Errorf("Backups can only be restored on a LXD without any instance")
+		}
+	}
+
+	err = query.Restore(tx, tables, skip)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Run a read-only and paginated query.
+func internalDatabaseQuery(d *Daemon, r *http.Request) response.Response {
+	req := internalDatabaseQueryPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if !shared.StringInSlice(req.Database, []string{"local", "global"}) {
+		return response.BadRequest(fmt.Errorf("Invalid database"))
+	}
+
+	// Only allow a single SELECT statement.
+	stmt := strings.TrimRight(strings.TrimSpace(req.Query), ";")
+	keyword := strings.ToUpper(strings.SplitN(stmt, " ", 2)[0])
+	if !shared.StringInSlice(keyword, []string{"SELECT", "WITH"}) || strings.Contains(stmt, ";") {
+		return response.BadRequest(fmt.Errorf("Only a single SELECT query is allowed"))
+	}
+
+	if req.Limit <= 0 || req.Limit > internalDatabaseQueryMaxLimit {
+		req.Limit = internalDatabaseQueryMaxLimit
+	}
+
+	if req.Offset < 0 {
+		return response.BadRequest(fmt.Errorf("Invalid offset"))
+	}
+
+	var db *sql.DB
+	if req.Database == "global" {
+		db = d.cluster.DB()
+	} else {
+		db = d.db.DB()
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// The transaction is never committed, fetch one more row to know whether there are more.
+	defer tx.Rollback()
+
+	result := internalSQLResult{}
+	err = internalSQLSelect(tx, fmt.Sprintf("SELECT * FROM (%s) LIMIT %d OFFSET %d", stmt, req.Limit+1, req.Offset), &result)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	page := internalDatabaseQueryResult{
+		Columns: result.Columns,
+		Rows:    result.Rows,
+	}
+
+	if len(page.Rows) > req.Limit {
+		page.Rows = page.Rows[:req.Limit]
+		page.More = true
+	}
+
+	if page.Rows == nil {
+		page.Rows = [][]interface{}{}
+	}
+
+	return response.SyncResponse(true, page)
+}
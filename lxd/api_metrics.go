@@ -0,0 +1,147 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/metrics"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+
+	log "github.com/lxc/lxd/shared/log15"
+)
+
+var metricsCmd = APIEndpoint{
+	Path: "metrics",
+
+	Get: APIEndpointAction{Handler: metricsGet, AccessHandler: allowMetrics, AllowUntrusted: true},
+}
+
+// allowMetrics lets clients trusted with a metrics certificate through, as well as regular trusted clients.
+// Restricted users (RBAC) must request the metrics of a project they can view.
+func allowMetrics(d *Daemon, r *http.Request) response.Response {
+	if r.TLS != nil {
+		for _, cert := range r.TLS.PeerCertificates {
+			trusted, _ := util.CheckTrustState(*cert, d.metricsCerts, nil, false)
+			if trusted {
+				return response.EmptySyncResponse
+			}
+		}
+	}
+
+	err := d.checkTrustedClient(r)
+	if err != nil {
+		return response.Forbidden(nil)
+	}
+
+	if d.userIsAdmin(r) {
+		return response.EmptySyncResponse
+	}
+
+	projectName := queryParam(r, "project")
+	if projectName != "" && d.userHasPermission(r, projectName, "view") {
+		return response.EmptySyncResponse
+	}
+
+	return response.Forbidden(nil)
+}
+
+// metricsGet returns the metrics of the instances running on this member, along with daemon metrics, in the
+// OpenMetrics text format. The instance metrics can be restricted to a single project.
+func metricsGet(d *Daemon, r *http.Request) response.Response {
+	projectName := queryParam(r, "project")
+
+	insts, err := instance.LoadNodeAll(d.State(), instancetype.Any)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	set := metrics.NewMetricSet(nil)
+
+	for _, inst := range insts {
+		if projectName != "" && inst.Project() != projectName {
+			continue
+		}
+
+		if !inst.IsRunning() {
+			continue
+		}
+
+		state, err := inst.RenderState()
+		if err != nil {
+			logger.Warn("Failed to get instance state for metrics", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+			continue
+		}
+
+		set.Merge(instanceMetrics(inst, state))
+	}
+
+	// Operations and daemon metrics aren't project specific.
+	if projectName == "" {
+		set.Merge(operationsMetrics())
+		set.Merge(metrics.DaemonMetrics())
+	}
+
+	return response.ManualResponse(func(w http.ResponseWriter) error {
+		w.Header().Set("Content-Type", metrics.ContentType)
+		w.WriteHeader(http.StatusOK)
+
+		_, err := w.Write([]byte(set.String()))
+		return err
+	})
+}
+
+// instanceMetrics converts the state of a running instance into metrics.
+func instanceMetrics(inst instance.Instance, state *api.InstanceState) *metrics.MetricSet {
+	set := metrics.NewMetricSet(map[string]string{
+		"project": inst.Project(),
+		"name":    inst.Name(),
+		"type":    inst.Type().String(),
+	})
+
+	// The CPU usage is reported in nanoseconds.
+	set.AddSamples(metrics.CPUSecondsTotal, metrics.Sample{Value: float64(state.CPU.Usage) / 1000000000})
+	set.AddSamples(metrics.MemoryUsageBytes, metrics.Sample{Value: float64(state.Memory.Usage)})
+	set.AddSamples(metrics.MemoryUsagePeakBytes, metrics.Sample{Value: float64(state.Memory.UsagePeak)})
+	set.AddSamples(metrics.MemorySwapUsageBytes, metrics.Sample{Value: float64(state.Memory.SwapUsage)})
+	set.AddSamples(metrics.Processes, metrics.Sample{Value: float64(state.Processes)})
+
+	for name, disk := range state.Disk {
+		set.AddSamples(metrics.DiskUsageBytes, metrics.Sample{Labels: map[string]string{"device": name}, Value: float64(disk.Usage)})
+	}
+
+	for name, network := range state.Network {
+		labels := map[string]string{"device": name}
+
+		set.AddSamples(metrics.NetworkReceiveBytesTotal, metrics.Sample{Labels: labels, Value: float64(network.Counters.BytesReceived)})
+		set.AddSamples(metrics.NetworkTransmitBytesTotal, metrics.Sample{Labels: labels, Value: float64(network.Counters.BytesSent)})
+		set.AddSamples(metrics.NetworkReceivePacketsTotal, metrics.Sample{Labels: labels, Value: float64(network.Counters.PacketsReceived)})
+		set.AddSamples(metrics.NetworkTransmitPacketsTotal, metrics.Sample{Labels: labels, Value: float64(network.Counters.PacketsSent)})
+	}
+
+	return set
+}
+
+// operationsMetrics counts the operations known to this member by project and status.
+func operationsMetrics() *metrics.MetricSet {
+	type key struct {
+		project string
+		status  string
+	}
+
+	counts := map[key]int{}
+	for _, op := range operations.Clone() {
+		counts[key{project: op.Project(), status: op.Status().String()}]++
+	}
+
+	set := metrics.NewMetricSet(nil)
+	for k, count := range counts {
+		set.AddSamples(metrics.Operations, metrics.Sample{Labels: map[string]string{"project": k.project, "status": k.status}, Value: float64(count)})
+	}
+
+	return set
+}
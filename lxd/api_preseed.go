@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/response"
+)
+
+var preseedCmd = APIEndpoint{
+	Path: "preseed",
+
+	Get: APIEndpointAction{Handler: preseedGet},
+	Put: APIEndpointAction{Handler: preseedPut},
+}
+
+// preseedGet returns the current server configuration as a preseed document, the same as 'lxd init --dump'.
+func preseedGet(d *Daemon, r *http.Request) response.Response {
+	// Connect to ourselves to gather the configuration through the API.
+	client, err := lxd.ConnectLXDUnix(d.UnixSocket(), nil)
+	if err != nil {
+		return response.SmartError(errors.Wrap(err, "Failed to connect to local LXD"))
+	}
+
+	config, err := initDataNodeDump(client)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, config)
+}
+
+// preseedPut applies a preseed document, the same as 'lxd init --preseed'. Existing entities are updated in
+// place and everything is reverted if any part of the document fails to apply.
+func preseedPut(d *Daemon, r *http.Request) response.Response {
+	req := initDataNode{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Connect to ourselves to apply the configuration through the API.
+	client, err := lxd.ConnectLXDUnix(d.UnixSocket(), nil)
+	if err != nil {
+		return response.SmartError(errors.Wrap(err, "Failed to connect to local LXD"))
+	}
+
+	revert, err := initDataNodeApply(client, req)
+	if err != nil {
+		revert()
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
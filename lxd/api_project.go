@@ -11,9 +11,12 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 
+	"github.com/lxc/lxd/lxd/backup"
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/operations"
 	projecthelpers "github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
@@ -22,7 +25,10 @@ import (
 	"github.com/lxc/lxd/shared/version"
 )
 
-var projectFeatures = []string{"features.images", "features.profiles", "features.storage.volumes"}
+var projectFeatures = []string{"features.images", "features.profiles", "features.storage.volumes", "features.networks"}
+
+// projectFeaturesDefaults are the features enabled by default on new projects.
+var projectFeaturesDefaults = []string{"features.images", "features.profiles", "features.storage.volumes"}
 
 var projectsCmd = APIEndpoint{
 	Path: "projects",
@@ -100,7 +106,7 @@ func projectsPost(d *Daemon, r *http.Request) response.Response {
 	if project.Config == nil {
 		project.Config = map[string]string{}
 	}
-	for _, feature := range projectFeatures {
+	for _, feature := range projectFeaturesDefaults {
 		_, ok := project.Config[feature]
 		if !ok {
 			project.Config[feature] = "true"
@@ -158,6 +164,8 @@ func projectsPost(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
+	d.State().Events.SendLifecycle(project.Name, lifecycle.ProjectCreated.Event(project.Name, request.CreateRequestor(r), nil))
+
 	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/projects/%s", version.APIVersion, project.Name))
 }
 
@@ -240,7 +248,7 @@ func projectPut(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
-	return projectChange(d, project, req)
+	return projectChange(d, r, project, req)
 }
 
 func projectPatch(d *Daemon, r *http.Request) response.Response {
@@ -308,11 +316,11 @@ func projectPatch(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
-	return projectChange(d, project, req)
+	return projectChange(d, r, project, req)
 }
 
 // Common logic between PUT and PATCH.
-func projectChange(d *Daemon, project *api.Project, req api.ProjectPut) response.Response {
+func projectChange(d *Daemon, r *http.Request, project *api.Project, req api.ProjectPut) response.Response {
 	// Make a list of config keys that have changed.
 	configChanged := []string{}
 	for key := range project.Config {
@@ -386,6 +394,17 @@ func projectChange(d *Daemon, project *api.Project, req api.ProjectPut) response
 		return response.SmartError(err)
 	}
 
+	// Reschedule the image tasks if the image policy of the project changed.
+	for _, key := range projectImageKeys {
+		if shared.StringInSlice(key, configChanged) && !d.os.MockMode {
+			d.taskAutoUpdate.Reset()
+			d.taskPruneImages.Reset()
+			break
+		}
+	}
+
+	d.State().Events.SendLifecycle(project.Name, lifecycle.ProjectUpdated.Event(project.Name, request.CreateRequestor(r), nil))
+
 	return response.EmptySyncResponse
 }
 
@@ -450,6 +469,13 @@ func projectPost(d *Daemon, r *http.Request) response.Response {
 			}
 		}
 
+		// Certificate roles are cached by project name.
+		readSavedClientCAList(d)
+
+		d.State().Events.SendLifecycle(req.Name, lifecycle.ProjectRenamed.Event(req.Name, op.Requestor(), map[string]interface{}{
+			"old_name": name,
+		}))
+
 		return nil
 	}
 
@@ -458,6 +484,8 @@ func projectPost(d *Daemon, r *http.Request) response.Response {
 		return response.InternalError(err)
 	}
 
+	op.SetRequestor(r)
+
 	return operations.OperationResponse(op)
 }
 
@@ -498,6 +526,11 @@ func projectDelete(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
+	// Drop the roles given on the deleted project from the cache.
+	readSavedClientCAList(d)
+
+	d.State().Events.SendLifecycle(name, lifecycle.ProjectDeleted.Event(name, request.CreateRequestor(r), nil))
+
 	return response.EmptySyncResponse
 }
 
@@ -523,15 +556,32 @@ func isEitherAllowOrBlockOrManaged(value string) error {
 
 // Validate the project configuration
 var projectConfigKeys = map[string]func(value string) error{
+	"backups.encryption.method": func(value string) error {
+		if value == "" {
+			return nil
+		}
+
+		return validate.IsOneOf(value, backup.EncryptionMethods)
+	},
+	"backups.encryption.recipients":  validate.IsAny,
+	"backups.retention":              validate.Optional(validate.IsUint32),
+	"backups.schedule":               shared.IsSchedule,
+	"backups.schedule.stopped":       validate.Optional(validate.IsBool),
+	"backups.target":                 shared.KnownInstanceConfigKeys["backups.target"],
 	"features.profiles":              validate.Optional(validate.IsBool),
 	"features.images":                validate.Optional(validate.IsBool),
 	"features.storage.volumes":       validate.Optional(validate.IsBool),
+	"features.networks":              validate.Optional(validate.IsBool),
+	"images.auto_update_cached":      validate.Optional(validate.IsBool),
+	"images.auto_update_interval":    validate.Optional(validate.IsUint32),
+	"images.remote_cache_expiry":     validate.Optional(validate.IsUint32),
 	"limits.containers":              validate.Optional(validate.IsUint32),
 	"limits.virtual-machines":        validate.Optional(validate.IsUint32),
 	"limits.memory":                  validate.Optional(validate.IsSize),
 	"limits.processes":               validate.Optional(validate.IsUint32),
 	"limits.cpu":                     validate.Optional(validate.IsUint32),
 	"limits.disk":                    validate.Optional(validate.IsSize),
+	"limits.networks":                validate.Optional(validate.IsUint32),
 	"restricted":                     validate.Optional(validate.IsBool),
 	"restricted.containers.nesting":  isEitherAllowOrBlock,
 	"restricted.containers.lowlevel": isEitherAllowOrBlock,
@@ -547,6 +597,10 @@ var projectConfigKeys = map[string]func(value string) error{
 	"restricted.devices.usb":               isEitherAllowOrBlock,
 	"restricted.devices.nic":               isEitherAllowOrBlockOrManaged,
 	"restricted.devices.disk":              isEitherAllowOrBlockOrManaged,
+	"restricted.devices.disk.paths":        validate.IsAny,
+	"restricted.networks.access":           validate.IsAny,
+	"restricted.networks.uplinks":          validate.IsAny,
+	"restricted.storage.pools":             validate.IsAny,
 }
 
 func projectValidateConfig(config map[string]string) error {
@@ -5,6 +5,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"context"
@@ -17,6 +22,7 @@ import (
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/revert"
@@ -25,6 +31,7 @@ import (
 	"github.com/lxc/lxd/lxd/task"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/cancel"
 	"github.com/lxc/lxd/shared/idmap"
 	"github.com/lxc/lxd/shared/instancewriter"
 	log "github.com/lxc/lxd/shared/log15"
@@ -32,8 +39,29 @@ import (
 	"github.com/lxc/lxd/shared/logging"
 )
 
-// Create a new backup.
-func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.Instance) error {
+// backupCreateOpts represents the backup settings which aren't recorded in the database.
+type backupCreateOpts struct {
+	// If set, the backup is streamed to the target rather than being stored locally.
+	target *api.BackupTarget
+
+	// If set, only the changes since that snapshot are included in the backup.
+	incrementalFrom string
+
+	// If not set, the encryption configured on the instance's project (if any) is used.
+	encryption *api.BackupEncryption
+
+	// Names or patterns of the snapshots to include (all when empty).
+	snapshots []string
+
+	// If set, only the snapshots created within that period (e.g. "7d") are included.
+	snapshotsMaxAge string
+
+	// If set (qcow2 or ova), the instance's disk is exported in that format instead of a backup tarball.
+	format string
+}
+
+// Create a new backup. The backup is interrupted and reverted if ctx is done before it completes.
+func backupCreate(ctx context.Context, s *state.State, args db.InstanceBackup, sourceInst instance.Instance, opts backupCreateOpts) error {
 	logger := logging.AddContext(logger.Log, log.Ctx{"project": sourceInst.Project(), "instance": sourceInst.Name(), "name": args.Name})
 	logger.Debug("Instance backup started")
 	defer logger.Debug("Instance backup finished")
@@ -52,57 +80,163 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 		args.OptimizedStorage = false
 	}
 
-	// Create the database entry.
-	err = s.Cluster.CreateInstanceBackup(args)
+	// Incremental backups can't fall back to a full backup, so fail if they can't be honoured.
+	if opts.incrementalFrom != "" && (!args.OptimizedStorage || !pool.Driver().Info().IncrementalBackups) {
+		return fmt.Errorf("Storage pool driver %q doesn't support incremental backups", pool.Driver().Info().Name)
+	}
+
+	// Detect compression method.
+	compress := args.CompressionAlgorithm
+	if compress == "" {
+		compress, err = cluster.ConfigGetString(s.Cluster, "backups.compression_algorithm")
+		if err != nil {
+			return err
+		}
+	}
+
+	// Figure out which snapshots to include.
+	snapshots, err := backupSnapshots(sourceInst, args.InstanceOnly, opts)
 	if err != nil {
-		if err == db.ErrAlreadyDefined {
-			return fmt.Errorf("Backup %q already exists", args.Name)
+		return err
+	}
+
+	// Use the project's encryption settings unless specified in the request.
+	encryption := opts.encryption
+	if encryption == nil {
+		var projectConfig map[string]string
+		err = s.Cluster.Transaction(func(tx *db.ClusterTx) error {
+			p, err := tx.GetProject(sourceInst.Project())
+			if err != nil {
+				return err
+			}
+
+			projectConfig = p.Config
+			return nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "Load project")
 		}
 
-		return errors.Wrap(err, "Insert backup info into database")
+		method := projectConfig["backups.encryption.method"]
+		if method != "" {
+			encryption = &api.BackupEncryption{
+				Method:     method,
+				Recipients: backup.ParseRecipients(method, projectConfig["backups.encryption.recipients"]),
+			}
+		}
 	}
 
-	revert.Add(func() { s.Cluster.DeleteInstanceBackup(args.Name) })
+	target := opts.target
 
-	// Get the backup struct.
-	b, err := instance.BackupLoadByName(s, sourceInst.Project(), args.Name)
-	if err != nil {
-		return errors.Wrap(err, "Load backup object")
+	var tarFileWriter io.WriteCloser
+	if target != nil {
+		// Stream the tarball to the target, nothing gets recorded locally.
+		logger.Debug("Opening backup target for writing", log.Ctx{"url": target.URL, "bucket": target.BucketName, "path": target.Path})
+		uploader, err := backup.NewTargetWriter(ctx, *target)
+		if err != nil {
+			return errors.Wrap(err, "Error opening backup target for writing")
+		}
+
+		revert.Add(func() { uploader.CloseWithError(fmt.Errorf("Backup failed")) })
+		tarFileWriter = uploader
+	} else {
+		// Create the database entry.
+		err = s.Cluster.CreateInstanceBackup(args)
+		if err != nil {
+			if err == db.ErrAlreadyDefined {
+				return fmt.Errorf("Backup %q already exists", args.Name)
+			}
+
+			return errors.Wrap(err, "Insert backup info into database")
+		}
+
+		revert.Add(func() { s.Cluster.DeleteInstanceBackup(args.Name) })
+
+		// Get the backup struct.
+		b, err := instance.BackupLoadByName(s, sourceInst.Project(), args.Name)
+		if err != nil {
+			return errors.Wrap(err, "Load backup object")
+		}
+
+		// Create the target path if needed.
+		backupsPath := shared.VarPath("backups", project.Instance(sourceInst.Project(), sourceInst.Name()))
+		if !shared.PathExists(backupsPath) {
+			err := os.MkdirAll(backupsPath, 0700)
+			if err != nil {
+				return err
+			}
+
+			revert.Add(func() { os.Remove(backupsPath) })
+		}
+
+		tarPath := shared.VarPath("backups", project.Instance(sourceInst.Project(), b.Name()))
+
+		// Setup the tarball writer.
+		logger.Debug("Opening backup tarball for writing", log.Ctx{"path": tarPath})
+		tarFile, err := os.OpenFile(tarPath, os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return errors.Wrapf(err, "Error opening backup tarball for writing %q", tarPath)
+		}
+		defer tarFile.Close()
+		revert.Add(func() { os.Remove(tarPath) })
+
+		tarFileWriter = tarFile
 	}
 
-	// Detect compression method.
-	var compress string
-	b.SetCompressionAlgorithm(args.CompressionAlgorithm)
-	if b.CompressionAlgorithm() != "" {
-		compress = b.CompressionAlgorithm()
+	// Stop writing the backup once the context is done.
+	contextWriter := cancel.NewContextWriter(ctx, tarFileWriter)
+
+	// Encrypt the compressed tarball before it's written out.
+	var backupWriter io.Writer = contextWriter
+	var encrypter *backup.EncryptWriter
+	if encryption != nil {
+		logger.Debug("Encrypting backup", log.Ctx{"method": encryption.Method})
+		encrypter, err = backup.NewEncryptWriter(*encryption, contextWriter)
+		if err != nil {
+			return errors.Wrap(err, "Error setting up backup encryption")
+		}
+
+		revert.Add(func() { encrypter.Close() })
+		backupWriter = encrypter
+	}
+
+	if opts.format != "" {
+		// Export the instance's disk image for use by other hypervisors.
+		logger.Debug("Exporting instance disk", log.Ctx{"format": opts.format})
+		err = backupExportDisk(sourceInst, pool, opts.format, backupWriter)
+		if err != nil {
+			return errors.Wrapf(err, "Error exporting instance disk as %s", opts.format)
+		}
 	} else {
-		compress, err = cluster.ConfigGetString(s.Cluster, "backups.compression_algorithm")
+		err = backupWriteTarball(sourceInst, pool, args, compress, snapshots, opts.incrementalFrom, backupWriter)
 		if err != nil {
 			return err
 		}
 	}
 
-	// Create the target path if needed.
-	backupsPath := shared.VarPath("backups", project.Instance(sourceInst.Project(), sourceInst.Name()))
-	if !shared.PathExists(backupsPath) {
-		err := os.MkdirAll(backupsPath, 0700)
+	// Flush the encrypted output.
+	if encrypter != nil {
+		err = encrypter.Close()
 		if err != nil {
-			return err
+			return errors.Wrap(err, "Error encrypting backup")
 		}
+	}
 
-		revert.Add(func() { os.Remove(backupsPath) })
+	// Complete the upload when streaming to a target.
+	if target != nil {
+		err = tarFileWriter.Close()
+		if err != nil {
+			return errors.Wrap(err, "Error uploading backup")
+		}
 	}
 
-	target := shared.VarPath("backups", project.Instance(sourceInst.Project(), b.Name()))
+	revert.Success()
+	return nil
+}
 
-	// Setup the tarball writer.
-	logger.Debug("Opening backup tarball for writing", log.Ctx{"path": target})
-	tarFileWriter, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
-		return errors.Wrapf(err, "Error opening backup tarball for writing %q", target)
-	}
-	defer tarFileWriter.Close()
-	revert.Add(func() { os.Remove(target) })
+// backupWriteTarball writes the (optionally compressed) backup tarball of the instance to backupWriter.
+func backupWriteTarball(sourceInst instance.Instance, pool storagePools.Pool, args db.InstanceBackup, compress string, snapshots []string, incrementalFrom string, backupWriter io.Writer) error {
+	var err error
 
 	// Get IDMap to unshift container as the tarball is created.
 	var idmap *idmap.IdmapSet
@@ -127,21 +261,26 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 		logger.Debug("Started backup tarball writer")
 		defer logger.Debug("Finished backup tarball writer")
 		if compress != "none" {
-			compressErr = compressFile(compress, tarPipeReader, tarFileWriter)
+			compressErr = compressFile(compress, tarPipeReader, backupWriter)
 
 			// If a compression error occurred, close the tarPipeWriter to end the export.
 			if compressErr != nil {
 				tarPipeWriter.Close()
 			}
 		} else {
-			_, err = io.Copy(tarFileWriter, tarPipeReader)
+			_, err = io.Copy(backupWriter, tarPipeReader)
+
+			// If a write error occurred, close the tarPipeWriter to end the export.
+			if err != nil {
+				tarPipeWriter.Close()
+			}
 		}
 		resCh <- err
 	}(tarWriterRes)
 
 	// Write index file.
 	logger.Debug("Adding backup index file")
-	err = backupWriteIndex(sourceInst, pool, b.OptimizedStorage(), !b.InstanceOnly(), tarWriter)
+	err = backupWriteIndex(sourceInst, pool, args.OptimizedStorage, snapshots, incrementalFrom, tarWriter)
 
 	// Check compression errors.
 	if compressErr != nil {
@@ -153,7 +292,7 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 		return errors.Wrapf(err, "Error writing backup index file")
 	}
 
-	err = pool.BackupInstance(sourceInst, tarWriter, b.OptimizedStorage(), !b.InstanceOnly(), nil)
+	err = pool.BackupInstance(sourceInst, tarWriter, args.OptimizedStorage, snapshots, incrementalFrom, nil)
 	if err != nil {
 		return errors.Wrap(err, "Backup create")
 	}
@@ -175,12 +314,76 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 		return errors.Wrap(err, "Error writing tarball")
 	}
 
-	revert.Success()
 	return nil
 }
 
+// backupSnapshots returns the names of the snapshots (oldest first) to include in a backup.
+// For incremental backups only the snapshots taken after the base snapshot are considered.
+func backupSnapshots(inst instance.Instance, instanceOnly bool, opts backupCreateOpts) ([]string, error) {
+	snapshots := []string{}
+	if instanceOnly {
+		return snapshots, nil
+	}
+
+	snaps, err := inst.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	// Compute the oldest creation date allowed.
+	var cutoff time.Time
+	if opts.snapshotsMaxAge != "" {
+		now := time.Now()
+		maxAge, err := shared.GetSnapshotExpiry(now, opts.snapshotsMaxAge)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid snapshots maximum age %q", opts.snapshotsMaxAge)
+		}
+
+		cutoff = now.Add(now.Sub(maxAge))
+	}
+
+	foundBase := opts.incrementalFrom == ""
+	for _, snap := range snaps {
+		_, snapName, _ := shared.InstanceGetParentAndSnapshotName(snap.Name())
+
+		// Skip the snapshots already contained in the previous backups.
+		if !foundBase {
+			foundBase = snapName == opts.incrementalFrom
+			continue
+		}
+
+		if !cutoff.IsZero() && snap.CreationDate().Before(cutoff) {
+			continue
+		}
+
+		if len(opts.snapshots) > 0 && !backupSnapshotMatches(snapName, opts.snapshots) {
+			continue
+		}
+
+		snapshots = append(snapshots, snapName)
+	}
+
+	if !foundBase {
+		return nil, fmt.Errorf("Incremental base snapshot %q not found", opts.incrementalFrom)
+	}
+
+	return snapshots, nil
+}
+
+// backupSnapshotMatches returns whether the snapshot name matches one of the names or shell patterns.
+func backupSnapshotMatches(snapName string, patterns []string) bool {
+	for _, pattern := range patterns {
+		match, err := filepath.Match(pattern, snapName)
+		if err == nil && match {
+			return true
+		}
+	}
+
+	return false
+}
+
 // backupWriteIndex generates an index.yaml file and then writes it to the root of the backup tarball.
-func backupWriteIndex(sourceInst instance.Instance, pool storagePools.Pool, optimized bool, snapshots bool, tarWriter *instancewriter.InstanceTarWriter) error {
+func backupWriteIndex(sourceInst instance.Instance, pool storagePools.Pool, optimized bool, snapshots []string, incrementalFrom string, tarWriter *instancewriter.InstanceTarWriter) error {
 	// Indicate whether the driver will include a driver-specific optimized header.
 	poolDriverOptimizedHeader := false
 	if optimized {
@@ -188,25 +391,15 @@ func backupWriteIndex(sourceInst instance.Instance, pool storagePools.Pool, opti
 	}
 
 	indexInfo := backup.Info{
+		Version:          backup.FormatVersion,
 		Name:             sourceInst.Name(),
 		Pool:             pool.Name(),
-		Snapshots:        []string{},
+		Snapshots:        snapshots,
 		Backend:          pool.Driver().Info().Name,
 		Type:             api.InstanceType(sourceInst.Type().String()),
 		OptimizedStorage: &optimized,
 		OptimizedHeader:  &poolDriverOptimizedHeader,
-	}
-
-	if snapshots {
-		snaps, err := sourceInst.Snapshots()
-		if err != nil {
-			return err
-		}
-
-		for _, snap := range snaps {
-			_, snapName, _ := shared.InstanceGetParentAndSnapshotName(snap.Name())
-			indexInfo.Snapshots = append(indexInfo.Snapshots, snapName)
-		}
+		IncrementalFrom:  incrementalFrom,
 	}
 
 	// Convert to YAML.
@@ -235,7 +428,12 @@ func backupWriteIndex(sourceInst instance.Instance, pool storagePools.Pool, opti
 func pruneExpiredContainerBackupsTask(d *Daemon) (task.Func, task.Schedule) {
 	f := func(ctx context.Context) {
 		opRun := func(op *operations.Operation) error {
-			return pruneExpiredContainerBackups(ctx, d)
+			err := pruneExpiredContainerBackups(ctx, d)
+			if err != nil {
+				return err
+			}
+
+			return pruneExpiredStoragePoolVolumeBackups(ctx, d)
 		}
 
 		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationBackupsExpire, nil, nil, opRun, nil, nil)
@@ -290,3 +488,226 @@ func pruneExpiredContainerBackups(ctx context.Context, d *Daemon) error {
 
 	return nil
 }
+
+// scheduledBackupKeys are the instance configuration keys which default to the project's configuration.
+var scheduledBackupKeys = []string{"backups.retention", "backups.schedule", "backups.schedule.stopped", "backups.target"}
+
+// scheduledBackupConfig returns the expanded configuration of the instance, with the scheduled backup keys it
+// doesn't set taken from its project's configuration.
+func scheduledBackupConfig(inst instance.Instance, projectConfig map[string]string) map[string]string {
+	config := map[string]string{}
+	for k, v := range inst.ExpandedConfig() {
+		config[k] = v
+	}
+
+	for _, key := range scheduledBackupKeys {
+		_, ok := config[key]
+		if !ok && projectConfig[key] != "" {
+			config[key] = projectConfig[key]
+		}
+	}
+
+	return config
+}
+
+// scheduledBackup is an instance due for a scheduled backup, along with its effective configuration.
+type scheduledBackup struct {
+	inst   instance.Instance
+	config map[string]string
+}
+
+func autoCreateInstanceBackupsTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		// Load all local instances.
+		allInstances, err := instance.LoadNodeAll(d.State(), instancetype.Any)
+		if err != nil {
+			logger.Error("Failed to load instances for scheduled backups", log.Ctx{"err": err})
+			return
+		}
+
+		// Load the project configurations, which provide the defaults of the instances.
+		projectConfigs := map[string]map[string]string{}
+		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			projects, err := tx.GetProjects(db.ProjectFilter{})
+			if err != nil {
+				return err
+			}
+
+			for _, p := range projects {
+				projectConfigs[p.Name] = p.Config
+			}
+
+			return nil
+		})
+		if err != nil {
+			logger.Error("Failed to load projects for scheduled backups", log.Ctx{"err": err})
+			return
+		}
+
+		// Figure out which need a backup (if any).
+		instances := []scheduledBackup{}
+		for _, inst := range allInstances {
+			config := scheduledBackupConfig(inst, projectConfigs[inst.Project()])
+
+			schedule := config["backups.schedule"]
+			if schedule == "" {
+				continue
+			}
+
+			// Check if it's time for a backup.
+			due, err := shared.ScheduleDue(schedule, fmt.Sprintf("%s/%s", inst.Project(), inst.Name()), time.Now())
+			if err != nil || !due {
+				continue
+			}
+
+			// Check if the instance is running.
+			if !shared.IsTrue(config["backups.schedule.stopped"]) && !inst.IsRunning() {
+				continue
+			}
+
+			instances = append(instances, scheduledBackup{inst: inst, config: config})
+		}
+
+		if len(instances) == 0 {
+			return
+		}
+
+		opRun := func(op *operations.Operation) error {
+			return autoCreateInstanceBackups(ctx, d, instances)
+		}
+
+		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationBackupCreate, nil, nil, opRun, nil, nil)
+		if err != nil {
+			logger.Error("Failed to start scheduled instance backups operation", log.Ctx{"err": err})
+			return
+		}
+
+		logger.Info("Creating scheduled instance backups")
+		_, err = op.Run()
+		if err != nil {
+			logger.Error("Failed to create scheduled instance backups", log.Ctx{"err": err})
+		}
+		logger.Info("Done creating scheduled instance backups")
+	}
+
+	first := true
+	schedule := func() (time.Duration, error) {
+		interval := time.Minute
+
+		if first {
+			first = false
+			return interval, task.ErrSkip
+		}
+
+		return interval, nil
+	}
+
+	return f, schedule
+}
+
+func autoCreateInstanceBackups(ctx context.Context, d *Daemon, instances []scheduledBackup) error {
+	failed := []string{}
+
+	for _, b := range instances {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		inst := b.inst
+		err := autoCreateInstanceBackup(ctx, d, inst, b.config)
+		warningUpdateLocalNode(d, inst.Project(), db.WarningEntityTypeInstance, inst.ID(), db.WarningTypeScheduledBackupFailed, err)
+		if err != nil {
+			logger.Warn("Failed creating scheduled instance backup", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+			failed = append(failed, project.Instance(inst.Project(), inst.Name()))
+
+			d.events.SendLifecycle(inst.Project(), lifecycle.InstanceBackupFailed.Event(inst, nil, map[string]interface{}{
+				"error": err.Error(),
+			}))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("Failed creating scheduled backups of: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// autoCreateInstanceBackup creates a scheduled backup of the instance and then applies its retention policy.
+func autoCreateInstanceBackup(ctx context.Context, d *Daemon, inst instance.Instance, config map[string]string) error {
+	name := fmt.Sprintf("scheduled-%s", time.Now().UTC().Format("20060102-150405"))
+
+	args := db.InstanceBackup{
+		Name:             inst.Name() + shared.SnapshotDelimiter + name,
+		InstanceID:       inst.ID(),
+		CreationDate:     time.Now(),
+		ExpiryDate:       time.Time{}, // Retention is handled below.
+		InstanceOnly:     false,
+		OptimizedStorage: shared.IsTrue(config["backups.optimized_storage"]),
+	}
+
+	// Scheduled backups uploaded to S3 use the server wide storage settings.
+	var target *api.BackupTarget
+	if config["backups.target"] == "s3" {
+		target = &api.BackupTarget{Protocol: "s3"}
+		err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			clusterConfig, err := cluster.ConfigLoad(tx)
+			if err != nil {
+				return err
+			}
+
+			target.URL, target.BucketName, target.AccessKey, target.SecretKey = clusterConfig.BackupsS3()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		target.Path = path.Join(inst.Project(), inst.Name(), name)
+	}
+
+	err := backupCreate(ctx, d.State(), args, inst, backupCreateOpts{target: target})
+	if err != nil {
+		return err
+	}
+
+	keep, _ := strconv.Atoi(config["backups.retention"])
+	if keep <= 0 {
+		return nil
+	}
+
+	// Remove the oldest scheduled backups beyond the retention count.
+	if target != nil {
+		target.Path = path.Join(inst.Project(), inst.Name(), "scheduled-")
+		return backup.PruneTarget(ctx, *target, keep)
+	}
+
+	backups, err := inst.Backups()
+	if err != nil {
+		return err
+	}
+
+	scheduled := []string{}
+	for _, b := range backups {
+		if strings.HasPrefix(b.Name(), inst.Name()+shared.SnapshotDelimiter+"scheduled-") {
+			scheduled = append(scheduled, b.Name())
+		}
+	}
+
+	if len(scheduled) <= keep {
+		return nil
+	}
+
+	// The names embed the creation time so sorting them puts the oldest first.
+	sort.Strings(scheduled)
+	for _, backupName := range scheduled[:len(scheduled)-keep] {
+		err = backup.DoBackupDelete(d.State(), inst.Project(), backupName, inst.Name())
+		if err != nil {
+			return errors.Wrapf(err, "Error deleting instance backup %s", backupName)
+		}
+	}
+
+	return nil
+}
@@ -22,11 +22,17 @@ import (
 type Instance interface {
 	Name() string
 	Project() string
+	Location() string
 }
 
+// FormatVersion is the version of the backup format written by this LXD. It is increased whenever a change
+// to the tarball layout or the index would prevent older versions from restoring the backup.
+const FormatVersion = 1
+
 // Info represents exported backup information.
 type Info struct {
-	Project          string           `json:"-" yaml:"-"` // Project is set during import based on current project.
+	Version          int              `json:"version,omitempty" yaml:"version,omitempty"` // Format version (0 for backups predating versioning).
+	Project          string           `json:"-" yaml:"-"`                                 // Project is set during import based on current project.
 	Name             string           `json:"name" yaml:"name"`
 	Backend          string           `json:"backend" yaml:"backend"`
 	Pool             string           `json:"pool" yaml:"pool"`
@@ -34,6 +40,23 @@ type Info struct {
 	OptimizedStorage *bool            `json:"optimized,omitempty" yaml:"optimized,omitempty"`               // Optional field to handle older optimized backups that don't have this field.
 	OptimizedHeader  *bool            `json:"optimized_header,omitempty" yaml:"optimized_header,omitempty"` // Optional field to handle older optimized backups that don't have this field.
 	Type             api.InstanceType `json:"type" yaml:"type"`
+	IncrementalFrom  string           `json:"incremental_from,omitempty" yaml:"incremental_from,omitempty"` // Snapshot the backup is relative to (empty for full backups).
+	Volume           *VolumeInfo      `json:"volume,omitempty" yaml:"volume,omitempty"`                     // Set for custom volume backups only.
+}
+
+// CheckCompatibility checks that the backup can be restored by this LXD onto a pool using the given storage
+// driver. Optimized backups contain the driver's native stream and so can only be restored onto the same
+// driver, other backups can be restored onto any pool.
+func (b *Info) CheckCompatibility(driverName string) error {
+	if b.Version > FormatVersion {
+		return fmt.Errorf("Backup format version %d is newer than the supported version %d", b.Version, FormatVersion)
+	}
+
+	if b.OptimizedStorage != nil && *b.OptimizedStorage && b.Backend != driverName {
+		return fmt.Errorf("Optimized backup storage driver %q differs from the target storage pool driver %q, use a non-optimized backup instead", b.Backend, driverName)
+	}
+
+	return nil
 }
 
 // GetInfo extracts backup information from a given ReadSeeker.
@@ -228,6 +251,8 @@ func (b *Backup) Render() *api.InstanceBackup {
 		InstanceOnly:     b.instanceOnly,
 		ContainerOnly:    b.instanceOnly,
 		OptimizedStorage: b.optimizedStorage,
+		Instance:         b.instance.Name(),
+		Location:         b.instance.Location(),
 	}
 }
 
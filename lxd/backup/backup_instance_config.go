@@ -51,7 +51,8 @@ func updateRootDevicePool(devices map[string]map[string]string, poolName string)
 }
 
 // UpdateInstanceConfigStoragePool changes the pool information in the backup.yaml to the pool
-// specified in b.Pool.
+// specified in b.Pool. It also renames the instance and its snapshots to b.Name, so that a backup
+// can be restored under a different name than the one it was taken from.
 func UpdateInstanceConfigStoragePool(c *db.Cluster, b Info, mountPath string) error {
 	// Load the storage pool.
 	_, pool, err := c.GetStoragePool(b.Pool)
@@ -84,6 +85,20 @@ func UpdateInstanceConfigStoragePool(c *db.Cluster, b Info, mountPath string) er
 			updateRootDevicePool(snapshot.ExpandedDevices, pool.Name)
 		}
 
+		// Change the instance name in the backup.yaml.
+		if backup.Container.Name != b.Name {
+			backup.Container.Name = b.Name
+
+			if backup.Volume != nil {
+				backup.Volume.Name = b.Name
+			}
+
+			for _, snapshot := range backup.Snapshots {
+				_, snapName, _ := shared.InstanceGetParentAndSnapshotName(snapshot.Name)
+				snapshot.Name = snapName
+			}
+		}
+
 		if !rootDiskDeviceFound {
 			return fmt.Errorf("No root device could be found")
 		}
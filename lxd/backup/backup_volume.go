@@ -0,0 +1,138 @@
+package backup
+
+import (
+	"os"
+	"time"
+
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// VolumeInfo represents the custom volume specific information of a custom volume backup.
+type VolumeInfo struct {
+	Description string            `json:"description" yaml:"description"`
+	Config      map[string]string `json:"config" yaml:"config"`
+}
+
+// VolumeBackup represents a custom volume backup.
+type VolumeBackup struct {
+	state       *state.State
+	projectName string
+	poolName    string
+	volumeName  string
+
+	// Properties
+	id               int
+	name             string
+	creationDate     time.Time
+	expiryDate       time.Time
+	volumeOnly       bool
+	optimizedStorage bool
+}
+
+// NewVolumeBackup instantiates a new VolumeBackup struct. The name is of the form <volume>/<backup>.
+func NewVolumeBackup(state *state.State, projectName, poolName, volumeName string, ID int, name string, creationDate, expiryDate time.Time, volumeOnly, optimizedStorage bool) *VolumeBackup {
+	return &VolumeBackup{
+		state:            state,
+		projectName:      projectName,
+		poolName:         poolName,
+		volumeName:       volumeName,
+		id:               ID,
+		name:             name,
+		creationDate:     creationDate,
+		expiryDate:       expiryDate,
+		volumeOnly:       volumeOnly,
+		optimizedStorage: optimizedStorage,
+	}
+}
+
+// VolumeBackupsPath returns the directory holding the backups of the given custom volume.
+func VolumeBackupsPath(projectName, poolName, volumeName string) string {
+	return shared.VarPath("backups", "custom", poolName, project.StorageVolume(projectName, volumeName))
+}
+
+// VolumeBackupPath returns the path of the tarball of the given custom volume backup (of the form
+// <volume>/<backup>).
+func VolumeBackupPath(projectName, poolName, backupName string) string {
+	return shared.VarPath("backups", "custom", poolName, project.StorageVolume(projectName, backupName))
+}
+
+// Name returns the name of the backup.
+func (b *VolumeBackup) Name() string {
+	return b.name
+}
+
+// VolumeOnly returns whether only the volume itself is to be backed up.
+func (b *VolumeBackup) VolumeOnly() bool {
+	return b.volumeOnly
+}
+
+// OptimizedStorage returns whether the backup is to be performed using
+// optimization supported by the storage driver.
+func (b *VolumeBackup) OptimizedStorage() bool {
+	return b.optimizedStorage
+}
+
+// Path returns the path of the backup tarball.
+func (b *VolumeBackup) Path() string {
+	return VolumeBackupPath(b.projectName, b.poolName, b.name)
+}
+
+// Rename renames a custom volume backup.
+func (b *VolumeBackup) Rename(newName string) error {
+	newPath := VolumeBackupPath(b.projectName, b.poolName, newName)
+
+	// Rename the backup tarball.
+	err := os.Rename(b.Path(), newPath)
+	if err != nil {
+		return err
+	}
+
+	// Rename the database record.
+	err = b.state.Cluster.RenameStoragePoolVolumeBackup(b.id, newName)
+	if err != nil {
+		return err
+	}
+
+	b.name = newName
+	return nil
+}
+
+// Delete removes a custom volume backup.
+func (b *VolumeBackup) Delete() error {
+	// Delete the on-disk data.
+	if shared.PathExists(b.Path()) {
+		err := os.Remove(b.Path())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check if we can remove the volume directory.
+	backupsPath := VolumeBackupsPath(b.projectName, b.poolName, b.volumeName)
+	empty, _ := shared.PathIsEmpty(backupsPath)
+	if empty {
+		err := os.Remove(backupsPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Remove the database record.
+	return b.state.Cluster.DeleteStoragePoolVolumeBackup(b.id)
+}
+
+// Render returns a StoragePoolVolumeBackup struct of the backup.
+func (b *VolumeBackup) Render() *api.StoragePoolVolumeBackup {
+	_, backupName, _ := shared.InstanceGetParentAndSnapshotName(b.name)
+
+	return &api.StoragePoolVolumeBackup{
+		Name:             backupName,
+		CreatedAt:        b.creationDate,
+		ExpiresAt:        b.expiryDate,
+		VolumeOnly:       b.volumeOnly,
+		OptimizedStorage: b.optimizedStorage,
+	}
+}
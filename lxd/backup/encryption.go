@@ -0,0 +1,147 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// EncryptionMethods lists the supported backup encryption tools.
+var EncryptionMethods = []string{"age", "gpg"}
+
+const pgpPublicKeyHeader = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+
+// ValidateEncryption checks that a backup encryption setting is usable.
+func ValidateEncryption(encryption api.BackupEncryption) error {
+	if !shared.StringInSlice(encryption.Method, EncryptionMethods) {
+		return fmt.Errorf("Unsupported backup encryption method %q", encryption.Method)
+	}
+
+	if len(encryption.Recipients) == 0 {
+		return fmt.Errorf("Backup encryption requires at least one recipient")
+	}
+
+	for _, recipient := range encryption.Recipients {
+		if encryption.Method == "gpg" && !strings.HasPrefix(strings.TrimSpace(recipient), pgpPublicKeyHeader) {
+			return fmt.Errorf("GPG backup encryption recipients must be ASCII armored public keys")
+		}
+
+		if encryption.Method == "age" && !strings.HasPrefix(recipient, "age1") && !strings.HasPrefix(recipient, "ssh-") {
+			return fmt.Errorf("Invalid age backup encryption recipient %q", recipient)
+		}
+	}
+
+	_, err := exec.LookPath(encryption.Method)
+	if err != nil {
+		return fmt.Errorf("Backup encryption tool %q not found", encryption.Method)
+	}
+
+	return nil
+}
+
+// ParseRecipients splits a list of recipients as stored in configuration.
+// age recipients are separated by commas or whitespace while GPG public keys are simply concatenated.
+func ParseRecipients(method string, value string) []string {
+	recipients := []string{}
+
+	if method == "gpg" {
+		for _, key := range strings.SplitAfter(value, "-----END PGP PUBLIC KEY BLOCK-----") {
+			key = strings.TrimSpace(key)
+			if key != "" {
+				recipients = append(recipients, key)
+			}
+		}
+
+		return recipients
+	}
+
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	})
+}
+
+// EncryptWriter encrypts everything written to it into an underlying writer.
+type EncryptWriter struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr bytes.Buffer
+	tmpDir string
+}
+
+// NewEncryptWriter returns a writer encrypting data to the recipients before writing it to w.
+// The output is only complete once the writer has been closed.
+func NewEncryptWriter(encryption api.BackupEncryption, w io.Writer) (*EncryptWriter, error) {
+	err := ValidateEncryption(encryption)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the GPG keyring and recipient files away from the system ones.
+	tmpDir, err := ioutil.TempDir(shared.VarPath("backups"), "lxd_backup_encrypt_")
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{}
+	if encryption.Method == "gpg" {
+		args = append(args, "--homedir", tmpDir, "--batch", "--no-tty", "--trust-model", "always", "--compress-algo", "none", "--output", "-", "--encrypt")
+		for i, recipient := range encryption.Recipients {
+			keyPath := filepath.Join(tmpDir, fmt.Sprintf("recipient%d.asc", i))
+			err = ioutil.WriteFile(keyPath, []byte(recipient), 0600)
+			if err != nil {
+				os.RemoveAll(tmpDir)
+				return nil, err
+			}
+
+			args = append(args, "--recipient-file", keyPath)
+		}
+	} else {
+		for _, recipient := range encryption.Recipients {
+			args = append(args, "-r", recipient)
+		}
+	}
+
+	e := &EncryptWriter{tmpDir: tmpDir}
+	e.cmd = exec.Command(encryption.Method, args...)
+	e.cmd.Stdout = w
+	e.cmd.Stderr = &e.stderr
+
+	e.stdin, err = e.cmd.StdinPipe()
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, err
+	}
+
+	err = e.cmd.Start()
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (e *EncryptWriter) Write(p []byte) (int, error) {
+	return e.stdin.Write(p)
+}
+
+// Close flushes the encrypted output and waits for the encryption tool to exit.
+func (e *EncryptWriter) Close() error {
+	defer os.RemoveAll(e.tmpDir)
+
+	e.stdin.Close()
+	err := e.cmd.Wait()
+	if err != nil {
+		return fmt.Errorf("%s: %v (%s)", e.cmd.Path, err, strings.TrimSpace(e.stderr.String()))
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// ValidateTarget checks that a backup upload target is usable.
+func ValidateTarget(target api.BackupTarget) error {
+	if target.Protocol != "s3" {
+		return fmt.Errorf("Unsupported backup target protocol %q", target.Protocol)
+	}
+
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return fmt.Errorf("Invalid backup target URL %q: %v", target.URL, err)
+	}
+
+	if u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("Invalid backup target URL %q", target.URL)
+	}
+
+	if target.BucketName == "" {
+		return fmt.Errorf("Missing backup target bucket name")
+	}
+
+	if target.Path == "" {
+		return fmt.Errorf("Missing backup target path")
+	}
+
+	return nil
+}
+
+// targetClient returns an S3 client for the given target.
+func targetClient(target api.BackupTarget) (*minio.Client, error) {
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	return minio.New(u.Host, &minio.Options{
+		Creds:  credentials.NewStaticV4(target.AccessKey, target.SecretKey, ""),
+		Secure: u.Scheme == "https",
+	})
+}
+
+// PruneTarget deletes the oldest objects whose name starts with the target's path, only keeping the
+// most recent ones.
+func PruneTarget(ctx context.Context, target api.BackupTarget, keep int) error {
+	err := ValidateTarget(target)
+	if err != nil {
+		return err
+	}
+
+	client, err := targetClient(target)
+	if err != nil {
+		return err
+	}
+
+	objects := []minio.ObjectInfo{}
+	for object := range client.ListObjects(ctx, target.BucketName, minio.ListObjectsOptions{Prefix: target.Path, Recursive: true}) {
+		if object.Err != nil {
+			return object.Err
+		}
+
+		objects = append(objects, object)
+	}
+
+	if len(objects) <= keep {
+		return nil
+	}
+
+	// Delete oldest first.
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].LastModified.Before(objects[j].LastModified)
+	})
+
+	for _, object := range objects[:len(objects)-keep] {
+		err := client.RemoveObject(ctx, target.BucketName, object.Key, minio.RemoveObjectOptions{})
+		if err != nil {
+			return fmt.Errorf("Failed deleting %q from backup target: %v", object.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// TargetWriter streams everything written to it into an S3 object.
+type TargetWriter struct {
+	pipeWriter *io.PipeWriter
+	done       chan error
+}
+
+// NewTargetWriter returns a writer uploading a backup to the given target as it's being written.
+// The upload only completes once the writer is closed, CloseWithError aborts it.
+func NewTargetWriter(ctx context.Context, target api.BackupTarget) (*TargetWriter, error) {
+	err := ValidateTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := targetClient(target)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	w := &TargetWriter{
+		pipeWriter: pipeWriter,
+		done:       make(chan error, 1),
+	}
+
+	go func() {
+		// Unknown size, the client falls back to a multipart upload.
+		_, err := client.PutObject(ctx, target.BucketName, target.Path, pipeReader, -1, minio.PutObjectOptions{ContentType: "application/octet-stream"})
+
+		// Unblock the writer if the upload failed early.
+		pipeReader.CloseWithError(err)
+		w.done <- err
+	}()
+
+	return w, nil
+}
+
+func (w *TargetWriter) Write(p []byte) (int, error) {
+	return w.pipeWriter.Write(p)
+}
+
+// Close finishes the upload and returns its result.
+func (w *TargetWriter) Close() error {
+	w.pipeWriter.Close()
+	return <-w.done
+}
+
+// CloseWithError aborts the upload.
+func (w *TargetWriter) CloseWithError(err error) error {
+	w.pipeWriter.CloseWithError(err)
+	<-w.done
+	return nil
+}
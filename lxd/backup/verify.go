@@ -0,0 +1,241 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// ageHeader is the start of files encrypted with age.
+const ageHeader = "age-encryption.org/"
+
+// VerifyResult describes a backup that passed verification.
+type VerifyResult struct {
+	// SHA256 checksum of the backup file.
+	SHA256 string
+
+	// Format of the backup file (e.g. ".tar.gz", ".qcow2" or ".ova").
+	Format string
+
+	// Number of entries in the backup tarball.
+	Entries int
+
+	// Whether the backup contains optimized storage streams.
+	Optimized bool
+}
+
+// Verify checks the integrity of the backup file at path without restoring it.
+//
+// The whole file is decompressed and its tarball structure walked (relying on the compressor's own
+// checksums), the index is checked against the content of the tarball and optimized storage
+// streams are parsed with the tools of their storage driver.
+func Verify(path string) (*VerifyResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	result := VerifyResult{}
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, f)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed reading backup file")
+	}
+
+	result.SHA256 = fmt.Sprintf("%x", hash.Sum(nil))
+
+	f.Seek(0, 0)
+	_, ext, unpacker, err := shared.DetectCompressionFile(f)
+	if err != nil {
+		header := make([]byte, len(ageHeader))
+		f.Seek(0, 0)
+		f.Read(header)
+		if string(header) == ageHeader {
+			return nil, fmt.Errorf("Encrypted backups can't be verified by the server")
+		}
+
+		return nil, errors.Wrap(err, "Unknown backup format (the backup may be encrypted)")
+	}
+
+	result.Format = ext
+
+	switch ext {
+	case ".qcow2":
+		// Exported virtual machine disk.
+		_, err = shared.RunCommand("qemu-img", "check", "-f", "qcow2", path)
+		if err != nil {
+			return nil, errors.Wrap(err, "Corrupted qcow2 image")
+		}
+
+		return &result, nil
+	case ".squashfs":
+		return nil, fmt.Errorf("Verifying squashfs backups isn't supported")
+	}
+
+	// Decompress the tarball, keeping track of the decompression status.
+	f.Seek(0, 0)
+	var r io.Reader = f
+	var cmd *exec.Cmd
+	stderr := bytes.Buffer{}
+
+	if len(unpacker) > 0 {
+		cmd = exec.Command(unpacker[0], unpacker[1:]...)
+		cmd.Stdin = f
+		cmd.Stderr = &stderr
+
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, err
+		}
+
+		err = cmd.Start()
+		if err != nil {
+			return nil, err
+		}
+
+		r = stdout
+	}
+
+	err = verifyTarball(tar.NewReader(r), &result)
+	if err != nil {
+		if cmd != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+		}
+
+		return nil, err
+	}
+
+	if cmd != nil {
+		// Drain the decompressor so it can detect trailing corruption.
+		io.Copy(ioutil.Discard, r)
+
+		err = cmd.Wait()
+		if err != nil {
+			return nil, fmt.Errorf("Corrupted %s compression: %s", ext, strings.TrimSpace(stderr.String()))
+		}
+	}
+
+	return &result, nil
+}
+
+// verifyTarball walks the whole tarball and checks that its content matches its index.
+func verifyTarball(tr *tar.Reader, result *VerifyResult) error {
+	var info *Info
+	entries := map[string]bool{}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "Corrupted backup tarball")
+		}
+
+		result.Entries++
+		entries[hdr.Name] = true
+
+		switch {
+		case hdr.Name == "backup/index.yaml":
+			info = &Info{}
+			err = yaml.NewDecoder(tr).Decode(info)
+			if err != nil {
+				return errors.Wrap(err, "Invalid backup index")
+			}
+
+			if info.Version > FormatVersion {
+				return fmt.Errorf("Backup format version %d is newer than the supported version %d", info.Version, FormatVersion)
+			}
+		case strings.HasPrefix(hdr.Name, "backup/") && strings.HasSuffix(hdr.Name, ".bin"):
+			// Optimized storage stream, the index always comes first.
+			if info == nil {
+				return fmt.Errorf("Backup index must precede the optimized stream %q", hdr.Name)
+			}
+
+			result.Optimized = true
+			err = verifyOptimizedStream(info.Backend, tr)
+			if err != nil {
+				return errors.Wrapf(err, "Corrupted optimized stream %q", hdr.Name)
+			}
+		case strings.HasSuffix(hdr.Name, ".ovf") && result.Entries == 1:
+			// Exported virtual machine bundle.
+			result.Format = ".ova"
+		}
+
+		// Read the rest of the entry to detect truncated tarballs.
+		_, err = io.Copy(ioutil.Discard, tr)
+		if err != nil {
+			return errors.Wrapf(err, "Corrupted backup tarball entry %q", hdr.Name)
+		}
+	}
+
+	if result.Format == ".ova" {
+		return nil
+	}
+
+	if info == nil {
+		return fmt.Errorf("Backup is missing index.yaml")
+	}
+
+	// Check that all the snapshots listed in the index are present.
+	for _, snapName := range info.Snapshots {
+		found := false
+		for name := range entries {
+			if strings.HasPrefix(name, fmt.Sprintf("backup/snapshots/%s", snapName)) || strings.HasPrefix(name, fmt.Sprintf("backup/virtual-machine-snapshots/%s", snapName)) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("Backup is missing snapshot %q", snapName)
+		}
+	}
+
+	return nil
+}
+
+// verifyOptimizedStream parses an optimized storage stream without applying it.
+func verifyOptimizedStream(backend string, r io.Reader) error {
+	var args []string
+	switch backend {
+	case "btrfs":
+		args = []string{"btrfs", "receive", "--dump"}
+	case "zfs":
+		args = []string{"zstreamdump"}
+	default:
+		return fmt.Errorf("Unsupported optimized storage driver %q", backend)
+	}
+
+	_, err := exec.LookPath(args[0])
+	if err != nil {
+		return fmt.Errorf("Unable to verify %s streams: %q not found", backend, args[0])
+	}
+
+	stderr := bytes.Buffer{}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = r
+	cmd.Stdout = ioutil.Discard
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/resources"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/units"
+)
+
+// backupOVFTemplate is the OVF descriptor included in OVA exports. It describes a single virtual
+// machine with a stream optimized VMDK disk attached to a SCSI controller.
+var backupOVFTemplate = template.Must(template.New("ovf").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1" xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData" xmlns:vssd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_VirtualSystemSettingData">
+  <References>
+    <File ovf:id="file1" ovf:href="{{.diskFile}}" ovf:size="{{.diskFileSize}}"/>
+  </References>
+  <DiskSection>
+    <Info>Virtual disk information</Info>
+    <Disk ovf:diskId="vmdisk1" ovf:fileRef="file1" ovf:capacity="{{.diskCapacity}}" ovf:capacityAllocationUnits="byte" ovf:format="http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized"/>
+  </DiskSection>
+  <VirtualSystem ovf:id="{{.name}}">
+    <Info>A virtual machine exported from LXD</Info>
+    <Name>{{.name}}</Name>
+    <OperatingSystemSection ovf:id="1">
+      <Info>The kind of installed guest operating system</Info>
+    </OperatingSystemSection>
+    <VirtualHardwareSection>
+      <Info>Virtual hardware requirements</Info>
+      <System>
+        <vssd:ElementName>Virtual Hardware Family</vssd:ElementName>
+        <vssd:InstanceID>0</vssd:InstanceID>
+        <vssd:VirtualSystemIdentifier>{{.name}}</vssd:VirtualSystemIdentifier>
+        <vssd:VirtualSystemType>vmx-13</vssd:VirtualSystemType>
+      </System>
+      <Item>
+        <rasd:AllocationUnits>hertz * 10^6</rasd:AllocationUnits>
+        <rasd:ElementName>{{.cpuCount}} virtual CPU(s)</rasd:ElementName>
+        <rasd:InstanceID>1</rasd:InstanceID>
+        <rasd:ResourceType>3</rasd:ResourceType>
+        <rasd:VirtualQuantity>{{.cpuCount}}</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:AllocationUnits>byte * 2^20</rasd:AllocationUnits>
+        <rasd:ElementName>{{.memory}}MB of memory</rasd:ElementName>
+        <rasd:InstanceID>2</rasd:InstanceID>
+        <rasd:ResourceType>4</rasd:ResourceType>
+        <rasd:VirtualQuantity>{{.memory}}</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:ElementName>SCSI Controller 0</rasd:ElementName>
+        <rasd:InstanceID>3</rasd:InstanceID>
+        <rasd:ResourceSubType>VirtualSCSI</rasd:ResourceSubType>
+        <rasd:ResourceType>6</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:AddressOnParent>0</rasd:AddressOnParent>
+        <rasd:ElementName>Hard Disk 1</rasd:ElementName>
+        <rasd:HostResource>ovf:/disk/vmdisk1</rasd:HostResource>
+        <rasd:InstanceID>4</rasd:InstanceID>
+        <rasd:Parent>3</rasd:Parent>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>
+`))
+
+// backupExportDisk writes the root disk of a virtual machine to w, either as a qcow2 image or as an
+// OVA bundle (a tarball containing an OVF descriptor and a stream optimized VMDK image).
+func backupExportDisk(inst instance.Instance, pool storagePools.Pool, format string, w io.Writer) error {
+	_, err := pool.MountInstance(inst, nil)
+	if err != nil {
+		return err
+	}
+	defer pool.UnmountInstance(inst, nil)
+
+	diskPath, err := pool.GetInstanceDisk(inst)
+	if err != nil {
+		return errors.Wrap(err, "Failed getting instance disk path")
+	}
+
+	// Convert the disk in a temporary directory.
+	tmpDir, err := ioutil.TempDir(shared.VarPath("backups"), "lxd_backup_export_")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var imagePath string
+	switch format {
+	case "qcow2":
+		imagePath = filepath.Join(tmpDir, fmt.Sprintf("%s.qcow2", inst.Name()))
+		_, err = shared.RunCommand("qemu-img", "convert", "-f", "raw", "-O", "qcow2", diskPath, imagePath)
+	case "ova":
+		imagePath = filepath.Join(tmpDir, fmt.Sprintf("%s-disk1.vmdk", inst.Name()))
+		_, err = shared.RunCommand("qemu-img", "convert", "-f", "raw", "-O", "vmdk", "-o", "subformat=streamOptimized", diskPath, imagePath)
+	default:
+		return fmt.Errorf("Unsupported export format %q", format)
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed converting instance disk")
+	}
+
+	image, err := os.Open(imagePath)
+	if err != nil {
+		return err
+	}
+	defer image.Close()
+
+	if format == "qcow2" {
+		_, err = io.Copy(w, image)
+		return err
+	}
+
+	imageInfo, err := image.Stat()
+	if err != nil {
+		return err
+	}
+
+	capacity, err := backupDiskCapacity(diskPath)
+	if err != nil {
+		return err
+	}
+
+	cpuCount, memory, err := backupInstanceResources(inst)
+	if err != nil {
+		return err
+	}
+
+	// Generate the OVF descriptor.
+	ovf := &bytes.Buffer{}
+	err = backupOVFTemplate.Execute(ovf, map[string]interface{}{
+		"name":         inst.Name(),
+		"cpuCount":     cpuCount,
+		"memory":       memory,
+		"diskFile":     filepath.Base(imagePath),
+		"diskFileSize": imageInfo.Size(),
+		"diskCapacity": capacity,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed generating OVF descriptor")
+	}
+
+	// The OVF descriptor must be the first entry of the OVA bundle.
+	tw := tar.NewWriter(w)
+	now := time.Now()
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:    fmt.Sprintf("%s.ovf", inst.Name()),
+		Mode:    0644,
+		Size:    int64(ovf.Len()),
+		ModTime: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(ovf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:    filepath.Base(imagePath),
+		Mode:    0644,
+		Size:    imageInfo.Size(),
+		ModTime: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tw, image)
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// backupDiskCapacity returns the size in bytes of the disk file or block device at path.
+func backupDiskCapacity(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return -1, err
+	}
+	defer f.Close()
+
+	return f.Seek(0, io.SeekEnd)
+}
+
+// backupInstanceResources returns the number of CPUs and the memory (in MiB) of a virtual machine,
+// using the same defaults as when starting it.
+func backupInstanceResources(inst instance.Instance) (int, int64, error) {
+	cpuCount := 1
+	cpus := inst.ExpandedConfig()["limits.cpu"]
+	if cpus != "" {
+		count, err := strconv.Atoi(cpus)
+		if err != nil {
+			// Pinned CPUs.
+			set, err := resources.ParseCpuset(cpus)
+			if err != nil {
+				return -1, -1, errors.Wrap(err, "limits.cpu invalid")
+			}
+
+			count = len(set)
+		}
+
+		cpuCount = count
+	}
+
+	memSize := inst.ExpandedConfig()["limits.memory"]
+	if memSize == "" {
+		memSize = "1GiB"
+	}
+
+	memSizeBytes, err := units.ParseByteSizeString(memSize)
+	if err != nil {
+		return -1, -1, errors.Wrap(err, "limits.memory invalid")
+	}
+
+	return cpuCount, memSizeBytes / 1024 / 1024, nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var batchCmd = APIEndpoint{
+	Path: "batch",
+
+	Post: APIEndpointAction{Handler: batchPost, AccessHandler: allowAuthenticated},
+}
+
+// batchPost runs an ordered list of API requests as a single background operation.
+// Each request goes through the normal routing and access checks, so a batch never grants more than the
+// individual requests would.
+func batchPost(d *Daemon, r *http.Request) response.Response {
+	req := api.BatchPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if len(req.Requests) == 0 {
+		return response.BadRequest(fmt.Errorf("No requests provided"))
+	}
+
+	for i, entry := range req.Requests {
+		if !shared.StringInSlice(entry.Method, []string{"GET", "POST", "PUT", "PATCH", "DELETE"}) {
+			return response.BadRequest(fmt.Errorf("Invalid method %q for request %d", entry.Method, i))
+		}
+
+		u, err := url.Parse(entry.URL)
+		if err != nil {
+			return response.BadRequest(fmt.Errorf("Invalid URL %q for request %d: %v", entry.URL, i, err))
+		}
+
+		if !strings.HasPrefix(u.Path, fmt.Sprintf("/%s/", version.APIVersion)) {
+			return response.BadRequest(fmt.Errorf("Invalid URL %q for request %d", entry.URL, i))
+		}
+
+		if strings.HasPrefix(u.Path, fmt.Sprintf("/%s/batch", version.APIVersion)) {
+			return response.BadRequest(fmt.Errorf("Batch requests can't be nested"))
+		}
+	}
+
+	run := func(op *operations.Operation) error {
+		results := make([]api.BatchResult, 0, len(req.Requests))
+
+		// Resources created by each successful request, used to undo atomic batches.
+		type created struct {
+			index int
+			urls  []string
+		}
+		reverts := []created{}
+
+		for i, entry := range req.Requests {
+			resp, headers, err := batchRequest(d, r, entry.Method, entry.URL, entry.Body)
+
+			result := api.BatchResult{
+				Method: entry.Method,
+				URL:    entry.URL,
+			}
+
+			if resp != nil {
+				result.StatusCode = resp.StatusCode
+				result.Metadata = resp.Metadata
+			}
+
+			if err != nil {
+				result.Error = err.Error()
+			}
+
+			results = append(results, result)
+			op.UpdateMetadata(map[string]interface{}{"results": results})
+
+			if err == nil {
+				reverts = append(reverts, created{index: i, urls: batchCreatedURLs(entry, resp, headers)})
+				continue
+			}
+
+			if req.Atomic {
+				for j := len(reverts) - 1; j >= 0; j-- {
+					if len(reverts[j].urls) == 0 {
+						continue
+					}
+
+					reverted := true
+					for k := len(reverts[j].urls) - 1; k >= 0; k-- {
+						_, _, err := batchRequest(d, r, "DELETE", reverts[j].urls[k], nil)
+						if err != nil {
+							logger.Error("Failed to revert batch request", log.Ctx{"url": reverts[j].urls[k], "err": err})
+							reverted = false
+						}
+					}
+
+					results[reverts[j].index].Reverted = reverted
+				}
+
+				op.UpdateMetadata(map[string]interface{}{"results": results})
+			}
+
+			return fmt.Errorf("Request %d (%s %s) failed: %v", i, entry.Method, entry.URL, err)
+		}
+
+		return nil
+	}
+
+	op, err := operations.OperationCreate(d.State(), projectParam(r), operations.OperationClassTask, db.OperationBatch, nil, nil, run, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// batchRequest dispatches a single request through the main API router using the credentials of the original
+// batch request. Background operations started by the request are waited on before returning.
+func batchRequest(d *Daemon, r *http.Request, method string, path string, body interface{}) (*api.Response, http.Header, error) {
+	var reader io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		reader = bytes.NewReader(buf)
+	}
+
+	// The batch runs in a background operation, so don't tie it to the lifetime of the original request.
+	req, err := http.NewRequest(method, path, reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for key, values := range r.Header {
+		if key == "Content-Length" {
+			continue
+		}
+
+		req.Header[key] = values
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = r.RemoteAddr
+	req.TLS = r.TLS
+	req.Host = r.Host
+
+	rec := httptest.NewRecorder()
+	d.router.ServeHTTP(rec, req)
+
+	resp := api.Response{}
+	err = json.NewDecoder(rec.Body).Decode(&resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to parse response: %v", err)
+	}
+
+	switch resp.Type {
+	case api.ErrorResponse:
+		resp.StatusCode = resp.Code
+		return &resp, rec.Header(), fmt.Errorf("%s", resp.Error)
+	case api.AsyncResponse:
+		opAPI, err := resp.MetadataAsOperation()
+		if err != nil {
+			return &resp, rec.Header(), err
+		}
+
+		op, err := operations.OperationGetInternal(opAPI.ID)
+		if err != nil {
+			return &resp, rec.Header(), fmt.Errorf("Operation %q isn't running on this server", opAPI.ID)
+		}
+
+		_, err = op.WaitFinal(-1)
+		if err != nil {
+			return &resp, rec.Header(), err
+		}
+
+		_, opAPI, err = op.Render()
+		if err != nil {
+			return &resp, rec.Header(), err
+		}
+
+		resp.Metadata, err = json.Marshal(opAPI)
+		if err != nil {
+			return &resp, rec.Header(), err
+		}
+
+		resp.StatusCode = int(opAPI.StatusCode)
+		if opAPI.StatusCode != api.Success {
+			return &resp, rec.Header(), fmt.Errorf("%s", opAPI.Err)
+		}
+	}
+
+	return &resp, rec.Header(), nil
+}
+
+// batchCreatedURLs returns the URLs of the entities created by a successful POST request against a collection.
+// Only direct children of the collection are considered, so renames and actions on existing entities are ignored.
+func batchCreatedURLs(entry api.BatchRequest, resp *api.Response, headers http.Header) []string {
+	if entry.Method != "POST" || resp == nil {
+		return nil
+	}
+
+	u, err := url.Parse(entry.URL)
+	if err != nil {
+		return nil
+	}
+
+	prefix := strings.TrimSuffix(u.Path, "/") + "/"
+	isChild := func(path string) bool {
+		return strings.HasPrefix(path, prefix) && !strings.Contains(strings.TrimPrefix(path, prefix), "/")
+	}
+
+	candidates := []string{}
+	if resp.Type == api.AsyncResponse {
+		opAPI, err := resp.MetadataAsOperation()
+		if err == nil {
+			for _, values := range opAPI.Resources {
+				candidates = append(candidates, values...)
+			}
+		}
+	} else if headers.Get("Location") != "" {
+		candidates = append(candidates, headers.Get("Location"))
+	}
+
+	urls := []string{}
+	for _, candidate := range candidates {
+		if !isChild(candidate) {
+			continue
+		}
+
+		// Keep the project and target of the original request.
+		if u.RawQuery != "" {
+			candidate = fmt.Sprintf("%s?%s", candidate, u.RawQuery)
+		}
+
+		urls = append(urls, candidate)
+	}
+
+	return urls
+}
@@ -0,0 +1,45 @@
+package bgp
+
+import (
+	bgpLog "github.com/osrg/gobgp/v3/pkg/log"
+
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// logWrapper forwards the messages of the BGP server to the LXD logger.
+type logWrapper struct {
+	level bgpLog.LogLevel
+}
+
+func (l *logWrapper) Panic(msg string, fields bgpLog.Fields) {
+	logger.Crit(msg, log.Ctx(fields))
+}
+
+func (l *logWrapper) Fatal(msg string, fields bgpLog.Fields) {
+	logger.Crit(msg, log.Ctx(fields))
+}
+
+func (l *logWrapper) Error(msg string, fields bgpLog.Fields) {
+	logger.Error(msg, log.Ctx(fields))
+}
+
+func (l *logWrapper) Warn(msg string, fields bgpLog.Fields) {
+	logger.Warn(msg, log.Ctx(fields))
+}
+
+func (l *logWrapper) Info(msg string, fields bgpLog.Fields) {
+	logger.Info(msg, log.Ctx(fields))
+}
+
+func (l *logWrapper) Debug(msg string, fields bgpLog.Fields) {
+	logger.Debug(msg, log.Ctx(fields))
+}
+
+func (l *logWrapper) SetLevel(level bgpLog.LogLevel) {
+	l.level = level
+}
+
+func (l *logWrapper) GetLevel() bgpLog.LogLevel {
+	return l.level
+}
@@ -0,0 +1,429 @@
+package bgp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	bgpAPI "github.com/osrg/gobgp/v3/api"
+	bgpServer "github.com/osrg/gobgp/v3/pkg/server"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// Server represents a BGP server instance.
+type Server struct {
+	bgp *bgpServer.BgpServer
+
+	// Internal state (to handle reconfiguration).
+	address  string
+	asn      uint32
+	routerID net.IP
+
+	// Prefixes and peers, kept around so they can be re-applied when the server is reconfigured.
+	paths map[string]*path
+	peers map[string]*peer
+
+	mu sync.Mutex
+}
+
+// path is a prefix announced by the server.
+type path struct {
+	owner   string
+	prefix  net.IPNet
+	nexthop net.IP
+
+	// Identifier of the path in the running server, nil if not currently announced.
+	uuid []byte
+}
+
+// peer is a BGP neighbor of the server.
+type peer struct {
+	address  net.IP
+	asn      uint32
+	password string
+
+	// Users of the peer (the same peer can be configured by multiple networks).
+	owners map[string]struct{}
+}
+
+// NewServer returns a new server instance.
+func NewServer() *Server {
+	// Setup new struct.
+	s := &Server{
+		paths: map[string]*path{},
+		peers: map[string]*peer{},
+	}
+
+	return s
+}
+
+// Start sets up the BGP listener.
+func (s *Server) Start(address string, asn uint32, routerID net.IP) error {
+	// Locking.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Setup the listener.
+	return s.start(address, asn, routerID)
+}
+
+func (s *Server) start(address string, asn uint32, routerID net.IP) error {
+	// Skip if not configured.
+	if address == "" || asn == 0 {
+		return nil
+	}
+
+	// Set default port if needed.
+	address = canonicalAddress(address)
+
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid BGP address %q", address)
+	}
+
+	port, err := strconv.ParseInt(portStr, 10, 32)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid BGP port %q", portStr)
+	}
+
+	// Default to the listen address as router ID if it's a specific IPv4 address.
+	if routerID == nil {
+		listenIP := net.ParseIP(host)
+		if listenIP == nil || listenIP.To4() == nil || listenIP.IsUnspecified() {
+			return fmt.Errorf("A BGP router ID must be set when not listening on a specific IPv4 address")
+		}
+
+		routerID = listenIP
+	}
+
+	// Setup the server.
+	s.bgp = bgpServer.NewBgpServer(bgpServer.LoggerOption(&logWrapper{}))
+	go s.bgp.Serve()
+
+	err = s.bgp.StartBgp(context.Background(), &bgpAPI.StartBgpRequest{
+		Global: &bgpAPI.Global{
+			Asn:             asn,
+			RouterId:        routerID.String(),
+			ListenPort:      int32(port),
+			ListenAddresses: []string{host},
+		},
+	})
+	if err != nil {
+		s.bgp.Stop()
+		s.bgp = nil
+		return errors.Wrapf(err, "Failed to start BGP server on %q", address)
+	}
+
+	// Record the configuration.
+	s.address = address
+	s.asn = asn
+	s.routerID = routerID
+
+	// Add the existing peers.
+	for _, p := range s.peers {
+		err := s.addPeer(p)
+		if err != nil {
+			logger.Warnf("Failed to add BGP peer %q: %v", p.address.String(), err)
+		}
+	}
+
+	// Announce the existing prefixes.
+	for _, p := range s.paths {
+		err := s.addPath(p)
+		if err != nil {
+			logger.Warnf("Failed to announce BGP prefix %q: %v", p.prefix.String(), err)
+		}
+	}
+
+	return nil
+}
+
+// Stop tears down the BGP listener.
+func (s *Server) Stop() error {
+	// Locking.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stop()
+}
+
+func (s *Server) stop() error {
+	// Skip if no instance.
+	if s.bgp == nil {
+		return nil
+	}
+
+	// Stop the server, closing all the peer sessions.
+	err := s.bgp.StopBgp(context.Background(), &bgpAPI.StopBgpRequest{})
+	if err != nil {
+		return errors.Wrap(err, "Failed to stop BGP server")
+	}
+
+	s.bgp.Stop()
+
+	// Forget the identifiers of the announced paths.
+	for _, p := range s.paths {
+		p.uuid = nil
+	}
+
+	// Unset the configuration.
+	s.bgp = nil
+	s.address = ""
+	s.asn = 0
+	s.routerID = nil
+
+	return nil
+}
+
+// Reconfigure updates the listener with a new configuration.
+func (s *Server) Reconfigure(address string, asn uint32, routerID net.IP) error {
+	// Locking.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Get the old configuration.
+	oldAddress := s.address
+	oldASN := s.asn
+	oldRouterID := s.routerID
+
+	// Setup reverter.
+	revert := true
+	defer func() {
+		if revert && oldAddress != "" {
+			s.start(oldAddress, oldASN, oldRouterID)
+		}
+	}()
+
+	// Stop the listener.
+	err := s.stop()
+	if err != nil {
+		return err
+	}
+
+	// Start the listener (skipped if disabled).
+	err = s.start(address, asn, routerID)
+	if err != nil {
+		return err
+	}
+
+	// All done.
+	revert = false
+	return nil
+}
+
+// AddPrefix announces a prefix with the given next-hop on behalf of the given owner. A nil next-hop lets the
+// server use its own address.
+func (s *Server) AddPrefix(subnet net.IPNet, nexthop net.IP, owner string) error {
+	// Locking.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if nexthop == nil {
+		nexthop = net.IPv4zero
+		if subnet.IP.To4() == nil {
+			nexthop = net.IPv6zero
+		}
+	}
+
+	p := &path{owner: owner, prefix: subnet, nexthop: nexthop}
+	key := fmt.Sprintf("%s/%s/%s", owner, subnet.String(), nexthop.String())
+
+	// Skip if already announced.
+	_, ok := s.paths[key]
+	if ok {
+		return nil
+	}
+
+	if s.bgp != nil {
+		err := s.addPath(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.paths[key] = p
+
+	return nil
+}
+
+// RemovePrefixByOwner withdraws all the prefixes announced on behalf of the given owner.
+func (s *Server) RemovePrefixByOwner(owner string) error {
+	// Locking.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, p := range s.paths {
+		if p.owner != owner {
+			continue
+		}
+
+		if s.bgp != nil && p.uuid != nil {
+			err := s.bgp.DeletePath(context.Background(), &bgpAPI.DeletePathRequest{Uuid: p.uuid})
+			if err != nil {
+				return errors.Wrapf(err, "Failed to withdraw BGP prefix %q", p.prefix.String())
+			}
+		}
+
+		delete(s.paths, key)
+	}
+
+	return nil
+}
+
+// AddPeer adds a BGP neighbor on behalf of the given owner. Adding an already existing peer with the same settings
+// only records the extra owner, while different settings are refused.
+func (s *Server) AddPeer(address net.IP, asn uint32, password string, owner string) error {
+	// Locking.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.peers[address.String()]
+	if ok {
+		if p.asn != asn || p.password != password {
+			return fmt.Errorf("BGP peer %q is already configured with different settings", address.String())
+		}
+
+		p.owners[owner] = struct{}{}
+		return nil
+	}
+
+	p = &peer{address: address, asn: asn, password: password, owners: map[string]struct{}{owner: {}}}
+
+	if s.bgp != nil {
+		err := s.addPeer(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.peers[address.String()] = p
+
+	return nil
+}
+
+// RemovePeer removes the given owner from a BGP neighbor, removing the neighbor once it has no owners left.
+func (s *Server) RemovePeer(address net.IP, owner string) error {
+	// Locking.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.peers[address.String()]
+	if !ok {
+		return nil
+	}
+
+	delete(p.owners, owner)
+	if len(p.owners) > 0 {
+		return nil
+	}
+
+	if s.bgp != nil {
+		err := s.bgp.DeletePeer(context.Background(), &bgpAPI.DeletePeerRequest{Address: address.String()})
+		if err != nil {
+			return errors.Wrapf(err, "Failed to remove BGP peer %q", address.String())
+		}
+	}
+
+	delete(s.peers, address.String())
+
+	return nil
+}
+
+// addPath announces the path in the running server.
+func (s *Server) addPath(p *path) error {
+	prefixLen, _ := p.prefix.Mask.Size()
+
+	family := &bgpAPI.Family{Afi: bgpAPI.Family_AFI_IP, Safi: bgpAPI.Family_SAFI_UNICAST}
+	if p.prefix.IP.To4() == nil {
+		family.Afi = bgpAPI.Family_AFI_IP6
+	}
+
+	nlri, err := anypb.New(&bgpAPI.IPAddressPrefix{
+		Prefix:    p.prefix.IP.String(),
+		PrefixLen: uint32(prefixLen),
+	})
+	if err != nil {
+		return err
+	}
+
+	origin, err := anypb.New(&bgpAPI.OriginAttribute{Origin: 0})
+	if err != nil {
+		return err
+	}
+
+	// IPv6 next-hops are carried by the multiprotocol reachability attribute.
+	var nexthop *anypb.Any
+	if family.Afi == bgpAPI.Family_AFI_IP {
+		nexthop, err = anypb.New(&bgpAPI.NextHopAttribute{NextHop: p.nexthop.String()})
+	} else {
+		nexthop, err = anypb.New(&bgpAPI.MpReachNLRIAttribute{
+			Family:   family,
+			NextHops: []string{p.nexthop.String()},
+			Nlris:    []*anypb.Any{nlri},
+		})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.bgp.AddPath(context.Background(), &bgpAPI.AddPathRequest{
+		Path: &bgpAPI.Path{
+			Family: family,
+			Nlri:   nlri,
+			Pattrs: []*anypb.Any{origin, nexthop},
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Failed to announce BGP prefix %q", p.prefix.String())
+	}
+
+	p.uuid = resp.Uuid
+
+	return nil
+}
+
+// addPeer adds the peer to the running server.
+func (s *Server) addPeer(p *peer) error {
+	families := []*bgpAPI.AfiSafi{}
+	for _, afi := range []bgpAPI.Family_Afi{bgpAPI.Family_AFI_IP, bgpAPI.Family_AFI_IP6} {
+		families = append(families, &bgpAPI.AfiSafi{
+			Config: &bgpAPI.AfiSafiConfig{
+				Family:  &bgpAPI.Family{Afi: afi, Safi: bgpAPI.Family_SAFI_UNICAST},
+				Enabled: true,
+			},
+		})
+	}
+
+	err := s.bgp.AddPeer(context.Background(), &bgpAPI.AddPeerRequest{
+		Peer: &bgpAPI.Peer{
+			Conf: &bgpAPI.PeerConf{
+				NeighborAddress: p.address.String(),
+				PeerAsn:         p.asn,
+				AuthPassword:    p.password,
+			},
+			AfiSafis: families,
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Failed to add BGP peer %q", p.address.String())
+	}
+
+	return nil
+}
+
+// canonicalAddress adds the default BGP port to the address if missing.
+func canonicalAddress(address string) string {
+	_, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return net.JoinHostPort(strings.Trim(address, "[]"), fmt.Sprintf("%d", 179))
+	}
+
+	return address
+}
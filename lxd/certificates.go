@@ -15,6 +15,9 @@ import (
 	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/rbac"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
@@ -48,9 +51,15 @@ func certificatesGet(d *Daemon, r *http.Request) response.Response {
 		certResponses := []api.Certificate{}
 
 		var baseCerts []db.Certificate
+		var roles map[string]map[string]string
 		var err error
 		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
 			baseCerts, err = tx.GetCertificates(db.CertificateFilter{})
+			if err != nil {
+				return err
+			}
+
+			roles, err = tx.GetCertificatesRoles()
 			return err
 		})
 		if err != nil {
@@ -62,20 +71,19 @@ func certificatesGet(d *Daemon, r *http.Request) response.Response {
 			resp.Fingerprint = baseCert.Fingerprint
 			resp.Certificate = baseCert.Certificate
 			resp.Name = baseCert.Name
-			if baseCert.Type == 1 {
-				resp.Type = "client"
-			} else {
-				resp.Type = "unknown"
-			}
+			resp.Type = db.CertificateTypeName(baseCert.Type)
+			resp.Roles = roles[baseCert.Fingerprint]
 			certResponses = append(certResponses, resp)
 		}
 		return response.SyncResponse(true, certResponses)
 	}
 
 	body := []string{}
-	for _, cert := range d.clientCerts {
-		fingerprint := fmt.Sprintf("/%s/certificates/%s", version.APIVersion, shared.CertFingerprint(&cert))
-		body = append(body, fingerprint)
+	for _, certs := range []map[string]x509.Certificate{d.clientCerts, d.metricsCerts} {
+		for _, cert := range certs {
+			fingerprint := fmt.Sprintf("/%s/certificates/%s", version.APIVersion, shared.CertFingerprint(&cert))
+			body = append(body, fingerprint)
+		}
 	}
 
 	return response.SyncResponse(true, body)
@@ -83,11 +91,18 @@ func certificatesGet(d *Daemon, r *http.Request) response.Response {
 
 func readSavedClientCAList(d *Daemon) {
 	d.clientCerts = map[string]x509.Certificate{}
+	d.metricsCerts = map[string]x509.Certificate{}
 
 	var dbCerts []db.Certificate
+	var roles map[string]map[string]string
 	var err error
 	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
 		dbCerts, err = tx.GetCertificates(db.CertificateFilter{})
+		if err != nil {
+			return err
+		}
+
+		roles, err = tx.GetCertificatesRoles()
 		return err
 	})
 	if err != nil {
@@ -95,6 +110,10 @@ func readSavedClientCAList(d *Daemon) {
 		return
 	}
 
+	d.clientRolesMu.Lock()
+	d.clientRoles = roles
+	d.clientRolesMu.Unlock()
+
 	for _, dbCert := range dbCerts {
 		certBlock, _ := pem.Decode([]byte(dbCert.Certificate))
 		if certBlock == nil {
@@ -108,8 +127,26 @@ func readSavedClientCAList(d *Daemon) {
 			continue
 		}
 
-		d.clientCerts[shared.CertFingerprint(cert)] = *cert
+		d.certificatesCache(dbCert.Type)[shared.CertFingerprint(cert)] = *cert
+	}
+}
+
+// certificatesCache returns the in-memory trust store for the given certificate type. Metrics certificates are
+// kept apart from the client ones, as they only grant access to the metrics endpoint.
+func (d *Daemon) certificatesCache(certType int) map[string]x509.Certificate {
+	if certType == db.CertificateTypeMetrics {
+		if d.metricsCerts == nil {
+			d.metricsCerts = map[string]x509.Certificate{}
+		}
+
+		return d.metricsCerts
+	}
+
+	if d.clientCerts == nil {
+		d.clientCerts = map[string]x509.Certificate{}
 	}
+
+	return d.clientCerts
 }
 
 func certificatesPost(d *Daemon, r *http.Request) response.Response {
@@ -125,22 +162,54 @@ func certificatesPost(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
-	trusted, _, protocol, err := d.Authenticate(r)
+	trusted, _, _, err := d.Authenticate(r)
 	if err != nil {
 		return response.SmartError(err)
 	}
 
-	if (!trusted || (protocol == "candid" && !d.userIsAdmin(r))) && util.PasswordCheck(secret, req.Password) != nil {
-		if req.Password != "" {
-			logger.Warn("Bad trust password", log.Ctx{"url": r.URL.RequestURI(), "ip": r.RemoteAddr})
+	// Only administrators can issue certificate add tokens.
+	if req.Token {
+		if !trusted || !d.userIsAdmin(r) {
+			return response.Forbidden(nil)
 		}
-		return response.Forbidden(nil)
+
+		return certificateTokenCreate(d, req)
 	}
 
-	if req.Type != "client" {
+	// Restricted users can't add certificates without the trust password or a token.
+	if (!trusted || !d.userIsAdmin(r)) && util.PasswordCheck(secret, req.Password) != nil {
+		meta := tokenConsume(d, db.OperationCertificateAddToken, req.Password)
+		if meta != nil {
+			// The token determines the name, type and roles of the new certificate.
+			req.Name, _ = meta["clientName"].(string)
+			req.Type, _ = meta["type"].(string)
+			req.Roles = tokenRoles(meta)
+		} else {
+			meta = tokenConsume(d, db.OperationClusterJoinToken, req.Password)
+			if meta == nil {
+				if req.Password != "" {
+					logger.Warn("Bad trust password", log.Ctx{"url": r.URL.RequestURI(), "ip": r.RemoteAddr})
+				}
+				return response.Forbidden(nil)
+			}
+
+			// Joining members are trusted as regular clients until they're part of the cluster.
+			req.Name, _ = meta["serverName"].(string)
+			req.Type = "client"
+			req.Roles = nil
+		}
+	}
+
+	certType, ok := db.CertificateTypes[req.Type]
+	if !ok {
 		return response.BadRequest(fmt.Errorf("Unknown request type %s", req.Type))
 	}
 
+	err = certificateRolesValidate(certType, req.Roles)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	// Extract the certificate
 	var cert *x509.Certificate
 	var name string
@@ -166,25 +235,26 @@ func certificatesPost(d *Daemon, r *http.Request) response.Response {
 			return response.InternalError(err)
 		}
 
-		name = remoteHost
+		if req.Name != "" {
+			name = req.Name
+		} else {
+			name = remoteHost
+		}
 	} else {
 		return response.BadRequest(fmt.Errorf("Can't use TLS data on non-TLS link"))
 	}
 
 	fingerprint := shared.CertFingerprint(cert)
-
-	if d.clientCerts == nil {
-		d.clientCerts = map[string]x509.Certificate{}
-	}
+	certs := d.certificatesCache(certType)
 
 	if !isClusterNotification(r) {
 		// Check if we already have the certificate
 		existingCert, _ := d.cluster.GetCertificate(fingerprint)
 		if existingCert != nil {
 			// Deal with the cache being potentially out of sync
-			_, ok := d.clientCerts[fingerprint]
+			_, ok := certs[fingerprint]
 			if !ok {
-				d.clientCerts[fingerprint] = *cert
+				certs[fingerprint] = *cert
 				return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/certificates/%s", version.APIVersion, fingerprint))
 			}
 
@@ -194,12 +264,19 @@ func certificatesPost(d *Daemon, r *http.Request) response.Response {
 		// Store the certificate in the cluster database
 		dbCert := db.Certificate{
 			Fingerprint: shared.CertFingerprint(cert),
-			Type:        1,
+			Type:        certType,
 			Name:        name,
 			Certificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})),
 		}
 
-		err = d.cluster.CreateCertificate(dbCert)
+		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			_, err := tx.CreateCertificate(dbCert)
+			if err != nil {
+				return err
+			}
+
+			return tx.UpdateCertificateRoles(dbCert.Fingerprint, req.Roles)
+		})
 		if err != nil {
 			return response.SmartError(err)
 		}
@@ -210,25 +287,128 @@ func certificatesPost(d *Daemon, r *http.Request) response.Response {
 		if err != nil {
 			return response.SmartError(err)
 		}
-		req := api.CertificatesPost{
+		notifyReq := api.CertificatesPost{
 			Certificate: base64.StdEncoding.EncodeToString(cert.Raw),
 		}
-		req.Name = name
-		req.Type = "client"
+		notifyReq.Name = name
+		notifyReq.Type = db.CertificateTypeName(certType)
+		notifyReq.Roles = req.Roles
 
 		err = notifier(func(client lxd.InstanceServer) error {
-			return client.CreateCertificate(req)
+			return client.CreateCertificate(notifyReq)
 		})
 		if err != nil {
 			return response.SmartError(err)
 		}
 	}
 
-	d.clientCerts[shared.CertFingerprint(cert)] = *cert
+	certs[fingerprint] = *cert
+
+	if len(req.Roles) > 0 {
+		d.clientRolesMu.Lock()
+		if d.clientRoles == nil {
+			d.clientRoles = map[string]map[string]string{}
+		}
+
+		d.clientRoles[fingerprint] = req.Roles
+		d.clientRolesMu.Unlock()
+	}
 
 	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/certificates/%s", version.APIVersion, fingerprint))
 }
 
+// certificateTokenCreate issues a single-use token allowing a client to add its certificate to the trust store.
+// The name, type and roles of the future certificate are taken from the request.
+func certificateTokenCreate(d *Daemon, req api.CertificatesPost) response.Response {
+	if req.Name == "" {
+		return response.BadRequest(fmt.Errorf("A client name must be provided"))
+	}
+
+	if req.Type == "" {
+		req.Type = "client"
+	}
+
+	certType, ok := db.CertificateTypes[req.Type]
+	if !ok {
+		return response.BadRequest(fmt.Errorf("Unknown request type %s", req.Type))
+	}
+
+	err := certificateRolesValidate(certType, req.Roles)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	var config *cluster.Config
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		config, err = cluster.ConfigLoad(tx)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	address, err := node.HTTPSAddress(d.db)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	addresses, err := util.ListenAddresses(address)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if len(addresses) == 0 {
+		return response.BadRequest(fmt.Errorf("The server isn't listening on the network"))
+	}
+
+	secret, err := shared.RandomCryptoString()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	token := api.CertificateAddToken{
+		ClientName:  req.Name,
+		Fingerprint: d.endpoints.NetworkCert().Fingerprint(),
+		Addresses:   addresses,
+		Secret:      secret,
+	}
+
+	meta := map[string]interface{}{
+		"secret":      secret,
+		"fingerprint": token.Fingerprint,
+		"addresses":   token.Addresses,
+		"clientName":  req.Name,
+		"type":        req.Type,
+		"roles":       req.Roles,
+		"token":       token.String(),
+	}
+
+	op, err := tokenCreate(d, db.OperationCertificateAddToken, config.RemoteTokenExpiry(), meta)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// certificateRolesValidate checks the built-in roles given to a certificate. Only client certificates can be
+// restricted with roles.
+func certificateRolesValidate(certType int, roles map[string]string) error {
+	if len(roles) > 0 && certType != db.CertificateTypeClient {
+		return fmt.Errorf("Roles can only be given to client certificates")
+	}
+
+	for project, role := range roles {
+		err := rbac.ValidateRole(role)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid role for project %q", project)
+		}
+	}
+
+	return nil
+}
+
 func certificateGet(d *Daemon, r *http.Request) response.Response {
 	fingerprint := mux.Vars(r)["fingerprint"]
 
@@ -240,10 +420,21 @@ func certificateGet(d *Daemon, r *http.Request) response.Response {
 	return response.SyncResponseETag(true, cert, cert)
 }
 
-func doCertificateGet(db *db.Cluster, fingerprint string) (api.Certificate, error) {
+func doCertificateGet(c *db.Cluster, fingerprint string) (api.Certificate, error) {
 	resp := api.Certificate{}
 
-	dbCertInfo, err := db.GetCertificate(fingerprint)
+	var dbCertInfo *db.Certificate
+	var roles map[string]map[string]string
+	err := c.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		dbCertInfo, err = tx.GetCertificate(fingerprint + "%")
+		if err != nil {
+			return err
+		}
+
+		roles, err = tx.GetCertificatesRoles()
+		return err
+	})
 	if err != nil {
 		return resp, err
 	}
@@ -251,11 +442,8 @@ func doCertificateGet(db *db.Cluster, fingerprint string) (api.Certificate, erro
 	resp.Fingerprint = dbCertInfo.Fingerprint
 	resp.Certificate = dbCertInfo.Certificate
 	resp.Name = dbCertInfo.Name
-	if dbCertInfo.Type == 1 {
-		resp.Type = "client"
-	} else {
-		resp.Type = "unknown"
-	}
+	resp.Type = db.CertificateTypeName(dbCertInfo.Type)
+	resp.Roles = roles[dbCertInfo.Fingerprint]
 
 	return resp, nil
 }
@@ -279,7 +467,7 @@ func certificatePut(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
-	return doCertificateUpdate(d, fingerprint, req)
+	return doCertificateUpdate(d, r, fingerprint, req)
 }
 
 func certificatePatch(d *Daemon, r *http.Request) response.Response {
@@ -314,19 +502,64 @@ func certificatePatch(d *Daemon, r *http.Request) response.Response {
 		req.Type = value
 	}
 
-	return doCertificateUpdate(d, fingerprint, req.Writable())
+	// Get roles
+	roles, err := reqRaw.GetMap("roles")
+	if err == nil {
+		req.Roles = map[string]string{}
+		for project, role := range roles {
+			value, ok := role.(string)
+			if !ok {
+				return response.BadRequest(fmt.Errorf("Invalid role for project %q", project))
+			}
+
+			req.Roles[project] = value
+		}
+	}
+
+	return doCertificateUpdate(d, r, fingerprint, req.Writable())
 }
 
-func doCertificateUpdate(d *Daemon, fingerprint string, req api.CertificatePut) response.Response {
-	if req.Type != "client" {
+func doCertificateUpdate(d *Daemon, r *http.Request, fingerprint string, req api.CertificatePut) response.Response {
+	certType, ok := db.CertificateTypes[req.Type]
+	if !ok {
 		return response.BadRequest(fmt.Errorf("Unknown request type %s", req.Type))
 	}
 
-	err := d.cluster.RenameCertificate(fingerprint, req.Name)
+	err := certificateRolesValidate(certType, req.Roles)
 	if err != nil {
-		return response.SmartError(err)
+		return response.BadRequest(err)
 	}
 
+	if !isClusterNotification(r) {
+		err = d.cluster.UpdateCertificate(fingerprint, req.Name, certType)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			return tx.UpdateCertificateRoles(fingerprint, req.Roles)
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		// Notify other nodes so that they refresh their trust store.
+		notifier, err := cluster.NewNotifier(d.State(), d.endpoints.NetworkCert(), cluster.NotifyAlive)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		err = notifier(func(client lxd.InstanceServer) error {
+			return client.UpdateCertificate(fingerprint, req, "")
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	// The certificate may have moved to another trust store or changed roles.
+	readSavedClientCAList(d)
+
 	return response.EmptySyncResponse
 }
 
@@ -5,8 +5,10 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"net/url"
 	"os/exec"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kballard/go-shellquote"
@@ -15,6 +17,10 @@ import (
 
 	"github.com/lxc/lxd/lxd/config"
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/events"
+	"github.com/lxc/lxd/lxd/scheduler"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/validate"
 )
 
 // Config holds cluster-wide configuration values.
@@ -40,6 +46,11 @@ func ConfigLoad(tx *db.ClusterTx) (*Config, error) {
 	return &Config{tx: tx, m: m}, nil
 }
 
+// BGPASN returns the BGP autonomous system number of the cluster.
+func (c *Config) BGPASN() int64 {
+	return c.m.GetInt64("core.bgp_asn")
+}
+
 // HTTPSAllowedHeaders returns the relevant CORS setting.
 func (c *Config) HTTPSAllowedHeaders() string {
 	return c.m.GetString("core.https_allowed_headers")
@@ -60,6 +71,20 @@ func (c *Config) HTTPSAllowedCredentials() bool {
 	return c.m.GetBool("core.https_allowed_credentials")
 }
 
+// HTTPSCompression returns whether JSON responses should be compressed when the client supports it.
+func (c *Config) HTTPSCompression() bool {
+	return c.m.GetBool("core.https_compression")
+}
+
+// BackupsS3 returns the URL, bucket name, access key and secret key of the S3 storage
+// scheduled backups are uploaded to.
+func (c *Config) BackupsS3() (string, string, string, string) {
+	return c.m.GetString("backups.s3.url"),
+		c.m.GetString("backups.s3.bucket_name"),
+		c.m.GetString("backups.s3.access_key"),
+		c.m.GetString("backups.s3.secret_key")
+}
+
 // TrustPassword returns the LXD trust password for authenticating clients.
 func (c *Config) TrustPassword() string {
 	return c.m.GetString("core.trust_password")
@@ -90,6 +115,18 @@ func (c *Config) RBACServer() (string, string, int64, string, string, string, st
 		c.m.GetString("rbac.agent.public_key")
 }
 
+// OIDCServer returns all the OpenID Connect settings needed to verify client tokens.
+func (c *Config) OIDCServer() (string, string, string) {
+	return c.m.GetString("oidc.issuer"),
+		c.m.GetString("oidc.client.id"),
+		c.m.GetString("oidc.audience")
+}
+
+// AutoUpdateCached returns whether the images cached from remote servers get updated automatically.
+func (c *Config) AutoUpdateCached() bool {
+	return c.m.GetBool("images.auto_update_cached")
+}
+
 // AutoUpdateInterval returns the configured images auto update interval.
 func (c *Config) AutoUpdateInterval() time.Duration {
 	n := c.m.GetInt64("images.auto_update_interval")
@@ -102,6 +139,26 @@ func (c *Config) RemoteCacheExpiry() int64 {
 	return c.m.GetInt64("images.remote_cache_expiry")
 }
 
+// Offline returns whether outbound connections are disabled.
+func (c *Config) Offline() bool {
+	return c.m.GetBool("core.offline")
+}
+
+// ImageMirrors returns the configured image server mirrors, indexed by the URL prefix they replace.
+func (c *Config) ImageMirrors() map[string]string {
+	mirrors := map[string]string{}
+	for _, entry := range strings.Split(c.m.GetString("images.mirrors"), ",") {
+		fields := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(fields) != 2 {
+			continue
+		}
+
+		mirrors[fields[0]] = fields[1]
+	}
+
+	return mirrors
+}
+
 // ProxyHTTPS returns the configured HTTPS proxy, if any.
 func (c *Config) ProxyHTTPS() string {
 	return c.m.GetString("core.proxy_https")
@@ -124,6 +181,11 @@ func (c *Config) MAASController() (string, string) {
 	return url, key
 }
 
+// NetworkOVNNorthboundConnection returns the OVN northbound database connection string.
+func (c *Config) NetworkOVNNorthboundConnection() string {
+	return c.m.GetString("network.ovn.northbound_connection")
+}
+
 // OfflineThreshold returns the configured heartbeat threshold, i.e. the
 // number of seconds before after which an unresponsive node is considered
 // offline..
@@ -149,6 +211,48 @@ func (c *Config) MaxStandBy() int64 {
 	return c.m.GetInt64("cluster.max_standby")
 }
 
+// EventHubs returns the number of cluster members that will be assigned the
+// event-hub role.
+func (c *Config) EventHubs() int64 {
+	return c.m.GetInt64("cluster.event_hubs")
+}
+
+// EventsWebhook returns the URL, signing secret, event types, retry count and initial retry interval of the
+// webhook the events get sent to.
+func (c *Config) EventsWebhook() (string, string, []string, int, time.Duration) {
+	types := []string{}
+	for _, eventType := range strings.Split(c.m.GetString("events.webhook.types"), ",") {
+		eventType = strings.TrimSpace(eventType)
+		if eventType != "" {
+			types = append(types, eventType)
+		}
+	}
+
+	return c.m.GetString("events.webhook.url"),
+		c.m.GetString("events.webhook.secret"),
+		types,
+		int(c.m.GetInt64("events.webhook.retries")),
+		time.Duration(c.m.GetInt64("events.webhook.retry_interval")) * time.Second
+}
+
+// JoinTokenExpiry returns the expiry expression of cluster join tokens (e.g. "3H").
+func (c *Config) JoinTokenExpiry() string {
+	return c.m.GetString("cluster.join_token_expiry")
+}
+
+// RemoteTokenExpiry returns the expiry expression of certificate add tokens (e.g. "1d").
+func (c *Config) RemoteTokenExpiry() string {
+	return c.m.GetString("core.remote_token_expiry")
+}
+
+// InstancePlacement returns the policy and, for the scriptlet policy, the
+// scriptlet used to place new instances on cluster members.
+func (c *Config) InstancePlacement() (string, string) {
+	policy := c.m.GetString("scheduler.instance.placement")
+	scriptlet := c.m.GetString("scheduler.instance.placement.scriptlet")
+	return policy, scriptlet
+}
+
 // Dump current configuration keys and their values. Keys with values matching
 // their defaults are omitted.
 func (c *Config) Dump() map[string]interface{} {
@@ -174,6 +278,14 @@ func (c *Config) Patch(patch map[string]interface{}) (map[string]string, error)
 }
 
 func (c *Config) update(values map[string]interface{}) (map[string]string, error) {
+	// Users authenticated through OpenID Connect get their permissions from RBAC, without it they'd be
+	// denied everything.
+	oidcIssuer, _ := values["oidc.issuer"].(string)
+	rbacURL, _ := values["rbac.api.url"].(string)
+	if oidcIssuer != "" && rbacURL == "" {
+		return nil, fmt.Errorf("OpenID Connect authentication (oidc.issuer) requires RBAC (rbac.api.url) to give users their permissions")
+	}
+
 	changed, err := c.m.Change(values)
 	if err != nil {
 		return nil, err
@@ -238,38 +350,63 @@ func configGet(cluster *db.Cluster) (*Config, error) {
 
 // ConfigSchema defines available server configuration keys.
 var ConfigSchema = config.Schema{
-	"backups.compression_algorithm":  {Default: "gzip", Validator: validateCompression},
-	"cluster.offline_threshold":      {Type: config.Int64, Default: offlineThresholdDefault(), Validator: offlineThresholdValidator},
-	"cluster.images_minimal_replica": {Type: config.Int64, Default: "3", Validator: imageMinimalReplicaValidator},
-	"cluster.max_voters":             {Type: config.Int64, Default: "3", Validator: maxVotersValidator},
-	"cluster.max_standby":            {Type: config.Int64, Default: "2", Validator: maxStandByValidator},
-	"core.https_allowed_headers":     {},
-	"core.https_allowed_methods":     {},
-	"core.https_allowed_origin":      {},
-	"core.https_allowed_credentials": {Type: config.Bool},
-	"core.proxy_http":                {},
-	"core.proxy_https":               {},
-	"core.proxy_ignore_hosts":        {},
-	"core.trust_password":            {Hidden: true, Setter: passwordSetter},
-	"core.trust_ca_certificates":     {Type: config.Bool},
-	"candid.api.key":                 {},
-	"candid.api.url":                 {},
-	"candid.domains":                 {},
-	"candid.expiry":                  {Type: config.Int64, Default: "3600"},
-	"images.auto_update_cached":      {Type: config.Bool, Default: "true"},
-	"images.auto_update_interval":    {Type: config.Int64, Default: "6"},
-	"images.compression_algorithm":   {Default: "gzip", Validator: validateCompression},
-	"images.remote_cache_expiry":     {Type: config.Int64, Default: "10"},
-	"maas.api.key":                   {},
-	"maas.api.url":                   {},
-	"rbac.agent.url":                 {},
-	"rbac.agent.username":            {},
-	"rbac.agent.private_key":         {},
-	"rbac.agent.public_key":          {},
-	"rbac.api.expiry":                {Type: config.Int64, Default: "3600"},
-	"rbac.api.key":                   {},
-	"rbac.api.url":                   {},
-	"rbac.expiry":                    {Type: config.Int64, Default: "3600"},
+	"backups.compression_algorithm":          {Default: "gzip", Validator: validateCompression},
+	"backups.s3.access_key":                  {},
+	"backups.s3.bucket_name":                 {},
+	"backups.s3.secret_key":                  {Hidden: true},
+	"backups.s3.url":                         {},
+	"cluster.offline_threshold":              {Type: config.Int64, Default: offlineThresholdDefault(), Validator: offlineThresholdValidator},
+	"cluster.images_minimal_replica":         {Type: config.Int64, Default: "3", Validator: imageMinimalReplicaValidator},
+	"cluster.max_voters":                     {Type: config.Int64, Default: "3", Validator: maxVotersValidator},
+	"cluster.max_standby":                    {Type: config.Int64, Default: "2", Validator: maxStandByValidator},
+	"cluster.join_token_expiry":              {Default: "3H", Validator: validateExpiry},
+	"cluster.event_hubs":                     {Type: config.Int64, Default: "0", Validator: eventHubsValidator},
+	"core.bgp_asn":                           {Type: config.Int64, Default: "0", Validator: validate.Optional(validate.IsUint32)},
+	"core.https_allowed_headers":             {},
+	"core.https_allowed_methods":             {},
+	"core.https_allowed_origin":              {},
+	"core.https_allowed_credentials":         {Type: config.Bool},
+	"core.https_compression":                 {Type: config.Bool},
+	"core.offline":                           {Type: config.Bool},
+	"core.proxy_http":                        {},
+	"core.proxy_https":                       {},
+	"core.proxy_ignore_hosts":                {},
+	"core.remote_token_expiry":               {Default: "1d", Validator: validateExpiry},
+	"core.shutdown_timeout":                  {Type: config.Int64, Default: "5", Validator: positiveIntValidator},
+	"core.trust_password":                    {Hidden: true, Setter: passwordSetter},
+	"core.trust_ca_certificates":             {Type: config.Bool},
+	"candid.api.key":                         {},
+	"candid.api.url":                         {},
+	"candid.domains":                         {},
+	"candid.expiry":                          {Type: config.Int64, Default: "3600"},
+	"events.webhook.url":                     {Validator: validateWebhookURL},
+	"events.webhook.secret":                  {Hidden: true},
+	"events.webhook.types":                   {Default: "lifecycle,operation", Validator: validateWebhookTypes},
+	"events.webhook.retries":                 {Type: config.Int64, Default: "3", Validator: positiveIntValidator},
+	"events.webhook.retry_interval":          {Type: config.Int64, Default: "5", Validator: positiveIntValidator},
+	"images.auto_update_cached":              {Type: config.Bool, Default: "true"},
+	"images.auto_update_interval":            {Type: config.Int64, Default: "6"},
+	"images.compression_algorithm":           {Default: "gzip", Validator: validateCompression},
+	"images.mirrors":                         {Validator: validateImageMirrors},
+	"images.remote_cache_expiry":             {Type: config.Int64, Default: "10"},
+	"images.simplestreams":                   {Type: config.Bool, Default: "false"},
+	"maas.api.key":                           {},
+	"maas.api.url":                           {},
+	"network.ovn.northbound_connection":      {Default: "unix:/var/run/ovn/ovnnb_db.sock"},
+	"network.stats.interval":                 {Type: config.Int64, Default: "0", Validator: networkStatsIntervalValidator},
+	"oidc.audience":                          {},
+	"oidc.client.id":                         {},
+	"oidc.issuer":                            {},
+	"rbac.agent.url":                         {},
+	"rbac.agent.username":                    {},
+	"rbac.agent.private_key":                 {},
+	"rbac.agent.public_key":                  {},
+	"rbac.api.expiry":                        {Type: config.Int64, Default: "3600"},
+	"rbac.api.key":                           {},
+	"rbac.api.url":                           {},
+	"rbac.expiry":                            {Type: config.Int64, Default: "3600"},
+	"scheduler.instance.placement":           {Default: scheduler.PolicyBalanced, Validator: scheduler.ValidatePolicy},
+	"scheduler.instance.placement.scriptlet": {Hidden: true},
 
 	// Keys deprecated since the implementation of the storage api.
 	"storage.lvm_fstype":           {Setter: deprecatedStorage, Default: "ext4"},
@@ -340,6 +477,95 @@ func maxStandByValidator(value string) error {
 	return nil
 }
 
+func eventHubsValidator(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("Value is not a number")
+	}
+
+	if n < 0 {
+		return fmt.Errorf("Value must be zero or greater")
+	}
+
+	return nil
+}
+
+func networkStatsIntervalValidator(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("Value is not a number")
+	}
+
+	if n != 0 && n < 10 {
+		return fmt.Errorf("Value must be zero (disabled) or at least 10 seconds")
+	}
+
+	return nil
+}
+
+func positiveIntValidator(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("Value is not a number")
+	}
+
+	if n < 0 {
+		return fmt.Errorf("Value must be zero or greater")
+	}
+
+	return nil
+}
+
+func validateWebhookURL(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("Invalid URL %q, expected an HTTP or HTTPS URL", value)
+	}
+
+	return nil
+}
+
+func validateWebhookTypes(value string) error {
+	for _, eventType := range strings.Split(value, ",") {
+		eventType = strings.TrimSpace(eventType)
+		if eventType == "" {
+			continue
+		}
+
+		if !shared.StringInSlice(eventType, events.WebhookEventTypes) {
+			return fmt.Errorf("Invalid event type %q (must be one of %s)", eventType, strings.Join(events.WebhookEventTypes, ", "))
+		}
+	}
+
+	return nil
+}
+
+func validateImageMirrors(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		fields := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(fields) != 2 || fields[0] == "" || fields[1] == "" {
+			return fmt.Errorf("Invalid mirror %q, expected <server URL>=<mirror URL>", entry)
+		}
+
+		for _, field := range fields {
+			u, err := url.Parse(field)
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				return fmt.Errorf("Invalid URL %q", field)
+			}
+		}
+	}
+
+	return nil
+}
+
 func passwordSetter(value string) (string, error) {
 	// Nothing to do on unset
 	if value == "" {
@@ -384,6 +610,11 @@ func validateCompression(value string) error {
 	return err
 }
 
+func validateExpiry(value string) error {
+	_, err := shared.GetSnapshotExpiry(time.Time{}, value)
+	return err
+}
+
 func deprecatedStorage(value string) (string, error) {
 	if value == "" {
 		return "", nil
@@ -121,3 +121,28 @@ func TestConfig_PatchKeepsValues(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, map[string]string{"core.proxy_http": "foo.bar"}, values)
 }
+
+// OpenID Connect authentication can't be enabled without RBAC, nor can RBAC be disabled while it's enabled.
+func TestConfig_OIDCRequiresRBAC(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	config, err := cluster.ConfigLoad(tx)
+	require.NoError(t, err)
+
+	_, err = config.Patch(map[string]interface{}{"oidc.issuer": "https://sso.example.com"})
+	require.EqualError(t, err, "OpenID Connect authentication (oidc.issuer) requires RBAC (rbac.api.url) to give users their permissions")
+
+	_, err = config.Patch(map[string]interface{}{
+		"oidc.issuer":  "https://sso.example.com",
+		"rbac.api.url": "https://rbac.example.com",
+	})
+	require.NoError(t, err)
+
+	_, err = config.Patch(map[string]interface{}{"rbac.api.url": ""})
+	require.EqualError(t, err, "OpenID Connect authentication (oidc.issuer) requires RBAC (rbac.api.url) to give users their permissions")
+
+	values, err := tx.Config()
+	require.NoError(t, err)
+	assert.Equal(t, "https://rbac.example.com", values["rbac.api.url"])
+}
@@ -29,11 +29,7 @@ func Connect(address string, cert *shared.CertInfo, notify bool) (lxd.InstanceSe
 	if !notify {
 		connected := false
 		for i := 0; i < 20; i++ {
-			listenersLock.Lock()
-			_, ok := listeners[address]
-			listenersLock.Unlock()
-
-			if ok {
+			if eventsListening(address) {
 				connected = true
 				break
 			}
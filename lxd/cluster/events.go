@@ -2,9 +2,13 @@ package cluster
 
 import (
 	"context"
+	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
+
 	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/endpoints"
@@ -14,13 +18,22 @@ import (
 	"github.com/lxc/lxd/shared/logger"
 )
 
+// EventHubClientUserAgent is the user agent used by members getting the events of the whole cluster from an
+// event hub. Event hubs relay the events received from other members to such listeners.
+const EventHubClientUserAgent = "lxd-cluster-event-hub-client"
+
 var listeners = map[string]*lxd.EventListener{}
+var listenersHub string // Address of the event hub used by this member, if any.
 var listenersLock sync.Mutex
 
 // Events starts a task that continuously monitors the list of cluster nodes and
 // maintains a pool of websocket connections against all of them, in order to
 // get notified about events.
 //
+// When some members have the event-hub role, members which aren't event hubs
+// only connect to one of them and get the events of the whole cluster relayed
+// by it, while the event hubs keep connections against all members.
+//
 // Whenever an event is received the given callback is invoked.
 func Events(endpoints *endpoints.Endpoints, cluster *db.Cluster, f func(int64, api.Event)) (task.Func, task.Schedule) {
 	// Update our pool of event listeners. Since database queries are
@@ -73,6 +86,46 @@ func eventsUpdateListeners(endpoints *endpoints.Endpoints, cluster *db.Cluster,
 
 	address := endpoints.NetworkAddress()
 
+	// Figure out the name of this member and the online event hubs.
+	localName := ""
+	isHub := false
+	hubs := []db.NodeInfo{}
+	for _, node := range nodes {
+		if node.Address == address {
+			localName = node.Name
+		}
+
+		if node.IsOffline(offlineThreshold) || !shared.StringInSlice(string(db.ClusterRoleEventHub), node.Roles) {
+			continue
+		}
+
+		if node.Address == address {
+			isHub = true
+			continue
+		}
+
+		hubs = append(hubs, node)
+	}
+
+	// Members which aren't event hubs get the events of the whole cluster through a single hub.
+	if !isHub && len(hubs) > 0 {
+		eventsUpdateHubListener(endpoints, hubs, localName, f)
+		return
+	}
+
+	// Stop relying on an event hub, the events are now fetched from all members.
+	listenersLock.Lock()
+	if listenersHub != "" {
+		listener, ok := listeners[listenersHub]
+		if ok {
+			listener.Disconnect()
+			delete(listeners, listenersHub)
+		}
+
+		listenersHub = ""
+	}
+	listenersLock.Unlock()
+
 	addresses := make([]string, len(nodes))
 	for i, node := range nodes {
 		addresses[i] = node.Address
@@ -122,6 +175,155 @@ func eventsUpdateListeners(endpoints *endpoints.Endpoints, cluster *db.Cluster,
 	listenersLock.Unlock()
 }
 
+// eventsUpdateHubListener makes sure that this member listens to exactly one of the given event hubs, connecting
+// to another one when the current connection is lost.
+func eventsUpdateHubListener(endpoints *endpoints.Endpoints, hubs []db.NodeInfo, localName string, f func(int64, api.Event)) {
+	hubAddresses := make([]string, len(hubs))
+	for i, hub := range hubs {
+		hubAddresses[i] = hub.Address
+	}
+
+	// Drop the listeners against other members, their events are relayed by the hub.
+	listenersLock.Lock()
+	for address, listener := range listeners {
+		if address == listenersHub && shared.StringInSlice(address, hubAddresses) && listener.IsActive() {
+			continue
+		}
+
+		listener.Disconnect()
+		delete(listeners, address)
+	}
+
+	_, ok := listeners[listenersHub]
+	if !ok {
+		listenersHub = ""
+	}
+
+	connected := listenersHub != ""
+	listenersLock.Unlock()
+
+	if connected {
+		return
+	}
+
+	// Connect to the first reachable hub, starting from a random one to spread the load.
+	offset := rand.Intn(len(hubs))
+	for i := range hubs {
+		hub := hubs[(offset+i)%len(hubs)]
+
+		listener, err := eventsConnectHub(hub.Address, endpoints.NetworkCert())
+		if err != nil {
+			logger.Warnf("Failed to get events from event hub %s: %v", hub.Address, err)
+			continue
+		}
+
+		logger.Debugf("Listening for events on event hub %s", hub.Address)
+		listener.AddHandler(nil, func(event api.Event) {
+			// Skip the events of this member relayed back by the hub.
+			if event.Location == localName {
+				return
+			}
+
+			f(hub.ID, event)
+		})
+
+		listenersLock.Lock()
+		listeners[hub.Address] = listener
+		listenersHub = hub.Address
+		listenersLock.Unlock()
+
+		return
+	}
+}
+
+// eventsListening returns whether this member gets the events of the member with the given address, either
+// directly or through an event hub.
+func eventsListening(address string) bool {
+	listenersLock.Lock()
+	defer listenersLock.Unlock()
+
+	if listenersHub != "" {
+		return true
+	}
+
+	_, ok := listeners[address]
+	return ok
+}
+
+// EventHubsRebalance makes sure that the number of online members with the event-hub role matches the
+// cluster.event_hubs configuration, moving the role away from offline members. Nothing is done when no event hub
+// count is configured, in which case the role can be assigned manually.
+func EventHubsRebalance(cluster *db.Cluster) error {
+	return cluster.Transaction(func(tx *db.ClusterTx) error {
+		config, err := ConfigLoad(tx)
+		if err != nil {
+			return err
+		}
+
+		count := int(config.EventHubs())
+		if count <= 0 {
+			return nil
+		}
+
+		nodes, err := tx.GetNodes()
+		if err != nil {
+			return err
+		}
+
+		if len(nodes) == 1 {
+			return nil
+		}
+
+		offlineThreshold := config.OfflineThreshold()
+
+		hubs := []db.NodeInfo{}
+		candidates := []db.NodeInfo{}
+		for _, node := range nodes {
+			isHub := shared.StringInSlice(string(db.ClusterRoleEventHub), node.Roles)
+
+			if node.IsOffline(offlineThreshold) {
+				if isHub {
+					err := tx.RemoveNodeRole(node.ID, db.ClusterRoleEventHub)
+					if err != nil {
+						return errors.Wrapf(err, "Remove event-hub role from member %q", node.Name)
+					}
+				}
+
+				continue
+			}
+
+			if isHub {
+				hubs = append(hubs, node)
+			} else {
+				candidates = append(candidates, node)
+			}
+		}
+
+		for len(hubs) > count {
+			hub := hubs[len(hubs)-1]
+			err := tx.RemoveNodeRole(hub.ID, db.ClusterRoleEventHub)
+			if err != nil {
+				return errors.Wrapf(err, "Remove event-hub role from member %q", hub.Name)
+			}
+
+			hubs = hubs[:len(hubs)-1]
+		}
+
+		for len(hubs) < count && len(candidates) > 0 {
+			candidate := candidates[0]
+			err := tx.CreateNodeRole(candidate.ID, db.ClusterRoleEventHub)
+			if err != nil {
+				return errors.Wrapf(err, "Add event-hub role to member %q", candidate.Name)
+			}
+
+			hubs = append(hubs, candidate)
+			candidates = candidates[1:]
+		}
+
+		return nil
+	})
+}
+
 // Establish a client connection to get events from the given node.
 func eventsConnect(address string, cert *shared.CertInfo) (*lxd.EventListener, error) {
 	client, err := Connect(address, cert, true)
@@ -135,3 +337,21 @@ func eventsConnect(address string, cert *shared.CertInfo) (*lxd.EventListener, e
 
 	return client.GetEvents()
 }
+
+// Establish a client connection to get the events of the whole cluster from the given event hub.
+func eventsConnectHub(address string, cert *shared.CertInfo) (*lxd.EventListener, error) {
+	args := &lxd.ConnectionArgs{
+		TLSServerCert: string(cert.PublicKey()),
+		TLSClientCert: string(cert.PublicKey()),
+		TLSClientKey:  string(cert.PrivateKey()),
+		SkipGetServer: true,
+		UserAgent:     EventHubClientUserAgent,
+	}
+
+	client, err := lxd.ConnectLXD(fmt.Sprintf("https://%s", address), args)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.UseProject("*").GetEvents()
+}
@@ -120,6 +120,12 @@ type Gateway struct {
 
 	// Keep track of skews
 	timeSkew bool
+
+	// Heartbeat states acknowledged by the members when leader, and last heartbeat state
+	// received, used for differential heartbeats.
+	heartbeatAcks  heartbeatAcks
+	heartbeatState *APIHeartbeat
+	heartbeatLock  sync.Mutex
 }
 
 // Current dqlite protocol version.
@@ -178,8 +184,8 @@ func (g *Gateway) HandlerFuncs(nodeRefreshTask func(*APIHeartbeat)) map[string]h
 
 		// Handle heatbeats (these normally come from leader, but can come from joining nodes too).
 		if r.Method == "PUT" {
-			var heartbeatData APIHeartbeat
-			err := json.NewDecoder(r.Body).Decode(&heartbeatData)
+			heartbeatData := &APIHeartbeat{}
+			err := json.NewDecoder(r.Body).Decode(heartbeatData)
 			if err != nil {
 				logger.Errorf("Error decoding heartbeat body: %v", err)
 				http.Error(w, "400 invalid heartbeat payload", http.StatusBadRequest)
@@ -204,6 +210,39 @@ func (g *Gateway) HandlerFuncs(nodeRefreshTask func(*APIHeartbeat)) map[string]h
 				}
 			}
 
+			// Rebuild the full state from differential heartbeats, asking for the full state
+			// if we don't hold the one the changes apply to.
+			unchanged := false
+			g.heartbeatLock.Lock()
+			if heartbeatData.Differential {
+				if g.heartbeatState == nil {
+					g.heartbeatLock.Unlock()
+					http.Error(w, "409 stale heartbeat state", http.StatusConflict)
+					return
+				}
+
+				unchanged = len(heartbeatData.Members) == 0 && len(heartbeatData.Removed) == 0 && g.heartbeatState.FullStateList
+
+				heartbeatData, err = g.heartbeatState.apply(heartbeatData)
+				if err != nil {
+					g.heartbeatLock.Unlock()
+					logger.Debugf("Differential heartbeat doesn't apply to the current state: %v", err)
+					http.Error(w, "409 stale heartbeat state", http.StatusConflict)
+					return
+				}
+			} else {
+				heartbeatData.Checksum = heartbeatData.checksum()
+			}
+
+			// Keep a copy, as the node refresh task modifies the members.
+			g.heartbeatState = heartbeatData.delta(nil)
+			g.heartbeatLock.Unlock()
+
+			if unchanged {
+				logger.Debugf("Unchanged heartbeat state received, skipping update")
+				return
+			}
+
 			raftNodes := make([]db.RaftNode, 0)
 			for _, node := range heartbeatData.Members {
 				if node.RaftID > 0 {
@@ -239,7 +278,7 @@ func (g *Gateway) HandlerFuncs(nodeRefreshTask func(*APIHeartbeat)) map[string]h
 
 			// If node refresh task is specified, run it async.
 			if nodeRefreshTask != nil {
-				go nodeRefreshTask(&heartbeatData)
+				go nodeRefreshTask(heartbeatData)
 			}
 
 			return
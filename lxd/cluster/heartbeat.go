@@ -3,17 +3,21 @@ package cluster
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"math/rand"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/metrics"
 	"github.com/lxc/lxd/lxd/task"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/version"
 	"github.com/pkg/errors"
 )
 
@@ -46,6 +50,193 @@ type APIHeartbeat struct {
 	// This can be used to indicate to the receiving node that the state is fresh enough to
 	// trigger node refresh activies (such as forkdns).
 	FullStateList bool
+
+	// Indicates that Members only holds the members which changed since the state with the
+	// BaseChecksum checksum, Removed holding the IDs of the members which are gone.
+	Differential bool
+	BaseChecksum string
+	Removed      []int64
+
+	// Checksum of the member states (ignoring heartbeat times) and versions.
+	Checksum string
+
+	// Heartbeat states acknowledged by the members, used by the leader to send differential
+	// heartbeats. Not sent to nodes.
+	acks *heartbeatAcks
+}
+
+// errHeartbeatStale is returned when a member doesn't hold the state a differential heartbeat applies to.
+var errHeartbeatStale = fmt.Errorf("Stale heartbeat state")
+
+// heartbeatMemberChanged returns whether the state of a member changed, ignoring its heartbeat time.
+func heartbeatMemberChanged(a APIHeartbeatMember, b APIHeartbeatMember) bool {
+	return a.Address != b.Address || a.RaftID != b.RaftID || a.RaftRole != b.RaftRole || a.Online != b.Online
+}
+
+// heartbeatDeltasSupported returns whether a member with the given number of API extensions
+// handles differential heartbeats.
+func heartbeatDeltasSupported(apiExtensions int) bool {
+	for i, extension := range version.APIExtensions {
+		if extension == "clustering_heartbeat_deltas" {
+			return apiExtensions > i
+		}
+	}
+
+	return false
+}
+
+// checksum returns a checksum of the member states, ignoring their heartbeat times, and of the versions.
+func (hbState *APIHeartbeat) checksum() string {
+	ids := make([]int64, 0, len(hbState.Members))
+	for id := range hbState.Members {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	hash := sha256.New()
+	for _, id := range ids {
+		member := hbState.Members[id]
+		fmt.Fprintf(hash, "%d %s %d %d %t\n", member.ID, member.Address, member.RaftID, member.RaftRole, member.Online)
+	}
+
+	fmt.Fprintf(hash, "%d %d\n", hbState.Version.Schema, hbState.Version.APIExtensions)
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// delta returns a new heartbeat holding the changes since the given base state, or the full state if the base
+// is nil.
+func (hbState *APIHeartbeat) delta(base *APIHeartbeat) *APIHeartbeat {
+	delta := &APIHeartbeat{
+		Members:       make(map[int64]APIHeartbeatMember),
+		Version:       hbState.Version,
+		Time:          hbState.Time,
+		FullStateList: hbState.FullStateList,
+		Checksum:      hbState.Checksum,
+	}
+
+	if base == nil {
+		for id, member := range hbState.Members {
+			delta.Members[id] = member
+		}
+
+		return delta
+	}
+
+	delta.Differential = true
+	delta.BaseChecksum = base.Checksum
+
+	for id, member := range hbState.Members {
+		baseMember, ok := base.Members[id]
+		if !ok || heartbeatMemberChanged(baseMember, member) {
+			delta.Members[id] = member
+		}
+	}
+
+	for id := range base.Members {
+		_, ok := hbState.Members[id]
+		if !ok {
+			delta.Removed = append(delta.Removed, id)
+		}
+	}
+
+	return delta
+}
+
+// apply returns the full state resulting from applying the given differential heartbeat to this state.
+func (hbState *APIHeartbeat) apply(delta *APIHeartbeat) (*APIHeartbeat, error) {
+	if delta.BaseChecksum != hbState.Checksum {
+		return nil, errHeartbeatStale
+	}
+
+	result := &APIHeartbeat{
+		Members:       make(map[int64]APIHeartbeatMember),
+		Version:       delta.Version,
+		Time:          delta.Time,
+		FullStateList: delta.FullStateList,
+	}
+
+	for id, member := range hbState.Members {
+		result.Members[id] = member
+	}
+
+	for id, member := range delta.Members {
+		result.Members[id] = member
+	}
+
+	for _, id := range delta.Removed {
+		delete(result.Members, id)
+	}
+
+	result.Checksum = result.checksum()
+	if result.Checksum != delta.Checksum {
+		return nil, errHeartbeatStale
+	}
+
+	return result, nil
+}
+
+// heartbeatAcks records the heartbeat state last acknowledged by each member, so that the leader
+// only sends them what changed since.
+type heartbeatAcks struct {
+	mu    sync.Mutex
+	last  *APIHeartbeat     // State sent by the previous Send call.
+	acked map[string]string // Checksum of the state acknowledged by each member, by address.
+}
+
+// base returns the state acknowledged by the member with the given address, nil if unknown.
+func (a *heartbeatAcks) base(address string, current *APIHeartbeat) *APIHeartbeat {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	checksum, ok := a.acked[address]
+	if !ok {
+		return nil
+	}
+
+	if checksum == current.Checksum {
+		return current
+	}
+
+	if a.last != nil && checksum == a.last.Checksum {
+		return a.last
+	}
+
+	return nil
+}
+
+// ack records that the member with the given address holds the state with the given checksum.
+func (a *heartbeatAcks) ack(address string, checksum string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.acked == nil {
+		a.acked = make(map[string]string)
+	}
+
+	a.acked[address] = checksum
+}
+
+// done records the state sent to the members, forgetting about the members which are gone.
+func (a *heartbeatAcks) done(current *APIHeartbeat) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.last = current
+
+	addresses := make([]string, 0, len(current.Members))
+	for _, member := range current.Members {
+		addresses = append(addresses, member.Address)
+	}
+
+	for address := range a.acked {
+		if !shared.StringInSlice(address, addresses) {
+			delete(a.acked, address)
+		}
+	}
+
+	metrics.ForgetHeartbeats(addresses)
 }
 
 // Update updates an existing APIHeartbeat struct with the raft and all node states supplied.
@@ -109,9 +300,18 @@ func (hbState *APIHeartbeat) Update(fullStateList bool, raftNodes []db.RaftNode,
 }
 
 // Send sends heartbeat requests to the nodes supplied and updates heartbeat state.
+//
+// Members which acknowledged a recent state only get the changes since then, unless they don't
+// hold that state anymore.
 func (hbState *APIHeartbeat) Send(ctx context.Context, cert *shared.CertInfo, localAddress string, nodes []db.NodeInfo, delay bool) {
+	// All members are sent the same state, as a whole or as the changes since the one they hold.
+	hbState.Lock()
+	hbState.Checksum = hbState.checksum()
+	current := hbState.delta(nil)
+	hbState.Unlock()
+
 	heartbeatsWg := sync.WaitGroup{}
-	sendHeartbeat := func(nodeID int64, address string, delay bool, heartbeatData *APIHeartbeat) {
+	sendHeartbeat := func(nodeID int64, address string, delay bool, deltas bool) {
 		defer heartbeatsWg.Done()
 
 		if delay {
@@ -120,23 +320,46 @@ func (hbState *APIHeartbeat) Send(ctx context.Context, cert *shared.CertInfo, lo
 		}
 		logger.Debugf("Sending heartbeat to %s", address)
 
+		start := time.Now()
+
+		var base *APIHeartbeat
+		if deltas && hbState.acks != nil {
+			base = hbState.acks.base(address, current)
+		}
+
+		heartbeatData := current.delta(base)
+
 		// Update timestamp to current, used for time skew detection
 		heartbeatData.Time = time.Now().UTC()
 
 		err := HeartbeatNode(ctx, address, cert, heartbeatData)
+		if err == errHeartbeatStale && heartbeatData.Differential {
+			logger.Debugf("Stale heartbeat state for %s, sending full state", address)
+			heartbeatData = current.delta(nil)
+			heartbeatData.Time = time.Now().UTC()
+			err = HeartbeatNode(ctx, address, cert, heartbeatData)
+		}
+
 		if err == nil {
-			heartbeatData.Lock()
+			if hbState.acks != nil {
+				hbState.acks.ack(address, current.Checksum)
+			}
+
+			metrics.TrackHeartbeat(address, heartbeatData.Differential, time.Since(start))
+
+			hbState.Lock()
 			// Ensure only update nodes that exist in Members already.
 			hbNode, existing := hbState.Members[nodeID]
 			if !existing {
+				hbState.Unlock()
 				return
 			}
 
 			hbNode.LastHeartbeat = time.Now()
 			hbNode.Online = true
 			hbNode.updated = true
-			heartbeatData.Members[nodeID] = hbNode
-			heartbeatData.Unlock()
+			hbState.Members[nodeID] = hbNode
+			hbState.Unlock()
 			logger.Debugf("Successful heartbeat for %s", address)
 		} else {
 			logger.Debugf("Failed heartbeat for %s: %v", address, err)
@@ -158,9 +381,13 @@ func (hbState *APIHeartbeat) Send(ctx context.Context, cert *shared.CertInfo, lo
 
 		// Parallelize the rest.
 		heartbeatsWg.Add(1)
-		go sendHeartbeat(node.ID, node.Address, delay, hbState)
+		go sendHeartbeat(node.ID, node.Address, delay, heartbeatDeltasSupported(node.APIExtensions))
 	}
 	heartbeatsWg.Wait()
+
+	if hbState.acks != nil {
+		hbState.acks.done(current)
+	}
 }
 
 // HeartbeatTask returns a task function that performs leader-initiated heartbeat
@@ -183,7 +410,11 @@ func HeartbeatTask(gateway *Gateway) (task.Func, task.Schedule) {
 		}
 	}
 
-	schedule := task.Every(time.Duration(heartbeatInterval) * time.Second)
+	// Add some jitter to the interval, so that the rounds of successive leaders don't line up.
+	schedule := func() (time.Duration, error) {
+		jitter := time.Duration(rand.Int63n(int64(heartbeatJitter)))
+		return time.Duration(heartbeatInterval)*time.Second - heartbeatJitter/2 + jitter, nil
+	}
 
 	return heartbeatWrapper, schedule
 }
@@ -255,7 +486,7 @@ func (g *Gateway) heartbeat(ctx context.Context, initialHeartbeat bool) {
 	}
 
 	// Cumulative set of node states (will be written back to database once done).
-	hbState := &APIHeartbeat{}
+	hbState := &APIHeartbeat{acks: &g.heartbeatAcks}
 
 	// If this leader node hasn't sent a heartbeat recently, then its node state records
 	// are likely out of date, this can happen when a node becomes a leader.
@@ -334,6 +565,12 @@ func (g *Gateway) heartbeat(ctx context.Context, initialHeartbeat bool) {
 		logger.Warnf("Failed to update heartbeat: %v", err)
 	}
 
+	// Make sure the configured number of online members act as event hubs.
+	err = EventHubsRebalance(g.Cluster)
+	if err != nil {
+		logger.Warnf("Failed to rebalance event hubs: %v", err)
+	}
+
 	// If full node state was sent and node refresh task is specified, run it async.
 	if g.HeartbeatNodeHook != nil {
 		go g.HeartbeatNodeHook(hbState)
@@ -346,6 +583,9 @@ func (g *Gateway) heartbeat(ctx context.Context, initialHeartbeat bool) {
 // heartbeatInterval Number of seconds to wait between to heartbeat rounds.
 const heartbeatInterval = 10
 
+// heartbeatJitter is the range of the random variation of the interval between two heartbeat rounds.
+const heartbeatJitter = 2 * time.Second
+
 // HeartbeatNode performs a single heartbeat request against the node with the given address.
 func HeartbeatNode(taskCtx context.Context, address string, cert *shared.CertInfo, heartbeatData *APIHeartbeat) error {
 	logger.Debugf("Sending heartbeat request to %s", address)
@@ -390,6 +630,10 @@ func HeartbeatNode(taskCtx context.Context, address string, cert *shared.CertInf
 	}
 	defer response.Body.Close()
 
+	if response.StatusCode == http.StatusConflict {
+		return errHeartbeatStale
+	}
+
 	if response.StatusCode != http.StatusOK {
 		return fmt.Errorf("HTTP request failed: %s", response.Status)
 	}
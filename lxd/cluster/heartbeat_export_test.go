@@ -0,0 +1,14 @@
+package cluster
+
+// Delta returns the changes since the given base state, or the full state if the
+// base is nil.
+func (hbState *APIHeartbeat) Delta(base *APIHeartbeat) *APIHeartbeat {
+	hbState.Checksum = hbState.checksum()
+	return hbState.delta(base)
+}
+
+// Apply returns the full state resulting from applying the given differential
+// heartbeat.
+func (hbState *APIHeartbeat) Apply(delta *APIHeartbeat) (*APIHeartbeat, error) {
+	return hbState.apply(delta)
+}
@@ -18,6 +18,53 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// Differential heartbeats only hold the changed members, and rebuild the full
+// state when applied to the state they were computed from.
+func TestHeartbeat_Delta(t *testing.T) {
+	state := &cluster.APIHeartbeat{
+		Members: map[int64]cluster.APIHeartbeatMember{
+			1: {ID: 1, Address: "10.0.0.1:8443", RaftID: 1, RaftRole: int(db.RaftVoter), Online: true},
+			2: {ID: 2, Address: "10.0.0.2:8443", RaftID: 2, RaftRole: int(db.RaftVoter), Online: true},
+			3: {ID: 3, Address: "10.0.0.3:8443", RaftID: 3, RaftRole: int(db.RaftVoter), Online: true},
+		},
+	}
+
+	base := state.Delta(nil)
+	assert.False(t, base.Differential)
+	assert.Len(t, base.Members, 3)
+
+	// Heartbeat times alone aren't changes.
+	member := state.Members[1]
+	member.LastHeartbeat = time.Now()
+	state.Members[1] = member
+
+	delta := state.Delta(base)
+	assert.True(t, delta.Differential)
+	assert.Len(t, delta.Members, 0)
+	assert.Equal(t, base.Checksum, delta.Checksum)
+
+	// Changed, added and removed members are.
+	member = state.Members[2]
+	member.Online = false
+	state.Members[2] = member
+	state.Members[4] = cluster.APIHeartbeatMember{ID: 4, Address: "10.0.0.4:8443"}
+	delete(state.Members, 3)
+
+	delta = state.Delta(base)
+	assert.Len(t, delta.Members, 2)
+	assert.Equal(t, []int64{3}, delta.Removed)
+
+	result, err := base.Apply(delta)
+	require.NoError(t, err)
+	assert.Equal(t, delta.Checksum, result.Checksum)
+	assert.Len(t, result.Members, 3)
+	assert.False(t, result.Members[2].Online)
+
+	// Changes computed from another state don't apply.
+	_, err = result.Apply(delta)
+	assert.Error(t, err)
+}
+
 // After a heartbeat request is completed, the leader updates the heartbeat
 // timestamp column, and the serving node updates its cache of raft nodes.
 func TestHeartbeat(t *testing.T) {
@@ -407,22 +407,31 @@ func Join(state *state.State, gateway *Gateway, cert *shared.CertInfo, name stri
 		}
 
 		// Networks.
-		ids, err = tx.GetNonPendingNetworkIDs()
+		networkIDs, err := tx.GetNonPendingNetworkIDs()
 		if err != nil {
 			return errors.Wrap(err, "failed to get cluster network IDs")
 		}
-		for name, id := range ids {
-			config, ok := networks[name]
-			if !ok {
-				return fmt.Errorf("joining node has no config for network %s", name)
-			}
-			err := tx.NetworkNodeJoin(id, node.ID)
-			if err != nil {
-				return errors.Wrap(err, "failed to add joining node's to the network")
-			}
-			err = tx.CreateNetworkConfig(id, node.ID, config)
-			if err != nil {
-				return errors.Wrap(err, "failed to add joining node's network config")
+		for projectName, projectIDs := range networkIDs {
+			for name, id := range projectIDs {
+				config := map[string]string{}
+
+				// Only the networks of the default project have node-specific config.
+				if projectName == "default" {
+					var ok bool
+					config, ok = networks[name]
+					if !ok {
+						return fmt.Errorf("joining node has no config for network %s", name)
+					}
+				}
+
+				err := tx.NetworkNodeJoin(id, node.ID)
+				if err != nil {
+					return errors.Wrap(err, "failed to add joining node's to the network")
+				}
+				err = tx.CreateNetworkConfig(id, node.ID, config)
+				if err != nil {
+					return errors.Wrap(err, "failed to add joining node's network config")
+				}
 			}
 		}
 
@@ -820,6 +829,103 @@ func Handover(state *state.State, gateway *Gateway, address string) (string, []d
 	return "", nil, nil
 }
 
+// ChangeRole forces the dqlite role of the member with the given address. If
+// this would exceed cluster.max_voters or cluster.max_standby, another member
+// with that role (preferably an offline one) is demoted to make room for it.
+//
+// It returns the updated list of nodes, with the new roles set, along with the
+// address of the demoted member, if any.
+//
+// It should be called only by the current leader.
+func ChangeRole(state *state.State, gateway *Gateway, address string, role db.RaftRole) ([]db.RaftNode, string, error) {
+	nodes, err := gateway.currentRaftNodes()
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Get current raft nodes")
+	}
+
+	var maxVoters int64
+	var maxStandBy int64
+	err = state.Cluster.Transaction(func(tx *db.ClusterTx) error {
+		config, err := ConfigLoad(tx)
+		if err != nil {
+			return errors.Wrap(err, "Load cluster configuration")
+		}
+		maxVoters = config.MaxVoters()
+		maxStandBy = config.MaxStandBy()
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	target := -1
+	for i, node := range nodes {
+		if node.Address == address {
+			target = i
+			break
+		}
+	}
+
+	if target == -1 {
+		return nil, "", fmt.Errorf("Member %s is not part of the database cluster", address)
+	}
+
+	if nodes[target].Role == role {
+		return nodes, "", nil
+	}
+
+	if nodes[target].ID == gateway.info.ID {
+		return nil, "", fmt.Errorf("The role of the database leader cannot be changed")
+	}
+
+	if role != db.RaftSpare && !HasConnectivity(gateway.cert, address) {
+		return nil, "", fmt.Errorf("Member %s is offline and cannot be promoted", address)
+	}
+
+	// Demote another member if there's no room left for the new role.
+	demoted := ""
+	if role != db.RaftSpare {
+		max := int(maxVoters)
+		demotedRole := db.RaftStandBy
+		if role == db.RaftStandBy {
+			max = int(maxStandBy)
+			demotedRole = db.RaftSpare
+		}
+
+		count := 0
+		candidate := -1
+		for i, node := range nodes {
+			if i == target || node.Role != role {
+				continue
+			}
+
+			count++
+
+			// Never demote the leader, and prefer offline members.
+			if node.ID == gateway.info.ID {
+				continue
+			}
+
+			if candidate == -1 || !HasConnectivity(gateway.cert, node.Address) {
+				candidate = i
+			}
+		}
+
+		if count >= max {
+			if candidate == -1 {
+				return nil, "", fmt.Errorf("No member can be demoted to make room for %s", address)
+			}
+
+			nodes[candidate].Role = demotedRole
+			demoted = nodes[candidate].Address
+		}
+	}
+
+	nodes[target].Role = role
+
+	return nodes, demoted, nil
+}
+
 // Build an app.RolesChanges object feeded with the current cluster state.
 func newRolesChanges(state *state.State, gateway *Gateway, nodes []db.RaftNode) (*app.RolesChanges, error) {
 	var maxVoters int
@@ -957,6 +1063,18 @@ func List(state *state.State, gateway *Gateway) ([]api.ClusterMember, error) {
 		raftRoles[address] = node.Role
 	}
 
+	leaderAddress := ""
+	leader, err := cli.Leader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if leader != nil {
+		leaderAddress, err = gateway.nodeAddress(leader.Address)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	result := make([]api.ClusterMember, len(nodes))
 	now := time.Now()
 	version := nodes[0].Version()
@@ -967,17 +1085,26 @@ func List(state *state.State, gateway *Gateway) ([]api.ClusterMember, error) {
 		result[i].Roles = node.Roles
 		if result[i].Database {
 			result[i].Roles = append(result[i].Roles, string(db.ClusterRoleDatabase))
+			if node.Address == leaderAddress {
+				result[i].Roles = append(result[i].Roles, string(db.ClusterRoleDatabaseLeader))
+			}
+		} else if raftRoles[node.Address] == db.RaftStandBy {
+			result[i].Roles = append(result[i].Roles, string(db.ClusterRoleDatabaseStandBy))
 		}
 		result[i].Architecture, err = osarch.ArchitectureName(node.Architecture)
 		if err != nil {
 			return nil, err
 		}
 		result[i].FailureDomain = domains[node.Address]
+		result[i].Groups = node.Groups
 
 		if node.IsOffline(offlineThreshold) {
 			result[i].Status = "Offline"
 			result[i].Message = fmt.Sprintf(
 				"no heartbeat since %s", now.Sub(node.Heartbeat))
+		} else if node.State == db.ClusterMemberStateEvacuated {
+			result[i].Status = "Evacuated"
+			result[i].Message = "unavailable due to maintenance"
 		} else {
 			result[i].Status = "Online"
 			result[i].Message = "fully operational"
@@ -8,12 +8,14 @@ import (
 	sqldriver "database/sql/driver"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/CanonicalLtd/candidclient"
@@ -29,10 +31,12 @@ import (
 	"gopkg.in/macaroon-bakery.v2/bakery/identchecker"
 	"gopkg.in/macaroon-bakery.v2/httpbakery"
 
+	"github.com/lxc/lxd/lxd/bgp"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/daemon"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/device"
+	"github.com/lxc/lxd/lxd/dns"
 	"github.com/lxc/lxd/lxd/endpoints"
 	"github.com/lxc/lxd/lxd/events"
 	"github.com/lxc/lxd/lxd/firewall"
@@ -42,8 +46,11 @@ import (
 	_ "github.com/lxc/lxd/lxd/instance/drivers"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/maas"
+	"github.com/lxc/lxd/lxd/metrics"
 	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/oidc"
 	"github.com/lxc/lxd/lxd/rbac"
+	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/seccomp"
 	"github.com/lxc/lxd/lxd/state"
@@ -61,6 +68,8 @@ import (
 // A Daemon can respond to requests from a shared client.
 type Daemon struct {
 	clientCerts  map[string]x509.Certificate
+	metricsCerts map[string]x509.Certificate
+	clientRoles  map[string]map[string]string // Built-in roles of restricted client certificates, by project
 	os           *sys.OS
 	db           *db.Node
 	firewall     firewall.Firewall
@@ -81,17 +90,27 @@ type Daemon struct {
 	clusterTasks task.Group
 
 	// Indexes of tasks that need to be reset when their execution interval changes
-	taskPruneImages *task.Task
-	taskAutoUpdate  *task.Task
+	taskPruneImages  *task.Task
+	taskAutoUpdate   *task.Task
+	taskNetworkStats *task.Task
 
 	config    *DaemonConfig
 	endpoints *endpoints.Endpoints
 	gateway   *cluster.Gateway
 	seccomp   *seccomp.Server
+	dns       *dns.Server
+	bgp       *bgp.Server
+
+	// Main REST API router, used to dispatch batched requests
+	router *mux.Router
 
 	proxy func(req *http.Request) (*url.URL, error)
 
 	externalAuth *externalAuth
+	oidcVerifier *oidc.Verifier
+
+	// Time of the last API request, in nanoseconds since the epoch (atomic).
+	lastActivity int64
 
 	// Stores last heartbeat node information to detect node changes.
 	lastNodeList *cluster.APIHeartbeat
@@ -101,6 +120,9 @@ type Daemon struct {
 	clusterMembershipMutex   sync.RWMutex
 	clusterMembershipClosing bool // Prevent further rebalances
 
+	// Protects clientRoles, which is updated when certificates are added.
+	clientRolesMu sync.RWMutex
+
 	ctx    context.Context
 	cancel context.CancelFunc
 }
@@ -117,6 +139,7 @@ type DaemonConfig struct {
 	Trace              []string      // List of sub-systems to trace
 	RaftLatency        float64       // Coarse grain measure of the cluster latency
 	DqliteSetupTimeout time.Duration // How long to wait for the cluster database to be up
+	IdleTimeout        time.Duration // How long to stay idle before exiting (zero to disable)
 }
 
 // IdentityClientWrapper is a wrapper around an IdentityClient.
@@ -290,6 +313,16 @@ func (d *Daemon) Authenticate(r *http.Request) (bool, string, string, error) {
 		return false, "", "", fmt.Errorf("Bad/missing TLS on network query")
 	}
 
+	if d.oidcVerifier != nil && oidc.IsRequest(r) {
+		// Validate OpenID Connect bearer token
+		username, err := d.oidcVerifier.Auth(r.Context(), r)
+		if err != nil {
+			return false, "", "", err
+		}
+
+		return true, username, "oidc", nil
+	}
+
 	if d.externalAuth != nil && r.Header.Get(httpbakery.BakeryProtocolHeader) != "" {
 		// Validate external authentication
 		ctx := httpbakery.ContextWithRequest(context.TODO(), r)
@@ -364,7 +397,7 @@ func (d *Daemon) State() *state.State {
 	// If the daemon is shutting down, the context will be cancelled.
 	// This information will be available throughout the code, and can be used to prevent new
 	// operations from starting during shutdown.
-	return state.NewState(d.ctx, d.db, d.cluster, d.maas, d.os, d.endpoints, d.events, d.devlxdEvents, d.firewall, d.proxy)
+	return state.NewState(d.ctx, d.db, d.cluster, d.maas, d.os, d.endpoints, d.events, d.devlxdEvents, d.firewall, d.bgp, d.proxy)
 }
 
 // UnixSocket returns the full path to the unix.socket file that this daemon is
@@ -389,6 +422,9 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 	route := restAPI.HandleFunc(uri, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
+		// Record API activity for the idle timeout
+		atomic.StoreInt64(&d.lastActivity, time.Now().UnixNano())
+
 		if !(r.RemoteAddr == "@" && version == "internal") {
 			// Block public API requests until we're done with basic
 			// initialization tasks, such setting up the cluster database.
@@ -404,9 +440,10 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 		// Authentication
 		trusted, username, protocol, err := d.Authenticate(r)
 		if err != nil {
-			// If not a macaroon discharge request, return the error
+			// If not a macaroon discharge request or an OIDC token failure, return the error
 			_, ok := err.(*bakery.DischargeRequiredError)
-			if !ok {
+			_, oidcErr := err.(*oidc.AuthError)
+			if !ok && !oidcErr {
 				response.InternalError(err).Render(w)
 				return
 			}
@@ -431,6 +468,11 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 		} else if derr, ok := err.(*bakery.DischargeRequiredError); ok {
 			writeMacaroonsRequiredResponse(d.externalAuth.bakery, r, w, derr, d.externalAuth.expiry)
 			return
+		} else if d.oidcVerifier != nil && (oidc.IsRequest(r) || r.Header.Get("X-LXD-oidc") != "") {
+			// Tell OIDC clients where to get a (new) token
+			d.oidcVerifier.WriteHeaders(w)
+			response.ErrorResponse(http.StatusUnauthorized, "Unauthorized").Render(w)
+			return
 		} else {
 			logger.Warn("Rejecting request from untrusted client", log.Ctx{"ip": r.RemoteAddr})
 			response.Forbidden(nil).Render(w)
@@ -455,6 +497,10 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 		var resp response.Response
 		resp = response.NotImplemented(nil)
 
+		// Record the time spent handling the request for the metrics
+		start := time.Now()
+		defer func() { metrics.TrackAPIRequest(r.Method, time.Since(start)) }()
+
 		// Return Unavailable Error (503) if daemon is shutting down.
 		// There are some exceptions:
 		// - internal calls, e.g. lxd shutdown
@@ -465,6 +511,24 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 			return
 		}
 
+		// Record the state changing requests of the clients in the audit trail.
+		if trusted && version != "internal" && r.Method != "GET" && protocol != "cluster" {
+			statusWriter := response.NewStatusWriter(w)
+			w = statusWriter
+
+			defer func() {
+				err := d.events.SendAudit(projectParam(r), events.AuditEntry{
+					Method:    r.Method,
+					URL:       r.URL.RequestURI(),
+					Status:    statusWriter.Status(),
+					Requestor: request.CreateRequestor(r),
+				})
+				if err != nil {
+					logger.Warn("Failed to record API request in the audit trail", log.Ctx{"url": r.URL.RequestURI(), "err": err})
+				}
+			}()
+		}
+
 		handleRequest := func(action APIEndpointAction) response.Response {
 			if action.Handler == nil {
 				return response.NotImplemented(nil)
@@ -853,7 +917,12 @@ func (d *Daemon) init() error {
 		return errors.Wrap(err, "failed to open cluster database")
 	}
 
-	d.firewall = firewall.New()
+	firewallDriver, err := node.FirewallDriver(d.db)
+	if err != nil {
+		return errors.Wrap(err, "Failed to load the firewall driver configuration")
+	}
+
+	d.firewall = firewall.New(firewallDriver)
 	logger.Infof("Firewall loaded driver %q", d.firewall)
 
 	err = cluster.NotifyUpgradeCompleted(d.State(), certInfo)
@@ -910,6 +979,9 @@ func (d *Daemon) init() error {
 		return err
 	}
 
+	// Setup the BGP server, the networks register their prefixes and peers with it as they start.
+	d.bgp = bgp.NewServer()
+
 	// Setup the networks.
 	logger.Infof("Initializing networks")
 	err = networkStartup(d.State())
@@ -917,6 +989,41 @@ func (d *Daemon) init() error {
 		return err
 	}
 
+	// Start the BGP listener.
+	bgpAddress, bgpRouterID, err := node.BGPAddress(d.db)
+	if err != nil {
+		return errors.Wrap(err, "Failed to fetch BGP address")
+	}
+
+	bgpASN, err := cluster.ConfigGetInt64(d.cluster, "core.bgp_asn")
+	if err != nil {
+		return errors.Wrap(err, "Failed to fetch BGP ASN")
+	}
+
+	err = d.bgp.Start(bgpAddress, uint32(bgpASN), net.ParseIP(bgpRouterID))
+	if err != nil {
+		return err
+	}
+
+	// Setup the DNS server for the network zones.
+	dnsAddress, err := node.DNSAddress(d.db)
+	if err != nil {
+		return errors.Wrap(err, "Failed to fetch DNS address")
+	}
+
+	d.dns = dns.NewServer(d.cluster, func(name string) (*dns.Zone, error) {
+		return networkZoneDNS(d.State(), name)
+	}, func(name string) (string, error) {
+		return networkDNSForward(d.State(), name)
+	})
+
+	if dnsAddress != "" {
+		err = d.dns.Start(dnsAddress)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Cleanup leftover images.
 	pruneLeftoverImages(d)
 
@@ -938,6 +1045,10 @@ func (d *Daemon) init() error {
 	maasAPIKey := ""
 	maasMachine := ""
 
+	oidcIssuer := ""
+	oidcClientID := ""
+	oidcAudience := ""
+
 	err = d.db.Transaction(func(tx *db.NodeTx) error {
 		config, err := node.ConfigLoad(tx)
 		if err != nil {
@@ -945,6 +1056,7 @@ func (d *Daemon) init() error {
 		}
 
 		maasMachine = config.MAASMachine()
+		d.events.SetSyslogTypes(config.SyslogEvents())
 		return nil
 	})
 	if err != nil {
@@ -962,9 +1074,27 @@ func (d *Daemon) init() error {
 			config.ProxyHTTPS(), config.ProxyHTTP(), config.ProxyIgnoreHosts(),
 		)
 
+		serverName, err := tx.GetLocalNodeName()
+		if err != nil {
+			return err
+		}
+
+		daemonConfigSetWebhook(d, config, serverName)
+
 		candidAPIURL, candidAPIKey, candidExpiry, candidDomains = config.CandidServer()
 		maasAPIURL, maasAPIKey = config.MAASController()
 		rbacAPIURL, rbacAPIKey, rbacExpiry, rbacAgentURL, rbacAgentUsername, rbacAgentPrivateKey, rbacAgentPublicKey = config.RBACServer()
+		oidcIssuer, oidcClientID, oidcAudience = config.OIDCServer()
+
+		// External services can't be reached in offline mode
+		if config.Offline() && (candidAPIURL != "" || maasAPIURL != "" || rbacAPIURL != "" || oidcIssuer != "") {
+			logger.Warn("Offline mode is enabled, not connecting to MAAS, Candid, RBAC or OpenID Connect")
+			candidAPIURL = ""
+			maasAPIURL = ""
+			rbacAPIURL = ""
+			oidcIssuer = ""
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -985,6 +1115,8 @@ func (d *Daemon) init() error {
 		}
 	}
 
+	d.setupOIDC(oidcIssuer, oidcClientID, oidcAudience)
+
 	if !d.os.MockMode {
 		// Start the scheduler
 		go deviceEventListener(d.State())
@@ -1099,6 +1231,9 @@ func (d *Daemon) Ready() error {
 		// Take snapshot of containers (minutely check of configurable cron expression)
 		d.tasks.Add(autoCreateContainerSnapshotsTask(d))
 
+		// Take backup of instances (minutely check of configurable cron expression)
+		d.tasks.Add(autoCreateInstanceBackupsTask(d))
+
 		// Remove expired container snapshots (minutely)
 		d.tasks.Add(pruneExpiredContainerSnapshotsTask(d))
 
@@ -1107,6 +1242,33 @@ func (d *Daemon) Ready() error {
 
 		// Take snapshot of custom volumes (minutely check of configurable cron expression)
 		d.tasks.Add(autoCreateCustomVolumeSnapshotsTask(d))
+
+		// Take backup of custom volumes (minutely check of configurable cron expression)
+		d.tasks.Add(autoCreateCustomVolumeBackupsTask(d))
+
+		// Remove expired certificate add and cluster join tokens (minutely)
+		d.tasks.Add(pruneExpiredTokensTask(d))
+
+		// Refresh the prefixes delegated to bridge networks (minutely)
+		d.tasks.Add(networkPrefixDelegationTask(d))
+
+		// Sample the network counters (configurable interval)
+		d.taskNetworkStats = d.tasks.Add(networkStatsTask(d))
+
+		// Restart crashed and unhealthy instances (every 10s)
+		d.tasks.Add(instancesHealthTask(d))
+
+		// Exit when idle (minutely, if configured)
+		if d.config.IdleTimeout > 0 && clustered {
+			logger.Warn("Ignoring idle timeout as clustered LXD can't exit when idle")
+		} else if d.config.IdleTimeout > 0 {
+			if !d.endpoints.SocketActivated() {
+				logger.Warn("Idle timeout set without socket activation, LXD won't be restarted on demand")
+			}
+
+			atomic.StoreInt64(&d.lastActivity, time.Now().UnixNano())
+			d.tasks.Add(idleShutdownTask(d))
+		}
 	}
 
 	// Start all background tasks
@@ -1237,6 +1399,14 @@ func (d *Daemon) Stop() error {
 		trackError(d.seccomp.Stop(), "Stop seccomp")
 	}
 
+	if d.dns != nil {
+		trackError(d.dns.Stop(), "Stop DNS server")
+	}
+
+	if d.bgp != nil {
+		trackError(d.bgp.Stop(), "Stop BGP server")
+	}
+
 	var err error
 	if n := len(errs); n > 0 {
 		format := "%v"
@@ -1384,16 +1554,40 @@ func (d *Daemon) setupRBACServer(rbacURL string, rbacKey string, rbacExpiry int6
 	return nil
 }
 
-func (d *Daemon) userIsAdmin(r *http.Request) bool {
-	if d.externalAuth == nil || d.rbac == nil || r.RemoteAddr == "@" {
-		return true
+// userRoles returns the built-in roles of the client certificate used for the request, or nil if the
+// certificate isn't restricted to a set of projects.
+func (d *Daemon) userRoles(r *http.Request) map[string]string {
+	username, _ := r.Context().Value("username").(string)
+	if username == "" {
+		return nil
 	}
 
-	if r.Context().Value("protocol") == "cluster" {
+	d.clientRolesMu.RLock()
+	defer d.clientRolesMu.RUnlock()
+
+	return d.clientRoles[username]
+}
+
+func (d *Daemon) userIsAdmin(r *http.Request) bool {
+	if r.RemoteAddr == "@" || r.Context().Value("protocol") == "cluster" {
 		return true
 	}
 
+	// Certificates with built-in roles are restricted to their projects.
 	if r.Context().Value("protocol") == "tls" {
+		return d.userRoles(r) == nil
+	}
+
+	// Users authenticated through OpenID Connect get their roles from RBAC.
+	if r.Context().Value("protocol") == "oidc" {
+		if d.rbac == nil {
+			return false
+		}
+
+		return d.rbac.IsAdmin(r.Context().Value("username").(string))
+	}
+
+	if d.externalAuth == nil || d.rbac == nil {
 		return true
 	}
 
@@ -1401,21 +1595,50 @@ func (d *Daemon) userIsAdmin(r *http.Request) bool {
 }
 
 func (d *Daemon) userHasPermission(r *http.Request, project string, permission string) bool {
-	if d.externalAuth == nil || d.rbac == nil || r.RemoteAddr == "@" {
+	if r.RemoteAddr == "@" || r.Context().Value("protocol") == "cluster" {
 		return true
 	}
 
-	if r.Context().Value("protocol") == "cluster" {
-		return true
+	if r.Context().Value("protocol") == "tls" {
+		roles := d.userRoles(r)
+		if roles == nil {
+			return true
+		}
+
+		return rbac.RoleHasPermission(roles[project], permission)
 	}
 
-	if r.Context().Value("protocol") == "tls" {
+	if r.Context().Value("protocol") == "oidc" {
+		if d.rbac == nil {
+			return false
+		}
+
+		return d.rbac.HasPermission(r.Context().Value("username").(string), project, permission)
+	}
+
+	if d.externalAuth == nil || d.rbac == nil {
 		return true
 	}
 
 	return d.rbac.HasPermission(r.Context().Value("username").(string), project, permission)
 }
 
+// Setup OpenID Connect authentication
+func (d *Daemon) setupOIDC(issuer string, clientID string, audience string) {
+	// Both the issuer and the client ID are needed, otherwise disable OIDC
+	if issuer == "" || clientID == "" {
+		d.oidcVerifier = nil
+		return
+	}
+
+	// OpenID Connect users get their permissions from RBAC.
+	if d.rbac == nil {
+		logger.Warn("RBAC isn't available, OpenID Connect users won't be allowed to do anything")
+	}
+
+	d.oidcVerifier = oidc.NewVerifier(issuer, clientID, audience)
+}
+
 // Setup MAAS
 func (d *Daemon) setupMAASController(server string, key string, machine string) error {
 	var err error
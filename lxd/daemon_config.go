@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/events"
 	"github.com/lxc/lxd/lxd/node"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/shared"
@@ -51,3 +52,22 @@ func daemonConfigSetProxy(d *Daemon, config *cluster.Config) {
 		config.ProxyIgnoreHosts(),
 	)
 }
+
+func daemonConfigSetWebhook(d *Daemon, config *cluster.Config, serverName string) {
+	url, secret, types, retries, retryInterval := config.EventsWebhook()
+
+	// External services can't be reached in offline mode
+	if config.Offline() {
+		url = ""
+	}
+
+	d.events.SetWebhook(events.WebhookConfig{
+		URL:           url,
+		Secret:        secret,
+		Types:         types,
+		Retries:       retries,
+		RetryInterval: retryInterval,
+		Proxy:         d.proxy,
+		Location:      serverName,
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// idleShutdownTask returns a task which asks the daemon to shut down once it has been idle for longer
+// than the configured idle timeout.
+func idleShutdownTask(d *Daemon) (task.Func, task.Schedule) {
+	requested := false
+
+	f := func(ctx context.Context) {
+		if requested {
+			return
+		}
+
+		idle, err := d.isIdle()
+		if err != nil {
+			logger.Warnf("Failed to check whether LXD is idle: %v", err)
+			return
+		}
+
+		if !idle {
+			return
+		}
+
+		logger.Infof("No activity for %s, shutting down", d.config.IdleTimeout)
+		requested = true
+
+		// The shutdown channel is unbuffered, don't block the task group.
+		go func() {
+			d.shutdownChan <- struct{}{}
+		}()
+	}
+
+	return f, task.Every(time.Minute, task.SkipFirst)
+}
+
+// isIdle returns whether no API request was received within the idle timeout and there are no
+// running instances, operations or event listeners.
+func (d *Daemon) isIdle() (bool, error) {
+	lastActivity := time.Unix(0, atomic.LoadInt64(&d.lastActivity))
+	if time.Since(lastActivity) < d.config.IdleTimeout {
+		return false, nil
+	}
+
+	if d.events.ListenerCount() > 0 {
+		return false, nil
+	}
+
+	for _, op := range operations.Clone() {
+		if op.Status() == api.Running {
+			return false, nil
+		}
+	}
+
+	instances, err := instance.LoadNodeAll(d.State(), instancetype.Any)
+	if err != nil {
+		return false, err
+	}
+
+	for _, inst := range instances {
+		if inst.IsRunning() {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
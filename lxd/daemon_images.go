@@ -7,9 +7,13 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
@@ -29,6 +33,47 @@ import (
 var imagesDownloading = map[string]chan bool{}
 var imagesDownloadingLock sync.Mutex
 
+// errOffline is returned when connecting to a server which isn't mirrored while in offline mode.
+var errOffline = fmt.Errorf("LXD is in offline mode")
+
+// imageServerURL returns the URL to use to reach the given image server, replacing the longest
+// matching prefix configured in images.mirrors. In offline mode, errOffline is returned for servers
+// which aren't mirrored.
+func (d *Daemon) imageServerURL(server string) (string, error) {
+	var offline bool
+	var mirrors map[string]string
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		config, err := cluster.ConfigLoad(tx)
+		if err != nil {
+			return err
+		}
+
+		offline = config.Offline()
+		mirrors = config.ImageMirrors()
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	match := ""
+	for prefix := range mirrors {
+		if strings.HasPrefix(server, prefix) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+
+	if match != "" {
+		return mirrors[match] + strings.TrimPrefix(server, match), nil
+	}
+
+	if offline {
+		return "", errOffline
+	}
+
+	return server, nil
+}
+
 // ImageDownload resolves the image fingerprint and if not in the database, downloads it
 func (d *Daemon) ImageDownload(op *operations.Operation, server string, protocol string, certificate string, secret string, alias string, imageType string, forContainer bool, autoUpdate bool, storagePool string, preferCached bool, project string, budget int64) (*api.Image, error) {
 	var err error
@@ -45,8 +90,25 @@ func (d *Daemon) ImageDownload(op *operations.Operation, server string, protocol
 	// Default the fingerprint to the alias string we received
 	fp := alias
 
+	// Apply the image mirrors, only already cached images can be used in offline mode
+	serverURL, err := d.imageServerURL(server)
+	offline := err == errOffline
+	if err != nil && !offline {
+		return nil, err
+	}
+
+	if offline && shared.StringInSlice(protocol, []string{"lxd", "simplestreams"}) {
+		for _, architecture := range d.os.Architectures {
+			cachedFingerprint, err := d.cluster.GetCachedImageSourceFingerprint(server, protocol, alias, imageType, architecture)
+			if err == nil {
+				fp = cachedFingerprint
+				break
+			}
+		}
+	}
+
 	// Attempt to resolve the alias
-	if shared.StringInSlice(protocol, []string{"lxd", "simplestreams"}) {
+	if !offline && shared.StringInSlice(protocol, []string{"lxd", "simplestreams"}) {
 		args := &lxd.ConnectionArgs{
 			TLSServerCert: certificate,
 			UserAgent:     version.UserAgent,
@@ -57,13 +119,13 @@ func (d *Daemon) ImageDownload(op *operations.Operation, server string, protocol
 
 		if protocol == "lxd" {
 			// Setup LXD client
-			remote, err = lxd.ConnectPublicLXD(server, args)
+			remote, err = lxd.ConnectPublicLXD(serverURL, args)
 			if err != nil {
 				return nil, err
 			}
 		} else {
 			// Setup simplestreams client
-			remote, err = lxd.ConnectSimpleStreams(server, args)
+			remote, err = lxd.ConnectSimpleStreams(serverURL, args)
 			if err != nil {
 				return nil, err
 			}
@@ -92,11 +154,11 @@ func (d *Daemon) ImageDownload(op *operations.Operation, server string, protocol
 	// server/protocol/alias, regardless of whether it's stale or
 	// not (we can assume that it will be not *too* stale since
 	// auto-update is on).
-	interval, err := cluster.ConfigGetInt64(d.cluster, "images.auto_update_interval")
+	policy, err := imagePolicyGet(d.cluster, project)
 	if err != nil {
 		return nil, err
 	}
-	if preferCached && interval > 0 && alias != fp {
+	if preferCached && policy.autoUpdateInterval > 0 && alias != fp {
 		for _, architecture := range d.os.Architectures {
 			cachedFingerprint, err := d.cluster.GetCachedImageSourceFingerprint(server, protocol, alias, imageType, architecture)
 			if err == nil && cachedFingerprint != fp {
@@ -172,6 +234,11 @@ func (d *Daemon) ImageDownload(op *operations.Operation, server string, protocol
 		return info, nil
 	}
 
+	// Nothing can be downloaded in offline mode
+	if offline {
+		return nil, errors.Wrapf(errOffline, "Image %q from %q isn't available locally", alias, server)
+	}
+
 	// Deal with parallel downloads
 	imagesDownloadingLock.Lock()
 	if waitChannel, ok := imagesDownloading[fp]; ok {
@@ -247,6 +314,16 @@ func (d *Daemon) ImageDownload(op *operations.Operation, server string, protocol
 
 		if meta["download_progress"] != progress.Text {
 			meta["download_progress"] = progress.Text
+
+			// Structured progress for API callers.
+			meta["progress"] = map[string]string{
+				"stage":     "download",
+				"percent":   strconv.Itoa(progress.Percentage),
+				"processed": strconv.FormatInt(progress.TransferredBytes, 10),
+				"total":     strconv.FormatInt(progress.TotalBytes, 10),
+				"speed":     strconv.FormatInt(progress.BytesPerSecond, 10),
+			}
+
 			op.UpdateMetadata(meta)
 		}
 	}
@@ -257,6 +334,8 @@ func (d *Daemon) ImageDownload(op *operations.Operation, server string, protocol
 		op.SetCanceler(canceler)
 	}
 
+	ctx := op.Context()
+
 	if protocol == "lxd" || protocol == "simplestreams" {
 		// Create the target files
 		dest, err := os.Create(destName)
@@ -353,7 +432,7 @@ func (d *Daemon) ImageDownload(op *operations.Operation, server string, protocol
 			return nil, err
 		}
 
-		req, err := http.NewRequest("GET", server, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", serverURL, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -423,6 +502,11 @@ func (d *Daemon) ImageDownload(op *operations.Operation, server string, protocol
 		return nil, fmt.Errorf("Unsupported protocol: %v", protocol)
 	}
 
+	// Stop here if the operation got cancelled during the download.
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "Image download cancelled")
+	}
+
 	// Override visiblity
 	info.Public = false
 
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Users authenticated through OpenID Connect have no permissions when RBAC isn't available.
+func TestDaemon_OIDCWithoutRBAC(t *testing.T) {
+	d := &Daemon{}
+
+	r := httptest.NewRequest("GET", "/1.0/instances", nil)
+	ctx := context.WithValue(context.WithValue(r.Context(), "username", "user@example.com"), "protocol", "oidc")
+	r = r.WithContext(ctx)
+
+	assert.False(t, d.userIsAdmin(r))
+	assert.False(t, d.userHasPermission(r, "default", "view"))
+}
@@ -100,6 +100,31 @@ WHERE projects.name=? AND instances.name=?`
 	return result, nil
 }
 
+// GetProjectInstanceBackups returns the names of all instance backups in the
+// given project, regardless of the cluster member hosting them.
+func (c *Cluster) GetProjectInstanceBackups(project string) ([]string, error) {
+	var result []string
+	var name string
+
+	q := `SELECT instances_backups.name FROM instances_backups
+JOIN instances ON instances_backups.instance_id=instances.id
+JOIN projects ON projects.id=instances.project_id
+WHERE projects.name=?
+ORDER BY instances_backups.name`
+	inargs := []interface{}{project}
+	outfmt := []interface{}{name}
+	dbResults, err := queryScan(c, q, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range dbResults {
+		result = append(result, r[0].(string))
+	}
+
+	return result, nil
+}
+
 // CreateInstanceBackup creates a new backup.
 func (c *Cluster) CreateInstanceBackup(args InstanceBackup) error {
 	_, err := c.getInstanceBackupID(args.Name)
@@ -2,6 +2,10 @@
 
 package db
 
+import (
+	"fmt"
+)
+
 // Code generation directives.
 //
 //go:generate -command mapper lxd-generate db mapper -t certificates.mapper.go
@@ -22,6 +26,29 @@ package db
 //go:generate mapper method -p db -e certificate Delete
 //go:generate mapper method -p db -e certificate Rename
 
+// Supported certificate types.
+const (
+	CertificateTypeClient  = 1
+	CertificateTypeMetrics = 2
+)
+
+// CertificateTypes maps the API names of the certificate types to their database value.
+var CertificateTypes = map[string]int{
+	"client":  CertificateTypeClient,
+	"metrics": CertificateTypeMetrics,
+}
+
+// CertificateTypeName returns the API name of the given certificate type.
+func CertificateTypeName(certType int) string {
+	for name, value := range CertificateTypes {
+		if value == certType {
+			return name
+		}
+	}
+
+	return "unknown"
+}
+
 // Certificate is here to pass the certificates content
 // from the database around
 type Certificate struct {
@@ -75,3 +102,97 @@ func (c *Cluster) RenameCertificate(fingerprint string, name string) error {
 	})
 	return err
 }
+
+// UpdateCertificate updates a certificate's name and type.
+func (c *Cluster) UpdateCertificate(fingerprint string, name string, certType int) error {
+	err := c.Transaction(func(tx *ClusterTx) error {
+		result, err := tx.tx.Exec("UPDATE certificates SET name=?, type=? WHERE fingerprint=?", name, certType, fingerprint)
+		if err != nil {
+			return err
+		}
+
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if n != 1 {
+			return fmt.Errorf("Query affected %d rows instead of 1", n)
+		}
+
+		return nil
+	})
+	return err
+}
+
+// GetCertificatesRoles returns the roles given to certificates, indexed by certificate fingerprint and then by
+// project name. Certificates without any role are not included.
+func (c *ClusterTx) GetCertificatesRoles() (map[string]map[string]string, error) {
+	rows, err := c.tx.Query(`
+SELECT certificates.fingerprint, projects.name, certificates_roles.role
+  FROM certificates_roles
+  JOIN certificates ON certificates.id = certificates_roles.certificate_id
+  JOIN projects ON projects.id = certificates_roles.project_id
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := map[string]map[string]string{}
+	for rows.Next() {
+		var fingerprint string
+		var project string
+		var role string
+
+		err := rows.Scan(&fingerprint, &project, &role)
+		if err != nil {
+			return nil, err
+		}
+
+		if roles[fingerprint] == nil {
+			roles[fingerprint] = map[string]string{}
+		}
+
+		roles[fingerprint][project] = role
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// UpdateCertificateRoles replaces the roles given to the certificate with the given fingerprint. The roles are
+// indexed by project name.
+func (c *ClusterTx) UpdateCertificateRoles(fingerprint string, roles map[string]string) error {
+	cert, err := c.GetCertificate(fingerprint)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.tx.Exec("DELETE FROM certificates_roles WHERE certificate_id=?", cert.ID)
+	if err != nil {
+		return err
+	}
+
+	for project, role := range roles {
+		projectID, err := c.GetProjectID(project)
+		if err != nil {
+			if err == ErrNoSuchObject {
+				return fmt.Errorf("Project %q not found", project)
+			}
+
+			return err
+		}
+
+		_, err = c.tx.Exec("INSERT INTO certificates_roles (certificate_id, project_id, role) VALUES (?, ?, ?)", cert.ID, projectID, role)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
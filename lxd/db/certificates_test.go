@@ -22,3 +22,46 @@ func TestGetCertificate(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, cert.Fingerprint, "foobar")
 }
+
+// The name and type of a certificate can be updated.
+func TestUpdateCertificate(t *testing.T) {
+	cluster, cleanup := db.NewTestCluster(t)
+	defer cleanup()
+
+	err := cluster.CreateCertificate(db.Certificate{Fingerprint: "foobar", Name: "foo", Type: db.CertificateTypeClient})
+	require.NoError(t, err)
+
+	err = cluster.UpdateCertificate("foobar", "bar", db.CertificateTypeMetrics)
+	require.NoError(t, err)
+
+	cert, err := cluster.GetCertificate("foobar")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", cert.Name)
+	assert.Equal(t, "metrics", db.CertificateTypeName(cert.Type))
+}
+
+// Roles can be given to a certificate on a per-project basis.
+func TestUpdateCertificateRoles(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	_, err := tx.CreateCertificate(db.Certificate{Fingerprint: "foobar", Type: db.CertificateTypeClient})
+	require.NoError(t, err)
+
+	err = tx.UpdateCertificateRoles("foobar", map[string]string{"default": "viewer"})
+	require.NoError(t, err)
+
+	roles, err := tx.GetCertificatesRoles()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{"foobar": {"default": "viewer"}}, roles)
+
+	err = tx.UpdateCertificateRoles("foobar", map[string]string{"missing": "admin"})
+	assert.EqualError(t, err, `Project "missing" not found`)
+
+	err = tx.UpdateCertificateRoles("foobar", nil)
+	require.NoError(t, err)
+
+	roles, err = tx.GetCertificatesRoles()
+	require.NoError(t, err)
+	assert.Len(t, roles, 0)
+}
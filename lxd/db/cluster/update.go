@@ -70,6 +70,350 @@ var updates = map[int]schema.Update{
 	31: updateFromV30,
 	32: updateFromV31,
 	33: updateFromV32,
+	34: updateFromV33,
+	35: updateFromV34,
+	36: updateFromV35,
+	37: updateFromV36,
+	38: updateFromV37,
+	39: updateFromV38,
+	40: updateFromV39,
+	41: updateFromV40,
+	42: updateFromV41,
+	43: updateFromV42,
+	44: updateFromV43,
+}
+
+// Add warnings table.
+func updateFromV43(tx *sql.Tx) error {
+	stmt := `
+CREATE TABLE warnings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    node_id INTEGER,
+    project_id INTEGER,
+    entity_type TEXT NOT NULL DEFAULT '',
+    entity_id INTEGER NOT NULL DEFAULT -1,
+    uuid TEXT NOT NULL,
+    type TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    first_seen_date DATETIME NOT NULL,
+    last_seen_date DATETIME NOT NULL,
+    updated_date DATETIME,
+    last_message TEXT NOT NULL,
+    count INTEGER NOT NULL,
+    UNIQUE (uuid),
+    FOREIGN KEY (node_id) REFERENCES nodes(id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX warnings_unique_node_id_project_id_entity_type_entity_id_type ON warnings(IFNULL(node_id, -1), IFNULL(project_id, -1), entity_type, entity_id, type);
+`
+	_, err := tx.Exec(stmt)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add warnings table")
+	}
+
+	return nil
+}
+
+// Add storage_volumes_backups table.
+func updateFromV42(tx *sql.Tx) error {
+	stmt := `
+CREATE TABLE storage_volumes_backups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    storage_volume_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    creation_date DATETIME,
+    expiry_date DATETIME,
+    volume_only INTEGER NOT NULL default 0,
+    optimized_storage INTEGER NOT NULL default 0,
+    FOREIGN KEY (storage_volume_id) REFERENCES "storage_volumes" (id) ON DELETE CASCADE,
+    UNIQUE (storage_volume_id, name)
+);
+`
+	_, err := tx.Exec(stmt)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add storage volumes backups table")
+	}
+
+	return nil
+}
+
+// Add certificates_roles table.
+func updateFromV41(tx *sql.Tx) error {
+	stmt := `
+CREATE TABLE certificates_roles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    certificate_id INTEGER NOT NULL,
+    project_id INTEGER NOT NULL,
+    role TEXT NOT NULL,
+    UNIQUE (certificate_id, project_id),
+    FOREIGN KEY (certificate_id) REFERENCES certificates (id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+`
+	_, err := tx.Exec(stmt)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add certificates roles table")
+	}
+
+	return nil
+}
+
+// Add project_id column to networks table, making network names unique per project.
+func updateFromV40(tx *sql.Tx) error {
+	stmts := `
+CREATE TABLE networks_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    project_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT,
+    state INTEGER NOT NULL DEFAULT 0,
+    type INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (project_id, name),
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+
+-- Make a copy of the tables referencing networks, since dropping the old
+-- networks table triggers cascading deletes.
+CREATE TABLE networks_config_copy AS SELECT * FROM networks_config;
+CREATE TABLE networks_forwards_copy AS SELECT * FROM networks_forwards;
+CREATE TABLE networks_forwards_config_copy AS SELECT * FROM networks_forwards_config;
+CREATE TABLE networks_nodes_copy AS SELECT * FROM networks_nodes;
+CREATE TABLE networks_peers_copy AS SELECT * FROM networks_peers;
+CREATE TABLE networks_peers_config_copy AS SELECT * FROM networks_peers_config;
+CREATE TABLE networks_reservations_copy AS SELECT * FROM networks_reservations;
+
+-- Existing networks are moved to the default project.
+INSERT INTO networks_new (id, project_id, name, description, state, type)
+    SELECT id, (SELECT id FROM projects WHERE name = 'default'), name, description, state, type FROM networks;
+
+DROP TABLE networks;
+ALTER TABLE networks_new RENAME TO networks;
+
+-- Restore the content of the tables referencing networks.
+INSERT INTO networks_config SELECT * FROM networks_config_copy;
+INSERT INTO networks_forwards SELECT * FROM networks_forwards_copy;
+INSERT INTO networks_forwards_config SELECT * FROM networks_forwards_config_copy;
+INSERT INTO networks_nodes SELECT * FROM networks_nodes_copy;
+INSERT INTO networks_peers SELECT * FROM networks_peers_copy;
+INSERT INTO networks_peers_config SELECT * FROM networks_peers_config_copy;
+INSERT INTO networks_reservations SELECT * FROM networks_reservations_copy;
+
+DROP TABLE networks_config_copy;
+DROP TABLE networks_forwards_copy;
+DROP TABLE networks_forwards_config_copy;
+DROP TABLE networks_nodes_copy;
+DROP TABLE networks_peers_copy;
+DROP TABLE networks_peers_config_copy;
+DROP TABLE networks_reservations_copy;
+
+DROP VIEW projects_used_by_ref;
+CREATE VIEW projects_used_by_ref (name,
+    value) AS
+  SELECT projects.name,
+    printf('/1.0/instances/%s?project=%s',
+    "instances".name,
+    projects.name)
+    FROM "instances" JOIN projects ON project_id=projects.id UNION
+  SELECT projects.name,
+    printf('/1.0/images/%s?project=%s',
+    images.fingerprint,
+    projects.name)
+    FROM images JOIN projects ON project_id=projects.id UNION
+  SELECT projects.name,
+    printf('/1.0/storage-pools/%s/volumes/custom/%s?project=%s&target=%s',
+    storage_pools.name,
+    storage_volumes.name,
+    projects.name,
+    nodes.name)
+    FROM storage_volumes JOIN storage_pools ON storage_pool_id=storage_pools.id JOIN nodes ON node_id=nodes.id JOIN projects ON project_id=projects.id WHERE storage_volumes.type=2 UNION
+  SELECT projects.name,
+    printf('/1.0/profiles/%s?project=%s',
+    profiles.name,
+    projects.name)
+    FROM profiles JOIN projects ON project_id=projects.id UNION
+  SELECT projects.name,
+    printf('/1.0/networks/%s?project=%s',
+    networks.name,
+    projects.name)
+    FROM networks JOIN projects ON project_id=projects.id;
+`
+	_, err := tx.Exec(stmts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add project_id column to networks table")
+	}
+
+	return nil
+}
+
+// Add cluster_groups and nodes_cluster_groups tables.
+func updateFromV39(tx *sql.Tx) error {
+	stmts := `
+CREATE TABLE cluster_groups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    UNIQUE (name)
+);
+CREATE TABLE nodes_cluster_groups (
+    node_id INTEGER NOT NULL,
+    group_id INTEGER NOT NULL,
+    FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES cluster_groups (id) ON DELETE CASCADE,
+    UNIQUE (node_id, group_id)
+);
+`
+	_, err := tx.Exec(stmts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add cluster groups tables")
+	}
+
+	return nil
+}
+
+// Add state column to nodes table.
+func updateFromV38(tx *sql.Tx) error {
+	_, err := tx.Exec("ALTER TABLE nodes ADD COLUMN state INTEGER NOT NULL DEFAULT 0")
+	if err != nil {
+		return errors.Wrap(err, "Failed to add state column to nodes table")
+	}
+
+	return nil
+}
+
+// Add networks_reservations table.
+func updateFromV37(tx *sql.Tx) error {
+	stmt := `
+CREATE TABLE networks_reservations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    hwaddr TEXT NOT NULL,
+    ipv4_address TEXT NOT NULL,
+    ipv6_address TEXT NOT NULL,
+    description TEXT NOT NULL,
+    UNIQUE (network_id, hwaddr),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+`
+	_, err := tx.Exec(stmt)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add network reservations table")
+	}
+
+	return nil
+}
+
+// Add networks_peers and networks_peers_config tables.
+func updateFromV36(tx *sql.Tx) error {
+	stmts := `
+CREATE TABLE networks_peers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    target_network_name TEXT NOT NULL,
+    target_network_id INTEGER,
+    UNIQUE (network_id, name),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE,
+    FOREIGN KEY (target_network_id) REFERENCES networks (id) ON DELETE SET NULL
+);
+CREATE TABLE networks_peers_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_peer_id INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    UNIQUE (network_peer_id, key),
+    FOREIGN KEY (network_peer_id) REFERENCES networks_peers (id) ON DELETE CASCADE
+);
+`
+	_, err := tx.Exec(stmts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add network peers tables")
+	}
+
+	return nil
+}
+
+// Add networks_zones and networks_zones_config tables.
+func updateFromV35(tx *sql.Tx) error {
+	stmts := `
+CREATE TABLE networks_zones (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    UNIQUE (name)
+);
+CREATE TABLE networks_zones_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_zone_id INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    UNIQUE (network_zone_id, key),
+    FOREIGN KEY (network_zone_id) REFERENCES networks_zones (id) ON DELETE CASCADE
+);
+`
+	_, err := tx.Exec(stmts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add network zones tables")
+	}
+
+	return nil
+}
+
+// Add networks_acls and networks_acls_config tables.
+func updateFromV34(tx *sql.Tx) error {
+	stmts := `
+CREATE TABLE networks_acls (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    ingress TEXT NOT NULL,
+    egress TEXT NOT NULL,
+    UNIQUE (name)
+);
+CREATE TABLE networks_acls_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_acl_id INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    UNIQUE (network_acl_id, key),
+    FOREIGN KEY (network_acl_id) REFERENCES networks_acls (id) ON DELETE CASCADE
+);
+`
+	_, err := tx.Exec(stmts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add network ACLs tables")
+	}
+
+	return nil
+}
+
+// Add networks_forwards and networks_forwards_config tables.
+func updateFromV33(tx *sql.Tx) error {
+	stmts := `
+CREATE TABLE networks_forwards (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    listen_address TEXT NOT NULL,
+    description TEXT NOT NULL,
+    ports TEXT NOT NULL,
+    UNIQUE (network_id, listen_address),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+CREATE TABLE networks_forwards_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_forward_id INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    UNIQUE (network_forward_id, key),
+    FOREIGN KEY (network_forward_id) REFERENCES networks_forwards (id) ON DELETE CASCADE
+);
+`
+	_, err := tx.Exec(stmts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add network forwards tables")
+	}
+
+	return nil
 }
 
 // Add type field to networks.
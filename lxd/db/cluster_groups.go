@@ -0,0 +1,177 @@
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetClusterGroupNames returns the names of all cluster groups.
+func (c *ClusterTx) GetClusterGroupNames() ([]string, error) {
+	return query.SelectStrings(c.tx, "SELECT name FROM cluster_groups ORDER BY name")
+}
+
+// GetClusterGroup returns the ID and the cluster group with the given name, including its members.
+func (c *ClusterTx) GetClusterGroup(name string) (int64, *api.ClusterGroup, error) {
+	id := int64(-1)
+	group := api.ClusterGroup{Name: name}
+
+	err := c.tx.QueryRow("SELECT id, description FROM cluster_groups WHERE name=?", name).Scan(&id, &group.Description)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, nil, ErrNoSuchObject
+		}
+
+		return -1, nil, err
+	}
+
+	group.Members, err = query.SelectStrings(c.tx, `
+SELECT nodes.name FROM nodes
+  JOIN nodes_cluster_groups ON nodes.id = nodes_cluster_groups.node_id
+ WHERE nodes_cluster_groups.group_id=? ORDER BY nodes.name`, id)
+	if err != nil {
+		return -1, nil, errors.Wrap(err, "Failed to load cluster group members")
+	}
+
+	return id, &group, nil
+}
+
+// CreateClusterGroup creates a new cluster group with the given members.
+func (c *ClusterTx) CreateClusterGroup(name string, description string, members []string) (int64, error) {
+	result, err := c.tx.Exec("INSERT INTO cluster_groups (name, description) VALUES (?, ?)", name, description)
+	if err != nil {
+		return -1, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, err
+	}
+
+	err = c.updateClusterGroupMembers(id, members)
+	if err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+// UpdateClusterGroup updates the description and the members of the cluster group with the given name.
+func (c *ClusterTx) UpdateClusterGroup(name string, description string, members []string) error {
+	id, _, err := c.GetClusterGroup(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.tx.Exec("UPDATE cluster_groups SET description=? WHERE id=?", description, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.tx.Exec("DELETE FROM nodes_cluster_groups WHERE group_id=?", id)
+	if err != nil {
+		return err
+	}
+
+	return c.updateClusterGroupMembers(id, members)
+}
+
+// updateClusterGroupMembers adds the members with the given names to the cluster group with the given ID.
+func (c *ClusterTx) updateClusterGroupMembers(id int64, members []string) error {
+	for _, member := range members {
+		node, err := c.GetNodeByName(member)
+		if err != nil {
+			if err == ErrNoSuchObject {
+				return fmt.Errorf("No cluster member called %q", member)
+			}
+
+			return err
+		}
+
+		_, err = c.tx.Exec("INSERT INTO nodes_cluster_groups (node_id, group_id) VALUES (?, ?)", node.ID, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RenameClusterGroup renames the cluster group with the given name.
+func (c *ClusterTx) RenameClusterGroup(name string, newName string) error {
+	count, err := query.Count(c.tx, "cluster_groups", "name=?", newName)
+	if err != nil {
+		return err
+	}
+
+	if count != 0 {
+		return ErrAlreadyDefined
+	}
+
+	result, err := c.tx.Exec("UPDATE cluster_groups SET name=? WHERE name=?", newName, name)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n != 1 {
+		return ErrNoSuchObject
+	}
+
+	return nil
+}
+
+// DeleteClusterGroup deletes the cluster group with the given name.
+func (c *ClusterTx) DeleteClusterGroup(name string) error {
+	result, err := c.tx.Exec("DELETE FROM cluster_groups WHERE name=?", name)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n != 1 {
+		return ErrNoSuchObject
+	}
+
+	return nil
+}
+
+// UpdateNodeClusterGroups sets the cluster groups of the node with the given ID.
+func (c *ClusterTx) UpdateNodeClusterGroups(id int64, groups []string) error {
+	_, err := c.tx.Exec("DELETE FROM nodes_cluster_groups WHERE node_id=?", id)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		groupID, _, err := c.GetClusterGroup(group)
+		if err != nil {
+			if err == ErrNoSuchObject {
+				return fmt.Errorf("No cluster group called %q", group)
+			}
+
+			return err
+		}
+
+		_, err = c.tx.Exec("INSERT INTO nodes_cluster_groups (node_id, group_id) VALUES (?, ?)", id, groupID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+// +build linux,cgo,!agent
+
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+)
+
+// Cluster groups can be created, updated, renamed and deleted, and are
+// reported as part of the node information.
+func TestClusterGroups(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	_, err := tx.CreateNode("buzz", "1.2.3.4:666")
+	require.NoError(t, err)
+
+	_, err = tx.CreateClusterGroup("rack1", "First rack", []string{"buzz"})
+	require.NoError(t, err)
+
+	_, group, err := tx.GetClusterGroup("rack1")
+	require.NoError(t, err)
+	assert.Equal(t, "First rack", group.Description)
+	assert.Equal(t, []string{"buzz"}, group.Members)
+
+	node, err := tx.GetNodeByName("buzz")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rack1"}, node.Groups)
+
+	err = tx.UpdateClusterGroup("rack1", "", []string{"none"})
+	require.NoError(t, err)
+
+	_, group, err = tx.GetClusterGroup("rack1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"none"}, group.Members)
+
+	err = tx.RenameClusterGroup("rack1", "rack2")
+	require.NoError(t, err)
+
+	names, err := tx.GetClusterGroupNames()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rack2"}, names)
+
+	err = tx.DeleteClusterGroup("rack2")
+	require.NoError(t, err)
+
+	_, _, err = tx.GetClusterGroup("rack2")
+	assert.Equal(t, db.ErrNoSuchObject, err)
+}
+
+// Only nodes part of the requested cluster group are considered.
+func TestGetNodeWithLeastInstances_Group(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	id, err := tx.CreateNode("buzz", "1.2.3.4:666")
+	require.NoError(t, err)
+
+	// Add a container to the newly created node.
+	_, err = tx.Tx().Exec(`
+INSERT INTO instances (id, node_id, name, architecture, type, project_id) VALUES (1, ?, 'foo', 1, 1, 1)
+`, id)
+	require.NoError(t, err)
+
+	_, err = tx.CreateClusterGroup("rack1", "", []string{"buzz"})
+	require.NoError(t, err)
+
+	name, err := tx.GetNodeWithLeastInstances(nil, "rack1")
+	require.NoError(t, err)
+	assert.Equal(t, "buzz", name)
+}
@@ -3,6 +3,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -16,6 +17,7 @@ import (
 	"github.com/lxc/lxd/lxd/db/cluster"
 	"github.com/lxc/lxd/lxd/db/node"
 	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/lxd/metrics"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/logger"
 )
@@ -112,8 +114,14 @@ func (n *Node) Dir() string {
 // function returns no error, all database changes are committed to the
 // node-level database, otherwise they are rolled back.
 func (n *Node) Transaction(f func(*NodeTx) error) error {
+	return n.TransactionContext(context.Background(), f)
+}
+
+// TransactionContext is like Transaction, but the transaction is rolled back
+// if the given context is cancelled before it completes.
+func (n *Node) TransactionContext(ctx context.Context, f func(*NodeTx) error) error {
 	nodeTx := &NodeTx{}
-	return query.Transaction(n.db, func(tx *sql.Tx) error {
+	return query.TransactionContext(ctx, n.db, func(tx *sql.Tx) error {
 		nodeTx.tx = tx
 		return f(nodeTx)
 	})
@@ -343,9 +351,16 @@ func (c *Cluster) GetNodeID() int64 {
 // If EnterExclusive has been called before, calling Transaction will block
 // until ExitExclusive has been called as well to release the lock.
 func (c *Cluster) Transaction(f func(*ClusterTx) error) error {
+	return c.TransactionContext(context.Background(), f)
+}
+
+// TransactionContext is like Transaction, but the transaction is rolled back
+// and no further retries are attempted if the given context is cancelled,
+// e.g. because the client of an API request went away.
+func (c *Cluster) TransactionContext(ctx context.Context, f func(*ClusterTx) error) error {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.transaction(f)
+	return c.transaction(ctx, f)
 }
 
 // EnterExclusive acquires a lock on the cluster db, so any successive call to
@@ -373,17 +388,20 @@ func (c *Cluster) EnterExclusive() error {
 func (c *Cluster) ExitExclusive(f func(*ClusterTx) error) error {
 	logger.Debug("Releasing exclusive lock on cluster db")
 	defer c.mu.Unlock()
-	return c.transaction(f)
+	return c.transaction(context.Background(), f)
 }
 
-func (c *Cluster) transaction(f func(*ClusterTx) error) error {
+func (c *Cluster) transaction(ctx context.Context, f func(*ClusterTx) error) error {
 	clusterTx := &ClusterTx{
 		nodeID: c.nodeID,
 		stmts:  c.stmts,
 	}
 
-	return c.retry(func() error {
-		return query.Transaction(c.db, func(tx *sql.Tx) error {
+	start := time.Now()
+	defer func() { metrics.TrackDBTransaction(time.Since(start)) }()
+
+	return c.retryContext(ctx, func() error {
+		return query.TransactionContext(ctx, c.db, func(tx *sql.Tx) error {
 			clusterTx.tx = tx
 			return f(clusterTx)
 		})
@@ -391,10 +409,14 @@ func (c *Cluster) transaction(f func(*ClusterTx) error) error {
 }
 
 func (c *Cluster) retry(f func() error) error {
+	return c.retryContext(context.Background(), f)
+}
+
+func (c *Cluster) retryContext(ctx context.Context, f func() error) error {
 	if c.closing {
 		return f()
 	}
-	return query.Retry(f)
+	return query.RetryContext(ctx, f)
 }
 
 // NodeID sets the the node NodeID associated with this cluster instance. It's used for
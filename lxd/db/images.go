@@ -269,8 +269,9 @@ type ExpiredImage struct {
 	ProjectName string
 }
 
-// GetExpiredImages returns the names and project name of all images that have expired since the given time.
-func (c *Cluster) GetExpiredImages(expiry int64) ([]ExpiredImage, error) {
+// GetExpiredImages returns the names and project name of all cached images that haven't been used for the
+// number of days set for their project. Images of projects without a positive expiry never expire.
+func (c *Cluster) GetExpiredImages(expiry map[string]int64) ([]ExpiredImage, error) {
 	var images []Image
 	err := c.Transaction(func(tx *ClusterTx) error {
 		var err error
@@ -283,6 +284,11 @@ func (c *Cluster) GetExpiredImages(expiry int64) ([]ExpiredImage, error) {
 
 	results := []ExpiredImage{}
 	for _, r := range images {
+		days := expiry[r.Project]
+		if days <= 0 {
+			continue
+		}
+
 		// Figure out the expiry
 		timestamp := r.UploadDate
 		if !r.LastUseDate.IsZero() {
@@ -290,7 +296,7 @@ func (c *Cluster) GetExpiredImages(expiry int64) ([]ExpiredImage, error) {
 		}
 
 		imageExpiry := timestamp
-		imageExpiry = imageExpiry.Add(time.Duration(expiry*24) * time.Hour)
+		imageExpiry = imageExpiry.Add(time.Duration(days*24) * time.Hour)
 
 		// Check if expired
 		if imageExpiry.After(time.Now()) {
@@ -58,3 +58,31 @@ func TestImageExists(t *testing.T) {
 
 	assert.True(t, exists)
 }
+
+func TestGetExpiredImages(t *testing.T) {
+	cluster, cleanup := db.NewTestCluster(t)
+	defer cleanup()
+
+	err := cluster.CreateImage(
+		"default", "abc", "x.gz", 16, false, false, "amd64", time.Now(), time.Now(), map[string]string{}, "container")
+	require.NoError(t, err)
+
+	require.NoError(t, cluster.InitImageLastUseDate("abc"))
+	require.NoError(t, cluster.UpdateImageLastUseDate("abc", time.Now().Add(-72*time.Hour)))
+
+	images, err := cluster.GetExpiredImages(map[string]int64{"default": 2})
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	assert.Equal(t, "abc", images[0].Fingerprint)
+	assert.Equal(t, "default", images[0].ProjectName)
+
+	// Not expired yet.
+	images, err = cluster.GetExpiredImages(map[string]int64{"default": 5})
+	require.NoError(t, err)
+	assert.Len(t, images, 0)
+
+	// Expiry disabled for the project.
+	images, err = cluster.GetExpiredImages(map[string]int64{"default": 0})
+	require.NoError(t, err)
+	assert.Len(t, images, 0)
+}
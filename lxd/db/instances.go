@@ -324,7 +324,13 @@ SELECT instances.name, nodes.id, nodes.address, nodes.heartbeat
 // Load all instances across all projects and expands their config and devices
 // using the profiles they are associated to.
 func (c *ClusterTx) instanceListExpanded() ([]Instance, error) {
-	instances, err := c.GetInstances(InstanceFilter{})
+	return c.instanceListExpandedWithFilter(InstanceFilter{})
+}
+
+// Load the instances matching the given filter and expands their config and
+// devices using the profiles they are associated to.
+func (c *ClusterTx) instanceListExpandedWithFilter(filter InstanceFilter) ([]Instance, error) {
+	instances, err := c.GetInstances(filter)
 	if err != nil {
 		return nil, errors.Wrap(err, "Load instances")
 	}
@@ -511,6 +517,38 @@ func (c *ClusterTx) UpdateInstanceNode(project, oldName, newName, newNode string
 	return nil
 }
 
+// UpdateInstanceNodeID associates the instance with the given name with another node, keeping its
+// name and storage volumes records untouched.
+//
+// It's used when live moving a VM, once it is running on the new node.
+func (c *ClusterTx) UpdateInstanceNodeID(project, name, newNode string) error {
+	instanceID, err := c.GetInstanceID(project, name)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get instance's ID")
+	}
+
+	node, err := c.GetNodeByName(newNode)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get new node's info")
+	}
+
+	result, err := c.tx.Exec("UPDATE instances SET node_id=? WHERE id=?", node.ID, instanceID)
+	if err != nil {
+		return errors.Wrap(err, "Failed to update instance's node ID")
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get rows affected by instance update")
+	}
+
+	if n != 1 {
+		return fmt.Errorf("Unexpected number of updated rows in instances table: %d", n)
+	}
+
+	return nil
+}
+
 // GetLocalInstancesInProject retuurns all instances of the given type on the
 // local node within the given project.
 func (c *ClusterTx) GetLocalInstancesInProject(project string, instanceType instancetype.Type) ([]Instance, error) {
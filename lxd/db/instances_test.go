@@ -349,6 +349,29 @@ func TestGetInstanceToNodeMap(t *testing.T) {
 		}, result)
 }
 
+// An instance can be associated with another node.
+func TestUpdateInstanceNodeID(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	nodeID1 := int64(1) // This is the default local node
+
+	_, err := tx.CreateNode("node2", "1.2.3.4:666")
+	require.NoError(t, err)
+
+	addContainer(t, tx, nodeID1, "c1")
+
+	err = tx.UpdateInstanceNodeID("default", "c1", "node2")
+	require.NoError(t, err)
+
+	result, err := tx.GetInstanceToNodeMap("default", instancetype.Container)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"c1": "node2"}, result)
+
+	err = tx.UpdateInstanceNodeID("default", "c1", "node3")
+	assert.Error(t, err)
+}
+
 func TestGetInstancePool(t *testing.T) {
 	cluster, cleanup := db.NewTestCluster(t)
 	defer cleanup()
@@ -0,0 +1,196 @@
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkACLs returns the names of all the network ACLs.
+func (c *Cluster) GetNetworkACLs() ([]string, error) {
+	var names []string
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var err error
+		names, err = query.SelectStrings(tx.tx, "SELECT name FROM networks_acls ORDER BY name")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// GetNetworkACL returns the ID and the ACL with the given name.
+func (c *Cluster) GetNetworkACL(name string) (int64, *api.NetworkACL, error) {
+	id := int64(-1)
+	acl := api.NetworkACL{
+		NetworkACLPost: api.NetworkACLPost{
+			Name: name,
+		},
+	}
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var ingress, egress string
+
+		q := "SELECT id, description, ingress, egress FROM networks_acls WHERE name=?"
+		err := tx.tx.QueryRow(q, name).Scan(&id, &acl.Description, &ingress, &egress)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNoSuchObject
+			}
+
+			return err
+		}
+
+		err = json.Unmarshal([]byte(ingress), &acl.Ingress)
+		if err != nil {
+			return errors.Wrapf(err, "Failed unmarshalling ingress rules of network ACL %q", name)
+		}
+
+		err = json.Unmarshal([]byte(egress), &acl.Egress)
+		if err != nil {
+			return errors.Wrapf(err, "Failed unmarshalling egress rules of network ACL %q", name)
+		}
+
+		acl.Config, err = query.SelectConfig(tx.tx, "networks_acls_config", "network_acl_id=?", id)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return -1, nil, err
+	}
+
+	return id, &acl, nil
+}
+
+// CreateNetworkACL creates a new network ACL.
+func (c *Cluster) CreateNetworkACL(info *api.NetworkACLsPost) (int64, error) {
+	var id int64
+
+	ingress, egress, err := networkACLRulesMarshal(&info.NetworkACLPut)
+	if err != nil {
+		return -1, err
+	}
+
+	err = c.Transaction(func(tx *ClusterTx) error {
+		result, err := tx.tx.Exec("INSERT INTO networks_acls (name, description, ingress, egress) VALUES (?, ?, ?, ?)", info.Name, info.Description, ingress, egress)
+		if err != nil {
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		return networkACLConfigAdd(tx.tx, id, info.Config)
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+// UpdateNetworkACL updates the network ACL with the given ID.
+func (c *Cluster) UpdateNetworkACL(id int64, info *api.NetworkACLPut) error {
+	ingress, egress, err := networkACLRulesMarshal(info)
+	if err != nil {
+		return err
+	}
+
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE networks_acls SET description=?, ingress=?, egress=? WHERE id=?", info.Description, ingress, egress, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.tx.Exec("DELETE FROM networks_acls_config WHERE network_acl_id=?", id)
+		if err != nil {
+			return err
+		}
+
+		return networkACLConfigAdd(tx.tx, id, info.Config)
+	})
+}
+
+// RenameNetworkACL renames the network ACL with the given ID.
+func (c *Cluster) RenameNetworkACL(id int64, newName string) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE networks_acls SET name=? WHERE id=?", newName, id)
+		return err
+	})
+}
+
+// DeleteNetworkACL deletes the network ACL with the given ID.
+func (c *Cluster) DeleteNetworkACL(id int64) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		deleted, err := query.DeleteObject(tx.tx, "networks_acls", id)
+		if err != nil {
+			return err
+		}
+
+		if !deleted {
+			return ErrNoSuchObject
+		}
+
+		return nil
+	})
+}
+
+// networkACLRulesMarshal returns the JSON encoded ingress and egress rules of the ACL.
+func networkACLRulesMarshal(info *api.NetworkACLPut) (string, string, error) {
+	ingress := info.Ingress
+	if ingress == nil {
+		ingress = []api.NetworkACLRule{}
+	}
+
+	egress := info.Egress
+	if egress == nil {
+		egress = []api.NetworkACLRule{}
+	}
+
+	ingressJSON, err := json.Marshal(ingress)
+	if err != nil {
+		return "", "", errors.Wrap(err, "Failed marshalling ingress rules")
+	}
+
+	egressJSON, err := json.Marshal(egress)
+	if err != nil {
+		return "", "", errors.Wrap(err, "Failed marshalling egress rules")
+	}
+
+	return string(ingressJSON), string(egressJSON), nil
+}
+
+func networkACLConfigAdd(tx *sql.Tx, id int64, config map[string]string) error {
+	stmt, err := tx.Prepare("INSERT INTO networks_acls_config (network_acl_id, key, value) VALUES(?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for k, v := range config {
+		if v == "" {
+			continue
+		}
+
+		_, err = stmt.Exec(id, k, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
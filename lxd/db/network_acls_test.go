@@ -0,0 +1,65 @@
+// +build linux,cgo,!agent
+
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Network ACLs can be created, fetched, updated, renamed and deleted.
+func TestNetworkACLs(t *testing.T) {
+	cluster, cleanup := db.NewTestCluster(t)
+	defer cleanup()
+
+	acl := api.NetworkACLsPost{
+		NetworkACLPost: api.NetworkACLPost{Name: "web"},
+		NetworkACLPut: api.NetworkACLPut{
+			Description: "Web servers",
+			Config:      map[string]string{"user.foo": "bar"},
+			Ingress: []api.NetworkACLRule{
+				{Action: "allow", Protocol: "tcp", DestinationPort: "80,443", State: "enabled"},
+			},
+		},
+	}
+
+	id, err := cluster.CreateNetworkACL(&acl)
+	require.NoError(t, err)
+
+	names, err := cluster.GetNetworkACLs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web"}, names)
+
+	aclID, info, err := cluster.GetNetworkACL("web")
+	require.NoError(t, err)
+	assert.Equal(t, id, aclID)
+	assert.Equal(t, "Web servers", info.Description)
+	assert.Equal(t, "bar", info.Config["user.foo"])
+	assert.Equal(t, acl.Ingress, info.Ingress)
+	assert.Empty(t, info.Egress)
+
+	put := info.Writable()
+	put.Config = map[string]string{}
+	put.Ingress = nil
+	err = cluster.UpdateNetworkACL(id, &put)
+	require.NoError(t, err)
+
+	err = cluster.RenameNetworkACL(id, "www")
+	require.NoError(t, err)
+
+	_, info, err = cluster.GetNetworkACL("www")
+	require.NoError(t, err)
+	assert.Empty(t, info.Config)
+	assert.Empty(t, info.Ingress)
+
+	err = cluster.DeleteNetworkACL(id)
+	require.NoError(t, err)
+
+	_, _, err = cluster.GetNetworkACL("www")
+	assert.Equal(t, db.ErrNoSuchObject, err)
+}
@@ -0,0 +1,178 @@
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkForwardListenAddresses returns the listen addresses of all the forwards of the network with the
+// given ID.
+func (c *Cluster) GetNetworkForwardListenAddresses(networkID int64) ([]string, error) {
+	var addresses []string
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var err error
+		addresses, err = query.SelectStrings(tx.tx, "SELECT listen_address FROM networks_forwards WHERE network_id=? ORDER BY id", networkID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
+
+// GetNetworkForwards returns all the forwards of the network with the given ID.
+func (c *Cluster) GetNetworkForwards(networkID int64) ([]*api.NetworkForward, error) {
+	addresses, err := c.GetNetworkForwardListenAddresses(networkID)
+	if err != nil {
+		return nil, err
+	}
+
+	forwards := make([]*api.NetworkForward, 0, len(addresses))
+	for _, address := range addresses {
+		_, forward, err := c.GetNetworkForward(networkID, address)
+		if err != nil {
+			return nil, err
+		}
+
+		forwards = append(forwards, forward)
+	}
+
+	return forwards, nil
+}
+
+// GetNetworkForward returns the ID and the forward of the network with the given ID listening on the given
+// address.
+func (c *Cluster) GetNetworkForward(networkID int64, listenAddress string) (int64, *api.NetworkForward, error) {
+	id := int64(-1)
+	forward := api.NetworkForward{
+		ListenAddress: listenAddress,
+	}
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var ports string
+
+		q := "SELECT id, description, ports FROM networks_forwards WHERE network_id=? AND listen_address=?"
+		err := tx.tx.QueryRow(q, networkID, listenAddress).Scan(&id, &forward.Description, &ports)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNoSuchObject
+			}
+
+			return err
+		}
+
+		err = json.Unmarshal([]byte(ports), &forward.Ports)
+		if err != nil {
+			return errors.Wrapf(err, "Failed unmarshalling ports of network forward %q", listenAddress)
+		}
+
+		forward.Config, err = query.SelectConfig(tx.tx, "networks_forwards_config", "network_forward_id=?", id)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return -1, nil, err
+	}
+
+	return id, &forward, nil
+}
+
+// CreateNetworkForward creates a new forward of the network with the given ID.
+func (c *Cluster) CreateNetworkForward(networkID int64, info *api.NetworkForwardsPost) (int64, error) {
+	var id int64
+
+	ports, err := json.Marshal(info.Ports)
+	if err != nil {
+		return -1, errors.Wrap(err, "Failed marshalling ports")
+	}
+
+	err = c.Transaction(func(tx *ClusterTx) error {
+		result, err := tx.tx.Exec("INSERT INTO networks_forwards (network_id, listen_address, description, ports) VALUES (?, ?, ?, ?)", networkID, info.ListenAddress, info.Description, string(ports))
+		if err != nil {
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		return networkForwardConfigAdd(tx.tx, id, info.Config)
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+// UpdateNetworkForward updates the forward with the given ID.
+func (c *Cluster) UpdateNetworkForward(forwardID int64, info *api.NetworkForwardPut) error {
+	ports, err := json.Marshal(info.Ports)
+	if err != nil {
+		return errors.Wrap(err, "Failed marshalling ports")
+	}
+
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE networks_forwards SET description=?, ports=? WHERE id=?", info.Description, string(ports), forwardID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.tx.Exec("DELETE FROM networks_forwards_config WHERE network_forward_id=?", forwardID)
+		if err != nil {
+			return err
+		}
+
+		return networkForwardConfigAdd(tx.tx, forwardID, info.Config)
+	})
+}
+
+// DeleteNetworkForward deletes the forward with the given ID.
+func (c *Cluster) DeleteNetworkForward(forwardID int64) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		deleted, err := query.DeleteObject(tx.tx, "networks_forwards", forwardID)
+		if err != nil {
+			return err
+		}
+
+		if !deleted {
+			return ErrNoSuchObject
+		}
+
+		return nil
+	})
+}
+
+func networkForwardConfigAdd(tx *sql.Tx, forwardID int64, config map[string]string) error {
+	stmt, err := tx.Prepare("INSERT INTO networks_forwards_config (network_forward_id, key, value) VALUES(?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for k, v := range config {
+		if v == "" {
+			continue
+		}
+
+		_, err = stmt.Exec(forwardID, k, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
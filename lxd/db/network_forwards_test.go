@@ -0,0 +1,65 @@
+// +build linux,cgo,!agent
+
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Network forwards can be created, fetched, updated and deleted.
+func TestNetworkForwards(t *testing.T) {
+	cluster, cleanup := db.NewTestCluster(t)
+	defer cleanup()
+
+	networkID, err := cluster.CreateNetwork("default", "lxdbr0", "", db.NetworkTypeBridge, nil)
+	require.NoError(t, err)
+
+	forward := api.NetworkForwardsPost{
+		ListenAddress: "192.0.2.1",
+		NetworkForwardPut: api.NetworkForwardPut{
+			Description: "web",
+			Config:      map[string]string{"target_address": "10.0.0.2"},
+			Ports: []api.NetworkForwardPort{
+				{Protocol: "tcp", ListenPort: "80", TargetAddress: "10.0.0.3"},
+			},
+		},
+	}
+
+	forwardID, err := cluster.CreateNetworkForward(networkID, &forward)
+	require.NoError(t, err)
+
+	addresses, err := cluster.GetNetworkForwardListenAddresses(networkID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.0.2.1"}, addresses)
+
+	id, info, err := cluster.GetNetworkForward(networkID, "192.0.2.1")
+	require.NoError(t, err)
+	assert.Equal(t, forwardID, id)
+	assert.Equal(t, "web", info.Description)
+	assert.Equal(t, "10.0.0.2", info.Config["target_address"])
+	assert.Equal(t, forward.Ports, info.Ports)
+
+	put := info.Writable()
+	put.Config = map[string]string{}
+	put.Ports = []api.NetworkForwardPort{}
+	err = cluster.UpdateNetworkForward(forwardID, &put)
+	require.NoError(t, err)
+
+	forwards, err := cluster.GetNetworkForwards(networkID)
+	require.NoError(t, err)
+	require.Len(t, forwards, 1)
+	assert.Empty(t, forwards[0].Config)
+	assert.Empty(t, forwards[0].Ports)
+
+	err = cluster.DeleteNetworkForward(forwardID)
+	require.NoError(t, err)
+
+	_, _, err = cluster.GetNetworkForward(networkID, "192.0.2.1")
+	assert.Equal(t, db.ErrNoSuchObject, err)
+}
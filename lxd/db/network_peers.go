@@ -0,0 +1,242 @@
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkPeerNames returns the names of all the peerings of the network with the given ID.
+func (c *Cluster) GetNetworkPeerNames(networkID int64) ([]string, error) {
+	var names []string
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var err error
+		names, err = query.SelectStrings(tx.tx, "SELECT name FROM networks_peers WHERE network_id=? ORDER BY id", networkID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// GetNetworkPeers returns all the peerings of the network with the given ID.
+func (c *Cluster) GetNetworkPeers(networkID int64) ([]*api.NetworkPeer, error) {
+	names, err := c.GetNetworkPeerNames(networkID)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]*api.NetworkPeer, 0, len(names))
+	for _, name := range names {
+		_, peer, err := c.GetNetworkPeer(networkID, name)
+		if err != nil {
+			return nil, err
+		}
+
+		peers = append(peers, peer)
+	}
+
+	return peers, nil
+}
+
+// GetNetworkPeer returns the ID and the peering with the given name of the network with the given ID.
+// The peering is in the Created state once the target network has a matching peering back to this network,
+// and in the Pending state otherwise.
+func (c *Cluster) GetNetworkPeer(networkID int64, name string) (int64, *api.NetworkPeer, error) {
+	id := int64(-1)
+	peer := api.NetworkPeer{
+		Name: name,
+	}
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var targetNetworkID sql.NullInt64
+
+		q := "SELECT id, description, target_network_name, target_network_id FROM networks_peers WHERE network_id=? AND name=?"
+		err := tx.tx.QueryRow(q, networkID, name).Scan(&id, &peer.Description, &peer.TargetNetwork, &targetNetworkID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNoSuchObject
+			}
+
+			return err
+		}
+
+		peer.Status = api.NetworkStatusPending
+		if targetNetworkID.Valid {
+			peer.Status = api.NetworkStatusCreated
+		}
+
+		peer.Config, err = query.SelectConfig(tx.tx, "networks_peers_config", "network_peer_id=?", id)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return -1, nil, err
+	}
+
+	return id, &peer, nil
+}
+
+// GetNetworkPeerTarget returns the ID of the target network of the peering with the given ID, or -1 if the
+// peering is still pending.
+func (c *Cluster) GetNetworkPeerTarget(peerID int64) (int64, error) {
+	var targetNetworkID sql.NullInt64
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		err := tx.tx.QueryRow("SELECT target_network_id FROM networks_peers WHERE id=?", peerID).Scan(&targetNetworkID)
+		if err == sql.ErrNoRows {
+			return ErrNoSuchObject
+		}
+
+		return err
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	if !targetNetworkID.Valid {
+		return -1, nil
+	}
+
+	return targetNetworkID.Int64, nil
+}
+
+// CreateNetworkPeer creates a new peering of the network with the given ID. If the target network already has a
+// pending peering back to this network, both peerings are linked and mutual is returned as true.
+func (c *Cluster) CreateNetworkPeer(networkID int64, info *api.NetworkPeersPost) (int64, bool, error) {
+	var id int64
+	var mutual bool
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		result, err := tx.tx.Exec("INSERT INTO networks_peers (network_id, name, description, target_network_name) VALUES (?, ?, ?, ?)", networkID, info.Name, info.Description, info.TargetNetwork)
+		if err != nil {
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		err = networkPeerConfigAdd(tx.tx, id, info.Config)
+		if err != nil {
+			return err
+		}
+
+		// Look for a pending peering of the target network pointing back at this network.
+		var targetNetworkID, targetPeerID int64
+		q := `
+SELECT networks_peers.network_id, networks_peers.id
+  FROM networks_peers
+  JOIN networks ON networks.id = networks_peers.network_id
+ WHERE networks.name = ?
+   AND networks.project_id = (SELECT project_id FROM networks WHERE id = ?)
+   AND networks_peers.target_network_name = (SELECT name FROM networks WHERE id = ?)
+   AND networks_peers.target_network_id IS NULL
+`
+		err = tx.tx.QueryRow(q, info.TargetNetwork, networkID, networkID).Scan(&targetNetworkID, &targetPeerID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil
+			}
+
+			return err
+		}
+
+		_, err = tx.tx.Exec("UPDATE networks_peers SET target_network_id=? WHERE id=?", targetNetworkID, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.tx.Exec("UPDATE networks_peers SET target_network_id=? WHERE id=?", networkID, targetPeerID)
+		if err != nil {
+			return err
+		}
+
+		mutual = true
+		return nil
+	})
+	if err != nil {
+		return -1, false, err
+	}
+
+	return id, mutual, nil
+}
+
+// UpdateNetworkPeer updates the peering with the given ID.
+func (c *Cluster) UpdateNetworkPeer(peerID int64, info *api.NetworkPeerPut) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE networks_peers SET description=? WHERE id=?", info.Description, peerID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.tx.Exec("DELETE FROM networks_peers_config WHERE network_peer_id=?", peerID)
+		if err != nil {
+			return err
+		}
+
+		return networkPeerConfigAdd(tx.tx, peerID, info.Config)
+	})
+}
+
+// DeleteNetworkPeer deletes the peering with the given ID. The matching peering of the target network, if any,
+// goes back to the Pending state.
+func (c *Cluster) DeleteNetworkPeer(peerID int64) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		q := `
+UPDATE networks_peers SET target_network_id = NULL
+ WHERE id IN (
+	SELECT other.id
+	  FROM networks_peers AS other
+	  JOIN networks_peers AS peer ON peer.target_network_id = other.network_id AND other.target_network_id = peer.network_id
+	 WHERE peer.id = ?
+ )
+`
+		_, err := tx.tx.Exec(q, peerID)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := query.DeleteObject(tx.tx, "networks_peers", peerID)
+		if err != nil {
+			return err
+		}
+
+		if !deleted {
+			return ErrNoSuchObject
+		}
+
+		return nil
+	})
+}
+
+func networkPeerConfigAdd(tx *sql.Tx, peerID int64, config map[string]string) error {
+	stmt, err := tx.Prepare("INSERT INTO networks_peers_config (network_peer_id, key, value) VALUES(?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for k, v := range config {
+		if v == "" {
+			continue
+		}
+
+		_, err = stmt.Exec(peerID, k, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
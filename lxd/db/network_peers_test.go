@@ -0,0 +1,73 @@
+// +build linux,cgo,!agent
+
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Network peerings become mutual once both sides exist and go back to pending when one side is deleted.
+func TestNetworkPeers(t *testing.T) {
+	cluster, cleanup := db.NewTestCluster(t)
+	defer cleanup()
+
+	net1ID, err := cluster.CreateNetwork("default", "ovn1", "", db.NetworkTypeOVN, nil)
+	require.NoError(t, err)
+
+	net2ID, err := cluster.CreateNetwork("default", "ovn2", "", db.NetworkTypeOVN, nil)
+	require.NoError(t, err)
+
+	peer1 := api.NetworkPeersPost{
+		Name:           "to-ovn2",
+		TargetNetwork:  "ovn2",
+		NetworkPeerPut: api.NetworkPeerPut{Description: "first", Config: map[string]string{"user.foo": "bar"}},
+	}
+
+	peer1ID, mutual, err := cluster.CreateNetworkPeer(net1ID, &peer1)
+	require.NoError(t, err)
+	assert.False(t, mutual)
+
+	_, info, err := cluster.GetNetworkPeer(net1ID, "to-ovn2")
+	require.NoError(t, err)
+	assert.Equal(t, api.NetworkStatusPending, info.Status)
+	assert.Equal(t, "bar", info.Config["user.foo"])
+
+	peer2 := api.NetworkPeersPost{Name: "to-ovn1", TargetNetwork: "ovn1"}
+	peer2ID, mutual, err := cluster.CreateNetworkPeer(net2ID, &peer2)
+	require.NoError(t, err)
+	assert.True(t, mutual)
+
+	peers, err := cluster.GetNetworkPeers(net1ID)
+	require.NoError(t, err)
+	require.Len(t, peers, 1)
+	assert.Equal(t, api.NetworkStatusCreated, peers[0].Status)
+
+	targetID, err := cluster.GetNetworkPeerTarget(peer2ID)
+	require.NoError(t, err)
+	assert.Equal(t, net1ID, targetID)
+
+	put := info.Writable()
+	put.Config = map[string]string{}
+	err = cluster.UpdateNetworkPeer(peer1ID, &put)
+	require.NoError(t, err)
+
+	err = cluster.DeleteNetworkPeer(peer1ID)
+	require.NoError(t, err)
+
+	names, err := cluster.GetNetworkPeerNames(net1ID)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	_, info, err = cluster.GetNetworkPeer(net2ID, "to-ovn1")
+	require.NoError(t, err)
+	assert.Equal(t, api.NetworkStatusPending, info.Status)
+
+	err = cluster.DeleteNetworkPeer(peer1ID)
+	assert.Equal(t, db.ErrNoSuchObject, err)
+}
@@ -0,0 +1,104 @@
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkReservations returns all the DHCP reservations of the network with the given ID.
+func (c *Cluster) GetNetworkReservations(networkID int64) ([]*api.NetworkReservation, error) {
+	reservations := []*api.NetworkReservation{}
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		q := "SELECT hwaddr, ipv4_address, ipv6_address, description FROM networks_reservations WHERE network_id=? ORDER BY id"
+		rows, err := tx.tx.Query(q, networkID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			reservation := api.NetworkReservation{}
+
+			err = rows.Scan(&reservation.MAC, &reservation.IPv4Address, &reservation.IPv6Address, &reservation.Description)
+			if err != nil {
+				return err
+			}
+
+			reservations = append(reservations, &reservation)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// GetNetworkReservation returns the ID and the DHCP reservation of the network with the given ID for the given
+// MAC address.
+func (c *Cluster) GetNetworkReservation(networkID int64, hwaddr string) (int64, *api.NetworkReservation, error) {
+	id := int64(-1)
+	reservation := api.NetworkReservation{}
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		q := "SELECT id, hwaddr, ipv4_address, ipv6_address, description FROM networks_reservations WHERE network_id=? AND hwaddr=?"
+		err := tx.tx.QueryRow(q, networkID, hwaddr).Scan(&id, &reservation.MAC, &reservation.IPv4Address, &reservation.IPv6Address, &reservation.Description)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNoSuchObject
+			}
+
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return -1, nil, err
+	}
+
+	return id, &reservation, nil
+}
+
+// CreateNetworkReservation creates a new DHCP reservation on the network with the given ID.
+func (c *Cluster) CreateNetworkReservation(networkID int64, info *api.NetworkReservation) (int64, error) {
+	var id int64
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		result, err := tx.tx.Exec("INSERT INTO networks_reservations (network_id, hwaddr, ipv4_address, ipv6_address, description) VALUES (?, ?, ?, ?, ?)", networkID, info.MAC, info.IPv4Address, info.IPv6Address, info.Description)
+		if err != nil {
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		return err
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+// DeleteNetworkReservation deletes the DHCP reservation with the given ID.
+func (c *Cluster) DeleteNetworkReservation(reservationID int64) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		deleted, err := query.DeleteObject(tx.tx, "networks_reservations", reservationID)
+		if err != nil {
+			return err
+		}
+
+		if !deleted {
+			return ErrNoSuchObject
+		}
+
+		return nil
+	})
+}
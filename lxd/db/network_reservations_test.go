@@ -0,0 +1,49 @@
+// +build linux,cgo,!agent
+
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Network DHCP reservations can be created, fetched and deleted.
+func TestNetworkReservations(t *testing.T) {
+	cluster, cleanup := db.NewTestCluster(t)
+	defer cleanup()
+
+	networkID, err := cluster.CreateNetwork("default", "lxdbr0", "", db.NetworkTypeBridge, nil)
+	require.NoError(t, err)
+
+	reservation := api.NetworkReservation{
+		Description: "router",
+		MAC:         "00:16:3e:00:00:01",
+		IPv4Address: "10.0.0.2",
+	}
+
+	reservationID, err := cluster.CreateNetworkReservation(networkID, &reservation)
+	require.NoError(t, err)
+
+	_, err = cluster.CreateNetworkReservation(networkID, &reservation)
+	assert.Error(t, err)
+
+	id, info, err := cluster.GetNetworkReservation(networkID, "00:16:3e:00:00:01")
+	require.NoError(t, err)
+	assert.Equal(t, reservationID, id)
+	assert.Equal(t, reservation, *info)
+
+	reservations, err := cluster.GetNetworkReservations(networkID)
+	require.NoError(t, err)
+	assert.Equal(t, []*api.NetworkReservation{&reservation}, reservations)
+
+	err = cluster.DeleteNetworkReservation(reservationID)
+	require.NoError(t, err)
+
+	_, _, err = cluster.GetNetworkReservation(networkID, "00:16:3e:00:00:01")
+	assert.Equal(t, db.ErrNoSuchObject, err)
+}
@@ -0,0 +1,180 @@
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkZones returns the names of all the network zones.
+func (c *Cluster) GetNetworkZones() ([]string, error) {
+	var names []string
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var err error
+		names, err = query.SelectStrings(tx.tx, "SELECT name FROM networks_zones ORDER BY name")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// GetNetworkZone returns the ID and the zone with the given name.
+func (c *Cluster) GetNetworkZone(name string) (int64, *api.NetworkZone, error) {
+	id := int64(-1)
+	zone := api.NetworkZone{
+		Name: name,
+	}
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		err := tx.tx.QueryRow("SELECT id, description FROM networks_zones WHERE name=?", name).Scan(&id, &zone.Description)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNoSuchObject
+			}
+
+			return err
+		}
+
+		zone.Config, err = query.SelectConfig(tx.tx, "networks_zones_config", "network_zone_id=?", id)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return -1, nil, err
+	}
+
+	return id, &zone, nil
+}
+
+// GetNetworkZoneKeys returns a map of TSIG key names to secrets for all the peers of all the zones.
+// The key names are in the form "<zone>_<peer>." as expected by the DNS library.
+func (c *Cluster) GetNetworkZoneKeys() (map[string]string, error) {
+	secrets := map[string]string{}
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		q := `
+SELECT networks_zones.name, networks_zones_config.key, networks_zones_config.value
+  FROM networks_zones_config
+  JOIN networks_zones ON networks_zones.id=networks_zones_config.network_zone_id
+ WHERE networks_zones_config.key LIKE 'peers.%.key'
+`
+		rows, err := tx.tx.Query(q)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var zone, key, value string
+
+			err = rows.Scan(&zone, &key, &value)
+			if err != nil {
+				return err
+			}
+
+			fields := strings.SplitN(key, ".", 3)
+			if len(fields) != 3 {
+				continue
+			}
+
+			secrets[fmt.Sprintf("%s_%s.", zone, fields[1])] = value
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// CreateNetworkZone creates a new network zone.
+func (c *Cluster) CreateNetworkZone(info *api.NetworkZonesPost) (int64, error) {
+	var id int64
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		result, err := tx.tx.Exec("INSERT INTO networks_zones (name, description) VALUES (?, ?)", info.Name, info.Description)
+		if err != nil {
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		return networkZoneConfigAdd(tx.tx, id, info.Config)
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+// UpdateNetworkZone updates the network zone with the given ID.
+func (c *Cluster) UpdateNetworkZone(id int64, info *api.NetworkZonePut) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE networks_zones SET description=? WHERE id=?", info.Description, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.tx.Exec("DELETE FROM networks_zones_config WHERE network_zone_id=?", id)
+		if err != nil {
+			return err
+		}
+
+		return networkZoneConfigAdd(tx.tx, id, info.Config)
+	})
+}
+
+// DeleteNetworkZone deletes the network zone with the given ID.
+func (c *Cluster) DeleteNetworkZone(id int64) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		deleted, err := query.DeleteObject(tx.tx, "networks_zones", id)
+		if err != nil {
+			return err
+		}
+
+		if !deleted {
+			return ErrNoSuchObject
+		}
+
+		return nil
+	})
+}
+
+func networkZoneConfigAdd(tx *sql.Tx, id int64, config map[string]string) error {
+	stmt, err := tx.Prepare("INSERT INTO networks_zones_config (network_zone_id, key, value) VALUES(?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for k, v := range config {
+		if v == "" {
+			continue
+		}
+
+		_, err = stmt.Exec(id, k, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+// +build linux,cgo,!agent
+
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Network zones can be created, fetched, updated and deleted.
+func TestNetworkZones(t *testing.T) {
+	cluster, cleanup := db.NewTestCluster(t)
+	defer cleanup()
+
+	zone := api.NetworkZonesPost{
+		Name: "lxd.example.net",
+		NetworkZonePut: api.NetworkZonePut{
+			Description: "Instances",
+			Config: map[string]string{
+				"peers.ns1.address": "192.0.2.1",
+				"peers.ns1.key":     "c2VjcmV0",
+				"peers.ns2.address": "192.0.2.2",
+			},
+		},
+	}
+
+	id, err := cluster.CreateNetworkZone(&zone)
+	require.NoError(t, err)
+
+	names, err := cluster.GetNetworkZones()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lxd.example.net"}, names)
+
+	zoneID, info, err := cluster.GetNetworkZone("lxd.example.net")
+	require.NoError(t, err)
+	assert.Equal(t, id, zoneID)
+	assert.Equal(t, "Instances", info.Description)
+	assert.Equal(t, "192.0.2.1", info.Config["peers.ns1.address"])
+
+	keys, err := cluster.GetNetworkZoneKeys()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"lxd.example.net_ns1.": "c2VjcmV0"}, keys)
+
+	put := info.Writable()
+	put.Config = map[string]string{}
+	err = cluster.UpdateNetworkZone(id, &put)
+	require.NoError(t, err)
+
+	_, info, err = cluster.GetNetworkZone("lxd.example.net")
+	require.NoError(t, err)
+	assert.Empty(t, info.Config)
+
+	err = cluster.DeleteNetworkZone(id)
+	require.NoError(t, err)
+
+	_, _, err = cluster.GetNetworkZone("lxd.example.net")
+	assert.Equal(t, db.ErrNoSuchObject, err)
+}
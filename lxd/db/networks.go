@@ -7,25 +7,33 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/pkg/errors"
+
 	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 )
 
-// GetNetworksLocalConfig returns a map associating each network name to its
-// node-specific config values on the local node (i.e. the ones where node_id
-// equals the ID of the local node).
+// GetNetworksLocalConfig returns a map associating each network name of the
+// default project to its node-specific config values on the local node (i.e.
+// the ones where node_id equals the ID of the local node).
+//
+// Networks of other projects don't have node-specific config values.
 func (c *ClusterTx) GetNetworksLocalConfig() (map[string]map[string]string, error) {
-	names, err := query.SelectStrings(c.tx, "SELECT name FROM networks")
+	names, err := query.SelectStrings(c.tx, `
+SELECT networks.name FROM networks
+  JOIN projects ON projects.id = networks.project_id
+  WHERE projects.name = ?`, "default")
 	if err != nil {
 		return nil, err
 	}
 	networks := make(map[string]map[string]string, len(names))
 	for _, name := range names {
-		table := "networks_config JOIN networks ON networks.id=networks_config.network_id"
+		table := "networks_config JOIN networks ON networks.id=networks_config.network_id JOIN projects ON projects.id=networks.project_id"
 		config, err := query.SelectConfig(
-			c.tx, table, "networks.name=? AND networks_config.node_id=?",
-			name, c.nodeID)
+			c.tx, table, "projects.name=? AND networks.name=? AND networks_config.node_id=?",
+			"default", name, c.nodeID)
 		if err != nil {
 			return nil, err
 		}
@@ -34,23 +42,29 @@ func (c *ClusterTx) GetNetworksLocalConfig() (map[string]map[string]string, erro
 	return networks, nil
 }
 
-// GetNonPendingNetworkIDs returns a map associating each network name to its ID.
+// GetNonPendingNetworkIDs returns a map associating each project name to a
+// map of its network names to their ID.
 //
 // Pending networks are skipped.
-func (c *ClusterTx) GetNonPendingNetworkIDs() (map[string]int64, error) {
+func (c *ClusterTx) GetNonPendingNetworkIDs() (map[string]map[string]int64, error) {
 	networks := []struct {
-		id   int64
-		name string
+		id      int64
+		project string
+		name    string
 	}{}
 	dest := func(i int) []interface{} {
 		networks = append(networks, struct {
-			id   int64
-			name string
+			id      int64
+			project string
+			name    string
 		}{})
-		return []interface{}{&networks[i].id, &networks[i].name}
+		return []interface{}{&networks[i].id, &networks[i].project, &networks[i].name}
 
 	}
-	stmt, err := c.tx.Prepare("SELECT id, name FROM networks WHERE NOT state=?")
+	stmt, err := c.tx.Prepare(`
+SELECT networks.id, projects.name, networks.name FROM networks
+  JOIN projects ON projects.id = networks.project_id
+  WHERE NOT networks.state=?`)
 	if err != nil {
 		return nil, err
 	}
@@ -59,17 +73,33 @@ func (c *ClusterTx) GetNonPendingNetworkIDs() (map[string]int64, error) {
 	if err != nil {
 		return nil, err
 	}
-	ids := map[string]int64{}
+	ids := map[string]map[string]int64{}
 	for _, network := range networks {
-		ids[network.name] = network.id
+		if ids[network.project] == nil {
+			ids[network.project] = map[string]int64{}
+		}
+		ids[network.project][network.name] = network.id
 	}
 	return ids, nil
 }
 
-// GetNetworkID returns the ID of the network with the given name.
-func (c *ClusterTx) GetNetworkID(name string) (int64, error) {
-	stmt := "SELECT id FROM networks WHERE name=?"
-	ids, err := query.SelectIntegers(c.tx, stmt, name)
+// GetNetworkNames returns the names of all networks in the given project.
+func (c *ClusterTx) GetNetworkNames(project string) ([]string, error) {
+	stmt := `
+SELECT networks.name FROM networks
+  JOIN projects ON projects.id = networks.project_id
+  WHERE projects.name = ? ORDER BY networks.name`
+	return query.SelectStrings(c.tx, stmt, project)
+}
+
+// GetNetworkID returns the ID of the network with the given name in the given
+// project.
+func (c *ClusterTx) GetNetworkID(project string, name string) (int64, error) {
+	stmt := `
+SELECT networks.id FROM networks
+  JOIN projects ON projects.id = networks.project_id
+  WHERE projects.name = ? AND networks.name = ?`
+	ids, err := query.SelectIntegers(c.tx, stmt, project, name)
 	if err != nil {
 		return -1, err
 	}
@@ -147,8 +177,9 @@ WHERE networks.id = ? AND networks.state = ?
 	return configs, nil
 }
 
-// CreatePendingNetwork creates a new pending network on the node with the given name.
-func (c *ClusterTx) CreatePendingNetwork(node, name string, netType NetworkType, conf map[string]string) error {
+// CreatePendingNetwork creates a new pending network in the given project on
+// the node with the given name.
+func (c *ClusterTx) CreatePendingNetwork(node, project, name string, netType NetworkType, conf map[string]string) error {
 	// First check if a network with the given name exists, and, if so, that it's in the pending state.
 	network := struct {
 		id      int64
@@ -165,13 +196,16 @@ func (c *ClusterTx) CreatePendingNetwork(node, name string, netType NetworkType,
 		return []interface{}{&network.id, &network.state, &network.netType}
 	}
 
-	stmt, err := c.tx.Prepare("SELECT id, state, type FROM networks WHERE name=?")
+	stmt, err := c.tx.Prepare(`
+SELECT networks.id, networks.state, networks.type FROM networks
+  JOIN projects ON projects.id = networks.project_id
+  WHERE projects.name = ? AND networks.name = ?`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	err = query.SelectObjects(stmt, dest, name)
+	err = query.SelectObjects(stmt, dest, project, name)
 	if err != nil {
 		return err
 	}
@@ -183,8 +217,13 @@ func (c *ClusterTx) CreatePendingNetwork(node, name string, netType NetworkType,
 	var networkID = network.id
 	if networkID == 0 {
 		// No existing network with the given name was found, let's create one.
-		columns := []string{"name", "type"}
-		values := []interface{}{name, netType}
+		projectID, err := c.GetProjectID(project)
+		if err != nil {
+			return err
+		}
+
+		columns := []string{"project_id", "name", "type"}
+		values := []interface{}{projectID, name, netType}
 		networkID, err = query.UpsertObject(c.tx, "networks", columns, values)
 		if err != nil {
 			return err
@@ -233,18 +272,18 @@ func (c *ClusterTx) CreatePendingNetwork(node, name string, netType NetworkType,
 }
 
 // NetworkCreated sets the state of the given network to "Created".
-func (c *ClusterTx) NetworkCreated(name string) error {
-	return c.networkState(name, networkCreated)
+func (c *ClusterTx) NetworkCreated(project string, name string) error {
+	return c.networkState(project, name, networkCreated)
 }
 
 // NetworkErrored sets the state of the given network to "Errored".
-func (c *ClusterTx) NetworkErrored(name string) error {
-	return c.networkState(name, networkErrored)
+func (c *ClusterTx) NetworkErrored(project string, name string) error {
+	return c.networkState(project, name, networkErrored)
 }
 
-func (c *ClusterTx) networkState(name string, state int) error {
-	stmt := "UPDATE networks SET state=? WHERE name=?"
-	result, err := c.tx.Exec(stmt, state, name)
+func (c *ClusterTx) networkState(project string, name string, state int) error {
+	stmt := "UPDATE networks SET state=? WHERE project_id = (SELECT id FROM projects WHERE name = ?) AND name=?"
+	result, err := c.tx.Exec(stmt, state, project, name)
 	if err != nil {
 		return err
 	}
@@ -258,19 +297,32 @@ func (c *ClusterTx) networkState(name string, state int) error {
 	return nil
 }
 
-// GetNetworks returns the names of existing networks.
-func (c *Cluster) GetNetworks() ([]string, error) {
+// GetNetworks returns the names of existing networks in the given project.
+func (c *Cluster) GetNetworks(project string) ([]string, error) {
+	networks, err := c.networks("projects.name=?", project)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return networks[project], nil
+}
+
+// GetNetworksAllProjects returns the names of existing networks, grouped by
+// project name.
+func (c *Cluster) GetNetworksAllProjects() (map[string][]string, error) {
 	return c.networks("")
 }
 
-// GetNonPendingNetworks returns the names of all networks that are not pending.
-func (c *Cluster) GetNonPendingNetworks() ([]string, error) {
-	return c.networks("NOT state=?", networkPending)
+// GetNonPendingNetworks returns the names of all networks that are not
+// pending, grouped by project name.
+func (c *Cluster) GetNonPendingNetworks() (map[string][]string, error) {
+	return c.networks("NOT networks.state=?", networkPending)
 }
 
-// Get all networks matching the given WHERE filter (if given).
-func (c *Cluster) networks(where string, args ...interface{}) ([]string, error) {
-	q := "SELECT name FROM networks"
+// Get all networks matching the given WHERE filter (if given), grouped by
+// project name.
+func (c *Cluster) networks(where string, args ...interface{}) (map[string][]string, error) {
+	q := "SELECT projects.name, networks.name FROM networks JOIN projects ON projects.id = networks.project_id"
 	inargs := []interface{}{}
 
 	if where != "" {
@@ -280,16 +332,20 @@ func (c *Cluster) networks(where string, args ...interface{}) ([]string, error)
 		}
 	}
 
+	q += " ORDER BY networks.name"
+
+	var project string
 	var name string
-	outfmt := []interface{}{name}
+	outfmt := []interface{}{project, name}
 	result, err := queryScan(c, q, inargs, outfmt)
 	if err != nil {
-		return []string{}, err
+		return nil, err
 	}
 
-	response := []string{}
+	response := map[string][]string{}
 	for _, r := range result {
-		response = append(response, r[0].(string))
+		project := r[0].(string)
+		response[project] = append(response[project], r[1].(string))
 	}
 
 	return response, nil
@@ -307,31 +363,36 @@ type NetworkType int
 
 // Network types.
 const (
-	NetworkTypeBridge  NetworkType = iota // Network type bridge.
-	NetworkTypeMacvlan                    // Network type macvlan.
-	NetworkTypeSriov                      // Network type sriov.
+	NetworkTypeBridge   NetworkType = iota // Network type bridge.
+	NetworkTypeMacvlan                     // Network type macvlan.
+	NetworkTypeSriov                       // Network type sriov.
+	NetworkTypeOVN                         // Network type ovn.
+	NetworkTypePhysical                    // Network type physical.
 )
 
-// GetNetworkInAnyState returns the network with the given name.
+// GetNetworkInAnyState returns the network with the given name in the given
+// project.
 //
 // The network can be in any state.
-func (c *Cluster) GetNetworkInAnyState(name string) (int64, *api.Network, error) {
-	return c.getNetwork(name, false)
+func (c *Cluster) GetNetworkInAnyState(project string, name string) (int64, *api.Network, error) {
+	return c.getNetwork(project, name, false)
 }
 
-// Get the network with the given name. If onlyCreated is true, only return
-// networks in the created state.
-func (c *Cluster) getNetwork(name string, onlyCreated bool) (int64, *api.Network, error) {
+// Get the network with the given name in the given project. If onlyCreated is
+// true, only return networks in the created state.
+func (c *Cluster) getNetwork(project string, name string, onlyCreated bool) (int64, *api.Network, error) {
 	description := sql.NullString{}
 	id := int64(-1)
 	state := 0
 	var netType NetworkType
 
-	q := "SELECT id, description, state, type FROM networks WHERE name=?"
-	arg1 := []interface{}{name}
+	q := `SELECT networks.id, networks.description, networks.state, networks.type FROM networks
+  JOIN projects ON projects.id = networks.project_id
+  WHERE projects.name=? AND networks.name=?`
+	arg1 := []interface{}{project, name}
 	arg2 := []interface{}{&id, &description, &state, &netType}
 	if onlyCreated {
-		q += " AND state=?"
+		q += " AND networks.state=?"
 		arg1 = append(arg1, networkCreated)
 	}
 	err := dbQueryRowScan(c, q, arg1, arg2)
@@ -373,6 +434,10 @@ func (c *Cluster) getNetwork(name string, onlyCreated bool) (int64, *api.Network
 		network.Type = "macvlan"
 	case NetworkTypeSriov:
 		network.Type = "sriov"
+	case NetworkTypeOVN:
+		network.Type = "ovn"
+	case NetworkTypePhysical:
+		network.Type = "physical"
 	default:
 		network.Type = "" // Unknown
 	}
@@ -406,7 +471,8 @@ SELECT nodes.name FROM nodes
 }
 
 // GetNetworkWithInterface returns the network associated with the interface with
-// the given name.
+// the given name. Only bridge networks, which are part of the default project,
+// have interfaces.
 func (c *Cluster) GetNetworkWithInterface(devName string) (int64, *api.Network, error) {
 	id := int64(-1)
 	name := ""
@@ -424,6 +490,12 @@ func (c *Cluster) GetNetworkWithInterface(devName string) (int64, *api.Network,
 		for _, entry := range strings.Split(r[2].(string), ",") {
 			entry = strings.TrimSpace(entry)
 
+			// Entries in the "<parent>/<vlan>" format refer to the "<parent>.<vlan>" interface.
+			fields := strings.SplitN(entry, "/", 2)
+			if len(fields) == 2 {
+				entry = fmt.Sprintf("%s.%s", fields[0], fields[1])
+			}
+
 			if entry == devName {
 				id = r[0].(int64)
 				name = r[1].(string)
@@ -495,11 +567,16 @@ func (c *Cluster) getNetworkConfig(id int64) (map[string]string, error) {
 	return config, nil
 }
 
-// CreateNetwork creates a new network.
-func (c *Cluster) CreateNetwork(name, description string, netType NetworkType, config map[string]string) (int64, error) {
+// CreateNetwork creates a new network in the given project.
+func (c *Cluster) CreateNetwork(project, name, description string, netType NetworkType, config map[string]string) (int64, error) {
 	var id int64
 	err := c.Transaction(func(tx *ClusterTx) error {
-		result, err := tx.tx.Exec("INSERT INTO networks (name, description, state, type) VALUES (?, ?, ?, ?)", name, description, networkCreated, netType)
+		projectID, err := tx.GetProjectID(project)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.tx.Exec("INSERT INTO networks (project_id, name, description, state, type) VALUES (?, ?, ?, ?, ?)", projectID, name, description, networkCreated, netType)
 		if err != nil {
 			return err
 		}
@@ -530,9 +607,9 @@ func (c *Cluster) CreateNetwork(name, description string, netType NetworkType, c
 	return id, err
 }
 
-// UpdateNetwork updates the network with the given name.
-func (c *Cluster) UpdateNetwork(name, description string, config map[string]string) error {
-	id, netInfo, err := c.GetNetworkInAnyState(name)
+// UpdateNetwork updates the network with the given name in the given project.
+func (c *Cluster) UpdateNetwork(project, name, description string, config map[string]string) error {
+	id, netInfo, err := c.GetNetworkInAnyState(project, name)
 	if err != nil {
 		return err
 	}
@@ -555,7 +632,7 @@ func (c *Cluster) UpdateNetwork(name, description string, config map[string]stri
 
 		// Update network status if change applied successfully.
 		if netInfo.Status == api.NetworkStatusErrored {
-			err = tx.NetworkCreated(name)
+			err = tx.NetworkCreated(project, name)
 			if err != nil {
 				return err
 			}
@@ -614,9 +691,9 @@ func clearNetworkConfig(tx *sql.Tx, networkID, nodeID int64) error {
 	return nil
 }
 
-// DeleteNetwork deletes the network with the given name.
-func (c *Cluster) DeleteNetwork(name string) error {
-	id, _, err := c.GetNetworkInAnyState(name)
+// DeleteNetwork deletes the network with the given name in the given project.
+func (c *Cluster) DeleteNetwork(project string, name string) error {
+	id, _, err := c.GetNetworkInAnyState(project, name)
 	if err != nil {
 		return err
 	}
@@ -629,9 +706,9 @@ func (c *Cluster) DeleteNetwork(name string) error {
 	return nil
 }
 
-// RenameNetwork renames a network.
-func (c *Cluster) RenameNetwork(oldName string, newName string) error {
-	id, _, err := c.GetNetworkInAnyState(oldName)
+// RenameNetwork renames a network in the given project.
+func (c *Cluster) RenameNetwork(project string, oldName string, newName string) error {
+	id, _, err := c.GetNetworkInAnyState(project, oldName)
 	if err != nil {
 		return err
 	}
@@ -648,4 +725,77 @@ func (c *Cluster) RenameNetwork(oldName string, newName string) error {
 var NodeSpecificNetworkConfig = []string{
 	"bridge.external_interfaces",
 	"parent",
+	"volatile.last_state.created",
+}
+
+// NetworkUser is an instance or a profile along with its NIC devices.
+type NetworkUser struct {
+	Project string
+	Name    string
+	Profile bool
+
+	// NetworkProject is the project in which the "network" property of the NIC devices is resolved.
+	NetworkProject string
+
+	Devices map[string]map[string]string
+}
+
+// GetNetworkUsers returns all the instances, with their devices expanded from their profiles, and all the
+// profiles which have NIC devices, in a single transaction.
+func (c *ClusterTx) GetNetworkUsers() ([]NetworkUser, error) {
+	projects, err := c.GetProjects(ProjectFilter{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Load projects")
+	}
+
+	// Networks only live in a project other than the default one if it has the networks feature enabled.
+	networkProjects := map[string]string{}
+	for _, project := range projects {
+		networkProjects[project.Name] = "default"
+		if shared.IsTrue(project.Config["features.networks"]) {
+			networkProjects[project.Name] = project.Name
+		}
+	}
+
+	instances, err := c.instanceListExpandedWithFilter(InstanceFilter{Type: instancetype.Any})
+	if err != nil {
+		return nil, errors.Wrap(err, "Load instances")
+	}
+
+	profiles, err := c.GetProfiles(ProfileFilter{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Load profiles")
+	}
+
+	users := []NetworkUser{}
+	addUser := func(project string, name string, profile bool, devices map[string]map[string]string) {
+		nics := map[string]map[string]string{}
+		for devName, device := range devices {
+			if device["type"] == "nic" {
+				nics[devName] = device
+			}
+		}
+
+		if len(nics) == 0 {
+			return
+		}
+
+		users = append(users, NetworkUser{
+			Project:        project,
+			Name:           name,
+			Profile:        profile,
+			NetworkProject: networkProjects[project],
+			Devices:        nics,
+		})
+	}
+
+	for _, instance := range instances {
+		addUser(instance.Project, instance.Name, false, instance.Devices)
+	}
+
+	for _, profile := range profiles {
+		addUser(profile.Project, profile.Name, true, profile.Devices)
+	}
+
+	return users, nil
 }
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/shared/api"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -15,7 +17,7 @@ func TestGetNetworksLocalConfigs(t *testing.T) {
 	cluster, cleanup := db.NewTestCluster(t)
 	defer cleanup()
 
-	_, err := cluster.CreateNetwork("lxdbr0", "", db.NetworkTypeBridge, map[string]string{
+	_, err := cluster.CreateNetwork("default", "lxdbr0", "", db.NetworkTypeBridge, map[string]string{
 		"dns.mode":                   "none",
 		"bridge.external_interfaces": "vlan0",
 	})
@@ -45,15 +47,15 @@ func TestCreatePendingNetwork(t *testing.T) {
 	require.NoError(t, err)
 
 	config := map[string]string{"bridge.external_interfaces": "foo"}
-	err = tx.CreatePendingNetwork("buzz", "network1", db.NetworkTypeBridge, config)
+	err = tx.CreatePendingNetwork("buzz", "default", "network1", db.NetworkTypeBridge, config)
 	require.NoError(t, err)
 
-	networkID, err := tx.GetNetworkID("network1")
+	networkID, err := tx.GetNetworkID("default", "network1")
 	require.NoError(t, err)
 	assert.True(t, networkID > 0)
 
 	config = map[string]string{"bridge.external_interfaces": "bar"}
-	err = tx.CreatePendingNetwork("rusp", "network1", db.NetworkTypeBridge, config)
+	err = tx.CreatePendingNetwork("rusp", "default", "network1", db.NetworkTypeBridge, config)
 	require.NoError(t, err)
 
 	// The initial node (whose name is 'none' by default) is missing.
@@ -61,7 +63,7 @@ func TestCreatePendingNetwork(t *testing.T) {
 	require.EqualError(t, err, "Network not defined on nodes: none")
 
 	config = map[string]string{"bridge.external_interfaces": "egg"}
-	err = tx.CreatePendingNetwork("none", "network1", db.NetworkTypeBridge, config)
+	err = tx.CreatePendingNetwork("none", "default", "network1", db.NetworkTypeBridge, config)
 	require.NoError(t, err)
 
 	// Now the storage is defined on all nodes.
@@ -82,10 +84,10 @@ func TestNetworksCreatePending_AlreadyDefined(t *testing.T) {
 	_, err := tx.CreateNode("buzz", "1.2.3.4:666")
 	require.NoError(t, err)
 
-	err = tx.CreatePendingNetwork("buzz", "network1", db.NetworkTypeBridge, map[string]string{})
+	err = tx.CreatePendingNetwork("buzz", "default", "network1", db.NetworkTypeBridge, map[string]string{})
 	require.NoError(t, err)
 
-	err = tx.CreatePendingNetwork("buzz", "network1", db.NetworkTypeBridge, map[string]string{})
+	err = tx.CreatePendingNetwork("buzz", "default", "network1", db.NetworkTypeBridge, map[string]string{})
 	require.Equal(t, db.ErrAlreadyDefined, err)
 }
 
@@ -94,6 +96,96 @@ func TestNetworksCreatePending_NonExistingNode(t *testing.T) {
 	tx, cleanup := db.NewTestClusterTx(t)
 	defer cleanup()
 
-	err := tx.CreatePendingNetwork("buzz", "network1", db.NetworkTypeBridge, map[string]string{})
+	err := tx.CreatePendingNetwork("buzz", "default", "network1", db.NetworkTypeBridge, map[string]string{})
 	require.Equal(t, db.ErrNoSuchObject, err)
 }
+
+// Networks with the same name can exist in different projects.
+func TestCreateNetwork_SameNameInDifferentProjects(t *testing.T) {
+	cluster, cleanup := db.NewTestCluster(t)
+	defer cleanup()
+
+	err := cluster.Transaction(func(tx *db.ClusterTx) error {
+		project := api.ProjectsPost{}
+		project.Name = "tenant1"
+		project.Config = map[string]string{"features.networks": "true"}
+		_, err := tx.CreateProject(project)
+		return err
+	})
+	require.NoError(t, err)
+
+	id1, err := cluster.CreateNetwork("default", "ovn1", "", db.NetworkTypeOVN, nil)
+	require.NoError(t, err)
+
+	id2, err := cluster.CreateNetwork("tenant1", "ovn1", "", db.NetworkTypeOVN, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, id1, id2)
+
+	_, err = cluster.CreateNetwork("tenant1", "ovn1", "", db.NetworkTypeOVN, nil)
+	assert.Error(t, err)
+
+	names, err := cluster.GetNetworks("tenant1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ovn1"}, names)
+
+	id, _, err := cluster.GetNetworkInAnyState("tenant1", "ovn1")
+	require.NoError(t, err)
+	assert.Equal(t, id2, id)
+
+	err = cluster.DeleteNetwork("tenant1", "ovn1")
+	require.NoError(t, err)
+
+	_, _, err = cluster.GetNetworkInAnyState("default", "ovn1")
+	require.NoError(t, err)
+}
+
+// The GetNetworkUsers method returns the instances and profiles with NIC devices, the instance devices being
+// expanded from their profiles.
+func TestGetNetworkUsers(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	profile := db.Profile{
+		Project: "default",
+		Name:    "profile1",
+		Devices: map[string]map[string]string{"eth0": {"type": "nic", "network": "lxdbr0"}},
+	}
+
+	_, err := tx.CreateProfile(profile)
+	require.NoError(t, err)
+
+	vm := db.Instance{
+		Project:      "default",
+		Name:         "vm1",
+		Node:         "none",
+		Type:         instancetype.VM,
+		Architecture: 1,
+		Devices:      map[string]map[string]string{"root": {"type": "disk", "pool": "default", "path": "/"}},
+		Profiles:     []string{"profile1"},
+	}
+
+	_, err = tx.CreateInstance(vm)
+	require.NoError(t, err)
+
+	container := db.Instance{
+		Project:      "default",
+		Name:         "c1",
+		Node:         "none",
+		Type:         instancetype.Container,
+		Architecture: 1,
+		Devices:      map[string]map[string]string{"root": {"type": "disk", "pool": "default", "path": "/"}},
+		Profiles:     []string{"default"},
+	}
+
+	_, err = tx.CreateInstance(container)
+	require.NoError(t, err)
+
+	users, err := tx.GetNetworkUsers()
+	require.NoError(t, err)
+
+	nics := map[string]map[string]string{"eth0": {"type": "nic", "network": "lxdbr0"}}
+	assert.Equal(t, []db.NetworkUser{
+		{Project: "default", Name: "vm1", NetworkProject: "default", Devices: nics},
+		{Project: "default", Name: "profile1", Profile: true, NetworkProject: "default", Devices: nics},
+	}, users)
+}
@@ -25,12 +25,29 @@ type ClusterRole string
 // ClusterRoleDatabase represents the database role in a cluster.
 const ClusterRoleDatabase = ClusterRole("database")
 
+// ClusterRoleDatabaseStandBy represents a database stand-by in a cluster.
+const ClusterRoleDatabaseStandBy = ClusterRole("database-standby")
+
+// ClusterRoleDatabaseLeader represents the database leader in a cluster.
+const ClusterRoleDatabaseLeader = ClusterRole("database-leader")
+
+// ClusterRoleEventHub represents a cluster member which relays the events of all other members.
+const ClusterRoleEventHub = ClusterRole("event-hub")
+
 // ClusterRoles maps role ids into human-readable names.
 //
-// Note: the database role is currently stored directly in the raft
-// configuration which acts as single source of truth for it. This map should
+// Note: the database roles are currently stored directly in the raft
+// configuration which acts as single source of truth for them. This map should
 // only contain LXD-specific cluster roles.
-var ClusterRoles = map[int]ClusterRole{}
+var ClusterRoles = map[int]ClusterRole{
+	1: ClusterRoleEventHub,
+}
+
+// Numeric type codes identifying the state of a cluster member.
+const (
+	ClusterMemberStateCreated   = 0
+	ClusterMemberStateEvacuated = 1
+)
 
 // NodeInfo holds information about a single LXD instance in a cluster.
 type NodeInfo struct {
@@ -43,6 +60,8 @@ type NodeInfo struct {
 	Heartbeat     time.Time // Timestamp of the last heartbeat
 	Roles         []string  // List of cluster roles
 	Architecture  int       // Node architecture
+	State         int       // Node state
+	Groups        []string  // List of cluster groups
 }
 
 // IsOffline returns true if the last successful heartbeat time of the node is
@@ -264,6 +283,34 @@ func (c *ClusterTx) nodes(pending bool, where string, args ...interface{}) ([]No
 		return nil, err
 	}
 
+	// Get node groups
+	nodeGroups := map[int64][]string{}
+	rows, err = c.tx.Query("SELECT node_id, cluster_groups.name FROM nodes_cluster_groups JOIN cluster_groups ON cluster_groups.id = nodes_cluster_groups.group_id")
+	if err != nil {
+		if err.Error() != "no such table: nodes_cluster_groups" {
+			return nil, err
+		}
+	} else {
+		// Don't fail on a missing table, we need to handle updates
+		defer rows.Close()
+
+		for rows.Next() {
+			var nodeID int64
+			var group string
+			err := rows.Scan(&nodeID, &group)
+			if err != nil {
+				return nil, err
+			}
+
+			nodeGroups[nodeID] = append(nodeGroups[nodeID], group)
+		}
+
+		err = rows.Err()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Process node entries
 	nodes := []NodeInfo{}
 	dest := func(i int) []interface{} {
@@ -277,6 +324,7 @@ func (c *ClusterTx) nodes(pending bool, where string, args ...interface{}) ([]No
 			&nodes[i].APIExtensions,
 			&nodes[i].Heartbeat,
 			&nodes[i].Architecture,
+			&nodes[i].State,
 		}
 	}
 	if pending {
@@ -286,7 +334,7 @@ func (c *ClusterTx) nodes(pending bool, where string, args ...interface{}) ([]No
 	}
 
 	// Get the node entries
-	sql = "SELECT id, name, address, description, schema, api_extensions, heartbeat, arch FROM nodes WHERE pending=?"
+	sql = "SELECT id, name, address, description, schema, api_extensions, heartbeat, arch, state FROM nodes WHERE pending=?"
 	if where != "" {
 		sql += fmt.Sprintf("AND %s ", where)
 	}
@@ -303,12 +351,14 @@ func (c *ClusterTx) nodes(pending bool, where string, args ...interface{}) ([]No
 		return nil, errors.Wrap(err, "Failed to fetch nodes")
 	}
 
-	// Add the roles
+	// Add the roles and groups
 	for i, node := range nodes {
 		roles, ok := nodeRoles[node.ID]
 		if ok {
 			nodes[i].Roles = roles
 		}
+
+		nodes[i].Groups = nodeGroups[node.ID]
 	}
 
 	return nodes, nil
@@ -434,7 +484,7 @@ func (c *ClusterTx) UpdateNodeRoles(id int64, roles []ClusterRole) error {
 	roleIDs := []int{}
 	for _, role := range roles {
 		// Skip internal-only roles.
-		if role == ClusterRoleDatabase {
+		if role == ClusterRoleDatabase || role == ClusterRoleDatabaseStandBy || role == ClusterRoleDatabaseLeader {
 			continue
 		}
 
@@ -462,6 +512,23 @@ func (c *ClusterTx) UpdateNodeRoles(id int64, roles []ClusterRole) error {
 	return nil
 }
 
+// UpdateNodeStatus changes the state of a node.
+func (c *ClusterTx) UpdateNodeStatus(id int64, state int) error {
+	result, err := c.tx.Exec("UPDATE nodes SET state=? WHERE id=?", state, id)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return fmt.Errorf("Query updated %d rows instead of 1", n)
+	}
+
+	return nil
+}
+
 // UpdateNodeFailureDomain changes the failure domain of a node.
 func (c *ClusterTx) UpdateNodeFailureDomain(id int64, domain string) error {
 	var domainID interface{}
@@ -758,25 +825,55 @@ func (c *ClusterTx) GetNodeOfflineThreshold() (time.Duration, error) {
 	return threshold, nil
 }
 
-// GetNodeWithLeastInstances returns the name of the non-offline node with with
-// the least number of containers (either already created or being created with
-// an operation). If archs is not empty, then return only nodes with an
-// architecture in that list.
-func (c *ClusterTx) GetNodeWithLeastInstances(archs []int) (string, error) {
+// GetNodeWithLeastInstances returns the name of the non-offline and
+// non-evacuated node with with the least number of containers (either already
+// created or being created with an operation). If archs is not empty, then
+// return only nodes with an architecture in that list. If group is not empty,
+// then return only nodes member of that cluster group.
+func (c *ClusterTx) GetNodeWithLeastInstances(archs []int, group string) (string, error) {
+	nodes, err := c.GetCandidateNodes(archs, group)
+	if err != nil {
+		return "", err
+	}
+
+	name := ""
+	containers := -1
+	for _, node := range nodes {
+		count, err := c.GetNodeInstancesCount(node.ID)
+		if err != nil {
+			return "", err
+		}
+
+		if containers == -1 || count < containers {
+			containers = count
+			name = node.Name
+		}
+	}
+	return name, nil
+}
+
+// GetCandidateNodes returns the non-offline and non-evacuated nodes new
+// instances can be placed on. If archs is not empty, then return only nodes
+// with an architecture in that list. If group is not empty, then return only
+// nodes member of that cluster group.
+func (c *ClusterTx) GetCandidateNodes(archs []int, group string) ([]NodeInfo, error) {
 	threshold, err := c.GetNodeOfflineThreshold()
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get offline threshold")
+		return nil, errors.Wrap(err, "failed to get offline threshold")
 	}
 
 	nodes, err := c.GetNodes()
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get current nodes")
+		return nil, errors.Wrap(err, "failed to get current nodes")
 	}
 
-	name := ""
-	containers := -1
+	candidates := []NodeInfo{}
 	for _, node := range nodes {
-		if node.IsOffline(threshold) {
+		if node.IsOffline(threshold) || node.State == ClusterMemberStateEvacuated {
+			continue
+		}
+
+		if group != "" && !shared.StringInSlice(group, node.Groups) {
 			continue
 		}
 
@@ -784,7 +881,7 @@ func (c *ClusterTx) GetNodeWithLeastInstances(archs []int) (string, error) {
 			// Get personalities too.
 			personalities, err := osarch.ArchitecturePersonalities(node.Architecture)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 
 			supported := []int{node.Architecture}
@@ -802,26 +899,29 @@ func (c *ClusterTx) GetNodeWithLeastInstances(archs []int) (string, error) {
 			}
 		}
 
-		// Fetch the number of containers already created on this node.
-		created, err := query.Count(c.tx, "instances", "node_id=?", node.ID)
-		if err != nil {
-			return "", errors.Wrap(err, "Failed to get instances count")
-		}
+		candidates = append(candidates, node)
+	}
 
-		// Fetch the number of containers currently being created on this node.
-		pending, err := query.Count(
-			c.tx, "operations", "node_id=? AND type=?", node.ID, OperationContainerCreate)
-		if err != nil {
-			return "", errors.Wrap(err, "Failed to get pending instances count")
-		}
+	return candidates, nil
+}
 
-		count := created + pending
-		if containers == -1 || count < containers {
-			containers = count
-			name = node.Name
-		}
+// GetNodeInstancesCount returns the number of containers of the node with the
+// given ID, either already created or being created with an operation.
+func (c *ClusterTx) GetNodeInstancesCount(id int64) (int, error) {
+	// Fetch the number of containers already created on this node.
+	created, err := query.Count(c.tx, "instances", "node_id=?", id)
+	if err != nil {
+		return -1, errors.Wrap(err, "Failed to get instances count")
 	}
-	return name, nil
+
+	// Fetch the number of containers currently being created on this node.
+	pending, err := query.Count(
+		c.tx, "operations", "node_id=? AND type=?", id, OperationContainerCreate)
+	if err != nil {
+		return -1, errors.Wrap(err, "Failed to get pending instances count")
+	}
+
+	return created + pending, nil
 }
 
 // SetNodeVersion updates the schema and API version of the node with the
@@ -314,7 +314,7 @@ INSERT INTO instances (id, node_id, name, architecture, type, project_id) VALUES
 `)
 	require.NoError(t, err)
 
-	name, err := tx.GetNodeWithLeastInstances(nil)
+	name, err := tx.GetNodeWithLeastInstances(nil, "")
 	require.NoError(t, err)
 	assert.Equal(t, "buzz", name)
 }
@@ -338,7 +338,31 @@ INSERT INTO instances (id, node_id, name, architecture, type, project_id) VALUES
 	err = tx.SetNodeHeartbeat("0.0.0.0", time.Now().Add(-time.Minute))
 	require.NoError(t, err)
 
-	name, err := tx.GetNodeWithLeastInstances(nil)
+	name, err := tx.GetNodeWithLeastInstances(nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "buzz", name)
+}
+
+// If there are nodes, and one of them is evacuated, return the name of the
+// other node, even if the evacuated one has less containers.
+func TestGetNodeWithLeastInstances_EvacuatedNode(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	id, err := tx.CreateNode("buzz", "1.2.3.4:666")
+	require.NoError(t, err)
+
+	// Add a container to the newly created node.
+	_, err = tx.Tx().Exec(`
+INSERT INTO instances (id, node_id, name, architecture, type, project_id) VALUES (1, ?, 'foo', 1, 1, 1)
+`, id)
+	require.NoError(t, err)
+
+	// Mark the default node as evacuated.
+	err = tx.UpdateNodeStatus(1, db.ClusterMemberStateEvacuated)
+	require.NoError(t, err)
+
+	name, err := tx.GetNodeWithLeastInstances(nil, "")
 	require.NoError(t, err)
 	assert.Equal(t, "buzz", name)
 }
@@ -358,7 +382,7 @@ INSERT INTO operations (id, uuid, node_id, type, project_id) VALUES (1, 'abc', 1
 `, db.OperationContainerCreate)
 	require.NoError(t, err)
 
-	name, err := tx.GetNodeWithLeastInstances(nil)
+	name, err := tx.GetNodeWithLeastInstances(nil, "")
 	require.NoError(t, err)
 	assert.Equal(t, "buzz", name)
 }
@@ -387,7 +411,7 @@ INSERT INTO instances (id, node_id, name, architecture, type, project_id) VALUES
 	require.NoError(t, err)
 
 	// The local node is returned despite it has more containers.
-	name, err := tx.GetNodeWithLeastInstances([]int{localArch})
+	name, err := tx.GetNodeWithLeastInstances([]int{localArch}, "")
 	require.NoError(t, err)
 	assert.Equal(t, "none", name)
 }
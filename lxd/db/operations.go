@@ -16,6 +16,7 @@ type Operation struct {
 	UUID        string        // User-visible identifier
 	NodeAddress string        // Address of the node the operation is running on
 	Type        OperationType // Type of the operation
+	Project     string        // Name of the project of the operation, empty if not part of any project
 }
 
 // GetLocalOperations returns all operations associated with this node.
@@ -42,6 +43,16 @@ SELECT DISTINCT nodes.address
 	return query.SelectStrings(c.tx, stmt, project)
 }
 
+// GetAllNodesWithRunningOperations returns a list of nodes that have running operations in any project.
+func (c *ClusterTx) GetAllNodesWithRunningOperations() ([]string, error) {
+	stmt := `
+SELECT DISTINCT nodes.address
+  FROM operations
+  JOIN nodes ON nodes.id = operations.node_id
+`
+	return query.SelectStrings(c.tx, stmt)
+}
+
 // GetOperationByUUID returns the operation with the given UUID.
 func (c *ClusterTx) GetOperationByUUID(uuid string) (Operation, error) {
 	null := Operation{}
@@ -114,10 +125,13 @@ func (c *ClusterTx) operations(where string, args ...interface{}) ([]Operation,
 			&operations[i].UUID,
 			&operations[i].NodeAddress,
 			&operations[i].Type,
+			&operations[i].Project,
 		}
 	}
 	sql := `
-SELECT operations.id, uuid, nodes.address, type FROM operations JOIN nodes ON nodes.id = node_id `
+SELECT operations.id, uuid, nodes.address, type, coalesce(projects.name, '') FROM operations
+  JOIN nodes ON nodes.id = node_id
+  LEFT OUTER JOIN projects ON projects.id = operations.project_id `
 	if where != "" {
 		sql += fmt.Sprintf("WHERE %s ", where)
 	}
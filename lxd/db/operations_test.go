@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/shared/api"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -28,6 +29,7 @@ func TestOperation(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, id, operation.ID)
 	assert.Equal(t, db.OperationContainerCreate, operation.Type)
+	assert.Equal(t, "default", operation.Project)
 
 	uuids, err := tx.GetLocalOperationsUUIDs()
 	require.NoError(t, err)
@@ -58,6 +60,7 @@ func TestOperationNoProject(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, id, operation.ID)
 	assert.Equal(t, db.OperationContainerCreate, operation.Type)
+	assert.Equal(t, "", operation.Project)
 
 	uuids, err := tx.GetLocalOperationsUUIDs()
 	require.NoError(t, err)
@@ -69,3 +72,27 @@ func TestOperationNoProject(t *testing.T) {
 	_, err = tx.GetOperationByUUID("abcd")
 	assert.Equal(t, db.ErrNoSuchObject, err)
 }
+
+// List the nodes with running operations, in a single project or in all of them.
+func TestGetNodesWithRunningOperations(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	_, err := tx.CreateProject(api.ProjectsPost{Name: "p1"})
+	require.NoError(t, err)
+
+	_, err = tx.CreateOperation("p1", "abcd", db.OperationContainerCreate)
+	require.NoError(t, err)
+
+	nodes, err := tx.GetNodesWithRunningOperations("default")
+	require.NoError(t, err)
+	assert.Len(t, nodes, 0)
+
+	nodes, err = tx.GetNodesWithRunningOperations("p1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0.0.0.0"}, nodes)
+
+	nodes, err = tx.GetAllNodesWithRunningOperations()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0.0.0.0"}, nodes)
+}
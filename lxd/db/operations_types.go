@@ -56,6 +56,19 @@ const (
 	OperationBackupsExpire
 	OperationSnapshotsExpire
 	OperationCustomVolumeSnapshotsExpire
+	OperationBatch
+	OperationBackupVerify
+	OperationInstanceRebuild
+	OperationInstancesStateUpdate
+	OperationClusterMemberEvacuate
+	OperationClusterMemberRestore
+	OperationCertificateAddToken
+	OperationClusterJoinToken
+	OperationCustomVolumeBackupCreate
+	OperationCustomVolumeBackupRemove
+	OperationCustomVolumeBackupRename
+	OperationCustomVolumeBackupRestore
+	OperationInstancesShutdown
 )
 
 // Description return a human-readable description of the operation type.
@@ -153,6 +166,32 @@ func (t OperationType) Description() string {
 		return "Cleaning up expired instance snapshots"
 	case OperationCustomVolumeSnapshotsExpire:
 		return "Cleaning up expired volume snapshots"
+	case OperationBatch:
+		return "Executing batch request"
+	case OperationBackupVerify:
+		return "Verifying backup"
+	case OperationInstanceRebuild:
+		return "Rebuilding instance"
+	case OperationInstancesStateUpdate:
+		return "Updating instances state"
+	case OperationClusterMemberEvacuate:
+		return "Evacuating cluster member"
+	case OperationClusterMemberRestore:
+		return "Restoring cluster member"
+	case OperationCertificateAddToken:
+		return "Certificate add token"
+	case OperationClusterJoinToken:
+		return "Cluster join token"
+	case OperationCustomVolumeBackupCreate:
+		return "Creating custom volume backup"
+	case OperationCustomVolumeBackupRemove:
+		return "Deleting custom volume backup"
+	case OperationCustomVolumeBackupRename:
+		return "Renaming custom volume backup"
+	case OperationCustomVolumeBackupRestore:
+		return "Restoring custom volume backup"
+	case OperationInstancesShutdown:
+		return "Shutting down instances"
 	default:
 		return "Executing operation"
 	}
@@ -169,6 +208,8 @@ func (t OperationType) Permission() string {
 		return "operate-containers"
 	case OperationBackupRemove:
 		return "operate-containers"
+	case OperationBackupVerify:
+		return "operate-containers"
 	case OperationConsoleShow:
 		return "operate-containers"
 	case OperationContainerFreeze:
@@ -193,6 +234,8 @@ func (t OperationType) Permission() string {
 		return "operate-containers"
 	case OperationSnapshotDelete:
 		return "operate-containers"
+	case OperationInstancesStateUpdate:
+		return "operate-containers"
 
 	case OperationContainerCreate:
 		return "manage-containers"
@@ -200,6 +243,8 @@ func (t OperationType) Permission() string {
 		return "manage-containers"
 	case OperationContainerRename:
 		return "manage-containers"
+	case OperationInstanceRebuild:
+		return "manage-containers"
 	case OperationContainerMigrate:
 		return "manage-containers"
 	case OperationContainerLiveMigrate:
@@ -224,6 +269,16 @@ func (t OperationType) Permission() string {
 
 	case OperationCustomVolumeSnapshotsExpire:
 		return "operate-volumes"
+	case OperationCustomVolumeBackupCreate:
+		return "manage-storage-volumes"
+	case OperationCustomVolumeBackupRemove:
+		return "manage-storage-volumes"
+	case OperationCustomVolumeBackupRename:
+		return "manage-storage-volumes"
+	case OperationCustomVolumeBackupRestore:
+		return "manage-storage-volumes"
+	case OperationInstancesShutdown:
+		return "operate-containers"
 	}
 
 	return ""
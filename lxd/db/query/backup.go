@@ -0,0 +1,176 @@
+package query
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// Table holds the columns and rows of a database table, as saved in a
+// backup.
+type Table struct {
+	Columns []string        `json:"columns" yaml:"columns"`
+	Rows    [][]interface{} `json:"rows" yaml:"rows"`
+}
+
+// Backup returns the content of the schema table and of all tables found in
+// the given schema, indexed by table name.
+//
+// Time values are encoded as a map with a single "time" key so that they can
+// be told apart from strings when restoring them.
+func Backup(tx *sql.Tx, schema string) (map[string]Table, error) {
+	tables := map[string]Table{}
+
+	names := []string{"schema"}
+	for name := range dumpParseSchema(schema) {
+		names = append(names, name)
+	}
+
+	for _, name := range names {
+		table, err := backupTable(tx, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to backup table %s", name)
+		}
+
+		tables[name] = table
+	}
+
+	return tables, nil
+}
+
+func backupTable(tx *sql.Tx, name string) (Table, error) {
+	table := Table{Rows: [][]interface{}{}}
+
+	rows, err := tx.Query(fmt.Sprintf("SELECT * FROM %s ORDER BY rowid", name))
+	if err != nil {
+		return table, errors.Wrap(err, "failed to fetch rows")
+	}
+	defer rows.Close()
+
+	table.Columns, err = rows.Columns()
+	if err != nil {
+		return table, errors.Wrap(err, "failed to get columns")
+	}
+
+	for i := 0; rows.Next(); i++ {
+		raw := make([]interface{}, len(table.Columns))
+		row := make([]interface{}, len(table.Columns))
+		for j := range raw {
+			row[j] = &raw[j]
+		}
+
+		err := rows.Scan(row...)
+		if err != nil {
+			return table, errors.Wrapf(err, "failed to scan row %d", i)
+		}
+
+		for j, v := range raw {
+			switch v := v.(type) {
+			case []byte:
+				raw[j] = string(v)
+			case time.Time:
+				raw[j] = map[string]interface{}{"time": v.Format(time.RFC3339Nano)}
+			}
+		}
+
+		table.Rows = append(table.Rows, raw)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return table, errors.Wrap(err, "got a row error")
+	}
+
+	return table, nil
+}
+
+// Restore replaces the content of the given tables with the rows saved in a
+// backup. Tables listed in skip are left untouched, foreign keys are only
+// checked once all tables have been restored.
+func Restore(tx *sql.Tx, tables map[string]Table, skip []string) error {
+	_, err := tx.Exec("PRAGMA defer_foreign_keys = ON")
+	if err != nil {
+		return errors.Wrap(err, "failed to defer foreign keys")
+	}
+
+	names := []string{}
+	for name := range tables {
+		if shared.StringInSlice(name, skip) {
+			continue
+		}
+
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		_, err := tx.Exec(fmt.Sprintf("DELETE FROM %s", name))
+		if err != nil {
+			return errors.Wrapf(err, "failed to empty table %s", name)
+		}
+	}
+
+	for _, name := range names {
+		table := tables[name]
+		if len(table.Rows) == 0 {
+			continue
+		}
+
+		stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", name, strings.Join(table.Columns, ", "), Params(len(table.Columns)))
+		for i, row := range table.Rows {
+			if len(row) != len(table.Columns) {
+				return fmt.Errorf("row %d of table %s has %d values instead of %d", i, name, len(row), len(table.Columns))
+			}
+
+			values := make([]interface{}, len(row))
+			for j, v := range row {
+				values[j], err = restoreValue(v)
+				if err != nil {
+					return errors.Wrapf(err, "bad value in column %s of row %d of table %s", table.Columns[j], i, name)
+				}
+			}
+
+			_, err := tx.Exec(stmt, values...)
+			if err != nil {
+				return errors.Wrapf(err, "failed to restore row %d of table %s", i, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Convert a value decoded from a backup back to its database type.
+func restoreValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case json.Number:
+		i, err := v.Int64()
+		if err == nil {
+			return i, nil
+		}
+
+		return v.Float64()
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v), nil
+		}
+
+		return v, nil
+	case map[string]interface{}:
+		s, ok := v["time"].(string)
+		if !ok {
+			return nil, fmt.Errorf("unknown value type")
+		}
+
+		return time.Parse(time.RFC3339Nano, s)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,57 @@
+package query_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db/query"
+)
+
+func TestBackup(t *testing.T) {
+	tx := newTxForDump(t, "local")
+	tables, err := query.Backup(tx, schemas["local"])
+	require.NoError(t, err)
+
+	assert.Len(t, tables, 4)
+	assert.Equal(t, []string{"id", "name", "applied_at"}, tables["patches"].Columns)
+	assert.Len(t, tables["patches"].Rows, 2)
+	assert.Equal(t, "invalid_profile_names", tables["patches"].Rows[0][1])
+	assert.Len(t, tables["raft_nodes"].Rows, 0)
+}
+
+func TestRestore(t *testing.T) {
+	tx := newTxForDump(t, "local")
+	tables, err := query.Backup(tx, schemas["local"])
+	require.NoError(t, err)
+
+	// Round-trip the backup through JSON, like when saved to a file.
+	data, err := json.Marshal(tables)
+	require.NoError(t, err)
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	saved := map[string]query.Table{}
+	require.NoError(t, decoder.Decode(&saved))
+
+	// Restore on top of modified data.
+	_, err = tx.Exec("DELETE FROM patches WHERE id=1")
+	require.NoError(t, err)
+	_, err = tx.Exec("INSERT INTO config (key, value) VALUES ('core.https_address', ':8443')")
+	require.NoError(t, err)
+
+	err = query.Restore(tx, saved, []string{"schema"})
+	require.NoError(t, err)
+
+	restored, err := query.Backup(tx, schemas["local"])
+	require.NoError(t, err)
+
+	assert.Len(t, restored["config"].Rows, 0)
+	require.Len(t, restored["patches"].Rows, 2)
+	assert.Equal(t, int64(1), restored["patches"].Rows[0][0])
+	assert.Equal(t, "invalid_profile_names", restored["patches"].Rows[0][1])
+}
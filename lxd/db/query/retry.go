@@ -1,6 +1,7 @@
 package query
 
 import (
+	"context"
 	"database/sql"
 	"strings"
 	"time"
@@ -16,6 +17,12 @@ import (
 //
 // This should by typically used to wrap transactions.
 func Retry(f func() error) error {
+	return RetryContext(context.Background(), f)
+}
+
+// RetryContext is like Retry, but stops retrying as soon as the given context
+// is done.
+func RetryContext(ctx context.Context, f func() error) error {
 	// TODO: the retry loop should be configurable.
 	var err error
 	for i := 0; i < 5; i++ {
@@ -26,6 +33,11 @@ func Retry(f func() error) error {
 				break
 			}
 
+			// Nor when the caller isn't interested in the result anymore.
+			if ctx.Err() != nil {
+				break
+			}
+
 			// Process actual errors.
 			logger.Debugf("Database error: %#v", err)
 			if IsRetriableError(err) {
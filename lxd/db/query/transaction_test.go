@@ -1,6 +1,7 @@
 package query_test
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"testing"
@@ -39,6 +40,28 @@ func TestTransaction_FunctionError(t *testing.T) {
 	assert.NotContains(t, tables, "test")
 }
 
+// If the context gets cancelled while in the transaction function, the
+// transaction is rolled back and the context error returned.
+func TestTransactionContext_Cancelled(t *testing.T) {
+	db := newDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := query.TransactionContext(ctx, db, func(tx *sql.Tx) error {
+		_, err := tx.Exec("CREATE TABLE test (id INTEGER)")
+		assert.NoError(t, err)
+		cancel()
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	tables, err := query.SelectStrings(tx, "SELECT name FROM sqlite_master WHERE type = 'table'")
+	assert.NoError(t, err)
+	assert.NotContains(t, tables, "test")
+}
+
 // Return a new in-memory SQLite database.
 func newDB(t *testing.T) *sql.DB {
 	db, err := sql.Open("sqlite3", ":memory:")
@@ -0,0 +1,175 @@
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"time"
+)
+
+// StoragePoolVolumeBackup is a value object holding all db-related details about a custom volume backup.
+type StoragePoolVolumeBackup struct {
+	ID                   int
+	VolumeID             int64
+	Name                 string
+	CreationDate         time.Time
+	ExpiryDate           time.Time
+	VolumeOnly           bool
+	OptimizedStorage     bool
+	CompressionAlgorithm string
+
+	// Set when loading backups, not used when creating them.
+	ProjectName string
+	PoolName    string
+}
+
+// storagePoolVolumeBackupsQuery selects the custom volume backups, along with the project and pool of their
+// volume. The conditions are appended by the callers.
+const storagePoolVolumeBackupsQuery = `
+SELECT storage_volumes_backups.id, storage_volumes_backups.storage_volume_id, storage_volumes_backups.name,
+       storage_volumes_backups.creation_date, storage_volumes_backups.expiry_date,
+       storage_volumes_backups.volume_only, storage_volumes_backups.optimized_storage,
+       projects.name, storage_pools.name
+    FROM storage_volumes_backups
+    JOIN storage_volumes ON storage_volumes.id = storage_volumes_backups.storage_volume_id
+    JOIN storage_pools ON storage_pools.id = storage_volumes.storage_pool_id
+    JOIN projects ON projects.id = storage_volumes.project_id
+`
+
+// getStoragePoolVolumeBackups returns the custom volume backups matching the given conditions.
+func (c *Cluster) getStoragePoolVolumeBackups(where string, args ...interface{}) ([]StoragePoolVolumeBackup, error) {
+	backups := []StoragePoolVolumeBackup{}
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		rows, err := tx.tx.Query(storagePoolVolumeBackupsQuery+where, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			b := StoragePoolVolumeBackup{}
+			volumeOnlyInt := 0
+			optimizedStorageInt := 0
+
+			err := rows.Scan(&b.ID, &b.VolumeID, &b.Name, &b.CreationDate, &b.ExpiryDate,
+				&volumeOnlyInt, &optimizedStorageInt, &b.ProjectName, &b.PoolName)
+			if err != nil {
+				return err
+			}
+
+			b.VolumeOnly = volumeOnlyInt == 1
+			b.OptimizedStorage = optimizedStorageInt == 1
+
+			backups = append(backups, b)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return backups, nil
+}
+
+// GetStoragePoolVolumeBackups returns all the backups of the custom volume with the given ID.
+func (c *Cluster) GetStoragePoolVolumeBackups(volumeID int64) ([]StoragePoolVolumeBackup, error) {
+	return c.getStoragePoolVolumeBackups("WHERE storage_volumes_backups.storage_volume_id = ? ORDER BY storage_volumes_backups.id", volumeID)
+}
+
+// GetStoragePoolVolumeBackup returns the backup with the given name of the custom volume with the given ID.
+func (c *Cluster) GetStoragePoolVolumeBackup(volumeID int64, name string) (StoragePoolVolumeBackup, error) {
+	backups, err := c.getStoragePoolVolumeBackups("WHERE storage_volumes_backups.storage_volume_id = ? AND storage_volumes_backups.name = ?", volumeID, name)
+	if err != nil {
+		return StoragePoolVolumeBackup{}, err
+	}
+
+	if len(backups) == 0 {
+		return StoragePoolVolumeBackup{}, ErrNoSuchObject
+	}
+
+	return backups[0], nil
+}
+
+// GetExpiredStoragePoolVolumeBackups returns the expired backups of the custom volumes of the local member.
+func (c *Cluster) GetExpiredStoragePoolVolumeBackups() ([]StoragePoolVolumeBackup, error) {
+	backups, err := c.getStoragePoolVolumeBackups("WHERE storage_volumes.node_id = ?", c.nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	expired := []StoragePoolVolumeBackup{}
+	for _, b := range backups {
+		// Since zero time causes some issues due to timezones, we check the
+		// unix timestamp instead of IsZero().
+		if b.ExpiryDate.Unix() <= 0 {
+			continue
+		}
+
+		if time.Now().Unix()-b.ExpiryDate.Unix() >= 0 {
+			expired = append(expired, b)
+		}
+	}
+
+	return expired, nil
+}
+
+// CreateStoragePoolVolumeBackup creates a new custom volume backup.
+func (c *Cluster) CreateStoragePoolVolumeBackup(args StoragePoolVolumeBackup) error {
+	_, err := c.GetStoragePoolVolumeBackup(args.VolumeID, args.Name)
+	if err == nil {
+		return ErrAlreadyDefined
+	}
+
+	if err != ErrNoSuchObject {
+		return err
+	}
+
+	volumeOnlyInt := 0
+	if args.VolumeOnly {
+		volumeOnlyInt = 1
+	}
+
+	optimizedStorageInt := 0
+	if args.OptimizedStorage {
+		optimizedStorageInt = 1
+	}
+
+	return exec(c, `
+INSERT INTO storage_volumes_backups (storage_volume_id, name, creation_date, expiry_date, volume_only, optimized_storage)
+  VALUES (?, ?, ?, ?, ?, ?)
+`, args.VolumeID, args.Name, args.CreationDate.Unix(), args.ExpiryDate.Unix(), volumeOnlyInt, optimizedStorageInt)
+}
+
+// DeleteStoragePoolVolumeBackup removes the custom volume backup with the given ID from the database.
+func (c *Cluster) DeleteStoragePoolVolumeBackup(id int) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		return storagePoolVolumeBackupExec(tx, "DELETE FROM storage_volumes_backups WHERE id = ?", id)
+	})
+}
+
+// RenameStoragePoolVolumeBackup renames the custom volume backup with the given ID.
+func (c *Cluster) RenameStoragePoolVolumeBackup(id int, newName string) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		return storagePoolVolumeBackupExec(tx, "UPDATE storage_volumes_backups SET name = ? WHERE id = ?", newName, id)
+	})
+}
+
+// storagePoolVolumeBackupExec runs a statement expected to affect exactly one custom volume backup.
+func storagePoolVolumeBackupExec(tx *ClusterTx, stmt string, args ...interface{}) error {
+	result, err := tx.tx.Exec(stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNoSuchObject
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+// +build linux,cgo,!agent
+
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoragePoolVolumeBackups(t *testing.T) {
+	cluster, cleanup := db.NewTestCluster(t)
+	defer cleanup()
+
+	var volumeID int64
+	err := cluster.Transaction(func(tx *db.ClusterTx) error {
+		poolID := addPool(t, tx, "pool1")
+		addVolume(t, tx, poolID, 1, "vol1")
+
+		return tx.Tx().QueryRow("SELECT id FROM storage_volumes WHERE name = 'vol1'").Scan(&volumeID)
+	})
+	require.NoError(t, err)
+
+	args := db.StoragePoolVolumeBackup{
+		VolumeID:     volumeID,
+		Name:         "vol1/backup0",
+		CreationDate: time.Now(),
+		ExpiryDate:   time.Now().Add(-time.Hour),
+		VolumeOnly:   true,
+	}
+
+	err = cluster.CreateStoragePoolVolumeBackup(args)
+	require.NoError(t, err)
+
+	err = cluster.CreateStoragePoolVolumeBackup(args)
+	assert.Equal(t, db.ErrAlreadyDefined, err)
+
+	backup, err := cluster.GetStoragePoolVolumeBackup(volumeID, "vol1/backup0")
+	require.NoError(t, err)
+	assert.Equal(t, "default", backup.ProjectName)
+	assert.Equal(t, "pool1", backup.PoolName)
+	assert.True(t, backup.VolumeOnly)
+	assert.False(t, backup.OptimizedStorage)
+
+	expired, err := cluster.GetExpiredStoragePoolVolumeBackups()
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, backup.ID, expired[0].ID)
+
+	err = cluster.RenameStoragePoolVolumeBackup(backup.ID, "vol1/backup1")
+	require.NoError(t, err)
+
+	backups, err := cluster.GetStoragePoolVolumeBackups(volumeID)
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, "vol1/backup1", backups[0].Name)
+
+	err = cluster.DeleteStoragePoolVolumeBackup(backup.ID)
+	require.NoError(t, err)
+
+	_, err = cluster.GetStoragePoolVolumeBackup(volumeID, "vol1/backup1")
+	assert.Equal(t, db.ErrNoSuchObject, err)
+
+	err = cluster.DeleteStoragePoolVolumeBackup(backup.ID)
+	assert.Equal(t, db.ErrNoSuchObject, err)
+}
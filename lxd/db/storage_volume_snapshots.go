@@ -145,7 +145,8 @@ func (c *Cluster) GetStorageVolumeSnapshotExpiry(volumeID int64) (time.Time, err
 	return expiry, nil
 }
 
-// GetExpiredStorageVolumeSnapshots returns a list of expired volume snapshots.
+// GetExpiredStorageVolumeSnapshots returns a list of expired volume snapshots, only considering the volumes
+// the local member is responsible for.
 func (c *Cluster) GetExpiredStorageVolumeSnapshots() ([]StorageVolumeArgs, error) {
 	var result []StorageVolumeArgs
 	var volumeName string
@@ -160,8 +161,8 @@ FROM storage_volumes_snapshots
 JOIN storage_volumes ON storage_volumes_snapshots.storage_volume_id = storage_volumes.id
 JOIN storage_pools ON storage_volumes.storage_pool_id = storage_pools.id
 JOIN projects ON storage_volumes.project_id = projects.id
-WHERE storage_volumes.type = ?`
-	infmt := []interface{}{StoragePoolVolumeTypeCustom}
+WHERE storage_volumes.type = ?` + localVolumesFilter
+	infmt := []interface{}{StoragePoolVolumeTypeCustom, c.nodeID}
 	outfmt := []interface{}{volumeName, snapshotName, expiryDate, poolName, projectName}
 	dbResults, err := queryScan(c, q, infmt, outfmt)
 	if err != nil {
@@ -37,8 +37,33 @@ func (c *Cluster) GetStoragePoolVolumesNames(poolID int64) ([]string, error) {
 	return out, nil
 }
 
+// localVolumesFilter restricts a query on storage_volumes to the volumes the local member is responsible for
+// in scheduled tasks. Volumes on remote storage have an entry for every member, in which case only the member
+// with the lowest ID is responsible for them.
+const localVolumesFilter = `
+  AND storage_volumes.node_id = ?
+  AND NOT EXISTS (
+    SELECT 1 FROM storage_volumes AS other
+    WHERE other.storage_pool_id = storage_volumes.storage_pool_id
+      AND other.project_id = storage_volumes.project_id
+      AND other.name = storage_volumes.name
+      AND other.type = storage_volumes.type
+      AND other.node_id < storage_volumes.node_id)
+`
+
 // GetStoragePoolVolumesWithType return a list of all volumes of the given type.
 func (c *Cluster) GetStoragePoolVolumesWithType(volumeType int) ([]StorageVolumeArgs, error) {
+	return c.getStoragePoolVolumesWithType(volumeType, false)
+}
+
+// GetResponsibleStoragePoolVolumesWithType returns the volumes of the given type the local member is responsible
+// for, that is the volumes on its local storage pools and, for volumes on remote storage pools, only if it
+// is the member with the lowest ID the volume is defined on.
+func (c *Cluster) GetResponsibleStoragePoolVolumesWithType(volumeType int) ([]StorageVolumeArgs, error) {
+	return c.getStoragePoolVolumesWithType(volumeType, true)
+}
+
+func (c *Cluster) getStoragePoolVolumesWithType(volumeType int, local bool) ([]StorageVolumeArgs, error) {
 	var id int64
 	var name string
 	var description string
@@ -54,6 +79,10 @@ WHERE storage_volumes.type = ?
 `
 
 	inargs := []interface{}{volumeType}
+	if local {
+		stmt += localVolumesFilter
+		inargs = append(inargs, c.nodeID)
+	}
 	outargs := []interface{}{id, name, description, poolName, projectName}
 
 	result, err := queryScan(c, stmt, inargs, outargs)
@@ -55,3 +55,56 @@ INSERT INTO storage_volumes(storage_pool_id, node_id, name, type, project_id) VA
 	_, err := tx.Tx().Exec(stmt, poolID, nodeID, name)
 	require.NoError(t, err)
 }
+
+// Only the volumes the local node is responsible for are returned.
+func TestGetResponsibleStoragePoolVolumesWithType(t *testing.T) {
+	cluster, cleanup := db.NewTestCluster(t)
+	defer cleanup()
+
+	err := cluster.Transaction(func(tx *db.ClusterTx) error {
+		nodeID1 := int64(1) // This is the default local node
+
+		nodeID2, err := tx.CreateNode("node2", "1.2.3.4:666")
+		require.NoError(t, err)
+
+		localPoolID := addPool(t, tx, "local")
+		remotePoolID := addPool(t, tx, "remote")
+
+		stmt := `
+INSERT INTO storage_volumes(storage_pool_id, node_id, name, type, project_id) VALUES (?, ?, ?, ?, 1)
+`
+		volumes := []struct {
+			poolID int64
+			nodeID int64
+			name   string
+		}{
+			{localPoolID, nodeID1, "local1"},
+			{localPoolID, nodeID2, "local2"},
+			{remotePoolID, nodeID1, "shared1"},
+			{remotePoolID, nodeID2, "shared1"},
+			{remotePoolID, nodeID2, "shared2"},
+		}
+
+		for _, v := range volumes {
+			_, err := tx.Tx().Exec(stmt, v.poolID, v.nodeID, v.name, db.StoragePoolVolumeTypeCustom)
+			require.NoError(t, err)
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	volumes, err := cluster.GetResponsibleStoragePoolVolumesWithType(db.StoragePoolVolumeTypeCustom)
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, v := range volumes {
+		names = append(names, v.PoolName+"/"+v.Name)
+	}
+
+	assert.ElementsMatch(t, []string{"local/local1", "remote/shared1"}, names)
+
+	volumes, err = cluster.GetStoragePoolVolumesWithType(db.StoragePoolVolumeTypeCustom)
+	require.NoError(t, err)
+	assert.Len(t, volumes, 5)
+}
@@ -0,0 +1,272 @@
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/pborman/uuid"
+
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// WarningStatus is the status of a warning.
+type WarningStatus int
+
+// Warning statuses.
+const (
+	WarningStatusNew          WarningStatus = 1
+	WarningStatusAcknowledged WarningStatus = 2
+	WarningStatusResolved     WarningStatus = 4
+)
+
+// WarningStatuses maps the warning statuses to their API names.
+var WarningStatuses = map[WarningStatus]string{
+	WarningStatusNew:          api.WarningStatusNew,
+	WarningStatusAcknowledged: api.WarningStatusAcknowledged,
+	WarningStatusResolved:     api.WarningStatusResolved,
+}
+
+// WarningStatusFromString returns the warning status matching the given API name.
+func WarningStatusFromString(name string) (WarningStatus, error) {
+	for status, statusName := range WarningStatuses {
+		if statusName == name {
+			return status, nil
+		}
+	}
+
+	return -1, fmt.Errorf("Unknown warning status %q", name)
+}
+
+// Types of the entities warnings relate to.
+const (
+	WarningEntityTypeInstance      = "instance"
+	WarningEntityTypeStorageVolume = "storage-volume"
+)
+
+// Warning types.
+const (
+	WarningTypeScheduledBackupFailed = "Failed scheduled backup"
+)
+
+// Warning is a value object holding db-related details about a warning.
+type Warning struct {
+	ID            int
+	Node          string
+	Project       string
+	EntityType    string
+	EntityID      int
+	UUID          string
+	Type          string
+	Status        WarningStatus
+	FirstSeenDate time.Time
+	LastSeenDate  time.Time
+	UpdatedDate   time.Time
+	LastMessage   string
+	Count         int
+}
+
+// warningsQuery selects the warnings along with the name of their member and project. The conditions are
+// appended by the callers.
+const warningsQuery = `
+SELECT warnings.id, coalesce(nodes.name, ''), coalesce(projects.name, ''), warnings.entity_type, warnings.entity_id,
+       warnings.uuid, warnings.type, warnings.status, warnings.first_seen_date, warnings.last_seen_date,
+       coalesce(warnings.updated_date, warnings.first_seen_date), warnings.last_message, warnings.count
+    FROM warnings
+    LEFT JOIN nodes ON nodes.id = warnings.node_id
+    LEFT JOIN projects ON projects.id = warnings.project_id
+`
+
+// getWarnings returns the warnings matching the given conditions.
+func (c *ClusterTx) getWarnings(where string, args ...interface{}) ([]Warning, error) {
+	rows, err := c.tx.Query(warningsQuery+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	warnings := []Warning{}
+	for rows.Next() {
+		w := Warning{}
+		err := rows.Scan(&w.ID, &w.Node, &w.Project, &w.EntityType, &w.EntityID, &w.UUID, &w.Type, &w.Status,
+			&w.FirstSeenDate, &w.LastSeenDate, &w.UpdatedDate, &w.LastMessage, &w.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		warnings = append(warnings, w)
+	}
+
+	return warnings, rows.Err()
+}
+
+// GetWarnings returns all the warnings, or only those of the given project if not empty.
+func (c *ClusterTx) GetWarnings(projectName string) ([]Warning, error) {
+	if projectName == "" {
+		return c.getWarnings("ORDER BY warnings.id")
+	}
+
+	return c.getWarnings("WHERE projects.name = ? ORDER BY warnings.id", projectName)
+}
+
+// GetWarning returns the warning with the given UUID.
+func (c *ClusterTx) GetWarning(UUID string) (*Warning, error) {
+	warnings, err := c.getWarnings("WHERE warnings.uuid = ?", UUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(warnings) == 0 {
+		return nil, ErrNoSuchObject
+	}
+
+	return &warnings[0], nil
+}
+
+// UpsertWarningLocalNode records a warning of the given type about an entity of the local member. If the
+// warning already exists, its message and count are updated and it's reopened if it was resolved.
+func (c *ClusterTx) UpsertWarningLocalNode(projectName string, entityType string, entityID int, typeName string, message string) error {
+	var projectID interface{}
+	if projectName != "" {
+		id, err := c.GetProjectID(projectName)
+		if err != nil {
+			return err
+		}
+
+		projectID = id
+	}
+
+	now := time.Now().UTC()
+
+	var id int
+	var status WarningStatus
+	err := c.tx.QueryRow(`
+SELECT id, status FROM warnings
+    WHERE IFNULL(node_id, -1) = ? AND IFNULL(project_id, -1) = IFNULL(?, -1) AND entity_type = ? AND entity_id = ? AND type = ?
+`, c.nodeID, projectID, entityType, entityID, typeName).Scan(&id, &status)
+	if err == sql.ErrNoRows {
+		_, err = c.tx.Exec(`
+INSERT INTO warnings (node_id, project_id, entity_type, entity_id, uuid, type, status, first_seen_date, last_seen_date, updated_date, last_message, count)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)
+`, c.nodeID, projectID, entityType, entityID, uuid.NewRandom().String(), typeName, WarningStatusNew, now, now, now, message)
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Acknowledged warnings stay acknowledged, resolved ones are reopened.
+	if status == WarningStatusResolved {
+		status = WarningStatusNew
+	}
+
+	_, err = c.tx.Exec(`
+UPDATE warnings SET status = ?, last_seen_date = ?, updated_date = ?, last_message = ?, count = count + 1
+    WHERE id = ?
+`, status, now, now, message, id)
+	return err
+}
+
+// ResolveWarningsLocalNode resolves the warnings of the given type about an entity of the local member.
+func (c *ClusterTx) ResolveWarningsLocalNode(projectName string, entityType string, entityID int, typeName string) error {
+	_, err := c.tx.Exec(`
+UPDATE warnings SET status = ?, updated_date = ?
+    WHERE IFNULL(node_id, -1) = ? AND IFNULL(project_id, -1) = IFNULL((SELECT id FROM projects WHERE name = ?), -1)
+          AND entity_type = ? AND entity_id = ? AND type = ? AND status != ?
+`, WarningStatusResolved, time.Now().UTC(), c.nodeID, projectName, entityType, entityID, typeName, WarningStatusResolved)
+	return err
+}
+
+// UpdateWarningStatus sets the status of the warning with the given UUID.
+func (c *ClusterTx) UpdateWarningStatus(UUID string, status WarningStatus) error {
+	result, err := c.tx.Exec("UPDATE warnings SET status = ?, updated_date = ? WHERE uuid = ?", status, time.Now().UTC(), UUID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNoSuchObject
+	}
+
+	return nil
+}
+
+// DeleteWarning deletes the warning with the given UUID.
+func (c *ClusterTx) DeleteWarning(UUID string) error {
+	result, err := c.tx.Exec("DELETE FROM warnings WHERE uuid = ?", UUID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNoSuchObject
+	}
+
+	return nil
+}
+
+// WarningToAPI converts the warning to its API representation, resolving the URL of the entity it relates to.
+func (c *ClusterTx) WarningToAPI(w Warning) (*api.Warning, error) {
+	entityURL := ""
+
+	switch w.EntityType {
+	case WarningEntityTypeInstance:
+		var name string
+		err := c.tx.QueryRow("SELECT name FROM instances WHERE id = ?", w.EntityID).Scan(&name)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		if name != "" {
+			entityURL = fmt.Sprintf("/%s/instances/%s", version.APIVersion, url.PathEscape(name))
+		}
+	case WarningEntityTypeStorageVolume:
+		var poolName, name string
+		err := c.tx.QueryRow(`
+SELECT storage_pools.name, storage_volumes.name FROM storage_volumes
+    JOIN storage_pools ON storage_pools.id = storage_volumes.storage_pool_id
+    WHERE storage_volumes.id = ?
+`, w.EntityID).Scan(&poolName, &name)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		if name != "" {
+			entityURL = fmt.Sprintf("/%s/storage-pools/%s/volumes/custom/%s", version.APIVersion, url.PathEscape(poolName), url.PathEscape(name))
+		}
+	}
+
+	if entityURL != "" && w.Project != "" && w.Project != "default" {
+		entityURL += "?project=" + url.QueryEscape(w.Project)
+	}
+
+	return &api.Warning{
+		WarningPut: api.WarningPut{
+			Status: WarningStatuses[w.Status],
+		},
+		UUID:        w.UUID,
+		Location:    w.Node,
+		Project:     w.Project,
+		Type:        w.Type,
+		Count:       w.Count,
+		FirstSeenAt: w.FirstSeenDate,
+		LastSeenAt:  w.LastSeenDate,
+		LastMessage: w.LastMessage,
+		EntityURL:   entityURL,
+	}, nil
+}
@@ -0,0 +1,52 @@
+// +build linux,cgo,!agent
+
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+)
+
+// Record a warning, record it again, resolve it and reopen it.
+func TestWarning(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	err := tx.UpsertWarningLocalNode("default", db.WarningEntityTypeInstance, 1, db.WarningTypeScheduledBackupFailed, "first")
+	require.NoError(t, err)
+
+	err = tx.UpsertWarningLocalNode("default", db.WarningEntityTypeInstance, 1, db.WarningTypeScheduledBackupFailed, "second")
+	require.NoError(t, err)
+
+	warnings, err := tx.GetWarnings("default")
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, 2, warnings[0].Count)
+	assert.Equal(t, "second", warnings[0].LastMessage)
+	assert.Equal(t, db.WarningStatusNew, warnings[0].Status)
+
+	err = tx.ResolveWarningsLocalNode("default", db.WarningEntityTypeInstance, 1, db.WarningTypeScheduledBackupFailed)
+	require.NoError(t, err)
+
+	warning, err := tx.GetWarning(warnings[0].UUID)
+	require.NoError(t, err)
+	assert.Equal(t, db.WarningStatusResolved, warning.Status)
+
+	err = tx.UpsertWarningLocalNode("default", db.WarningEntityTypeInstance, 1, db.WarningTypeScheduledBackupFailed, "third")
+	require.NoError(t, err)
+
+	warning, err = tx.GetWarning(warnings[0].UUID)
+	require.NoError(t, err)
+	assert.Equal(t, db.WarningStatusNew, warning.Status)
+	assert.Equal(t, 3, warning.Count)
+
+	err = tx.DeleteWarning(warning.UUID)
+	require.NoError(t, err)
+
+	_, err = tx.GetWarning(warning.UUID)
+	assert.Equal(t, db.ErrNoSuchObject, err)
+}
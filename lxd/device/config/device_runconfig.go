@@ -33,6 +33,14 @@ type RootFSEntryItem struct {
 	Opts []string // Describes the mount options associated with the filesystem.
 }
 
+// USBDeviceItem represents a single host USB device passed to an instance.
+type USBDeviceItem struct {
+	DeviceName     string // The name of the instance device the USB device belongs to.
+	HostDevicePath string // The path of the USB device on the host, empty when it should be detached.
+	BusNum         int    // The host USB bus number.
+	DevNum         int    // The host USB device number.
+}
+
 // RunConfig represents LXD defined run-time config used for device setup/cleanup.
 type RunConfig struct {
 	RootFS           RootFSEntryItem  // RootFS to setup.
@@ -42,4 +50,5 @@ type RunConfig struct {
 	Uevents          [][]string       // Uevents to inject.
 	PostHooks        []func() error   // Functions to be run after device attach/detach.
 	GPUDevice        []RunConfigItem  // GPU device configuration settings.
+	USBDevice        []USBDeviceItem  // USB devices to attach/detach.
 }
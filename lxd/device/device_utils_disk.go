@@ -3,8 +3,11 @@ package device
 import (
 	"bufio"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -266,3 +269,88 @@ func diskCephfsOptions(clusterName string, userName string, fsName string, fsPat
 
 	return srcpath, fsOptions, nil
 }
+
+// diskVirtiofsdPaths are the locations distributions install the virtiofsd helper to outside of PATH.
+var diskVirtiofsdPaths = []string{"/usr/lib/qemu/virtiofsd", "/usr/libexec/virtiofsd"}
+
+// diskVirtiofsdPath returns the path of the virtiofsd helper or an empty string if it isn't installed.
+func diskVirtiofsdPath() string {
+	path, err := exec.LookPath("virtiofsd")
+	if err == nil {
+		return path
+	}
+
+	for _, path := range diskVirtiofsdPaths {
+		if shared.PathExists(path) {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// diskVirtiofsdCgroupPath returns the path of the cgroup with the given name, created next to the cgroup LXD
+// runs in (like the cgroups of the containers). Only the unified hierarchy is supported.
+func diskVirtiofsdCgroupPath(name string) (string, error) {
+	content, err := ioutil.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			return filepath.Join("/sys/fs/cgroup", filepath.Dir(strings.TrimPrefix(line, "0::")), name), nil
+		}
+	}
+
+	return "", fmt.Errorf("Couldn't find the unified cgroup of LXD")
+}
+
+// diskVirtiofsdConfine moves the virtiofsd process into a dedicated cgroup.
+func diskVirtiofsdConfine(name string, pid int64) error {
+	cgroupPath, err := diskVirtiofsdCgroupPath(name)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(cgroupPath, 0755)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(cgroupPath, "cgroup.procs"), []byte(fmt.Sprintf("%d", pid)), 0644)
+}
+
+// diskVirtiofsdRelease kills any process left in the dedicated cgroup of a virtiofsd process and removes it.
+func diskVirtiofsdRelease(name string) error {
+	cgroupPath, err := diskVirtiofsdCgroupPath(name)
+	if err != nil || !shared.PathExists(cgroupPath) {
+		return nil
+	}
+
+	procs, err := ioutil.ReadFile(filepath.Join(cgroupPath, "cgroup.procs"))
+	if err != nil {
+		return err
+	}
+
+	for _, field := range strings.Fields(string(procs)) {
+		pid, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+
+		unix.Kill(pid, unix.SIGKILL)
+	}
+
+	// The cgroup can only be removed once the killed processes are gone.
+	for i := 0; i < 10; i++ {
+		err = os.Remove(cgroupPath)
+		if err == nil {
+			return nil
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return err
+}
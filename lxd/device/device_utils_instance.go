@@ -7,6 +7,7 @@ import (
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/state"
 )
 
@@ -66,3 +67,15 @@ func instanceSupported(instType instancetype.Type, supportedTypes ...instancetyp
 
 	return false
 }
+
+// deviceHotplugSendLifecycle emits the lifecycle event of a host device being attached to or detached from a
+// running instance in response to a hotplug event.
+func deviceHotplugSendLifecycle(s *state.State, inst instance.Instance, deviceName string, action string, ctx map[string]interface{}) {
+	lifecycleAction := lifecycle.InstanceDeviceHotplugged
+	if action == "remove" {
+		lifecycleAction = lifecycle.InstanceDeviceUnplugged
+	}
+
+	ctx["device"] = deviceName
+	s.Events.SendLifecycle(inst.Project(), lifecycleAction.Event(inst, nil, ctx))
+}
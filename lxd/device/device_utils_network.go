@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"strconv"
 	"strings"
 	"sync"
@@ -338,7 +339,7 @@ func networkCreateTap(hostName string, m deviceConfig.Device) error {
 // networkSetupHostVethRoutes configures a nic device's host side veth routes.
 // Accepts an optional oldDevice that will have its old host routes removed before adding the new device routes.
 // This allows live update of a veth device.
-func networkSetupHostVethRoutes(s *state.State, device deviceConfig.Device, oldDevice deviceConfig.Device, v map[string]string) error {
+func networkSetupHostVethRoutes(s *state.State, projectName string, device deviceConfig.Device, oldDevice deviceConfig.Device, v map[string]string) error {
 	// Check whether host device resolution succeeded.
 	if device["host_name"] == "" {
 		return fmt.Errorf("Failed to find host side veth name for device %q", device["name"])
@@ -347,11 +348,11 @@ func networkSetupHostVethRoutes(s *state.State, device deviceConfig.Device, oldD
 	// If oldDevice provided, remove old routes if any remain.
 	if oldDevice != nil {
 		networkVethFillFromVolatile(oldDevice, v)
-		networkRemoveVethRoutes(s, oldDevice)
+		networkRemoveVethRoutes(s, projectName, oldDevice)
 	}
 
 	// Setup static routes to container.
-	err := networkSetVethRoutes(s, device)
+	err := networkSetVethRoutes(s, projectName, device)
 	if err != nil {
 		return err
 	}
@@ -373,11 +374,11 @@ func networkVethFillFromVolatile(device deviceConfig.Device, volatile map[string
 }
 
 // networkSetVethRoutes applies any static routes configured from the host to the container nic.
-func networkSetVethRoutes(s *state.State, m deviceConfig.Device) error {
+func networkSetVethRoutes(s *state.State, projectName string, m deviceConfig.Device) error {
 	// Decide whether the route should point to the veth parent or the bridge parent.
 	routeDev := m["host_name"]
 
-	nicType, err := nictype.NICType(s, m)
+	nicType, err := nictype.NICType(s, projectName, m)
 	if err != nil {
 		return err
 	}
@@ -417,10 +418,10 @@ func networkSetVethRoutes(s *state.State, m deviceConfig.Device) error {
 
 // networkRemoveVethRoutes removes any routes created for this device on the host that were first added
 // with networkSetVethRoutes(). Expects to be passed the device config from the oldExpandedDevices.
-func networkRemoveVethRoutes(s *state.State, m deviceConfig.Device) {
+func networkRemoveVethRoutes(s *state.State, projectName string, m deviceConfig.Device) {
 	// Decide whether the route should point to the veth parent or the bridge parent
 	routeDev := m["host_name"]
-	nicType, err := nictype.NICType(s, m)
+	nicType, err := nictype.NICType(s, projectName, m)
 	if err != nil {
 		logger.Errorf("Failed to get NIC type for %q", m["name"])
 		return
@@ -466,8 +467,6 @@ func networkRemoveVethRoutes(s *state.State, m deviceConfig.Device) {
 
 // networkSetupHostVethLimits applies any network rate limits to the veth device specified in the config.
 func networkSetupHostVethLimits(m deviceConfig.Device) error {
-	var err error
-
 	veth := m["host_name"]
 
 	if veth == "" || !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", veth)) {
@@ -481,20 +480,9 @@ func networkSetupHostVethLimits(m deviceConfig.Device) error {
 	}
 
 	// Parse the values
-	var ingressInt int64
-	if m["limits.ingress"] != "" {
-		ingressInt, err = units.ParseBitSizeString(m["limits.ingress"])
-		if err != nil {
-			return err
-		}
-	}
-
-	var egressInt int64
-	if m["limits.egress"] != "" {
-		egressInt, err = units.ParseBitSizeString(m["limits.egress"])
-		if err != nil {
-			return err
-		}
+	ingressInt, egressInt, err := NetworkLimits(m)
+	if err != nil {
+		return err
 	}
 
 	// Clean any existing entry
@@ -534,6 +522,36 @@ func networkSetupHostVethLimits(m deviceConfig.Device) error {
 	return nil
 }
 
+// NetworkLimits returns the ingress and egress limits of a NIC in bit/s, as seen from the instance, 0 meaning
+// unlimited. The limits.max key takes precedence over the limits.ingress and limits.egress keys.
+func NetworkLimits(m deviceConfig.Device) (int64, int64, error) {
+	ingress := m["limits.ingress"]
+	egress := m["limits.egress"]
+	if m["limits.max"] != "" {
+		ingress = m["limits.max"]
+		egress = m["limits.max"]
+	}
+
+	var err error
+	var ingressInt int64
+	if ingress != "" {
+		ingressInt, err = units.ParseBitSizeString(ingress)
+		if err != nil {
+			return -1, -1, err
+		}
+	}
+
+	var egressInt int64
+	if egress != "" {
+		egressInt, err = units.ParseBitSizeString(egress)
+		if err != nil {
+			return -1, -1, err
+		}
+	}
+
+	return ingressInt, egressInt, nil
+}
+
 // networkValidGateway validates the gateway value.
 func networkValidGateway(value string) error {
 	if shared.StringInSlice(value, []string{"none", "auto"}) {
@@ -594,3 +612,18 @@ func networkInterfaceBindWait(ifName string) error {
 
 	return fmt.Errorf("Bind of interface %q took too long", ifName)
 }
+
+// networkParentCheckUp returns an error if the named parent interface is administratively down, as instance
+// traffic couldn't be forwarded through it.
+func networkParentCheckUp(ifName string) error {
+	iface, err := net.InterfaceByName(ifName)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to get parent device %q", ifName)
+	}
+
+	if iface.Flags&net.FlagUp == 0 {
+		return fmt.Errorf("Parent device %q is down", ifName)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package device
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/state"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// PCIEvent represents the properties of a PCI device uevent.
+type PCIEvent struct {
+	Action string
+
+	Vendor   string
+	Product  string
+	SlotName string
+}
+
+// pciHandlers stores the event handler callbacks for PCI events.
+var pciHandlers = map[string]func(PCIEvent) (*deviceConfig.RunConfig, error){}
+
+// pciMutex controls access to the pciHandlers map.
+var pciMutex sync.Mutex
+
+// pciRegisterHandler registers a handler function to be called whenever a PCI device event occurs.
+func pciRegisterHandler(inst instance.Instance, deviceName string, handler func(PCIEvent) (*deviceConfig.RunConfig, error)) {
+	pciMutex.Lock()
+	defer pciMutex.Unlock()
+
+	// Null delimited string of project name, instance name and device name.
+	key := fmt.Sprintf("%s\000%s\000%s", inst.Project(), inst.Name(), deviceName)
+	pciHandlers[key] = handler
+}
+
+// pciUnregisterHandler removes a registered PCI handler function for a device.
+func pciUnregisterHandler(inst instance.Instance, deviceName string) {
+	pciMutex.Lock()
+	defer pciMutex.Unlock()
+
+	// Null delimited string of project name, instance name and device name.
+	key := fmt.Sprintf("%s\000%s\000%s", inst.Project(), inst.Name(), deviceName)
+	delete(pciHandlers, key)
+}
+
+// PCIRunHandlers executes any handlers registered for PCI events.
+func PCIRunHandlers(state *state.State, event *PCIEvent) {
+	pciMutex.Lock()
+	defer pciMutex.Unlock()
+
+	for key, hook := range pciHandlers {
+		keyParts := strings.SplitN(key, "\000", 3)
+		projectName := keyParts[0]
+		instanceName := keyParts[1]
+		deviceName := keyParts[2]
+
+		if hook == nil {
+			delete(pciHandlers, key)
+			continue
+		}
+
+		runConf, err := hook(*event)
+		if err != nil {
+			logger.Error("PCI event hook failed", log.Ctx{"err": err, "project": projectName, "instance": instanceName, "device": deviceName})
+			continue
+		}
+
+		// If runConf supplied, load instance and call its PCI event handler function so
+		// any instance specific device actions can occur.
+		if runConf != nil {
+			instance, err := instance.LoadByProjectAndName(state, projectName, instanceName)
+			if err != nil {
+				logger.Error("PCI event loading instance failed", log.Ctx{"err": err, "project": projectName, "instance": instanceName, "device": deviceName})
+				continue
+			}
+
+			err = instance.DeviceEventHandler(runConf)
+			if err != nil {
+				logger.Error("PCI event instance handler failed", log.Ctx{"err": err, "project": projectName, "instance": instanceName, "device": deviceName})
+				continue
+			}
+
+			deviceHotplugSendLifecycle(state, instance, deviceName, event.Action, map[string]interface{}{
+				"vendorid":  event.Vendor,
+				"productid": event.Product,
+				"pci":       event.SlotName,
+			})
+		}
+	}
+}
+
+// PCINewEvent instantiates a new PCIEvent struct from the PCI_ID ("vendor:product") and PCI_SLOT_NAME uevent
+// properties.
+func PCINewEvent(action string, pciID string, slotName string) (PCIEvent, error) {
+	parts := strings.SplitN(pciID, ":", 2)
+	if len(parts) != 2 {
+		return PCIEvent{}, fmt.Errorf("Invalid PCI ID %q", pciID)
+	}
+
+	return PCIEvent{
+		Action:   action,
+		Vendor:   strings.ToLower(parts[0]),
+		Product:  strings.ToLower(parts[1]),
+		SlotName: slotName,
+	}, nil
+}
@@ -90,6 +90,12 @@ func UnixHotplugRunHandlers(state *state.State, event *UnixHotplugEvent) {
 				logger.Error("Unix hotplug event instance handler failed", log.Ctx{"err": err, "project": projectName, "instance": instanceName, "device": deviceName})
 				continue
 			}
+
+			deviceHotplugSendLifecycle(state, instance, deviceName, event.Action, map[string]interface{}{
+				"vendorid":  event.Vendor,
+				"productid": event.Product,
+				"path":      event.Path,
+			})
 		}
 	}
 }
@@ -24,6 +24,8 @@ type USBEvent struct {
 	Path        string
 	Major       uint32
 	Minor       uint32
+	BusNum      int
+	DevNum      int
 	UeventParts []string
 	UeventLen   int
 }
@@ -90,6 +92,12 @@ func USBRunHandlers(state *state.State, event *USBEvent) {
 				logger.Error("USB event instance handler failed", log.Ctx{"err": err, "project": projectName, "instance": instanceName, "device": deviceName})
 				continue
 			}
+
+			deviceHotplugSendLifecycle(state, instance, deviceName, event.Action, map[string]interface{}{
+				"vendorid":  event.Vendor,
+				"productid": event.Product,
+				"path":      event.Path,
+			})
 		}
 	}
 }
@@ -106,17 +114,18 @@ func USBNewEvent(action string, vendor string, product string, major string, min
 		return USBEvent{}, err
 	}
 
+	busnumInt, err := strconv.Atoi(busnum)
+	if err != nil {
+		return USBEvent{}, err
+	}
+
+	devnumInt, err := strconv.Atoi(devnum)
+	if err != nil {
+		return USBEvent{}, err
+	}
+
 	path := devname
 	if devname == "" {
-		busnumInt, err := strconv.Atoi(busnum)
-		if err != nil {
-			return USBEvent{}, err
-		}
-
-		devnumInt, err := strconv.Atoi(devnum)
-		if err != nil {
-			return USBEvent{}, err
-		}
 		path = fmt.Sprintf("/dev/bus/usb/%03d/%03d", busnumInt, devnumInt)
 	} else {
 		if !filepath.IsAbs(devname) {
@@ -131,6 +140,8 @@ func USBNewEvent(action string, vendor string, product string, major string, min
 		path,
 		uint32(majorInt),
 		uint32(minorInt),
+		busnumInt,
+		devnumInt,
 		ueventParts,
 		ueventLen,
 	}, nil
@@ -26,7 +26,9 @@ import (
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/idmap"
+	log "github.com/lxc/lxd/shared/log15"
 	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/osarch"
 	"github.com/lxc/lxd/shared/subprocess"
 	"github.com/lxc/lxd/shared/units"
 	"github.com/lxc/lxd/shared/validate"
@@ -203,6 +205,21 @@ func (d *disk) validateConfig(instConf instance.ConfigReader) error {
 			if contentType == db.StoragePoolVolumeContentTypeBlock && d.inst.Type() != instancetype.VM {
 				return fmt.Errorf("Block volumes cannot be used on containers")
 			}
+
+			// Block volumes may only be attached to a single instance, unless they have been marked as
+			// shared (in which case the guests are expected to coordinate access themselves).
+			if contentType == db.StoragePoolVolumeContentTypeBlock && !shared.IsTrue(volume.Config["security.shared"]) {
+				usedBy, err := storagePools.VolumeUsedByRunningInstancesWithProfilesGet(d.state, d.inst.Project(), d.config["pool"], d.config["source"], db.StoragePoolVolumeTypeNameCustom, false)
+				if err != nil {
+					return err
+				}
+
+				for _, instName := range usedBy {
+					if instName != d.inst.Name() {
+						return fmt.Errorf("Block storage volume %q is already attached to instance %q and doesn't have security.shared enabled", d.config["source"], instName)
+					}
+				}
+			}
 		}
 	}
 
@@ -485,15 +502,28 @@ func (d *disk) startVM() (*deviceConfig.RunConfig, error) {
 				DevName: d.name,
 			}
 
-			// If the source being added is a directory, then we will be using 9p directory sharing to mount
-			// the directory inside the VM, as such we need to indicate to the VM the target path to mount to.
+			// If the source being added is a directory, then we will be using virtiofs or 9p directory sharing
+			// to mount the directory inside the VM, as such we need to indicate to the VM the target path to
+			// mount to.
 			if shared.IsDir(srcPath) {
 				mount.TargetPath = d.config["path"]
 				mount.FSType = "9p"
 
+				virtiofsdPath := diskVirtiofsdPath()
+
 				if shared.IsTrue(d.config["readonly"]) {
 					// Don't use proxy in readonly mode.
 					mount.Opts = append(mount.Opts, "ro")
+				} else if virtiofsdPath != "" && d.inst.Architecture() == osarch.ARCH_64BIT_INTEL_X86 {
+					// Use virtiofs when the helper is available and the VM memory can be shared with it
+					// (only possible on x86_64), falling back to 9p otherwise.
+					sockPath, err := d.startVirtiofsd(virtiofsdPath, srcPath, revert)
+					if err != nil {
+						return nil, err
+					}
+
+					mount.FSType = "virtiofs"
+					mount.DevPath = sockPath // Use socket path as dev path so qemu connects to virtiofsd.
 				} else {
 					sockPath := filepath.Join(d.inst.DevicesPath(), fmt.Sprintf("%s.sock", d.name))
 					mount.DevPath = sockPath // Use socket path as dev path so qemu connects to proxy.
@@ -1126,10 +1156,84 @@ func (d *disk) Stop() (*deviceConfig.RunConfig, error) {
 	return &runConf, nil
 }
 
+// startVirtiofsd starts the virtiofsd helper sharing a directory with the VM and returns the path of the
+// socket qemu should connect to.
+func (d *disk) startVirtiofsd(virtiofsdPath string, srcPath string, reverter *revert.Reverter) (string, error) {
+	sockPath := filepath.Join(d.inst.DevicesPath(), fmt.Sprintf("%s.sock", d.name))
+
+	// Remove old socket if needed.
+	os.Remove(sockPath)
+
+	// Start virtiofsd in non-daemon mode and as root so that when the VM process is started as an
+	// unprivileged user, we can still share directories that process cannot access.
+	proc, err := subprocess.NewProcess(virtiofsdPath, []string{fmt.Sprintf("--socket-path=%s", sockPath), "-o", fmt.Sprintf("source=%s", srcPath), "-o", "cache=none"}, "", "")
+	if err != nil {
+		return "", err
+	}
+
+	err = proc.Start()
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to start virtiofsd for device %q", d.name)
+	}
+
+	reverter.Add(func() { proc.Stop() })
+
+	pidPath := filepath.Join(d.inst.DevicesPath(), fmt.Sprintf("%s.pid", d.name))
+	err = proc.Save(pidPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to save virtiofsd state for device %q", d.name)
+	}
+
+	// Confine virtiofsd to its own cgroup so that its resource usage is accounted separately and nothing it
+	// spawns outlives the device.
+	if d.state.OS.CGInfo.Layout == cgroup.CgroupsUnified {
+		pid, err := proc.GetPid()
+		if err == nil {
+			err = diskVirtiofsdConfine(d.virtiofsdCgroupName(), pid)
+		}
+
+		if err != nil {
+			logger.Warn("Failed to confine virtiofsd to its own cgroup", log.Ctx{"device": d.name, "instance": d.inst.Name(), "project": d.inst.Project(), "err": err})
+		}
+	}
+
+	// Wait for socket file to exist (as otherwise qemu can race the creation of this file).
+	for i := 0; i < 10; i++ {
+		if shared.PathExists(sockPath) {
+			break
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// Allow the unprivileged qemu process to connect to the socket.
+	if d.state.OS.UnprivUser != "" {
+		err = os.Chown(sockPath, d.state.OS.UnprivUID, -1)
+		if err != nil {
+			return "", errors.Wrapf(err, "Failed to change ownership of virtiofsd socket for device %q", d.name)
+		}
+	}
+
+	return sockPath, nil
+}
+
+// virtiofsdCgroupName returns the name of the cgroup confining the virtiofsd helper of the device.
+func (d *disk) virtiofsdCgroupName() string {
+	return fmt.Sprintf("lxd.virtiofsd.%s.%s", project.Instance(d.inst.Project(), d.inst.Name()), d.name)
+}
+
 func (d *disk) stopVM() (*deviceConfig.RunConfig, error) {
 	pidPath := filepath.Join(d.inst.DevicesPath(), fmt.Sprintf("%s.pid", d.name))
 
-	// VM disk dir shares uses virtfs-proxy-helper, so we should stop that if it is running.
+	// Remove the cgroup which virtiofsd was confined to (if any) along with anything left in it.
+	defer func() {
+		err := diskVirtiofsdRelease(d.virtiofsdCgroupName())
+		if err != nil {
+			logger.Warn("Failed to remove virtiofsd cgroup", log.Ctx{"device": d.name, "instance": d.inst.Name(), "project": d.inst.Project(), "err": err})
+		}
+	}()
+
+	// VM disk dir shares use virtfs-proxy-helper or virtiofsd, so we should stop that if it is running.
 	if shared.PathExists(pidPath) {
 		proc, err := subprocess.ImportProcess(pidPath)
 		if err != nil {
@@ -16,7 +16,9 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/resources"
+	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/validate"
 )
 
@@ -47,6 +49,10 @@ func (d *gpu) validateConfig(instConf instance.ConfigReader) error {
 		"uid":       unixValidUserID,
 		"gid":       unixValidUserID,
 		"mode":      unixValidOctalFileMode,
+		"gputype": validate.Optional(func(value string) error {
+			return validate.IsOneOf(value, []string{"physical", "mig", "sriov"})
+		}),
+		"mig.uuid": validate.IsAny,
 	}
 
 	err := d.config.Validate(rules)
@@ -70,6 +76,25 @@ func (d *gpu) validateConfig(instConf instance.ConfigReader) error {
 		}
 	}
 
+	switch d.config["gputype"] {
+	case "mig":
+		if !instanceSupported(instConf.Type(), instancetype.Container) {
+			return fmt.Errorf("MIG GPUs are only supported on containers")
+		}
+
+		if d.config["mig.uuid"] == "" {
+			return fmt.Errorf(`"mig.uuid" is required when "gputype" is "mig"`)
+		}
+	case "sriov":
+		if !instanceSupported(instConf.Type(), instancetype.VM) {
+			return fmt.Errorf("SR-IOV GPUs are only supported on virtual machines")
+		}
+	}
+
+	if d.config["mig.uuid"] != "" && d.config["gputype"] != "mig" {
+		return fmt.Errorf(`Cannot use "mig.uuid" when "gputype" isn't "mig"`)
+	}
+
 	if instConf.Type() == instancetype.VM {
 		for _, field := range []string{"uid", "gid", "mode"} {
 			if d.config[field] != "" {
@@ -101,9 +126,102 @@ func (d *gpu) Start() (*deviceConfig.RunConfig, error) {
 		return d.startVM()
 	}
 
+	if d.config["gputype"] == "mig" {
+		return d.startContainerMIG()
+	}
+
 	return d.startContainer()
 }
 
+// gpuGetReserved returns the MIG instance UUIDs and the PCI slots of the virtual functions in use by the GPU
+// devices of the other instances on the local member.
+func gpuGetReserved(s *state.State, inst instance.Instance) (map[string]struct{}, error) {
+	reservedDevicesMutex.Lock()
+	defer reservedDevicesMutex.Unlock()
+
+	instances, err := instance.LoadNodeAll(s, instancetype.Any)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved := map[string]struct{}{}
+	for _, other := range instances {
+		if other.Project() == inst.Project() && other.Name() == inst.Name() {
+			continue
+		}
+
+		config := other.ExpandedConfig()
+		for devName, devConfig := range other.ExpandedDevices() {
+			if devConfig["type"] != "gpu" {
+				continue
+			}
+
+			// MIG instances are only recorded in the device config, so only count the running containers.
+			if devConfig["gputype"] == "mig" && other.IsRunning() {
+				reserved[devConfig["mig.uuid"]] = struct{}{}
+			}
+
+			slotName := config[fmt.Sprintf("volatile.%s.last_state.pci.slot.name", devName)]
+			if slotName != "" {
+				reserved[slotName] = struct{}{}
+			}
+		}
+	}
+
+	return reserved, nil
+}
+
+// startContainerMIG checks the requested NVIDIA MIG instance is available and has the NVIDIA container runtime
+// expose it to the container.
+func (d *gpu) startContainerMIG() (*deviceConfig.RunConfig, error) {
+	if !shared.IsTrue(d.inst.ExpandedConfig()["nvidia.runtime"]) {
+		return nil, fmt.Errorf(`MIG GPUs require "nvidia.runtime" to be enabled`)
+	}
+
+	gpus, err := resources.GetGPU()
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	for _, gpu := range gpus.Cards {
+		// Skip any cards that don't match the vendorid, pci, productid or DRM ID settings (if specified).
+		if (d.config["vendorid"] != "" && gpu.VendorID != d.config["vendorid"]) ||
+			(d.config["pci"] != "" && gpu.PCIAddress != d.config["pci"]) ||
+			(d.config["productid"] != "" && gpu.ProductID != d.config["productid"]) ||
+			(d.config["id"] != "" && (gpu.DRM == nil || fmt.Sprintf("%d", gpu.DRM.ID) != d.config["id"])) {
+			continue
+		}
+
+		if gpu.Nvidia != nil {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("Failed to detect requested NVIDIA GPU device")
+	}
+
+	reserved, err := gpuGetReserved(d.state, d.inst)
+	if err != nil {
+		return nil, err
+	}
+
+	_, inUse := reserved[d.config["mig.uuid"]]
+	if inUse {
+		return nil, fmt.Errorf("MIG instance %q is already in use by another instance", d.config["mig.uuid"])
+	}
+
+	runConf := deviceConfig.RunConfig{}
+	runConf.GPUDevice = append(runConf.GPUDevice, deviceConfig.RunConfigItem{
+		Key:   "NVIDIA_VISIBLE_DEVICES",
+		Value: fmt.Sprintf("MIG-%s", strings.TrimPrefix(d.config["mig.uuid"], "MIG-")),
+	})
+
+	return &runConf, nil
+}
+
 // startContainer detects the requested GPU devices and sets up unix-char devices.
 // Returns RunConfig populated with mount info required to pass the unix-char devices into the container.
 func (d *gpu) startContainer() (*deviceConfig.RunConfig, error) {
@@ -225,6 +343,7 @@ func (d *gpu) startVM() (*deviceConfig.RunConfig, error) {
 
 	saveData := make(map[string]string)
 	var pciAddress string
+	var sriov *api.ResourcesGPUCardSRIOV
 
 	for _, gpu := range gpus.Cards {
 		// Skip any cards that don't match the vendorid, pci, productid or DRM ID settings (if specified).
@@ -240,12 +359,39 @@ func (d *gpu) startVM() (*deviceConfig.RunConfig, error) {
 		}
 
 		pciAddress = gpu.PCIAddress
+		sriov = gpu.SRIOV
 	}
 
 	if pciAddress == "" {
 		return nil, fmt.Errorf("Failed to detect requested GPU device")
 	}
 
+	// Pass the first virtual function of the card which isn't used by another instance.
+	if d.config["gputype"] == "sriov" {
+		if sriov == nil || len(sriov.VFs) == 0 {
+			return nil, fmt.Errorf("GPU %q doesn't have any SR-IOV virtual functions", pciAddress)
+		}
+
+		reserved, err := gpuGetReserved(d.state, d.inst)
+		if err != nil {
+			return nil, err
+		}
+
+		parentAddress := pciAddress
+		pciAddress = ""
+		for _, vf := range sriov.VFs {
+			_, inUse := reserved[vf.PCIAddress]
+			if !inUse {
+				pciAddress = vf.PCIAddress
+				break
+			}
+		}
+
+		if pciAddress == "" {
+			return nil, fmt.Errorf("No free virtual function left on GPU %q", parentAddress)
+		}
+	}
+
 	// Get PCI information about the GPU device.
 	devicePath := filepath.Join("/sys/bus/pci/devices", pciAddress)
 	pciDev, err := pciParseUeventFile(filepath.Join(devicePath, "uevent"))
@@ -256,7 +402,12 @@ func (d *gpu) startVM() (*deviceConfig.RunConfig, error) {
 	saveData["last_state.pci.slot.name"] = pciDev.SlotName
 	saveData["last_state.pci.driver"] = pciDev.Driver
 
-	err = d.pciDeviceDriverOverrideIOMMU(pciDev, "vfio-pci", false)
+	// Virtual functions are overridden on their own, leaving the parent card and its other functions alone.
+	if d.config["gputype"] == "sriov" {
+		err = pciDeviceDriverOverride(pciDev, "vfio-pci")
+	} else {
+		err = d.pciDeviceDriverOverrideIOMMU(pciDev, "vfio-pci", false)
+	}
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed to override IOMMU group driver")
 	}
@@ -275,6 +426,86 @@ func (d *gpu) startVM() (*deviceConfig.RunConfig, error) {
 	return &runConf, nil
 }
 
+// Register is run after the device is started or when LXD starts.
+func (d *gpu) Register() error {
+	// Only physical GPUs passed to VMs and matched by vendor or product can be found again after being hotplugged.
+	if d.inst.Type() != instancetype.VM || d.config["pci"] != "" || d.config["id"] != "" || !shared.StringInSlice(d.config["gputype"], []string{"", "physical"}) {
+		return nil
+	}
+
+	devConfig := d.config
+	deviceName := d.name
+	volatileGet := d.volatileGet
+	volatileSet := d.volatileSet
+
+	// Handler for when a PCI event occurs.
+	f := func(e PCIEvent) (*deviceConfig.RunConfig, error) {
+		if (devConfig["vendorid"] != "" && devConfig["vendorid"] != e.Vendor) || (devConfig["productid"] != "" && devConfig["productid"] != e.Product) {
+			return nil, nil
+		}
+
+		v := volatileGet()
+		runConf := deviceConfig.RunConfig{}
+
+		if e.Action == "add" {
+			// VMs cannot match multiple GPUs per device.
+			if v["last_state.pci.slot.name"] != "" {
+				return nil, nil
+			}
+
+			pciDev, err := pciParseUeventFile(filepath.Join("/sys/bus/pci/devices", e.SlotName, "uevent"))
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to get PCI device info for GPU %q", e.SlotName)
+			}
+
+			err = d.pciDeviceDriverOverrideIOMMU(pciDev, "vfio-pci", false)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to override IOMMU group driver")
+			}
+
+			err = volatileSet(map[string]string{
+				"last_state.pci.slot.name": pciDev.SlotName,
+				"last_state.pci.driver":    pciDev.Driver,
+			})
+			if err != nil {
+				return nil, err
+			}
+		} else if e.Action == "remove" {
+			if v["last_state.pci.slot.name"] != e.SlotName {
+				return nil, nil
+			}
+
+			err := volatileSet(map[string]string{
+				"last_state.pci.slot.name": "",
+				"last_state.pci.driver":    "",
+			})
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, nil
+		}
+
+		// The PCI slot name is left empty when the GPU should be detached.
+		pciSlotName := ""
+		if e.Action == "add" {
+			pciSlotName = e.SlotName
+		}
+
+		runConf.GPUDevice = append(runConf.GPUDevice,
+			[]deviceConfig.RunConfigItem{
+				{Key: "devName", Value: deviceName},
+				{Key: "pciSlotName", Value: pciSlotName},
+			}...)
+
+		return &runConf, nil
+	}
+
+	pciRegisterHandler(d.inst, d.name, f)
+
+	return nil
+}
+
 // pciDeviceDriverOverrideIOMMU overrides all functions in the specified device's IOMMU group (if exists) that
 // are functions of the device. If IOMMU group doesn't exist, only the device itself is overridden.
 // If restore argument is true, then IOMMU VF devices related to the main device have their driver override cleared
@@ -330,6 +561,9 @@ func (d *gpu) pciDeviceDriverOverrideIOMMU(pciDev pciDevice, driverOverride stri
 
 // Stop is run when the device is removed from the instance.
 func (d *gpu) Stop() (*deviceConfig.RunConfig, error) {
+	// Unregister any PCI event handlers for this device.
+	pciUnregisterHandler(d.inst, d.name)
+
 	runConf := deviceConfig.RunConfig{
 		PostHooks: []func() error{d.postStop},
 	}
@@ -368,7 +602,12 @@ func (d *gpu) postStop() error {
 			SlotName: v["last_state.pci.slot.name"],
 		}
 
-		err := d.pciDeviceDriverOverrideIOMMU(pciDev, v["last_state.pci.driver"], true)
+		var err error
+		if d.config["gputype"] == "sriov" {
+			err = pciDeviceDriverOverride(pciDev, v["last_state.pci.driver"])
+		} else {
+			err = d.pciDeviceDriverOverrideIOMMU(pciDev, v["last_state.pci.driver"], true)
+		}
 		if err != nil {
 			return err
 		}
@@ -17,14 +17,18 @@ import (
 
 	"github.com/lxc/lxd/lxd/db"
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/device/nictype"
 	"github.com/lxc/lxd/lxd/dnsmasq"
 	"github.com/lxc/lxd/lxd/dnsmasq/dhcpalloc"
 	firewallDrivers "github.com/lxc/lxd/lxd/firewall/drivers"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/network/acl"
 	"github.com/lxc/lxd/lxd/network/openvswitch"
+	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
@@ -79,7 +83,12 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 		}
 
 		// If network property is specified, lookup network settings and apply them to the device's config.
-		n, err := network.LoadByName(d.state, d.config["network"])
+		networkProjectName, err := project.NetworkProject(d.state.Cluster, instConf.Project())
+		if err != nil {
+			return errors.Wrapf(err, "Failed to load network restrictions from project %q", instConf.Project())
+		}
+
+		n, err := network.LoadByName(d.state, networkProjectName, d.config["network"])
 		if err != nil {
 			return errors.Wrapf(err, "Error loading network config for %q", d.config["network"])
 		}
@@ -190,6 +199,13 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 		return nil
 	}
 
+	// Add bridge specific network ACL validation.
+	rules["security.acls"] = func(value string) error {
+		return acl.Exists(d.state, acl.ParseNames(value)...)
+	}
+	rules["security.acls.default.ingress.action"] = validate.Optional(acl.ValidAction)
+	rules["security.acls.default.egress.action"] = validate.Optional(acl.ValidAction)
+
 	// Now run normal validation.
 	err := d.config.Validate(rules)
 	if err != nil {
@@ -215,7 +231,7 @@ func (d *nicBridged) validateEnvironment() error {
 // CanHotPlug returns whether the device can be managed whilst the instance is running, it also
 // returns a list of fields that can be updated without triggering a device remove & add.
 func (d *nicBridged) CanHotPlug() (bool, []string) {
-	return true, []string{"limits.ingress", "limits.egress", "limits.max", "ipv4.routes", "ipv6.routes", "ipv4.address", "ipv6.address", "security.mac_filtering", "security.ipv4_filtering", "security.ipv6_filtering"}
+	return true, []string{"limits.ingress", "limits.egress", "limits.max", "ipv4.routes", "ipv6.routes", "ipv4.address", "ipv6.address", "security.mac_filtering", "security.ipv4_filtering", "security.ipv6_filtering", "security.acls", "security.acls.default.ingress.action", "security.acls.default.egress.action"}
 }
 
 // Add is run when a device is added to an instance whether or not the instance is running.
@@ -268,7 +284,7 @@ func (d *nicBridged) Start() (*deviceConfig.RunConfig, error) {
 	networkVethFillFromVolatile(d.config, saveData)
 
 	// Apply host-side routes.
-	err = networkSetupHostVethRoutes(d.state, d.config, nil, saveData)
+	err = networkSetupHostVethRoutes(d.state, d.inst.Project(), d.config, nil, saveData)
 	if err != nil {
 		return nil, err
 	}
@@ -293,6 +309,15 @@ func (d *nicBridged) Start() (*deviceConfig.RunConfig, error) {
 	}
 	revert.Add(func() { d.removeFilters(d.config) })
 
+	// Apply the network ACLs.
+	if d.config["security.acls"] != "" {
+		err = acl.InstanceDeviceApply(d.state, d.inst.Project(), d.inst.Name(), d.name, saveData["host_name"], d.config)
+		if err != nil {
+			return nil, err
+		}
+		revert.Add(func() { d.state.Firewall.InstanceClearACLRules(d.inst.Project(), d.inst.Name(), d.name) })
+	}
+
 	// Attach host side veth interface to bridge.
 	err = network.AttachInterface(d.config["parent"], saveData["host_name"])
 	if err != nil {
@@ -370,7 +395,7 @@ func (d *nicBridged) Update(oldDevices deviceConfig.Devices, isRunning bool) err
 		}
 
 		// Apply host-side routes.
-		err = networkSetupHostVethRoutes(d.state, d.config, oldConfig, v)
+		err = networkSetupHostVethRoutes(d.state, d.inst.Project(), d.config, oldConfig, v)
 		if err != nil {
 			return err
 		}
@@ -386,6 +411,14 @@ func (d *nicBridged) Update(oldDevices deviceConfig.Devices, isRunning bool) err
 		if err != nil {
 			return err
 		}
+
+		// Apply the network ACLs (removes the existing rules if no ACLs are referenced anymore).
+		if d.config["security.acls"] != "" || oldConfig["security.acls"] != "" {
+			err = acl.InstanceDeviceApply(d.state, d.inst.Project(), d.inst.Name(), d.name, d.config["host_name"], d.config)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	// Rebuild dnsmasq entry if needed and reload.
@@ -444,9 +477,16 @@ func (d *nicBridged) postStop() error {
 		}
 	}
 
-	networkRemoveVethRoutes(d.state, d.config)
+	networkRemoveVethRoutes(d.state, d.inst.Project(), d.config)
 	d.removeFilters(d.config)
 
+	if d.config["security.acls"] != "" {
+		err := d.state.Firewall.InstanceClearACLRules(d.inst.Project(), d.inst.Name(), d.name)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to remove network ACL rules for %q", d.name)
+		}
+	}
+
 	return nil
 }
 
@@ -489,7 +529,7 @@ func (d *nicBridged) rebuildDnsmasqEntry() error {
 	dnsmasq.ConfigMutex.Lock()
 	defer dnsmasq.ConfigMutex.Unlock()
 
-	_, dbInfo, err := d.state.Cluster.GetNetworkInAnyState(d.config["parent"])
+	_, dbInfo, err := d.state.Cluster.GetNetworkInAnyState(project.Default, d.config["parent"])
 	if err != nil {
 		return err
 	}
@@ -646,7 +686,7 @@ func (d *nicBridged) setFilters() (err error) {
 	IPv6 := net.ParseIP(d.config["ipv6.address"])
 
 	// Check if the parent is managed and load config. If parent is unmanaged continue anyway.
-	n, err := network.LoadByName(d.state, d.config["parent"])
+	n, err := network.LoadByName(d.state, project.Default, d.config["parent"])
 	if err != nil && err != db.ErrNoSuchObject {
 		return err
 	}
@@ -717,6 +757,67 @@ func (d *nicBridged) setFilters() (err error) {
 	return nil
 }
 
+// BridgedNICRefreshFilters re-applies the IP filters of the bridged NICs of the running local instances which
+// are connected to the given managed bridge without a static address, so that their address gets re-allocated
+// from the current subnets and DHCP ranges of the network. It's meant to be called after its addressing changed.
+func BridgedNICRefreshFilters(s *state.State, networkName string) error {
+	insts, err := instance.LoadNodeAll(s, instancetype.Any)
+	if err != nil {
+		return err
+	}
+
+	for _, inst := range insts {
+		if !inst.IsRunning() {
+			continue
+		}
+
+		for devName, devConfig := range inst.ExpandedDevices() {
+			if devConfig["type"] != "nic" || (devConfig["network"] != networkName && devConfig["parent"] != networkName) {
+				continue
+			}
+
+			nicType, err := nictype.NICType(s, inst.Project(), devConfig)
+			if err != nil || nicType != "bridged" {
+				continue
+			}
+
+			dynamicIPv4 := shared.IsTrue(devConfig["security.ipv4_filtering"]) && devConfig["ipv4.address"] == ""
+			dynamicIPv6 := shared.IsTrue(devConfig["security.ipv6_filtering"]) && devConfig["ipv6.address"] == ""
+			if !dynamicIPv4 && !dynamicIPv6 {
+				continue
+			}
+
+			localConfig := inst.LocalConfig()
+			volatile := map[string]string{
+				"host_name": localConfig[fmt.Sprintf("volatile.%s.host_name", devName)],
+				"hwaddr":    localConfig[fmt.Sprintf("volatile.%s.hwaddr", devName)],
+			}
+
+			dev, err := New(inst, s, devName, devConfig.Clone(), func() map[string]string { return volatile }, nil)
+			if err != nil {
+				return errors.Wrapf(err, "Failed loading device %q of instance %q", devName, inst.Name())
+			}
+
+			nic, ok := dev.(*nicBridged)
+			if !ok {
+				continue
+			}
+
+			networkVethFillFromVolatile(nic.config, volatile)
+
+			// The filters of the previous allocation are removed before it gets replaced.
+			nic.removeFilters(nic.config)
+
+			err = nic.setFilters()
+			if err != nil {
+				return errors.Wrapf(err, "Failed setting up the filters of device %q of instance %q", devName, inst.Name())
+			}
+		}
+	}
+
+	return nil
+}
+
 const (
 	clearLeaseAll = iota
 	clearLeaseIPv4Only
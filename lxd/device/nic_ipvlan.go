@@ -151,6 +151,11 @@ func (d *nicIPVLAN) validateEnvironment() error {
 		return fmt.Errorf("The vlan setting can only be used when combined with a parent interface")
 	}
 
+	err := networkParentCheckUp(d.config["parent"])
+	if err != nil {
+		return err
+	}
+
 	// Only check sysctls for l2proxy if mode is l3s.
 	if d.mode() != ipvlanModeL3S {
 		return nil
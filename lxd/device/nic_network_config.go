@@ -0,0 +1,165 @@
+package device
+
+import (
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/shared/validate"
+)
+
+// nicNetworkConfig is a cloud-init network configuration (version 2, netplan compatible).
+type nicNetworkConfig struct {
+	Version   int                                 `yaml:"version"`
+	Ethernets map[string]nicNetworkConfigEthernet `yaml:"ethernets"`
+}
+
+// nicNetworkConfigEthernet is the static configuration of an interface in a cloud-init network configuration.
+type nicNetworkConfigEthernet struct {
+	DHCP4     bool                    `yaml:"dhcp4"`
+	DHCP6     bool                    `yaml:"dhcp6"`
+	AcceptRA  bool                    `yaml:"accept-ra"`
+	Addresses []string                `yaml:"addresses,omitempty"`
+	Routes    []nicNetworkConfigRoute `yaml:"routes,omitempty"`
+}
+
+// nicNetworkConfigRoute is a static route in a cloud-init network configuration.
+type nicNetworkConfigRoute struct {
+	To     string `yaml:"to"`
+	Via    string `yaml:"via,omitempty"`
+	OnLink bool   `yaml:"on-link,omitempty"`
+	Scope  string `yaml:"scope,omitempty"`
+}
+
+// NICNetworkConfig returns a cloud-init network configuration statically configuring the addresses and default
+// gateways of the routed and ipvlan NICs in the given devices, for use as seed data by instances which would
+// otherwise try to use DHCP on those interfaces. An empty string is returned if there are no such NICs.
+func NICNetworkConfig(devices deviceConfig.Devices) (string, error) {
+	config := nicNetworkConfig{
+		Version:   2,
+		Ethernets: map[string]nicNetworkConfigEthernet{},
+	}
+
+	for _, entry := range devices.Sorted() {
+		m := entry.Config
+		if m["type"] != "nic" || m["name"] == "" {
+			continue
+		}
+
+		var ethernet nicNetworkConfigEthernet
+		switch m["nictype"] {
+		case "routed":
+			ethernet = nicRoutedNetworkConfig(m)
+		case "ipvlan":
+			ethernet = nicIPVLANNetworkConfig(m)
+		default:
+			continue
+		}
+
+		config.Ethernets[m["name"]] = ethernet
+	}
+
+	if len(config.Ethernets) == 0 {
+		return "", nil
+	}
+
+	data, err := yaml.Marshal(&config)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// nicRoutedNetworkConfig returns the static configuration of a routed NIC. The addresses are host routes and
+// the default gateways are the link-local addresses configured on the host side of the veth pair.
+func nicRoutedNetworkConfig(m deviceConfig.Device) nicNetworkConfigEthernet {
+	ethernet := nicNetworkConfigEthernet{}
+
+	if m["ipv4.address"] != "" {
+		for _, addr := range strings.Split(m["ipv4.address"], ",") {
+			ethernet.Addresses = append(ethernet.Addresses, fmt.Sprintf("%s/32", strings.TrimSpace(addr)))
+		}
+
+		if nicHasAutoGateway(m["ipv4.gateway"]) {
+			gateway := m["ipv4.host_address"]
+			if gateway == "" {
+				gateway = nicRoutedIPv4GW
+			}
+
+			ethernet.Routes = append(ethernet.Routes, nicNetworkConfigRoute{To: "0.0.0.0/0", Via: gateway, OnLink: true})
+		}
+	}
+
+	if m["ipv6.address"] != "" {
+		for _, addr := range strings.Split(m["ipv6.address"], ",") {
+			ethernet.Addresses = append(ethernet.Addresses, fmt.Sprintf("%s/128", strings.TrimSpace(addr)))
+		}
+
+		if nicHasAutoGateway(m["ipv6.gateway"]) {
+			gateway := m["ipv6.host_address"]
+			if gateway == "" {
+				gateway = nicRoutedIPv6GW
+			}
+
+			ethernet.Routes = append(ethernet.Routes, nicNetworkConfigRoute{To: "::/0", Via: gateway, OnLink: true})
+		}
+	}
+
+	return ethernet
+}
+
+// nicIPVLANNetworkConfig returns the static configuration of an ipvlan NIC. In l3s mode the addresses are host
+// routes and the default gateways are device routes, in l2 mode the user supplied gateways are used.
+func nicIPVLANNetworkConfig(m deviceConfig.Device) nicNetworkConfigEthernet {
+	ethernet := nicNetworkConfigEthernet{}
+	l2 := m["mode"] == ipvlanModeL2
+
+	if m["ipv4.address"] != "" {
+		for _, addr := range strings.Split(m["ipv4.address"], ",") {
+			addr = strings.TrimSpace(addr)
+
+			if !l2 {
+				addr = fmt.Sprintf("%s/32", addr)
+			} else if validate.IsNetworkAddressV4(addr) == nil {
+				addr = fmt.Sprintf("%s/24", addr)
+			}
+
+			ethernet.Addresses = append(ethernet.Addresses, addr)
+		}
+
+		if !l2 && nicHasAutoGateway(m["ipv4.gateway"]) {
+			ethernet.Routes = append(ethernet.Routes, nicNetworkConfigRoute{To: "0.0.0.0/0", Scope: "link"})
+		}
+
+		if l2 && m["ipv4.gateway"] != "" {
+			ethernet.Routes = append(ethernet.Routes, nicNetworkConfigRoute{To: "0.0.0.0/0", Via: m["ipv4.gateway"]})
+		}
+	}
+
+	if m["ipv6.address"] != "" {
+		for _, addr := range strings.Split(m["ipv6.address"], ",") {
+			addr = strings.TrimSpace(addr)
+
+			if !l2 {
+				addr = fmt.Sprintf("%s/128", addr)
+			} else if validate.IsNetworkAddressV6(addr) == nil {
+				addr = fmt.Sprintf("%s/64", addr)
+			}
+
+			ethernet.Addresses = append(ethernet.Addresses, addr)
+		}
+
+		if !l2 && nicHasAutoGateway(m["ipv6.gateway"]) {
+			ethernet.Routes = append(ethernet.Routes, nicNetworkConfigRoute{To: "::/0", Scope: "link"})
+		}
+
+		if l2 && m["ipv6.gateway"] != "" {
+			ethernet.Routes = append(ethernet.Routes, nicNetworkConfigRoute{To: "::/0", Via: m["ipv6.gateway"]})
+		}
+	}
+
+	return ethernet
+}
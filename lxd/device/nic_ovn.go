@@ -0,0 +1,329 @@
+package device
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/pkg/errors"
+
+	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/network/openvswitch"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// ovnIntegrationBridge is the name of the OVS bridge that OVN uses to connect local ports to logical switches.
+const ovnIntegrationBridge = "br-int"
+
+// ovnNet defines an interface for accessing instance specific functions on OVN network.
+type ovnNet interface {
+	network.Network
+
+	InstanceDevicePortAdd(instanceID int, deviceName string, mac net.HardwareAddr, ips []net.IP) (openvswitch.OVNSwitchPort, error)
+	InstanceDevicePortDelete(instanceID int, deviceName string) error
+	MTU() uint32
+}
+
+type nicOVN struct {
+	deviceCommon
+
+	network ovnNet // Populated in validateConfig().
+}
+
+// validateConfig checks the supplied config for correctness.
+func (d *nicOVN) validateConfig(instConf instance.ConfigReader) error {
+	if !instanceSupported(instConf.Type(), instancetype.Container, instancetype.VM) {
+		return ErrUnsupportedDevType
+	}
+
+	requiredFields := []string{
+		"network",
+	}
+
+	optionalFields := []string{
+		"name",
+		"hwaddr",
+		"host_name",
+		"ipv4.address",
+		"limits.ingress",
+		"limits.egress",
+		"limits.max",
+		"boot.priority",
+	}
+
+	// The NIC type and MTU are defined by the network.
+	bannedKeys := []string{"nictype", "mtu"}
+	for _, bannedKey := range bannedKeys {
+		if d.config[bannedKey] != "" {
+			return fmt.Errorf("Cannot use %q property in conjunction with %q property", bannedKey, "network")
+		}
+	}
+
+	err := d.config.Validate(nicValidationRules(requiredFields, optionalFields))
+	if err != nil {
+		return err
+	}
+
+	networkProjectName, err := project.NetworkProject(d.state.Cluster, instConf.Project())
+	if err != nil {
+		return errors.Wrapf(err, "Failed to load network restrictions from project %q", instConf.Project())
+	}
+
+	n, err := network.LoadByName(d.state, networkProjectName, d.config["network"])
+	if err != nil {
+		return errors.Wrapf(err, "Error loading network config for %q", d.config["network"])
+	}
+
+	if n.Status() == api.NetworkStatusPending {
+		return fmt.Errorf("Specified network is not fully created")
+	}
+
+	if n.Type() != "ovn" {
+		return fmt.Errorf("Specified network must be of type ovn")
+	}
+
+	ovnNet, ok := n.(ovnNet)
+	if !ok {
+		return fmt.Errorf("Network is not ovnNet interface type")
+	}
+
+	d.network = ovnNet
+
+	// Check the static IPv4 address is within the network's subnet.
+	if d.config["ipv4.address"] != "" {
+		netConfig := n.Config()
+		if shared.StringInSlice(netConfig["ipv4.address"], []string{"", "none"}) {
+			return fmt.Errorf("Cannot specify %q when IPv4 is disabled on the network", "ipv4.address")
+		}
+
+		_, subnet, err := net.ParseCIDR(netConfig["ipv4.address"])
+		if err != nil {
+			return errors.Wrapf(err, "Invalid network ipv4.address")
+		}
+
+		if !subnet.Contains(net.ParseIP(d.config["ipv4.address"])) {
+			return fmt.Errorf("Device IP address %q not within network %q subnet", d.config["ipv4.address"], n.Name())
+		}
+	}
+
+	return nil
+}
+
+// validateEnvironment checks the runtime environment for correctness.
+func (d *nicOVN) validateEnvironment() error {
+	if d.inst.Type() == instancetype.Container && d.config["name"] == "" {
+		return fmt.Errorf("Requires name property to start")
+	}
+
+	if !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", ovnIntegrationBridge)) {
+		return fmt.Errorf("OVS integration bridge device %q doesn't exist", ovnIntegrationBridge)
+	}
+
+	return nil
+}
+
+// CanHotPlug returns whether the device can be managed whilst the instance is running, it also
+// returns a list of fields that can be updated without triggering a device remove & add.
+func (d *nicOVN) CanHotPlug() (bool, []string) {
+	return true, []string{"limits.ingress", "limits.egress", "limits.max"}
+}
+
+// Start is run when the device is added to a running instance or instance is starting up.
+func (d *nicOVN) Start() (*deviceConfig.RunConfig, error) {
+	err := d.validateEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	saveData := make(map[string]string)
+	saveData["host_name"] = d.config["host_name"]
+
+	// Inherit the MTU from the network so the Geneve encapsulation overhead is accounted for.
+	d.config["mtu"] = fmt.Sprintf("%d", d.network.MTU())
+
+	var peerName string
+
+	// Create veth pair and configure the peer end with custom hwaddr and mtu if supplied.
+	if d.inst.Type() == instancetype.Container {
+		if saveData["host_name"] == "" {
+			saveData["host_name"] = networkRandomDevName("veth")
+		}
+		peerName, err = networkCreateVethPair(saveData["host_name"], d.config)
+	} else if d.inst.Type() == instancetype.VM {
+		if saveData["host_name"] == "" {
+			saveData["host_name"] = networkRandomDevName("tap")
+		}
+		peerName = saveData["host_name"] // VMs use the host_name to link to the TAP FD.
+		err = networkCreateTap(saveData["host_name"], d.config)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	revert.Add(func() { NetworkRemoveInterface(saveData["host_name"]) })
+
+	mac, err := net.ParseMAC(d.config["hwaddr"])
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid hwaddr")
+	}
+
+	ips := []net.IP{}
+	if d.config["ipv4.address"] != "" {
+		ips = append(ips, net.ParseIP(d.config["ipv4.address"]))
+	}
+
+	// Add the logical switch port for the instance NIC.
+	portName, err := d.network.InstanceDevicePortAdd(d.inst.ID(), d.name, mac, ips)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed adding OVN logical switch port")
+	}
+
+	revert.Add(func() { d.network.InstanceDevicePortDelete(d.inst.ID(), d.name) })
+
+	// Attach the host side interface to the integration bridge and associate it to the logical switch port.
+	ovs := openvswitch.NewOVS()
+	err = ovs.BridgePortAdd(ovnIntegrationBridge, saveData["host_name"], true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed attaching interface %q to %q", saveData["host_name"], ovnIntegrationBridge)
+	}
+
+	revert.Add(func() { ovs.BridgePortDelete(ovnIntegrationBridge, saveData["host_name"]) })
+
+	err = ovs.InterfaceAssociateOVNSwitchPort(saveData["host_name"], portName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed associating interface %q to OVN logical switch port %q", saveData["host_name"], portName)
+	}
+
+	// Apply the rate limits through OVS.
+	err = d.setupLimits(saveData["host_name"])
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.volatileSet(saveData)
+	if err != nil {
+		return nil, err
+	}
+
+	runConf := deviceConfig.RunConfig{}
+	runConf.NetworkInterface = []deviceConfig.RunConfigItem{
+		{Key: "name", Value: d.config["name"]},
+		{Key: "type", Value: "phys"},
+		{Key: "flags", Value: "up"},
+		{Key: "link", Value: peerName},
+	}
+
+	if d.inst.Type() == instancetype.VM {
+		runConf.NetworkInterface = append(runConf.NetworkInterface,
+			[]deviceConfig.RunConfigItem{
+				{Key: "devName", Value: d.name},
+				{Key: "hwaddr", Value: d.config["hwaddr"]},
+			}...)
+	}
+
+	revert.Success()
+	return &runConf, nil
+}
+
+// Update applies configuration changes to a started device.
+func (d *nicOVN) Update(oldDevices deviceConfig.Devices, isRunning bool) error {
+	if !isRunning {
+		return nil
+	}
+
+	err := d.validateEnvironment()
+	if err != nil {
+		return err
+	}
+
+	v := d.volatileGet()
+
+	// Populate device config with volatile fields if needed.
+	networkVethFillFromVolatile(d.config, v)
+
+	return d.setupLimits(d.config["host_name"])
+}
+
+// setupLimits applies the rate limits of the NIC to its host side interface attached to the integration bridge.
+// The instance's egress traffic is policed as it enters OVS, and its ingress traffic is shaped by a QoS on the
+// port, the limits being removed when not set.
+func (d *nicOVN) setupLimits(hostName string) error {
+	ingress, egress, err := NetworkLimits(d.config)
+	if err != nil {
+		return err
+	}
+
+	ovs := openvswitch.NewOVS()
+	err = ovs.InterfaceSetIngressPolicing(hostName, uint64(egress/1000))
+	if err != nil {
+		return errors.Wrapf(err, "Failed setting egress limit of interface %q", hostName)
+	}
+
+	err = ovs.PortSetQoSMaxRate(hostName, uint64(ingress))
+	if err != nil {
+		return errors.Wrapf(err, "Failed setting ingress limit of interface %q", hostName)
+	}
+
+	return nil
+}
+
+// Stop is run when the device is removed from the instance.
+func (d *nicOVN) Stop() (*deviceConfig.RunConfig, error) {
+	runConf := deviceConfig.RunConfig{
+		PostHooks: []func() error{d.postStop},
+	}
+
+	return &runConf, nil
+}
+
+// postStop is run after the device is removed from the instance.
+func (d *nicOVN) postStop() error {
+	defer d.volatileSet(map[string]string{
+		"host_name": "",
+	})
+
+	v := d.volatileGet()
+
+	networkVethFillFromVolatile(d.config, v)
+
+	err := d.network.InstanceDevicePortDelete(d.inst.ID(), d.name)
+	if err != nil {
+		return errors.Wrapf(err, "Failed deleting OVN logical switch port")
+	}
+
+	if d.config["host_name"] != "" {
+		ovs := openvswitch.NewOVS()
+
+		// Remove the QoS record of the port, it would be left behind otherwise.
+		if d.config["limits.ingress"] != "" || d.config["limits.max"] != "" {
+			err = ovs.PortSetQoSMaxRate(d.config["host_name"], 0)
+			if err != nil {
+				return errors.Wrapf(err, "Failed removing ingress limit of interface %q", d.config["host_name"])
+			}
+		}
+
+		err = ovs.BridgePortDelete(ovnIntegrationBridge, d.config["host_name"])
+		if err != nil {
+			return errors.Wrapf(err, "Failed detaching interface %q from %q", d.config["host_name"], ovnIntegrationBridge)
+		}
+
+		if shared.PathExists(fmt.Sprintf("/sys/class/net/%s", d.config["host_name"])) {
+			// Removing host-side end of veth pair will delete the peer end too.
+			err = NetworkRemoveInterface(d.config["host_name"])
+			if err != nil {
+				return fmt.Errorf("Failed to remove interface %s: %s", d.config["host_name"], err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -9,9 +9,11 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/revert"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 )
 
 type nicPhysical struct {
@@ -24,9 +26,11 @@ func (d *nicPhysical) validateConfig(instConf instance.ConfigReader) error {
 		return ErrUnsupportedDevType
 	}
 
-	requiredFields := []string{"parent"}
+	requiredFields := []string{}
 	optionalFields := []string{
 		"name",
+		"network",
+		"parent",
 		"maas.subnet.ipv4",
 		"maas.subnet.ipv6",
 		"boot.priority",
@@ -36,6 +40,67 @@ func (d *nicPhysical) validateConfig(instConf instance.ConfigReader) error {
 		optionalFields = append(optionalFields, "mtu", "hwaddr", "vlan")
 	}
 
+	// Check that if network proeperty is set that conflicting keys are not present.
+	if d.config["network"] != "" {
+		requiredFields = append(requiredFields, "network")
+
+		bannedKeys := []string{"nictype", "parent", "mtu", "vlan", "maas.subnet.ipv4", "maas.subnet.ipv6"}
+		for _, bannedKey := range bannedKeys {
+			if d.config[bannedKey] != "" {
+				return fmt.Errorf("Cannot use %q property in conjunction with %q property", bannedKey, "network")
+			}
+		}
+
+		// If network property is specified, lookup network settings and apply them to the device's config.
+		networkProjectName, err := project.NetworkProject(d.state.Cluster, instConf.Project())
+		if err != nil {
+			return errors.Wrapf(err, "Failed to load network restrictions from project %q", instConf.Project())
+		}
+
+		n, err := network.LoadByName(d.state, networkProjectName, d.config["network"])
+		if err != nil {
+			return errors.Wrapf(err, "Error loading network config for %q", d.config["network"])
+		}
+
+		if n.Status() == api.NetworkStatusPending {
+			return fmt.Errorf("Specified network is not fully created")
+		}
+
+		if n.Type() != "physical" {
+			return fmt.Errorf("Specified network must be of type physical")
+		}
+
+		// The interface of a physical network used as OVN uplink is attached to OVS on the host.
+		ovnNetworks, err := network.OVNNetworksUsingUplink(d.state, n.Name())
+		if err != nil {
+			return err
+		}
+
+		if len(ovnNetworks) > 0 {
+			return fmt.Errorf("Specified network is in use as uplink network")
+		}
+
+		netConfig := n.Config()
+
+		// Get actual host interface from network's parent and VLAN settings (created when network started).
+		d.config["parent"] = network.GetHostDevice(netConfig["parent"], netConfig["vlan"])
+
+		// Copy certain keys verbatim from the network's settings.
+		inheritKeys := []string{"maas.subnet.ipv4", "maas.subnet.ipv6"}
+		if instConf.Type() == instancetype.Container {
+			inheritKeys = append(inheritKeys, "mtu")
+		}
+
+		for _, inheritKey := range inheritKeys {
+			if _, found := netConfig[inheritKey]; found {
+				d.config[inheritKey] = netConfig[inheritKey]
+			}
+		}
+	} else {
+		// If no network property supplied, then parent property is required.
+		requiredFields = append(requiredFields, "parent")
+	}
+
 	err := d.config.Validate(nicValidationRules(requiredFields, optionalFields))
 	if err != nil {
 		return err
@@ -83,6 +83,13 @@ func (d *nicRouted) validateEnvironment() error {
 		return fmt.Errorf("The vlan setting can only be used when combined with a parent interface")
 	}
 
+	if d.config["parent"] != "" {
+		err := networkParentCheckUp(d.config["parent"])
+		if err != nil {
+			return err
+		}
+	}
+
 	// Check necessary "all" sysctls are configured for use with l2proxy parent for routed mode.
 	if d.config["parent"] != "" && d.config["ipv6.address"] != "" {
 		// net.ipv6.conf.all.forwarding=1 is required to enable general packet forwarding for IPv6.
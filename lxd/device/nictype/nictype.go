@@ -8,18 +8,25 @@ import (
 	"github.com/pkg/errors"
 
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/state"
 )
 
 // NICType resolves the NIC Type for the supplied NIC device config.
 // If the device "type" is "nic" and the "network" property is specified in the device config, then NIC type is
 // resolved from the network's type. Otherwise the device's "nictype" property is returned (which may be empty if
-// used with non-NIC device configs).
-func NICType(s *state.State, d deviceConfig.Device) (string, error) {
+// used with non-NIC device configs). The network is looked up in the network project of the supplied instance
+// project.
+func NICType(s *state.State, instProjectName string, d deviceConfig.Device) (string, error) {
 	// NIC devices support resolving their "nictype" from their "network" property.
 	if d["type"] == "nic" {
 		if d["network"] != "" {
-			_, netInfo, err := s.Cluster.GetNetworkInAnyState(d["network"])
+			networkProjectName, err := project.NetworkProject(s.Cluster, instProjectName)
+			if err != nil {
+				return "", errors.Wrapf(err, "Failed to load network project for project %q", instProjectName)
+			}
+
+			_, netInfo, err := s.Cluster.GetNetworkInAnyState(networkProjectName, d["network"])
 			if err != nil {
 				return "", errors.Wrapf(err, "Failed to load network %q", d["network"])
 			}
@@ -32,6 +39,10 @@ func NICType(s *state.State, d deviceConfig.Device) (string, error) {
 				nicType = "macvlan"
 			case "sriov":
 				nicType = "sriov"
+			case "ovn":
+				nicType = "ovn"
+			case "physical":
+				nicType = "physical"
 			default:
 				return "", fmt.Errorf("Unrecognised NIC network type for network %q", d["network"])
 			}
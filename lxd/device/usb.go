@@ -7,9 +7,12 @@ import (
 	"path"
 	"strings"
 
+	"github.com/pkg/errors"
+
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/validate"
 )
@@ -29,6 +32,20 @@ func usbIsOurDevice(config deviceConfig.Device, usb *USBEvent) bool {
 	return true
 }
 
+// usbAllowVMAccess gives the unprivileged QEMU process access to the host USB device.
+func usbAllowVMAccess(s *state.State, devPath string) error {
+	if s.OS.UnprivUser == "" {
+		return nil
+	}
+
+	err := os.Chown(devPath, s.OS.UnprivUID, -1)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to change ownership of USB device %q", devPath)
+	}
+
+	return nil
+}
+
 type usb struct {
 	deviceCommon
 }
@@ -45,7 +62,7 @@ func (d *usb) isRequired() bool {
 
 // validateConfig checks the supplied config for correctness.
 func (d *usb) validateConfig(instConf instance.ConfigReader) error {
-	if !instanceSupported(instConf.Type(), instancetype.Container) {
+	if !instanceSupported(instConf.Type(), instancetype.Container, instancetype.VM) {
 		return ErrUnsupportedDevType
 	}
 
@@ -74,6 +91,7 @@ func (d *usb) Register() error {
 	devConfig := d.config
 	deviceName := d.name
 	state := d.state
+	instType := d.inst.Type()
 
 	// Handler for when a USB event occurs.
 	f := func(e USBEvent) (*deviceConfig.RunConfig, error) {
@@ -83,6 +101,28 @@ func (d *usb) Register() error {
 
 		runConf := deviceConfig.RunConfig{}
 
+		// VMs get the USB device passed through, the path being left empty when it should be detached.
+		if instType == instancetype.VM {
+			usbDev := deviceConfig.USBDeviceItem{
+				DeviceName: deviceName,
+				BusNum:     e.BusNum,
+				DevNum:     e.DevNum,
+			}
+
+			if e.Action == "add" {
+				err := usbAllowVMAccess(state, e.Path)
+				if err != nil {
+					return nil, err
+				}
+
+				usbDev.HostDevicePath = e.Path
+			}
+
+			runConf.USBDevice = append(runConf.USBDevice, usbDev)
+
+			return &runConf, nil
+		}
+
 		if e.Action == "add" {
 			err := unixDeviceSetupCharNum(state, devicesPath, "unix", deviceName, devConfig, e.Major, e.Minor, e.Path, false, &runConf)
 			if err != nil {
@@ -131,13 +171,29 @@ func (d *usb) Start() (*deviceConfig.RunConfig, error) {
 			continue
 		}
 
+		if d.inst.Type() == instancetype.VM {
+			err := usbAllowVMAccess(d.state, usb.Path)
+			if err != nil {
+				return nil, err
+			}
+
+			runConf.USBDevice = append(runConf.USBDevice, deviceConfig.USBDeviceItem{
+				DeviceName:     d.name,
+				HostDevicePath: usb.Path,
+				BusNum:         usb.BusNum,
+				DevNum:         usb.DevNum,
+			})
+
+			continue
+		}
+
 		err := unixDeviceSetupCharNum(d.state, d.inst.DevicesPath(), "unix", d.name, d.config, usb.Major, usb.Minor, usb.Path, false, &runConf)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	if d.isRequired() && len(runConf.Mounts) <= 0 {
+	if d.isRequired() && len(runConf.Mounts) <= 0 && len(runConf.USBDevice) <= 0 {
 		return nil, fmt.Errorf("Required USB device not found")
 	}
 
@@ -149,10 +205,15 @@ func (d *usb) Stop() (*deviceConfig.RunConfig, error) {
 	// Unregister any USB event handlers for this device.
 	usbUnregisterHandler(d.inst, d.name)
 
-	runConf := deviceConfig.RunConfig{
-		PostHooks: []func() error{d.postStop},
+	runConf := deviceConfig.RunConfig{}
+
+	// The USB devices passed to VMs are detached along with the device itself.
+	if d.inst.Type() == instancetype.VM {
+		return &runConf, nil
 	}
 
+	runConf.PostHooks = []func() error{d.postStop}
+
 	err := unixDeviceRemove(d.inst.DevicesPath(), "unix", d.name, "", &runConf)
 	if err != nil {
 		return nil, err
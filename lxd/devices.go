@@ -68,7 +68,7 @@ func (c deviceTaskCPUs) Len() int           { return len(c) }
 func (c deviceTaskCPUs) Less(i, j int) bool { return *c[i].count < *c[j].count }
 func (c deviceTaskCPUs) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
 
-func deviceNetlinkListener() (chan []string, chan []string, chan device.USBEvent, chan device.UnixHotplugEvent, error) {
+func deviceNetlinkListener() (chan []string, chan []string, chan device.USBEvent, chan device.PCIEvent, chan device.UnixHotplugEvent, error) {
 	NETLINK_KOBJECT_UEVENT := 15
 	UEVENT_BUFFER_SIZE := 2048
 
@@ -77,7 +77,7 @@ func deviceNetlinkListener() (chan []string, chan []string, chan device.USBEvent
 		NETLINK_KOBJECT_UEVENT,
 	)
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return nil, nil, nil, nil, nil, err
 	}
 
 	nl := unix.SockaddrNetlink{
@@ -88,15 +88,16 @@ func deviceNetlinkListener() (chan []string, chan []string, chan device.USBEvent
 
 	err = unix.Bind(fd, &nl)
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return nil, nil, nil, nil, nil, err
 	}
 
 	chCPU := make(chan []string, 1)
 	chNetwork := make(chan []string, 0)
 	chUSB := make(chan device.USBEvent)
+	chPCI := make(chan device.PCIEvent)
 	chUnix := make(chan device.UnixHotplugEvent)
 
-	go func(chCPU chan []string, chNetwork chan []string, chUSB chan device.USBEvent, chUnix chan device.UnixHotplugEvent) {
+	go func(chCPU chan []string, chNetwork chan []string, chUSB chan device.USBEvent, chPCI chan device.PCIEvent, chUnix chan device.UnixHotplugEvent) {
 		b := make([]byte, UEVENT_BUFFER_SIZE*2)
 		for {
 			r, err := unix.Read(fd, b)
@@ -238,6 +239,30 @@ func deviceNetlinkListener() (chan []string, chan []string, chan device.USBEvent
 				chUSB <- usb
 			}
 
+			if props["SUBSYSTEM"] == "pci" && !udevEvent {
+				if props["ACTION"] != "add" && props["ACTION"] != "remove" {
+					continue
+				}
+
+				pciID, ok := props["PCI_ID"]
+				if !ok {
+					continue
+				}
+
+				slotName, ok := props["PCI_SLOT_NAME"]
+				if !ok {
+					continue
+				}
+
+				pci, err := device.PCINewEvent(props["ACTION"], pciID, slotName)
+				if err != nil {
+					logger.Error("Error reading pci device", log.Ctx{"err": err, "path": props["DEVPATH"]})
+					continue
+				}
+
+				chPCI <- pci
+			}
+
 			// unix hotplug device events rely on information added by udev
 			if udevEvent {
 				action := props["ACTION"]
@@ -311,9 +336,9 @@ func deviceNetlinkListener() (chan []string, chan []string, chan device.USBEvent
 			}
 
 		}
-	}(chCPU, chNetwork, chUSB, chUnix)
+	}(chCPU, chNetwork, chUSB, chPCI, chUnix)
 
-	return chCPU, chNetwork, chUSB, chUnix, nil
+	return chCPU, chNetwork, chUSB, chPCI, chUnix, nil
 }
 
 func deviceTaskBalance(s *state.State) {
@@ -516,7 +541,7 @@ func deviceNetworkPriority(s *state.State, netif string) {
 }
 
 func deviceEventListener(s *state.State) {
-	chNetlinkCPU, chNetlinkNetwork, chUSB, chUnix, err := deviceNetlinkListener()
+	chNetlinkCPU, chNetlinkNetwork, chUSB, chPCI, chUnix, err := deviceNetlinkListener()
 	if err != nil {
 		logger.Errorf("scheduler: Couldn't setup netlink listener: %v", err)
 		return
@@ -551,6 +576,8 @@ func deviceEventListener(s *state.State) {
 			networkAutoAttach(s.Cluster, e[0])
 		case e := <-chUSB:
 			device.USBRunHandlers(s, &e)
+		case e := <-chPCI:
+			device.PCIRunHandlers(s, &e)
 		case e := <-chUnix:
 			device.UnixHotplugRunHandlers(s, &e)
 		case e := <-cgroup.DeviceSchedRebalance:
@@ -571,7 +598,7 @@ func deviceEventListener(s *state.State) {
 
 // devicesRegister calls the Register() function on all supported devices so they receive events.
 func devicesRegister(s *state.State) {
-	instances, err := instance.LoadNodeAll(s, instancetype.Container)
+	instances, err := instance.LoadNodeAll(s, instancetype.Any)
 	if err != nil {
 		logger.Error("Problem loading instances list", log.Ctx{"err": err})
 		return
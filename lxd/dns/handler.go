@@ -0,0 +1,263 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// axfrChunkSize is the maximum number of records sent per message during a zone transfer.
+const axfrChunkSize = 100
+
+// forwardTimeout is how long to wait for the reply of a DNS server a query is forwarded to.
+const forwardTimeout = 5 * time.Second
+
+type dnsHandler struct {
+	server *Server
+}
+
+// ServeDNS answers queries and zone transfers for the zones known to LXD. Only the peers configured on a zone
+// are allowed to query it, using their TSIG key if one is set.
+func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	// Only allow single question queries.
+	if len(r.Question) != 1 {
+		d.reply(w, r, dns.RcodeFormatError)
+		return
+	}
+
+	q := r.Question[0]
+	if q.Qclass != dns.ClassINET {
+		d.reply(w, r, dns.RcodeNotImplemented)
+		return
+	}
+
+	// Find the zone the question is in.
+	zone, err := d.findZone(q.Name)
+	if err != nil {
+		logger.Errorf("Failed loading DNS zone for %q: %v", q.Name, err)
+		d.reply(w, r, dns.RcodeServerFailure)
+		return
+	}
+
+	if zone == nil {
+		d.forward(w, r)
+		return
+	}
+
+	// Check access.
+	if !d.isAllowed(w, r, zone) {
+		d.reply(w, r, dns.RcodeRefused)
+		return
+	}
+
+	// Parse the zone content.
+	records := []dns.RR{}
+	zp := dns.NewZoneParser(strings.NewReader(zone.Content), "", "")
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		records = append(records, rr)
+	}
+
+	err = zp.Err()
+	if err != nil {
+		logger.Errorf("Failed parsing DNS zone %q: %v", zone.Info.Name, err)
+		d.reply(w, r, dns.RcodeServerFailure)
+		return
+	}
+
+	// The SOA record is always generated first.
+	if len(records) == 0 || records[0].Header().Rrtype != dns.TypeSOA {
+		d.reply(w, r, dns.RcodeServerFailure)
+		return
+	}
+
+	soa := records[0]
+
+	// Handle zone transfers.
+	if q.Qtype == dns.TypeAXFR {
+		// Zone transfers are only supported over TCP and for the zone itself.
+		_, isTCP := w.RemoteAddr().(*net.TCPAddr)
+		if !isTCP || dns.Fqdn(zone.Info.Name) != strings.ToLower(q.Name) {
+			d.reply(w, r, dns.RcodeRefused)
+			return
+		}
+
+		// A transfer starts and ends with the SOA record.
+		records = append(records, soa)
+
+		ch := make(chan *dns.Envelope)
+		go func() {
+			for i := 0; i < len(records); i += axfrChunkSize {
+				end := i + axfrChunkSize
+				if end > len(records) {
+					end = len(records)
+				}
+
+				ch <- &dns.Envelope{RR: records[i:end]}
+			}
+
+			close(ch)
+		}()
+
+		tr := new(dns.Transfer)
+		err = tr.Out(w, r, ch)
+		if err != nil {
+			logger.Errorf("Failed DNS zone transfer of %q: %v", zone.Info.Name, err)
+		}
+
+		return
+	}
+
+	// Handle regular queries.
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+
+	nameFound := false
+	for _, rr := range records {
+		if !strings.EqualFold(rr.Header().Name, q.Name) {
+			continue
+		}
+
+		nameFound = true
+		if q.Qtype == dns.TypeANY || rr.Header().Rrtype == q.Qtype {
+			m.Answer = append(m.Answer, rr)
+		}
+	}
+
+	if len(m.Answer) == 0 {
+		if !nameFound {
+			m.Rcode = dns.RcodeNameError
+		}
+
+		m.Ns = append(m.Ns, soa)
+	}
+
+	d.writeMsg(w, r, m)
+}
+
+// forward relays the query to the DNS server handling the name (such as the dnsmasq instance of a network),
+// refusing it if there is none.
+func (d dnsHandler) forward(w dns.ResponseWriter, r *dns.Msg) {
+	if d.server.forwardRetriever == nil {
+		d.reply(w, r, dns.RcodeRefused)
+		return
+	}
+
+	address, err := d.server.forwardRetriever(r.Question[0].Name)
+	if err != nil {
+		logger.Errorf("Failed finding DNS forwarder for %q: %v", r.Question[0].Name, err)
+		d.reply(w, r, dns.RcodeServerFailure)
+		return
+	}
+
+	if address == "" {
+		d.reply(w, r, dns.RcodeRefused)
+		return
+	}
+
+	// Use the same transport as the request.
+	client := &dns.Client{Net: "udp", Timeout: forwardTimeout}
+	_, isTCP := w.RemoteAddr().(*net.TCPAddr)
+	if isTCP {
+		client.Net = "tcp"
+	}
+
+	resp, _, err := client.Exchange(r, address)
+	if err != nil {
+		logger.Errorf("Failed forwarding DNS query for %q to %q: %v", r.Question[0].Name, address, err)
+		d.reply(w, r, dns.RcodeServerFailure)
+		return
+	}
+
+	err = w.WriteMsg(resp)
+	if err != nil {
+		logger.Errorf("Failed sending DNS reply: %v", err)
+	}
+}
+
+// findZone returns the most specific zone containing the name, or nil if no zone matches.
+func (d dnsHandler) findZone(name string) (*Zone, error) {
+	labels := dns.SplitDomainName(strings.ToLower(name))
+	for i := range labels {
+		zone, err := d.server.zoneRetriever(strings.Join(labels[i:], "."))
+		if err != nil {
+			if err == db.ErrNoSuchObject {
+				continue
+			}
+
+			return nil, err
+		}
+
+		return zone, nil
+	}
+
+	return nil, nil
+}
+
+// isAllowed checks that the request comes from one of the zone's peers, and that it is signed with the peer's
+// TSIG key if one is set.
+func (d dnsHandler) isAllowed(w dns.ResponseWriter, r *dns.Msg, zone *Zone) bool {
+	host, _, err := net.SplitHostPort(w.RemoteAddr().String())
+	if err != nil {
+		return false
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for k, v := range zone.Info.Config {
+		if !strings.HasPrefix(k, "peers.") || !strings.HasSuffix(k, ".address") {
+			continue
+		}
+
+		peerIP := net.ParseIP(v)
+		if peerIP == nil || !peerIP.Equal(ip) {
+			continue
+		}
+
+		peerName := strings.TrimSuffix(strings.TrimPrefix(k, "peers."), ".address")
+		if zone.Info.Config[fmt.Sprintf("peers.%s.key", peerName)] == "" {
+			return true
+		}
+
+		// The request must be signed with the peer's key.
+		tsig := r.IsTsig()
+		if tsig == nil || w.TsigStatus() != nil {
+			continue
+		}
+
+		if strings.EqualFold(tsig.Hdr.Name, fmt.Sprintf("%s_%s.", zone.Info.Name, peerName)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reply sends an empty reply with the given return code.
+func (d dnsHandler) reply(w dns.ResponseWriter, r *dns.Msg, rcode int) {
+	m := new(dns.Msg)
+	m.SetRcode(r, rcode)
+	d.writeMsg(w, r, m)
+}
+
+// writeMsg sends the reply, signing it if the request was signed with a valid key.
+func (d dnsHandler) writeMsg(w dns.ResponseWriter, r *dns.Msg, m *dns.Msg) {
+	tsig := r.IsTsig()
+	if tsig != nil && w.TsigStatus() == nil {
+		m.SetTsig(tsig.Hdr.Name, tsig.Algorithm, tsig.Fudge, time.Now().Unix())
+	}
+
+	err := w.WriteMsg(m)
+	if err != nil {
+		logger.Errorf("Failed sending DNS reply: %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// Zone represents a DNS zone served by the server.
+type Zone struct {
+	Info    api.NetworkZone
+	Content string
+}
+
+// ZoneRetriever returns the zone with the given name, or db.ErrNoSuchObject if it doesn't exist.
+type ZoneRetriever func(name string) (*Zone, error)
+
+// ForwardRetriever returns the address of the DNS server queries for the given name should be forwarded to, or an
+// empty string if the name isn't handled by any.
+type ForwardRetriever func(name string) (string, error)
+
+// Server represents a DNS server instance.
+type Server struct {
+	tcpDNS *dns.Server
+	udpDNS *dns.Server
+
+	// External dependencies.
+	db               *db.Cluster
+	zoneRetriever    ZoneRetriever
+	forwardRetriever ForwardRetriever
+
+	// Internal state (to handle reconfiguration).
+	address string
+
+	mu sync.Mutex
+}
+
+// NewServer returns a new server instance.
+func NewServer(db *db.Cluster, retriever ZoneRetriever, forwarder ForwardRetriever) *Server {
+	// Setup new struct.
+	s := &Server{db: db, zoneRetriever: retriever, forwardRetriever: forwarder}
+	return s
+}
+
+// Start sets up the DNS listener.
+func (s *Server) Start(address string) error {
+	// Locking.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Setup the listener.
+	return s.start(address)
+}
+
+func (s *Server) start(address string) error {
+	// Set default port if needed.
+	address = canonicalAddress(address)
+
+	// Load the TSIG keys.
+	secrets, err := s.db.GetNetworkZoneKeys()
+	if err != nil {
+		return errors.Wrap(err, "Failed to load DNS TSIG keys")
+	}
+
+	// Setup the TCP listener.
+	tcpListener, err := net.Listen("tcp", address)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to bind DNS TCP listener on %q", address)
+	}
+
+	// Setup the UDP listener.
+	udpListener, err := net.ListenPacket("udp", address)
+	if err != nil {
+		tcpListener.Close()
+		return errors.Wrapf(err, "Failed to bind DNS UDP listener on %q", address)
+	}
+
+	// Setup the handler.
+	handler := dnsHandler{}
+	handler.server = s
+
+	s.tcpDNS = &dns.Server{Listener: tcpListener, Handler: handler, TsigSecret: secrets}
+	s.udpDNS = &dns.Server{PacketConn: udpListener, Handler: handler, TsigSecret: secrets}
+
+	// Start listening.
+	go func(srv *dns.Server) {
+		err := srv.ActivateAndServe()
+		if err != nil {
+			logger.Errorf("DNS TCP listener stopped: %v", err)
+		}
+	}(s.tcpDNS)
+
+	go func(srv *dns.Server) {
+		err := srv.ActivateAndServe()
+		if err != nil {
+			logger.Errorf("DNS UDP listener stopped: %v", err)
+		}
+	}(s.udpDNS)
+
+	// Record the address.
+	s.address = address
+
+	return nil
+}
+
+// Stop tears down the DNS listener.
+func (s *Server) Stop() error {
+	// Locking.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Skip if no instance.
+	if s.tcpDNS == nil || s.udpDNS == nil {
+		return nil
+	}
+
+	// Stop the listener.
+	s.tcpDNS.Shutdown()
+	s.udpDNS.Shutdown()
+
+	// Unset the address.
+	s.tcpDNS = nil
+	s.udpDNS = nil
+	s.address = ""
+
+	return nil
+}
+
+// Reconfigure updates the listener with a new configuration.
+func (s *Server) Reconfigure(address string) error {
+	// Get the old address.
+	s.mu.Lock()
+	oldAddress := s.address
+	s.mu.Unlock()
+
+	// Setup reverter.
+	revert := true
+	defer func() {
+		if revert && oldAddress != "" {
+			s.Start(oldAddress)
+		}
+	}()
+
+	// Stop the listener.
+	err := s.Stop()
+	if err != nil {
+		return err
+	}
+
+	// Check if should be disabled.
+	if address == "" {
+		revert = false
+		return nil
+	}
+
+	// Start the listener.
+	err = s.Start(address)
+	if err != nil {
+		return err
+	}
+
+	// All done.
+	revert = false
+	return nil
+}
+
+// UpdateTSIG reloads the TSIG keys from the database, restarting the listener if running.
+func (s *Server) UpdateTSIG() error {
+	// Get the current address.
+	s.mu.Lock()
+	address := s.address
+	s.mu.Unlock()
+
+	// Skip if not running.
+	if address == "" {
+		return nil
+	}
+
+	// Restart the listener with the new keys.
+	return s.Reconfigure(address)
+}
+
+// canonicalAddress adds the default DNS port to the address if missing.
+func canonicalAddress(address string) string {
+	_, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return net.JoinHostPort(strings.Trim(address, "[]"), fmt.Sprintf("%d", 53))
+	}
+
+	return address
+}
@@ -56,6 +56,27 @@ func UpdateStaticEntry(network string, projectName string, instanceName string,
 	return nil
 }
 
+// UpdateReservationEntry writes a single dhcp-host line for a network DHCP reservation. The entry is named after
+// the MAC address, which can't clash with instance entries as colons aren't allowed in instance names.
+func UpdateReservationEntry(network string, hwaddr string, ipv4Address string, ipv6Address string) error {
+	hwaddr = strings.ToLower(hwaddr)
+	line := hwaddr
+
+	if ipv4Address != "" {
+		line += fmt.Sprintf(",%s", ipv4Address)
+	}
+
+	if ipv6Address != "" {
+		line += fmt.Sprintf(",[%s]", ipv6Address)
+	}
+
+	if line == hwaddr {
+		return nil
+	}
+
+	return ioutil.WriteFile(shared.VarPath("networks", network, "dnsmasq.hosts", fmt.Sprintf("reservation-%s", hwaddr)), []byte(line+"\n"), 0644)
+}
+
 // RemoveStaticEntry removes a single dhcp-host line for a network/instance combination.
 func RemoveStaticEntry(network string, projectName string, instanceName string) error {
 	err := os.Remove(shared.VarPath("networks", network, "dnsmasq.hosts", project.Instance(projectName, instanceName)))
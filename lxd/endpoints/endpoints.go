@@ -243,6 +243,14 @@ func (e *Endpoints) up(config *Config) error {
 	return nil
 }
 
+// SocketActivated returns whether the local endpoint was inherited through socket-based activation.
+func (e *Endpoints) SocketActivated() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.inherited[local]
+}
+
 // Down brings down all endpoints and stops serving HTTP requests.
 func (e *Endpoints) Down() error {
 	e.mu.Lock()
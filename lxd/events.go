@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/events"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/logger"
@@ -20,23 +22,21 @@ var eventsCmd = APIEndpoint{
 type eventsServe struct {
 	req *http.Request
 	d   *Daemon
+
+	// Project to listen to ("*" for all projects) and event types to subscribe to.
+	project string
+	types   []string
 }
 
 func (r *eventsServe) Render(w http.ResponseWriter) error {
-	return eventsSocket(r.d, r.req, w)
+	return eventsSocket(r.d, r.req, w, r.project, r.types)
 }
 
 func (r *eventsServe) String() string {
 	return "event handler"
 }
 
-func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter) error {
-	project := projectParam(r)
-	typeStr := r.FormValue("type")
-	if typeStr == "" {
-		typeStr = "logging,operation,lifecycle"
-	}
-
+func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter, project string, types []string) error {
 	// Upgrade the connection to websocket
 	c, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -58,8 +58,15 @@ func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter) error {
 
 	// If this request is an internal one initiated by another node wanting
 	// to watch the events on this node, set the listener to broadcast only
-	// local events.
-	listener, err := d.events.AddListener(project, c, strings.Split(typeStr, ","), serverName, isClusterNotification(r))
+	// local events. Members using this node as an event hub (see
+	// cluster.EventHubClientUserAgent) get the forwarded events as well.
+	var listener *events.Listener
+	if d.userIsAdmin(r) {
+		listener, err = d.events.AddListener(project, c, types, serverName, isClusterNotification(r))
+	} else {
+		// Events which aren't specific to any project, such as those of tokens, are for administrators.
+		listener, err = d.events.AddProjectListener(project, c, types, serverName)
+	}
 	if err != nil {
 		return err
 	}
@@ -94,5 +101,39 @@ func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter) error {
 }
 
 func eventsGet(d *Daemon, r *http.Request) response.Response {
-	return &eventsServe{req: r, d: d}
+	projectName := projectParam(r)
+	if shared.IsTrue(r.FormValue("all-projects")) {
+		projectName = "*"
+	}
+
+	typeStr := r.FormValue("type")
+	if typeStr == "" {
+		typeStr = "logging,operation,lifecycle"
+	}
+
+	types := strings.Split(typeStr, ",")
+
+	// Only admins can watch the events of all projects. Other users only get the events of projects they
+	// can view and don't get the server log, which isn't specific to any project.
+	isAdmin := d.userIsAdmin(r)
+	if projectName == "*" && !isAdmin {
+		return response.Forbidden(fmt.Errorf("Only administrators can watch the events of all projects"))
+	}
+
+	if !isAdmin {
+		if !d.userHasPermission(r, projectName, "view") {
+			return response.Forbidden(nil)
+		}
+
+		projectTypes := []string{}
+		for _, eventType := range types {
+			if eventType != "logging" {
+				projectTypes = append(projectTypes, eventType)
+			}
+		}
+
+		types = projectTypes
+	}
+
+	return &eventsServe{req: r, d: d, project: projectName, types: types}
 }
@@ -22,6 +22,14 @@ type Server struct {
 
 	listeners map[string]*Listener
 	lock      sync.Mutex
+
+	// Event types mirrored to the system log.
+	syslogTypes []string
+	syslogQueue chan syslogEvent
+
+	// Webhook the local events get sent to.
+	webhook      WebhookConfig
+	webhookQueue chan webhookEvent
 }
 
 // NewServer returns a new event server.
@@ -47,6 +55,26 @@ func (s *Server) AddListener(group string, connection *websocket.Conn, messageTy
 		id:           uuid.NewRandom().String(),
 	}
 
+	return s.addListener(listener)
+}
+
+// AddProjectListener creates and returns a new event listener which only gets the events of the given project,
+// leaving out those which aren't specific to any project.
+func (s *Server) AddProjectListener(project string, connection *websocket.Conn, messageTypes []string, location string) (*Listener, error) {
+	listener := &Listener{
+		group:        project,
+		connection:   connection,
+		messageTypes: messageTypes,
+		location:     location,
+		projectOnly:  true,
+		active:       make(chan bool, 1),
+		id:           uuid.NewRandom().String(),
+	}
+
+	return s.addListener(listener)
+}
+
+func (s *Server) addListener(listener *Listener) (*Listener, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
@@ -59,18 +87,32 @@ func (s *Server) AddListener(group string, connection *websocket.Conn, messageTy
 	return listener, nil
 }
 
-// SendLifecycle broadcasts a lifecycle event.
-func (s *Server) SendLifecycle(group, action, source string,
-	context map[string]interface{}) error {
-	s.Send(group, "lifecycle", api.EventLifecycle{
-		Action:  action,
-		Source:  source,
-		Context: context})
-	return nil
+// ListenerCount returns the number of connected event listeners.
+func (s *Server) ListenerCount() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.listeners)
+}
+
+// SendLifecycle broadcasts a lifecycle event to the listeners of the given project.
+func (s *Server) SendLifecycle(projectName string, event api.EventLifecycle) error {
+	return s.send(projectName, projectName, "lifecycle", event)
+}
+
+// SendOperation broadcasts an operation event to the listeners of the given project, or to all listeners if
+// the operation isn't tied to a project.
+func (s *Server) SendOperation(projectName string, op interface{}) error {
+	return s.send(projectName, projectName, "operation", op)
 }
 
 // Send broadcasts a custom event.
 func (s *Server) Send(group, eventType string, eventMessage interface{}) error {
+	return s.send(group, "", eventType, eventMessage)
+}
+
+// send broadcasts an event to the listeners of the given group, recording the project the event relates to.
+func (s *Server) send(group, projectName, eventType string, eventMessage interface{}) error {
 	encodedMessage, err := json.Marshal(eventMessage)
 	if err != nil {
 		return err
@@ -79,8 +121,12 @@ func (s *Server) Send(group, eventType string, eventMessage interface{}) error {
 		Type:      eventType,
 		Timestamp: time.Now(),
 		Metadata:  encodedMessage,
+		Project:   projectName,
 	}
 
+	s.queueSyslog(group, event)
+	s.queueWebhook(event)
+
 	return s.broadcast(group, event, false)
 }
 
@@ -103,7 +149,8 @@ func (s *Server) Forward(id int64, event api.Event) {
 		}
 	}
 
-	err := s.broadcast("", event, true)
+	// Only deliver project specific events to the listeners of that project.
+	err := s.broadcast(event.Project, event, true)
 	if err != nil {
 		logger.Warnf("Failed to forward event from node %d: %v", id, err)
 	}
@@ -117,6 +164,10 @@ func (s *Server) broadcast(group string, event api.Event, isForward bool) error
 			continue
 		}
 
+		if group == "" && listener.projectOnly {
+			continue
+		}
+
 		if isForward && listener.noForward {
 			continue
 		}
@@ -187,6 +238,9 @@ type Listener struct {
 	// nodes. It only used by listeners created internally by LXD nodes
 	// connecting to other LXD nodes to get their local events only.
 	noForward bool
+
+	// If true, this listener doesn't get the events which aren't specific to any project.
+	projectOnly bool
 }
 
 // MessageTypes returns a list of message types the listener will be notified of.
@@ -0,0 +1,269 @@
+package events
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// journalSocket is the path to the native journald socket.
+const journalSocket = "/run/systemd/journal/socket"
+
+// syslogQueueSize is the number of events which can be waiting to be written to the system log before new
+// ones get dropped.
+const syslogQueueSize = 1024
+
+// SyslogEventTypes lists the event types that can be mirrored to syslog/journald.
+var SyslogEventTypes = []string{"lifecycle", "operation", "audit"}
+
+// AuditEntry records a state changing API request.
+type AuditEntry struct {
+	Method    string                       `json:"method"`
+	URL       string                       `json:"url"`
+	Status    int                          `json:"status"`
+	Requestor *api.EventLifecycleRequestor `json:"requestor,omitempty"`
+}
+
+// syslogEvent is an event waiting to be written to the system log.
+type syslogEvent struct {
+	group string
+	event api.Event
+}
+
+// SetSyslogTypes configures which locally generated event types get mirrored to the system log.
+// An empty list disables mirroring.
+func (s *Server) SetSyslogTypes(types []string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.syslogTypes = types
+
+	// Start the writer the first time mirroring is enabled.
+	if len(types) > 0 && s.syslogQueue == nil {
+		s.syslogQueue = make(chan syslogEvent, syslogQueueSize)
+		go syslogWorker(s.syslogQueue)
+	}
+}
+
+// SendAudit writes an audit entry to the system log if audit events are mirrored. Audit entries aren't sent
+// to the event listeners.
+func (s *Server) SendAudit(projectName string, entry AuditEntry) error {
+	encodedMessage, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.queueSyslog(projectName, api.Event{
+		Type:      "audit",
+		Timestamp: time.Now(),
+		Metadata:  encodedMessage,
+		Project:   projectName,
+	})
+
+	return nil
+}
+
+// queueSyslog queues the given event to be written to the system log if mirroring is enabled for its type.
+func (s *Server) queueSyslog(group string, event api.Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.syslogQueue == nil || !shared.StringInSlice(event.Type, s.syslogTypes) {
+		return
+	}
+
+	select {
+	case s.syslogQueue <- syslogEvent{group: group, event: event}:
+	default:
+		logger.Warnf("Dropping %s event, the system log queue is full", event.Type)
+	}
+}
+
+// syslogWorker writes the queued events one at a time, so that they're logged in order.
+func syslogWorker(queue chan syslogEvent) {
+	writer := &syslogWriter{}
+	for entry := range queue {
+		writer.write(entry.group, entry.event)
+	}
+}
+
+// syslogWriter holds the connections to journald and syslog, which are kept open across events.
+type syslogWriter struct {
+	journal net.Conn
+	syslog  *syslog.Writer
+}
+
+// write mirrors an event to journald with structured fields, falling back to plain syslog when the
+// journal isn't available.
+func (w *syslogWriter) write(group string, event api.Event) {
+	fields, err := syslogFields(group, event)
+	if err != nil {
+		logger.Warnf("Failed to prepare %s event for the system log: %v", event.Type, err)
+		return
+	}
+
+	err = w.writeJournal(fields)
+	if err == nil {
+		return
+	}
+
+	logger.Debugf("Failed to send %s event to journald, falling back to syslog: %v", event.Type, err)
+
+	if w.syslog == nil {
+		w.syslog, err = syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, "lxd")
+		if err != nil {
+			logger.Warnf("Failed to connect to syslog: %v", err)
+			return
+		}
+	}
+
+	// The syslog writer reconnects by itself on failure.
+	err = w.syslog.Info(syslogMessage(fields))
+	if err != nil {
+		logger.Warnf("Failed to send %s event to syslog: %v", event.Type, err)
+	}
+}
+
+// writeJournal sends a message to journald using its native protocol, (re)connecting as needed.
+func (w *syslogWriter) writeJournal(fields map[string]string) error {
+	if w.journal == nil {
+		conn, err := net.Dial("unixgram", journalSocket)
+		if err != nil {
+			return err
+		}
+
+		w.journal = conn
+	}
+
+	_, err := w.journal.Write(journalMessage(fields))
+	if err != nil {
+		w.journal.Close()
+		w.journal = nil
+	}
+
+	return err
+}
+
+// syslogMessage renders the fields as a plain syslog message. Plain syslog has no structured data, so the
+// fields are appended to the message.
+func syslogMessage(fields map[string]string) string {
+	keys := []string{}
+	for key := range fields {
+		if key != "MESSAGE" && key != "PRIORITY" && key != "SYSLOG_IDENTIFIER" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	message := fields["MESSAGE"]
+	for _, key := range keys {
+		message += fmt.Sprintf(" %s=%q", strings.ToLower(strings.TrimPrefix(key, "LXD_")), fields[key])
+	}
+
+	return message
+}
+
+// syslogFields converts an event into journald style fields.
+func syslogFields(group string, event api.Event) (map[string]string, error) {
+	fields := map[string]string{
+		"PRIORITY":          "6",
+		"SYSLOG_IDENTIFIER": "lxd",
+		"LXD_EVENT_TYPE":    event.Type,
+	}
+
+	if group != "" {
+		fields["LXD_PROJECT"] = group
+	}
+
+	if event.Location != "" {
+		fields["LXD_LOCATION"] = event.Location
+	}
+
+	switch event.Type {
+	case "lifecycle":
+		lifecycle := api.EventLifecycle{}
+		err := json.Unmarshal(event.Metadata, &lifecycle)
+		if err != nil {
+			return nil, err
+		}
+
+		fields["MESSAGE"] = fmt.Sprintf("%s %s", lifecycle.Action, lifecycle.Source)
+		fields["LXD_ACTION"] = lifecycle.Action
+		fields["LXD_SOURCE"] = lifecycle.Source
+
+		if len(lifecycle.Context) > 0 {
+			context, err := json.Marshal(lifecycle.Context)
+			if err != nil {
+				return nil, err
+			}
+
+			fields["LXD_CONTEXT"] = string(context)
+		}
+	case "operation":
+		op := api.Operation{}
+		err := json.Unmarshal(event.Metadata, &op)
+		if err != nil {
+			return nil, err
+		}
+
+		fields["MESSAGE"] = fmt.Sprintf("%s: %s", op.Description, op.Status)
+		fields["LXD_OPERATION"] = op.ID
+		fields["LXD_STATUS"] = op.Status
+
+		if op.Err != "" {
+			fields["LXD_ERROR"] = op.Err
+		}
+	case "audit":
+		entry := AuditEntry{}
+		err := json.Unmarshal(event.Metadata, &entry)
+		if err != nil {
+			return nil, err
+		}
+
+		fields["MESSAGE"] = fmt.Sprintf("%s %s: %d", entry.Method, entry.URL, entry.Status)
+		fields["LXD_METHOD"] = entry.Method
+		fields["LXD_URL"] = entry.URL
+		fields["LXD_STATUS"] = strconv.Itoa(entry.Status)
+
+		if entry.Requestor != nil {
+			fields["LXD_USERNAME"] = entry.Requestor.Username
+			fields["LXD_PROTOCOL"] = entry.Requestor.Protocol
+			fields["LXD_ADDRESS"] = entry.Requestor.Address
+		}
+	default:
+		fields["MESSAGE"] = string(event.Metadata)
+	}
+
+	return fields, nil
+}
+
+// journalMessage encodes the fields using the native journald protocol.
+func journalMessage(fields map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	for key, value := range fields {
+		if !strings.Contains(value, "\n") {
+			fmt.Fprintf(buf, "%s=%s\n", key, value)
+			continue
+		}
+
+		// Multi-line values use the binary length-prefixed encoding.
+		buf.WriteString(key)
+		buf.WriteByte('\n')
+		binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,68 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestSyslogFields_Lifecycle(t *testing.T) {
+	metadata, err := json.Marshal(api.EventLifecycle{
+		Action:  "container-started",
+		Source:  "/1.0/containers/c1",
+		Context: map[string]interface{}{"stateful": false},
+	})
+	require.NoError(t, err)
+
+	fields, err := syslogFields("default", api.Event{Type: "lifecycle", Metadata: metadata})
+	require.NoError(t, err)
+
+	assert.Equal(t, "container-started /1.0/containers/c1", fields["MESSAGE"])
+	assert.Equal(t, "lifecycle", fields["LXD_EVENT_TYPE"])
+	assert.Equal(t, "container-started", fields["LXD_ACTION"])
+	assert.Equal(t, "/1.0/containers/c1", fields["LXD_SOURCE"])
+	assert.Equal(t, "default", fields["LXD_PROJECT"])
+	assert.Equal(t, `{"stateful":false}`, fields["LXD_CONTEXT"])
+}
+
+func TestSyslogFields_Operation(t *testing.T) {
+	metadata, err := json.Marshal(api.Operation{
+		ID:          "abc",
+		Description: "Creating container",
+		Status:      "Failure",
+		Err:         "boom",
+	})
+	require.NoError(t, err)
+
+	fields, err := syslogFields("", api.Event{Type: "operation", Metadata: metadata})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Creating container: Failure", fields["MESSAGE"])
+	assert.Equal(t, "abc", fields["LXD_OPERATION"])
+	assert.Equal(t, "boom", fields["LXD_ERROR"])
+	assert.NotContains(t, fields, "LXD_PROJECT")
+}
+
+func TestSyslogFields_Audit(t *testing.T) {
+	metadata, err := json.Marshal(AuditEntry{
+		Method:    "DELETE",
+		URL:       "/1.0/instances/c1?project=foo",
+		Status:    202,
+		Requestor: &api.EventLifecycleRequestor{Username: "alice", Protocol: "tls", Address: "10.0.0.1:1234"},
+	})
+	require.NoError(t, err)
+
+	fields, err := syslogFields("foo", api.Event{Type: "audit", Metadata: metadata})
+	require.NoError(t, err)
+
+	assert.Equal(t, "DELETE /1.0/instances/c1?project=foo: 202", fields["MESSAGE"])
+	assert.Equal(t, "202", fields["LXD_STATUS"])
+	assert.Equal(t, "alice", fields["LXD_USERNAME"])
+	assert.Equal(t, "foo", fields["LXD_PROJECT"])
+
+	assert.Equal(t, `DELETE /1.0/instances/c1?project=foo: 202 address="10.0.0.1:1234" event_type="audit" method="DELETE" project="foo" protocol="tls" status="202" url="/1.0/instances/c1?project=foo" username="alice"`, syslogMessage(fields))
+}
@@ -0,0 +1,164 @@
+package events
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// WebhookEventTypes lists the event types that can be sent to a webhook.
+var WebhookEventTypes = []string{"lifecycle", "operation"}
+
+// WebhookSignatureHeader is the HTTP header holding the HMAC-SHA256 signature of the webhook request body.
+const WebhookSignatureHeader = "X-LXD-Signature"
+
+// webhookQueueSize is the number of events which can be waiting for delivery before new ones get dropped.
+const webhookQueueSize = 1024
+
+// webhookTimeout is the timeout of a single webhook delivery attempt.
+const webhookTimeout = 10 * time.Second
+
+// WebhookConfig holds the settings of the webhook locally generated events get sent to.
+type WebhookConfig struct {
+	// URL of the endpoint, an empty URL disables the webhook.
+	URL string
+
+	// Secret used to sign the request body, requests aren't signed if empty.
+	Secret string
+
+	// Event types to send.
+	Types []string
+
+	// Number of times a failed delivery is retried.
+	Retries int
+
+	// Delay before the first retry, doubled after each failed attempt.
+	RetryInterval time.Duration
+
+	// Proxy function used for the requests.
+	Proxy func(req *http.Request) (*url.URL, error)
+
+	// Name of the local server, set as the location of the events.
+	Location string
+}
+
+// webhookEvent is an event waiting to be delivered, along with the configuration it was queued with.
+type webhookEvent struct {
+	config WebhookConfig
+	event  api.Event
+}
+
+// SetWebhook configures the webhook locally generated events get sent to.
+func (s *Server) SetWebhook(config WebhookConfig) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.webhook = config
+
+	// Start the delivery worker the first time a webhook is configured.
+	if config.URL != "" && s.webhookQueue == nil {
+		s.webhookQueue = make(chan webhookEvent, webhookQueueSize)
+		go webhookWorker(s.webhookQueue)
+	}
+}
+
+// queueWebhook queues the given event for delivery if the webhook is configured for its type.
+func (s *Server) queueWebhook(event api.Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.webhook.URL == "" || !shared.StringInSlice(event.Type, s.webhook.Types) {
+		return
+	}
+
+	if event.Location == "" {
+		event.Location = s.webhook.Location
+	}
+
+	select {
+	case s.webhookQueue <- webhookEvent{config: s.webhook, event: event}:
+	default:
+		logger.Warnf("Dropping %s event, the webhook delivery queue is full", event.Type)
+	}
+}
+
+// webhookWorker delivers the queued events one at a time, so that the endpoint receives them in order.
+func webhookWorker(queue chan webhookEvent) {
+	for entry := range queue {
+		err := sendWebhook(entry.config, entry.event)
+		if err != nil {
+			logger.Warnf("Failed to send %s event to webhook %q: %v", entry.event.Type, entry.config.URL, err)
+		}
+	}
+}
+
+// sendWebhook POSTs the event to the webhook, retrying with an exponential backoff on failure.
+func sendWebhook(config WebhookConfig, event api.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: &http.Transport{Proxy: config.Proxy},
+	}
+
+	interval := config.RetryInterval
+	for attempt := 0; ; attempt++ {
+		err = postWebhook(client, config, body)
+		if err == nil || attempt >= config.Retries {
+			return err
+		}
+
+		logger.Debugf("Failed to send %s event to webhook %q, retrying in %v: %v", event.Type, config.URL, interval, err)
+		time.Sleep(interval)
+		interval *= 2
+	}
+}
+
+// postWebhook makes a single delivery attempt.
+func postWebhook(client *http.Client, config WebhookConfig, body []byte) error {
+	req, err := http.NewRequest("POST", config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", version.UserAgent)
+
+	if config.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, fmt.Sprintf("sha256=%s", WebhookSignature(config.Secret, body)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Unexpected response status %q", resp.Status)
+	}
+
+	return nil
+}
+
+// WebhookSignature returns the hex encoded HMAC-SHA256 of the request body using the given secret.
+func WebhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
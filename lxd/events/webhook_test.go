@@ -0,0 +1,58 @@
+package events
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestSendWebhook_Signed(t *testing.T) {
+	var body []byte
+	var signature string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		signature = r.Header.Get(WebhookSignatureHeader)
+	}))
+	defer server.Close()
+
+	event := api.Event{Type: "lifecycle", Metadata: json.RawMessage(`{"action":"instance-started"}`)}
+	err := sendWebhook(WebhookConfig{URL: server.URL, Secret: "secret"}, event)
+	require.NoError(t, err)
+
+	received := api.Event{}
+	require.NoError(t, json.Unmarshal(body, &received))
+	assert.Equal(t, "lifecycle", received.Type)
+	assert.Equal(t, "sha256="+WebhookSignature("secret", body), signature)
+}
+
+func TestSendWebhook_Retries(t *testing.T) {
+	attempts := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	config := WebhookConfig{URL: server.URL, Retries: 2, RetryInterval: time.Millisecond}
+	err := sendWebhook(config, api.Event{Type: "operation"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	config.Retries = 1
+	err = sendWebhook(config, api.Event{Type: "operation"})
+	assert.Error(t, err)
+	assert.Equal(t, 2, attempts)
+}
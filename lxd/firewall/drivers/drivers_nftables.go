@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"os/exec"
+	"regexp"
 	"strconv"
 	"strings"
 	"text/template"
@@ -27,6 +28,9 @@ const nftablesContentTemplate = "nftablesContent"
 // to contain underscores (where as instance name is not).
 const nftablesChainSeparator = "."
 
+// nftablesCounterRegex matches the counter statement of a listed rule.
+var nftablesCounterRegex = regexp.MustCompile(`\s*counter packets (\d+) bytes (\d+)`)
+
 // nftablesMinVersion We need at least 0.9.1 as this was when the arp ether saddr filters were added.
 const nftablesMinVersion = "0.9.1"
 
@@ -274,6 +278,219 @@ func (d Nftables) NetworkClear(networkName string, ipVersion uint) error {
 	return nil
 }
 
+// NetworkApplyForwards replaces the address forwards of the network with the given ones.
+func (d Nftables) NetworkApplyForwards(networkName string, forwards []AddressForward) error {
+	err := d.NetworkClearForwards(networkName)
+	if err != nil {
+		return err
+	}
+
+	// Generate the rules, grouped by family as each family uses its own table.
+	rules := map[string][]map[string]interface{}{}
+	for _, forward := range forwards {
+		family := "ip"
+		targetDest := fmt.Sprintf("%s:%d", forward.TargetAddress.String(), forward.TargetPort)
+		if forward.ListenAddress.To4() == nil {
+			family = "ip6"
+			targetDest = fmt.Sprintf("[%s]:%d", forward.TargetAddress.String(), forward.TargetPort)
+		}
+
+		rules[family] = append(rules[family], map[string]interface{}{
+			"family":     family,
+			"protocol":   forward.Protocol,
+			"listenHost": forward.ListenAddress.String(),
+			"listenPort": forward.ListenPort,
+			"targetHost": forward.TargetAddress.String(),
+			"targetPort": forward.TargetPort,
+			"targetDest": targetDest,
+		})
+	}
+
+	for family, familyRules := range rules {
+		tplFields := map[string]interface{}{
+			"namespace":      nftablesNamespace,
+			"chainSeparator": nftablesChainSeparator,
+			"networkName":    networkName,
+			"family":         family,
+			"rules":          familyRules,
+		}
+
+		err = d.applyNftConfig(nftablesNetForwards, tplFields)
+		if err != nil {
+			d.NetworkClearForwards(networkName)
+			return errors.Wrapf(err, "Failed adding forward rules for network %q (%s)", networkName, family)
+		}
+	}
+
+	return nil
+}
+
+// NetworkClearForwards removes the address forwards of the network.
+func (d Nftables) NetworkClearForwards(networkName string) error {
+	err := d.removeChains([]string{"ip", "ip6"}, networkName, "fwdprert", "fwdout", "fwdpstrt")
+	if err != nil {
+		return errors.Wrapf(err, "Failed clearing forward rules for network %q", networkName)
+	}
+
+	return nil
+}
+
+// NetworkApplyACLRules replaces the ACL rules applied to the traffic routed in and out of the network.
+func (d Nftables) NetworkApplyACLRules(networkName string, rules []ACLRule) error {
+	err := d.NetworkClearACLRules(networkName)
+	if err != nil {
+		return err
+	}
+
+	for _, ipVersion := range []uint{4, 6} {
+		family, _ := d.getIPFamily(ipVersion)
+
+		tplRules := []string{}
+		tplRules = append(tplRules, d.aclRules(rules, ipVersion, "egress", "iifname", networkName, false)...)
+		tplRules = append(tplRules, d.aclRules(rules, ipVersion, "ingress", "oifname", networkName, false)...)
+
+		tplFields := map[string]interface{}{
+			"namespace":      nftablesNamespace,
+			"chainSeparator": nftablesChainSeparator,
+			"networkName":    networkName,
+			"family":         family,
+			"rules":          tplRules,
+		}
+
+		err = d.applyNftConfig(nftablesNetACL, tplFields)
+		if err != nil {
+			d.NetworkClearACLRules(networkName)
+			return errors.Wrapf(err, "Failed adding ACL rules for network %q (%s)", networkName, family)
+		}
+	}
+
+	return nil
+}
+
+// NetworkClearACLRules removes the ACL rules of the network.
+func (d Nftables) NetworkClearACLRules(networkName string) error {
+	err := d.removeChains([]string{"ip", "ip6"}, networkName, "aclfwd")
+	if err != nil {
+		return errors.Wrapf(err, "Failed clearing ACL rules for network %q", networkName)
+	}
+
+	return nil
+}
+
+// NetworkCounters returns the counters of the address forward and ACL rules of the network.
+func (d Nftables) NetworkCounters(networkName string) ([]RuleCounters, error) {
+	counters := []RuleCounters{}
+
+	for _, family := range []string{"ip", "ip6"} {
+		for _, chain := range []string{"fwdprert", "fwdout", "fwdpstrt", "aclfwd"} {
+			chainName := fmt.Sprintf("%s%s%s", chain, nftablesChainSeparator, networkName)
+
+			// Skip chains which don't exist as the network doesn't have forwards or ACLs.
+			output, err := shared.RunCommandCLocale("nft", "list", "chain", family, nftablesNamespace, chainName)
+			if err != nil {
+				continue
+			}
+
+			for _, line := range strings.Split(output, "\n") {
+				match := nftablesCounterRegex.FindStringSubmatch(line)
+				if match == nil {
+					continue
+				}
+
+				packets, _ := strconv.ParseUint(match[1], 10, 64)
+				bytes, _ := strconv.ParseUint(match[2], 10, 64)
+
+				counters = append(counters, RuleCounters{
+					Chain:   fmt.Sprintf("%s %s", family, chainName),
+					Rule:    strings.TrimSpace(nftablesCounterRegex.ReplaceAllString(line, "")),
+					Packets: packets,
+					Bytes:   bytes,
+				})
+			}
+		}
+	}
+
+	return counters, nil
+}
+
+// aclRules renders the ACL rules of the given direction and IP version, matching the interface with ifMatch.
+// If etherType is true, the rules are also restricted to the matching ethernet type (for the bridge family).
+func (d Nftables) aclRules(rules []ACLRule, ipVersion uint, direction string, ifMatch string, ifName string, etherType bool) []string {
+	ipFamily, _ := d.getIPFamily(ipVersion)
+	nftRules := []string{}
+
+	for _, rule := range rules {
+		if rule.Direction != direction {
+			continue
+		}
+
+		rule, ok := aclRuleForIPVersion(rule, ipVersion)
+		if !ok {
+			continue
+		}
+
+		parts := []string{fmt.Sprintf("%s %q", ifMatch, ifName)}
+		if etherType {
+			parts = append(parts, fmt.Sprintf("ether type %s", ipFamily))
+		}
+
+		if len(rule.Source) > 0 {
+			parts = append(parts, fmt.Sprintf("%s saddr {%s}", ipFamily, strings.Join(rule.Source, ", ")))
+		}
+
+		if len(rule.Destination) > 0 {
+			parts = append(parts, fmt.Sprintf("%s daddr {%s}", ipFamily, strings.Join(rule.Destination, ", ")))
+		}
+
+		switch rule.Protocol {
+		case "tcp", "udp":
+			if len(rule.SourcePort) == 0 && len(rule.DestinationPort) == 0 {
+				parts = append(parts, fmt.Sprintf("meta l4proto %s", rule.Protocol))
+			}
+
+			if len(rule.SourcePort) > 0 {
+				parts = append(parts, fmt.Sprintf("%s sport {%s}", rule.Protocol, strings.Join(rule.SourcePort, ", ")))
+			}
+
+			if len(rule.DestinationPort) > 0 {
+				parts = append(parts, fmt.Sprintf("%s dport {%s}", rule.Protocol, strings.Join(rule.DestinationPort, ", ")))
+			}
+		case "icmp4", "icmp6":
+			icmp := "icmp"
+			l4proto := "icmp"
+			if rule.Protocol == "icmp6" {
+				icmp = "icmpv6"
+				l4proto = "ipv6-icmp"
+			}
+
+			if rule.ICMPType != "" {
+				parts = append(parts, fmt.Sprintf("%s type %s", icmp, rule.ICMPType))
+
+				if rule.ICMPCode != "" {
+					parts = append(parts, fmt.Sprintf("%s code %s", icmp, rule.ICMPCode))
+				}
+			} else {
+				parts = append(parts, fmt.Sprintf("meta l4proto %s", l4proto))
+			}
+		}
+
+		parts = append(parts, "counter")
+
+		switch rule.Action {
+		case "allow":
+			parts = append(parts, "accept")
+		case "reject":
+			parts = append(parts, "reject")
+		default:
+			parts = append(parts, "drop")
+		}
+
+		nftRules = append(nftRules, strings.Join(parts, " "))
+	}
+
+	return nftRules
+}
+
 //instanceDeviceLabel returns the unique label used for instance device chains.
 func (d Nftables) instanceDeviceLabel(projectName, instanceName, deviceName string) string {
 	return fmt.Sprintf("%s%s%s", project.Instance(projectName, instanceName), nftablesChainSeparator, deviceName)
@@ -489,6 +706,53 @@ func (d Nftables) removeChains(families []string, chainSuffix string, chains ...
 	return nil
 }
 
+// InstanceSetupACLRules replaces the ACL rules applied to the traffic of the specified instance device.
+func (d Nftables) InstanceSetupACLRules(projectName string, instanceName string, deviceName string, hostName string, rules []ACLRule) error {
+	err := d.InstanceClearACLRules(projectName, instanceName, deviceName)
+	if err != nil {
+		return err
+	}
+
+	deviceLabel := d.instanceDeviceLabel(projectName, instanceName, deviceName)
+
+	egressRules := []string{}
+	ingressRules := []string{}
+	for _, ipVersion := range []uint{4, 6} {
+		egressRules = append(egressRules, d.aclRules(rules, ipVersion, "egress", "iifname", hostName, true)...)
+		ingressRules = append(ingressRules, d.aclRules(rules, ipVersion, "ingress", "oifname", hostName, true)...)
+	}
+
+	tplFields := map[string]interface{}{
+		"namespace":      nftablesNamespace,
+		"chainSeparator": nftablesChainSeparator,
+		"family":         "bridge",
+		"deviceLabel":    deviceLabel,
+		"hostName":       hostName,
+		"egressRules":    egressRules,
+		"ingressRules":   ingressRules,
+	}
+
+	err = d.applyNftConfig(nftablesInstanceACL, tplFields)
+	if err != nil {
+		d.InstanceClearACLRules(projectName, instanceName, deviceName)
+		return errors.Wrapf(err, "Failed adding ACL rules for instance device %q", deviceLabel)
+	}
+
+	return nil
+}
+
+// InstanceClearACLRules removes the ACL rules of the specified instance device.
+func (d Nftables) InstanceClearACLRules(projectName string, instanceName string, deviceName string) error {
+	deviceLabel := d.instanceDeviceLabel(projectName, instanceName, deviceName)
+
+	err := d.removeChains([]string{"bridge"}, deviceLabel, "aclin", "aclfwd", "aclout")
+	if err != nil {
+		return errors.Wrapf(err, "Failed clearing ACL rules for instance device %q", deviceLabel)
+	}
+
+	return nil
+}
+
 // InstanceSetupRPFilter activates reverse path filtering for the specified instance device on the host interface.
 func (d Nftables) InstanceSetupRPFilter(projectName string, instanceName string, deviceName string, hostName string) error {
 	deviceLabel := d.instanceDeviceLabel(projectName, instanceName, deviceName)
@@ -76,6 +76,101 @@ chain pstrt{{.chainSeparator}}{{.deviceLabel}} {
 }
 `))
 
+var nftablesNetForwards = template.Must(template.New("nftablesNetForwards").Parse(`
+chain fwdprert{{.chainSeparator}}{{.networkName}} {
+	type nat hook prerouting priority -100; policy accept;
+	{{- range .rules}}
+	{{if .protocol -}}
+	{{.family}} daddr {{.listenHost}} {{.protocol}} dport {{.listenPort}} counter dnat to {{.targetDest}}
+	{{- else -}}
+	{{.family}} daddr {{.listenHost}} counter dnat to {{.targetHost}}
+	{{- end}}
+	{{- end}}
+}
+
+chain fwdout{{.chainSeparator}}{{.networkName}} {
+	type nat hook output priority -100; policy accept;
+	{{- range .rules}}
+	{{if .protocol -}}
+	{{.family}} daddr {{.listenHost}} {{.protocol}} dport {{.listenPort}} counter dnat to {{.targetDest}}
+	{{- else -}}
+	{{.family}} daddr {{.listenHost}} counter dnat to {{.targetHost}}
+	{{- end}}
+	{{- end}}
+}
+
+chain fwdpstrt{{.chainSeparator}}{{.networkName}} {
+	type nat hook postrouting priority 100; policy accept;
+	{{- range .rules}}
+	{{if .protocol -}}
+	{{.family}} saddr {{.targetHost}} {{.family}} daddr {{.targetHost}} {{.protocol}} dport {{.targetPort}} counter masquerade
+	{{- else -}}
+	{{.family}} saddr {{.targetHost}} {{.family}} daddr {{.targetHost}} counter masquerade
+	{{- end}}
+	{{- end}}
+}
+`))
+
+// nftablesNetACL defines the rules applying network ACLs to the traffic routed in and out of a network.
+// Replies to already accepted traffic are always allowed.
+var nftablesNetACL = template.Must(template.New("nftablesNetACL").Parse(`
+chain aclfwd{{.chainSeparator}}{{.networkName}} {
+	type filter hook forward priority 0; policy accept;
+	iifname "{{.networkName}}" ct state established,related accept
+	oifname "{{.networkName}}" ct state established,related accept
+	{{- range .rules}}
+	{{.}}
+	{{- end}}
+}
+`))
+
+// nftablesInstanceACL defines the rules applying network ACLs to the traffic of a bridged instance device.
+// ARP, IPv6 neighbour discovery, DHCP and DNS with the host are always allowed so the instance keeps working on
+// its network, as are replies to already accepted traffic.
+var nftablesInstanceACL = template.Must(template.New("nftablesInstanceACL").Parse(`
+chain aclin{{.chainSeparator}}{{.deviceLabel}} {
+	type filter hook input priority 0; policy accept;
+	iifname "{{.hostName}}" ct state established,related accept
+	iifname "{{.hostName}}" ether type arp accept
+	iifname "{{.hostName}}" ether type ip6 icmpv6 type {nd-router-solicit, nd-neighbor-solicit, nd-neighbor-advert} accept
+	iifname "{{.hostName}}" ether type ip udp dport 67 accept
+	iifname "{{.hostName}}" ether type ip6 udp dport 547 accept
+	iifname "{{.hostName}}" udp dport 53 accept
+	iifname "{{.hostName}}" tcp dport 53 accept
+	{{- range .egressRules}}
+	{{.}}
+	{{- end}}
+}
+
+chain aclfwd{{.chainSeparator}}{{.deviceLabel}} {
+	type filter hook forward priority 0; policy accept;
+	iifname "{{.hostName}}" ct state established,related accept
+	oifname "{{.hostName}}" ct state established,related accept
+	iifname "{{.hostName}}" ether type arp accept
+	oifname "{{.hostName}}" ether type arp accept
+	iifname "{{.hostName}}" ether type ip6 icmpv6 type {nd-neighbor-solicit, nd-neighbor-advert} accept
+	oifname "{{.hostName}}" ether type ip6 icmpv6 type {nd-neighbor-solicit, nd-neighbor-advert} accept
+	{{- range .egressRules}}
+	{{.}}
+	{{- end}}
+	{{- range .ingressRules}}
+	{{.}}
+	{{- end}}
+}
+
+chain aclout{{.chainSeparator}}{{.deviceLabel}} {
+	type filter hook output priority 0; policy accept;
+	oifname "{{.hostName}}" ct state established,related accept
+	oifname "{{.hostName}}" ether type arp accept
+	oifname "{{.hostName}}" ether type ip6 icmpv6 type {nd-router-advert, nd-neighbor-solicit, nd-neighbor-advert} accept
+	oifname "{{.hostName}}" ether type ip udp sport 67 accept
+	oifname "{{.hostName}}" ether type ip6 udp sport 547 accept
+	{{- range .ingressRules}}
+	{{.}}
+	{{- end}}
+}
+`))
+
 // nftablesInstanceBridgeFilter defines the rules needed for MAC, IPv4 and IPv6 bridge security filtering.
 // To prevent instances from using IPs that are different from their assigned IPs we use ARP and NDP filtering
 // to prevent neighbour advertisements that are not allowed. However in order for DHCPv4 & DHCPv6 to work back to
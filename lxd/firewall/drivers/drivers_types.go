@@ -0,0 +1,41 @@
+package drivers
+
+import (
+	"net"
+)
+
+// AddressForward represents a NAT address forward.
+// If Protocol is empty, all the traffic to ListenAddress is forwarded to TargetAddress and the ports are ignored.
+// Forwards are matched in the order they are applied.
+type AddressForward struct {
+	ListenAddress net.IP
+	TargetAddress net.IP
+	Protocol      string
+	ListenPort    uint64
+	TargetPort    uint64
+}
+
+// ACLRule represents a network ACL rule.
+// Direction is relative to the instances: "ingress" is traffic towards them and "egress" is traffic from them.
+// Action is one of "allow", "reject" or "drop". Empty match fields match any traffic.
+// Source and Destination contain IP addresses or CIDR subnets, ports are either single ports or "start-end"
+// ranges. Rules are matched in order and the first matching rule decides the fate of the traffic.
+type ACLRule struct {
+	Direction       string
+	Action          string
+	Source          []string
+	Destination     []string
+	Protocol        string
+	SourcePort      []string
+	DestinationPort []string
+	ICMPType        string
+	ICMPCode        string
+}
+
+// RuleCounters represents the packet and byte counters of a firewall rule.
+type RuleCounters struct {
+	Chain   string
+	Rule    string
+	Packets uint64
+	Bytes   uint64
+}
@@ -0,0 +1,47 @@
+package drivers
+
+import (
+	"net"
+)
+
+// aclRuleForIPVersion returns a copy of the ACL rule restricted to the addresses of the given IP version.
+// Returns false if the rule cannot match any traffic of that IP version.
+func aclRuleForIPVersion(rule ACLRule, ipVersion uint) (ACLRule, bool) {
+	if (rule.Protocol == "icmp4" && ipVersion != 4) || (rule.Protocol == "icmp6" && ipVersion != 6) {
+		return rule, false
+	}
+
+	filter := func(addresses []string) ([]string, bool) {
+		if len(addresses) == 0 {
+			return nil, true
+		}
+
+		filtered := []string{}
+		for _, address := range addresses {
+			ip := net.ParseIP(address)
+			if ip == nil {
+				ip, _, _ = net.ParseCIDR(address)
+			}
+
+			if ip != nil && (ip.To4() != nil) == (ipVersion == 4) {
+				filtered = append(filtered, address)
+			}
+		}
+
+		return filtered, len(filtered) > 0
+	}
+
+	var ok bool
+
+	rule.Source, ok = filter(rule.Source)
+	if !ok {
+		return rule, false
+	}
+
+	rule.Destination, ok = filter(rule.Destination)
+	if !ok {
+		return rule, false
+	}
+
+	return rule, true
+}
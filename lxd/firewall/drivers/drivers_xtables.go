@@ -10,6 +10,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/pkg/errors"
+
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/revert"
@@ -258,6 +260,275 @@ func (d Xtables) NetworkClear(networkName string, ipVersion uint) error {
 	return nil
 }
 
+// networkForwardIPTablesComment returns the iptables comment that is added to each network forward rule.
+func (d Xtables) networkForwardIPTablesComment(networkName string) string {
+	return fmt.Sprintf("LXD network %s forward", networkName)
+}
+
+// NetworkApplyForwards replaces the address forwards of the network with the given ones.
+func (d Xtables) NetworkApplyForwards(networkName string, forwards []AddressForward) error {
+	err := d.NetworkClearForwards(networkName)
+	if err != nil {
+		return err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+	revert.Add(func() { d.NetworkClearForwards(networkName) })
+
+	comment := d.networkForwardIPTablesComment(networkName)
+
+	// Rules are prepended, so add them in reverse order to keep the forwards order.
+	for i := len(forwards) - 1; i >= 0; i-- {
+		forward := forwards[i]
+
+		ipVersion := uint(4)
+		if forward.ListenAddress.To4() == nil {
+			ipVersion = 6
+		}
+
+		listenHost := forward.ListenAddress.String()
+		targetHost := forward.TargetAddress.String()
+
+		dnatRule := []string{"--destination", listenHost, "-j", "DNAT", "--to-destination", targetHost}
+		masqueradeRule := []string{"--source", targetHost, "--destination", targetHost, "-j", "MASQUERADE"}
+
+		if forward.Protocol != "" {
+			listenPort := fmt.Sprintf("%d", forward.ListenPort)
+			targetPort := fmt.Sprintf("%d", forward.TargetPort)
+
+			dnatRule = []string{"-p", forward.Protocol, "--destination", listenHost, "--dport", listenPort, "-j", "DNAT", "--to-destination", net.JoinHostPort(targetHost, targetPort)}
+			masqueradeRule = []string{"-p", forward.Protocol, "--source", targetHost, "--destination", targetHost, "--dport", targetPort, "-j", "MASQUERADE"}
+		}
+
+		// outbound <-> instance and host <-> instance.
+		for _, chain := range []string{"PREROUTING", "OUTPUT"} {
+			err = d.iptablesPrepend(ipVersion, comment, "nat", chain, dnatRule...)
+			if err != nil {
+				return err
+			}
+		}
+
+		// instance <-> instance.
+		err = d.iptablesPrepend(ipVersion, comment, "nat", "POSTROUTING", masqueradeRule...)
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// NetworkClearForwards removes the address forwards of the network.
+func (d Xtables) NetworkClearForwards(networkName string) error {
+	comment := d.networkForwardIPTablesComment(networkName)
+	errs := []error{}
+	for _, ipVersion := range []uint{4, 6} {
+		err := d.iptablesClear(ipVersion, comment, "nat")
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Failed to remove forward rules for network %q: %v", networkName, errs)
+	}
+
+	return nil
+}
+
+// networkACLIPTablesComment returns the iptables comment that is added to each network ACL rule.
+func (d Xtables) networkACLIPTablesComment(networkName string) string {
+	return fmt.Sprintf("LXD network %s acl", networkName)
+}
+
+// NetworkApplyACLRules replaces the ACL rules applied to the traffic routed in and out of the network.
+func (d Xtables) NetworkApplyACLRules(networkName string, rules []ACLRule) error {
+	err := d.NetworkClearACLRules(networkName)
+	if err != nil {
+		return err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+	revert.Add(func() { d.NetworkClearACLRules(networkName) })
+
+	comment := d.networkACLIPTablesComment(networkName)
+
+	for _, ipVersion := range []uint{4, 6} {
+		iptRules := [][]string{
+			{"-i", networkName, "-m", "conntrack", "--ctstate", "ESTABLISHED,RELATED", "-j", "ACCEPT"},
+			{"-o", networkName, "-m", "conntrack", "--ctstate", "ESTABLISHED,RELATED", "-j", "ACCEPT"},
+		}
+
+		for _, direction := range []string{"egress", "ingress"} {
+			ifMatch := "-i"
+			if direction == "ingress" {
+				ifMatch = "-o"
+			}
+
+			for _, rule := range rules {
+				if rule.Direction != direction {
+					continue
+				}
+
+				rule, ok := aclRuleForIPVersion(rule, ipVersion)
+				if !ok {
+					continue
+				}
+
+				iptRules = append(iptRules, d.aclRule(rule, ipVersion, ifMatch, networkName))
+			}
+		}
+
+		// Rules are prepended, so add them in reverse order to keep the ACL order.
+		for i := len(iptRules) - 1; i >= 0; i-- {
+			err = d.iptablesPrepend(ipVersion, comment, "filter", "FORWARD", iptRules[i]...)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// NetworkClearACLRules removes the ACL rules of the network.
+func (d Xtables) NetworkClearACLRules(networkName string) error {
+	comment := d.networkACLIPTablesComment(networkName)
+	errs := []error{}
+	for _, ipVersion := range []uint{4, 6} {
+		err := d.iptablesClear(ipVersion, comment, "filter")
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Failed to remove ACL rules for network %q: %v", networkName, errs)
+	}
+
+	return nil
+}
+
+// NetworkCounters returns the counters of the address forward and ACL rules of the network.
+func (d Xtables) NetworkCounters(networkName string) ([]RuleCounters, error) {
+	counters := []RuleCounters{}
+	comments := []string{d.networkForwardIPTablesComment(networkName), d.networkACLIPTablesComment(networkName)}
+
+	for _, cmd := range []string{"iptables-save", "ip6tables-save"} {
+		// Skip missing commands, like for kernels without IPv6 support.
+		_, err := exec.LookPath(cmd)
+		if err != nil {
+			continue
+		}
+
+		output, err := shared.TryRunCommand(cmd, "-c")
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed listing rules with %q", cmd)
+		}
+
+		for _, line := range strings.Split(output, "\n") {
+			// Lines are "[<packets>:<bytes>] -A <chain> <rule>".
+			fields := strings.Fields(line)
+			if len(fields) < 4 || !strings.HasPrefix(fields[0], "[") || fields[1] != "-A" {
+				continue
+			}
+
+			found := false
+			for _, comment := range comments {
+				if strings.Contains(line, fmt.Sprintf("\"generated for %s\"", comment)) {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				continue
+			}
+
+			values := strings.SplitN(strings.Trim(fields[0], "[]"), ":", 2)
+			if len(values) != 2 {
+				continue
+			}
+
+			packets, _ := strconv.ParseUint(values[0], 10, 64)
+			bytes, _ := strconv.ParseUint(values[1], 10, 64)
+
+			counters = append(counters, RuleCounters{
+				Chain:   fmt.Sprintf("%s %s", strings.TrimSuffix(cmd, "-save"), fields[2]),
+				Rule:    strings.Join(fields[3:], " "),
+				Packets: packets,
+				Bytes:   bytes,
+			})
+		}
+	}
+
+	return counters, nil
+}
+
+// aclRule converts an ACL rule into iptables arguments, matching the interface with ifMatch.
+func (d Xtables) aclRule(rule ACLRule, ipVersion uint, ifMatch string, ifName string) []string {
+	args := []string{ifMatch, ifName}
+
+	if len(rule.Source) > 0 {
+		args = append(args, "-s", strings.Join(rule.Source, ","))
+	}
+
+	if len(rule.Destination) > 0 {
+		args = append(args, "-d", strings.Join(rule.Destination, ","))
+	}
+
+	// multiport uses ":" as the port range separator.
+	ports := func(ports []string) string {
+		return strings.Replace(strings.Join(ports, ","), "-", ":", -1)
+	}
+
+	switch rule.Protocol {
+	case "tcp", "udp":
+		args = append(args, "-p", rule.Protocol)
+
+		if len(rule.SourcePort) > 0 {
+			args = append(args, "-m", "multiport", "--sports", ports(rule.SourcePort))
+		}
+
+		if len(rule.DestinationPort) > 0 {
+			args = append(args, "-m", "multiport", "--dports", ports(rule.DestinationPort))
+		}
+	case "icmp4", "icmp6":
+		proto := "icmp"
+		typeArg := "--icmp-type"
+		if ipVersion == 6 {
+			proto = "ipv6-icmp"
+			typeArg = "--icmpv6-type"
+		}
+
+		args = append(args, "-p", proto)
+
+		if rule.ICMPType != "" {
+			icmpType := rule.ICMPType
+			if rule.ICMPCode != "" {
+				icmpType = fmt.Sprintf("%s/%s", rule.ICMPType, rule.ICMPCode)
+			}
+
+			args = append(args, typeArg, icmpType)
+		}
+	}
+
+	switch rule.Action {
+	case "allow":
+		args = append(args, "-j", "ACCEPT")
+	case "reject":
+		args = append(args, "-j", "REJECT")
+	default:
+		args = append(args, "-j", "DROP")
+	}
+
+	return args
+}
+
 //instanceDeviceIPTablesComment returns the iptables comment that is added to each instance device related rule.
 func (d Xtables) instanceDeviceIPTablesComment(projectName string, instanceName string, deviceName string) string {
 	return fmt.Sprintf("LXD container %s (%s)", project.Instance(projectName, instanceName), deviceName)
@@ -695,6 +966,16 @@ func (d Xtables) iptablesClear(ipVersion uint, comment string, fromTables ...str
 	return nil
 }
 
+// InstanceSetupACLRules is not supported by xtables as instance device ACLs require bridge level IP filtering.
+func (d Xtables) InstanceSetupACLRules(projectName string, instanceName string, deviceName string, hostName string, rules []ACLRule) error {
+	return fmt.Errorf("Network ACLs on instance devices require the nftables firewall driver")
+}
+
+// InstanceClearACLRules is a no-op as instance device ACLs are not supported by xtables.
+func (d Xtables) InstanceClearACLRules(projectName string, instanceName string, deviceName string) error {
+	return nil
+}
+
 // InstanceSetupRPFilter activates reverse path filtering for the specified instance device on the host interface.
 func (d Xtables) InstanceSetupRPFilter(projectName string, instanceName string, deviceName string, hostName string) error {
 	comment := fmt.Sprintf("%s rpfilter", d.instanceDeviceIPTablesComment(projectName, instanceName, deviceName))
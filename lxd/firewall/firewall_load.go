@@ -6,11 +6,26 @@ import (
 )
 
 // New returns an appropriate firewall implementation.
-// Uses xtables if nftables isn't compatible or isn't in use already, otherwise uses nftables.
-func New() Firewall {
+// If a driver is requested ("nftables" or "xtables"), it is used even if it isn't detected as compatible.
+// Otherwise uses xtables if nftables isn't compatible or isn't in use already, otherwise uses nftables.
+func New(driver string) Firewall {
 	nftables := drivers.Nftables{}
 	xtables := drivers.Xtables{}
 
+	if driver != "" {
+		var fw Firewall = xtables
+		if driver == nftables.String() {
+			fw = nftables
+		}
+
+		_, err := fw.Compat()
+		if err != nil {
+			logger.Warnf("Firewall driver %q was requested but some features may not work as expected due to: %v", fw, err)
+		}
+
+		return fw
+	}
+
 	nftablesInUse, nftablesCompatErr := nftables.Compat()
 	if nftablesCompatErr != nil {
 		logger.Debugf(`Firewall detected "nftables" incompatibility: %v`, nftablesCompatErr)
@@ -16,6 +16,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,9 +30,9 @@ import (
 	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
-	"github.com/lxc/lxd/lxd/filter"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/node"
 	"github.com/lxc/lxd/lxd/operations"
 	projectutils "github.com/lxc/lxd/lxd/project"
@@ -42,6 +43,7 @@ import (
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/filter"
 	"github.com/lxc/lxd/shared/ioprogress"
 	log "github.com/lxc/lxd/shared/log15"
 	"github.com/lxc/lxd/shared/logger"
@@ -402,8 +404,14 @@ func imgPostURLInfo(d *Daemon, req api.ImagesPost, op *operations.Operation, pro
 		return nil, err
 	}
 
+	// Apply the image mirrors
+	sourceURL, err := d.imageServerURL(req.Source.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't fetch %q", req.Source.URL)
+	}
+
 	// Resolve the image URL
-	head, err := http.NewRequest("HEAD", req.Source.URL, nil)
+	head, err := http.NewRequest("HEAD", sourceURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -912,6 +920,11 @@ func imagesPost(d *Daemon, r *http.Request) response.Response {
 		return response.InternalError(err)
 	}
 
+	// Downloads from other servers can be interrupted.
+	if !imageUpload && shared.StringInSlice(req.Source.Type, []string{"image", "url"}) {
+		op.SetCancelable()
+	}
+
 	return operations.OperationResponse(op)
 }
 
@@ -1078,13 +1091,151 @@ func imagesGet(d *Daemon, r *http.Request) response.Response {
 	if err != nil {
 		return response.SmartError(err)
 	}
-	return response.SyncResponse(true, result)
+	return response.SyncResponsePaginated(r, result)
+}
+
+// projectImageKeys are the image refresh and cached image expiry settings which a project can override.
+var projectImageKeys = []string{"images.auto_update_cached", "images.auto_update_interval", "images.remote_cache_expiry"}
+
+// imagePolicy holds the image refresh and cached image expiry settings applying to a project.
+type imagePolicy struct {
+	autoUpdateCached   bool
+	autoUpdateInterval time.Duration
+	remoteCacheExpiry  int64
+}
+
+// imageProjectPolicy returns the image policy of a project, the settings it doesn't set being taken from the
+// server configuration.
+func imageProjectPolicy(config *cluster.Config, projectConfig map[string]string) (imagePolicy, error) {
+	policy := imagePolicy{
+		autoUpdateCached:   config.AutoUpdateCached(),
+		autoUpdateInterval: config.AutoUpdateInterval(),
+		remoteCacheExpiry:  config.RemoteCacheExpiry(),
+	}
+
+	if projectConfig["images.auto_update_cached"] != "" {
+		policy.autoUpdateCached = shared.IsTrue(projectConfig["images.auto_update_cached"])
+	}
+
+	if projectConfig["images.auto_update_interval"] != "" {
+		hours, err := strconv.ParseInt(projectConfig["images.auto_update_interval"], 10, 64)
+		if err != nil {
+			return imagePolicy{}, errors.Wrap(err, "Invalid images.auto_update_interval")
+		}
+
+		policy.autoUpdateInterval = time.Duration(hours) * time.Hour
+	}
+
+	if projectConfig["images.remote_cache_expiry"] != "" {
+		days, err := strconv.ParseInt(projectConfig["images.remote_cache_expiry"], 10, 64)
+		if err != nil {
+			return imagePolicy{}, errors.Wrap(err, "Invalid images.remote_cache_expiry")
+		}
+
+		policy.remoteCacheExpiry = days
+	}
+
+	return policy, nil
+}
+
+// imagePolicies returns the image policy of every project having its own set of images.
+func imagePolicies(tx *db.ClusterTx) (map[string]imagePolicy, error) {
+	config, err := cluster.ConfigLoad(tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to load cluster configuration")
+	}
+
+	projects, err := tx.GetProjects(db.ProjectFilter{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to load projects")
+	}
+
+	policies := make(map[string]imagePolicy, len(projects))
+	for _, p := range projects {
+		if !shared.IsTrue(p.Config["features.images"]) {
+			continue
+		}
+
+		policy, err := imageProjectPolicy(config, p.Config)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to load the image policy of project %q", p.Name)
+		}
+
+		policies[p.Name] = policy
+	}
+
+	return policies, nil
+}
+
+// imagePolicyGet returns the image policy applying to the images used by the given project, which is the one of
+// the default project if it doesn't have its own set of images.
+func imagePolicyGet(c *db.Cluster, projectName string) (imagePolicy, error) {
+	var policy imagePolicy
+	err := c.Transaction(func(tx *db.ClusterTx) error {
+		config, err := cluster.ConfigLoad(tx)
+		if err != nil {
+			return errors.Wrap(err, "Failed to load cluster configuration")
+		}
+
+		p, err := tx.GetProject(projectName)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to load project %q", projectName)
+		}
+
+		if !shared.IsTrue(p.Config["features.images"]) {
+			p, err = tx.GetProject(projectutils.Default)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to load project %q", projectutils.Default)
+			}
+		}
+
+		policy, err = imageProjectPolicy(config, p.Config)
+		return err
+	})
+
+	return policy, err
 }
 
 func autoUpdateImagesTask(d *Daemon) (task.Func, task.Schedule) {
+	// Time of the last refresh of the images of each project.
+	lastUpdate := map[string]time.Time{}
+
 	f := func(ctx context.Context) {
+		var policies map[string]imagePolicy
+		err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			var err error
+			policies, err = imagePolicies(tx)
+			return err
+		})
+		if err != nil {
+			logger.Error("Failed to load image policies", log.Ctx{"err": err})
+			return
+		}
+
+		// Only refresh the projects whose interval elapsed since their last refresh, allowing for some
+		// drift of the task schedule.
+		projectNames := []string{}
+		for name, policy := range policies {
+			if policy.autoUpdateInterval <= 0 {
+				continue
+			}
+
+			if time.Since(lastUpdate[name]) < policy.autoUpdateInterval-time.Minute {
+				continue
+			}
+
+			projectNames = append(projectNames, name)
+			lastUpdate[name] = time.Now()
+		}
+
+		if len(projectNames) == 0 {
+			return
+		}
+
+		sort.Strings(projectNames)
+
 		opRun := func(op *operations.Operation) error {
-			return autoUpdateImages(ctx, d)
+			return autoUpdateImages(ctx, d, projectNames)
 		}
 
 		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationImagesUpdate, nil, nil, opRun, nil, nil)
@@ -1101,14 +1252,21 @@ func autoUpdateImagesTask(d *Daemon) (task.Func, task.Schedule) {
 		logger.Infof("Done updating images")
 	}
 
+	// Run as often as the shortest interval of all projects.
 	schedule := func() (time.Duration, error) {
 		var interval time.Duration
 		err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
-			config, err := cluster.ConfigLoad(tx)
+			policies, err := imagePolicies(tx)
 			if err != nil {
-				return errors.Wrap(err, "failed to load cluster configuration")
+				return err
+			}
+
+			for _, policy := range policies {
+				if policy.autoUpdateInterval > 0 && (interval == 0 || policy.autoUpdateInterval < interval) {
+					interval = policy.autoUpdateInterval
+				}
 			}
-			interval = config.AutoUpdateInterval()
+
 			return nil
 		})
 		if err != nil {
@@ -1119,24 +1277,7 @@ func autoUpdateImagesTask(d *Daemon) (task.Func, task.Schedule) {
 	return f, schedule
 }
 
-func autoUpdateImages(ctx context.Context, d *Daemon) error {
-	projectNames := []string{}
-	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
-		projects, err := tx.GetProjects(db.ProjectFilter{})
-		if err != nil {
-			return err
-		}
-
-		for _, project := range projects {
-			projectNames = append(projectNames, project.Name)
-		}
-
-		return nil
-	})
-	if err != nil {
-		return errors.Wrap(err, "Unable to retrieve project names")
-	}
-
+func autoUpdateImages(ctx context.Context, d *Daemon, projectNames []string) error {
 	for _, project := range projectNames {
 		err := autoUpdateImagesInProject(ctx, d, project)
 		if err != nil {
@@ -1197,6 +1338,15 @@ func autoUpdateImage(d *Daemon, op *operations.Operation, id int, info *api.Imag
 		return err
 	}
 
+	// Skip automatic updates from servers which can't be reached in offline mode
+	if op == nil {
+		_, err = d.imageServerURL(source.Server)
+		if err == errOffline {
+			logger.Debug("Offline mode is enabled, not updating image", log.Ctx{"fp": fingerprint, "server": source.Server})
+			return nil
+		}
+	}
+
 	// Get the IDs of all storage pools on which a storage volume
 	// for the requested image currently exists.
 	poolIDs, err := d.cluster.GetPoolsWithImage(fingerprint)
@@ -1315,6 +1465,8 @@ func autoUpdateImage(d *Daemon, op *operations.Operation, id int, info *api.Imag
 	}
 
 	setRefreshResult(true)
+	d.State().Events.SendLifecycle(project, lifecycle.ImageRefreshed.Event(hash, op.Requestor(), map[string]interface{}{"old_fingerprint": fingerprint}))
+
 	return nil
 }
 
@@ -1338,12 +1490,28 @@ func pruneExpiredImagesTask(d *Daemon) (task.Func, task.Schedule) {
 		logger.Infof("Done pruning expired images")
 	}
 
+	// expiryEnabled returns whether the cached images of any project are set to expire.
+	expiryEnabled := func() (bool, error) {
+		expiry, err := imagesRemoteCacheExpiry(d.cluster)
+		if err != nil {
+			return false, err
+		}
+
+		for _, days := range expiry {
+			if days > 0 {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
 	// Skip the first run, and instead run an initial pruning synchronously
 	// before we start updating images later on in the start up process.
-	expiry, err := cluster.ConfigGetInt64(d.cluster, "images.remote_cache_expiry")
+	enabled, err := expiryEnabled()
 	if err != nil {
-		logger.Error("Unable to fetch cluster configuration", log.Ctx{"err": err})
-	} else if expiry > 0 {
+		logger.Error("Unable to fetch image policies", log.Ctx{"err": err})
+	} else if enabled {
 		f(context.Background())
 	}
 
@@ -1355,14 +1523,14 @@ func pruneExpiredImagesTask(d *Daemon) (task.Func, task.Schedule) {
 			return interval, task.ErrSkip
 		}
 
-		expiry, err := cluster.ConfigGetInt64(d.cluster, "images.remote_cache_expiry")
+		enabled, err := expiryEnabled()
 		if err != nil {
-			logger.Error("Unable to fetch cluster configuration", log.Ctx{"err": err})
+			logger.Error("Unable to fetch image policies", log.Ctx{"err": err})
 			return interval, nil
 		}
 
 		// Check if we're supposed to prune at all
-		if expiry <= 0 {
+		if !enabled {
 			interval = 0
 		}
 
@@ -1422,10 +1590,32 @@ func pruneLeftoverImages(d *Daemon) {
 	logger.Infof("Done pruning leftover image files")
 }
 
+// imagesRemoteCacheExpiry returns the number of days after which the unused cached images of each project expire.
+func imagesRemoteCacheExpiry(c *db.Cluster) (map[string]int64, error) {
+	expiry := map[string]int64{}
+	err := c.Transaction(func(tx *db.ClusterTx) error {
+		policies, err := imagePolicies(tx)
+		if err != nil {
+			return err
+		}
+
+		for name, policy := range policies {
+			expiry[name] = policy.remoteCacheExpiry
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return expiry, nil
+}
+
 func pruneExpiredImages(ctx context.Context, d *Daemon) error {
-	expiry, err := cluster.ConfigGetInt64(d.cluster, "images.remote_cache_expiry")
+	expiry, err := imagesRemoteCacheExpiry(d.cluster)
 	if err != nil {
-		return errors.Wrap(err, "Unable to fetch cluster configuration")
+		return errors.Wrap(err, "Unable to fetch image policies")
 	}
 
 	// Get the list of expired images.
@@ -1445,6 +1635,28 @@ func pruneExpiredImages(ctx context.Context, d *Daemon) error {
 		default:
 		}
 
+		imgID, _, err := d.cluster.GetImage(img.ProjectName, img.Fingerprint, false)
+		if err != nil {
+			return errors.Wrapf(err, "Error retrieving image info for fingerprint %q and project %q", img.Fingerprint, img.ProjectName)
+		}
+
+		// The image files are shared by all the projects using the image, only remove the database entry
+		// if other projects still reference it.
+		referenced, err := d.cluster.ImageIsReferencedByOtherProjects(img.ProjectName, img.Fingerprint)
+		if err != nil {
+			return errors.Wrapf(err, "Error checking the projects using image %q", img.Fingerprint)
+		}
+
+		if referenced {
+			err = d.cluster.DeleteImage(imgID)
+			if err != nil {
+				return errors.Wrapf(err, "Error deleting image %q from database", img.Fingerprint)
+			}
+
+			d.State().Events.SendLifecycle(img.ProjectName, lifecycle.ImageDeleted.Event(img.Fingerprint, nil, map[string]interface{}{"reason": "expired"}))
+			continue
+		}
+
 		// Get the IDs of all storage pools on which a storage volume
 		// for the requested image currently exists.
 		poolIDs, err := d.cluster.GetPoolsWithImage(img.Fingerprint)
@@ -1483,15 +1695,12 @@ func pruneExpiredImages(ctx context.Context, d *Daemon) error {
 			}
 		}
 
-		imgID, _, err := d.cluster.GetImage(img.ProjectName, img.Fingerprint, false)
-		if err != nil {
-			return errors.Wrapf(err, "Error retrieving image info for fingerprint %q and project %q", img.Fingerprint, img.ProjectName)
-		}
-
 		// Remove the database entry for the image.
 		if err = d.cluster.DeleteImage(imgID); err != nil {
 			return errors.Wrapf(err, "Error deleting image %q from database", img.Fingerprint)
 		}
+
+		d.State().Events.SendLifecycle(img.ProjectName, lifecycle.ImageDeleted.Event(img.Fingerprint, nil, map[string]interface{}{"reason": "expired"}))
 	}
 
 	return nil
@@ -2019,6 +2228,12 @@ func imageExport(d *Daemon, r *http.Request) response.Response {
 	public := d.checkTrustedClient(r) != nil || allowProjectPermission("images", "view")(d, r) != response.EmptySyncResponse
 	secret := r.FormValue("secret")
 
+	// Individual parts of split images can be requested, so that they can be downloaded separately.
+	part := r.FormValue("part")
+	if part != "" && !shared.StringInSlice(part, []string{"metadata", "rootfs"}) {
+		return response.BadRequest(fmt.Errorf("Invalid image part %q", part))
+	}
+
 	var imgInfo *api.Image
 	var err error
 	if r.RemoteAddr == "@devlxd" {
@@ -2068,7 +2283,32 @@ func imageExport(d *Daemon, r *http.Request) response.Response {
 	}
 	filename := fmt.Sprintf("%s%s", imgInfo.Fingerprint, ext)
 
+	// Single files are served with support for range requests, the ETag allows clients to safely resume an
+	// interrupted download.
+	headers := map[string]string{"ETag": fmt.Sprintf(`"%s"`, imgInfo.Fingerprint)}
+
 	if shared.PathExists(rootfsPath) {
+		if part != "" {
+			headers["ETag"] = fmt.Sprintf(`"%s-%s"`, imgInfo.Fingerprint, part)
+
+			files := make([]response.FileResponseEntry, 1)
+			files[0].Identifier = part
+			files[0].Path = imagePath
+			files[0].Filename = "meta-" + filename
+
+			if part == "rootfs" {
+				_, ext, _, err = shared.DetectCompression(rootfsPath)
+				if err != nil {
+					ext = ""
+				}
+
+				files[0].Path = rootfsPath
+				files[0].Filename = fmt.Sprintf("%s%s", imgInfo.Fingerprint, ext)
+			}
+
+			return response.FileResponse(r, files, headers, false)
+		}
+
 		files := make([]response.FileResponseEntry, 2)
 
 		files[0].Identifier = "metadata"
@@ -2094,12 +2334,16 @@ func imageExport(d *Daemon, r *http.Request) response.Response {
 		return response.FileResponse(r, files, nil, false)
 	}
 
+	if part == "rootfs" {
+		return response.BadRequest(fmt.Errorf("Image %q isn't a split image", imgInfo.Fingerprint))
+	}
+
 	files := make([]response.FileResponseEntry, 1)
 	files[0].Identifier = filename
 	files[0].Path = imagePath
 	files[0].Filename = filename
 
-	return response.FileResponse(r, files, nil, false)
+	return response.FileResponse(r, files, headers, false)
 }
 
 func imageExportPost(d *Daemon, r *http.Request) response.Response {
@@ -2128,6 +2372,16 @@ func imageExportPost(d *Daemon, r *http.Request) response.Response {
 		CacheExpiry:   time.Hour,
 	}
 
+	// Outbound connections are disabled in offline mode
+	offline, err := cluster.ConfigGetBool(d.cluster, "core.offline")
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if offline {
+		return response.BadRequest(errors.Wrapf(errOffline, "Can't push image to %q", req.Target))
+	}
+
 	// Setup LXD client
 	remote, err := lxd.ConnectLXD(req.Target, args)
 	if err != nil {
@@ -2302,6 +2556,8 @@ func imageRefresh(d *Daemon, r *http.Request) response.Response {
 		return response.InternalError(err)
 	}
 
+	op.SetRequestor(r)
+
 	return operations.OperationResponse(op)
 }
 
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/simplestreams"
+)
+
+// apiStreams holds the endpoints publishing the public images of the default project as a simplestreams server.
+var apiStreams = []APIEndpoint{
+	streamsIndexCmd,
+	streamsImagesCmd,
+	streamsFileCmd,
+}
+
+var streamsIndexCmd = APIEndpoint{
+	Path: "index.json",
+
+	Get: APIEndpointAction{Handler: streamsIndexGet, AllowUntrusted: true},
+}
+
+var streamsImagesCmd = APIEndpoint{
+	Path: "images.json",
+
+	Get: APIEndpointAction{Handler: streamsImagesGet, AllowUntrusted: true},
+}
+
+var streamsFileCmd = APIEndpoint{
+	Path: "files/{fingerprint}/{name}",
+
+	Get: APIEndpointAction{Handler: streamsFileGet, AllowUntrusted: true},
+}
+
+// streamsFile is an image file published over simplestreams.
+type streamsFile struct {
+	path   string
+	name   string
+	size   int64
+	sha256 string
+}
+
+// streamsFiles caches the files of the published images, indexed by image fingerprint. The content of the files
+// of an image never changes, so their hashes only need to be computed once.
+var streamsFiles = map[string][]streamsFile{}
+var streamsFilesLock sync.Mutex
+
+// streamsImageFiles returns the files of the given image, which must be available locally.
+func streamsImageFiles(fingerprint string) ([]streamsFile, error) {
+	streamsFilesLock.Lock()
+	defer streamsFilesLock.Unlock()
+
+	files, ok := streamsFiles[fingerprint]
+	if ok {
+		return files, nil
+	}
+
+	imagePath := shared.VarPath("images", fingerprint)
+	rootfsPath := imagePath + ".rootfs"
+
+	_, ext, _, err := shared.DetectCompression(imagePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to detect the compression of image %q", fingerprint)
+	}
+
+	files = []streamsFile{{path: imagePath, name: fingerprint + ext}}
+	if shared.PathExists(rootfsPath) {
+		files[0].name = "meta" + ext
+
+		_, ext, _, err = shared.DetectCompression(rootfsPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to detect the compression of the root filesystem of image %q", fingerprint)
+		}
+
+		files = append(files, streamsFile{path: rootfsPath, name: "rootfs" + ext})
+	}
+
+	for i := range files {
+		f, err := os.Open(files[i].path)
+		if err != nil {
+			return nil, err
+		}
+
+		hash := sha256.New()
+		size, err := io.Copy(hash, f)
+		f.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to hash %q", files[i].path)
+		}
+
+		files[i].size = size
+		files[i].sha256 = fmt.Sprintf("%x", hash.Sum(nil))
+	}
+
+	streamsFiles[fingerprint] = files
+
+	return files, nil
+}
+
+// streamsEnabled returns whether the images are published over simplestreams.
+func streamsEnabled(d *Daemon) (bool, error) {
+	return cluster.ConfigGetBool(d.cluster, "images.simplestreams")
+}
+
+// streamsPublicImage returns the given public image of the default project.
+func streamsPublicImage(d *Daemon, fingerprint string) (*api.Image, error) {
+	_, info, err := d.cluster.GetImage(project.Default, fingerprint, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.Public || info.Fingerprint != fingerprint {
+		return nil, fmt.Errorf("Image %q not found", fingerprint)
+	}
+
+	return info, nil
+}
+
+// streamsProduct returns the simplestreams product describing the given image, the fingerprint being used as
+// the product name.
+func streamsProduct(info *api.Image, files []streamsFile) (simplestreams.Product, error) {
+	filePath := func(file streamsFile) string {
+		return fmt.Sprintf("streams/v1/files/%s/%s", info.Fingerprint, file.name)
+	}
+
+	items := map[string]simplestreams.ProductVersionItem{}
+	if len(files) == 1 {
+		items["lxd_combined.tar.gz"] = simplestreams.ProductVersionItem{
+			FileType:   "lxd_combined.tar.gz",
+			Path:       filePath(files[0]),
+			HashSha256: files[0].sha256,
+			Size:       files[0].size,
+		}
+	} else {
+		meta := simplestreams.ProductVersionItem{
+			FileType:   "lxd.tar.xz",
+			Path:       filePath(files[0]),
+			HashSha256: files[0].sha256,
+			Size:       files[0].size,
+		}
+
+		root := simplestreams.ProductVersionItem{
+			Path:       filePath(files[1]),
+			HashSha256: files[1].sha256,
+			Size:       files[1].size,
+		}
+
+		// The root filesystem type tells the clients which combined hash is the image fingerprint.
+		switch {
+		case info.Type == "virtual-machine":
+			root.FileType = "disk-kvm.img"
+			meta.LXDHashSha256DiskKvmImg = info.Fingerprint
+		case strings.HasSuffix(files[1].name, ".squashfs"):
+			root.FileType = "squashfs"
+			meta.LXDHashSha256SquashFs = info.Fingerprint
+		case strings.HasSuffix(files[1].name, ".tar.xz"):
+			root.FileType = "root.tar.xz"
+			meta.LXDHashSha256RootXz = info.Fingerprint
+		default:
+			return simplestreams.Product{}, fmt.Errorf("Unsupported root filesystem format %q", files[1].name)
+		}
+
+		items[meta.FileType] = meta
+		items[root.FileType] = root
+	}
+
+	aliases := []string{}
+	for _, alias := range info.Aliases {
+		aliases = append(aliases, alias.Name)
+	}
+
+	architecture := info.Properties["architecture"]
+	if architecture == "" {
+		architecture = info.Architecture
+	}
+
+	return simplestreams.Product{
+		Aliases:         strings.Join(aliases, ","),
+		Architecture:    architecture,
+		OperatingSystem: info.Properties["os"],
+		Release:         info.Properties["release"],
+		ReleaseTitle:    info.Properties["release"],
+		Version:         info.Properties["version"],
+		Versions: map[string]simplestreams.ProductVersion{
+			// Clients expect the version names to start with the image date.
+			info.CreatedAt.UTC().Format("20060102_1504"): {
+				Items: items,
+				Label: info.Properties["variant"],
+			},
+		},
+	}, nil
+}
+
+// streamsProducts returns the products of all the public images of the default project available locally.
+func streamsProducts(d *Daemon) (map[string]simplestreams.Product, error) {
+	fingerprints, err := d.cluster.GetImagesFingerprints(project.Default, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve the list of public images")
+	}
+
+	products := map[string]simplestreams.Product{}
+	for _, fingerprint := range fingerprints {
+		info, err := streamsPublicImage(d, fingerprint)
+		if err != nil {
+			return nil, err
+		}
+
+		files, err := streamsImageFiles(fingerprint)
+		if err != nil {
+			logger.Debug("Skipping image not available locally", log.Ctx{"fingerprint": fingerprint, "err": err})
+			continue
+		}
+
+		product, err := streamsProduct(info, files)
+		if err != nil {
+			logger.Debug("Skipping image which can't be published", log.Ctx{"fingerprint": fingerprint, "err": err})
+			continue
+		}
+
+		products[fingerprint] = product
+	}
+
+	return products, nil
+}
+
+// streamsJSON renders the raw JSON document expected by the simplestreams clients.
+func streamsJSON(data interface{}) response.Response {
+	return response.ManualResponse(func(w http.ResponseWriter) error {
+		return json.NewEncoder(w).Encode(data)
+	})
+}
+
+func streamsIndexGet(d *Daemon, r *http.Request) response.Response {
+	enabled, err := streamsEnabled(d)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !enabled {
+		return response.NotFound(nil)
+	}
+
+	products, err := streamsProducts(d)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	names := []string{}
+	for name := range products {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	updated := time.Now().UTC().Format(time.RFC1123Z)
+
+	return streamsJSON(simplestreams.Stream{
+		Format:  "index:1.0",
+		Updated: updated,
+		Index: map[string]simplestreams.StreamIndex{
+			"images": {
+				DataType: "image-downloads",
+				Path:     "streams/v1/images.json",
+				Format:   "products:1.0",
+				Updated:  updated,
+				Products: names,
+			},
+		},
+	})
+}
+
+func streamsImagesGet(d *Daemon, r *http.Request) response.Response {
+	enabled, err := streamsEnabled(d)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !enabled {
+		return response.NotFound(nil)
+	}
+
+	products, err := streamsProducts(d)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return streamsJSON(simplestreams.Products{
+		ContentID: "images",
+		DataType:  "image-downloads",
+		Format:    "products:1.0",
+		Updated:   time.Now().UTC().Format(time.RFC1123Z),
+		Products:  products,
+	})
+}
+
+func streamsFileGet(d *Daemon, r *http.Request) response.Response {
+	fingerprint := mux.Vars(r)["fingerprint"]
+	name := mux.Vars(r)["name"]
+
+	enabled, err := streamsEnabled(d)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !enabled {
+		return response.NotFound(nil)
+	}
+
+	_, err = streamsPublicImage(d, fingerprint)
+	if err != nil {
+		return response.NotFound(err)
+	}
+
+	files, err := streamsImageFiles(fingerprint)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	for _, file := range files {
+		if file.name != name {
+			continue
+		}
+
+		entries := []response.FileResponseEntry{{
+			Identifier: file.name,
+			Path:       file.path,
+			Filename:   file.name,
+		}}
+
+		headers := map[string]string{"ETag": fmt.Sprintf(`"%s"`, file.sha256)}
+
+		return response.FileResponse(r, entries, headers, false)
+	}
+
+	return response.NotFound(fmt.Errorf("File %q not found", name))
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"net/url"
 
 	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/shared"
@@ -10,10 +11,26 @@ import (
 )
 
 type initDataNode struct {
-	api.ServerPut `yaml:",inline"`
-	Networks      []api.NetworksPost     `json:"networks" yaml:"networks"`
-	StoragePools  []api.StoragePoolsPost `json:"storage_pools" yaml:"storage_pools"`
-	Profiles      []api.ProfilesPost     `json:"profiles" yaml:"profiles"`
+	api.ServerPut  `yaml:",inline"`
+	Networks       []api.NetworksPost      `json:"networks" yaml:"networks"`
+	StoragePools   []api.StoragePoolsPost  `json:"storage_pools" yaml:"storage_pools"`
+	StorageVolumes []initDataStorageVolume `json:"storage_volumes,omitempty" yaml:"storage_volumes,omitempty"`
+	Profiles       []api.ProfilesPost      `json:"profiles" yaml:"profiles"`
+	Projects       []initDataProject       `json:"projects" yaml:"projects"`
+}
+
+// initDataProject is a project along with the networks, custom storage volumes and profiles it holds itself.
+type initDataProject struct {
+	api.ProjectsPost `yaml:",inline"`
+	Networks         []api.NetworksPost      `json:"networks,omitempty" yaml:"networks,omitempty"`
+	StorageVolumes   []initDataStorageVolume `json:"storage_volumes,omitempty" yaml:"storage_volumes,omitempty"`
+	Profiles         []api.ProfilesPost      `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+}
+
+// initDataStorageVolume is a custom storage volume along with the storage pool it's on.
+type initDataStorageVolume struct {
+	api.StorageVolumesPost `yaml:",inline"`
+	Pool                   string `json:"pool" yaml:"pool"`
 }
 
 type initDataCluster struct {
@@ -67,6 +84,92 @@ func initDataNodeApply(d lxd.InstanceServer, config initDataNode) (func(), error
 		}
 	}
 
+	// Apply project configuration
+	if config.Projects != nil && len(config.Projects) > 0 {
+		// Get the list of projects
+		projectNames, err := d.GetProjectNames()
+		if err != nil {
+			return revert, errors.Wrap(err, "Failed to retrieve list of projects")
+		}
+
+		// Project creator
+		createProject := func(project initDataProject) error {
+			// Create the project if doesn't exist
+			err := d.CreateProject(project.ProjectsPost)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to create project '%s'", project.Name)
+			}
+
+			// Setup reverter
+			reverts = append(reverts, func() {
+				d.DeleteProject(project.Name)
+			})
+
+			return nil
+		}
+
+		// Project updater
+		updateProject := func(project initDataProject) error {
+			// Get the current project
+			currentProject, etag, err := d.GetProject(project.Name)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to retrieve current project '%s'", project.Name)
+			}
+
+			// Setup reverter
+			reverts = append(reverts, func() {
+				d.UpdateProject(currentProject.Name, currentProject.Writable(), "")
+			})
+
+			// Prepare the update
+			newProject := api.ProjectPut{}
+			err = shared.DeepCopy(currentProject.Writable(), &newProject)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to copy configuration of project '%s'", project.Name)
+			}
+
+			if newProject.Config == nil {
+				newProject.Config = map[string]string{}
+			}
+
+			// Description override
+			if project.Description != "" {
+				newProject.Description = project.Description
+			}
+
+			// Config overrides
+			for k, v := range project.Config {
+				newProject.Config[k] = fmt.Sprintf("%v", v)
+			}
+
+			// Apply it
+			err = d.UpdateProject(currentProject.Name, newProject, etag)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to update project '%s'", project.Name)
+			}
+
+			return nil
+		}
+
+		for _, project := range config.Projects {
+			// New project
+			if !shared.StringInSlice(project.Name, projectNames) {
+				err := createProject(project)
+				if err != nil {
+					return revert, err
+				}
+
+				continue
+			}
+
+			// Existing project
+			err := updateProject(project)
+			if err != nil {
+				return revert, err
+			}
+		}
+	}
+
 	// Apply network configuration
 	if config.Networks != nil && len(config.Networks) > 0 {
 		// Get the list of networks
@@ -236,6 +339,105 @@ func initDataNodeApply(d lxd.InstanceServer, config initDataNode) (func(), error
 		}
 	}
 
+	// Apply storage volume configuration
+	if config.StorageVolumes != nil && len(config.StorageVolumes) > 0 {
+		// Storage volume creator
+		createStorageVolume := func(storageVolume initDataStorageVolume) error {
+			// Create the storage volume if doesn't exist
+			err := d.CreateStoragePoolVolume(storageVolume.Pool, storageVolume.StorageVolumesPost)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to create storage volume '%s' on pool '%s'", storageVolume.Name, storageVolume.Pool)
+			}
+
+			// Setup reverter
+			reverts = append(reverts, func() {
+				d.DeleteStoragePoolVolume(storageVolume.Pool, "custom", storageVolume.Name)
+			})
+
+			return nil
+		}
+
+		// Storage volume updater
+		updateStorageVolume := func(storageVolume initDataStorageVolume) error {
+			// Get the current storage volume
+			currentStorageVolume, etag, err := d.GetStoragePoolVolume(storageVolume.Pool, "custom", storageVolume.Name)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to retrieve current storage volume '%s' on pool '%s'", storageVolume.Name, storageVolume.Pool)
+			}
+
+			// Setup reverter
+			reverts = append(reverts, func() {
+				d.UpdateStoragePoolVolume(storageVolume.Pool, "custom", currentStorageVolume.Name, currentStorageVolume.Writable(), "")
+			})
+
+			// Prepare the update
+			newStorageVolume := api.StorageVolumePut{}
+			err = shared.DeepCopy(currentStorageVolume.Writable(), &newStorageVolume)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to copy configuration of storage volume '%s' on pool '%s'", storageVolume.Name, storageVolume.Pool)
+			}
+
+			if newStorageVolume.Config == nil {
+				newStorageVolume.Config = map[string]string{}
+			}
+
+			// Description override
+			if storageVolume.Description != "" {
+				newStorageVolume.Description = storageVolume.Description
+			}
+
+			// Config overrides
+			for k, v := range storageVolume.Config {
+				newStorageVolume.Config[k] = fmt.Sprintf("%v", v)
+			}
+
+			// Apply it
+			err = d.UpdateStoragePoolVolume(storageVolume.Pool, "custom", currentStorageVolume.Name, newStorageVolume, etag)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to update storage volume '%s' on pool '%s'", storageVolume.Name, storageVolume.Pool)
+			}
+
+			return nil
+		}
+
+		// Get the list of storage volumes of each pool
+		storageVolumeNames := map[string][]string{}
+		for _, storageVolume := range config.StorageVolumes {
+			_, ok := storageVolumeNames[storageVolume.Pool]
+			if ok {
+				continue
+			}
+
+			names, err := d.GetStoragePoolVolumeNames(storageVolume.Pool)
+			if err != nil {
+				return revert, errors.Wrapf(err, "Failed to retrieve list of storage volumes of pool '%s'", storageVolume.Pool)
+			}
+
+			storageVolumeNames[storageVolume.Pool] = names
+		}
+
+		for _, storageVolume := range config.StorageVolumes {
+			// Only custom volumes can be defined.
+			storageVolume.Type = "custom"
+
+			// New storage volume
+			if !shared.StringInSlice(fmt.Sprintf("custom/%s", url.PathEscape(storageVolume.Name)), storageVolumeNames[storageVolume.Pool]) {
+				err := createStorageVolume(storageVolume)
+				if err != nil {
+					return revert, err
+				}
+
+				continue
+			}
+
+			// Existing storage volume
+			err := updateStorageVolume(storageVolume)
+			if err != nil {
+				return revert, err
+			}
+		}
+	}
+
 	// Apply profile configuration
 	if config.Profiles != nil && len(config.Profiles) > 0 {
 		// Get the list of profiles
@@ -333,7 +535,27 @@ func initDataNodeApply(d lxd.InstanceServer, config initDataNode) (func(), error
 		}
 	}
 
-	return nil, nil
+	// Apply the networks, storage volumes and profiles of the projects which have their own, now that the
+	// projects and storage pools exist.
+	for _, project := range config.Projects {
+		if len(project.Networks) == 0 && len(project.StorageVolumes) == 0 && len(project.Profiles) == 0 {
+			continue
+		}
+
+		projectConfig := initDataNode{
+			Networks:       project.Networks,
+			StorageVolumes: project.StorageVolumes,
+			Profiles:       project.Profiles,
+		}
+
+		projectRevert, err := initDataNodeApply(d.UseProject(project.Name), projectConfig)
+		reverts = append(reverts, projectRevert)
+		if err != nil {
+			return revert, errors.Wrapf(err, "Failed to apply configuration of project '%s'", project.Name)
+		}
+	}
+
+	return revert, nil
 }
 
 // Helper to initialize LXD clustering.
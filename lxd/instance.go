@@ -12,7 +12,6 @@ import (
 
 	"github.com/pkg/errors"
 	liblxc "gopkg.in/lxc/go-lxc.v2"
-	cron "gopkg.in/robfig/cron.v2"
 
 	"github.com/flosch/pongo2"
 	"github.com/lxc/lxd/lxd/cluster"
@@ -20,6 +19,7 @@ import (
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/revert"
@@ -97,29 +97,9 @@ func instanceCreateFromImage(d *Daemon, args db.InstanceArgs, hash string, op *o
 	}
 
 	// Check if the image is available locally or it's on another node.
-	nodeAddress, err := s.Cluster.LocateImage(hash)
+	err = ensureImageIsLocallyAvailable(d, args.Project, hash)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Locate image %s in the cluster", hash)
-	}
-	if nodeAddress != "" {
-		// The image is available from another node, let's try to import it.
-		logger.Debugf("Transferring image %s from node %s", hash, nodeAddress)
-		client, err := cluster.Connect(nodeAddress, d.endpoints.NetworkCert(), false)
-		if err != nil {
-			return nil, err
-		}
-
-		client = client.UseProject(args.Project)
-
-		err = imageImportFromNode(filepath.Join(d.os.VarDir, "images"), client, hash)
-		if err != nil {
-			return nil, err
-		}
-
-		err = d.cluster.AddImageToLocalNode(args.Project, hash)
-		if err != nil {
-			return nil, err
-		}
+		return nil, err
 	}
 
 	// Set the "image.*" keys.
@@ -172,6 +152,35 @@ func instanceCreateFromImage(d *Daemon, args db.InstanceArgs, hash string, op *o
 	return inst, nil
 }
 
+// ensureImageIsLocallyAvailable imports the image from another cluster member if it isn't available
+// on this one.
+func ensureImageIsLocallyAvailable(d *Daemon, projectName string, hash string) error {
+	nodeAddress, err := d.cluster.LocateImage(hash)
+	if err != nil {
+		return errors.Wrapf(err, "Locate image %s in the cluster", hash)
+	}
+
+	if nodeAddress == "" {
+		return nil
+	}
+
+	// The image is available from another node, let's try to import it.
+	logger.Debugf("Transferring image %s from node %s", hash, nodeAddress)
+	client, err := cluster.Connect(nodeAddress, d.endpoints.NetworkCert(), false)
+	if err != nil {
+		return err
+	}
+
+	client = client.UseProject(projectName)
+
+	err = imageImportFromNode(filepath.Join(d.os.VarDir, "images"), client, hash)
+	if err != nil {
+		return err
+	}
+
+	return d.cluster.AddImageToLocalNode(projectName, hash)
+}
+
 func instanceCreateAsCopy(s *state.State, args db.InstanceArgs, sourceInst instance.Instance, instanceOnly bool, refresh bool, op *operations.Operation) (instance.Instance, error) {
 	var inst, revertInst instance.Instance
 	var err error
@@ -422,11 +431,7 @@ func instanceCreateAsSnapshot(s *state.State, args db.InstanceArgs, sourceInstan
 		os.RemoveAll(sourceInstance.StatePath())
 	}
 
-	s.Events.SendLifecycle(sourceInstance.Project(), "container-snapshot-created",
-		fmt.Sprintf("/1.0/containers/%s", sourceInstance.Name()),
-		map[string]interface{}{
-			"snapshot_name": args.Name,
-		})
+	s.Events.SendLifecycle(sourceInstance.Project(), lifecycle.InstanceSnapshotCreated.Event(inst, op.Requestor(), nil))
 
 	revert.Success()
 	return inst, nil
@@ -476,7 +481,7 @@ func instanceCreateInternal(s *state.State, args db.InstanceArgs) (instance.Inst
 	}
 
 	// Validate container devices with the supplied container name and devices.
-	err = instance.ValidDevices(s, s.Cluster, args.Type, args.Devices, false)
+	err = instance.ValidDevices(s, s.Cluster, args.Project, args.Type, args.Devices, false)
 	if err != nil {
 		return nil, errors.Wrap(err, "Invalid devices")
 	}
@@ -714,29 +719,9 @@ func autoCreateContainerSnapshotsTask(d *Daemon) (task.Func, task.Schedule) {
 				continue
 			}
 
-			// Extend our schedule to one that is accepted by the used cron parser
-			sched, err := cron.Parse(fmt.Sprintf("* %s", schedule))
-			if err != nil {
-				continue
-			}
-
 			// Check if it's time to snapshot
-			now := time.Now()
-
-			// Truncate the time now back to the start of the minute, before passing to
-			// the cron scheduler, as it will add 1s to the scheduled time and we don't
-			// want the next scheduled time to roll over to the next minute and break
-			// the time comparison below.
-			now = now.Truncate(time.Minute)
-
-			// Calculate the next scheduled time based on the snapshots.schedule
-			// pattern and the time now.
-			next := sched.Next(now)
-
-			// Ignore everything that is more precise than minutes.
-			next = next.Truncate(time.Minute)
-
-			if !now.Equal(next) {
+			due, err := shared.ScheduleDue(schedule, fmt.Sprintf("%s/%s", c.Project(), c.Name()), time.Now())
+			if err != nil || !due {
 				continue
 			}
 
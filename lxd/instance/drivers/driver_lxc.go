@@ -37,6 +37,7 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/instance/operationlock"
+	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/maas"
 	"github.com/lxc/lxd/lxd/network"
 	"github.com/lxc/lxd/lxd/operations"
@@ -199,7 +200,7 @@ func lxcCreate(s *state.State, args db.InstanceArgs) (instance.Instance, error)
 		return nil, err
 	}
 
-	err = instance.ValidDevices(s, s.Cluster, c.Type(), c.expandedDevices, true)
+	err = instance.ValidDevices(s, s.Cluster, c.Project(), c.Type(), c.expandedDevices, true)
 	if err != nil {
 		c.Delete()
 		logger.Error("Failed creating container", ctxMap)
@@ -345,8 +346,11 @@ func lxcCreate(s *state.State, args db.InstanceArgs) (instance.Instance, error)
 	}
 
 	logger.Info("Created container", ctxMap)
-	c.state.Events.SendLifecycle(c.project, "container-created",
-		fmt.Sprintf("/1.0/containers/%s", c.name), nil)
+
+	// Snapshots get their own lifecycle event once their content is in place.
+	if !c.IsSnapshot() {
+		c.state.Events.SendLifecycle(c.project, lifecycle.InstanceCreated.Event(c, c.op.Requestor(), nil))
+	}
 
 	return c, nil
 }
@@ -1767,12 +1771,12 @@ func (c *lxc) deviceResetVolatile(devName string, oldConfig, newConfig deviceCon
 	volatileClear := make(map[string]string)
 	devicePrefix := fmt.Sprintf("volatile.%s.", devName)
 
-	newNICType, err := nictype.NICType(c.state, newConfig)
+	newNICType, err := nictype.NICType(c.state, c.Project(), newConfig)
 	if err != nil {
 		return err
 	}
 
-	oldNICType, err := nictype.NICType(c.state, oldConfig)
+	oldNICType, err := nictype.NICType(c.state, c.Project(), oldConfig)
 	if err != nil {
 		return err
 	}
@@ -2061,6 +2065,7 @@ func (c *lxc) startCommon() (string, []func() error, error) {
 
 	// Create the devices
 	nicID := -1
+	nvidiaDevices := []string{}
 
 	// Setup devices in sorted order, this ensures that device mounts are added in path order.
 	for _, d := range c.expandedDevices.Sorted() {
@@ -2200,12 +2205,27 @@ func (c *lxc) startCommon() (string, []func() error, error) {
 			}
 		}
 
+		// Collect the GPUs to be exposed by the NVIDIA container runtime.
+		for _, gpuItem := range runConf.GPUDevice {
+			if gpuItem.Key == "NVIDIA_VISIBLE_DEVICES" {
+				nvidiaDevices = append(nvidiaDevices, gpuItem.Value)
+			}
+		}
+
 		// Add any post start hooks.
 		if len(runConf.PostHooks) > 0 {
 			postStartHooks = append(postStartHooks, runConf.PostHooks...)
 		}
 	}
 
+	// Override the default of not exposing any GPU through the NVIDIA container runtime.
+	if len(nvidiaDevices) > 0 {
+		err = lxcSetConfigItem(c.c, "lxc.environment", fmt.Sprintf("NVIDIA_VISIBLE_DEVICES=%s", strings.Join(nvidiaDevices, ",")))
+		if err != nil {
+			return "", postStartHooks, errors.Wrapf(err, "Failed to setup NVIDIA visible devices")
+		}
+	}
+
 	// Rotate the log file
 	logfile := c.LogFilePath()
 	if shared.PathExists(logfile) {
@@ -2385,6 +2405,7 @@ func (c *lxc) Start(stateful bool) error {
 		}
 
 		logger.Info("Started container", ctxMap)
+		c.state.Events.SendLifecycle(c.project, lifecycle.InstanceStarted.Event(c, c.op.Requestor(), nil))
 		return nil
 	} else if c.stateful {
 		/* stateless start required when we have state, let's delete it */
@@ -2453,8 +2474,7 @@ func (c *lxc) Start(stateful bool) error {
 	}
 
 	logger.Info("Started container", ctxMap)
-	c.state.Events.SendLifecycle(c.project, "container-started",
-		fmt.Sprintf("/1.0/containers/%s", c.name), nil)
+	c.state.Events.SendLifecycle(c.project, lifecycle.InstanceStarted.Event(c, c.op.Requestor(), nil))
 
 	return nil
 }
@@ -2612,9 +2632,10 @@ func (c *lxc) Stop(stateful bool) error {
 			PreDumpDir:   "",
 		}
 
-		// Checkpoint
+		// Checkpoint, CRIU leaves the container running if it fails.
 		err = c.Migrate(&criuMigrationArgs)
 		if err != nil {
+			os.RemoveAll(stateDir)
 			op.Done(err)
 			logger.Error("Failed stopping container", ctxMap)
 			return err
@@ -2622,6 +2643,7 @@ func (c *lxc) Stop(stateful bool) error {
 
 		err = op.Wait()
 		if err != nil && c.IsRunning() {
+			os.RemoveAll(stateDir)
 			logger.Error("Failed stopping container", ctxMap)
 			return err
 		}
@@ -2636,8 +2658,7 @@ func (c *lxc) Stop(stateful bool) error {
 
 		op.Done(nil)
 		logger.Info("Stopped container", ctxMap)
-		c.state.Events.SendLifecycle(c.project, "container-stopped",
-			fmt.Sprintf("/1.0/containers/%s", c.name), nil)
+		c.state.Events.SendLifecycle(c.project, lifecycle.InstanceStopped.Event(c, c.op.Requestor(), nil))
 		return nil
 	} else if shared.PathExists(c.StatePath()) {
 		os.RemoveAll(c.StatePath())
@@ -2699,8 +2720,7 @@ func (c *lxc) Stop(stateful bool) error {
 	}
 
 	logger.Info("Stopped container", ctxMap)
-	c.state.Events.SendLifecycle(c.project, "container-stopped",
-		fmt.Sprintf("/1.0/containers/%s", c.name), nil)
+	c.state.Events.SendLifecycle(c.project, lifecycle.InstanceStopped.Event(c, c.op.Requestor(), nil))
 
 	return nil
 }
@@ -2761,8 +2781,7 @@ func (c *lxc) Shutdown(timeout time.Duration) error {
 	}
 
 	logger.Info("Shut down container", ctxMap)
-	c.state.Events.SendLifecycle(c.project, "container-shutdown",
-		fmt.Sprintf("/1.0/containers/%s", c.name), nil)
+	c.state.Events.SendLifecycle(c.project, lifecycle.InstanceShutdown.Event(c, c.op.Requestor(), nil))
 
 	return nil
 }
@@ -2854,6 +2873,14 @@ func (c *lxc) onStop(args map[string]string) error {
 		logger.Error("Failed to set container state", log.Ctx{"container": c.Name(), "err": err})
 	}
 
+	// Flag containers stopping on their own so that they get restarted.
+	if op == nil && target == "stop" && shared.IsTrue(c.expandedConfig["boot.autorestart"]) {
+		err = c.VolatileSet(map[string]string{"volatile.autorestart.pending": "true"})
+		if err != nil {
+			logger.Error("Failed to flag container for restart", log.Ctx{"container": c.Name(), "err": err})
+		}
+	}
+
 	go func(c *lxc, target string, op *operationlock.InstanceOperation) {
 		c.fromHook = false
 		err = nil
@@ -2887,8 +2914,7 @@ func (c *lxc) onStop(args map[string]string) error {
 		// Log and emit lifecycle if not user triggered
 		if op == nil {
 			logger.Info("Shut down container", ctxMap)
-			c.state.Events.SendLifecycle(c.project, "container-shutdown",
-				fmt.Sprintf("/1.0/containers/%s", c.name), nil)
+			c.state.Events.SendLifecycle(c.project, lifecycle.InstanceShutdown.Event(c, c.op.Requestor(), nil))
 		}
 
 		// Reboot the container
@@ -2971,8 +2997,7 @@ func (c *lxc) Freeze() error {
 	}
 
 	logger.Info("Froze container", ctxMap)
-	c.state.Events.SendLifecycle(c.project, "container-paused",
-		fmt.Sprintf("/1.0/containers/%s", c.name), nil)
+	c.state.Events.SendLifecycle(c.project, lifecycle.InstancePaused.Event(c, c.op.Requestor(), nil))
 
 	return err
 }
@@ -3022,8 +3047,7 @@ func (c *lxc) Unfreeze() error {
 	}
 
 	logger.Info("Unfroze container", ctxMap)
-	c.state.Events.SendLifecycle(c.project, "container-resumed",
-		fmt.Sprintf("/1.0/containers/%s", c.name), nil)
+	c.state.Events.SendLifecycle(c.project, lifecycle.InstanceResumed.Event(c, c.op.Requestor(), nil))
 
 	return err
 }
@@ -3431,10 +3455,9 @@ func (c *lxc) Restore(sourceContainer instance.Instance, stateful bool) error {
 		return nil
 	}
 
-	c.state.Events.SendLifecycle(c.project, "container-snapshot-restored",
-		fmt.Sprintf("/1.0/containers/%s", c.name), map[string]interface{}{
-			"snapshot_name": c.name,
-		})
+	c.state.Events.SendLifecycle(c.project, lifecycle.InstanceSnapshotRestored.Event(c, c.op.Requestor(), map[string]interface{}{
+		"snapshot_name": sourceContainer.Name(),
+	}))
 
 	// Restart the container.
 	if wasRunning {
@@ -3565,13 +3588,9 @@ func (c *lxc) Delete() error {
 	logger.Info("Deleted container", ctxMap)
 
 	if c.IsSnapshot() {
-		c.state.Events.SendLifecycle(c.project, "container-snapshot-deleted",
-			fmt.Sprintf("/1.0/containers/%s", c.name), map[string]interface{}{
-				"snapshot_name": c.name,
-			})
+		c.state.Events.SendLifecycle(c.project, lifecycle.InstanceSnapshotDeleted.Event(c, c.op.Requestor(), nil))
 	} else {
-		c.state.Events.SendLifecycle(c.project, "container-deleted",
-			fmt.Sprintf("/1.0/containers/%s", c.name), nil)
+		c.state.Events.SendLifecycle(c.project, lifecycle.InstanceDeleted.Event(c, c.op.Requestor(), nil))
 	}
 
 	return nil
@@ -3717,16 +3736,13 @@ func (c *lxc) Rename(newName string) error {
 	logger.Info("Renamed container", ctxMap)
 
 	if c.IsSnapshot() {
-		c.state.Events.SendLifecycle(c.project, "container-snapshot-renamed",
-			fmt.Sprintf("/1.0/containers/%s", oldName), map[string]interface{}{
-				"new_name":      newName,
-				"snapshot_name": oldName,
-			})
+		c.state.Events.SendLifecycle(c.project, lifecycle.InstanceSnapshotRenamed.Event(c, c.op.Requestor(), map[string]interface{}{
+			"old_name": oldName,
+		}))
 	} else {
-		c.state.Events.SendLifecycle(c.project, "container-renamed",
-			fmt.Sprintf("/1.0/containers/%s", oldName), map[string]interface{}{
-				"new_name": newName,
-			})
+		c.state.Events.SendLifecycle(c.project, lifecycle.InstanceRenamed.Event(c, c.op.Requestor(), map[string]interface{}{
+			"old_name": oldName,
+		}))
 	}
 
 	revert.Success()
@@ -3824,7 +3840,7 @@ func (c *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 		}
 
 		// Validate the new devices without using expanded devices validation (expensive checks disabled).
-		err = instance.ValidDevices(c.state, c.state.Cluster, c.Type(), args.Devices, false)
+		err = instance.ValidDevices(c.state, c.state.Cluster, c.Project(), c.Type(), args.Devices, false)
 		if err != nil {
 			return errors.Wrap(err, "Invalid devices")
 		}
@@ -3974,12 +3990,12 @@ func (c *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 		// devices are otherwise identical except for the fields returned here, then the
 		// device is considered to be being "updated" rather than "added & removed".
 
-		oldNICType, err := nictype.NICType(c.state, newDevice)
+		oldNICType, err := nictype.NICType(c.state, c.Project(), newDevice)
 		if err != nil {
 			return []string{} // Cannot hot-update due to config error.
 		}
 
-		newNICType, err := nictype.NICType(c.state, oldDevice)
+		newNICType, err := nictype.NICType(c.state, c.Project(), oldDevice)
 		if err != nil {
 			return []string{} // Cannot hot-update due to config error.
 		}
@@ -4005,7 +4021,7 @@ func (c *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 		}
 
 		// Do full expanded validation of the devices diff.
-		err = instance.ValidDevices(c.state, c.state.Cluster, c.Type(), c.expandedDevices, true)
+		err = instance.ValidDevices(c.state, c.state.Cluster, c.Project(), c.Type(), c.expandedDevices, true)
 		if err != nil {
 			return errors.Wrap(err, "Invalid expanded devices")
 		}
@@ -4498,16 +4514,7 @@ func (c *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 	// Success, update the closure to mark that the changes should be kept.
 	undoChanges = false
 
-	var endpoint string
-
-	if c.IsSnapshot() {
-		cName, sName, _ := shared.InstanceGetParentAndSnapshotName(c.name)
-		endpoint = fmt.Sprintf("/1.0/containers/%s/snapshots/%s", cName, sName)
-	} else {
-		endpoint = fmt.Sprintf("/1.0/containers/%s", c.name)
-	}
-
-	c.state.Events.SendLifecycle(c.project, "container-updated", endpoint, nil)
+	c.state.Events.SendLifecycle(c.project, lifecycle.InstanceUpdated.Event(c, c.op.Requestor(), nil))
 
 	return nil
 }
@@ -5095,6 +5102,25 @@ func (c *lxc) templateApplyNow(trigger string) error {
 		containerMeta["privileged"] = "false"
 	}
 
+	// Seed the static configuration of the routed and ipvlan NICs, unless a network configuration was
+	// supplied, as those don't get their addresses through DHCP.
+	config := c.expandedConfig
+	if config["user.network-config"] == "" {
+		networkConfig, err := device.NICNetworkConfig(c.expandedDevices)
+		if err != nil {
+			return errors.Wrap(err, "Failed to generate network configuration")
+		}
+
+		if networkConfig != "" {
+			config = make(map[string]string, len(c.expandedConfig)+1)
+			for k, v := range c.expandedConfig {
+				config[k] = v
+			}
+
+			config["user.network-config"] = networkConfig
+		}
+	}
+
 	// Go through the templates
 	for tplPath, tpl := range metadata.Templates {
 		var w *os.File
@@ -5155,7 +5181,7 @@ func (c *lxc) templateApplyNow(trigger string) error {
 		}
 
 		configGet := func(confKey, confDefault *pongo2.Value) *pongo2.Value {
-			val, ok := c.expandedConfig[confKey.String()]
+			val, ok := config[confKey.String()]
 			if !ok {
 				return confDefault
 			}
@@ -5168,7 +5194,7 @@ func (c *lxc) templateApplyNow(trigger string) error {
 			"path":       tplPath,
 			"container":  containerMeta,
 			"instance":   containerMeta,
-			"config":     c.expandedConfig,
+			"config":     config,
 			"devices":    c.expandedDevices,
 			"properties": tpl.Properties,
 			"config_get": configGet}, w)
@@ -5908,6 +5934,26 @@ func (c *lxc) networkState() map[string]api.InstanceStateNetwork {
 		}
 	}
 
+	// Report the rate limits of the NICs.
+	for name, m := range c.expandedDevices {
+		if m["type"] != "nic" {
+			continue
+		}
+
+		ifName := m["name"]
+		if ifName == "" {
+			ifName = name
+		}
+
+		dev, ok := result[ifName]
+		if !ok {
+			continue
+		}
+
+		dev.LimitsIngress, dev.LimitsEgress, _ = device.NetworkLimits(m)
+		result[ifName] = dev
+	}
+
 	return result
 }
 
@@ -6385,7 +6431,7 @@ func (c *lxc) FillNetworkDevice(name string, m deviceConfig.Device) (deviceConfi
 		return nil
 	}
 
-	nicType, err := nictype.NICType(c.state, m)
+	nicType, err := nictype.NICType(c.state, c.Project(), m)
 	if err != nil {
 		return nil, err
 	}
@@ -38,6 +38,7 @@ import (
 	"github.com/lxc/lxd/lxd/instance/drivers/qmp"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/instance/operationlock"
+	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/maas"
 	"github.com/lxc/lxd/lxd/network"
 	"github.com/lxc/lxd/lxd/operations"
@@ -67,6 +68,12 @@ const qemuUnsafeIO = "unsafeio"
 // qemuSerialChardevName is used to communicate state via qmp between Qemu and LXD.
 const qemuSerialChardevName = "qemu_serial-chardev"
 
+// qemuHotplugPorts is the number of empty PCIe root ports added to VMs so that NICs can be hotplugged.
+const qemuHotplugPorts = 4
+
+// qemuConsoleBufferSize is the amount of console output kept around for retrieval through the console log.
+const qemuConsoleBufferSize = 128 * 1024
+
 var errQemuAgentOffline = fmt.Errorf("LXD VM agent isn't currently running")
 
 var vmConsole = map[int]bool{}
@@ -219,7 +226,7 @@ func qemuCreate(s *state.State, args db.InstanceArgs) (instance.Instance, error)
 		return nil, err
 	}
 
-	err = instance.ValidDevices(s, s.Cluster, vm.Type(), vm.expandedDevices, true)
+	err = instance.ValidDevices(s, s.Cluster, vm.Project(), vm.Type(), vm.expandedDevices, true)
 	if err != nil {
 		logger.Error("Failed creating instance", ctxMap)
 		return nil, errors.Wrap(err, "Invalid devices")
@@ -279,8 +286,11 @@ func qemuCreate(s *state.State, args db.InstanceArgs) (instance.Instance, error)
 	}
 
 	logger.Info("Created instance", ctxMap)
-	vm.state.Events.SendLifecycle(vm.project, "virtual-machine-created",
-		fmt.Sprintf("/1.0/virtual-machines/%s", vm.name), nil)
+
+	// Snapshots get their own lifecycle event once their content is in place.
+	if !vm.IsSnapshot() {
+		vm.state.Events.SendLifecycle(vm.project, lifecycle.InstanceCreated.Event(vm, vm.op.Requestor(), nil))
+	}
 
 	revert = false
 	return vm, nil
@@ -507,6 +517,14 @@ func (vm *qemu) onStop(target string) error {
 		return err
 	}
 
+	// Flag instances stopping on their own so that they get restarted.
+	if op == nil && target == "stop" && shared.IsTrue(vm.expandedConfig["boot.autorestart"]) {
+		err = vm.VolatileSet(map[string]string{"volatile.autorestart.pending": "true"})
+		if err != nil {
+			logger.Errorf("Failed to flag instance '%s' for restart: %v", vm.Name(), err)
+		}
+	}
+
 	if target == "reboot" {
 		err = vm.Start(false)
 	} else if vm.ephemeral {
@@ -587,7 +605,7 @@ func (vm *qemu) Shutdown(timeout time.Duration) error {
 	}
 
 	op.Done(nil)
-	vm.state.Events.SendLifecycle(vm.project, "instance-shutdown", fmt.Sprintf("/1.0/virtual-machines/%s", vm.name), nil)
+	vm.state.Events.SendLifecycle(vm.project, lifecycle.InstanceShutdown.Event(vm, vm.op.Requestor(), nil))
 	return nil
 }
 
@@ -601,6 +619,12 @@ func (vm *qemu) ovmfPath() string {
 
 // Start starts the instance.
 func (vm *qemu) Start(stateful bool) error {
+	return vm.start(stateful, nil)
+}
+
+// start starts the instance. When incoming is set, QEMU waits for an incoming migration and
+// incoming is called to load the VM state before the emulation is started.
+func (vm *qemu) start(stateful bool, incoming func(monitor *qmp.Monitor) error) error {
 	// Ensure the correct vhost_vsock kernel module is loaded before establishing the vsock.
 	err := util.LoadModule("vhost_vsock")
 	if err != nil {
@@ -705,6 +729,13 @@ func (vm *qemu) Start(stateful bool) error {
 		return err
 	}
 
+	// Start each boot with an empty console log.
+	err = ioutil.WriteFile(vm.ConsoleBufferLogPath(), nil, 0600)
+	if err != nil {
+		op.Done(err)
+		return err
+	}
+
 	// Define a set of files to open and pass their file descriptors to qemu command.
 	fdFiles := make([]string, 0)
 
@@ -741,6 +772,10 @@ func (vm *qemu) Start(stateful bool) error {
 		"-chroot", vm.Path(),
 	}
 
+	if incoming != nil {
+		qemuCmd = append(qemuCmd, "-incoming", "defer")
+	}
+
 	// SMBIOS only on x86_64 and aarch64.
 	if shared.IntInSlice(vm.architecture, []int{osarch.ARCH_64BIT_INTEL_X86, osarch.ARCH_64BIT_ARMV8_LITTLE_ENDIAN}) {
 		qemuCmd = append(qemuCmd, "-smbios", "type=2,manufacturer=Canonical Ltd.,product=LXD")
@@ -926,6 +961,15 @@ func (vm *qemu) Start(stateful bool) error {
 		}
 	}
 
+	// Load the migrated state.
+	if incoming != nil {
+		err = incoming(monitor)
+		if err != nil {
+			op.Done(err)
+			return err
+		}
+	}
+
 	// Start the VM.
 	err = monitor.Start()
 	if err != nil {
@@ -958,8 +1002,11 @@ func (vm *qemu) Start(stateful bool) error {
 		return err
 	}
 
+	// Now that the VM is running, let devices subscribe to host device events.
+	vm.RegisterDevices()
+
 	revert.Success()
-	vm.state.Events.SendLifecycle(vm.project, "virtual-machine-started", fmt.Sprintf("/1.0/virtual-machines/%s", vm.name), nil)
+	vm.state.Events.SendLifecycle(vm.project, lifecycle.InstanceStarted.Event(vm, vm.op.Requestor(), nil))
 	return nil
 }
 
@@ -1054,9 +1101,27 @@ func (vm *qemu) deviceVolatileSetFunc(devName string) func(save map[string]strin
 	}
 }
 
-// RegisterDevices is not used by VMs.
+// RegisterDevices calls the Register() function on all of the instance's devices.
 func (vm *qemu) RegisterDevices() {
-	return
+	devices := vm.ExpandedDevices()
+	for _, dev := range devices.Sorted() {
+		d, _, err := vm.deviceLoad(dev.Name, dev.Config)
+		if err == device.ErrUnsupportedDevType {
+			continue
+		}
+
+		if err != nil {
+			logger.Error("Failed to load device to register", log.Ctx{"err": err, "instance": vm.Name(), "device": dev.Name})
+			continue
+		}
+
+		// Check whether device wants to register for any events.
+		err = d.Register()
+		if err != nil {
+			logger.Error("Failed to register device", log.Ctx{"err": err, "instance": vm.Name(), "device": dev.Name})
+			continue
+		}
+	}
 }
 
 // SaveConfigFile is not used by VMs.
@@ -1109,9 +1174,328 @@ func (vm *qemu) deviceStart(deviceName string, rawConfig deviceConfig.Device, is
 		return nil, err
 	}
 
+	// Hotplug the device into the running VM.
+	if isRunning && runConf != nil {
+		err = vm.deviceAttach(deviceName, runConf)
+		if err != nil {
+			d.Stop()
+			return nil, errors.Wrapf(err, "Failed to hotplug device %q", deviceName)
+		}
+
+		err = d.Register()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return runConf, nil
 }
 
+// deviceAttach hotplugs the disks and NICs of a device started while the VM is running. Directory shares and
+// Ceph disks can't be hotplugged and, like the other device types, only become available on the next VM start.
+func (vm *qemu) deviceAttach(deviceName string, runConf *deviceConfig.RunConfig) error {
+	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	for _, drive := range runConf.Mounts {
+		if drive.FSType == "9p" || drive.FSType == "virtiofs" || strings.HasPrefix(drive.DevPath, "rbd:") {
+			logger.Warn("Disk will be added to the virtual machine on its next start", log.Ctx{"project": vm.Project(), "instance": vm.Name(), "device": deviceName})
+			continue
+		}
+
+		err = vm.attachDrive(monitor, drive)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(runConf.NetworkInterface) > 0 {
+		err = vm.attachNetDev(monitor, runConf.NetworkInterface)
+		if err != nil {
+			return err
+		}
+	}
+
+	return vm.attachHostDevices(monitor, runConf)
+}
+
+// attachHostDevices hotplugs the GPUs and USB devices passed through from the host, or unplugs them when their
+// host path is empty.
+func (vm *qemu) attachHostDevices(monitor *qmp.Monitor, runConf *deviceConfig.RunConfig) error {
+	if len(runConf.GPUDevice) > 0 {
+		var devName, pciSlotName string
+		for _, gpuItem := range runConf.GPUDevice {
+			if gpuItem.Key == "devName" {
+				devName = gpuItem.Value
+			} else if gpuItem.Key == "pciSlotName" {
+				pciSlotName = gpuItem.Value
+			}
+		}
+
+		deviceID := fmt.Sprintf("dev-lxd_%s", devName)
+		if pciSlotName == "" {
+			err := monitor.RemoveDevice(deviceID)
+			if err != nil {
+				return err
+			}
+		} else {
+			_, busName, err := vm.qemuArchConfig()
+			if err != nil {
+				return err
+			}
+
+			device := map[string]interface{}{
+				"driver": "vfio-pci",
+				"id":     deviceID,
+				"host":   pciSlotName,
+			}
+
+			if busName == "pcie" {
+				port, err := monitor.GetFreePCIePort(busHotplugPortPrefix)
+				if err != nil {
+					return err
+				}
+
+				if port == "" {
+					return fmt.Errorf("No PCIe port left to hotplug the GPU into, restart the virtual machine")
+				}
+
+				device["bus"] = port
+				device["addr"] = "00.0"
+			} else if busName == "pci" {
+				device["bus"] = "pci.0"
+			} else if busName == "ccw" {
+				device["driver"] = "vfio-ccw"
+			}
+
+			err = monitor.AddDevice(device)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, usbDev := range runConf.USBDevice {
+		deviceID := qemuUSBDeviceID(usbDev)
+		if usbDev.HostDevicePath == "" {
+			err := monitor.RemoveDevice(deviceID)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err := monitor.AddDevice(map[string]interface{}{
+			"driver":   "usb-host",
+			"bus":      "qemu_usb.0",
+			"id":       deviceID,
+			"hostbus":  usbDev.BusNum,
+			"hostaddr": usbDev.DevNum,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attachDrive hotplugs a disk on the SCSI controller. Disks from the configuration file use the LUN 1 of the
+// SCSI target matching their boot index, so hotplugged disks use the first free LUN above it on target 0.
+func (vm *qemu) attachDrive(monitor *qmp.Monitor, driveConf deviceConfig.MountEntryItem) error {
+	aioMode, cacheMode, err := vm.driveIOModes(driveConf)
+	if err != nil {
+		return err
+	}
+
+	devices, err := monitor.GetDevices()
+	if err != nil {
+		return err
+	}
+
+	usedLUNs := map[int]bool{}
+	for devID, devType := range devices {
+		if devType != "scsi-hd" {
+			continue
+		}
+
+		lun, err := monitor.GetDeviceProperty(devID, "lun")
+		if err != nil {
+			return err
+		}
+
+		lunNum, ok := lun.(float64)
+		if ok {
+			usedLUNs[int(lunNum)] = true
+		}
+	}
+
+	lun := 2
+	for usedLUNs[lun] {
+		lun++
+	}
+
+	fileDriver := "file"
+	if shared.IsBlockdevPath(driveConf.DevPath) {
+		fileDriver = "host_device"
+	}
+
+	file := map[string]interface{}{
+		"driver":   fileDriver,
+		"filename": driveConf.DevPath,
+		"aio":      aioMode,
+	}
+
+	if driveConf.TargetPath != "/" {
+		file["locking"] = "off"
+	}
+
+	blockDev := map[string]interface{}{
+		"driver":    "raw",
+		"node-name": fmt.Sprintf("lxd_%s", driveConf.DevName),
+		"discard":   "unmap",
+		"cache": map[string]interface{}{
+			"direct":   cacheMode == "none",
+			"no-flush": cacheMode == "unsafe",
+		},
+		"file": file,
+	}
+
+	device := map[string]interface{}{
+		"driver":  "scsi-hd",
+		"bus":     "qemu_scsi.0",
+		"channel": 0,
+		"scsi-id": 0,
+		"lun":     lun,
+		"drive":   fmt.Sprintf("lxd_%s", driveConf.DevName),
+		"id":      fmt.Sprintf("dev-lxd_%s", driveConf.DevName),
+	}
+
+	return monitor.AddBlockDevice(blockDev, device)
+}
+
+// attachNetDev hotplugs a NIC. On PCIe machines, it uses one of the empty root ports added for that purpose.
+func (vm *qemu) attachNetDev(monitor *qmp.Monitor, nicConfig []deviceConfig.RunConfigItem) error {
+	var devName, nicName, devHwaddr, pciSlotName string
+	for _, nicItem := range nicConfig {
+		if nicItem.Key == "devName" {
+			devName = nicItem.Value
+		} else if nicItem.Key == "link" {
+			nicName = nicItem.Value
+		} else if nicItem.Key == "hwaddr" {
+			devHwaddr = nicItem.Value
+		} else if nicItem.Key == "pciSlotName" {
+			pciSlotName = nicItem.Value
+		}
+	}
+
+	_, busName, err := vm.qemuArchConfig()
+	if err != nil {
+		return err
+	}
+
+	device := map[string]interface{}{
+		"id": fmt.Sprintf("dev-lxd_%s", devName),
+	}
+
+	if busName == "pcie" {
+		port, err := monitor.GetFreePCIePort(busHotplugPortPrefix)
+		if err != nil {
+			return err
+		}
+
+		if port == "" {
+			return fmt.Errorf("No PCIe port left to hotplug the NIC into, restart the virtual machine")
+		}
+
+		device["bus"] = port
+		device["addr"] = "00.0"
+	} else if busName == "pci" {
+		device["bus"] = "pci.0"
+	}
+
+	var netDev map[string]interface{}
+	if shared.PathExists(fmt.Sprintf("/sys/class/net/%s/macvtap", nicName)) {
+		// The tap device file handle would need to be passed to the running QEMU process.
+		return fmt.Errorf("Hotplugging macvtap NICs isn't supported")
+	} else if shared.PathExists(fmt.Sprintf("/sys/class/net/%s/tun_flags", nicName)) {
+		netDev = map[string]interface{}{
+			"type":       "tap",
+			"id":         fmt.Sprintf("lxd_%s", devName),
+			"ifname":     nicName,
+			"vhost":      true,
+			"script":     "no",
+			"downscript": "no",
+		}
+
+		device["driver"] = "virtio-net-pci"
+		if busName == "ccw" {
+			device["driver"] = "virtio-net-ccw"
+		}
+
+		device["netdev"] = netDev["id"]
+		device["mac"] = devHwaddr
+	} else if pciSlotName != "" {
+		device["driver"] = "vfio-pci"
+		if busName == "ccw" {
+			device["driver"] = "vfio-ccw"
+		}
+
+		device["host"] = pciSlotName
+	} else {
+		return fmt.Errorf("Unrecognised device type")
+	}
+
+	return monitor.AddNIC(netDev, device)
+}
+
+// deviceDetach unplugs the disk or NIC of a device from the running VM before it gets stopped. Devices which
+// weren't hotplugged in the first place (such as directory shares) are left in place until the VM stops.
+func (vm *qemu) deviceDetach(deviceName string) error {
+	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	devices, err := monitor.GetDevices()
+	if err != nil {
+		return err
+	}
+
+	// Unplug the host USB devices passed through by the device.
+	for devID, devType := range devices {
+		if devType == "usb-host" && strings.HasPrefix(devID, fmt.Sprintf("dev-lxd_%s-", deviceName)) {
+			err = monitor.RemoveDevice(devID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	deviceID := fmt.Sprintf("dev-lxd_%s", deviceName)
+	devType := devices[deviceID]
+	if devType != "scsi-hd" && devType != "vfio-pci" && !strings.HasPrefix(devType, "virtio-net-") {
+		return nil
+	}
+
+	err = monitor.RemoveDevice(deviceID)
+	if err != nil {
+		return err
+	}
+
+	// Only hotplugged disks and tap NICs have a separate backend left to remove.
+	if devType == "scsi-hd" {
+		monitor.RemoveBlockDevice(fmt.Sprintf("lxd_%s", deviceName))
+	} else if devType != "vfio-pci" {
+		monitor.RemoveNIC(fmt.Sprintf("lxd_%s", deviceName))
+	}
+
+	return nil
+}
+
 // deviceStop loads a new device and calls its Stop() function.
 func (vm *qemu) deviceStop(deviceName string, rawConfig deviceConfig.Device) error {
 	logger := logging.AddContext(logger.Log, log.Ctx{"device": deviceName, "project": vm.Project(), "instance": vm.Name()})
@@ -1142,6 +1526,14 @@ func (vm *qemu) deviceStop(deviceName string, rawConfig deviceConfig.Device) err
 		return fmt.Errorf("Device cannot be stopped when instance is running")
 	}
 
+	// Unplug the device from the running VM.
+	if vm.IsRunning() {
+		err = vm.deviceDetach(deviceName)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to unplug device %q", deviceName)
+		}
+	}
+
 	runConf, err := d.Stop()
 	if err != nil {
 		return err
@@ -1585,14 +1977,25 @@ func (vm *qemu) generateQemuConfigFile(busName string, devConfs []*deviceConfig.
 	var sb *strings.Builder = &strings.Builder{}
 
 	err := qemuBase.Execute(sb, map[string]interface{}{
-		"architecture": vm.architectureName,
-		"spicePath":    vm.spicePath(),
+		"architecture":   vm.architectureName,
+		"spicePath":      vm.spicePath(),
+		"consoleLogPath": vm.ConsoleBufferLogPath(),
 	})
 	if err != nil {
 		return "", err
 	}
 
-	err = vm.addCPUMemoryConfig(sb)
+	// Directories shared through virtiofs need the memory of the VM to be shared with virtiofsd.
+	memoryShared := false
+	for _, runConf := range devConfs {
+		for _, drive := range runConf.Mounts {
+			if drive.FSType == "virtiofs" {
+				memoryShared = true
+			}
+		}
+	}
+
+	err = vm.addCPUMemoryConfig(sb, memoryShared)
 	if err != nil {
 		return "", err
 	}
@@ -1694,6 +2097,20 @@ func (vm *qemu) generateQemuConfigFile(busName string, devConfs []*deviceConfig.
 		return "", err
 	}
 
+	// The USB controller isn't available on the ccw bus.
+	if bus.name != "ccw" {
+		devBus, devAddr, multi = bus.allocate(busFunctionGroupGeneric)
+		err = qemuUSB.Execute(sb, map[string]interface{}{
+			"bus":           bus.name,
+			"devBus":        devBus,
+			"devAddr":       devAddr,
+			"multifunction": multi,
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
 	devBus, devAddr, multi = bus.allocate(busFunctionGroupNone)
 	err = qemuSCSI.Execute(sb, map[string]interface{}{
 		"bus":           bus.name,
@@ -1750,7 +2167,7 @@ func (vm *qemu) generateQemuConfigFile(busName string, devConfs []*deviceConfig.
 			for _, drive := range runConf.Mounts {
 				if drive.TargetPath == "/" {
 					err = vm.addRootDriveConfig(sb, bootIndexes, drive)
-				} else if drive.FSType == "9p" {
+				} else if drive.FSType == "9p" || drive.FSType == "virtiofs" {
 					err = vm.addDriveDirConfig(sb, bus, fdFiles, &agentMounts, drive)
 				} else {
 					err = vm.addDriveConfig(sb, bootIndexes, drive)
@@ -1776,6 +2193,24 @@ func (vm *qemu) generateQemuConfigFile(busName string, devConfs []*deviceConfig.
 				return "", err
 			}
 		}
+
+		// Add USB devices.
+		for _, usbDev := range runConf.USBDevice {
+			err = qemuUSBDev.Execute(sb, map[string]interface{}{
+				"devName":  usbDev.DeviceName,
+				"id":       qemuUSBDeviceID(usbDev),
+				"hostBus":  usbDev.BusNum,
+				"hostAddr": usbDev.DevNum,
+			})
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	// Add the empty ports devices can be hotplugged into.
+	for i := 0; i < qemuHotplugPorts; i++ {
+		bus.allocateHotplug()
 	}
 
 	// Write the agent mount config.
@@ -1795,8 +2230,14 @@ func (vm *qemu) generateQemuConfigFile(busName string, devConfs []*deviceConfig.
 	return configPath, ioutil.WriteFile(configPath, []byte(sb.String()), 0640)
 }
 
+// qemuUSBDeviceID returns the QEMU device ID of a host USB device passed to the VM.
+func qemuUSBDeviceID(usbDev deviceConfig.USBDeviceItem) string {
+	return fmt.Sprintf("dev-lxd_%s-%03d-%03d", usbDev.DeviceName, usbDev.BusNum, usbDev.DevNum)
+}
+
 // addCPUMemoryConfig adds the qemu config required for setting the number of virtualised CPUs and memory.
-func (vm *qemu) addCPUMemoryConfig(sb *strings.Builder) error {
+// The memory is shared with other processes when memoryShared is true, as required by vhost-user devices.
+func (vm *qemu) addCPUMemoryConfig(sb *strings.Builder, memoryShared bool) error {
 	// Default to a single core.
 	cpus := vm.expandedConfig["limits.cpu"]
 	if cpus == "" {
@@ -1880,6 +2321,7 @@ func (vm *qemu) addCPUMemoryConfig(sb *strings.Builder) error {
 		return fmt.Errorf("limits.memory invalid: %v", err)
 	}
 
+	ctx["memoryShared"] = memoryShared
 	ctx["hugepages"] = ""
 	if shared.IsTrue(vm.expandedConfig["limits.memory.hugepages"]) {
 		hugetlb, err := util.HugepagesPath()
@@ -1975,6 +2417,20 @@ func (vm *qemu) addDriveDirConfig(sb *strings.Builder, bus *qemuBus, fdFiles *[]
 
 	devBus, devAddr, multi := bus.allocate(busFunctionGroup9p)
 
+	// The virtiofs shares are served by virtiofsd over a vhost-user socket.
+	if driveConf.FSType == "virtiofs" {
+		return qemuDriveDirVirtiofs.Execute(sb, map[string]interface{}{
+			"bus":           bus.name,
+			"devBus":        devBus,
+			"devAddr":       devAddr,
+			"multifunction": multi,
+
+			"devName":  driveConf.DevName,
+			"mountTag": mountTag,
+			"path":     driveConf.DevPath,
+		})
+	}
+
 	// For read only shares, do not use proxy.
 	if shared.StringInSlice("ro", driveConf.Opts) {
 		return qemuDriveDir.Execute(sb, map[string]interface{}{
@@ -2007,6 +2463,23 @@ func (vm *qemu) addDriveDirConfig(sb *strings.Builder, bus *qemuBus, fdFiles *[]
 
 // addDriveConfig adds the qemu config required for adding a supplementary drive.
 func (vm *qemu) addDriveConfig(sb *strings.Builder, bootIndexes map[string]int, driveConf deviceConfig.MountEntryItem) error {
+	aioMode, cacheMode, err := vm.driveIOModes(driveConf)
+	if err != nil {
+		return err
+	}
+
+	return qemuDrive.Execute(sb, map[string]interface{}{
+		"devName":   driveConf.DevName,
+		"devPath":   driveConf.DevPath,
+		"bootIndex": bootIndexes[driveConf.DevName],
+		"cacheMode": cacheMode,
+		"aioMode":   aioMode,
+		"shared":    driveConf.TargetPath != "/" && !strings.HasPrefix(driveConf.DevPath, "rbd:"),
+	})
+}
+
+// driveIOModes returns the async IO and cache modes to use for a supplementary drive.
+func (vm *qemu) driveIOModes(driveConf deviceConfig.MountEntryItem) (string, string, error) {
 	// Use native kernel async IO and O_DIRECT by default.
 	aioMode := "native"
 	cacheMode := "none" // Bypass host cache, use O_DIRECT semantics.
@@ -2020,7 +2493,7 @@ func (vm *qemu) addDriveConfig(sb *strings.Builder, bootIndexes map[string]int,
 		// Disk dev path is a file, check whether it is located on a ZFS filesystem.
 		fsType, err := util.FilesystemDetect(driveConf.DevPath)
 		if err != nil {
-			return errors.Wrapf(err, "Failed detecting filesystem type of %q", driveConf.DevPath)
+			return "", "", errors.Wrapf(err, "Failed detecting filesystem type of %q", driveConf.DevPath)
 		}
 
 		// If FS is ZFS, avoid using direct I/O and use host page cache only.
@@ -2033,14 +2506,7 @@ func (vm *qemu) addDriveConfig(sb *strings.Builder, bootIndexes map[string]int,
 		}
 	}
 
-	return qemuDrive.Execute(sb, map[string]interface{}{
-		"devName":   driveConf.DevName,
-		"devPath":   driveConf.DevPath,
-		"bootIndex": bootIndexes[driveConf.DevName],
-		"cacheMode": cacheMode,
-		"aioMode":   aioMode,
-		"shared":    driveConf.TargetPath != "/" && !strings.HasPrefix(driveConf.DevPath, "rbd:"),
-	})
+	return aioMode, cacheMode, nil
 }
 
 // addNetDevConfig adds the qemu config required for adding a network device.
@@ -2266,7 +2732,7 @@ func (vm *qemu) Stop(stateful bool) error {
 		return err
 	}
 
-	vm.state.Events.SendLifecycle(vm.project, "virtual-machine-stopped", fmt.Sprintf("/1.0/virtual-machines/%s", vm.name), nil)
+	vm.state.Events.SendLifecycle(vm.project, lifecycle.InstanceStopped.Event(vm, vm.op.Requestor(), nil))
 	return nil
 }
 
@@ -2397,7 +2863,7 @@ func (vm *qemu) Restore(source instance.Instance, stateful bool) error {
 		return err
 	}
 
-	vm.state.Events.SendLifecycle(vm.project, "virtual-machine-snapshot-restored", fmt.Sprintf("/1.0/virtual-machines/%s", vm.name), map[string]interface{}{"snapshot_name": vm.name})
+	vm.state.Events.SendLifecycle(vm.project, lifecycle.InstanceSnapshotRestored.Event(vm, vm.op.Requestor(), map[string]interface{}{"snapshot_name": source.Name()}))
 
 	// Restart the insance.
 	if wasRunning {
@@ -2582,16 +3048,13 @@ func (vm *qemu) Rename(newName string) error {
 	logger.Info("Renamed instance", ctxMap)
 
 	if vm.IsSnapshot() {
-		vm.state.Events.SendLifecycle(vm.project, "virtual-machine-snapshot-renamed",
-			fmt.Sprintf("/1.0/virtual-machines/%s", oldName), map[string]interface{}{
-				"new_name":      newName,
-				"snapshot_name": oldName,
-			})
+		vm.state.Events.SendLifecycle(vm.project, lifecycle.InstanceSnapshotRenamed.Event(vm, vm.op.Requestor(), map[string]interface{}{
+			"old_name": oldName,
+		}))
 	} else {
-		vm.state.Events.SendLifecycle(vm.project, "virtual-machine-renamed",
-			fmt.Sprintf("/1.0/virtual-machines/%s", oldName), map[string]interface{}{
-				"new_name": newName,
-			})
+		vm.state.Events.SendLifecycle(vm.project, lifecycle.InstanceRenamed.Event(vm, vm.op.Requestor(), map[string]interface{}{
+			"old_name": oldName,
+		}))
 	}
 
 	revert.Success()
@@ -2719,9 +3182,7 @@ func (vm *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 			}
 		}
 
-		endpoint := fmt.Sprintf("/1.0/virtual-machines/%s", vm.name)
-
-		vm.state.Events.SendLifecycle(vm.project, "virtual-machine-updated", endpoint, nil)
+		vm.state.Events.SendLifecycle(vm.project, lifecycle.InstanceUpdated.Event(vm, vm.op.Requestor(), nil))
 
 		return nil
 	}
@@ -2755,7 +3216,7 @@ func (vm *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 		}
 
 		// Validate the new devices without using expanded devices validation (expensive checks disabled).
-		err = instance.ValidDevices(vm.state, vm.state.Cluster, vm.Type(), args.Devices, false)
+		err = instance.ValidDevices(vm.state, vm.state.Cluster, vm.Project(), vm.Type(), args.Devices, false)
 		if err != nil {
 			return errors.Wrap(err, "Invalid devices")
 		}
@@ -2897,12 +3358,12 @@ func (vm *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 		// between oldDevice and newDevice. The result of this is that as long as the
 		// devices are otherwise identical except for the fields returned here, then the
 		// device is considered to be being "updated" rather than "added & removed".
-		oldNICType, err := nictype.NICType(vm.state, newDevice)
+		oldNICType, err := nictype.NICType(vm.state, vm.Project(), newDevice)
 		if err != nil {
 			return []string{} // Cannot hot-update due to config error.
 		}
 
-		newNICType, err := nictype.NICType(vm.state, oldDevice)
+		newNICType, err := nictype.NICType(vm.state, vm.Project(), oldDevice)
 		if err != nil {
 			return []string{} // Cannot hot-update due to config error.
 		}
@@ -2928,7 +3389,7 @@ func (vm *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 		}
 
 		// Do full expanded validation of the devices diff.
-		err = instance.ValidDevices(vm.state, vm.state.Cluster, vm.Type(), vm.expandedDevices, true)
+		err = instance.ValidDevices(vm.state, vm.state.Cluster, vm.Project(), vm.Type(), vm.expandedDevices, true)
 		if err != nil {
 			return errors.Wrap(err, "Invalid expanded devices")
 		}
@@ -2998,16 +3459,7 @@ func (vm *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 	// Success, update the closure to mark that the changes should be kept.
 	undoChanges = false
 
-	var endpoint string
-
-	if vm.IsSnapshot() {
-		parentName, snapName, _ := shared.InstanceGetParentAndSnapshotName(vm.name)
-		endpoint = fmt.Sprintf("/1.0/virtual-machines/%s/snapshots/%s", parentName, snapName)
-	} else {
-		endpoint = fmt.Sprintf("/1.0/virtual-machines/%s", vm.name)
-	}
-
-	vm.state.Events.SendLifecycle(vm.project, "virtual-machine-updated", endpoint, nil)
+	vm.state.Events.SendLifecycle(vm.project, lifecycle.InstanceUpdated.Event(vm, vm.op.Requestor(), nil))
 	return nil
 }
 
@@ -3087,12 +3539,12 @@ func (vm *qemu) deviceResetVolatile(devName string, oldConfig, newConfig deviceC
 	volatileClear := make(map[string]string)
 	devicePrefix := fmt.Sprintf("volatile.%s.", devName)
 
-	newNICType, err := nictype.NICType(vm.state, newConfig)
+	newNICType, err := nictype.NICType(vm.state, vm.Project(), newConfig)
 	if err != nil {
 		return err
 	}
 
-	oldNICType, err := nictype.NICType(vm.state, oldConfig)
+	oldNICType, err := nictype.NICType(vm.state, vm.Project(), oldConfig)
 	if err != nil {
 		return err
 	}
@@ -3326,13 +3778,9 @@ func (vm *qemu) Delete() error {
 	logger.Info("Deleted instance", ctxMap)
 
 	if vm.IsSnapshot() {
-		vm.state.Events.SendLifecycle(vm.project, "virtual-machine-snapshot-deleted",
-			fmt.Sprintf("/1.0/virtual-machines/%s", vm.name), map[string]interface{}{
-				"snapshot_name": vm.name,
-			})
+		vm.state.Events.SendLifecycle(vm.project, lifecycle.InstanceSnapshotDeleted.Event(vm, vm.op.Requestor(), nil))
 	} else {
-		vm.state.Events.SendLifecycle(vm.project, "virtual-machine-deleted",
-			fmt.Sprintf("/1.0/virtual-machines/%s", vm.name), nil)
+		vm.state.Events.SendLifecycle(vm.project, lifecycle.InstanceDeleted.Event(vm, vm.op.Requestor(), nil))
 	}
 
 	return nil
@@ -3611,6 +4059,153 @@ func (vm *qemu) Migrate(args *instance.CriuMigrationArgs) error {
 	return instance.ErrNotImplemented
 }
 
+// MigrateSend streams the state of the running VM to conn, leaving it paused once the transfer is
+// complete. QEMU tracks the pages dirtied during the transfer and resends them until the remaining
+// state is small enough to be sent with the VM paused. When sharedStorage is false, the content of
+// the disks is sent along with the memory state.
+func (vm *qemu) MigrateSend(conn io.ReadWriteCloser, sharedStorage bool) error {
+	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	// QEMU connects to a local listener and we relay the stream to conn.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	// Throttle the vCPUs of busy VMs so that the dirty pages transfer converges.
+	err = monitor.MigrateSetCapabilities(map[string]bool{"auto-converge": true})
+	if err != nil {
+		return errors.Wrap(err, "Failed setting migration capabilities")
+	}
+
+	err = monitor.Migrate(fmt.Sprintf("tcp:%s", listener.Addr().String()), !sharedStorage)
+	if err != nil {
+		return errors.Wrap(err, "Failed starting migration")
+	}
+
+	qemuConn, err := listener.Accept()
+	if err != nil {
+		monitor.MigrateCancel()
+		return err
+	}
+	defer qemuConn.Close()
+
+	copyDone := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(conn, qemuConn)
+		copyDone <- err
+	}()
+
+	waitDone := make(chan error, 1)
+	go func() {
+		waitDone <- monitor.MigrateWait()
+	}()
+
+	select {
+	case err = <-waitDone:
+		if err != nil {
+			return err
+		}
+
+		err = <-copyDone
+	case err = <-copyDone:
+		if err != nil {
+			monitor.MigrateCancel()
+			<-waitDone
+		} else {
+			err = <-waitDone
+		}
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed sending migration stream")
+	}
+
+	return nil
+}
+
+// MigrateReceive starts the VM from the state streamed by MigrateSend on conn.
+func (vm *qemu) MigrateReceive(conn io.ReadWriteCloser) error {
+	return vm.start(false, func(monitor *qmp.Monitor) error {
+		// Pick a free local port for QEMU to listen on.
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return err
+		}
+
+		address := listener.Addr().String()
+		listener.Close()
+
+		err = monitor.MigrateIncoming(fmt.Sprintf("tcp:%s", address))
+		if err != nil {
+			return errors.Wrap(err, "Failed starting incoming migration")
+		}
+
+		var qemuConn net.Conn
+		for i := 0; i < 50; i++ {
+			qemuConn, err = net.Dial("tcp", address)
+			if err == nil {
+				break
+			}
+
+			time.Sleep(100 * time.Millisecond)
+		}
+		if err != nil {
+			return errors.Wrap(err, "Failed connecting to incoming migration")
+		}
+		defer qemuConn.Close()
+
+		copyDone := make(chan error, 1)
+		go func() {
+			_, err := io.Copy(qemuConn, conn)
+			copyDone <- err
+		}()
+
+		waitDone := make(chan error, 1)
+		go func() {
+			waitDone <- monitor.MigrateWait()
+		}()
+
+		select {
+		case err = <-waitDone:
+			return err
+		case err = <-copyDone:
+			if err != nil {
+				return errors.Wrap(err, "Failed receiving migration stream")
+			}
+
+			return <-waitDone
+		}
+	})
+}
+
+// MigrateAbort cancels an ongoing MigrateSend and resumes the VM.
+func (vm *qemu) MigrateAbort() error {
+	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	err = monitor.MigrateCancel()
+	if err != nil {
+		logger.Warn("Failed cancelling migration", log.Ctx{"instance": vm.name, "err": err})
+	}
+
+	status, err := monitor.Status()
+	if err != nil {
+		return err
+	}
+
+	if status == "running" {
+		return nil
+	}
+
+	return monitor.Start()
+}
+
 // CGroupSet is not implemented for VMs.
 func (vm *qemu) CGroupSet(key string, value string) error {
 	return instance.ErrNotImplemented
@@ -3655,9 +4250,30 @@ func (vm *qemu) VolatileSet(changes map[string]string) error {
 	return nil
 }
 
-// FileExists is not implemented for VMs.
+// FileExists returns whether file exists inside instance.
 func (vm *qemu) FileExists(path string) error {
-	return instance.ErrNotImplemented
+	client, err := vm.getAgentClient()
+	if err != nil {
+		return err
+	}
+
+	agent, err := lxdClient.ConnectLXDHTTP(nil, client)
+	if err != nil {
+		logger.Errorf("Failed to connect to lxd-agent on %s: %v", vm.Name(), err)
+		return fmt.Errorf("Failed to connect to lxd-agent")
+	}
+	defer agent.Disconnect()
+
+	content, _, err := agent.GetInstanceFile("", path)
+	if err != nil {
+		return err
+	}
+
+	if content != nil {
+		content.Close()
+	}
+
+	return nil
 }
 
 // FilePull retrieves a file from the instance.
@@ -3735,12 +4351,8 @@ func (vm *qemu) FilePush(fileType string, srcPath string, dstPath string, uid in
 
 		args.Content = f
 	} else if fileType == "symlink" {
-		symlinkTarget, err := os.Readlink(dstPath)
-		if err != nil {
-			return err
-		}
-
-		args.Content = bytes.NewReader([]byte(symlinkTarget))
+		// The source path holds the symlink target.
+		args.Content = bytes.NewReader([]byte(srcPath))
 	}
 
 	err = agent.CreateInstanceFile("", dstPath, args)
@@ -4057,7 +4669,7 @@ func (vm *qemu) RenderState() (*api.InstanceState, error) {
 			status.Processes = -1
 			networks := map[string]api.InstanceStateNetwork{}
 			for k, m := range vm.ExpandedDevices() {
-				nicType, err := nictype.NICType(vm.state, m)
+				nicType, err := nictype.NICType(vm.state, vm.Project(), m)
 				if err != nil {
 					return nil, err
 				}
@@ -4137,8 +4749,10 @@ func (vm *qemu) RenderState() (*api.InstanceState, error) {
 				if netStatus.Hwaddr == hwaddr {
 					if netStatus.HostName == "" {
 						netStatus.HostName = vm.localConfig[fmt.Sprintf("volatile.%s.host_name", k)]
-						status.Network[netName] = netStatus
 					}
+
+					netStatus.LimitsIngress, netStatus.LimitsEgress, _ = device.NetworkLimits(m)
+					status.Network[netName] = netStatus
 				}
 			}
 		}
@@ -4246,7 +4860,21 @@ func (vm *qemu) IsStateful() bool {
 
 // DeviceEventHandler handles events occurring on the instance's devices.
 func (vm *qemu) DeviceEventHandler(runConf *deviceConfig.RunConfig) error {
-	return fmt.Errorf("DeviceEventHandler Not implemented")
+	// Device events can only be processed when the VM is running.
+	if !vm.IsRunning() {
+		return nil
+	}
+
+	if runConf == nil {
+		return nil
+	}
+
+	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	return vm.attachHostDevices(monitor, runConf)
 }
 
 // ID returns the instance's ID.
@@ -4379,6 +5007,38 @@ func (vm *qemu) ConsoleBufferLogPath() string {
 	return filepath.Join(vm.LogPath(), "console.log")
 }
 
+// ConsoleLog returns the most recent output of the instance's console, up to qemuConsoleBufferSize bytes.
+func (vm *qemu) ConsoleLog() (string, error) {
+	f, err := os.Open(vm.ConsoleBufferLogPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+
+		return "", err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	if fi.Size() > qemuConsoleBufferSize {
+		_, err = f.Seek(fi.Size()-qemuConsoleBufferSize, io.SeekStart)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	buf, err := ioutil.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
 // RootfsPath returns the instance's rootfs path.
 func (vm *qemu) RootfsPath() string {
 	return filepath.Join(vm.Path(), "rootfs")
@@ -4455,7 +5115,7 @@ func (vm *qemu) FillNetworkDevice(name string, m deviceConfig.Device) (deviceCon
 		return nil
 	}
 
-	nicType, err := nictype.NICType(vm.state, m)
+	nicType, err := nictype.NICType(vm.state, vm.Project(), m)
 	if err != nil {
 		return nil, err
 	}
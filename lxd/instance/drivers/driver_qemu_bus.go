@@ -9,6 +9,8 @@ const busFunctionGroupNone = ""           // Add a non multi-function port.
 const busFunctionGroupGeneric = "generic" // Add multi-function port to generic group (used for internal devices).
 const busFunctionGroup9p = "9p"           // Add multi-function port to 9p group (used for 9p shares).
 
+const busHotplugPortPrefix = "qemu_pcie_hotplug" // Name prefix of the empty root ports used for hotplugging.
+
 type qemuBusEntry struct {
 	bridgeDev int // Device number on the root bridge.
 	bridgeFn  int // Function number on the root bridge.
@@ -133,6 +135,25 @@ func (a *qemuBus) allocate(multiFunctionGroup string) (string, string, bool) {
 	return "", "", false
 }
 
+// allocateHotplug adds an empty root port that a device can be hotplugged into while the VM is running.
+// Devices can be hotplugged directly on the other bus types, so nothing is done for them.
+func (a *qemuBus) allocateHotplug() {
+	if a.name != "pcie" {
+		return
+	}
+
+	r := a.allocateRoot()
+	qemuPCIe.Execute(a.sb, map[string]interface{}{
+		"index":   a.portNum,
+		"addr":    fmt.Sprintf("%x.%d", r.bridgeDev, r.bridgeFn),
+		"hotplug": true,
+
+		// First root port added on a bridge bus address needs multi-function enabled.
+		"multifunction": r.bridgeFn == 0,
+	})
+	a.portNum++
+}
+
 // qemuNewBus instantiates a new qemu bus allocator. Accepts the type name of the bus and the qemu config builder
 // which it will use to write root port config entries too as ports are allocated.
 func qemuNewBus(name string, sb *strings.Builder) *qemuBus {
@@ -44,6 +44,8 @@ strict = "on"
 # Console
 [chardev "console"]
 backend = "pty"
+logfile = "{{.consoleLogPath}}"
+logappend = "on"
 
 # Graphical console
 [spice]
@@ -108,7 +110,7 @@ bus = "dev-qemu_serial.0"
 `))
 
 var qemuPCIe = template.Must(template.New("qemuPCIe").Parse(`
-[device "qemu_pcie{{.index}}"]
+[device "{{if .hotplug}}qemu_pcie_hotplug{{else}}qemu_pcie{{end}}{{.index}}"]
 driver = "pcie-root-port"
 bus = "pcie.0"
 addr = "{{.addr}}"
@@ -134,6 +136,19 @@ multifunction = "on"
 {{- end }}
 `))
 
+var qemuUSB = template.Must(template.New("qemuUSB").Parse(`
+{{- if eq .bus "pci" "pcie"}}
+# USB controller
+[device "qemu_usb"]
+driver = "qemu-xhci"
+bus = "{{.devBus}}"
+addr = "{{.devAddr}}"
+{{if .multifunction -}}
+multifunction = "on"
+{{- end }}
+{{- end}}
+`))
+
 var qemuBalloon = template.Must(template.New("qemuBalloon").Parse(`
 # Balloon driver
 [device "qemu_balloon"]
@@ -251,6 +266,7 @@ threads = "{{.cpuThreads}}"
 {{if eq .architecture "x86_64" -}}
 {{$memory := .memory -}}
 {{$hugepages := .hugepages -}}
+{{$memoryShared := .memoryShared -}}
 {{if .cpuNumaHostNodes -}}
 {{range $index, $element := .cpuNumaHostNodes}}
 [object "mem{{$index}}"]
@@ -259,9 +275,14 @@ qom-type = "memory-backend-file"
 mem-path = "{{$hugepages}}"
 prealloc = "on"
 discard-data = "on"
+{{- else if $memoryShared}}
+qom-type = "memory-backend-memfd"
 {{- else}}
 qom-type = "memory-backend-ram"
 {{- end }}
+{{if $memoryShared -}}
+share = "on"
+{{end -}}
 size = "{{$memory}}M"
 host-nodes = "{{$element}}"
 policy = "bind"
@@ -278,9 +299,14 @@ qom-type = "memory-backend-file"
 mem-path = "{{$hugepages}}"
 prealloc = "on"
 discard-data = "on"
+{{- else if $memoryShared}}
+qom-type = "memory-backend-memfd"
 {{- else}}
 qom-type = "memory-backend-ram"
 {{- end }}
+{{if $memoryShared -}}
+share = "on"
+{{end -}}
 size = "{{$memory}}M"
 
 [numa]
@@ -388,6 +414,28 @@ multifunction = "on"
 {{- end }}
 `))
 
+var qemuDriveDirVirtiofs = template.Must(template.New("qemuDriveDirVirtiofs").Parse(`
+# {{.devName}} drive (virtiofs)
+[chardev "lxd_{{.devName}}"]
+backend = "socket"
+path = "{{.path}}"
+
+[device "dev-lxd_{{.devName}}"]
+{{- if eq .bus "pci" "pcie"}}
+driver = "vhost-user-fs-pci"
+bus = "{{.devBus}}"
+addr = "{{.devAddr}}"
+{{- end}}
+{{if eq .bus "ccw" -}}
+driver = "vhost-user-fs-ccw"
+{{- end}}
+chardev = "lxd_{{.devName}}"
+tag = "{{.mountTag}}"
+{{if .multifunction -}}
+multifunction = "on"
+{{- end }}
+`))
+
 // Devices use "lxd_" prefix indicating that this is a user named device.
 // The device name prefix must not be changed as we want to have /dev/disk/by-id be a usable stable identifier
 // inside the VM guest.
@@ -497,3 +545,13 @@ x-vga = "on"
 multifunction = "on"
 {{- end }}
 `))
+
+// Devices use "lxd_" prefix indicating that this is a user named device.
+var qemuUSBDev = template.Must(template.New("qemuUSBDev").Parse(`
+# USB host device ("{{.devName}}" device)
+[device "{{.id}}"]
+driver = "usb-host"
+bus = "qemu_usb.0"
+hostbus = "{{.hostBus}}"
+hostaddr = "{{.hostAddr}}"
+`))
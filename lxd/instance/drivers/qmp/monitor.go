@@ -322,3 +322,271 @@ func (m *Monitor) GetCPUs() ([]int, error) {
 
 	return pids, nil
 }
+
+// runWithArguments runs a QMP command with the provided arguments, returning QEMU's errors as is.
+func (m *Monitor) runWithArguments(cmd string, args interface{}) ([]byte, error) {
+	// Check if disconnected
+	if m.disconnected {
+		return nil, ErrMonitorDisconnect
+	}
+
+	req := map[string]interface{}{"execute": cmd}
+	if args != nil {
+		req["arguments"] = args
+	}
+
+	reqJSON, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.qmp.Run(reqJSON)
+}
+
+// MigrateSetCapabilities enables or disables migration capabilities.
+func (m *Monitor) MigrateSetCapabilities(capabilities map[string]bool) error {
+	caps := []map[string]interface{}{}
+	for name, state := range capabilities {
+		caps = append(caps, map[string]interface{}{"capability": name, "state": state})
+	}
+
+	_, err := m.runWithArguments("migrate-set-capabilities", map[string]interface{}{"capabilities": caps})
+	return err
+}
+
+// Migrate starts sending the VM state to the given URI. When blk is set, the content of the block
+// devices is sent along with the memory state.
+func (m *Monitor) Migrate(uri string, blk bool) error {
+	_, err := m.runWithArguments("migrate", map[string]interface{}{"uri": uri, "blk": blk})
+	return err
+}
+
+// MigrateIncoming starts listening for the VM state on the given URI.
+// QEMU must have been started with "-incoming defer".
+func (m *Monitor) MigrateIncoming(uri string) error {
+	_, err := m.runWithArguments("migrate-incoming", map[string]interface{}{"uri": uri})
+	return err
+}
+
+// MigrateCancel aborts an ongoing migration.
+func (m *Monitor) MigrateCancel() error {
+	_, err := m.runWithArguments("migrate_cancel", nil)
+	return err
+}
+
+// MigrateWait waits for the ongoing migration to complete.
+func (m *Monitor) MigrateWait() error {
+	for {
+		respRaw, err := m.runWithArguments("query-migrate", nil)
+		if err != nil {
+			return err
+		}
+
+		// Process the response.
+		var respDecoded struct {
+			Return struct {
+				Status    string `json:"status"`
+				ErrorDesc string `json:"error-desc"`
+			} `json:"return"`
+		}
+
+		err = json.Unmarshal(respRaw, &respDecoded)
+		if err != nil {
+			return ErrMonitorBadReturn
+		}
+
+		switch respDecoded.Return.Status {
+		case "completed":
+			return nil
+		case "failed":
+			return fmt.Errorf("Migration failed: %s", respDecoded.Return.ErrorDesc)
+		case "cancelled":
+			return fmt.Errorf("Migration cancelled")
+		}
+
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// AddBlockDevice adds a block node and attaches a device to it. The block node is removed again if the device
+// can't be added.
+func (m *Monitor) AddBlockDevice(blockDev map[string]interface{}, device map[string]interface{}) error {
+	_, err := m.runWithArguments("blockdev-add", blockDev)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.runWithArguments("device_add", device)
+	if err != nil {
+		m.RemoveBlockDevice(blockDev["node-name"].(string))
+		return err
+	}
+
+	return nil
+}
+
+// RemoveBlockDevice removes a block node which isn't attached to any device anymore.
+func (m *Monitor) RemoveBlockDevice(nodeName string) error {
+	_, err := m.runWithArguments("blockdev-del", map[string]interface{}{"node-name": nodeName})
+	return err
+}
+
+// AddNIC adds a network backend (when netDev isn't nil) and attaches a network device to it. The network backend
+// is removed again if the device can't be added.
+func (m *Monitor) AddNIC(netDev map[string]interface{}, device map[string]interface{}) error {
+	if netDev != nil {
+		_, err := m.runWithArguments("netdev_add", netDev)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := m.runWithArguments("device_add", device)
+	if err != nil {
+		if netDev != nil {
+			m.RemoveNIC(netDev["id"].(string))
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// AddDevice attaches a device which doesn't need any separate backend, such as a host PCI or USB device.
+func (m *Monitor) AddDevice(device map[string]interface{}) error {
+	_, err := m.runWithArguments("device_add", device)
+	return err
+}
+
+// RemoveNIC removes a network backend which isn't attached to any device anymore.
+func (m *Monitor) RemoveNIC(netDevID string) error {
+	_, err := m.runWithArguments("netdev_del", map[string]interface{}{"id": netDevID})
+	return err
+}
+
+// GetDevices returns the QEMU driver of each device added through the configuration file or hotplugged,
+// indexed by device ID.
+func (m *Monitor) GetDevices() (map[string]string, error) {
+	respRaw, err := m.runWithArguments("qom-list", map[string]interface{}{"path": "/machine/peripheral"})
+	if err != nil {
+		return nil, err
+	}
+
+	var respDecoded struct {
+		Return []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"return"`
+	}
+
+	err = json.Unmarshal(respRaw, &respDecoded)
+	if err != nil {
+		return nil, ErrMonitorBadReturn
+	}
+
+	// Devices show up as child properties of the peripheral container.
+	devices := map[string]string{}
+	for _, entry := range respDecoded.Return {
+		if strings.HasPrefix(entry.Type, "child<") {
+			devices[entry.Name] = strings.TrimSuffix(strings.TrimPrefix(entry.Type, "child<"), ">")
+		}
+	}
+
+	return devices, nil
+}
+
+// GetDeviceType returns the QEMU driver of a device, or an empty string if the device doesn't exist.
+func (m *Monitor) GetDeviceType(deviceID string) (string, error) {
+	devices, err := m.GetDevices()
+	if err != nil {
+		return "", err
+	}
+
+	return devices[deviceID], nil
+}
+
+// GetDeviceProperty returns the value of a property of a device.
+func (m *Monitor) GetDeviceProperty(deviceID string, property string) (interface{}, error) {
+	respRaw, err := m.runWithArguments("qom-get", map[string]interface{}{
+		"path":     fmt.Sprintf("/machine/peripheral/%s", deviceID),
+		"property": property,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var respDecoded struct {
+		Return interface{} `json:"return"`
+	}
+
+	err = json.Unmarshal(respRaw, &respDecoded)
+	if err != nil {
+		return nil, ErrMonitorBadReturn
+	}
+
+	return respDecoded.Return, nil
+}
+
+// RemoveDevice asks the guest to release a device and waits for QEMU to have removed it.
+func (m *Monitor) RemoveDevice(deviceID string) error {
+	_, err := m.runWithArguments("device_del", map[string]interface{}{"id": deviceID})
+	if err != nil {
+		return err
+	}
+
+	// The removal completes once the guest has acknowledged it.
+	for i := 0; i < 100; i++ {
+		devType, err := m.GetDeviceType(deviceID)
+		if err != nil {
+			return err
+		}
+
+		if devType == "" {
+			return nil
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return fmt.Errorf("Timed out waiting for the guest to release device %q", deviceID)
+}
+
+// GetFreePCIePort returns the first PCIe root port whose name has the given prefix and which doesn't have any
+// device attached, or an empty string if there are none left.
+func (m *Monitor) GetFreePCIePort(prefix string) (string, error) {
+	respRaw, err := m.runWithArguments("query-pci", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var respDecoded struct {
+		Return []struct {
+			Devices []struct {
+				QdevID    string `json:"qdev_id"`
+				PCIBridge *struct {
+					Devices []json.RawMessage `json:"devices"`
+				} `json:"pci_bridge"`
+			} `json:"devices"`
+		} `json:"return"`
+	}
+
+	err = json.Unmarshal(respRaw, &respDecoded)
+	if err != nil {
+		return "", ErrMonitorBadReturn
+	}
+
+	for _, bus := range respDecoded.Return {
+		for _, dev := range bus.Devices {
+			if !strings.HasPrefix(dev.QdevID, prefix) || dev.PCIBridge == nil {
+				continue
+			}
+
+			if len(dev.PCIBridge.Devices) == 0 {
+				return dev.QdevID, nil
+			}
+		}
+	}
+
+	return "", nil
+}
@@ -1,8 +1,8 @@
 package instance
 
 import (
-	"github.com/lxc/lxd/lxd/filter"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/filter"
 )
 
 // Filter returns a filtered list of instances that match the given clauses.
@@ -33,6 +33,7 @@ const (
 
 // ConfigReader is used to read instance config.
 type ConfigReader interface {
+	Project() string
 	Type() instancetype.Type
 	ExpandedConfig() map[string]string
 	ExpandedDevices() deviceConfig.Devices
@@ -149,6 +150,21 @@ type Container interface {
 	InsertSeccompUnixDevice(prefix string, m deviceConfig.Device, pid int) error
 }
 
+// VM interface is for VM specific functions.
+type VM interface {
+	Instance
+
+	// MigrateSend streams the state of the running VM to conn, leaving it paused once the
+	// transfer is complete. When sharedStorage is false, the disks are included in the stream.
+	MigrateSend(conn io.ReadWriteCloser, sharedStorage bool) error
+	// MigrateReceive starts the VM from the state streamed by MigrateSend on conn.
+	MigrateReceive(conn io.ReadWriteCloser) error
+	// MigrateAbort cancels an ongoing MigrateSend and resumes the VM.
+	MigrateAbort() error
+	// ConsoleLog returns the most recent output of the VM's console.
+	ConsoleLog() (string, error)
+}
+
 // CriuMigrationArgs arguments for CRIU migration.
 type CriuMigrationArgs struct {
 	Cmd          uint
@@ -31,7 +31,7 @@ import (
 )
 
 // ValidDevices is linked from instance/drivers.validDevices to validate device config.
-var ValidDevices func(state *state.State, cluster *db.Cluster, instanceType instancetype.Type, devices deviceConfig.Devices, expanded bool) error
+var ValidDevices func(state *state.State, cluster *db.Cluster, projectName string, instanceType instancetype.Type, devices deviceConfig.Devices, expanded bool) error
 
 // Load is linked from instance/drivers.load to allow different instance types to be loaded.
 var Load func(s *state.State, args db.InstanceArgs, profiles []api.Profile) (Instance, error)
@@ -782,6 +782,17 @@ func SuitableArchitectures(s *state.State, project string, req api.InstancesPost
 		return []int{inst.Architecture}, nil
 	}
 
+	// For backup, use the architecture of the instance the backup was taken from.
+	if req.Source.Type == "backup" {
+		instName, _, _ := shared.InstanceGetParentAndSnapshotName(req.Source.Source)
+		inst, err := fetchInstanceDatabaseObject(s, project, instName)
+		if err != nil {
+			return nil, err
+		}
+
+		return []int{inst.Architecture}, nil
+	}
+
 	// For image, things get a bit more complicated.
 	if req.Source.Type == "image" {
 		// Resolve the image.
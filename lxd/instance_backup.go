@@ -4,14 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 
+	"github.com/lxc/lxd/lxd/backup"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/response"
@@ -21,6 +24,49 @@ import (
 	"github.com/lxc/lxd/shared/version"
 )
 
+var backupsCmd = APIEndpoint{
+	Path: "backups",
+
+	Get: APIEndpointAction{Handler: backupsGet, AccessHandler: allowProjectPermission("containers", "view")},
+}
+
+// backupsGet lists the instance backups of a project across all cluster members.
+func backupsGet(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+	recursion := util.IsRecursionRequest(r)
+
+	names, err := d.cluster.GetProjectInstanceBackups(projectName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resultString := []string{}
+	resultMap := []*api.InstanceBackup{}
+
+	for _, name := range names {
+		instName, backupName, _ := shared.InstanceGetParentAndSnapshotName(name)
+
+		if !recursion {
+			url := fmt.Sprintf("/%s/instances/%s/backups/%s", version.APIVersion, instName, backupName)
+			resultString = append(resultString, url)
+			continue
+		}
+
+		backup, err := instance.BackupLoadByName(d.State(), projectName, name)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		resultMap = append(resultMap, backup.Render())
+	}
+
+	if !recursion {
+		return response.SyncResponse(true, resultString)
+	}
+
+	return response.SyncResponse(true, resultMap)
+}
+
 func containerBackupsGet(d *Daemon, r *http.Request) response.Response {
 	instanceType, err := urlInstanceTypeDetect(r)
 	if err != nil {
@@ -156,9 +202,74 @@ func containerBackupsPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(fmt.Errorf("Backup names may not contain slashes"))
 	}
 
+	// Validate the upload target.
+	if req.Target != nil {
+		err = backup.ValidateTarget(*req.Target)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
+	// Validate the snapshot selection.
+	for _, pattern := range req.Snapshots {
+		_, err = filepath.Match(pattern, "")
+		if err != nil {
+			return response.BadRequest(errors.Wrapf(err, "Invalid snapshot pattern %q", pattern))
+		}
+	}
+
+	if req.SnapshotsMaxAge != "" {
+		_, err = shared.GetSnapshotExpiry(time.Now(), req.SnapshotsMaxAge)
+		if err != nil {
+			return response.BadRequest(errors.Wrapf(err, "Invalid snapshots maximum age %q", req.SnapshotsMaxAge))
+		}
+	}
+
+	// Validate the encryption settings.
+	if req.Encryption != nil {
+		err = backup.ValidateEncryption(*req.Encryption)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
 	fullName := name + shared.SnapshotDelimiter + req.Name
 	instanceOnly := req.InstanceOnly || req.ContainerOnly
 
+	// Validate the export format.
+	if req.Format != "" {
+		if !shared.StringInSlice(req.Format, []string{"qcow2", "ova"}) {
+			return response.BadRequest(fmt.Errorf("Invalid backup format %q", req.Format))
+		}
+
+		if inst.Type() != instancetype.VM {
+			return response.BadRequest(fmt.Errorf("Only virtual machines can be exported as %s", req.Format))
+		}
+
+		if req.OptimizedStorage || req.IncrementalFrom != "" {
+			return response.BadRequest(fmt.Errorf("Backups exported as %s cannot be optimized or incremental", req.Format))
+		}
+
+		// Other hypervisors have no use for the LXD snapshots.
+		instanceOnly = true
+	}
+
+	// Validate the incremental base.
+	if req.IncrementalFrom != "" {
+		if !req.OptimizedStorage {
+			return response.BadRequest(fmt.Errorf("Incremental backups require optimized storage"))
+		}
+
+		if instanceOnly {
+			return response.BadRequest(fmt.Errorf("Incremental backups cannot be instance only"))
+		}
+
+		_, err = instance.LoadByProjectAndName(d.State(), project, name+shared.SnapshotDelimiter+req.IncrementalFrom)
+		if err != nil {
+			return response.BadRequest(errors.Wrapf(err, "Failed loading incremental base snapshot %q", req.IncrementalFrom))
+		}
+	}
+
 	backup := func(op *operations.Operation) error {
 		args := db.InstanceBackup{
 			Name:                 fullName,
@@ -170,7 +281,16 @@ func containerBackupsPost(d *Daemon, r *http.Request) response.Response {
 			CompressionAlgorithm: req.CompressionAlgorithm,
 		}
 
-		err := backupCreate(d.State(), args, inst)
+		opts := backupCreateOpts{
+			target:          req.Target,
+			incrementalFrom: req.IncrementalFrom,
+			encryption:      req.Encryption,
+			snapshots:       req.Snapshots,
+			snapshotsMaxAge: req.SnapshotsMaxAge,
+			format:          req.Format,
+		}
+
+		err := backupCreate(op.Context(), d.State(), args, inst, opts)
 		if err != nil {
 			return errors.Wrap(err, "Create backup")
 		}
@@ -181,7 +301,9 @@ func containerBackupsPost(d *Daemon, r *http.Request) response.Response {
 	resources := map[string][]string{}
 	resources["instances"] = []string{name}
 	resources["containers"] = resources["instances"]
-	resources["backups"] = []string{req.Name}
+	if req.Target == nil {
+		resources["backups"] = []string{req.Name}
+	}
 
 	op, err := operations.OperationCreate(d.State(), project, operations.OperationClassTask,
 		db.OperationBackupCreate, resources, nil, backup, nil, nil)
@@ -189,6 +311,8 @@ func containerBackupsPost(d *Daemon, r *http.Request) response.Response {
 		return response.InternalError(err)
 	}
 
+	op.SetCancelable()
+
 	return operations.OperationResponse(op)
 }
 
@@ -356,3 +480,56 @@ func containerBackupExportGet(d *Daemon, r *http.Request) response.Response {
 
 	return response.FileResponse(r, []response.FileResponseEntry{ent}, nil, false)
 }
+
+func containerBackupVerifyPost(d *Daemon, r *http.Request) response.Response {
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	proj := projectParam(r)
+	name := mux.Vars(r)["name"]
+	backupName := mux.Vars(r)["backupName"]
+
+	// Handle requests targeted to a container on a different node
+	resp, err := forwardedResponseIfInstanceIsRemote(d, r, proj, name, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+	if resp != nil {
+		return resp
+	}
+
+	fullName := name + shared.SnapshotDelimiter + backupName
+	b, err := instance.BackupLoadByName(d.State(), proj, fullName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	verify := func(op *operations.Operation) error {
+		result, err := backup.Verify(shared.VarPath("backups", project.Instance(proj, b.Name())))
+		if err != nil {
+			return errors.Wrapf(err, "Backup %q failed verification", backupName)
+		}
+
+		return op.UpdateMetadata(map[string]interface{}{
+			"sha256":    result.SHA256,
+			"format":    result.Format,
+			"entries":   result.Entries,
+			"optimized": result.Optimized,
+		})
+	}
+
+	resources := map[string][]string{}
+	resources["instances"] = []string{name}
+	resources["containers"] = resources["instances"]
+	resources["backups"] = []string{backupName}
+
+	op, err := operations.OperationCreate(d.State(), proj, operations.OperationClassTask,
+		db.OperationBackupVerify, resources, nil, verify, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
@@ -480,21 +480,34 @@ func containerConsoleLogGet(d *Daemon, r *http.Request) response.Response {
 		return resp
 	}
 
-	if !util.RuntimeLiblxcVersionAtLeast(3, 0, 0) {
-		return response.BadRequest(fmt.Errorf("Querying the console buffer requires liblxc >= 3.0"))
-	}
-
 	inst, err := instance.LoadByProjectAndName(d.State(), project, name)
 	if err != nil {
 		return response.SmartError(err)
 	}
 
+	ent := response.FileResponseEntry{}
+
+	if inst.Type() == instancetype.VM {
+		// The VM console output is kept in the log file, hand back its most recent part.
+		v := inst.(instance.VM)
+		logContents, err := v.ConsoleLog()
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		ent.Buffer = []byte(logContents)
+		return response.FileResponse(r, []response.FileResponseEntry{ent}, nil, false)
+	}
+
 	if inst.Type() != instancetype.Container {
 		return response.SmartError(fmt.Errorf("Instance is not container type"))
 	}
 
+	if !util.RuntimeLiblxcVersionAtLeast(3, 0, 0) {
+		return response.BadRequest(fmt.Errorf("Querying the console buffer requires liblxc >= 3.0"))
+	}
+
 	c := inst.(instance.Container)
-	ent := response.FileResponseEntry{}
 	if !c.IsRunning() {
 		// Hand back the contents of the console ringbuffer logfile.
 		consoleBufferLogPath := c.ConsoleBufferLogPath()
@@ -531,10 +544,6 @@ func containerConsoleLogGet(d *Daemon, r *http.Request) response.Response {
 }
 
 func containerConsoleLogDelete(d *Daemon, r *http.Request) response.Response {
-	if !util.RuntimeLiblxcVersionAtLeast(3, 0, 0) {
-		return response.BadRequest(fmt.Errorf("Clearing the console buffer requires liblxc >= 3.0"))
-	}
-
 	name := mux.Vars(r)["name"]
 	project := projectParam(r)
 
@@ -543,11 +552,13 @@ func containerConsoleLogDelete(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
-	if inst.Type() != instancetype.Container {
-		return response.SmartError(fmt.Errorf("Instance is not container type"))
+	if inst.Type() != instancetype.Container && inst.Type() != instancetype.VM {
+		return response.SmartError(fmt.Errorf("Unsupported instance type"))
 	}
 
-	c := inst.(instance.Container)
+	if inst.Type() == instancetype.Container && !util.RuntimeLiblxcVersionAtLeast(3, 0, 0) {
+		return response.BadRequest(fmt.Errorf("Clearing the console buffer requires liblxc >= 3.0"))
+	}
 
 	truncateConsoleLogFile := func(path string) error {
 		// Check that this is a regular file. We don't want to try and unlink
@@ -568,11 +579,14 @@ func containerConsoleLogDelete(d *Daemon, r *http.Request) response.Response {
 		return os.Truncate(path, 0)
 	}
 
-	if !inst.IsRunning() {
-		consoleLogpath := c.ConsoleBufferLogPath()
+	// QEMU appends to the console log file so it can be truncated while the VM is running.
+	if !inst.IsRunning() || inst.Type() == instancetype.VM {
+		consoleLogpath := inst.ConsoleBufferLogPath()
 		return response.SmartError(truncateConsoleLogFile(consoleLogpath))
 	}
 
+	c := inst.(instance.Container)
+
 	// Send a ringbuffer request to the container.
 	console := liblxc.ConsoleLogOptions{
 		ClearLog:       true,
@@ -38,6 +38,7 @@ func containerDelete(d *Daemon, r *http.Request) response.Response {
 	}
 
 	rmct := func(op *operations.Operation) error {
+		c.SetOperation(op)
 		return c.Delete()
 	}
 
@@ -49,5 +50,7 @@ func containerDelete(d *Daemon, r *http.Request) response.Response {
 		return response.InternalError(err)
 	}
 
+	op.SetRequestor(r)
+
 	return operations.OperationResponse(op)
 }
@@ -36,5 +36,5 @@ func containerGet(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
-	return response.SyncResponseETag(true, state, etag)
+	return response.SyncResponseETagConditional(r, state, etag)
 }
@@ -112,7 +112,10 @@ func instanceRefreshTypesTask(d *Daemon) (task.Func, task.Schedule) {
 func instanceRefreshTypes(ctx context.Context, d *Daemon) error {
 	// Attempt to download the new definitions
 	downloadParse := func(filename string, target interface{}) error {
-		url := fmt.Sprintf("https://images.linuxcontainers.org/meta/instance-types/%s", filename)
+		url, err := d.imageServerURL(fmt.Sprintf("https://images.linuxcontainers.org/meta/instance-types/%s", filename))
+		if err != nil {
+			return err
+		}
 
 		httpClient, err := util.HTTPClient("", d.proxy)
 		if err != nil {
@@ -165,6 +168,11 @@ func instanceRefreshTypes(ctx context.Context, d *Daemon) error {
 	// Get the list of instance type sources
 	sources := map[string]string{}
 	err := downloadParse(".yaml", &sources)
+	if err == errOffline {
+		logger.Debugf("Offline mode is enabled, not updating instance types")
+		return nil
+	}
+
 	if err != nil {
 		if err != ctx.Err() {
 			logger.Warnf("Failed to update instance types: %v", err)
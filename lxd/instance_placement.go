@@ -0,0 +1,166 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/scheduler"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/osarch"
+	"github.com/lxc/lxd/shared/units"
+)
+
+// instancePlacementTarget returns the name of the cluster member a new instance should be created on, according
+// to the configured placement policy. Only members supporting one of the given architectures and part of the
+// given cluster group (if any) are considered. An empty string is returned if there's no suitable member.
+func instancePlacementTarget(d *Daemon, project string, req *api.InstancesPost, architectures []int, group string) (string, error) {
+	var policy string
+	var scriptlet string
+	var nodes []db.NodeInfo
+	candidates := []scheduler.Candidate{}
+
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		config, err := cluster.ConfigLoad(tx)
+		if err != nil {
+			return errors.Wrap(err, "Failed to load cluster configuration")
+		}
+
+		policy, scriptlet = config.InstancePlacement()
+
+		nodes, err = tx.GetCandidateNodes(architectures, group)
+		if err != nil {
+			return err
+		}
+
+		for _, node := range nodes {
+			count, err := tx.GetNodeInstancesCount(node.ID)
+			if err != nil {
+				return err
+			}
+
+			architecture, _ := osarch.ArchitectureName(node.Architecture)
+			candidates = append(candidates, scheduler.Candidate{
+				Name:         node.Name,
+				Architecture: architecture,
+				Groups:       node.Groups,
+				Instances:    count,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(candidates) == 0 {
+		return "", nil
+	}
+
+	schedReq := scheduler.Request{
+		Project: project,
+		Name:    req.Name,
+		Type:    string(req.Type),
+	}
+
+	// The least-instances policy doesn't need the usage of the members.
+	if policy != scheduler.PolicyLeastInstances && len(candidates) > 1 {
+		schedReq.Memory, schedReq.Pool = instancePlacementRequirements(d, project, req)
+		instancePlacementResources(d, nodes, schedReq.Pool, candidates)
+	}
+
+	return scheduler.Select(policy, scriptlet, schedReq, candidates)
+}
+
+// instancePlacementRequirements returns the memory limit and the root disk storage pool of the instance being
+// created, taking its profiles into account.
+func instancePlacementRequirements(d *Daemon, project string, req *api.InstancesPost) (uint64, string) {
+	memory := ""
+	pool := ""
+
+	// Profiles are applied in order, so the last one wins.
+	for _, name := range req.Profiles {
+		_, profile, err := d.cluster.GetProfile(project, name)
+		if err != nil {
+			continue
+		}
+
+		if profile.Config["limits.memory"] != "" {
+			memory = profile.Config["limits.memory"]
+		}
+
+		_, device, _ := shared.GetRootDiskDevice(profile.Devices)
+		if device["pool"] != "" {
+			pool = device["pool"]
+		}
+	}
+
+	if req.Config["limits.memory"] != "" {
+		memory = req.Config["limits.memory"]
+	}
+
+	_, device, _ := shared.GetRootDiskDevice(req.Devices)
+	if device["pool"] != "" {
+		pool = device["pool"]
+	}
+
+	// Percentage based limits depend on the member and are ignored.
+	var limit uint64
+	if memory != "" && !strings.HasSuffix(memory, "%") {
+		value, err := units.ParseByteSizeString(memory)
+		if err == nil && value > 0 {
+			limit = uint64(value)
+		}
+	}
+
+	return limit, pool
+}
+
+// instancePlacementResources fills in the usage of the candidate members, querying them concurrently.
+// Members which can't be reached are left without resources.
+func instancePlacementResources(d *Daemon, nodes []db.NodeInfo, pool string, candidates []scheduler.Candidate) {
+	cert := d.endpoints.NetworkCert()
+
+	wg := sync.WaitGroup{}
+	for i := range nodes {
+		wg.Add(1)
+		go func(node db.NodeInfo, candidate *scheduler.Candidate) {
+			defer wg.Done()
+
+			client, err := cluster.Connect(node.Address, cert, true)
+			if err != nil {
+				logger.Warn("Failed to connect to cluster member for placement", log.Ctx{"member": node.Name, "err": err})
+				return
+			}
+
+			resources, err := client.GetServerResources()
+			if err != nil {
+				logger.Warn("Failed to get resources of cluster member for placement", log.Ctx{"member": node.Name, "err": err})
+				return
+			}
+
+			candidate.Resources = true
+			candidate.CPUs = resources.CPU.Total
+			candidate.Load = resources.Load.Average1
+			candidate.MemoryTotal = resources.Memory.Total
+			candidate.MemoryUsed = resources.Memory.Used
+
+			if pool != "" {
+				poolResources, err := client.GetStoragePoolResources(pool)
+				if err == nil {
+					candidate.PoolTotal = poolResources.Space.Total
+					candidate.PoolUsed = poolResources.Space.Used
+				}
+			}
+		}(nodes[i], &candidates[i])
+	}
+
+	wg.Wait()
+}
@@ -2,12 +2,15 @@ package main
 
 import (
 	"bytes"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
 
@@ -20,6 +23,7 @@ import (
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/revert"
 	driver "github.com/lxc/lxd/lxd/storage"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
@@ -32,6 +36,21 @@ var internalClusterContainerMovedCmd = APIEndpoint{
 	Post: APIEndpointAction{Handler: internalClusterContainerMovedPost},
 }
 
+var internalClusterInstanceMovedLiveCmd = APIEndpoint{
+	Path: "cluster/instance-moved-live/{name}",
+
+	Post: APIEndpointAction{Handler: internalClusterInstanceMovedLivePost},
+}
+
+// internalClusterInstanceMovedLive is sent to the target node of a live move, pointing it to the
+// migration source operation on the node currently running the instance.
+type internalClusterInstanceMovedLive struct {
+	Operation     string            `json:"operation" yaml:"operation"`
+	Certificate   string            `json:"certificate" yaml:"certificate"`
+	Websockets    map[string]string `json:"websockets" yaml:"websockets"`
+	SharedStorage bool              `json:"shared_storage" yaml:"shared_storage"`
+}
+
 func containerPost(d *Daemon, r *http.Request) response.Response {
 	instanceType, err := urlInstanceTypeDetect(r)
 	if err != nil {
@@ -175,6 +194,15 @@ func containerPost(d *Daemon, r *http.Request) response.Response {
 		if targetNode != "" {
 			// Check whether the container is running.
 			if !sourceNodeOffline && inst.IsRunning() {
+				// Running VMs can be moved live, keeping their name.
+				if inst.Type() == instancetype.VM && req.Live {
+					if req.Name != "" && req.Name != name {
+						return response.BadRequest(fmt.Errorf("Live moved instances can't be renamed"))
+					}
+
+					return instancePostClusteringMigrateLive(d, inst, targetNode)
+				}
+
 				return response.BadRequest(fmt.Errorf("Container is running"))
 			}
 
@@ -236,6 +264,8 @@ func containerPost(d *Daemon, r *http.Request) response.Response {
 				return response.InternalError(err)
 			}
 
+			op.SetCancelable()
+
 			return operations.OperationResponse(op)
 		}
 
@@ -245,6 +275,8 @@ func containerPost(d *Daemon, r *http.Request) response.Response {
 			return response.InternalError(err)
 		}
 
+		op.SetCancelable()
+
 		return operations.OperationResponse(op)
 	}
 
@@ -254,7 +286,8 @@ func containerPost(d *Daemon, r *http.Request) response.Response {
 		return response.Conflict(fmt.Errorf("Name '%s' already in use", req.Name))
 	}
 
-	run := func(*operations.Operation) error {
+	run := func(op *operations.Operation) error {
+		inst.SetOperation(op)
 		return inst.Rename(req.Name)
 	}
 
@@ -267,6 +300,8 @@ func containerPost(d *Daemon, r *http.Request) response.Response {
 		return response.InternalError(err)
 	}
 
+	op.SetRequestor(r)
+
 	return operations.OperationResponse(op)
 }
 
@@ -546,3 +581,254 @@ func containerPostCreateContainerMountPoint(d *Daemon, project, containerName st
 
 	return nil
 }
+
+// Live move a running VM to another cluster node.
+//
+// The instance keeps its database record, only its node is updated once the VM runs on the target
+// node. With shared storage (ceph) only the VM state is transferred, otherwise the target node also
+// receives the disks and the source node deletes its copy.
+func instancePostClusteringMigrateLive(d *Daemon, inst instance.Instance, newNode string) response.Response {
+	run := func(op *operations.Operation) error {
+		pool, err := driver.GetPoolByInstance(d.State(), inst)
+		if err != nil {
+			return errors.Wrap(err, "Failed to get instance's storage pool")
+		}
+
+		sharedStorage := pool.Driver().Info().Remote
+		if !sharedStorage {
+			snapshots, err := inst.Snapshots()
+			if err != nil {
+				return err
+			}
+
+			if len(snapshots) > 0 {
+				return fmt.Errorf("Live moving instances with snapshots requires shared storage")
+			}
+		}
+
+		var sourceAddress string
+		var targetAddress string
+		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			var err error
+
+			sourceAddress, err = tx.GetLocalNodeAddress()
+			if err != nil {
+				return errors.Wrap(err, "Failed to get local node address")
+			}
+
+			node, err := tx.GetNodeByName(newNode)
+			if err != nil {
+				return errors.Wrap(err, "Failed to get new node address")
+			}
+			targetAddress = node.Address
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Setup the migration source, the target node connects to it.
+		ws, err := newMigrationSource(inst, true, true)
+		if err != nil {
+			return err
+		}
+		ws.sharedStorage = sharedStorage
+
+		resources := map[string][]string{}
+		resources["instances"] = []string{inst.Name()}
+		resources["containers"] = resources["instances"]
+
+		sourceRun := func(op *operations.Operation) error {
+			return ws.Do(d.State(), op)
+		}
+
+		sourceCancel := func(op *operations.Operation) error {
+			ws.disconnect()
+			return nil
+		}
+
+		sourceOp, err := operations.OperationCreate(d.State(), inst.Project(), operations.OperationClassWebsocket, db.OperationContainerLiveMigrate, resources, ws.Metadata(), sourceRun, sourceCancel, ws.Connect)
+		if err != nil {
+			return err
+		}
+
+		sourceDone, err := sourceOp.Run()
+		if err != nil {
+			return err
+		}
+
+		websockets := map[string]string{}
+		for key, value := range ws.Metadata().(shared.Jmap) {
+			websockets[key] = value.(string)
+		}
+
+		cert := d.endpoints.NetworkCert()
+		client, err := cluster.Connect(targetAddress, cert, false)
+		if err != nil {
+			ws.disconnect()
+			return errors.Wrap(err, "Failed to connect to target node")
+		}
+
+		req := internalClusterInstanceMovedLive{
+			Operation:     fmt.Sprintf("https://%s%s", sourceAddress, sourceOp.URL()),
+			Certificate:   string(cert.PublicKey()),
+			Websockets:    websockets,
+			SharedStorage: sharedStorage,
+		}
+
+		targetOp, _, err := client.UseProject(inst.Project()).RawOperation("POST", fmt.Sprintf("/internal/cluster/instance-moved-live/%s", inst.Name()), req, "")
+		if err != nil {
+			ws.disconnect()
+			return errors.Wrap(err, "Failed to start live move on target node")
+		}
+
+		targetErr := targetOp.Wait()
+
+		// The source resumes the VM if the target failed and stops it otherwise.
+		err = <-sourceDone
+		if targetErr != nil {
+			return errors.Wrap(targetErr, "Failed live moving instance")
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "Failed live moving instance")
+		}
+
+		// Remove the local copy of the volume.
+		if !sharedStorage {
+			err = pool.DeleteInstance(inst, nil)
+			if err != nil {
+				logger.Errorf("Failed to delete live moved instance volume on source node: %v", err)
+			}
+		}
+
+		// Re-link the instance against the new node. Stopping the source VM recorded it as
+		// stopped, while it keeps running on the target node.
+		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			err := tx.UpdateInstanceNodeID(inst.Project(), inst.Name(), newNode)
+			if err != nil {
+				return err
+			}
+
+			return tx.UpdateInstancePowerState(inst.ID(), "RUNNING")
+		})
+		if err != nil {
+			return errors.Wrap(err, "Failed to relink instance database data")
+		}
+
+		return nil
+	}
+
+	resources := map[string][]string{}
+	resources["instances"] = []string{inst.Name()}
+	resources["containers"] = resources["instances"]
+
+	op, err := operations.OperationCreate(d.State(), inst.Project(), operations.OperationClassTask, db.OperationContainerLiveMigrate, resources, nil, run, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// Receive a VM being live moved from another cluster node.
+func internalClusterInstanceMovedLivePost(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+	name := mux.Vars(r)["name"]
+
+	req := internalClusterInstanceMovedLive{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if inst.Type() != instancetype.VM {
+		return response.BadRequest(fmt.Errorf("Only virtual machines can be live moved"))
+	}
+
+	certBlock, _ := pem.Decode([]byte(req.Certificate))
+	if certBlock == nil {
+		return response.BadRequest(fmt.Errorf("Invalid certificate"))
+	}
+
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	config, err := shared.GetTLSConfig("", "", "", cert)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	sink, err := newMigrationSink(&MigrationSinkArgs{
+		Url: req.Operation,
+		Dialer: websocket.Dialer{
+			TLSClientConfig: config,
+			NetDial:         shared.RFC3493Dialer},
+		Instance:      inst,
+		Secrets:       req.Websockets,
+		Live:          true,
+		InstanceOnly:  true,
+		SharedStorage: req.SharedStorage,
+	})
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	run := func(op *operations.Operation) error {
+		revert := revert.New()
+		defer revert.Fail()
+
+		// Record the instance volume on this node, the storage layer then receives it from the source.
+		if !req.SharedStorage {
+			pool, err := driver.GetPoolByInstance(d.State(), inst)
+			if err != nil {
+				return err
+			}
+
+			_, poolInfo, err := d.cluster.GetStoragePool(pool.Name())
+			if err != nil {
+				return err
+			}
+
+			volumeConfig := map[string]string{}
+			err = driver.VolumeFillDefault(volumeConfig, poolInfo)
+			if err != nil {
+				return err
+			}
+
+			_, err = d.cluster.CreateStoragePoolVolume(projectName, name, "", db.StoragePoolVolumeTypeVM, pool.ID(), volumeConfig, db.StoragePoolVolumeContentTypeBlock)
+			if err != nil {
+				return errors.Wrap(err, "Failed to create instance volume record")
+			}
+
+			revert.Add(func() { pool.DeleteInstance(inst, nil) })
+		}
+
+		err = sink.Do(d.State(), op)
+		if err != nil {
+			return errors.Wrap(err, "Error transferring instance state")
+		}
+
+		revert.Success()
+		return nil
+	}
+
+	resources := map[string][]string{}
+	resources["instances"] = []string{name}
+	resources["containers"] = resources["instances"]
+
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationContainerLiveMigrate, resources, nil, run, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
@@ -89,6 +89,7 @@ func containerPut(d *Daemon, r *http.Request) response.Response {
 				Project:      project,
 			}
 
+			c.SetOperation(op)
 			err = c.Update(args, true)
 			if err != nil {
 				return err
@@ -115,6 +116,8 @@ func containerPut(d *Daemon, r *http.Request) response.Response {
 		return response.InternalError(err)
 	}
 
+	op.SetRequestor(r)
+
 	return operations.OperationResponse(op)
 }
 
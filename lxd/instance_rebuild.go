@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/operations"
+	projecthelpers "github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// instanceRebuildPost rebuilds an instance from an image, replacing its root volume while keeping
+// its configuration, devices and profiles.
+func instanceRebuildPost(d *Daemon, r *http.Request) response.Response {
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	project := projectParam(r)
+	name := mux.Vars(r)["name"]
+
+	// Handle requests targeted to a container on a different node
+	resp, err := forwardedResponseIfInstanceIsRemote(d, r, project, name, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+	if resp != nil {
+		return resp
+	}
+
+	req := api.InstanceRebuildPost{}
+	err = shared.ReadToJSON(r.Body, &req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.Source.Type != "image" {
+		return response.BadRequest(fmt.Errorf("Instances can only be rebuilt from images"))
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), project, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if inst.IsRunning() && !req.Force {
+		return response.BadRequest(fmt.Errorf("The instance is running, stop it first or pass force"))
+	}
+
+	snapshots, err := inst.Snapshots()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if len(snapshots) > 0 {
+		return response.BadRequest(fmt.Errorf("Instances with snapshots can't be rebuilt"))
+	}
+
+	hash, err := instance.ResolveImage(d.State(), project, req.Source)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	run := func(op *operations.Operation) error {
+		var img *api.Image
+		if req.Source.Server != "" {
+			policy, err := imagePolicyGet(d.cluster, project)
+			if err != nil {
+				return err
+			}
+
+			var budget int64
+			err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+				budget, err = projecthelpers.GetImageSpaceBudget(tx, project)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			img, err = d.ImageDownload(
+				op, req.Source.Server, req.Source.Protocol, req.Source.Certificate,
+				req.Source.Secret, hash, inst.Type().String(), true, policy.autoUpdateCached, "", true, project, budget)
+			if err != nil {
+				return err
+			}
+		} else {
+			_, img, err = d.cluster.GetImage(project, hash, false)
+			if err != nil {
+				return err
+			}
+
+			err = ensureImageIsLocallyAvailable(d, project, img.Fingerprint)
+			if err != nil {
+				return err
+			}
+		}
+
+		return instanceRebuildFromImage(d, inst, img, req.Force, op)
+	}
+
+	resources := map[string][]string{}
+	resources["instances"] = []string{name}
+	resources["containers"] = resources["instances"]
+
+	op, err := operations.OperationCreate(d.State(), project, operations.OperationClassTask, db.OperationInstanceRebuild, resources, nil, run, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// instanceRebuildFromImage replaces the root volume of the instance with a fresh one created from the
+// image, restarting the instance if it was running.
+func instanceRebuildFromImage(d *Daemon, inst instance.Instance, img *api.Image, force bool, op *operations.Operation) error {
+	imgType, err := instancetype.New(img.Type)
+	if err != nil {
+		return err
+	}
+
+	if imgType != inst.Type() {
+		return fmt.Errorf("Requested image's type %q doesn't match instance type %q", imgType, inst.Type())
+	}
+
+	wasRunning := inst.IsRunning()
+	if wasRunning {
+		if !force {
+			return fmt.Errorf("The instance is running, stop it first or pass force")
+		}
+
+		err = inst.Stop(false)
+		if err != nil {
+			return err
+		}
+	}
+
+	pool, err := storagePools.GetPoolByInstance(d.State(), inst)
+	if err != nil {
+		return errors.Wrap(err, "Load instance storage pool")
+	}
+
+	// Keep the configuration of the root volume.
+	volType := db.StoragePoolVolumeTypeContainer
+	volTypeName := db.StoragePoolVolumeTypeNameContainer
+	if inst.Type() == instancetype.VM {
+		volType = db.StoragePoolVolumeTypeVM
+		volTypeName = db.StoragePoolVolumeTypeNameVM
+	}
+
+	_, vol, err := d.cluster.GetLocalStoragePoolVolume(inst.Project(), inst.Name(), volType, pool.ID())
+	if err != nil {
+		return errors.Wrap(err, "Load instance root volume")
+	}
+
+	err = pool.DeleteInstance(inst, op)
+	if err != nil {
+		return errors.Wrap(err, "Delete instance root volume")
+	}
+
+	err = storagePools.VolumeDBCreate(d.State(), inst.Project(), pool.Name(), inst.Name(), vol.Description, volTypeName, false, vol.Config, time.Time{}, string(storagePools.InstanceContentType(inst)))
+	if err != nil {
+		return errors.Wrap(err, "Recreate instance root volume record")
+	}
+
+	err = pool.CreateInstanceFromImage(inst, img.Fingerprint, op)
+	if err != nil {
+		return errors.Wrap(err, "Create instance root volume from image")
+	}
+
+	err = d.cluster.UpdateImageLastUseDate(img.Fingerprint, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("Error updating image last use date: %s", err)
+	}
+
+	// Replace the image keys with those of the new image.
+	config := map[string]string{}
+	for k, v := range inst.LocalConfig() {
+		if strings.HasPrefix(k, "image.") {
+			continue
+		}
+
+		config[k] = v
+	}
+
+	for k, v := range img.Properties {
+		config[fmt.Sprintf("image.%s", k)] = v
+	}
+
+	config["volatile.base_image"] = img.Fingerprint
+
+	// The new root filesystem is unshifted.
+	if inst.Type() == instancetype.Container {
+		config["volatile.last_state.idmap"] = "[]"
+	}
+
+	args := db.InstanceArgs{
+		Architecture: inst.Architecture(),
+		Config:       config,
+		Description:  inst.Description(),
+		Devices:      inst.LocalDevices(),
+		Ephemeral:    inst.IsEphemeral(),
+		Profiles:     inst.Profiles(),
+		Project:      inst.Project(),
+	}
+
+	err = inst.Update(args, false)
+	if err != nil {
+		return err
+	}
+
+	// Apply any post-storage configuration.
+	err = instanceConfigureInternal(d.State(), inst)
+	if err != nil {
+		return errors.Wrap(err, "Configure instance")
+	}
+
+	if wasRunning {
+		return inst.Start(false)
+	}
+
+	return nil
+}
@@ -85,10 +85,10 @@ func containerSnapshotsGet(d *Daemon, r *http.Request) response.Response {
 	}
 
 	if !recursion {
-		return response.SyncResponse(true, resultString)
+		return response.SyncResponseConditional(r, resultString)
 	}
 
-	return response.SyncResponse(true, resultMap)
+	return response.SyncResponseConditional(r, resultMap)
 }
 
 func containerSnapshotsPost(d *Daemon, r *http.Request) response.Response {
@@ -183,6 +183,8 @@ func containerSnapshotsPost(d *Daemon, r *http.Request) response.Response {
 		return response.InternalError(err)
 	}
 
+	op.SetRequestor(r)
+
 	return operations.OperationResponse(op)
 }
 
@@ -223,7 +225,7 @@ func containerSnapshotHandler(d *Daemon, r *http.Request) response.Response {
 	case "POST":
 		return snapshotPost(d, r, inst, containerName)
 	case "DELETE":
-		return snapshotDelete(d.State(), inst, snapshotName)
+		return snapshotDelete(d.State(), r, inst, snapshotName)
 	case "PUT":
 		return snapshotPut(d, r, inst, snapshotName)
 	default:
@@ -282,6 +284,7 @@ func snapshotPut(d *Daemon, r *http.Request, sc instance.Instance, name string)
 				Snapshot:     sc.IsSnapshot(),
 			}
 
+			sc.SetOperation(op)
 			err = sc.Update(args, false)
 			if err != nil {
 				return err
@@ -302,6 +305,8 @@ func snapshotPut(d *Daemon, r *http.Request, sc instance.Instance, name string)
 		return response.InternalError(err)
 	}
 
+	op.SetRequestor(r)
+
 	return operations.OperationResponse(op)
 }
 
@@ -414,6 +419,7 @@ func snapshotPost(d *Daemon, r *http.Request, sc instance.Instance, containerNam
 	}
 
 	rename := func(op *operations.Operation) error {
+		sc.SetOperation(op)
 		return sc.Rename(fullName)
 	}
 
@@ -425,11 +431,14 @@ func snapshotPost(d *Daemon, r *http.Request, sc instance.Instance, containerNam
 		return response.InternalError(err)
 	}
 
+	op.SetRequestor(r)
+
 	return operations.OperationResponse(op)
 }
 
-func snapshotDelete(s *state.State, sc instance.Instance, name string) response.Response {
+func snapshotDelete(s *state.State, r *http.Request, sc instance.Instance, name string) response.Response {
 	remove := func(op *operations.Operation) error {
+		sc.SetOperation(op)
 		return sc.Delete()
 	}
 
@@ -441,5 +450,7 @@ func snapshotDelete(s *state.State, sc instance.Instance, name string) response.
 		return response.InternalError(err)
 	}
 
+	op.SetRequestor(r)
+
 	return operations.OperationResponse(op)
 }
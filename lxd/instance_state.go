@@ -83,6 +83,28 @@ func containerStatePut(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	opType, do, err := instanceStateAction(d, c, raw)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	resources := map[string][]string{}
+	resources["containers"] = []string{name}
+
+	op, err := operations.OperationCreate(d.State(), project, operations.OperationClassTask, opType, resources, nil, do, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	op.SetRequestor(r)
+
+	return operations.OperationResponse(op)
+}
+
+// instanceStateAction returns the operation type and the function performing the state change
+// requested for the instance.
+func instanceStateAction(d *Daemon, c instance.Instance, raw api.InstanceStatePut) (db.OperationType, func(*operations.Operation) error, error) {
+	var err error
 	var opType db.OperationType
 	var do func(*operations.Operation) error
 	switch shared.InstanceAction(raw.Action) {
@@ -192,7 +214,7 @@ func containerStatePut(d *Daemon, r *http.Request) response.Response {
 		}
 	case shared.Freeze:
 		if !d.os.CGInfo.Supports(cgroup.Freezer, nil) {
-			return response.BadRequest(fmt.Errorf("This system doesn't support freezing instances"))
+			return db.OperationUnknown, nil, fmt.Errorf("This system doesn't support freezing instances")
 		}
 
 		opType = db.OperationContainerFreeze
@@ -202,7 +224,7 @@ func containerStatePut(d *Daemon, r *http.Request) response.Response {
 		}
 	case shared.Unfreeze:
 		if !d.os.CGInfo.Supports(cgroup.Freezer, nil) {
-			return response.BadRequest(fmt.Errorf("This system doesn't support unfreezing instances"))
+			return db.OperationUnknown, nil, fmt.Errorf("This system doesn't support unfreezing instances")
 		}
 
 		opType = db.OperationContainerUnfreeze
@@ -211,16 +233,8 @@ func containerStatePut(d *Daemon, r *http.Request) response.Response {
 			return c.Unfreeze()
 		}
 	default:
-		return response.BadRequest(fmt.Errorf("unknown action %s", raw.Action))
-	}
-
-	resources := map[string][]string{}
-	resources["containers"] = []string{name}
-
-	op, err := operations.OperationCreate(d.State(), project, operations.OperationClassTask, opType, resources, nil, do, nil, nil)
-	if err != nil {
-		return response.InternalError(err)
+		return db.OperationUnknown, nil, fmt.Errorf("unknown action %s", raw.Action)
 	}
 
-	return operations.OperationResponse(op)
+	return opType, do, nil
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"io/ioutil"
 	"sort"
 	"strconv"
@@ -8,11 +9,15 @@ import (
 	"sync"
 	"time"
 
+	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/logger"
 )
@@ -27,6 +32,7 @@ var instancesCmd = APIEndpoint{
 
 	Get:  APIEndpointAction{Handler: containersGet, AccessHandler: allowProjectPermission("containers", "view")},
 	Post: APIEndpointAction{Handler: containersPost, AccessHandler: allowProjectPermission("containers", "manage-containers")},
+	Put:  APIEndpointAction{Handler: instancesPut, AccessHandler: allowProjectPermission("containers", "operate-containers")},
 }
 
 var instanceCmd = APIEndpoint{
@@ -56,6 +62,17 @@ var instanceStateCmd = APIEndpoint{
 	Put: APIEndpointAction{Handler: containerStatePut, AccessHandler: allowProjectPermission("containers", "operate-containers")},
 }
 
+var instanceRebuildCmd = APIEndpoint{
+	Name: "instanceRebuild",
+	Path: "instances/{name}/rebuild",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerRebuild", Path: "containers/{name}/rebuild"},
+		{Name: "vmRebuild", Path: "virtual-machines/{name}/rebuild"},
+	},
+
+	Post: APIEndpointAction{Handler: instanceRebuildPost, AccessHandler: allowProjectPermission("containers", "manage-containers")},
+}
+
 var instanceFileCmd = APIEndpoint{
 	Name: "instanceFile",
 	Path: "instances/{name}/files",
@@ -181,6 +198,17 @@ var instanceBackupExportCmd = APIEndpoint{
 	Get: APIEndpointAction{Handler: containerBackupExportGet, AccessHandler: allowProjectPermission("containers", "view")},
 }
 
+var instanceBackupVerifyCmd = APIEndpoint{
+	Name: "instanceBackupVerify",
+	Path: "instances/{name}/backups/{backupName}/verify",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerBackupVerify", Path: "containers/{name}/backups/{backupName}/verify"},
+		{Name: "vmBackupVerify", Path: "virtual-machines/{name}/backups/{backupName}/verify"},
+	},
+
+	Post: APIEndpointAction{Handler: containerBackupVerifyPost, AccessHandler: allowProjectPermission("containers", "view")},
+}
+
 type containerAutostartList []instance.Instance
 
 func (slice containerAutostartList) Len() int {
@@ -219,29 +247,74 @@ func containersRestart(s *state.State) error {
 
 	sort.Sort(containerAutostartList(instances))
 
-	// Restart the instances
+	// Select the instances to start.
+	toStart := []instance.Instance{}
 	for _, c := range instances {
 		config := c.ExpandedConfig()
 		lastState := config["volatile.last_state.power"]
-
 		autoStart := config["boot.autostart"]
-		autoStartDelay := config["boot.autostart.delay"]
 
 		if shared.IsTrue(autoStart) || (autoStart == "" && lastState == "RUNNING") {
 			if c.IsRunning() {
 				continue
 			}
 
-			err = c.Start(false)
-			if err != nil {
-				logger.Errorf("Failed to start instance '%s': %v", c.Name(), err)
+			toStart = append(toStart, c)
+		}
+	}
+
+	// Bound the number of instances started concurrently.
+	parallelism, err := node.AutostartParallelism(s.Node)
+	if err != nil {
+		logger.Warnf("Failed to load the autostart parallelism, using the default: %v", err)
+		parallelism = 0
+	}
+
+	// Start the instances concurrently, one priority group at a time, waiting for the
+	// "boot.autostart.delay" of a group before starting the next one.
+	for len(toStart) > 0 {
+		priority, _ := strconv.Atoi(toStart[0].ExpandedConfig()["boot.autostart.priority"])
+
+		group := []instance.Instance{}
+		for len(toStart) > 0 {
+			nextPriority, _ := strconv.Atoi(toStart[0].ExpandedConfig()["boot.autostart.priority"])
+			if nextPriority != priority {
+				break
 			}
 
-			autoStartDelayInt, err := strconv.Atoi(autoStartDelay)
-			if err == nil {
-				time.Sleep(time.Duration(autoStartDelayInt) * time.Second)
+			group = append(group, toStart[0])
+			toStart = toStart[1:]
+		}
+
+		util.ParallelRun(len(group), int(parallelism), func(i int) {
+			// Restore the state saved on host shutdown, falling back to a regular start.
+			if group[i].IsStateful() {
+				err := group[i].Start(true)
+				if err == nil {
+					return
+				}
+
+				logger.Warnf("Failed to restore the state of instance '%s', starting it normally: %v", group[i].Name(), err)
+			}
+
+			err := group[i].Start(false)
+			if err != nil {
+				logger.Errorf("Failed to start instance '%s': %v", group[i].Name(), err)
+			}
+		})
+
+		// Wait for the longest delay of the group before starting the next one.
+		delay := 0
+		for _, c := range group {
+			autoStartDelayInt, err := strconv.Atoi(c.ExpandedConfig()["boot.autostart.delay"])
+			if err == nil && autoStartDelayInt > delay {
+				delay = autoStartDelayInt
 			}
 		}
+
+		if len(toStart) > 0 && delay > 0 {
+			time.Sleep(time.Duration(delay) * time.Second)
+		}
 	}
 
 	return nil
@@ -313,9 +386,21 @@ func containersOnDisk() (map[string][]string, error) {
 	return containers, nil
 }
 
-func instancesShutdown(s *state.State) error {
-	var wg sync.WaitGroup
+// shutdownTimeout returns how long the daemon waits for the operations and instances to stop when shutting down.
+func shutdownTimeout(s *state.State) time.Duration {
+	timeout, err := cluster.ConfigGetInt64(s.Cluster, "core.shutdown_timeout")
+	if err != nil {
+		return 5 * time.Minute
+	}
+
+	return time.Duration(timeout) * time.Minute
+}
 
+// instancesShutdown stops the local instances in "boot.stop.priority" order, waiting for each priority group to
+// be stopped before moving to the next one. Instances are given "boot.host_shutdown_timeout" seconds to shut down
+// cleanly, within the overall "core.shutdown_timeout", and are then forcefully stopped. The progress is tracked
+// in an operation when the database is available.
+func instancesShutdown(s *state.State) error {
 	dbAvailable := true
 
 	// Get all the instances
@@ -349,59 +434,142 @@ func instancesShutdown(s *state.State) error {
 
 	sort.Sort(containerStopList(instances))
 
+	deadline := time.Now().Add(5 * time.Minute)
 	if dbAvailable {
 		// Reset all instances states
 		err = s.Cluster.ResetInstancesPowerState()
 		if err != nil {
 			return err
 		}
+
+		deadline = time.Now().Add(shutdownTimeout(s))
 	}
 
-	var lastPriority int
+	shutdown := func(op *operations.Operation) error {
+		var wg sync.WaitGroup
+		var lastPriority int
+		var stopped int
+		var stoppedLock sync.Mutex
 
-	if len(instances) != 0 {
-		lastPriority, _ = strconv.Atoi(instances[0].ExpandedConfig()["boot.stop.priority"])
-	}
+		// Report the number of stopped instances and the priority being stopped.
+		progress := func(priority int) {
+			if op == nil {
+				return
+			}
 
-	for _, c := range instances {
-		priority, _ := strconv.Atoi(c.ExpandedConfig()["boot.stop.priority"])
+			stoppedLock.Lock()
+			metadata := map[string]interface{}{
+				"priority": priority,
+				"stopped":  stopped,
+				"total":    len(instances),
+			}
+			stoppedLock.Unlock()
 
-		// Enforce shutdown priority
-		if priority != lastPriority {
-			lastPriority = priority
+			op.UpdateMetadata(metadata)
+		}
 
-			// Wait for instances with higher priority to finish
-			wg.Wait()
+		if len(instances) != 0 {
+			lastPriority, _ = strconv.Atoi(instances[0].ExpandedConfig()["boot.stop.priority"])
+			progress(lastPriority)
 		}
 
-		// Record the current state
-		lastState := c.State()
+		for _, c := range instances {
+			priority, _ := strconv.Atoi(c.ExpandedConfig()["boot.stop.priority"])
 
-		// Stop the container
-		if lastState != "BROKEN" && lastState != "STOPPED" {
-			// Determinate how long to wait for the instance to shutdown cleanly
-			var timeoutSeconds int
-			value, ok := c.ExpandedConfig()["boot.host_shutdown_timeout"]
-			if ok {
-				timeoutSeconds, _ = strconv.Atoi(value)
-			} else {
-				timeoutSeconds = 30
+			// Enforce shutdown priority
+			if priority != lastPriority {
+				lastPriority = priority
+
+				// Wait for instances with higher priority to finish
+				wg.Wait()
+				progress(priority)
 			}
 
-			// Stop the instance
-			wg.Add(1)
-			go func(c instance.Instance, lastState string) {
-				c.Shutdown(time.Second * time.Duration(timeoutSeconds))
-				c.Stop(false)
+			// Record the current state
+			lastState := c.State()
+
+			// Stop the container
+			if lastState != "BROKEN" && lastState != "STOPPED" {
+				// Determinate how long to wait for the instance to shutdown cleanly
+				var timeoutSeconds int
+				value, ok := c.ExpandedConfig()["boot.host_shutdown_timeout"]
+				if ok {
+					timeoutSeconds, _ = strconv.Atoi(value)
+				} else {
+					timeoutSeconds = 30
+				}
+
+				// Don't wait past the overall shutdown timeout.
+				timeout := time.Second * time.Duration(timeoutSeconds)
+				remaining := time.Until(deadline)
+				if timeout > remaining {
+					timeout = remaining
+				}
+
+				// Stop the instance
+				wg.Add(1)
+				go func(c instance.Instance, lastState string) {
+					// Checkpoint the containers which want their state preserved, shutting them down otherwise.
+					stateful := c.Type() == instancetype.Container && shared.IsTrue(c.ExpandedConfig()["migration.stateful"])
+					if stateful {
+						err := c.Stop(true)
+						if err != nil {
+							logger.Warnf("Failed to checkpoint instance '%s', shutting it down: %v", c.Name(), err)
+							stateful = false
+						}
+					}
+
+					if !stateful {
+						if timeout > 0 {
+							c.Shutdown(timeout)
+						} else {
+							logger.Warnf("Shutdown timeout reached, stopping instance '%s'", c.Name())
+						}
+
+						c.Stop(false)
+					}
+
+					c.VolatileSet(map[string]string{"volatile.last_state.power": lastState})
+
+					stoppedLock.Lock()
+					stopped++
+					stoppedLock.Unlock()
+
+					progress(priority)
+
+					wg.Done()
+				}(c, lastState)
+			} else {
 				c.VolatileSet(map[string]string{"volatile.last_state.power": lastState})
+			}
+		}
+		wg.Wait()
+
+		return nil
+	}
 
-				wg.Done()
-			}(c, lastState)
-		} else {
-			c.VolatileSet(map[string]string{"volatile.last_state.power": lastState})
+	if dbAvailable {
+		resources := map[string][]string{}
+		for _, inst := range instances {
+			resources["instances"] = append(resources["instances"], inst.Name())
 		}
+		resources["containers"] = resources["instances"]
+
+		// The operation is created even though the daemon context is cancelled when shutting down.
+		opState := *s
+		opState.Context = context.Background()
+
+		op, err := operations.OperationCreate(&opState, "", operations.OperationClassTask, db.OperationInstancesShutdown, resources, nil, shutdown, nil, nil)
+		if err == nil {
+			var chErr chan error
+			chErr, err = op.Run()
+			if err == nil {
+				return <-chErr
+			}
+		}
+
+		logger.Warnf("Failed to track the instances shutdown in an operation: %v", err)
 	}
-	wg.Wait()
 
-	return nil
+	return shutdown(nil)
 }
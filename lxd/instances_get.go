@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sort"
@@ -15,12 +16,12 @@ import (
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/db/query"
-	"github.com/lxc/lxd/lxd/filter"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/filter"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/version"
 )
@@ -48,9 +49,9 @@ func containersGet(d *Daemon, r *http.Request) response.Response {
 	for i := 0; i < 100; i++ {
 		result, err := doContainersGet(d, r)
 		if err == nil {
-			return response.SyncResponse(true, result)
+			return response.SyncResponsePaginated(r, result)
 		}
-		if !query.IsRetriableError(err) {
+		if !query.IsRetriableError(err) || r.Context().Err() != nil {
 			logger.Debugf("DBERR: containersGet: error %q", err)
 			return response.SmartError(err)
 		}
@@ -99,7 +100,7 @@ func doContainersGet(d *Daemon, r *http.Request) (interface{}, error) {
 	// Get the list and location of all containers
 	var result map[string][]string // Containers by node address
 	var nodes map[string]string    // Node names by container
-	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+	err = d.cluster.TransactionContext(r.Context(), func(tx *db.ClusterTx) error {
 		var err error
 
 		result, err = tx.GetInstanceNamesByNodeAddress(project, instanceType)
@@ -193,7 +194,7 @@ func doContainersGet(d *Daemon, r *http.Request) (interface{}, error) {
 				cert := d.endpoints.NetworkCert()
 
 				if recursion == 1 {
-					cs, err := doContainersGetFromNode(project, address, cert, instanceType)
+					cs, err := doContainersGetFromNode(r.Context(), project, address, cert, instanceType)
 					if err != nil {
 						for _, name := range containers {
 							resultListAppend(name, api.Instance{}, err)
@@ -209,7 +210,7 @@ func doContainersGet(d *Daemon, r *http.Request) (interface{}, error) {
 					return
 				}
 
-				cs, err := doContainersFullGetFromNode(project, address, cert, instanceType)
+				cs, err := doContainersFullGetFromNode(r.Context(), project, address, cert, instanceType)
 				if err != nil {
 					for _, name := range containers {
 						resultFullListAppend(name, api.InstanceFull{}, err)
@@ -254,6 +255,17 @@ func doContainersGet(d *Daemon, r *http.Request) (interface{}, error) {
 							break
 						}
 
+						// Don't bother rendering if the client went away.
+						if r.Context().Err() != nil {
+							if recursion < 2 {
+								resultListAppend(container, api.Instance{}, r.Context().Err())
+							} else {
+								resultFullListAppend(container, api.InstanceFull{}, r.Context().Err())
+							}
+
+							continue
+						}
+
 						if recursion < 2 {
 							c, _, err := nodeCts[container].Render()
 							if err != nil {
@@ -325,8 +337,9 @@ func doContainersGet(d *Daemon, r *http.Request) (interface{}, error) {
 }
 
 // Fetch information about the containers on the given remote node, using the
-// rest API and with a timeout of 30 seconds.
-func doContainersGetFromNode(project, node string, cert *shared.CertInfo, instanceType instancetype.Type) ([]api.Instance, error) {
+// rest API and with a timeout of 30 seconds. Waiting is stopped early if the
+// given context is cancelled.
+func doContainersGetFromNode(ctx context.Context, project, node string, cert *shared.CertInfo, instanceType instancetype.Type) ([]api.Instance, error) {
 	f := func() ([]api.Instance, error) {
 		client, err := cluster.Connect(node, cert, true)
 		if err != nil {
@@ -351,19 +364,21 @@ func doContainersGetFromNode(project, node string, cert *shared.CertInfo, instan
 
 	go func() {
 		containers, err = f()
-		done <- struct{}{}
+		close(done)
 	}()
 
 	select {
 	case <-timeout:
 		err = fmt.Errorf("Timeout getting instances from node %s", node)
+	case <-ctx.Done():
+		err = ctx.Err()
 	case <-done:
 	}
 
 	return containers, err
 }
 
-func doContainersFullGetFromNode(project, node string, cert *shared.CertInfo, instanceType instancetype.Type) ([]api.InstanceFull, error) {
+func doContainersFullGetFromNode(ctx context.Context, project, node string, cert *shared.CertInfo, instanceType instancetype.Type) ([]api.InstanceFull, error) {
 	f := func() ([]api.InstanceFull, error) {
 		client, err := cluster.Connect(node, cert, true)
 		if err != nil {
@@ -388,12 +403,14 @@ func doContainersFullGetFromNode(project, node string, cert *shared.CertInfo, in
 
 	go func() {
 		instances, err = f()
-		done <- struct{}{}
+		close(done)
 	}()
 
 	select {
 	case <-timeout:
 		err = fmt.Errorf("Timeout getting instances from node %s", node)
+	case <-ctx.Done():
+		err = ctx.Err()
 	case <-done:
 	}
 
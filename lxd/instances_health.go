@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// instanceHealthTaskInterval is how often the instances are checked for restarts and health checks.
+const instanceHealthTaskInterval = 10 * time.Second
+
+// instanceRestartBackoffMin and instanceRestartBackoffMax bound the delay between two automatic restarts of an
+// instance, which doubles with each consecutive restart. The delay is reset once the instance stayed up for
+// instanceRestartBackoffReset.
+const instanceRestartBackoffMin = 10 * time.Second
+const instanceRestartBackoffMax = 5 * time.Minute
+const instanceRestartBackoffReset = 10 * time.Minute
+
+// instanceHealth holds the auto-restart and health-check state of an instance.
+type instanceHealth struct {
+	busy        bool      // Whether a health check or a restart is in progress.
+	failures    int       // Number of consecutive failed health checks.
+	lastCheck   time.Time // Time of the last health check.
+	restarts    int       // Number of consecutive automatic restarts.
+	lastRestart time.Time // Time of the last automatic restart.
+}
+
+// instancesHealth holds the state of the local instances, indexed by project and instance name.
+var instancesHealth = map[string]*instanceHealth{}
+var instancesHealthLock sync.Mutex
+
+// instanceRestartBackoff returns the delay to wait for before restarting an instance again after the given
+// number of consecutive restarts.
+func instanceRestartBackoff(restarts int) time.Duration {
+	if restarts <= 0 {
+		return 0
+	}
+
+	delay := instanceRestartBackoffMin
+	for i := 1; i < restarts && delay < instanceRestartBackoffMax; i++ {
+		delay *= 2
+	}
+
+	if delay > instanceRestartBackoffMax {
+		delay = instanceRestartBackoffMax
+	}
+
+	return delay
+}
+
+// instanceHealthCheckInterval returns the interval at which the health of the instance is checked, 0 if it has
+// no health check.
+func instanceHealthCheckInterval(inst instance.Instance) time.Duration {
+	config := inst.ExpandedConfig()
+	if config["boot.health_check.command"] == "" && config["boot.health_check.tcp"] == "" {
+		return 0
+	}
+
+	interval, err := strconv.Atoi(config["boot.health_check.interval"])
+	if err != nil || interval <= 0 {
+		interval = 30
+	}
+
+	return time.Duration(interval) * time.Second
+}
+
+// instanceHealthCheck runs the health checks of the instance, the command through exec (the agent for virtual
+// machines) and the TCP connection to the given port of the instance.
+func instanceHealthCheck(inst instance.Instance, timeout time.Duration) error {
+	config := inst.ExpandedConfig()
+
+	command := config["boot.health_check.command"]
+	if command != "" {
+		devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+		if err != nil {
+			return err
+		}
+		defer devNull.Close()
+
+		cmd, err := inst.Exec(api.InstanceExecPost{Command: []string{"sh", "-c", command}}, devNull, devNull, devNull)
+		if err != nil {
+			return fmt.Errorf("Failed to run health check command: %v", err)
+		}
+
+		chExit := make(chan error, 1)
+		go func() {
+			exitStatus, err := cmd.Wait()
+			if err == nil && exitStatus != 0 {
+				err = fmt.Errorf("Health check command exited with status %d", exitStatus)
+			}
+
+			chExit <- err
+		}()
+
+		select {
+		case err := <-chExit:
+			if err != nil {
+				return err
+			}
+		case <-time.After(timeout):
+			cmd.Signal(unix.SIGKILL)
+			return fmt.Errorf("Health check command timed out")
+		}
+	}
+
+	address := config["boot.health_check.tcp"]
+	if address != "" {
+		_, _, err := net.SplitHostPort(address)
+		if err != nil {
+			// Connect to the given port on a global address of the instance.
+			instState, err := inst.RenderState()
+			if err != nil {
+				return fmt.Errorf("Failed to get instance addresses: %v", err)
+			}
+
+			host := ""
+			for name, network := range instState.Network {
+				if name == "lo" || host != "" {
+					continue
+				}
+
+				for _, addr := range network.Addresses {
+					if addr.Scope == "global" {
+						host = addr.Address
+						break
+					}
+				}
+			}
+
+			if host == "" {
+				return fmt.Errorf("Instance has no global address")
+			}
+
+			address = net.JoinHostPort(host, address)
+		}
+
+		conn, err := net.DialTimeout("tcp", address, timeout)
+		if err != nil {
+			return fmt.Errorf("Health check connection failed: %v", err)
+		}
+
+		conn.Close()
+	}
+
+	return nil
+}
+
+// instanceAutoRestart (re)starts the instance, shutting it down first if it's running, and emits a lifecycle
+// event for the restart.
+func instanceAutoRestart(s *state.State, inst instance.Instance, reason string, restarts int) {
+	logger.Warn("Restarting instance", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "reason": reason, "restarts": restarts})
+
+	if inst.IsRunning() {
+		timeoutSeconds := 30
+		value, ok := inst.ExpandedConfig()["boot.host_shutdown_timeout"]
+		if ok {
+			timeoutSeconds, _ = strconv.Atoi(value)
+		}
+
+		err := inst.Shutdown(time.Duration(timeoutSeconds) * time.Second)
+		if err != nil {
+			err = inst.Stop(false)
+			if err != nil {
+				logger.Error("Failed to stop instance for restart", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+				return
+			}
+		}
+	}
+
+	err := inst.Start(false)
+	if err != nil {
+		logger.Error("Failed to restart instance", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+	}
+
+	err = inst.VolatileSet(map[string]string{"volatile.autorestart.pending": ""})
+	if err != nil {
+		logger.Warn("Failed to clear instance restart flag", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+	}
+
+	s.Events.SendLifecycle(inst.Project(), lifecycle.InstanceRestarted.Event(inst, nil, map[string]interface{}{
+		"reason":   reason,
+		"restarts": restarts,
+	}))
+}
+
+// instancesHealthTask restarts the local instances with "boot.autorestart" set which stopped on their own or
+// failed "boot.health_check.failures" consecutive health checks, backing off exponentially on repeated restarts.
+func instancesHealthTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		s := d.State()
+
+		insts, err := instance.LoadNodeAll(s, instancetype.Any)
+		if err != nil {
+			logger.Error("Failed to load instances for health checks", log.Ctx{"err": err})
+			return
+		}
+
+		instancesHealthLock.Lock()
+		defer instancesHealthLock.Unlock()
+
+		now := time.Now()
+		seen := map[string]bool{}
+		for _, inst := range insts {
+			if inst.IsSnapshot() {
+				continue
+			}
+
+			key := project.Instance(inst.Project(), inst.Name())
+			seen[key] = true
+
+			h, ok := instancesHealth[key]
+			if !ok {
+				h = &instanceHealth{}
+				instancesHealth[key] = h
+			}
+
+			if h.busy {
+				continue
+			}
+
+			autoRestart := shared.IsTrue(inst.ExpandedConfig()["boot.autorestart"])
+			pending := shared.IsTrue(inst.LocalConfig()["volatile.autorestart.pending"])
+
+			// Reset the backoff of instances which stayed up long enough.
+			if h.restarts > 0 && now.Sub(h.lastRestart) > instanceRestartBackoffReset {
+				h.restarts = 0
+			}
+
+			if !inst.IsRunning() {
+				h.failures = 0
+
+				if !autoRestart || !pending || now.Sub(h.lastRestart) < instanceRestartBackoff(h.restarts) {
+					continue
+				}
+
+				h.busy = true
+				h.restarts++
+				h.lastRestart = now
+				go func(inst instance.Instance, h *instanceHealth, restarts int) {
+					instanceAutoRestart(s, inst, "stopped", restarts)
+
+					instancesHealthLock.Lock()
+					h.busy = false
+					instancesHealthLock.Unlock()
+				}(inst, h, h.restarts)
+
+				continue
+			}
+
+			// Instances started by other means don't need restarting anymore.
+			if pending {
+				err := inst.VolatileSet(map[string]string{"volatile.autorestart.pending": ""})
+				if err != nil {
+					logger.Warn("Failed to clear instance restart flag", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+				}
+			}
+
+			interval := instanceHealthCheckInterval(inst)
+			if interval <= 0 || now.Sub(h.lastCheck) < interval {
+				continue
+			}
+
+			h.busy = true
+			h.lastCheck = now
+			go func(inst instance.Instance, h *instanceHealth) {
+				err := instanceHealthCheck(inst, interval)
+
+				instancesHealthLock.Lock()
+				defer instancesHealthLock.Unlock()
+
+				h.busy = false
+				if err == nil {
+					h.failures = 0
+					return
+				}
+
+				h.failures++
+				logger.Warn("Instance health check failed", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "failures": h.failures, "err": err})
+
+				threshold, err := strconv.Atoi(inst.ExpandedConfig()["boot.health_check.failures"])
+				if err != nil || threshold <= 0 {
+					threshold = 3
+				}
+
+				if !autoRestart || h.failures < threshold || time.Since(h.lastRestart) < instanceRestartBackoff(h.restarts) {
+					return
+				}
+
+				h.busy = true
+				h.failures = 0
+				h.restarts++
+				h.lastRestart = time.Now()
+				go func(restarts int) {
+					instanceAutoRestart(s, inst, "unhealthy", restarts)
+
+					instancesHealthLock.Lock()
+					h.busy = false
+					instancesHealthLock.Unlock()
+				}(h.restarts)
+			}(inst, h)
+		}
+
+		// Forget about the instances which are gone.
+		for key, h := range instancesHealth {
+			if !seen[key] && !h.busy {
+				delete(instancesHealth, key)
+			}
+		}
+	}
+
+	return f, task.Every(instanceHealthTaskInterval)
+}
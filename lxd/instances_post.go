@@ -17,6 +17,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 
+	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/backup"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
@@ -28,6 +29,7 @@ import (
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/revert"
 	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	log "github.com/lxc/lxd/shared/log15"
@@ -65,7 +67,7 @@ func createFromImage(d *Daemon, project string, req *api.InstancesPost) response
 
 		var info *api.Image
 		if req.Source.Server != "" {
-			autoUpdate, err := cluster.ConfigGetBool(d.cluster, "images.auto_update_cached")
+			policy, err := imagePolicyGet(d.cluster, project)
 			if err != nil {
 				return err
 			}
@@ -87,7 +89,7 @@ func createFromImage(d *Daemon, project string, req *api.InstancesPost) response
 
 			info, err = d.ImageDownload(
 				op, req.Source.Server, req.Source.Protocol, req.Source.Certificate,
-				req.Source.Secret, hash, imgType, true, autoUpdate, "", true, project, budget)
+				req.Source.Secret, hash, imgType, true, policy.autoUpdateCached, "", true, project, budget)
 			if err != nil {
 				return err
 			}
@@ -116,6 +118,8 @@ func createFromImage(d *Daemon, project string, req *api.InstancesPost) response
 		return response.InternalError(err)
 	}
 
+	op.SetCancelable()
+
 	return operations.OperationResponse(op)
 }
 
@@ -361,11 +365,15 @@ func createFromMigration(d *Daemon, project string, req *api.InstancesPost) resp
 		if err != nil {
 			return response.InternalError(err)
 		}
+
+		op.SetCancelable()
 	} else {
 		op, err = operations.OperationCreate(d.State(), project, operations.OperationClassTask, db.OperationContainerCreate, resources, nil, run, nil, nil)
 		if err != nil {
 			return response.InternalError(err)
 		}
+
+		op.SetCancelable()
 	}
 
 	revert = false
@@ -542,10 +550,14 @@ func createFromCopy(d *Daemon, project string, req *api.InstancesPost) response.
 		return response.InternalError(err)
 	}
 
+	op.SetCancelable()
+
 	return operations.OperationResponse(op)
 }
 
-func createFromBackup(d *Daemon, project string, data io.Reader, pool string) response.Response {
+// createFromBackup restores an instance from the supplied backup tarball. If name is not empty, the
+// instance is restored under that name rather than the one recorded in the backup.
+func createFromBackup(d *Daemon, project string, data io.Reader, pool string, name string) response.Response {
 	revert := revert.New()
 	defer revert.Fail()
 
@@ -602,6 +614,11 @@ func createFromBackup(d *Daemon, project string, data io.Reader, pool string) re
 	if err != nil {
 		return response.BadRequest(err)
 	}
+
+	if bInfo.Volume != nil {
+		return response.BadRequest(fmt.Errorf("Custom volume backups must be imported as storage volumes"))
+	}
+
 	bInfo.Project = project
 
 	// Override pool.
@@ -609,16 +626,49 @@ func createFromBackup(d *Daemon, project string, data io.Reader, pool string) re
 		bInfo.Pool = pool
 	}
 
+	// Override instance name.
+	if name != "" && name != bInfo.Name {
+		if bInfo.IncrementalFrom != "" {
+			return response.BadRequest(fmt.Errorf("Incremental backups cannot be restored under a different name"))
+		}
+
+		bInfo.Name = name
+	}
+
 	logger.Debug("Backup file info loaded", log.Ctx{
-		"type":      bInfo.Type,
-		"name":      bInfo.Name,
-		"project":   bInfo.Project,
-		"backend":   bInfo.Backend,
-		"pool":      bInfo.Pool,
-		"optimized": *bInfo.OptimizedStorage,
-		"snapshots": bInfo.Snapshots,
+		"type":            bInfo.Type,
+		"name":            bInfo.Name,
+		"project":         bInfo.Project,
+		"backend":         bInfo.Backend,
+		"pool":            bInfo.Pool,
+		"optimized":       *bInfo.OptimizedStorage,
+		"snapshots":       bInfo.Snapshots,
+		"incrementalFrom": bInfo.IncrementalFrom,
 	})
 
+	// Incremental backups are applied on top of the existing instance they were taken from.
+	if bInfo.IncrementalFrom != "" {
+		inst, err := instance.LoadByProjectAndName(d.State(), project, bInfo.Name)
+		if err != nil {
+			return response.BadRequest(errors.Wrapf(err, "Incremental backups can only be restored onto an existing instance"))
+		}
+
+		if inst.IsRunning() {
+			return response.BadRequest(fmt.Errorf("Instance %q must be stopped to restore an incremental backup", bInfo.Name))
+		}
+
+		instPool, err := storagePools.GetPoolByInstance(d.State(), inst)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		if pool != "" && pool != instPool.Name() {
+			return response.BadRequest(fmt.Errorf("Incremental backups must be restored onto the instance's storage pool %q", instPool.Name()))
+		}
+
+		bInfo.Pool = instPool.Name()
+	}
+
 	// Check storage pool exists.
 	_, _, err = d.State().Cluster.GetStoragePoolInAnyState(bInfo.Pool)
 	if errors.Cause(err) == db.ErrNoSuchObject {
@@ -646,6 +696,18 @@ func createFromBackup(d *Daemon, project string, data io.Reader, pool string) re
 		return response.InternalError(err)
 	}
 
+	// Check that the backup format is supported and, if the backup is optimized, that the source pool driver
+	// matches the target pool driver.
+	targetPool, err := storagePools.GetPoolByName(d.State(), bInfo.Pool)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = bInfo.CheckCompatibility(targetPool.Driver().Info().Name)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	// Copy reverter so far so we can use it inside run after this function has finished.
 	runRevert := revert.Clone()
 
@@ -658,11 +720,6 @@ func createFromBackup(d *Daemon, project string, data io.Reader, pool string) re
 			return err
 		}
 
-		// Check if the backup is optimized that the source pool driver matches the target pool driver.
-		if *bInfo.OptimizedStorage && pool.Driver().Info().Name != bInfo.Backend {
-			return fmt.Errorf("Optimized backup storage driver %q differs from the target storage pool driver %q", bInfo.Backend, pool.Driver().Info().Name)
-		}
-
 		// Dump tarball to storage. Because the backup file is unpacked and restored onto the storage
 		// device before the instance is created in the database it is necessary to return two functions;
 		// a post hook that can be run once the instance has been created in the database to run any
@@ -701,8 +758,10 @@ func createFromBackup(d *Daemon, project string, data io.Reader, pool string) re
 			return errors.Wrap(err, "Load instance")
 		}
 
-		// Clean up created instance if the post hook fails below.
-		runRevert.Add(func() { inst.Delete() })
+		// Clean up created instance if the post hook fails below (unless it existed before).
+		if bInfo.IncrementalFrom == "" {
+			runRevert.Add(func() { inst.Delete() })
+		}
 
 		// Run the storage post hook to perform any final actions now that the instance has been created
 		// in the database (this normally includes unmounting volumes that were mounted).
@@ -726,17 +785,128 @@ func createFromBackup(d *Daemon, project string, data io.Reader, pool string) re
 		return response.InternalError(err)
 	}
 
+	op.SetCancelable()
+
 	revert.Success()
 	return operations.OperationResponse(op)
 }
 
+// createFromBackupSource restores an existing instance backup as a new instance on this member. The
+// backup tarball is fetched from the member hosting the backup's instance if it isn't stored locally.
+func createFromBackupSource(d *Daemon, project string, req *api.InstancesPost) response.Response {
+	instName, backupName, isBackup := shared.InstanceGetParentAndSnapshotName(req.Source.Source)
+	if !isBackup {
+		return response.BadRequest(fmt.Errorf("Source backup must be in the form <instance>/<backup>"))
+	}
+
+	_, err := d.cluster.GetInstanceBackup(project, req.Source.Source)
+	if err != nil {
+		return response.SmartError(errors.Wrapf(err, "Load backup %q", req.Source.Source))
+	}
+
+	// Use the pool of the requested root disk device, if any.
+	pool := ""
+	_, rootDiskDevice, err := shared.GetRootDiskDevice(req.Devices)
+	if err == nil {
+		pool = rootDiskDevice["pool"]
+	}
+
+	// Locate the member hosting the backup.
+	var nodeAddress string
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+
+		nodeAddress, err = tx.GetNodeAddressOfInstance(project, instName, instancetype.Any)
+		if err != nil {
+			return errors.Wrap(err, "Failed to get address of instance's node")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if nodeAddress == "" {
+		backupFile, err := os.Open(shared.VarPath("backups", projecthelpers.Instance(project, req.Source.Source)))
+		if err != nil {
+			return response.SmartError(err)
+		}
+		defer backupFile.Close()
+
+		return createFromBackup(d, project, backupFile, pool, req.Name)
+	}
+
+	// Fetch the backup tarball from the member hosting it.
+	client, err := cluster.Connect(nodeAddress, d.endpoints.NetworkCert(), false)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	client = client.UseProject(project)
+
+	backupFile, err := ioutil.TempFile(shared.VarPath("backups"), "lxd_backup_fetch_")
+	if err != nil {
+		return response.InternalError(err)
+	}
+	defer os.Remove(backupFile.Name())
+	defer backupFile.Close()
+
+	logger.Debugf("Fetching backup %q from %s", req.Source.Source, nodeAddress)
+	_, err = client.GetInstanceBackupFile(instName, backupName, &lxd.BackupFileRequest{BackupFile: backupFile})
+	if err != nil {
+		return response.SmartError(errors.Wrapf(err, "Fetch backup %q", req.Source.Source))
+	}
+
+	_, err = backupFile.Seek(0, 0)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return createFromBackup(d, project, backupFile, pool, req.Name)
+}
+
+// createFromBackupURL restores an instance from a backup tarball downloaded from the source URL, typically
+// a presigned URL of an object storage bucket the backup was exported to.
+func createFromBackupURL(d *Daemon, project string, req *api.InstancesPost) response.Response {
+	u, err := url.Parse(req.Source.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return response.BadRequest(fmt.Errorf("Invalid backup URL %q", req.Source.URL))
+	}
+
+	// Use the pool of the requested root disk device, if any.
+	pool := ""
+	_, rootDiskDevice, err := shared.GetRootDiskDevice(req.Devices)
+	if err == nil {
+		pool = rootDiskDevice["pool"]
+	}
+
+	httpClient, err := util.HTTPClient("", d.proxy)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	logger.Debugf("Downloading backup from %s", u.Host)
+	resp, err := httpClient.Get(req.Source.URL)
+	if err != nil {
+		return response.SmartError(errors.Wrap(err, "Download backup"))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return response.BadRequest(fmt.Errorf("Failed to download backup: Unexpected status code %d", resp.StatusCode))
+	}
+
+	return createFromBackup(d, project, resp.Body, pool, req.Name)
+}
+
 func containersPost(d *Daemon, r *http.Request) response.Response {
 	project := projectParam(r)
 	logger.Debugf("Responding to instance create")
 
 	// If we're getting binary content, process separately
 	if r.Header.Get("Content-Type") == "application/octet-stream" {
-		return createFromBackup(d, project, r.Body, r.Header.Get("X-LXD-pool"))
+		return createFromBackup(d, project, r.Body, r.Header.Get("X-LXD-pool"), "")
 	}
 
 	// Parse the request
@@ -756,25 +926,59 @@ func containersPost(d *Daemon, r *http.Request) response.Response {
 		req.Type = api.InstanceType(urlType.String())
 	}
 
+	// A target of the form "@<group>" places the instance on a member of that cluster group.
 	targetNode := queryParam(r, "target")
+	targetGroup := ""
+	if strings.HasPrefix(targetNode, "@") {
+		targetGroup = strings.TrimPrefix(targetNode, "@")
+		targetNode = ""
+	}
+
 	if targetNode == "" {
-		// If no target node was specified, pick the node with the
-		// least number of containers. If there's just one node, or if
-		// the selected node is the local one, this is effectively a
-		// no-op, since GetNodeWithLeastInstances() will return an empty
-		// string.
-		architectures, err := instance.SuitableArchitectures(d.State(), project, req)
-		if err != nil {
-			return response.BadRequest(err)
+		// If no target node was specified, let the scheduler pick one
+		// according to the configured placement policy. If there's just
+		// one node, or if the selected node is the local one, this is
+		// effectively a no-op.
+		// Apply the image mirrors, in offline mode any architecture is considered.
+		archReq := req
+		offline := false
+		if req.Source.Type == "image" && req.Source.Server != "" {
+			archReq.Source.Server, err = d.imageServerURL(req.Source.Server)
+			offline = err == errOffline
+			if err != nil && !offline {
+				return response.SmartError(err)
+			}
 		}
-		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
-			var err error
-			targetNode, err = tx.GetNodeWithLeastInstances(architectures)
-			return err
-		})
+
+		var architectures []int
+		if !offline {
+			architectures, err = instance.SuitableArchitectures(d.State(), project, archReq)
+			if err != nil {
+				return response.BadRequest(err)
+			}
+		}
+		if targetGroup != "" {
+			err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+				_, _, err := tx.GetClusterGroup(targetGroup)
+				if err != nil {
+					return errors.Wrapf(err, "Failed to load cluster group %q", targetGroup)
+				}
+
+				return nil
+			})
+			if err != nil {
+				return response.SmartError(err)
+			}
+		}
+
+		targetNode, err = instancePlacementTarget(d, project, &req, architectures, targetGroup)
 		if err != nil {
 			return response.SmartError(err)
 		}
+
+		if targetGroup != "" && targetNode == "" {
+			return response.SmartError(fmt.Errorf("No suitable cluster member in group %q", targetGroup))
+		}
 	}
 
 	if targetNode != "" {
@@ -834,6 +1038,11 @@ func containersPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(fmt.Errorf("Invalid instance name: '%s' is reserved for snapshots", shared.SnapshotDelimiter))
 	}
 
+	// Backups downloaded from a URL are restored like uploaded ones.
+	if req.Source.Type == "backup" && req.Source.URL != "" {
+		return createFromBackupURL(d, project, &req)
+	}
+
 	// Check that the project's limits are not violated. Also, possibly
 	// automatically assign a name.
 	//
@@ -852,6 +1061,18 @@ func containersPost(d *Daemon, r *http.Request) response.Response {
 					return errors.Wrap(err, "Load source instance from database")
 				}
 
+				req.Type = api.InstanceType(source.Type.String())
+			case "backup":
+				if req.Source.Source == "" {
+					return fmt.Errorf("Must specify a source backup")
+				}
+
+				instName, _, _ := shared.InstanceGetParentAndSnapshotName(req.Source.Source)
+				source, err := instance.LoadInstanceDatabaseObject(tx, project, instName)
+				if err != nil {
+					return errors.Wrap(err, "Load source instance from database")
+				}
+
 				req.Type = api.InstanceType(source.Type.String())
 			case "migration":
 				req.Type = api.InstanceTypeContainer
@@ -899,6 +1120,8 @@ func containersPost(d *Daemon, r *http.Request) response.Response {
 		return createFromMigration(d, project, &req)
 	case "copy":
 		return createFromCopy(d, project, &req)
+	case "backup":
+		return createFromBackupSource(d, project, &req)
 	default:
 		return response.BadRequest(fmt.Errorf("Unknown source type %s", req.Source.Type))
 	}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// instancesPut changes the state of several instances at once, using a single background operation
+// recording the result for each of them.
+func instancesPut(d *Daemon, r *http.Request) response.Response {
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	project := projectParam(r)
+
+	req := api.InstancesPut{}
+
+	// We default to -1 (i.e. no timeout) here instead of 0 (instant
+	// timeout).
+	req.State.Timeout = -1
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if len(req.Names) == 0 {
+		return response.BadRequest(fmt.Errorf("No instances provided"))
+	}
+
+	if !shared.StringInSlice(req.State.Action, []string{string(shared.Start), string(shared.Stop), string(shared.Restart), string(shared.Freeze), string(shared.Unfreeze)}) {
+		return response.BadRequest(fmt.Errorf("unknown action %s", req.State.Action))
+	}
+
+	// Don't mess with instances while in setup mode
+	<-d.readyChan
+
+	// Figure out where each instance lives, failing early on unknown ones.
+	addresses := make(map[string]string, len(req.Names))
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		for _, name := range req.Names {
+			address, err := tx.GetNodeAddressOfInstance(project, name, instanceType)
+			if err != nil {
+				return err
+			}
+
+			addresses[name] = address
+		}
+
+		return nil
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Load the local instances and prepare their state change.
+	actions := map[string]func(*operations.Operation) error{}
+	for name, address := range addresses {
+		if address != "" {
+			continue
+		}
+
+		inst, err := instance.LoadByProjectAndName(d.State(), project, name)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		_, do, err := instanceStateAction(d, inst, req.State)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		actions[name] = do
+	}
+
+	run := func(op *operations.Operation) error {
+		results := map[string]string{}
+		resultsLock := sync.Mutex{}
+
+		wg := sync.WaitGroup{}
+		for name, address := range addresses {
+			wg.Add(1)
+			go func(name string, address string) {
+				defer wg.Done()
+
+				var err error
+				if address == "" {
+					err = actions[name](op)
+				} else {
+					err = instancesPutRemote(d, project, name, address, req.State)
+				}
+
+				resultsLock.Lock()
+				defer resultsLock.Unlock()
+
+				results[name] = "success"
+				if err != nil {
+					results[name] = err.Error()
+				}
+			}(name, address)
+		}
+		wg.Wait()
+
+		failed := 0
+		for _, result := range results {
+			if result != "success" {
+				failed++
+			}
+		}
+
+		err := op.UpdateMetadata(map[string]interface{}{"instances": results})
+		if err != nil {
+			return err
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("Failed to %s %d out of %d instances", req.State.Action, failed, len(results))
+		}
+
+		return nil
+	}
+
+	resources := map[string][]string{}
+	resources["instances"] = req.Names
+	resources["containers"] = resources["instances"]
+
+	op, err := operations.OperationCreate(d.State(), project, operations.OperationClassTask, db.OperationInstancesStateUpdate, resources, nil, run, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// instancesPutRemote changes the state of an instance living on another cluster member and waits for
+// the change to complete.
+func instancesPutRemote(d *Daemon, project string, name string, address string, state api.InstanceStatePut) error {
+	client, err := cluster.Connect(address, d.endpoints.NetworkCert(), false)
+	if err != nil {
+		return err
+	}
+
+	op, err := client.UseProject(project).UpdateInstanceState(name, state, "")
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
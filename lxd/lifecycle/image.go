@@ -0,0 +1,24 @@
+package lifecycle
+
+import (
+	"github.com/lxc/lxd/shared/api"
+)
+
+// ImageAction represents a lifecycle event action for images.
+type ImageAction string
+
+// All supported lifecycle events for images.
+const (
+	ImageRefreshed = ImageAction("image-refreshed")
+	ImageDeleted   = ImageAction("image-deleted")
+)
+
+// Event creates the lifecycle event for an action on an image.
+func (a ImageAction) Event(fingerprint string, requestor *api.EventLifecycleRequestor, ctx map[string]interface{}) api.EventLifecycle {
+	return api.EventLifecycle{
+		Action:    string(a),
+		Source:    sourceURL("images", fingerprint),
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
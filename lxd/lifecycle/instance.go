@@ -0,0 +1,46 @@
+package lifecycle
+
+import (
+	"github.com/lxc/lxd/shared/api"
+)
+
+// InstanceAction represents a lifecycle event action for instances.
+type InstanceAction string
+
+// All supported lifecycle events for instances.
+const (
+	InstanceCreated          = InstanceAction("instance-created")
+	InstanceStarted          = InstanceAction("instance-started")
+	InstanceStopped          = InstanceAction("instance-stopped")
+	InstanceShutdown         = InstanceAction("instance-shutdown")
+	InstancePaused           = InstanceAction("instance-paused")
+	InstanceResumed          = InstanceAction("instance-resumed")
+	InstanceUpdated          = InstanceAction("instance-updated")
+	InstanceRenamed          = InstanceAction("instance-renamed")
+	InstanceRestarted        = InstanceAction("instance-restarted")
+	InstanceDeleted          = InstanceAction("instance-deleted")
+	InstanceBackupFailed     = InstanceAction("instance-backup-failed")
+	InstanceDeviceHotplugged = InstanceAction("instance-device-hotplugged")
+	InstanceDeviceUnplugged  = InstanceAction("instance-device-unplugged")
+	InstanceSnapshotCreated  = InstanceAction("instance-snapshot-created")
+	InstanceSnapshotDeleted  = InstanceAction("instance-snapshot-deleted")
+	InstanceSnapshotRenamed  = InstanceAction("instance-snapshot-renamed")
+	InstanceSnapshotRestored = InstanceAction("instance-snapshot-restored")
+)
+
+// Event creates the lifecycle event for an action on an instance. Snapshots use the source of the snapshot.
+func (a InstanceAction) Event(inst instance, requestor *api.EventLifecycleRequestor, ctx map[string]interface{}) api.EventLifecycle {
+	parentName, snapshotName := splitSnapshotName(inst.Name())
+
+	source := sourceURL("instances", parentName)
+	if snapshotName != "" {
+		source = sourceURL("instances", parentName, "snapshots", snapshotName)
+	}
+
+	return api.EventLifecycle{
+		Action:    string(a),
+		Source:    source,
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
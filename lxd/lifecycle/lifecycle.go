@@ -0,0 +1,37 @@
+// Package lifecycle defines the actions of the lifecycle events sent by LXD, along with helpers building those
+// events for the affected entities.
+package lifecycle
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/lxc/lxd/shared/version"
+)
+
+// Internal copy of the instance interface to avoid import loops.
+type instance interface {
+	Name() string
+}
+
+// sourceURL returns the API path of an entity, escaping each of its elements.
+func sourceURL(elems ...string) string {
+	path := fmt.Sprintf("/%s", version.APIVersion)
+	for _, elem := range elems {
+		path = fmt.Sprintf("%s/%s", path, url.PathEscape(elem))
+	}
+
+	return path
+}
+
+// splitSnapshotName returns the parent and snapshot names of a "parent/snapshot" name, the snapshot name being
+// empty for non-snapshots.
+func splitSnapshotName(name string) (string, string) {
+	fields := strings.SplitN(name, "/", 2)
+	if len(fields) == 1 {
+		return fields[0], ""
+	}
+
+	return fields[0], fields[1]
+}
@@ -0,0 +1,27 @@
+package lifecycle
+
+import (
+	"github.com/lxc/lxd/shared/api"
+)
+
+// NetworkAction represents a lifecycle event action for networks.
+type NetworkAction string
+
+// All supported lifecycle events for networks.
+const (
+	NetworkCreated    = NetworkAction("network-created")
+	NetworkUpdated    = NetworkAction("network-updated")
+	NetworkRenamed    = NetworkAction("network-renamed")
+	NetworkDeleted    = NetworkAction("network-deleted")
+	NetworkRenumbered = NetworkAction("network-renumbered")
+)
+
+// Event creates the lifecycle event for an action on a network.
+func (a NetworkAction) Event(name string, requestor *api.EventLifecycleRequestor, ctx map[string]interface{}) api.EventLifecycle {
+	return api.EventLifecycle{
+		Action:    string(a),
+		Source:    sourceURL("networks", name),
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
@@ -0,0 +1,26 @@
+package lifecycle
+
+import (
+	"github.com/lxc/lxd/shared/api"
+)
+
+// ProfileAction represents a lifecycle event action for profiles.
+type ProfileAction string
+
+// All supported lifecycle events for profiles.
+const (
+	ProfileCreated = ProfileAction("profile-created")
+	ProfileUpdated = ProfileAction("profile-updated")
+	ProfileRenamed = ProfileAction("profile-renamed")
+	ProfileDeleted = ProfileAction("profile-deleted")
+)
+
+// Event creates the lifecycle event for an action on a profile.
+func (a ProfileAction) Event(name string, requestor *api.EventLifecycleRequestor, ctx map[string]interface{}) api.EventLifecycle {
+	return api.EventLifecycle{
+		Action:    string(a),
+		Source:    sourceURL("profiles", name),
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
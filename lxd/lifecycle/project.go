@@ -0,0 +1,26 @@
+package lifecycle
+
+import (
+	"github.com/lxc/lxd/shared/api"
+)
+
+// ProjectAction represents a lifecycle event action for projects.
+type ProjectAction string
+
+// All supported lifecycle events for projects.
+const (
+	ProjectCreated = ProjectAction("project-created")
+	ProjectUpdated = ProjectAction("project-updated")
+	ProjectRenamed = ProjectAction("project-renamed")
+	ProjectDeleted = ProjectAction("project-deleted")
+)
+
+// Event creates the lifecycle event for an action on a project.
+func (a ProjectAction) Event(name string, requestor *api.EventLifecycleRequestor, ctx map[string]interface{}) api.EventLifecycle {
+	return api.EventLifecycle{
+		Action:    string(a),
+		Source:    sourceURL("projects", name),
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
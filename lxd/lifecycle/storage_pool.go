@@ -0,0 +1,25 @@
+package lifecycle
+
+import (
+	"github.com/lxc/lxd/shared/api"
+)
+
+// StoragePoolAction represents a lifecycle event action for storage pools.
+type StoragePoolAction string
+
+// All supported lifecycle events for storage pools.
+const (
+	StoragePoolCreated = StoragePoolAction("storage-pool-created")
+	StoragePoolUpdated = StoragePoolAction("storage-pool-updated")
+	StoragePoolDeleted = StoragePoolAction("storage-pool-deleted")
+)
+
+// Event creates the lifecycle event for an action on a storage pool.
+func (a StoragePoolAction) Event(name string, requestor *api.EventLifecycleRequestor, ctx map[string]interface{}) api.EventLifecycle {
+	return api.EventLifecycle{
+		Action:    string(a),
+		Source:    sourceURL("storage-pools", name),
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
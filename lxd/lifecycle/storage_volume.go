@@ -0,0 +1,37 @@
+package lifecycle
+
+import (
+	"github.com/lxc/lxd/shared/api"
+)
+
+// StorageVolumeAction represents a lifecycle event action for storage volumes.
+type StorageVolumeAction string
+
+// All supported lifecycle events for storage volumes.
+const (
+	StorageVolumeCreated         = StorageVolumeAction("storage-volume-created")
+	StorageVolumeUpdated         = StorageVolumeAction("storage-volume-updated")
+	StorageVolumeRenamed         = StorageVolumeAction("storage-volume-renamed")
+	StorageVolumeDeleted         = StorageVolumeAction("storage-volume-deleted")
+	StorageVolumeSnapshotCreated = StorageVolumeAction("storage-volume-snapshot-created")
+	StorageVolumeSnapshotUpdated = StorageVolumeAction("storage-volume-snapshot-updated")
+	StorageVolumeSnapshotRenamed = StorageVolumeAction("storage-volume-snapshot-renamed")
+	StorageVolumeSnapshotDeleted = StorageVolumeAction("storage-volume-snapshot-deleted")
+)
+
+// Event creates the lifecycle event for an action on a storage volume. Snapshots use the source of the snapshot.
+func (a StorageVolumeAction) Event(poolName string, volumeType string, volumeName string, requestor *api.EventLifecycleRequestor, ctx map[string]interface{}) api.EventLifecycle {
+	parentName, snapshotName := splitSnapshotName(volumeName)
+
+	source := sourceURL("storage-pools", poolName, "volumes", volumeType, parentName)
+	if snapshotName != "" {
+		source = sourceURL("storage-pools", poolName, "volumes", volumeType, parentName, "snapshots", snapshotName)
+	}
+
+	return api.EventLifecycle{
+		Action:    string(a),
+		Source:    source,
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
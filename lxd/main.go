@@ -209,6 +209,10 @@ func main() {
 	clusterCmd := cmdCluster{global: &globalCmd}
 	app.AddCommand(clusterCmd.Command())
 
+	// database sub-command
+	databaseCmd := cmdDatabase{global: &globalCmd}
+	app.AddCommand(databaseCmd.Command())
+
 	// Run the main command and handle errors
 	err := app.Execute()
 	if err != nil {
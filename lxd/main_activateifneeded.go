@@ -14,6 +14,7 @@ import (
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/node"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/idmap"
 	"github.com/lxc/lxd/shared/logger"
 )
@@ -140,9 +141,36 @@ func (c *cmdActivateifneeded) Run(cmd *cobra.Command, args []string) error {
 			_, err := lxd.ConnectLXDUnix("", nil)
 			return err
 		}
+
+		// Check for scheduled instance backups
+		if config["backups.schedule"] != "" {
+			logger.Debugf("Daemon has scheduled instance backups, activating...")
+			_, err := lxd.ConnectLXDUnix("", nil)
+			return err
+		}
 	}
 
-	// Check for scheduled volume snapshots
+	// Check for projects with scheduled instance backups
+	if len(instances) > 0 {
+		var projects []api.Project
+		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			projects, err = tx.GetProjects(db.ProjectFilter{})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, p := range projects {
+			if p.Config["backups.schedule"] != "" {
+				logger.Debugf("Daemon has scheduled project backups, activating...")
+				_, err := lxd.ConnectLXDUnix("", nil)
+				return err
+			}
+		}
+	}
+
+	// Check for scheduled volume snapshots and backups
 	volumes, err := d.cluster.GetStoragePoolVolumesWithType(db.StoragePoolVolumeTypeCustom)
 	if err != nil {
 		return err
@@ -154,6 +182,12 @@ func (c *cmdActivateifneeded) Run(cmd *cobra.Command, args []string) error {
 			_, err := lxd.ConnectLXDUnix("", nil)
 			return err
 		}
+
+		if vol.Config["backups.schedule"] != "" {
+			logger.Debugf("Daemon has scheduled volume backups, activating...")
+			_, err := lxd.ConnectLXDUnix("", nil)
+			return err
+		}
 	}
 
 	logger.Debugf("No need to start the daemon now")
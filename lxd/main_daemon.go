@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/sys/unix"
@@ -17,7 +18,8 @@ type cmdDaemon struct {
 	global *cmdGlobal
 
 	// Common options
-	flagGroup string
+	flagGroup       string
+	flagIdleTimeout time.Duration
 }
 
 func (c *cmdDaemon) Command() *cobra.Command {
@@ -32,9 +34,15 @@ func (c *cmdDaemon) Command() *cobra.Command {
 
   There are however a number of subcommands that let you interact directly with
   the local LXD daemon and which may not be performed through the REST API alone.
+
+  When started through systemd socket activation, --idle-timeout makes the
+  daemon exit once it has been idle for the given duration, that is without
+  API requests, running instances, operations or event listeners. It's then
+  started again on the next connection to its socket.
 `
 	cmd.RunE = c.Run
 	cmd.Flags().StringVar(&c.flagGroup, "group", "", "The group of users that will be allowed to talk to LXD"+"``")
+	cmd.Flags().DurationVar(&c.flagIdleTimeout, "idle-timeout", 0, "Exit after being idle for that long (e.g. 30m, disabled by default)"+"``")
 
 	return cmd
 }
@@ -59,6 +67,7 @@ func (c *cmdDaemon) Run(cmd *cobra.Command, args []string) error {
 
 	conf := defaultDaemonConfig()
 	conf.Group = c.flagGroup
+	conf.IdleTimeout = c.flagIdleTimeout
 	conf.Trace = c.global.flagLogTrace
 	d := newDaemon(conf, sys.DefaultOS())
 
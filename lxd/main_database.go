@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared"
+)
+
+type cmdDatabase struct {
+	global *cmdGlobal
+}
+
+func (c *cmdDatabase) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "database"
+	cmd.Short = "Low-level database administration commands"
+	cmd.Long = `Description:
+  Low level administration tools for backing up, restoring and inspecting
+  the LXD database.
+`
+	cmd.Hidden = true
+
+	// Backup
+	backup := cmdDatabaseBackup{global: c.global}
+	cmd.AddCommand(backup.Command())
+
+	// Restore
+	restore := cmdDatabaseRestore{global: c.global}
+	cmd.AddCommand(restore.Command())
+
+	// Query
+	query := cmdDatabaseQuery{global: c.global}
+	cmd.AddCommand(query.Command())
+
+	return cmd
+}
+
+func databaseConnect() (lxd.InstanceServer, error) {
+	lxdArgs := lxd.ConnectionArgs{
+		SkipGetServer: true,
+	}
+
+	return lxd.ConnectLXDUnix("", &lxdArgs)
+}
+
+type cmdDatabaseBackup struct {
+	global *cmdGlobal
+}
+
+func (c *cmdDatabaseBackup) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "backup [<path>]"
+	cmd.Short = "Backup the LXD local and global databases to a file"
+	cmd.Long = `Description:
+  Backup the LXD local and global databases to a file
+
+  The backup is taken within a transaction and is therefore consistent. If
+  no path is given, the backup is written to the LXD backups directory.
+
+  The backup contains sensitive data such as certificates and should be
+  kept safe.
+`
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdDatabaseBackup) Run(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		cmd.Help()
+		return fmt.Errorf("Too many arguments")
+	}
+
+	req := internalDatabasePath{}
+	if len(args) == 1 {
+		path, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+
+		req.Path = path
+	}
+
+	d, err := databaseConnect()
+	if err != nil {
+		return err
+	}
+
+	response, _, err := d.RawQuery("POST", "/internal/database/backup", req, "")
+	if err != nil {
+		return errors.Wrap(err, "Failed to backup the database")
+	}
+
+	result := internalDatabasePath{}
+	err = json.Unmarshal(response.Metadata, &result)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Database backed up to %s\n", result.Path)
+	return nil
+}
+
+type cmdDatabaseRestore struct {
+	global *cmdGlobal
+}
+
+func (c *cmdDatabaseRestore) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "restore <path>"
+	cmd.Short = "Restore the LXD local and global databases from a file"
+	cmd.Long = `Description:
+  Restore the LXD local and global databases from a file
+
+  This is only allowed on a fresh, non-clustered LXD which doesn't have any
+  instance and runs the same version as the one which made the backup.
+
+  LXD must be restarted once the restore is complete.
+`
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdDatabaseRestore) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		cmd.Help()
+
+		if len(args) == 0 {
+			return nil
+		}
+
+		return fmt.Errorf("Too many arguments")
+	}
+
+	path, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+
+	d, err := databaseConnect()
+	if err != nil {
+		return err
+	}
+
+	_, _, err = d.RawQuery("POST", "/internal/database/restore", internalDatabasePath{Path: path}, "")
+	if err != nil {
+		return errors.Wrap(err, "Failed to restore the database")
+	}
+
+	fmt.Println("Database restored, please restart LXD")
+	return nil
+}
+
+type cmdDatabaseQuery struct {
+	global *cmdGlobal
+
+	flagLimit  int
+	flagOffset int
+}
+
+func (c *cmdDatabaseQuery) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "query <local|global> <query>"
+	cmd.Short = "Run a read-only query against the LXD local or global database"
+	cmd.Long = `Description:
+  Run a read-only query against the LXD local or global database
+
+  Only a single SELECT statement is allowed and it's never committed,
+  making this safe to use for support and debugging. Results are
+  paginated, use --offset to get the following rows.
+`
+	cmd.RunE = c.Run
+	cmd.Flags().IntVar(&c.flagLimit, "limit", 100, "Maximum number of rows to return"+"``")
+	cmd.Flags().IntVar(&c.flagOffset, "offset", 0, "Number of rows to skip"+"``")
+
+	return cmd
+}
+
+func (c *cmdDatabaseQuery) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		cmd.Help()
+
+		if len(args) == 0 {
+			return nil
+		}
+
+		return fmt.Errorf("Missing required arguments")
+	}
+
+	if !shared.StringInSlice(args[0], []string{"local", "global"}) {
+		cmd.Help()
+
+		return fmt.Errorf("Invalid database type")
+	}
+
+	d, err := databaseConnect()
+	if err != nil {
+		return err
+	}
+
+	req := internalDatabaseQueryPost{
+		Database: args[0],
+		Query:    args[1],
+		Limit:    c.flagLimit,
+		Offset:   c.flagOffset,
+	}
+
+	response, _, err := d.RawQuery("POST", "/internal/database/query", req, "")
+	if err != nil {
+		return err
+	}
+
+	result := internalDatabaseQueryResult{}
+	err = json.Unmarshal(response.Metadata, &result)
+	if err != nil {
+		return err
+	}
+
+	sqlPrintSelectResult(internalSQLResult{Columns: result.Columns, Rows: result.Rows})
+
+	if result.More {
+		fmt.Printf("More rows available, use --offset=%d to get them\n", c.flagOffset+len(result.Rows))
+	}
+
+	return nil
+}
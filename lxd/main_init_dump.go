@@ -2,28 +2,130 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 	yaml "gopkg.in/yaml.v2"
 
 	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 )
 
 func (c *cmdInit) RunDump(d lxd.InstanceServer) error {
-	currentServer, _, err := d.GetServer()
+	config, err := initDataNodeDump(d)
+	if err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(config)
 	if err != nil {
 		return errors.Wrap(err, "Failed to retrieve current server configuration")
 	}
 
+	fmt.Printf("%s\n", out)
+
+	return nil
+}
+
+// initDataNodeDump returns the current configuration of the server as a preseed document. It's used both by
+// the 'lxd init --dump' command and by the GET /1.0/preseed API.
+func initDataNodeDump(d lxd.InstanceServer) (*initDataNode, error) {
+	currentServer, _, err := d.GetServer()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve current server configuration")
+	}
+
 	var config initDataNode
 	config.Config = currentServer.Config
 
+	config.Networks, err = initDataNetworksDump(d)
+	if err != nil {
+		return nil, err
+	}
+
+	storagePools, err := d.GetStoragePools()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve current server configuration")
+	}
+
+	poolNames := make([]string, 0, len(storagePools))
+	for _, storagePool := range storagePools {
+		storagePoolsPost := api.StoragePoolsPost{}
+		storagePoolsPost.Config = storagePool.Config
+		storagePoolsPost.Description = storagePool.Description
+		storagePoolsPost.Name = storagePool.Name
+		storagePoolsPost.Driver = storagePool.Driver
+
+		config.StoragePools = append(config.StoragePools, storagePoolsPost)
+		poolNames = append(poolNames, storagePool.Name)
+	}
+
+	config.StorageVolumes, err = initDataStorageVolumesDump(d, poolNames)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Profiles, err = initDataProfilesDump(d)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.HasExtension("projects") {
+		projects, err := d.GetProjects()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to retrieve current server configuration")
+		}
+
+		for _, p := range projects {
+			projectsPost := initDataProject{}
+			projectsPost.Config = p.Config
+			projectsPost.Description = p.Description
+			projectsPost.Name = p.Name
+
+			// The entities of the default project are listed at the top level, as are those of the
+			// projects which don't have their own.
+			if p.Name != project.Default {
+				projectServer := d.UseProject(p.Name)
+
+				if shared.IsTrue(p.Config["features.networks"]) {
+					projectsPost.Networks, err = initDataNetworksDump(projectServer)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				if shared.IsTrue(p.Config["features.storage.volumes"]) {
+					projectsPost.StorageVolumes, err = initDataStorageVolumesDump(projectServer, poolNames)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				if shared.IsTrue(p.Config["features.profiles"]) {
+					projectsPost.Profiles, err = initDataProfilesDump(projectServer)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+
+			config.Projects = append(config.Projects, projectsPost)
+		}
+	}
+
+	return &config, nil
+}
+
+// initDataNetworksDump returns the managed networks of the given server (or project).
+func initDataNetworksDump(d lxd.InstanceServer) ([]api.NetworksPost, error) {
 	networks, err := d.GetNetworks()
 	if err != nil {
-		return errors.Wrap(err, "Failed to retrieve current server configuration")
+		return nil, errors.Wrap(err, "Failed to retrieve current server configuration")
 	}
 
+	result := []api.NetworksPost{}
 	for _, network := range networks {
 		// Only list managed networks
 		if !network.Managed {
@@ -35,29 +137,62 @@ func (c *cmdInit) RunDump(d lxd.InstanceServer) error {
 		networksPost.Name = network.Name
 		networksPost.Type = network.Type
 
-		config.Networks = append(config.Networks, networksPost)
+		result = append(result, networksPost)
 	}
 
-	storagePools, err := d.GetStoragePools()
-	if err != nil {
-		return errors.Wrap(err, "Failed to retrieve current server configuration")
-	}
+	return result, nil
+}
 
-	for _, storagePool := range storagePools {
-		storagePoolsPost := api.StoragePoolsPost{}
-		storagePoolsPost.Config = storagePool.Config
-		storagePoolsPost.Description = storagePool.Description
-		storagePoolsPost.Name = storagePool.Name
-		storagePoolsPost.Driver = storagePool.Driver
+// initDataStorageVolumesDump returns the custom storage volumes of the given server (or project) on the given
+// storage pools.
+func initDataStorageVolumesDump(d lxd.InstanceServer, poolNames []string) ([]initDataStorageVolume, error) {
+	result := []initDataStorageVolume{}
+	for _, poolName := range poolNames {
+		volumes, err := d.GetStoragePoolVolumes(poolName)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to retrieve current server configuration")
+		}
 
-		config.StoragePools = append(config.StoragePools, storagePoolsPost)
+		// Volumes of local pools are listed once per cluster member.
+		seen := map[string]bool{}
+
+		for _, volume := range volumes {
+			if volume.Type != "custom" || shared.IsSnapshot(volume.Name) || seen[volume.Name] {
+				continue
+			}
+
+			seen[volume.Name] = true
+
+			// Volatile keys are managed by LXD itself.
+			config := map[string]string{}
+			for k, v := range volume.Config {
+				if !strings.HasPrefix(k, "volatile.") {
+					config[k] = v
+				}
+			}
+
+			volumesPost := initDataStorageVolume{Pool: poolName}
+			volumesPost.Config = config
+			volumesPost.Description = volume.Description
+			volumesPost.Name = volume.Name
+			volumesPost.Type = volume.Type
+			volumesPost.ContentType = volume.ContentType
+
+			result = append(result, volumesPost)
+		}
 	}
 
+	return result, nil
+}
+
+// initDataProfilesDump returns the profiles of the given server (or project).
+func initDataProfilesDump(d lxd.InstanceServer) ([]api.ProfilesPost, error) {
 	profiles, err := d.GetProfiles()
 	if err != nil {
-		return errors.Wrap(err, "Failed to retrieve current server configuration")
+		return nil, errors.Wrap(err, "Failed to retrieve current server configuration")
 	}
 
+	result := []api.ProfilesPost{}
 	for _, profile := range profiles {
 		profilesPost := api.ProfilesPost{}
 		profilesPost.Config = profile.Config
@@ -65,15 +200,8 @@ func (c *cmdInit) RunDump(d lxd.InstanceServer) error {
 		profilesPost.Devices = profile.Devices
 		profilesPost.Name = profile.Name
 
-		config.Profiles = append(config.Profiles, profilesPost)
+		result = append(result, profilesPost)
 	}
 
-	out, err := yaml.Marshal(config)
-	if err != nil {
-		return errors.Wrap(err, "Failed to retrieve current server configuration")
-	}
-
-	fmt.Printf("%s\n", out)
-
-	return nil
+	return result, nil
 }
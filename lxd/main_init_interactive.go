@@ -137,7 +137,43 @@ func (c *cmdInit) askClustering(config *cmdInitData, d lxd.InstanceServer) error
 		if cli.AskBool("Are you joining an existing cluster? (yes/no) [default=no]: ", "no") {
 			// Existing cluster
 			config.Cluster.ServerAddress = serverAddress
-			for {
+
+			useToken := cli.AskBool("Do you have a join token? (yes/no) [default=no]: ", "no")
+			for useToken {
+				joinToken, err := shared.ClusterJoinTokenDecode(cli.AskString("Please provide the join token: ", "", nil))
+				if err != nil {
+					fmt.Printf("Invalid join token: %v\n", err)
+					continue
+				}
+
+				// The token determines the name of the new member.
+				config.Cluster.ServerName = joinToken.ServerName
+
+				// Find a reachable cluster member with the expected certificate.
+				for _, clusterAddress := range joinToken.Addresses {
+					cert, err := shared.GetRemoteCertificate(fmt.Sprintf("https://%s", clusterAddress), version.UserAgent)
+					if err != nil {
+						continue
+					}
+
+					if shared.CertFingerprint(cert) != joinToken.Fingerprint {
+						return fmt.Errorf("Certificate fingerprint mismatch between join token and cluster member %q", clusterAddress)
+					}
+
+					config.Cluster.ClusterAddress = clusterAddress
+					config.Cluster.ClusterCertificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+					break
+				}
+
+				if config.Cluster.ClusterCertificate == "" {
+					return fmt.Errorf("Unable to connect to any of the cluster members specified in the join token")
+				}
+
+				config.Cluster.ClusterPassword = joinToken.Secret
+				break
+			}
+
+			for !useToken {
 				// Cluster URL
 				clusterAddress := cli.AskString("IP address or FQDN of an existing cluster node: ", "", nil)
 				_, _, err := net.SplitHostPort(clusterAddress)
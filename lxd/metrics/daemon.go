@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// tracker accumulates the number and the total duration of events, keyed by a label value.
+type tracker struct {
+	mu        sync.Mutex
+	counts    map[string]float64
+	durations map[string]float64
+}
+
+func newTracker() *tracker {
+	return &tracker{
+		counts:    map[string]float64{},
+		durations: map[string]float64{},
+	}
+}
+
+func (t *tracker) track(key string, duration time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.counts[key]++
+	t.durations[key] += duration.Seconds()
+}
+
+// samples returns the count and duration samples, labelled with the given label name unless empty.
+func (t *tracker) samples(label string) ([]Sample, []Sample) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys := make([]string, 0, len(t.counts))
+	for key := range t.counts {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	counts := make([]Sample, 0, len(keys))
+	durations := make([]Sample, 0, len(keys))
+	for _, key := range keys {
+		var labels map[string]string
+		if label != "" {
+			labels = map[string]string{label: key}
+		}
+
+		counts = append(counts, Sample{Labels: labels, Value: t.counts[key]})
+		durations = append(durations, Sample{Labels: labels, Value: t.durations[key]})
+	}
+
+	return counts, durations
+}
+
+var apiRequests = newTracker()
+var dbTransactions = newTracker()
+var heartbeats = newTracker()
+
+// heartbeatsLast holds the time of the last successful heartbeat of each cluster member, by address.
+var heartbeatsLast = map[string]time.Time{}
+var heartbeatsLastLock sync.Mutex
+
+// TrackAPIRequest records an API request with the given method and handling time.
+func TrackAPIRequest(method string, duration time.Duration) {
+	apiRequests.track(method, duration)
+}
+
+// TrackDBTransaction records a cluster database transaction with the given duration.
+func TrackDBTransaction(duration time.Duration) {
+	dbTransactions.track("", duration)
+}
+
+// TrackHeartbeat records a successful heartbeat sent to the cluster member with the given address, holding
+// either the changes since the last heartbeat or the full cluster state.
+func TrackHeartbeat(address string, differential bool, duration time.Duration) {
+	kind := "full"
+	if differential {
+		kind = "differential"
+	}
+
+	heartbeats.track(kind, duration)
+
+	heartbeatsLastLock.Lock()
+	heartbeatsLast[address] = time.Now()
+	heartbeatsLastLock.Unlock()
+}
+
+// ForgetHeartbeats drops the heartbeat times of the cluster members which aren't in the given list of addresses.
+func ForgetHeartbeats(addresses []string) {
+	keep := map[string]bool{}
+	for _, address := range addresses {
+		keep[address] = true
+	}
+
+	heartbeatsLastLock.Lock()
+	defer heartbeatsLastLock.Unlock()
+
+	for address := range heartbeatsLast {
+		if !keep[address] {
+			delete(heartbeatsLast, address)
+		}
+	}
+}
+
+// heartbeatsLastSamples returns the time since the last successful heartbeat of each cluster member.
+func heartbeatsLastSamples() []Sample {
+	heartbeatsLastLock.Lock()
+	defer heartbeatsLastLock.Unlock()
+
+	addresses := make([]string, 0, len(heartbeatsLast))
+	for address := range heartbeatsLast {
+		addresses = append(addresses, address)
+	}
+
+	sort.Strings(addresses)
+
+	samples := make([]Sample, 0, len(addresses))
+	for _, address := range addresses {
+		samples = append(samples, Sample{Labels: map[string]string{"address": address}, Value: time.Since(heartbeatsLast[address]).Seconds()})
+	}
+
+	return samples
+}
+
+// DaemonMetrics returns the API request, database transaction and cluster heartbeat metrics collected since
+// startup.
+func DaemonMetrics() *MetricSet {
+	set := NewMetricSet(nil)
+
+	counts, durations := apiRequests.samples("method")
+	set.AddSamples(APIRequestsTotal, counts...)
+	set.AddSamples(APIRequestDurationSecondsTotal, durations...)
+
+	counts, durations = dbTransactions.samples("")
+	set.AddSamples(DBTransactionsTotal, counts...)
+	set.AddSamples(DBTransactionDurationSecondsTotal, durations...)
+
+	counts, durations = heartbeats.samples("type")
+	set.AddSamples(ClusterHeartbeatsTotal, counts...)
+	set.AddSamples(ClusterHeartbeatDurationSecondsTotal, durations...)
+	set.AddSamples(ClusterMemberLastHeartbeatSeconds, heartbeatsLastSamples()...)
+
+	return set
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the content type of the OpenMetrics text exposition format.
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// NewMetricSet returns a new MetricSet. The given labels are added to all of its samples.
+func NewMetricSet(labels map[string]string) *MetricSet {
+	return &MetricSet{
+		set:    map[MetricType][]Sample{},
+		labels: labels,
+	}
+}
+
+// AddSamples adds samples of the given metric type to the set.
+func (m *MetricSet) AddSamples(metricType MetricType, samples ...Sample) {
+	for _, sample := range samples {
+		labels := map[string]string{}
+
+		for k, v := range m.labels {
+			labels[k] = v
+		}
+
+		for k, v := range sample.Labels {
+			labels[k] = v
+		}
+
+		m.set[metricType] = append(m.set[metricType], Sample{Labels: labels, Value: sample.Value})
+	}
+}
+
+// Merge adds the samples of the given set to this one.
+func (m *MetricSet) Merge(metricSet *MetricSet) {
+	if metricSet == nil {
+		return
+	}
+
+	for metricType, samples := range metricSet.set {
+		m.set[metricType] = append(m.set[metricType], samples...)
+	}
+}
+
+// String renders the set in the OpenMetrics text format.
+func (m *MetricSet) String() string {
+	metricTypes := make([]int, 0, len(m.set))
+	for metricType := range m.set {
+		metricTypes = append(metricTypes, int(metricType))
+	}
+
+	sort.Ints(metricTypes)
+
+	var out strings.Builder
+
+	for _, metricType := range metricTypes {
+		info := metricInfos[MetricType(metricType)]
+		samples := m.set[MetricType(metricType)]
+
+		if len(samples) == 0 {
+			continue
+		}
+
+		// Counter families are named without their _total suffix.
+		family := info.name
+		kind := "gauge"
+		if info.counter {
+			family = strings.TrimSuffix(family, "_total")
+			kind = "counter"
+		}
+
+		fmt.Fprintf(&out, "# HELP %s %s\n", family, info.help)
+		fmt.Fprintf(&out, "# TYPE %s %s\n", family, kind)
+
+		for _, sample := range samples {
+			fmt.Fprintf(&out, "%s%s %s\n", info.name, renderLabels(sample.Labels), strconv.FormatFloat(sample.Value, 'f', -1, 64))
+		}
+	}
+
+	out.WriteString("# EOF\n")
+
+	return out.String()
+}
+
+// renderLabels returns the label set in the exposition format, sorted by label name.
+func renderLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, replacer.Replace(labels[name])))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(pairs, ","))
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricSet_String(t *testing.T) {
+	set := NewMetricSet(map[string]string{"project": "default", "name": "c1"})
+	set.AddSamples(MemoryUsageBytes, Sample{Value: 1024})
+	set.AddSamples(CPUSecondsTotal, Sample{Value: 1.5})
+	set.AddSamples(NetworkReceiveBytesTotal, Sample{Labels: map[string]string{"device": "eth\"0"}, Value: 10})
+
+	expected := `# HELP lxd_cpu_seconds The total CPU time used in seconds.
+# TYPE lxd_cpu_seconds counter
+lxd_cpu_seconds_total{name="c1",project="default"} 1.5
+# HELP lxd_memory_usage_bytes The memory used in bytes.
+# TYPE lxd_memory_usage_bytes gauge
+lxd_memory_usage_bytes{name="c1",project="default"} 1024
+# HELP lxd_network_receive_bytes The amount of received bytes on a given interface.
+# TYPE lxd_network_receive_bytes counter
+lxd_network_receive_bytes_total{device="eth\"0",name="c1",project="default"} 10
+# EOF
+`
+
+	assert.Equal(t, expected, set.String())
+}
+
+func TestMetricSet_Merge(t *testing.T) {
+	set := NewMetricSet(nil)
+	set.AddSamples(Processes, Sample{Labels: map[string]string{"name": "c1"}, Value: 3})
+
+	other := NewMetricSet(map[string]string{"name": "c2"})
+	other.AddSamples(Processes, Sample{Value: 5})
+
+	set.Merge(other)
+
+	assert.Equal(t, `# HELP lxd_processes The number of running processes.
+# TYPE lxd_processes gauge
+lxd_processes{name="c1"} 3
+lxd_processes{name="c2"} 5
+# EOF
+`, set.String())
+}
+
+func TestTracker(t *testing.T) {
+	tracker := newTracker()
+	tracker.track("GET", time.Second)
+	tracker.track("GET", 2*time.Second)
+	tracker.track("POST", time.Second)
+
+	counts, durations := tracker.samples("method")
+	assert.Equal(t, []Sample{
+		{Labels: map[string]string{"method": "GET"}, Value: 2},
+		{Labels: map[string]string{"method": "POST"}, Value: 1},
+	}, counts)
+	assert.Equal(t, []Sample{
+		{Labels: map[string]string{"method": "GET"}, Value: 3},
+		{Labels: map[string]string{"method": "POST"}, Value: 1},
+	}, durations)
+}
+
+func TestTrackHeartbeat(t *testing.T) {
+	TrackHeartbeat("10.0.0.2:8443", true, time.Second)
+	TrackHeartbeat("10.0.0.3:8443", false, time.Second)
+
+	samples := heartbeatsLastSamples()
+	assert.Len(t, samples, 2)
+	assert.Equal(t, map[string]string{"address": "10.0.0.2:8443"}, samples[0].Labels)
+
+	ForgetHeartbeats([]string{"10.0.0.3:8443"})
+
+	samples = heartbeatsLastSamples()
+	assert.Len(t, samples, 1)
+	assert.Equal(t, map[string]string{"address": "10.0.0.3:8443"}, samples[0].Labels)
+}
@@ -0,0 +1,83 @@
+package metrics
+
+// MetricType is a numeric code identifying the metric.
+type MetricType int
+
+const (
+	// CPUSecondsTotal represents the total CPU time used by an instance.
+	CPUSecondsTotal MetricType = iota
+	// MemoryUsageBytes represents the memory used by an instance.
+	MemoryUsageBytes
+	// MemoryUsagePeakBytes represents the peak memory used by an instance.
+	MemoryUsagePeakBytes
+	// MemorySwapUsageBytes represents the swap used by an instance.
+	MemorySwapUsageBytes
+	// DiskUsageBytes represents the disk space used by an instance's disk devices.
+	DiskUsageBytes
+	// NetworkReceiveBytesTotal represents the bytes received by an instance's network interfaces.
+	NetworkReceiveBytesTotal
+	// NetworkTransmitBytesTotal represents the bytes sent by an instance's network interfaces.
+	NetworkTransmitBytesTotal
+	// NetworkReceivePacketsTotal represents the packets received by an instance's network interfaces.
+	NetworkReceivePacketsTotal
+	// NetworkTransmitPacketsTotal represents the packets sent by an instance's network interfaces.
+	NetworkTransmitPacketsTotal
+	// Processes represents the number of processes running in an instance.
+	Processes
+	// Operations represents the number of operations known to the daemon.
+	Operations
+	// APIRequestsTotal represents the number of API requests handled by the daemon.
+	APIRequestsTotal
+	// APIRequestDurationSecondsTotal represents the time spent handling API requests.
+	APIRequestDurationSecondsTotal
+	// DBTransactionsTotal represents the number of cluster database transactions.
+	DBTransactionsTotal
+	// DBTransactionDurationSecondsTotal represents the time spent in cluster database transactions.
+	DBTransactionDurationSecondsTotal
+	// ClusterHeartbeatsTotal represents the number of heartbeats sent to the cluster members.
+	ClusterHeartbeatsTotal
+	// ClusterHeartbeatDurationSecondsTotal represents the time spent sending heartbeats to the cluster members.
+	ClusterHeartbeatDurationSecondsTotal
+	// ClusterMemberLastHeartbeatSeconds represents the time since the last heartbeat of a cluster member.
+	ClusterMemberLastHeartbeatSeconds
+)
+
+// metricInfo holds the exported name, help text and kind of a metric.
+type metricInfo struct {
+	name    string
+	help    string
+	counter bool
+}
+
+var metricInfos = map[MetricType]metricInfo{
+	CPUSecondsTotal:                      {"lxd_cpu_seconds_total", "The total CPU time used in seconds.", true},
+	MemoryUsageBytes:                     {"lxd_memory_usage_bytes", "The memory used in bytes.", false},
+	MemoryUsagePeakBytes:                 {"lxd_memory_usage_peak_bytes", "The peak memory used in bytes.", false},
+	MemorySwapUsageBytes:                 {"lxd_memory_swap_usage_bytes", "The swap used in bytes.", false},
+	DiskUsageBytes:                       {"lxd_disk_usage_bytes", "The disk space used in bytes.", false},
+	NetworkReceiveBytesTotal:             {"lxd_network_receive_bytes_total", "The amount of received bytes on a given interface.", true},
+	NetworkTransmitBytesTotal:            {"lxd_network_transmit_bytes_total", "The amount of transmitted bytes on a given interface.", true},
+	NetworkReceivePacketsTotal:           {"lxd_network_receive_packets_total", "The amount of received packets on a given interface.", true},
+	NetworkTransmitPacketsTotal:          {"lxd_network_transmit_packets_total", "The amount of transmitted packets on a given interface.", true},
+	Processes:                            {"lxd_processes", "The number of running processes.", false},
+	Operations:                           {"lxd_operations", "The number of operations by project and status.", false},
+	APIRequestsTotal:                     {"lxd_api_requests_total", "The number of API requests handled.", true},
+	APIRequestDurationSecondsTotal:       {"lxd_api_request_duration_seconds_total", "The time spent handling API requests in seconds.", true},
+	DBTransactionsTotal:                  {"lxd_db_transactions_total", "The number of cluster database transactions.", true},
+	DBTransactionDurationSecondsTotal:    {"lxd_db_transaction_duration_seconds_total", "The time spent in cluster database transactions in seconds.", true},
+	ClusterHeartbeatsTotal:               {"lxd_cluster_heartbeats_total", "The number of successful heartbeats sent to the cluster members.", true},
+	ClusterHeartbeatDurationSecondsTotal: {"lxd_cluster_heartbeat_duration_seconds_total", "The time spent sending successful heartbeats to the cluster members in seconds.", true},
+	ClusterMemberLastHeartbeatSeconds:    {"lxd_cluster_member_last_heartbeat_seconds", "The time since the last successful heartbeat of a cluster member in seconds.", false},
+}
+
+// Sample represents a single sample of a metric.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// MetricSet represents a set of metrics.
+type MetricSet struct {
+	set    map[MetricType][]Sample
+	labels map[string]string
+}
@@ -42,6 +42,10 @@ type migrationFields struct {
 	instanceOnly bool
 	instance     instance.Instance
 
+	// Set when both sides access the same storage volume, in which case only the
+	// state of a live migrated VM is transferred.
+	sharedStorage bool
+
 	// storage specific fields
 	volumeOnly bool
 }
@@ -130,6 +134,26 @@ func (c *migrationFields) controlChannel() <-chan migration.MigrationControl {
 	return ch
 }
 
+// migrationWaitConnected waits for all the migration websockets to be connected, failing if the operation gets
+// cancelled first.
+func migrationWaitConnected(op *operations.Operation, allConnected chan bool) error {
+	select {
+	case <-allConnected:
+		return nil
+	case <-op.Context().Done():
+		return fmt.Errorf("Migration cancelled before all websockets were connected")
+	}
+}
+
+// migrationWatchCancel calls disconnect once the operation gets cancelled. Closing the websockets makes any
+// ongoing transfer fail, so that the migration reverts its changes.
+func migrationWatchCancel(op *operations.Operation, disconnect func()) {
+	go func() {
+		<-op.Context().Done()
+		disconnect()
+	}()
+}
+
 type migrationSourceWs struct {
 	migrationFields
 
@@ -266,12 +290,13 @@ type MigrationSinkArgs struct {
 	Url     string
 
 	// Instance specific fields
-	Instance     instance.Instance
-	InstanceOnly bool
-	Idmap        *idmap.IdmapSet
-	Live         bool
-	Refresh      bool
-	Snapshots    []*migration.Snapshot
+	Instance      instance.Instance
+	InstanceOnly  bool
+	Idmap         *idmap.IdmapSet
+	Live          bool
+	Refresh       bool
+	Snapshots     []*migration.Snapshot
+	SharedStorage bool
 
 	// Storage specific fields
 	VolumeOnly bool
@@ -47,13 +47,11 @@ func newMigrationSource(inst instance.Instance, stateful bool, instanceOnly bool
 	}
 
 	if stateful && inst.IsRunning() {
-		if inst.Type() == instancetype.VM {
-			return nil, errors.Wrap(storagePools.ErrNotImplemented, "Unable to perform VM live migration")
-		}
-
-		_, err := exec.LookPath("criu")
-		if err != nil {
-			return nil, fmt.Errorf("Unable to perform container live migration. CRIU isn't installed on the source server")
+		if inst.Type() == instancetype.Container {
+			_, err := exec.LookPath("criu")
+			if err != nil {
+				return nil, fmt.Errorf("Unable to perform container live migration. CRIU isn't installed on the source server")
+			}
 		}
 
 		ret.live = true
@@ -334,7 +332,12 @@ func (s *migrationSourceWs) preDumpLoop(state *state.State, args *preDumpLoopArg
 }
 
 func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operation) error {
-	<-s.allConnected
+	err := migrationWaitConnected(migrateOp, s.allConnected)
+	if err != nil {
+		return err
+	}
+
+	migrationWatchCancel(migrateOp, s.disconnect)
 
 	var offerHeader migration.MigrationHeader
 	var poolMigrationTypes []migration.Type
@@ -422,7 +425,7 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 	// Add predump info to source header.
 	offerUsePreDumps := false
 	maxDumpIterations := 0
-	if s.live {
+	if s.live && s.instance.Type() == instancetype.Container {
 		offerUsePreDumps, maxDumpIterations = s.checkForPreDumpSupport()
 	}
 
@@ -472,7 +475,8 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 	// If s.live is true or Criu is set to CRIUTYPE_NONE rather than nil, it indicates that the
 	// source instance is running and that we should do a two stage transfer to minimize downtime.
 	// Indicate this info to the storage driver so that it can alter its behaviour if needed.
-	volSourceArgs.MultiSync = s.live || (respHeader.Criu != nil && *respHeader.Criu == migration.CRIUType_NONE)
+	// Live migrated VMs don't need a final sync as QEMU sends the disks changes along with their state.
+	volSourceArgs.MultiSync = (s.live && s.instance.Type() == instancetype.Container) || (respHeader.Criu != nil && *respHeader.Criu == migration.CRIUType_NONE)
 
 	rsyncBwlimit = pool.Driver().Config()["rsync.bwlimit"]
 	migrationTypes, err = migration.MatchTypes(respHeader, migration.MigrationFSType_RSYNC, poolMigrationTypes)
@@ -492,15 +496,33 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 	volSourceArgs.MigrationType = migrationTypes[0]
 	volSourceArgs.Snapshots = sendSnapshotNames
 	volSourceArgs.TrackProgress = true
-	err = pool.MigrateInstance(s.instance, &shared.WebsocketIO{Conn: s.fsConn}, volSourceArgs, migrateOp)
-	if err != nil {
-		return abort(err)
+
+	// With shared storage, the target already has access to the volume.
+	if !s.sharedStorage {
+		err = pool.MigrateInstance(s.instance, &shared.WebsocketIO{Conn: s.fsConn}, volSourceArgs, migrateOp)
+		if err != nil {
+			return abort(err)
+		}
 	}
 
 	restoreSuccess := make(chan bool, 1)
 	dumpSuccess := make(chan error, 1)
 
-	if s.live {
+	if s.live && s.instance.Type() == instancetype.VM {
+		if respHeader.Criu == nil || *respHeader.Criu != migration.CRIUType_CRIU_RSYNC {
+			return abort(fmt.Errorf("Target doesn't support VM live migration"))
+		}
+
+		// Stream the VM state over the CRIU connection, the VM is left paused on success.
+		vm := s.instance.(instance.VM)
+		err = vm.MigrateSend(&shared.WebsocketIO{Conn: s.criuConn}, s.sharedStorage)
+		if err != nil {
+			vm.MigrateAbort()
+			return abort(err)
+		}
+
+		dumpSuccess <- nil
+	} else if s.live {
 		if respHeader.Criu == nil {
 			return abort(fmt.Errorf("Got no CRIU socket type for live migration"))
 		} else if *respHeader.Criu != migration.CRIUType_CRIU_RSYNC {
@@ -692,6 +714,7 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 	err = s.recv(&msg)
 	if err != nil {
 		s.disconnect()
+		s.liveMigrationDone(false)
 		return err
 	}
 
@@ -703,6 +726,11 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 		}
 	}
 
+	err = s.liveMigrationDone(*msg.Success)
+	if err != nil {
+		logger.Errorf("Failed finalizing VM live migration: %v", err)
+	}
+
 	if !*msg.Success {
 		return fmt.Errorf(*msg.Message)
 	}
@@ -710,6 +738,21 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 	return nil
 }
 
+// liveMigrationDone stops the source VM once the target reported a successful live migration, or
+// resumes it otherwise.
+func (s *migrationSourceWs) liveMigrationDone(success bool) error {
+	if !s.live || s.instance.Type() != instancetype.VM {
+		return nil
+	}
+
+	vm := s.instance.(instance.VM)
+	if !success {
+		return vm.MigrateAbort()
+	}
+
+	return vm.Stop(false)
+}
+
 func newMigrationSink(args *MigrationSinkArgs) (*migrationSink, error) {
 	sink := migrationSink{
 		src:     migrationFields{instance: args.Instance, instanceOnly: args.InstanceOnly},
@@ -759,6 +802,13 @@ func newMigrationSink(args *MigrationSinkArgs) (*migrationSink, error) {
 		sink.src.live = ok
 	}
 
+	sink.src.sharedStorage = args.SharedStorage
+
+	// Live migrated VMs use QEMU rather than CRIU.
+	if args.Instance != nil && args.Instance.Type() == instancetype.VM {
+		return &sink, nil
+	}
+
 	_, err = exec.LookPath("criu")
 	if sink.push && sink.dest.live && err != nil {
 		return nil, fmt.Errorf("Unable to perform container live migration. CRIU isn't installed on the destination server")
@@ -773,7 +823,10 @@ func (c *migrationSink) Do(state *state.State, migrateOp *operations.Operation)
 	var err error
 
 	if c.push {
-		<-c.allConnected
+		err = migrationWaitConnected(migrateOp, c.allConnected)
+		if err != nil {
+			return err
+		}
 	}
 
 	disconnector := c.src.disconnect
@@ -805,6 +858,8 @@ func (c *migrationSink) Do(state *state.State, migrateOp *operations.Operation)
 		}
 	}
 
+	migrationWatchCancel(migrateOp, disconnector)
+
 	receiver := c.src.recv
 	if c.push {
 		receiver = c.dest.recv
@@ -1028,8 +1083,9 @@ func (c *migrationSink) Do(state *state.State, migrateOp *operations.Operation)
 
 			// If we are doing a stateful live transfer or the CRIU type indicates we
 			// are doing a stateless transfer with a running instance then we should
-			// expect the source to send us a final rootfs sync.
-			if live {
+			// expect the source to send us a final rootfs sync. Live migrated VMs get
+			// their disks changes as part of the QEMU stream instead.
+			if live && c.src.instance.Type() == instancetype.Container {
 				sendFinalFsDelta = true
 			}
 
@@ -1048,6 +1104,12 @@ func (c *migrationSink) Do(state *state.State, migrateOp *operations.Operation)
 				VolumeSize:    offerHeader.GetVolumeSize(),
 			}
 
+			// With shared storage, the volume is already accessible.
+			if c.src.sharedStorage {
+				fsTransfer <- nil
+				return
+			}
+
 			err = myTarget(fsConn, migrateOp, args)
 			if err != nil {
 				fsTransfer <- err
@@ -1069,6 +1131,27 @@ func (c *migrationSink) Do(state *state.State, migrateOp *operations.Operation)
 			fsTransfer <- nil
 		}()
 
+		if live && c.src.instance.Type() == instancetype.VM {
+			var criuConn *websocket.Conn
+			if c.push {
+				criuConn = c.dest.criuConn
+			} else {
+				criuConn = c.src.criuConn
+			}
+
+			// The disks must be in place before QEMU opens them.
+			err := <-fsTransfer
+			if err != nil {
+				restore <- err
+				return
+			}
+
+			// Start the VM from the streamed state, QEMU is killed on failure.
+			vm := c.src.instance.(instance.VM)
+			restore <- vm.MigrateReceive(&shared.WebsocketIO{Conn: criuConn})
+			return
+		}
+
 		if live {
 			var err error
 			imagesDir, err = ioutil.TempDir("", "lxd_restore_")
@@ -37,8 +37,13 @@ func newStorageMigrationSource(volumeOnly bool) (*migrationSourceWs, error) {
 }
 
 func (s *migrationSourceWs) DoStorage(state *state.State, projectName string, poolName string, volName string, migrateOp *operations.Operation) error {
-	<-s.allConnected
+	err := migrationWaitConnected(migrateOp, s.allConnected)
+	if err != nil {
+		return err
+	}
+
 	defer s.disconnect()
+	migrationWatchCancel(migrateOp, s.disconnect)
 
 	var offerHeader migration.MigrationHeader
 	var poolMigrationTypes []migration.Type
@@ -203,7 +208,10 @@ func (c *migrationSink) DoStorage(state *state.State, projectName string, poolNa
 	var err error
 
 	if c.push {
-		<-c.allConnected
+		err = migrationWaitConnected(op, c.allConnected)
+		if err != nil {
+			return err
+		}
 	}
 
 	disconnector := c.src.disconnect
@@ -229,6 +237,8 @@ func (c *migrationSink) DoStorage(state *state.State, projectName string, poolNa
 		}
 	}
 
+	migrationWatchCancel(op, disconnector)
+
 	receiver := c.src.recv
 	if c.push {
 		receiver = c.dest.recv
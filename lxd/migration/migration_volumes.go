@@ -3,6 +3,8 @@ package migration
 import (
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/shared"
@@ -206,6 +208,14 @@ func progressWrapperRender(op *operations.Operation, key string, description str
 
 	if meta[key] != progress {
 		meta[key] = progress
+
+		// Structured progress for API callers.
+		meta["progress"] = map[string]string{
+			"stage":     strings.TrimSuffix(key, "_progress"),
+			"processed": strconv.FormatInt(progressInt, 10),
+			"speed":     strconv.FormatInt(speedInt, 10),
+		}
+
 		op.UpdateMetadata(meta)
 	}
 }
@@ -0,0 +1,98 @@
+package acl
+
+import (
+	"github.com/pkg/errors"
+
+	firewallDrivers "github.com/lxc/lxd/lxd/firewall/drivers"
+	"github.com/lxc/lxd/lxd/state"
+)
+
+// ParseNames returns the ACL names listed in a security.acls config value.
+func ParseNames(value string) []string {
+	return splitList(value)
+}
+
+// NetworkApply applies the ACLs referenced by the network config to the traffic routed in and out of the network.
+// If the config doesn't reference any ACL, the previously applied ACL rules are removed.
+func NetworkApply(s *state.State, networkName string, config map[string]string) error {
+	names := ParseNames(config["security.acls"])
+	if len(names) == 0 {
+		return s.Firewall.NetworkClearACLRules(networkName)
+	}
+
+	rules, err := FirewallRules(s, names, config["security.acls.default.ingress.action"], config["security.acls.default.egress.action"])
+	if err != nil {
+		return err
+	}
+
+	return s.Firewall.NetworkApplyACLRules(networkName, rules)
+}
+
+// InstanceDeviceApply applies the ACLs referenced by the NIC config to the traffic of its host side interface.
+// If the config doesn't reference any ACL, the previously applied ACL rules are removed.
+func InstanceDeviceApply(s *state.State, projectName string, instanceName string, deviceName string, hostName string, config map[string]string) error {
+	names := ParseNames(config["security.acls"])
+	if len(names) == 0 {
+		return s.Firewall.InstanceClearACLRules(projectName, instanceName, deviceName)
+	}
+
+	rules, err := FirewallRules(s, names, config["security.acls.default.ingress.action"], config["security.acls.default.egress.action"])
+	if err != nil {
+		return err
+	}
+
+	return s.Firewall.InstanceSetupACLRules(projectName, instanceName, deviceName, hostName, rules)
+}
+
+// FirewallRules returns the enabled rules of the ACLs with the given names, in the order the ACLs are listed,
+// followed by the rules applying the default actions (reject if empty) to the traffic not matched by any rule.
+func FirewallRules(s *state.State, names []string, defaultIngressAction string, defaultEgressAction string) ([]firewallDrivers.ACLRule, error) {
+	rules := []firewallDrivers.ACLRule{}
+
+	for _, name := range names {
+		_, info, err := s.Cluster.GetNetworkACL(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed loading network ACL %q", name)
+		}
+
+		for _, direction := range []string{"ingress", "egress"} {
+			aclRules := info.Ingress
+			if direction == "egress" {
+				aclRules = info.Egress
+			}
+
+			for _, rule := range aclRules {
+				if rule.State == "disabled" {
+					continue
+				}
+
+				rules = append(rules, firewallDrivers.ACLRule{
+					Direction:       direction,
+					Action:          rule.Action,
+					Source:          splitList(rule.Source),
+					Destination:     splitList(rule.Destination),
+					Protocol:        rule.Protocol,
+					SourcePort:      splitList(rule.SourcePort),
+					DestinationPort: splitList(rule.DestinationPort),
+					ICMPType:        rule.ICMPType,
+					ICMPCode:        rule.ICMPCode,
+				})
+			}
+		}
+	}
+
+	if defaultIngressAction == "" {
+		defaultIngressAction = "reject"
+	}
+
+	if defaultEgressAction == "" {
+		defaultEgressAction = "reject"
+	}
+
+	rules = append(rules,
+		firewallDrivers.ACLRule{Direction: "ingress", Action: defaultIngressAction},
+		firewallDrivers.ACLRule{Direction: "egress", Action: defaultEgressAction},
+	)
+
+	return rules, nil
+}
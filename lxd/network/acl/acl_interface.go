@@ -0,0 +1,24 @@
+package acl
+
+import (
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// NetworkACL represents a LXD network ACL.
+type NetworkACL interface {
+	// Load.
+	init(state *state.State, id int64, info *api.NetworkACL)
+
+	// Info.
+	ID() int64
+	Name() string
+	Info() *api.NetworkACL
+	Etag() []interface{}
+	UsedBy() ([]string, error)
+
+	// Modifications.
+	Update(config *api.NetworkACLPut, clusterNotification bool) error
+	Rename(newName string) error
+	Delete() error
+}
@@ -0,0 +1,69 @@
+package acl
+
+import (
+	"fmt"
+
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// LoadByName loads and initialises a network ACL from the database by name.
+func LoadByName(s *state.State, name string) (NetworkACL, error) {
+	id, aclInfo, err := s.Cluster.GetNetworkACL(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var acl NetworkACL = &common{}
+	acl.init(s, id, aclInfo)
+
+	return acl, nil
+}
+
+// Create validates the supplied ACL and creates it in the database.
+func Create(s *state.State, aclInfo *api.NetworkACLsPost) error {
+	err := ValidName(aclInfo.Name)
+	if err != nil {
+		return err
+	}
+
+	err = validateConfig(&aclInfo.NetworkACLPut)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Cluster.CreateNetworkACL(aclInfo)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Exists checks that all the ACLs with the given names exist.
+func Exists(s *state.State, names ...string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	existing, err := s.Cluster.GetNetworkACLs()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		found := false
+		for _, existingName := range existing {
+			if name == existingName {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("Network ACL %q does not exist", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,458 @@
+package acl
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/logging"
+	"github.com/lxc/lxd/shared/validate"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// validActions are the actions an ACL rule can take on matching traffic.
+var validActions = []string{"allow", "reject", "drop"}
+
+// common represents a LXD network ACL.
+type common struct {
+	logger logger.Logger
+	state  *state.State
+	id     int64
+	info   *api.NetworkACL
+}
+
+// init initialise internal variables.
+func (d *common) init(state *state.State, id int64, info *api.NetworkACL) {
+	d.logger = logging.AddContext(logger.Log, log.Ctx{"networkACL": info.Name})
+	d.state = state
+	d.id = id
+	d.info = info
+}
+
+// ID returns the network ACL ID.
+func (d *common) ID() int64 {
+	return d.id
+}
+
+// Name returns the network ACL name.
+func (d *common) Name() string {
+	return d.info.Name
+}
+
+// Info returns a copy of the network ACL info.
+func (d *common) Info() *api.NetworkACL {
+	info := *d.info
+
+	return &info
+}
+
+// Etag returns the values used for etag generation.
+func (d *common) Etag() []interface{} {
+	return []interface{}{d.info.Name, d.info.Description, d.info.Ingress, d.info.Egress, d.info.Config}
+}
+
+// UsedBy returns the URLs of the networks, profiles and instances using the ACL.
+func (d *common) UsedBy() ([]string, error) {
+	usedBy := []string{}
+
+	// Look for networks using the ACL.
+	projectNetworks, err := d.state.Cluster.GetNetworksAllProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	for networkProject, networkNames := range projectNetworks {
+		for _, networkName := range networkNames {
+			_, netInfo, err := d.state.Cluster.GetNetworkInAnyState(networkProject, networkName)
+			if err != nil {
+				return nil, err
+			}
+
+			if !isInUseByConfig(netInfo.Config, d.info.Name) {
+				continue
+			}
+
+			uri := fmt.Sprintf("/%s/networks/%s", version.APIVersion, networkName)
+			if networkProject != project.Default {
+				uri += fmt.Sprintf("?project=%s", networkProject)
+			}
+
+			usedBy = append(usedBy, uri)
+		}
+	}
+
+	// Look for profiles using the ACL.
+	var profiles []db.Profile
+	err = d.state.Cluster.Transaction(func(tx *db.ClusterTx) error {
+		profiles, err = tx.GetProfiles(db.ProfileFilter{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, profile := range profiles {
+		if isInUseByDevices(profile.Devices, d.info.Name) {
+			uri := fmt.Sprintf("/%s/profiles/%s", version.APIVersion, profile.Name)
+			if profile.Project != project.Default {
+				uri += fmt.Sprintf("?project=%s", profile.Project)
+			}
+
+			usedBy = append(usedBy, uri)
+		}
+	}
+
+	// Look for instances using the ACL.
+	insts, err := instance.LoadFromAllProjects(d.state)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inst := range insts {
+		if isInUseByDevices(inst.LocalDevices().CloneNative(), d.info.Name) {
+			uri := fmt.Sprintf("/%s/instances/%s", version.APIVersion, inst.Name())
+			if inst.Project() != project.Default {
+				uri += fmt.Sprintf("?project=%s", inst.Project())
+			}
+
+			usedBy = append(usedBy, uri)
+		}
+	}
+
+	return usedBy, nil
+}
+
+// Update applies the supplied config to the ACL and re-applies the firewall rules of the networks and instance
+// devices using it on this member. If not a cluster notification, the other members are notified too.
+func (d *common) Update(config *api.NetworkACLPut, clusterNotification bool) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	if !clusterNotification {
+		err := validateConfig(config)
+		if err != nil {
+			return err
+		}
+
+		oldConfig := d.info.NetworkACLPut
+
+		err = d.state.Cluster.UpdateNetworkACL(d.id, config)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() {
+			d.state.Cluster.UpdateNetworkACL(d.id, &oldConfig)
+			d.info.NetworkACLPut = oldConfig
+			d.applyLocal()
+		})
+	}
+
+	d.info.NetworkACLPut = *config
+
+	err := d.applyLocal()
+	if err != nil {
+		return err
+	}
+
+	if !clusterNotification {
+		notifier, err := cluster.NewNotifier(d.state, d.state.Endpoints.NetworkCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client lxd.InstanceServer) error {
+			return client.UpdateNetworkACL(d.info.Name, *config, "")
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// Rename renames the ACL if it isn't in use.
+func (d *common) Rename(newName string) error {
+	err := ValidName(newName)
+	if err != nil {
+		return err
+	}
+
+	usedBy, err := d.UsedBy()
+	if err != nil {
+		return err
+	}
+
+	if len(usedBy) > 0 {
+		return fmt.Errorf("Cannot rename an ACL that is in use")
+	}
+
+	err = d.state.Cluster.RenameNetworkACL(d.id, newName)
+	if err != nil {
+		return err
+	}
+
+	d.info.Name = newName
+
+	return nil
+}
+
+// Delete deletes the ACL if it isn't in use.
+func (d *common) Delete() error {
+	usedBy, err := d.UsedBy()
+	if err != nil {
+		return err
+	}
+
+	if len(usedBy) > 0 {
+		return fmt.Errorf("Cannot delete an ACL that is in use")
+	}
+
+	return d.state.Cluster.DeleteNetworkACL(d.id)
+}
+
+// applyLocal re-applies the firewall rules of the networks and running instance devices on this member that use
+// the ACL.
+func (d *common) applyLocal() error {
+	// Bridge networks are always part of the default project.
+	networkNames, err := d.state.Cluster.GetNetworks(project.Default)
+	if err != nil {
+		return err
+	}
+
+	for _, networkName := range networkNames {
+		_, netInfo, err := d.state.Cluster.GetNetworkInAnyState(project.Default, networkName)
+		if err != nil {
+			return err
+		}
+
+		if netInfo.Type != "bridge" || !isInUseByConfig(netInfo.Config, d.info.Name) {
+			continue
+		}
+
+		if !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", networkName)) {
+			continue
+		}
+
+		err = NetworkApply(d.state, networkName, netInfo.Config)
+		if err != nil {
+			return errors.Wrapf(err, "Failed applying ACLs to network %q", networkName)
+		}
+	}
+
+	insts, err := instance.LoadNodeAll(d.state, instancetype.Any)
+	if err != nil {
+		return err
+	}
+
+	for _, inst := range insts {
+		if !inst.IsRunning() {
+			continue
+		}
+
+		for devName, devConfig := range inst.ExpandedDevices() {
+			if devConfig["type"] != "nic" || !isInUseByConfig(devConfig, d.info.Name) {
+				continue
+			}
+
+			hostName := inst.LocalConfig()[fmt.Sprintf("volatile.%s.host_name", devName)]
+			if hostName == "" {
+				continue
+			}
+
+			err = InstanceDeviceApply(d.state, inst.Project(), inst.Name(), devName, hostName, devConfig)
+			if err != nil {
+				return errors.Wrapf(err, "Failed applying ACLs to device %q of instance %q", devName, inst.Name())
+			}
+		}
+	}
+
+	return nil
+}
+
+// isInUseByConfig returns whether the ACL is referenced by the security.acls key of the given config.
+func isInUseByConfig(config map[string]string, aclName string) bool {
+	return shared.StringInSlice(aclName, ParseNames(config["security.acls"]))
+}
+
+// isInUseByDevices returns whether the ACL is referenced by any of the given NIC devices.
+func isInUseByDevices(devices map[string]map[string]string, aclName string) bool {
+	for _, devConfig := range devices {
+		if devConfig["type"] == "nic" && isInUseByConfig(devConfig, aclName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidName checks the ACL name is valid.
+func ValidName(name string) error {
+	if name == "" {
+		return fmt.Errorf("Name is required")
+	}
+
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '-' && r != '_' {
+			return fmt.Errorf("Name can only contain alphanumeric, dash and underscore characters")
+		}
+	}
+
+	return nil
+}
+
+// ValidAction checks the value is a valid ACL rule action.
+func ValidAction(value string) error {
+	return validate.IsOneOf(value, validActions)
+}
+
+// validateConfig checks the supplied ACL config and rules are valid.
+func validateConfig(config *api.NetworkACLPut) error {
+	for k := range config.Config {
+		if !strings.HasPrefix(k, "user.") {
+			return fmt.Errorf("Invalid option %q", k)
+		}
+	}
+
+	for i, rule := range config.Ingress {
+		err := validateRule(rule)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid ingress rule %d", i)
+		}
+	}
+
+	for i, rule := range config.Egress {
+		err := validateRule(rule)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid egress rule %d", i)
+		}
+	}
+
+	return nil
+}
+
+// validateRule checks the supplied rule is valid.
+func validateRule(rule api.NetworkACLRule) error {
+	if rule.Action == "" {
+		return fmt.Errorf("Action is required")
+	}
+
+	err := ValidAction(rule.Action)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid action")
+	}
+
+	err = validate.IsOneOf(rule.State, []string{"", "enabled", "disabled"})
+	if err != nil {
+		return errors.Wrapf(err, "Invalid state")
+	}
+
+	for _, address := range splitList(rule.Source) {
+		if net.ParseIP(address) == nil {
+			_, _, err := net.ParseCIDR(address)
+			if err != nil {
+				return fmt.Errorf("Invalid source %q", address)
+			}
+		}
+	}
+
+	for _, address := range splitList(rule.Destination) {
+		if net.ParseIP(address) == nil {
+			_, _, err := net.ParseCIDR(address)
+			if err != nil {
+				return fmt.Errorf("Invalid destination %q", address)
+			}
+		}
+	}
+
+	err = validate.IsOneOf(rule.Protocol, []string{"", "tcp", "udp", "icmp4", "icmp6"})
+	if err != nil {
+		return errors.Wrapf(err, "Invalid protocol")
+	}
+
+	if rule.SourcePort != "" || rule.DestinationPort != "" {
+		if rule.Protocol != "tcp" && rule.Protocol != "udp" {
+			return fmt.Errorf("Ports can only be used with the tcp and udp protocols")
+		}
+
+		for _, port := range append(splitList(rule.SourcePort), splitList(rule.DestinationPort)...) {
+			err = validatePort(port)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if rule.ICMPType != "" || rule.ICMPCode != "" {
+		if rule.Protocol != "icmp4" && rule.Protocol != "icmp6" {
+			return fmt.Errorf("ICMP type and code can only be used with the icmp4 and icmp6 protocols")
+		}
+
+		if rule.ICMPType == "" {
+			return fmt.Errorf("ICMP code requires an ICMP type")
+		}
+
+		err = validate.IsUint8(rule.ICMPType)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid ICMP type")
+		}
+
+		if rule.ICMPCode != "" {
+			err = validate.IsUint8(rule.ICMPCode)
+			if err != nil {
+				return errors.Wrapf(err, "Invalid ICMP code")
+			}
+		}
+	}
+
+	return nil
+}
+
+// validatePort checks the value is a valid port or "start-end" port range.
+func validatePort(value string) error {
+	fields := strings.SplitN(value, "-", 2)
+
+	start, err := strconv.ParseUint(fields[0], 10, 16)
+	if err != nil || start == 0 {
+		return fmt.Errorf("Invalid port %q", value)
+	}
+
+	if len(fields) > 1 {
+		end, err := strconv.ParseUint(fields[1], 10, 16)
+		if err != nil || end < start {
+			return fmt.Errorf("Invalid port range %q", value)
+		}
+	}
+
+	return nil
+}
+
+// splitList splits a comma separated list, ignoring empty entries.
+func splitList(value string) []string {
+	entries := []string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
@@ -0,0 +1,49 @@
+package acl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestValidateRule(t *testing.T) {
+	valid := []api.NetworkACLRule{
+		{Action: "allow"},
+		{Action: "reject", State: "disabled"},
+		{Action: "drop", Source: "192.0.2.1, 198.51.100.0/24", Destination: "2001:db8::/32"},
+		{Action: "allow", Protocol: "tcp", SourcePort: "1024-65535", DestinationPort: "80,443"},
+		{Action: "allow", Protocol: "icmp4", ICMPType: "8", ICMPCode: "0"},
+		{Action: "allow", Protocol: "icmp6"},
+	}
+
+	for _, rule := range valid {
+		assert.NoError(t, validateRule(rule), "%+v", rule)
+	}
+
+	invalid := []api.NetworkACLRule{
+		{},
+		{Action: "accept"},
+		{Action: "allow", State: "on"},
+		{Action: "allow", Source: "192.0.2.300"},
+		{Action: "allow", Protocol: "sctp"},
+		{Action: "allow", DestinationPort: "80"},
+		{Action: "allow", Protocol: "udp", DestinationPort: "0"},
+		{Action: "allow", Protocol: "udp", DestinationPort: "90-80"},
+		{Action: "allow", Protocol: "tcp", ICMPType: "8"},
+		{Action: "allow", Protocol: "icmp4", ICMPCode: "0"},
+		{Action: "allow", Protocol: "icmp6", ICMPType: "256"},
+	}
+
+	for _, rule := range invalid {
+		assert.Error(t, validateRule(rule), "%+v", rule)
+	}
+}
+
+func TestValidName(t *testing.T) {
+	assert.NoError(t, ValidName("web-servers_1"))
+	assert.Error(t, ValidName(""))
+	assert.Error(t, ValidName("web servers"))
+	assert.Error(t, ValidName("web/servers"))
+}
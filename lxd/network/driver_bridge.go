@@ -12,15 +12,20 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
+	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/apparmor"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/daemon"
 	"github.com/lxc/lxd/lxd/dnsmasq"
 	"github.com/lxc/lxd/lxd/dnsmasq/dhcpalloc"
+	firewallDrivers "github.com/lxc/lxd/lxd/firewall/drivers"
 	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/network/acl"
 	"github.com/lxc/lxd/lxd/network/openvswitch"
 	"github.com/lxc/lxd/lxd/node"
 	"github.com/lxc/lxd/lxd/revert"
@@ -80,6 +85,60 @@ func (n *bridge) stableMACSafe() bool {
 	return true
 }
 
+// bridgeExternalInterface parses an entry of "bridge.external_interfaces" and returns the name of the interface to
+// attach. Entries in the "<parent>/<vlan>" format refer to a VLAN interface managed by LXD, named "<parent>.<vlan>",
+// in which case the parent interface and VLAN ID are also returned.
+func bridgeExternalInterface(entry string) (string, string, string) {
+	fields := strings.SplitN(entry, "/", 2)
+	if len(fields) != 2 {
+		return entry, "", ""
+	}
+
+	return fmt.Sprintf("%s.%s", fields[0], fields[1]), fields[0], fields[1]
+}
+
+// setupExternalVLAN creates the VLAN interface of an external interface entry if it doesn't exist yet.
+func (n *bridge) setupExternalVLAN(ifName string, parent string, vlan string) error {
+	if shared.PathExists(fmt.Sprintf("/sys/class/net/%s", ifName)) {
+		return nil
+	}
+
+	if !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", parent)) {
+		return fmt.Errorf("Parent interface %q of external interface %q not found", parent, ifName)
+	}
+
+	// Bring the parent interface up so we can add a VLAN to it.
+	_, err := shared.RunCommand("ip", "link", "set", "dev", parent, "up")
+	if err != nil {
+		return errors.Wrapf(err, "Failed bringing up interface %q", parent)
+	}
+
+	_, err = shared.RunCommand("ip", "link", "add", "link", parent, "name", ifName, "type", "vlan", "id", vlan)
+	if err != nil {
+		return errors.Wrapf(err, "Failed creating VLAN interface %q", ifName)
+	}
+
+	// Prevent the VLAN interface from configuring itself from router advertisements.
+	util.SysctlSet(fmt.Sprintf("net/ipv6/conf/%s/accept_ra", ifName), "0")
+
+	return nil
+}
+
+// removeExternalVLAN removes the VLAN interface of an external interface entry, if it's managed by LXD.
+func (n *bridge) removeExternalVLAN(entry string) error {
+	ifName, _, vlan := bridgeExternalInterface(strings.TrimSpace(entry))
+	if vlan == "" || !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", ifName)) {
+		return nil
+	}
+
+	_, err := shared.RunCommand("ip", "link", "delete", "dev", ifName)
+	if err != nil {
+		return errors.Wrapf(err, "Failed deleting VLAN interface %q", ifName)
+	}
+
+	return nil
+}
+
 // fillConfig fills requested config with any default values.
 func (n *bridge) fillConfig(config map[string]string) error {
 	// Set some default values where needed.
@@ -137,8 +196,20 @@ func (n *bridge) Validate(config map[string]string) error {
 
 			for _, entry := range strings.Split(value, ",") {
 				entry = strings.TrimSpace(entry)
-				if err := validInterfaceName(entry); err != nil {
-					return errors.Wrapf(err, "Invalid interface name %q", entry)
+				ifName, parent, vlan := bridgeExternalInterface(entry)
+
+				if vlan != "" {
+					if err := validInterfaceName(parent); err != nil {
+						return errors.Wrapf(err, "Invalid parent interface name %q", parent)
+					}
+
+					if err := validate.IsNetworkVLAN(vlan); err != nil {
+						return errors.Wrapf(err, "Invalid VLAN ID in %q", entry)
+					}
+				}
+
+				if err := validInterfaceName(ifName); err != nil {
+					return errors.Wrapf(err, "Invalid interface name %q", ifName)
 				}
 			}
 
@@ -162,6 +233,8 @@ func (n *bridge) Validate(config map[string]string) error {
 		"bridge.mode": func(value string) error {
 			return validate.IsOneOf(value, []string{"standard", "fan"})
 		},
+		"bridge.multicast_querier":  validate.Optional(validate.IsBool),
+		"bridge.multicast_snooping": validate.Optional(validate.IsBool),
 
 		"fan.overlay_subnet": validate.Optional(validate.IsNetworkV4),
 		"fan.underlay_subnet": func(value string) error {
@@ -194,6 +267,7 @@ func (n *bridge) Validate(config map[string]string) error {
 		"ipv4.dhcp.ranges":  validate.IsAny,
 		"ipv4.routes":       validate.Optional(validate.IsNetworkV4List),
 		"ipv4.routing":      validate.Optional(validate.IsBool),
+		"ipv4.ovn.ranges":   validate.Optional(validIPRanges(4)),
 
 		"ipv6.address": func(value string) error {
 			if validate.IsOneOf(value, []string{"none", "auto"}) == nil {
@@ -214,17 +288,32 @@ func (n *bridge) Validate(config map[string]string) error {
 		"ipv6.dhcp.ranges":   validate.IsAny,
 		"ipv6.routes":        validate.Optional(validate.IsNetworkV6List),
 		"ipv6.routing":       validate.Optional(validate.IsBool),
+		"ipv6.ovn.ranges":    validate.Optional(validIPRanges(6)),
+
+		"ipv6.dhcp.pd.interface":       validate.Optional(validInterfaceName),
+		"volatile.ipv6.dhcp.pd.prefix": validate.Optional(validate.IsNetworkV6),
+		"volatile.ipv6.dhcp.pd.renew":  validate.Optional(validate.IsInt64),
 
 		"dns.domain": validate.IsAny,
+		"dns.expose": validate.Optional(validate.IsBool),
 		"dns.search": validate.IsAny,
 		"dns.mode": func(value string) error {
 			return validate.IsOneOf(value, []string{"dynamic", "managed", "none"})
 		},
+		"dns.zone.forward":      validate.Optional(validZone(n.state)),
+		"dns.zone.reverse.ipv4": validate.Optional(validZone(n.state)),
+		"dns.zone.reverse.ipv6": validate.Optional(validZone(n.state)),
 
 		"raw.dnsmasq": validate.IsAny,
 
 		"maas.subnet.ipv4": validate.IsAny,
 		"maas.subnet.ipv6": validate.IsAny,
+
+		"security.acls": func(value string) error {
+			return acl.Exists(n.state, acl.ParseNames(value)...)
+		},
+		"security.acls.default.ingress.action": validate.Optional(acl.ValidAction),
+		"security.acls.default.egress.action":  validate.Optional(acl.ValidAction),
 	}
 
 	// Add dynamic validation rules.
@@ -267,13 +356,44 @@ func (n *bridge) Validate(config map[string]string) error {
 		}
 	}
 
-	err := n.validate(config, rules)
+	// Add the BGP validation rules.
+	bgpRules, err := n.bgpValidationRules(config)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range bgpRules {
+		rules[k] = v
+	}
+
+	err = n.validate(config, rules)
 	if err != nil {
 		return err
 	}
 
 	// Peform composite key checks after per-key validation.
 
+	// Validate prefix delegation settings.
+	if config["ipv6.dhcp.pd.interface"] != "" {
+		if config["ipv6.address"] == "none" {
+			return fmt.Errorf("Prefix delegation requires IPv6 to be enabled")
+		}
+
+		clustered, err := cluster.Enabled(n.state.Node)
+		if err != nil {
+			return err
+		}
+
+		if clustered {
+			return fmt.Errorf("Prefix delegation isn't supported on clustered servers")
+		}
+	}
+
+	// Open vSwitch bridges can't act as multicast querier.
+	if config["bridge.driver"] == "openvswitch" && shared.IsTrue(config["bridge.multicast_querier"]) {
+		return fmt.Errorf("Multicast querier isn't supported with the openvswitch bridge driver")
+	}
+
 	// Validate network name when used in fan mode.
 	bridgeMode := config["bridge.mode"]
 	if bridgeMode == "fan" && len(n.name) > 11 {
@@ -565,6 +685,36 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		}
 	}
 
+	// Configure IGMP/MLD snooping (enabled unless disabled) and the multicast querier.
+	snooping := n.config["bridge.multicast_snooping"] == "" || shared.IsTrue(n.config["bridge.multicast_snooping"])
+	if n.config["bridge.driver"] == "openvswitch" {
+		ovs := openvswitch.NewOVS()
+		err = ovs.BridgeSet(n.name, fmt.Sprintf("mcast_snooping_enable=%t", snooping))
+		if err != nil {
+			return errors.Wrapf(err, "Failed configuring multicast snooping")
+		}
+	} else {
+		value := "0"
+		if snooping {
+			value = "1"
+		}
+
+		err = bridgeMulticastSet(n.name, "multicast_snooping", value)
+		if err != nil {
+			return err
+		}
+
+		value = "0"
+		if shared.IsTrue(n.config["bridge.multicast_querier"]) {
+			value = "1"
+		}
+
+		err = bridgeMulticastSet(n.name, "multicast_querier", value)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Bring it up.
 	_, err = shared.RunCommand("ip", "link", "set", "dev", n.name, "up")
 	if err != nil {
@@ -574,7 +724,14 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 	// Add any listed existing external interface.
 	if n.config["bridge.external_interfaces"] != "" {
 		for _, entry := range strings.Split(n.config["bridge.external_interfaces"], ",") {
-			entry = strings.TrimSpace(entry)
+			entry, parent, vlan := bridgeExternalInterface(strings.TrimSpace(entry))
+			if vlan != "" {
+				err = n.setupExternalVLAN(entry, parent, vlan)
+				if err != nil {
+					return err
+				}
+			}
+
 			iface, err := net.InterfaceByName(entry)
 			if err != nil {
 				n.logger.Warn("Skipping attaching missing external interface", log.Ctx{"interface": entry})
@@ -597,6 +754,21 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 				return fmt.Errorf("Only unconfigured network interfaces can be bridged")
 			}
 
+			// Apply the bridge MTU so the interface doesn't lower it when attached.
+			if n.config["bridge.mtu"] != "" {
+				_, err = shared.RunCommand("ip", "link", "set", "dev", entry, "mtu", n.config["bridge.mtu"])
+				if err != nil {
+					return errors.Wrapf(err, "Failed setting MTU on interface %q", entry)
+				}
+			}
+
+			if vlan != "" {
+				_, err = shared.RunCommand("ip", "link", "set", "dev", entry, "up")
+				if err != nil {
+					return errors.Wrapf(err, "Failed bringing up interface %q", entry)
+				}
+			}
+
 			err = AttachInterface(n.name, entry)
 			if err != nil {
 				return err
@@ -1223,6 +1395,14 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		return err
 	}
 
+	// Drop the IPv6 leases which aren't part of the subnet anymore when the bridge gets renumbered.
+	if oldConfig != nil && oldConfig["ipv6.address"] != n.config["ipv6.address"] {
+		err = n.pruneLeasesV6()
+		if err != nil {
+			return err
+		}
+	}
+
 	// Configure dnsmasq.
 	if n.config["bridge.mode"] == "fan" || !shared.StringInSlice(n.config["ipv4.address"], []string{"", "none"}) || !shared.StringInSlice(n.config["ipv6.address"], []string{"", "none"}) {
 		// Setup the dnsmasq domain.
@@ -1345,6 +1525,24 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		}
 	}
 
+	// Apply the address forwards.
+	err = n.forwardsSetup()
+	if err != nil {
+		return err
+	}
+
+	// Apply the network ACLs.
+	err = acl.NetworkApply(n.state, n.name, n.config)
+	if err != nil {
+		return err
+	}
+
+	// Setup the BGP peers and announce the network prefixes.
+	err = n.bgpSetup(oldConfig)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -1383,8 +1581,33 @@ func (n *bridge) Stop() error {
 		}
 	}
 
+	err := n.state.Firewall.NetworkClearForwards(n.name)
+	if err != nil {
+		return err
+	}
+
+	err = n.state.Firewall.NetworkClearACLRules(n.name)
+	if err != nil {
+		return err
+	}
+
+	err = n.bgpClear(n.config)
+	if err != nil {
+		return err
+	}
+
+	// Remove the VLAN interfaces managed by LXD.
+	if n.config["bridge.external_interfaces"] != "" {
+		for _, entry := range strings.Split(n.config["bridge.external_interfaces"], ",") {
+			err = n.removeExternalVLAN(entry)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	// Kill any existing dnsmasq and forkdns daemon for this network
-	err := dnsmasq.Kill(n.name, false)
+	err = dnsmasq.Kill(n.name, false)
 	if err != nil {
 		return err
 	}
@@ -1424,6 +1647,16 @@ func (n *bridge) Stop() error {
 func (n *bridge) Update(newNetwork api.NetworkPut, targetNode string, clusterNotification bool) error {
 	n.logger.Debug("Update", log.Ctx{"clusterNotification": clusterNotification, "newNetwork": newNetwork})
 
+	// Keep the prefix delegation state as it isn't user settable.
+	if newNetwork.Config["ipv6.dhcp.pd.interface"] != "" && newNetwork.Config["ipv6.dhcp.pd.interface"] == n.config["ipv6.dhcp.pd.interface"] {
+		for _, key := range []string{"volatile.ipv6.dhcp.pd.prefix", "volatile.ipv6.dhcp.pd.renew"} {
+			_, found := newNetwork.Config[key]
+			if !found && n.config[key] != "" {
+				newNetwork.Config[key] = n.config[key]
+			}
+		}
+	}
+
 	// Populate default values if they are missing.
 	err := n.fillConfig(newNetwork.Config)
 	if err != nil {
@@ -1468,23 +1701,29 @@ func (n *bridge) Update(newNetwork api.NetworkPut, targetNode string, clusterNot
 	// Detach any external interfaces should no longer be attached.
 	if shared.StringInSlice("bridge.external_interfaces", changedKeys) && n.isRunning() {
 		devices := []string{}
-		for _, dev := range strings.Split(newNetwork.Config["bridge.external_interfaces"], ",") {
-			dev = strings.TrimSpace(dev)
-			devices = append(devices, dev)
+		for _, entry := range strings.Split(newNetwork.Config["bridge.external_interfaces"], ",") {
+			entry = strings.TrimSpace(entry)
+			devices = append(devices, entry)
 		}
 
-		for _, dev := range strings.Split(oldNetwork.Config["bridge.external_interfaces"], ",") {
-			dev = strings.TrimSpace(dev)
-			if dev == "" {
+		for _, entry := range strings.Split(oldNetwork.Config["bridge.external_interfaces"], ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" || shared.StringInSlice(entry, devices) {
 				continue
 			}
 
-			if !shared.StringInSlice(dev, devices) && shared.PathExists(fmt.Sprintf("/sys/class/net/%s", dev)) {
+			dev, _, _ := bridgeExternalInterface(entry)
+			if shared.PathExists(fmt.Sprintf("/sys/class/net/%s", dev)) {
 				err = DetachInterface(n.name, dev)
 				if err != nil {
 					return err
 				}
 			}
+
+			err = n.removeExternalVLAN(entry)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -1863,3 +2102,589 @@ func (n *bridge) DHCPv6Subnet() *net.IPNet {
 
 	return subnet
 }
+
+// forwardRules validates the address forward and returns the firewall rules implementing it.
+// Port specific rules come first so that they take precedence over the default target address.
+func (n *bridge) forwardRules(listenAddress net.IP, forward *api.NetworkForwardPut) ([]firewallDrivers.AddressForward, error) {
+	if listenAddress == nil {
+		return nil, fmt.Errorf("Invalid listen address")
+	}
+
+	isIPv4 := listenAddress.To4() != nil
+	addressKey := "ipv6.address"
+	if isIPv4 {
+		addressKey = "ipv4.address"
+	}
+
+	_, subnet, _ := net.ParseCIDR(n.config[addressKey])
+	if subnet != nil && subnet.Contains(listenAddress) {
+		return nil, fmt.Errorf("Listen address %q can't be within the network subnet", listenAddress.String())
+	}
+
+	// Target addresses must be within the network subnet and of the same family as the listen address.
+	targetAddress := func(value string) (net.IP, error) {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("Invalid target address %q", value)
+		}
+
+		if (ip.To4() != nil) != isIPv4 {
+			return nil, fmt.Errorf("Target address %q isn't of the same family as the listen address", value)
+		}
+
+		if subnet == nil || !subnet.Contains(ip) {
+			return nil, fmt.Errorf("Target address %q isn't within the network subnet", value)
+		}
+
+		return ip, nil
+	}
+
+	for k := range forward.Config {
+		if k != "target_address" && !strings.HasPrefix(k, "user.") {
+			return nil, fmt.Errorf("Invalid forward config key %q", k)
+		}
+	}
+
+	rules := []firewallDrivers.AddressForward{}
+	usedPorts := map[string]bool{}
+
+	for i, port := range forward.Ports {
+		if !shared.StringInSlice(port.Protocol, []string{"tcp", "udp"}) {
+			return nil, fmt.Errorf("Invalid protocol %q for port specification %d", port.Protocol, i)
+		}
+
+		target, err := targetAddress(port.TargetAddress)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid port specification %d", i)
+		}
+
+		listenPorts, err := parsePortSpec(port.ListenPort)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid listen port for port specification %d", i)
+		}
+
+		targetPorts := listenPorts
+		if port.TargetPort != "" {
+			targetPorts, err = parsePortSpec(port.TargetPort)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Invalid target port for port specification %d", i)
+			}
+		}
+
+		if len(targetPorts) != 1 && len(targetPorts) != len(listenPorts) {
+			return nil, fmt.Errorf("Target ports must be a single port or match the number of listen ports for port specification %d", i)
+		}
+
+		for j, listenPort := range listenPorts {
+			key := fmt.Sprintf("%s/%d", port.Protocol, listenPort)
+			if usedPorts[key] {
+				return nil, fmt.Errorf("Listen port %s is used by more than one port specification", key)
+			}
+
+			usedPorts[key] = true
+
+			targetPort := targetPorts[0]
+			if len(targetPorts) > 1 {
+				targetPort = targetPorts[j]
+			}
+
+			rules = append(rules, firewallDrivers.AddressForward{
+				ListenAddress: listenAddress,
+				TargetAddress: target,
+				Protocol:      port.Protocol,
+				ListenPort:    listenPort,
+				TargetPort:    targetPort,
+			})
+		}
+	}
+
+	if forward.Config["target_address"] != "" {
+		target, err := targetAddress(forward.Config["target_address"])
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, firewallDrivers.AddressForward{
+			ListenAddress: listenAddress,
+			TargetAddress: target,
+		})
+	}
+
+	return rules, nil
+}
+
+// forwardsSetup applies the firewall rules of all the address forwards of the network.
+func (n *bridge) forwardsSetup() error {
+	if !n.isRunning() {
+		return nil
+	}
+
+	forwards, err := n.state.Cluster.GetNetworkForwards(n.id)
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading network forwards")
+	}
+
+	rules := []firewallDrivers.AddressForward{}
+	for _, forward := range forwards {
+		forwardRules, err := n.forwardRules(net.ParseIP(forward.ListenAddress), &forward.NetworkForwardPut)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid network forward %q", forward.ListenAddress)
+		}
+
+		rules = append(rules, forwardRules...)
+	}
+
+	return n.state.Firewall.NetworkApplyForwards(n.name, rules)
+}
+
+// ForwardCreate creates a network address forward.
+func (n *bridge) ForwardCreate(forward api.NetworkForwardsPost, clusterNotification bool) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	if !clusterNotification {
+		listenAddress := net.ParseIP(forward.ListenAddress)
+		_, err := n.forwardRules(listenAddress, &forward.NetworkForwardPut)
+		if err != nil {
+			return err
+		}
+
+		// Store the listen address in its canonical form.
+		forward.ListenAddress = listenAddress.String()
+
+		forwardID, err := n.state.Cluster.CreateNetworkForward(n.id, &forward)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() {
+			n.state.Cluster.DeleteNetworkForward(forwardID)
+			n.forwardsSetup()
+		})
+	}
+
+	err := n.forwardsSetup()
+	if err != nil {
+		return err
+	}
+
+	err = n.bgpSetupPrefixes()
+	if err != nil {
+		return err
+	}
+
+	if !clusterNotification {
+		err = n.notifyMembers(func(client lxd.InstanceServer) error {
+			return client.CreateNetworkForward(n.name, forward)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// ForwardUpdate updates a network address forward.
+func (n *bridge) ForwardUpdate(listenAddress string, newForward api.NetworkForwardPut, clusterNotification bool) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	if !clusterNotification {
+		forwardID, forward, err := n.state.Cluster.GetNetworkForward(n.id, listenAddress)
+		if err != nil {
+			return err
+		}
+
+		_, err = n.forwardRules(net.ParseIP(listenAddress), &newForward)
+		if err != nil {
+			return err
+		}
+
+		err = n.state.Cluster.UpdateNetworkForward(forwardID, &newForward)
+		if err != nil {
+			return err
+		}
+
+		oldForward := forward.Writable()
+		revert.Add(func() {
+			n.state.Cluster.UpdateNetworkForward(forwardID, &oldForward)
+			n.forwardsSetup()
+		})
+	}
+
+	err := n.forwardsSetup()
+	if err != nil {
+		return err
+	}
+
+	err = n.bgpSetupPrefixes()
+	if err != nil {
+		return err
+	}
+
+	if !clusterNotification {
+		err = n.notifyMembers(func(client lxd.InstanceServer) error {
+			return client.UpdateNetworkForward(n.name, listenAddress, newForward, "")
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// ForwardDelete deletes a network address forward.
+func (n *bridge) ForwardDelete(listenAddress string, clusterNotification bool) error {
+	if !clusterNotification {
+		forwardID, _, err := n.state.Cluster.GetNetworkForward(n.id, listenAddress)
+		if err != nil {
+			return err
+		}
+
+		err = n.state.Cluster.DeleteNetworkForward(forwardID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := n.forwardsSetup()
+	if err != nil {
+		return err
+	}
+
+	err = n.bgpSetupPrefixes()
+	if err != nil {
+		return err
+	}
+
+	if !clusterNotification {
+		err = n.notifyMembers(func(client lxd.InstanceServer) error {
+			return client.DeleteNetworkForward(n.name, listenAddress)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bgpSetup updates the BGP peers of the network and announces its prefixes.
+func (n *bridge) bgpSetup(oldConfig map[string]string) error {
+	err := n.bgpSetupPeers(oldConfig)
+	if err != nil {
+		return errors.Wrapf(err, "Failed setting up BGP peers")
+	}
+
+	err = n.bgpSetupPrefixes()
+	if err != nil {
+		return errors.Wrapf(err, "Failed announcing BGP prefixes")
+	}
+
+	return nil
+}
+
+// bgpSetupPrefixes announces the subnets of the network which aren't NATed and the listen addresses of its
+// address forwards.
+func (n *bridge) bgpSetupPrefixes() error {
+	if !n.isRunning() {
+		return nil
+	}
+
+	prefixes := []bgpPrefix{}
+
+	for _, ipVersion := range []uint{4, 6} {
+		address := n.config[fmt.Sprintf("ipv%d.address", ipVersion)]
+		if shared.StringInSlice(address, []string{"", "none"}) || shared.IsTrue(n.config[fmt.Sprintf("ipv%d.nat", ipVersion)]) {
+			continue
+		}
+
+		_, subnet, err := net.ParseCIDR(address)
+		if err != nil {
+			return err
+		}
+
+		prefixes = append(prefixes, bgpPrefix{prefix: *subnet, nexthop: n.bgpNextHop(ipVersion)})
+	}
+
+	listenAddresses, err := n.state.Cluster.GetNetworkForwardListenAddresses(n.id)
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading network forwards")
+	}
+
+	for _, listenAddress := range listenAddresses {
+		ip := net.ParseIP(listenAddress)
+		if ip == nil {
+			continue
+		}
+
+		ipVersion := uint(4)
+		bits := 32
+		if ip.To4() == nil {
+			ipVersion = 6
+			bits = 128
+		}
+
+		prefixes = append(prefixes, bgpPrefix{
+			prefix:  net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)},
+			nexthop: n.bgpNextHop(ipVersion),
+		})
+	}
+
+	return n.bgpAnnounce(prefixes)
+}
+
+// reservationValidate validates the DHCP reservation and converts its addresses to their canonical form.
+func (n *bridge) reservationValidate(reservation *api.NetworkReservation) error {
+	mac, err := net.ParseMAC(reservation.MAC)
+	if err != nil {
+		return fmt.Errorf("Invalid MAC address %q", reservation.MAC)
+	}
+
+	reservation.MAC = mac.String()
+
+	if reservation.IPv4Address == "" && reservation.IPv6Address == "" {
+		return fmt.Errorf("At least one of IPv4 or IPv6 address is required")
+	}
+
+	if reservation.IPv4Address != "" {
+		ip := net.ParseIP(reservation.IPv4Address)
+		if ip == nil || ip.To4() == nil {
+			return fmt.Errorf("Invalid IPv4 address %q", reservation.IPv4Address)
+		}
+
+		subnet := n.DHCPv4Subnet()
+		if subnet == nil {
+			return fmt.Errorf("IPv4 DHCP is disabled on the network")
+		}
+
+		routerIP, _, _ := net.ParseCIDR(n.config["ipv4.address"])
+		if !subnet.Contains(ip) || ip.Equal(routerIP) {
+			return fmt.Errorf("IPv4 address %q isn't a usable address of the network subnet %q", ip.String(), subnet.String())
+		}
+
+		reservation.IPv4Address = ip.String()
+	}
+
+	if reservation.IPv6Address != "" {
+		ip := net.ParseIP(reservation.IPv6Address)
+		if ip == nil || ip.To4() != nil {
+			return fmt.Errorf("Invalid IPv6 address %q", reservation.IPv6Address)
+		}
+
+		subnet := n.DHCPv6Subnet()
+		if subnet == nil || !shared.IsTrue(n.config["ipv6.dhcp.stateful"]) {
+			return fmt.Errorf("Stateful IPv6 DHCP is disabled on the network")
+		}
+
+		routerIP, _, _ := net.ParseCIDR(n.config["ipv6.address"])
+		if !subnet.Contains(ip) || ip.Equal(routerIP) {
+			return fmt.Errorf("IPv6 address %q isn't a usable address of the network subnet %q", ip.String(), subnet.String())
+		}
+
+		reservation.IPv6Address = ip.String()
+	}
+
+	// Check the addresses aren't already reserved for another MAC address.
+	reservations, err := n.state.Cluster.GetNetworkReservations(n.id)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range reservations {
+		if other.MAC == reservation.MAC {
+			continue
+		}
+
+		if (reservation.IPv4Address != "" && other.IPv4Address == reservation.IPv4Address) || (reservation.IPv6Address != "" && other.IPv6Address == reservation.IPv6Address) {
+			return fmt.Errorf("Address already reserved for MAC address %q", other.MAC)
+		}
+	}
+
+	return nil
+}
+
+// ReservationCreate creates a network DHCP reservation and applies it to the dnsmasq host files.
+func (n *bridge) ReservationCreate(reservation api.NetworkReservation, clusterNotification bool) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	if !clusterNotification {
+		err := n.reservationValidate(&reservation)
+		if err != nil {
+			return err
+		}
+
+		reservationID, err := n.state.Cluster.CreateNetworkReservation(n.id, &reservation)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() {
+			n.state.Cluster.DeleteNetworkReservation(reservationID)
+			UpdateDNSMasqStatic(n.state, n.name)
+		})
+	}
+
+	err := UpdateDNSMasqStatic(n.state, n.name)
+	if err != nil {
+		return err
+	}
+
+	if !clusterNotification {
+		err = n.notifyMembers(func(client lxd.InstanceServer) error {
+			return client.CreateNetworkReservation(n.name, reservation)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// ReservationDelete deletes a network DHCP reservation and removes it from the dnsmasq host files.
+func (n *bridge) ReservationDelete(hwaddr string, clusterNotification bool) error {
+	if !clusterNotification {
+		reservationID, _, err := n.state.Cluster.GetNetworkReservation(n.id, hwaddr)
+		if err != nil {
+			return err
+		}
+
+		err = n.state.Cluster.DeleteNetworkReservation(reservationID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := UpdateDNSMasqStatic(n.state, n.name)
+	if err != nil {
+		return err
+	}
+
+	if !clusterNotification {
+		err = n.notifyMembers(func(client lxd.InstanceServer) error {
+			return client.DeleteNetworkReservation(n.name, hwaddr)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pruneLeasesV6 removes the IPv6 leases outside of the current IPv6 subnet from the dnsmasq leases file. It must
+// be called while dnsmasq isn't running.
+func (n *bridge) pruneLeasesV6() error {
+	leasesPath := shared.VarPath("networks", n.name, "dnsmasq.leases")
+	if !shared.PathExists(leasesPath) {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(leasesPath)
+	if err != nil {
+		return err
+	}
+
+	subnet := n.DHCPv6Subnet()
+
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		// Lease lines are "<expiry> <MAC or IAID> <address> <hostname> <client ID>".
+		fields := strings.Fields(line)
+		if len(fields) >= 3 {
+			ip := net.ParseIP(fields[2])
+			if ip != nil && ip.To4() == nil && (subnet == nil || !subnet.Contains(ip)) {
+				continue
+			}
+		}
+
+		lines = append(lines, line)
+	}
+
+	err = ioutil.WriteFile(leasesPath, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to update dnsmasq leases file %q", leasesPath)
+	}
+
+	return nil
+}
+
+// RefreshPrefixDelegation requests a prefix from the upstream DHCPv6 server of the "ipv6.dhcp.pd.interface"
+// interface when the current one needs renewing. The bridge is renumbered using the first /64 of the delegated
+// prefix whenever it changes.
+func (n *bridge) RefreshPrefixDelegation() error {
+	ifName := n.config["ipv6.dhcp.pd.interface"]
+	if ifName == "" || !n.isRunning() {
+		return nil
+	}
+
+	// Skip until the current prefix needs renewing.
+	renew, err := strconv.ParseInt(n.config["volatile.ipv6.dhcp.pd.renew"], 10, 64)
+	if err == nil && time.Now().Before(time.Unix(renew, 0)) {
+		return nil
+	}
+
+	_, hint, _ := net.ParseCIDR(n.config["volatile.ipv6.dhcp.pd.prefix"])
+
+	// Identify the delegation by network so that each bridge gets its own prefix.
+	var iaid [4]byte
+	binary.BigEndian.PutUint32(iaid[:], uint32(n.id))
+
+	pd, err := requestDelegatedPrefix(ifName, iaid, hint)
+	if err != nil {
+		return err
+	}
+
+	ones, _ := pd.prefix.Mask.Size()
+	if ones > 64 {
+		return fmt.Errorf("Delegated prefix %q is smaller than a /64", pd.prefix.String())
+	}
+
+	newConfig := make(map[string]string, len(n.config))
+	for k, v := range n.config {
+		newConfig[k] = v
+	}
+
+	newConfig["volatile.ipv6.dhcp.pd.prefix"] = pd.prefix.String()
+	newConfig["volatile.ipv6.dhcp.pd.renew"] = fmt.Sprintf("%d", pd.renew.Unix())
+
+	// Use the first address of the first /64 of the prefix for the bridge.
+	gateway := make(net.IP, net.IPv6len)
+	copy(gateway, pd.prefix.IP.To16())
+	gateway[net.IPv6len-1] = 1
+	newConfig["ipv6.address"] = fmt.Sprintf("%s/64", gateway.String())
+
+	// Only record the renewal time if the bridge doesn't need renumbering.
+	if newConfig["ipv6.address"] == n.config["ipv6.address"] {
+		err = n.state.Cluster.UpdateNetwork(n.project, n.name, n.description, newConfig)
+		if err != nil {
+			return errors.Wrapf(err, "Failed saving prefix delegation state")
+		}
+
+		n.config = newConfig
+		return nil
+	}
+
+	oldAddress := n.config["ipv6.address"]
+
+	err = n.Update(api.NetworkPut{Description: n.description, Config: newConfig}, "", false)
+	if err != nil {
+		return errors.Wrapf(err, "Failed renumbering network to %q", newConfig["ipv6.address"])
+	}
+
+	n.logger.Info("Renumbered network from delegated prefix", log.Ctx{"prefix": pd.prefix.String(), "old": oldAddress, "new": newConfig["ipv6.address"]})
+	n.state.Events.SendLifecycle(n.project, lifecycle.NetworkRenumbered.Event(n.name, nil, map[string]interface{}{
+		"prefix":           pd.prefix.String(),
+		"old_ipv6_address": oldAddress,
+		"new_ipv6_address": newConfig["ipv6.address"],
+	}))
+
+	return nil
+}
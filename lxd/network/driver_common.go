@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -19,6 +20,7 @@ import (
 	log "github.com/lxc/lxd/shared/log15"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/logging"
+	"github.com/lxc/lxd/shared/validate"
 )
 
 // common represents a generic LXD network.
@@ -26,6 +28,7 @@ type common struct {
 	logger      logger.Logger
 	state       *state.State
 	id          int64
+	project     string
 	name        string
 	netType     string
 	description string
@@ -34,9 +37,10 @@ type common struct {
 }
 
 // init initialise internal variables.
-func (n *common) init(state *state.State, id int64, name string, netType string, description string, config map[string]string, status string) {
-	n.logger = logging.AddContext(logger.Log, log.Ctx{"driver": netType, "network": name})
+func (n *common) init(state *state.State, id int64, projectName string, name string, netType string, description string, config map[string]string, status string) {
+	n.logger = logging.AddContext(logger.Log, log.Ctx{"driver": netType, "project": projectName, "network": name})
 	n.id = id
+	n.project = projectName
 	n.name = name
 	n.netType = netType
 	n.config = config
@@ -99,6 +103,11 @@ func (n *common) ID() int64 {
 	return n.id
 }
 
+// Project returns the name of the project the network belongs to.
+func (n *common) Project() string {
+	return n.project
+}
+
 // Name returns the network name.
 func (n *common) Name() string {
 	return n.name
@@ -128,7 +137,7 @@ func (n *common) IsUsed() (bool, error) {
 	}
 
 	for _, inst := range insts {
-		inUse, err := IsInUseByInstance(n.state, inst, n.name)
+		inUse, err := IsInUseByInstance(n.state, inst, n.project, n.name)
 		if err != nil {
 			return false, err
 		}
@@ -153,7 +162,7 @@ func (n *common) IsUsed() (bool, error) {
 	}
 
 	for _, profile := range profiles {
-		inUse, err := IsInUseByProfile(n.state, *db.ProfileToAPI(&profile), n.name)
+		inUse, err := IsInUseByProfile(n.state, *db.ProfileToAPI(&profile), profile.Project, n.project, n.name)
 		if err != nil {
 			return false, err
 		}
@@ -220,7 +229,7 @@ func (n *common) DHCPv6Ranges() []dhcpalloc.DHCPRange {
 func (n *common) update(applyNetwork api.NetworkPut, targetNode string, clusterNotification bool) error {
 	// Update internal config before database has been updated (so that if update is a notification we apply
 	// the config being supplied and not that in the database).
-	n.init(n.state, n.id, n.name, n.netType, applyNetwork.Description, applyNetwork.Config, n.status)
+	n.init(n.state, n.id, n.project, n.name, n.netType, applyNetwork.Description, applyNetwork.Config, n.status)
 
 	// If this update isn't coming via a cluster notification itself, then notify all nodes of change and then
 	// update the database.
@@ -244,7 +253,7 @@ func (n *common) update(applyNetwork api.NetworkPut, targetNode string, clusterN
 			}
 
 			err = notifier(func(client lxd.InstanceServer) error {
-				return client.UpdateNetwork(n.name, sendNetwork, "")
+				return client.UseProject(n.project).UpdateNetwork(n.name, sendNetwork, "")
 			})
 			if err != nil {
 				return err
@@ -252,7 +261,7 @@ func (n *common) update(applyNetwork api.NetworkPut, targetNode string, clusterN
 		}
 
 		// Update the database.
-		err := n.state.Cluster.UpdateNetwork(n.name, applyNetwork.Description, applyNetwork.Config)
+		err := n.state.Cluster.UpdateNetwork(n.project, n.name, applyNetwork.Description, applyNetwork.Config)
 		if err != nil {
 			return err
 		}
@@ -325,13 +334,13 @@ func (n *common) rename(newName string) error {
 	}
 
 	// Rename the database entry.
-	err := n.state.Cluster.RenameNetwork(n.name, newName)
+	err := n.state.Cluster.RenameNetwork(n.project, n.name, newName)
 	if err != nil {
 		return err
 	}
 
 	// Reinitialise internal name variable and logger context with new name.
-	n.init(n.state, n.id, newName, n.netType, n.description, n.config, n.status)
+	n.init(n.state, n.id, n.project, newName, n.netType, n.description, n.config, n.status)
 
 	return nil
 }
@@ -341,7 +350,7 @@ func (n *common) delete(clusterNotification bool) error {
 	// Only delete database record if not cluster notification.
 	if !clusterNotification {
 		// Remove the network from the database.
-		err := n.state.Cluster.DeleteNetwork(n.name)
+		err := n.state.Cluster.DeleteNetwork(n.project, n.name)
 		if err != nil {
 			return err
 		}
@@ -359,3 +368,228 @@ func (n *common) Create(clusterNotification bool) error {
 func (n *common) HandleHeartbeat(heartbeatData *cluster.APIHeartbeat) error {
 	return nil
 }
+
+// RefreshPrefixDelegation is a no-op as prefix delegation isn't supported by this driver.
+func (n *common) RefreshPrefixDelegation() error {
+	return nil
+}
+
+// ForwardCreate returns ErrNotImplemented as address forwards aren't supported by this driver.
+func (n *common) ForwardCreate(forward api.NetworkForwardsPost, clusterNotification bool) error {
+	return ErrNotImplemented
+}
+
+// ForwardUpdate returns ErrNotImplemented as address forwards aren't supported by this driver.
+func (n *common) ForwardUpdate(listenAddress string, newForward api.NetworkForwardPut, clusterNotification bool) error {
+	return ErrNotImplemented
+}
+
+// ForwardDelete returns ErrNotImplemented as address forwards aren't supported by this driver.
+func (n *common) ForwardDelete(listenAddress string, clusterNotification bool) error {
+	return ErrNotImplemented
+}
+
+// ReservationCreate returns ErrNotImplemented as DHCP reservations aren't supported by this driver.
+func (n *common) ReservationCreate(reservation api.NetworkReservation, clusterNotification bool) error {
+	return ErrNotImplemented
+}
+
+// ReservationDelete returns ErrNotImplemented as DHCP reservations aren't supported by this driver.
+func (n *common) ReservationDelete(hwaddr string, clusterNotification bool) error {
+	return ErrNotImplemented
+}
+
+// PeerCreate returns ErrNotImplemented as peerings aren't supported by this driver.
+func (n *common) PeerCreate(peer api.NetworkPeersPost) error {
+	return ErrNotImplemented
+}
+
+// PeerUpdate returns ErrNotImplemented as peerings aren't supported by this driver.
+func (n *common) PeerUpdate(name string, newPeer api.NetworkPeerPut) error {
+	return ErrNotImplemented
+}
+
+// PeerDelete returns ErrNotImplemented as peerings aren't supported by this driver.
+func (n *common) PeerDelete(name string) error {
+	return ErrNotImplemented
+}
+
+// notifyMembers notifies all other cluster members that the address forwards or DHCP reservations of the network
+// have changed, so that they can apply them too.
+func (n *common) notifyMembers(hook func(client lxd.InstanceServer) error) error {
+	notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), cluster.NotifyAll)
+	if err != nil {
+		return err
+	}
+
+	return notifier(hook)
+}
+
+// bgpValidationRules returns the validation rules of the BGP keys present in the given config.
+func (n *common) bgpValidationRules(config map[string]string) (map[string]func(value string) error, error) {
+	rules := map[string]func(value string) error{
+		"bgp.ipv4.nexthop": validate.Optional(validate.IsNetworkAddressV4),
+		"bgp.ipv6.nexthop": validate.Optional(validate.IsNetworkAddressV6),
+	}
+
+	for k := range config {
+		if !strings.HasPrefix(k, "bgp.peers.") {
+			continue
+		}
+
+		// Peer keys are of the form bgp.peers.NAME.FIELD.
+		fields := strings.Split(k, ".")
+		if len(fields) != 4 || fields[2] == "" {
+			return nil, fmt.Errorf("Invalid network configuration key %q", k)
+		}
+
+		switch fields[3] {
+		case "address":
+			rules[k] = validate.IsNetworkAddress
+		case "asn":
+			rules[k] = validate.IsUint32
+		case "password":
+			rules[k] = validate.IsAny
+		default:
+			return nil, fmt.Errorf("Invalid network configuration key %q", k)
+		}
+	}
+
+	return rules, nil
+}
+
+// bgpPeer is a BGP peer configured on a network.
+type bgpPeer struct {
+	address  net.IP
+	asn      uint32
+	password string
+}
+
+// bgpGetPeers returns the BGP peers configured in the given network config, indexed by name.
+func (n *common) bgpGetPeers(config map[string]string) (map[string]bgpPeer, error) {
+	peers := map[string]bgpPeer{}
+
+	for k := range config {
+		if !strings.HasPrefix(k, "bgp.peers.") || !strings.HasSuffix(k, ".address") {
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(k, "bgp.peers."), ".address")
+
+		address := net.ParseIP(config[k])
+		if address == nil {
+			return nil, fmt.Errorf("Invalid address of BGP peer %q", name)
+		}
+
+		asn, err := strconv.ParseUint(config[fmt.Sprintf("bgp.peers.%s.asn", name)], 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid ASN of BGP peer %q", name)
+		}
+
+		peers[name] = bgpPeer{
+			address:  address,
+			asn:      uint32(asn),
+			password: config[fmt.Sprintf("bgp.peers.%s.password", name)],
+		}
+	}
+
+	return peers, nil
+}
+
+// bgpOwner returns the owner of the peers and prefixes set up on behalf of the network.
+func (n *common) bgpOwner() string {
+	return fmt.Sprintf("network_%d", n.id)
+}
+
+// bgpSetupPeers sets up the BGP peers of the network, removing those of oldConfig which changed or are no longer
+// set. Setting up a peer which is already in place has no effect.
+func (n *common) bgpSetupPeers(oldConfig map[string]string) error {
+	if n.state.BGP == nil {
+		return nil
+	}
+
+	oldPeers, err := n.bgpGetPeers(oldConfig)
+	if err != nil {
+		return err
+	}
+
+	peers, err := n.bgpGetPeers(n.config)
+	if err != nil {
+		return err
+	}
+
+	for name, oldPeer := range oldPeers {
+		peer, ok := peers[name]
+		if ok && peer.address.Equal(oldPeer.address) && peer.asn == oldPeer.asn && peer.password == oldPeer.password {
+			continue
+		}
+
+		err = n.state.BGP.RemovePeer(oldPeer.address, n.bgpOwner())
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, peer := range peers {
+		err = n.state.BGP.AddPeer(peer.address, peer.asn, peer.password, n.bgpOwner())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bgpNextHop returns the next-hop to announce the prefixes of the given IP version with, nil letting the BGP
+// server use its own address.
+func (n *common) bgpNextHop(ipVersion uint) net.IP {
+	return net.ParseIP(n.config[fmt.Sprintf("bgp.ipv%d.nexthop", ipVersion)])
+}
+
+// bgpPrefix is a prefix announced on behalf of a network.
+type bgpPrefix struct {
+	prefix  net.IPNet
+	nexthop net.IP
+}
+
+// bgpAnnounce replaces the prefixes announced on behalf of the network with the given ones.
+func (n *common) bgpAnnounce(prefixes []bgpPrefix) error {
+	if n.state.BGP == nil {
+		return nil
+	}
+
+	err := n.state.BGP.RemovePrefixByOwner(n.bgpOwner())
+	if err != nil {
+		return err
+	}
+
+	for _, prefix := range prefixes {
+		err = n.state.BGP.AddPrefix(prefix.prefix, prefix.nexthop, n.bgpOwner())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bgpClear removes the BGP peers of the given network config and withdraws the prefixes of the network.
+func (n *common) bgpClear(config map[string]string) error {
+	if n.state.BGP == nil {
+		return nil
+	}
+
+	peers, err := n.bgpGetPeers(config)
+	if err != nil {
+		return err
+	}
+
+	for _, peer := range peers {
+		err = n.state.BGP.RemovePeer(peer.address, n.bgpOwner())
+		if err != nil {
+			return err
+		}
+	}
+
+	return n.state.BGP.RemovePrefixByOwner(n.bgpOwner())
+}
@@ -0,0 +1,1362 @@
+package network
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/network/openvswitch"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/validate"
+)
+
+// ovnChassisPriorityMax is the maximum priority of a chassis in a HA chassis group.
+const ovnChassisPriorityMax = 32767
+
+// ovnDefaultMTU is the default MTU of OVN networks, which leaves room for the Geneve encapsulation overhead.
+const ovnDefaultMTU = 1442
+
+// Volatile keys used to store the addresses allocated to the network's router on the uplink network.
+const ovnVolatileUplinkIPv4 = "volatile.network.ipv4.address"
+const ovnVolatileUplinkIPv6 = "volatile.network.ipv6.address"
+
+// ovn represents a LXD OVN network.
+type ovn struct {
+	common
+}
+
+// getNetworkPrefix returns the prefix used for the OVN logical objects of this network.
+func (n *ovn) getNetworkPrefix() string {
+	return fmt.Sprintf("lxd-net%d", n.id)
+}
+
+// getChassisGroupName returns the name of the HA chassis group used for the network's external router port.
+func (n *ovn) getChassisGroupName() openvswitch.OVNChassisGroup {
+	return openvswitch.OVNChassisGroup(n.getNetworkPrefix())
+}
+
+// getRouterName returns the name of the network's logical router.
+func (n *ovn) getRouterName() openvswitch.OVNRouter {
+	return openvswitch.OVNRouter(fmt.Sprintf("%s-lr", n.getNetworkPrefix()))
+}
+
+// getRouterExtPortName returns the name of the router port connected to the external switch.
+func (n *ovn) getRouterExtPortName() openvswitch.OVNRouterPort {
+	return openvswitch.OVNRouterPort(fmt.Sprintf("%s-lrp-ext", n.getRouterName()))
+}
+
+// getRouterIntPortName returns the name of the router port connected to the internal switch.
+func (n *ovn) getRouterIntPortName() openvswitch.OVNRouterPort {
+	return openvswitch.OVNRouterPort(fmt.Sprintf("%s-lrp-int", n.getRouterName()))
+}
+
+// getExtSwitchName returns the name of the switch connecting the router to the uplink network.
+func (n *ovn) getExtSwitchName() openvswitch.OVNSwitch {
+	return openvswitch.OVNSwitch(fmt.Sprintf("%s-ls-ext", n.getNetworkPrefix()))
+}
+
+// getExtSwitchRouterPortName returns the name of the external switch port linked to the router.
+func (n *ovn) getExtSwitchRouterPortName() openvswitch.OVNSwitchPort {
+	return openvswitch.OVNSwitchPort(fmt.Sprintf("%s-lsp-router", n.getExtSwitchName()))
+}
+
+// getExtSwitchProviderPortName returns the name of the external switch port linked to the uplink network.
+func (n *ovn) getExtSwitchProviderPortName() openvswitch.OVNSwitchPort {
+	return openvswitch.OVNSwitchPort(fmt.Sprintf("%s-lsp-provider", n.getExtSwitchName()))
+}
+
+// getIntSwitchName returns the name of the switch that instance NICs are connected to.
+func (n *ovn) getIntSwitchName() openvswitch.OVNSwitch {
+	return openvswitch.OVNSwitch(fmt.Sprintf("%s-ls-int", n.getNetworkPrefix()))
+}
+
+// getIntSwitchRouterPortName returns the name of the internal switch port linked to the router.
+func (n *ovn) getIntSwitchRouterPortName() openvswitch.OVNSwitchPort {
+	return openvswitch.OVNSwitchPort(fmt.Sprintf("%s-lsp-router", n.getIntSwitchName()))
+}
+
+// getRouterPeerPortName returns the name of the router port linked to the router of the peer network.
+func (n *ovn) getRouterPeerPortName(peerNetworkID int64) openvswitch.OVNRouterPort {
+	return openvswitch.OVNRouterPort(fmt.Sprintf("%s-lrp-peer-net%d", n.getRouterName(), peerNetworkID))
+}
+
+// getInstanceDevicePortName returns the name of the internal switch port used by an instance NIC.
+func (n *ovn) getInstanceDevicePortName(instanceID int, deviceName string) openvswitch.OVNSwitchPort {
+	return openvswitch.OVNSwitchPort(fmt.Sprintf("%s-instance-%d-%s", n.getNetworkPrefix(), instanceID, deviceName))
+}
+
+// getUplinkOVSBridgeName returns the name of the OVS bridge used to connect to the uplink network.
+// If the uplink network is itself an OVS bridge it is used directly, otherwise a dedicated OVS bridge is
+// connected to the native uplink bridge by a veth pair, or to the physical uplink interface.
+func (n *ovn) getUplinkOVSBridgeName(uplinkNet Network) string {
+	if uplinkNet.Type() == "bridge" && uplinkNet.Config()["bridge.driver"] == "openvswitch" {
+		return uplinkNet.Name()
+	}
+
+	return fmt.Sprintf("lxdovn%d", uplinkNet.ID())
+}
+
+// getUplinkGatewayKey returns the uplink network config key holding its gateway address in CIDR format.
+func (n *ovn) getUplinkGatewayKey(uplinkNet Network, ipVersion uint) string {
+	if uplinkNet.Type() == "physical" {
+		return fmt.Sprintf("ipv%d.gateway", ipVersion)
+	}
+
+	return fmt.Sprintf("ipv%d.address", ipVersion)
+}
+
+// getMTU returns the MTU of the network.
+func (n *ovn) getMTU() uint32 {
+	if n.config["bridge.mtu"] != "" {
+		mtu, err := strconv.ParseUint(n.config["bridge.mtu"], 10, 32)
+		if err == nil {
+			return uint32(mtu)
+		}
+	}
+
+	return ovnDefaultMTU
+}
+
+// getDomainName returns the domain name advertised to instances.
+func (n *ovn) getDomainName() string {
+	if n.config["dns.domain"] != "" {
+		return n.config["dns.domain"]
+	}
+
+	return "lxd"
+}
+
+// getRouterMAC returns the MAC address used by the network's router ports.
+func (n *ovn) getRouterMAC() (net.HardwareAddr, error) {
+	hwAddr := n.config["bridge.hwaddr"]
+	if hwAddr == "" {
+		hwAddr = n.config["volatile.bridge.hwaddr"]
+	}
+
+	mac, err := net.ParseMAC(hwAddr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed parsing router MAC address %q", hwAddr)
+	}
+
+	return mac, nil
+}
+
+// getClient returns an OVN client connected to the configured northbound database.
+func (n *ovn) getClient() (*openvswitch.OVN, error) {
+	var nbConnection string
+
+	err := n.state.Cluster.Transaction(func(tx *db.ClusterTx) error {
+		config, err := cluster.ConfigLoad(tx)
+		if err != nil {
+			return err
+		}
+
+		nbConnection = config.NetworkOVNNorthboundConnection()
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed getting OVN northbound connection string")
+	}
+
+	return openvswitch.NewOVN(nbConnection), nil
+}
+
+// loadUplinkNetwork loads the uplink network and checks it is suitable for use by OVN networks.
+func (n *ovn) loadUplinkNetwork(uplinkName string) (Network, error) {
+	uplinkNet, err := LoadByName(n.state, project.Default, uplinkName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed loading uplink network %q", uplinkName)
+	}
+
+	if !shared.StringInSlice(uplinkNet.Type(), []string{"bridge", "physical"}) {
+		return nil, fmt.Errorf("Uplink network %q must be of type bridge or physical", uplinkName)
+	}
+
+	return uplinkNet, nil
+}
+
+// ValidateName validates network name.
+func (n *ovn) ValidateName(name string) error {
+	return validVirtualNetworkName(name)
+}
+
+// Validate network config.
+func (n *ovn) Validate(config map[string]string) error {
+	rules := map[string]func(value string) error{
+		"network":                validate.IsNotEmpty,
+		"bridge.hwaddr":          validate.Optional(validate.IsNetworkMAC),
+		"volatile.bridge.hwaddr": validate.Optional(validate.IsNetworkMAC),
+		"bridge.mtu":             validate.Optional(validate.IsInt64),
+		"ipv4.address": func(value string) error {
+			if validate.IsOneOf(value, []string{"none", "auto"}) == nil {
+				return nil
+			}
+
+			return validate.Optional(validate.IsNetworkAddressCIDRV4)(value)
+		},
+		"ipv4.nat": validate.Optional(validate.IsBool),
+		"ipv6.address": func(value string) error {
+			if validate.IsOneOf(value, []string{"none", "auto"}) == nil {
+				return nil
+			}
+
+			return validate.Optional(validate.IsNetworkAddressCIDRV6)(value)
+		},
+		"ipv6.nat":              validate.Optional(validate.IsBool),
+		"dns.domain":            validate.IsAny,
+		"dns.zone.forward":      validate.Optional(validZone(n.state)),
+		"dns.zone.reverse.ipv4": validate.Optional(validZone(n.state)),
+		"dns.zone.reverse.ipv6": validate.Optional(validZone(n.state)),
+		ovnVolatileUplinkIPv4:   validate.Optional(validate.IsNetworkAddressV4),
+		ovnVolatileUplinkIPv6:   validate.Optional(validate.IsNetworkAddressV6),
+	}
+
+	err := n.validate(config, rules)
+	if err != nil {
+		return err
+	}
+
+	// Check the uplink network is suitable and provides external addresses for the IP versions in use.
+	uplinkNet, err := n.loadUplinkNetwork(config["network"])
+	if err != nil {
+		return err
+	}
+
+	uplinkConfig := uplinkNet.Config()
+
+	// A physical uplink interface is attached to OVS, so it can't also be passed to instances.
+	if uplinkNet.Type() == "physical" {
+		inUse, err := uplinkNet.IsUsed()
+		if err != nil {
+			return err
+		}
+
+		if inUse {
+			return fmt.Errorf("Uplink network %q is in use by instances or profiles", uplinkNet.Name())
+		}
+	}
+
+	for _, ipVersion := range []uint{4, 6} {
+		if shared.StringInSlice(config[fmt.Sprintf("ipv%d.address", ipVersion)], []string{"", "none"}) {
+			continue
+		}
+
+		if uplinkConfig[fmt.Sprintf("ipv%d.ovn.ranges", ipVersion)] == "" {
+			return fmt.Errorf("Uplink network %q doesn't have %q set", uplinkNet.Name(), fmt.Sprintf("ipv%d.ovn.ranges", ipVersion))
+		}
+
+		gatewayKey := n.getUplinkGatewayKey(uplinkNet, ipVersion)
+		_, _, err = net.ParseCIDR(uplinkConfig[gatewayKey])
+		if err != nil {
+			return fmt.Errorf("Uplink network %q must have %q set to be used as gateway", uplinkNet.Name(), gatewayKey)
+		}
+	}
+
+	return nil
+}
+
+// fillConfig fills requested config with any default values.
+func (n *ovn) fillConfig(config map[string]string) error {
+	// Select the uplink network automatically if there is only one suitable candidate.
+	if config["network"] == "" {
+		uplinkName, err := n.findUplinkNetwork()
+		if err != nil {
+			return err
+		}
+
+		config["network"] = uplinkName
+	}
+
+	uplinkNet, err := n.loadUplinkNetwork(config["network"])
+	if err != nil {
+		return err
+	}
+
+	uplinkConfig := uplinkNet.Config()
+
+	// Only enable the IP versions for which the uplink network provides external addresses by default.
+	if config["ipv4.address"] == "" && uplinkConfig["ipv4.ovn.ranges"] != "" {
+		config["ipv4.address"] = "auto"
+	}
+
+	if config["ipv4.address"] == "auto" && config["ipv4.nat"] == "" {
+		config["ipv4.nat"] = "true"
+	}
+
+	if config["ipv6.address"] == "" && uplinkConfig["ipv6.ovn.ranges"] != "" {
+		content, err := ioutil.ReadFile("/proc/sys/net/ipv6/conf/default/disable_ipv6")
+		if err == nil && string(content) == "0\n" {
+			config["ipv6.address"] = "auto"
+		}
+	}
+
+	if config["ipv6.address"] == "auto" && config["ipv6.nat"] == "" {
+		config["ipv6.nat"] = "true"
+	}
+
+	// If no static hwaddr specified generate a volatile one to store in DB record so that the router ports
+	// keep the same MAC address when the network is reconfigured.
+	if config["bridge.hwaddr"] == "" && config["volatile.bridge.hwaddr"] == "" {
+		hwAddr, err := instance.DeviceNextInterfaceHWAddr()
+		if err != nil {
+			return errors.Wrapf(err, "Failed generating MAC address")
+		}
+
+		config["volatile.bridge.hwaddr"] = hwAddr
+	}
+
+	// Allocate the router's external addresses from the uplink network's OVN ranges.
+	for _, ipVersion := range []uint{4, 6} {
+		volatileKey := ovnVolatileUplinkIPv4
+		if ipVersion == 6 {
+			volatileKey = ovnVolatileUplinkIPv6
+		}
+
+		if shared.StringInSlice(config[fmt.Sprintf("ipv%d.address", ipVersion)], []string{"", "none"}) || config[volatileKey] != "" {
+			continue
+		}
+
+		if uplinkConfig[fmt.Sprintf("ipv%d.ovn.ranges", ipVersion)] == "" {
+			continue // Validate will report the missing ranges.
+		}
+
+		ip, err := n.allocateUplinkAddress(uplinkNet, ipVersion)
+		if err != nil {
+			return err
+		}
+
+		config[volatileKey] = ip.String()
+	}
+
+	return nil
+}
+
+// findUplinkNetwork returns the name of the only bridge or physical network that has OVN ranges configured.
+func (n *ovn) findUplinkNetwork() (string, error) {
+	// Uplink networks are always part of the default project.
+	networks, err := n.state.Cluster.GetNetworks(project.Default)
+	if err != nil {
+		return "", err
+	}
+
+	candidates := []string{}
+	for _, name := range networks {
+		_, netInfo, err := n.state.Cluster.GetNetworkInAnyState(project.Default, name)
+		if err != nil {
+			return "", err
+		}
+
+		if !shared.StringInSlice(netInfo.Type, []string{"bridge", "physical"}) {
+			continue
+		}
+
+		if netInfo.Config["ipv4.ovn.ranges"] != "" || netInfo.Config["ipv6.ovn.ranges"] != "" {
+			candidates = append(candidates, name)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("No suitable uplink network found, please create a bridge or physical network with %q or %q set", "ipv4.ovn.ranges", "ipv6.ovn.ranges")
+	}
+
+	if len(candidates) > 1 {
+		return "", fmt.Errorf("Multiple uplink networks available (%s), please specify one with the %q key", strings.Join(candidates, ", "), "network")
+	}
+
+	return candidates[0], nil
+}
+
+// allocateUplinkAddress returns the first address of the uplink network's OVN ranges not used by another
+// OVN network.
+func (n *ovn) allocateUplinkAddress(uplinkNet Network, ipVersion uint) (net.IP, error) {
+	volatileKey := ovnVolatileUplinkIPv4
+	if ipVersion == 6 {
+		volatileKey = ovnVolatileUplinkIPv6
+	}
+
+	ipRanges, err := parseIPRanges(uplinkNet.Config()[fmt.Sprintf("ipv%d.ovn.ranges", ipVersion)], ipVersion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed parsing uplink network %q OVN ranges", uplinkNet.Name())
+	}
+
+	configs, err := OVNNetworksUsingUplink(n.state, uplinkNet.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	usedIPs := map[string]struct{}{}
+	for id, config := range configs {
+		if id == n.id {
+			continue
+		}
+
+		ip := net.ParseIP(config[volatileKey])
+		if ip != nil {
+			usedIPs[ip.String()] = struct{}{}
+		}
+	}
+
+	for _, ipRange := range ipRanges {
+		for ip := ipRange.Start; ipRange.contains(ip); ip = nextIP(ip) {
+			_, used := usedIPs[ip.String()]
+			if !used {
+				return ip, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("No free IPv%d address available in uplink network %q OVN ranges", ipVersion, uplinkNet.Name())
+}
+
+// Create sets up the logical router and switches of the network in the OVN northbound database.
+// As the database is shared by all cluster members this is only done on the node the request originated from.
+func (n *ovn) Create(clusterNotification bool) error {
+	n.logger.Debug("Create", log.Ctx{"clusterNotification": clusterNotification, "config": n.config})
+
+	if clusterNotification {
+		return nil
+	}
+
+	return n.setup(false)
+}
+
+// setup creates (or updates when update is true) the logical objects of the network.
+func (n *ovn) setup(update bool) error {
+	client, err := n.getClient()
+	if err != nil {
+		return err
+	}
+
+	routerMAC, err := n.getRouterMAC()
+	if err != nil {
+		return err
+	}
+
+	uplinkNet, err := n.loadUplinkNetwork(n.config["network"])
+	if err != nil {
+		return err
+	}
+
+	uplinkConfig := uplinkNet.Config()
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Start from a fresh router on update so that stale addresses, routes and NAT rules are removed.
+	// The router is only linked to the switches by name so they don't need recreating.
+	if update {
+		err = client.LogicalRouterDelete(n.getRouterName())
+		if err != nil {
+			return errors.Wrapf(err, "Failed deleting router")
+		}
+	}
+
+	err = client.LogicalRouterAdd(n.getRouterName(), false)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding router")
+	}
+
+	if !update {
+		revert.Add(func() { client.LogicalRouterDelete(n.getRouterName()) })
+	}
+
+	err = client.LogicalSwitchAdd(n.getExtSwitchName(), update)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding external switch")
+	}
+
+	if !update {
+		revert.Add(func() { client.LogicalSwitchDelete(n.getExtSwitchName()) })
+	}
+
+	err = client.ChassisGroupAdd(n.getChassisGroupName(), update)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding chassis group")
+	}
+
+	if !update {
+		revert.Add(func() { client.ChassisGroupDelete(n.getChassisGroupName()) })
+	}
+
+	// Connect the router to the uplink network using the addresses allocated from its OVN ranges.
+	extRouterIPs := []*net.IPNet{}
+	uplinkGateways := map[uint]net.IP{}
+	for _, ipVersion := range []uint{4, 6} {
+		volatileKey := ovnVolatileUplinkIPv4
+		if ipVersion == 6 {
+			volatileKey = ovnVolatileUplinkIPv6
+		}
+
+		extIP := net.ParseIP(n.config[volatileKey])
+		if extIP == nil {
+			continue
+		}
+
+		gatewayIP, uplinkSubnet, err := net.ParseCIDR(uplinkConfig[n.getUplinkGatewayKey(uplinkNet, ipVersion)])
+		if err != nil {
+			return errors.Wrapf(err, "Failed parsing uplink network %q IPv%d gateway", uplinkNet.Name(), ipVersion)
+		}
+
+		extRouterIPs = append(extRouterIPs, &net.IPNet{IP: extIP, Mask: uplinkSubnet.Mask})
+		uplinkGateways[ipVersion] = gatewayIP
+	}
+
+	err = client.LogicalRouterPortAdd(n.getRouterName(), n.getRouterExtPortName(), routerMAC, extRouterIPs...)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding external router port")
+	}
+
+	err = client.LogicalRouterPortLinkChassisGroup(n.getRouterExtPortName(), n.getChassisGroupName())
+	if err != nil {
+		return errors.Wrapf(err, "Failed linking external router port to chassis group")
+	}
+
+	err = client.LogicalSwitchPortAdd(n.getExtSwitchName(), n.getExtSwitchRouterPortName(), update)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding external switch router port")
+	}
+
+	err = client.LogicalSwitchPortLinkRouter(n.getExtSwitchRouterPortName(), n.getRouterExtPortName())
+	if err != nil {
+		return errors.Wrapf(err, "Failed linking external router port to external switch port")
+	}
+
+	err = client.LogicalSwitchPortAdd(n.getExtSwitchName(), n.getExtSwitchProviderPortName(), update)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding external switch provider port")
+	}
+
+	err = client.LogicalSwitchPortLinkProviderNetwork(n.getExtSwitchProviderPortName(), uplinkNet.Name())
+	if err != nil {
+		return errors.Wrapf(err, "Failed linking external switch provider port to uplink network")
+	}
+
+	for ipVersion, gatewayIP := range uplinkGateways {
+		_, defaultRoute, _ := net.ParseCIDR("0.0.0.0/0")
+		if ipVersion == 6 {
+			_, defaultRoute, _ = net.ParseCIDR("::/0")
+		}
+
+		err = client.LogicalRouterRouteAdd(n.getRouterName(), defaultRoute, gatewayIP)
+		if err != nil {
+			return errors.Wrapf(err, "Failed adding IPv%d default route", ipVersion)
+		}
+	}
+
+	// Create the internal switch that instance NICs are connected to.
+	err = client.LogicalSwitchAdd(n.getIntSwitchName(), update)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding internal switch")
+	}
+
+	if !update {
+		revert.Add(func() { client.LogicalSwitchDelete(n.getIntSwitchName()) })
+	}
+
+	var routerIntPortIPv4, routerIntPortIPv6 net.IP
+	var routerIntPortIPv4Net, routerIntPortIPv6Net *net.IPNet
+	intRouterIPs := []*net.IPNet{}
+
+	if !shared.StringInSlice(n.config["ipv4.address"], []string{"", "none"}) {
+		routerIntPortIPv4, routerIntPortIPv4Net, err = net.ParseCIDR(n.config["ipv4.address"])
+		if err != nil {
+			return errors.Wrapf(err, "Failed parsing %q", "ipv4.address")
+		}
+
+		intRouterIPs = append(intRouterIPs, &net.IPNet{IP: routerIntPortIPv4, Mask: routerIntPortIPv4Net.Mask})
+	}
+
+	if !shared.StringInSlice(n.config["ipv6.address"], []string{"", "none"}) {
+		routerIntPortIPv6, routerIntPortIPv6Net, err = net.ParseCIDR(n.config["ipv6.address"])
+		if err != nil {
+			return errors.Wrapf(err, "Failed parsing %q", "ipv6.address")
+		}
+
+		intRouterIPs = append(intRouterIPs, &net.IPNet{IP: routerIntPortIPv6, Mask: routerIntPortIPv6Net.Mask})
+	}
+
+	err = client.LogicalRouterPortAdd(n.getRouterName(), n.getRouterIntPortName(), routerMAC, intRouterIPs...)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding internal router port")
+	}
+
+	if routerIntPortIPv6Net != nil {
+		err = client.LogicalRouterPortSetIPv6Advertisements(n.getRouterIntPortName(), openvswitch.OVNIPv6AddressModeSLAAC)
+		if err != nil {
+			return errors.Wrapf(err, "Failed setting IPv6 router advertisements")
+		}
+	}
+
+	err = client.LogicalSwitchPortAdd(n.getIntSwitchName(), n.getIntSwitchRouterPortName(), update)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding internal switch router port")
+	}
+
+	err = client.LogicalSwitchPortLinkRouter(n.getIntSwitchRouterPortName(), n.getRouterIntPortName())
+	if err != nil {
+		return errors.Wrapf(err, "Failed linking internal router port to internal switch port")
+	}
+
+	// Configure dynamic address allocation and DHCP for the instance NICs.
+	err = client.LogicalSwitchSetIPAllocation(n.getIntSwitchName(), routerIntPortIPv4Net, routerIntPortIPv4, routerIntPortIPv6Net)
+	if err != nil {
+		return errors.Wrapf(err, "Failed setting IP allocation settings on internal switch")
+	}
+
+	if routerIntPortIPv4Net != nil {
+		_, err = client.LogicalSwitchDHCPv4OptionsSet(n.getIntSwitchName(), routerIntPortIPv4Net, &openvswitch.OVNDHCPv4Opts{
+			ServerID:           routerIntPortIPv4,
+			ServerMAC:          routerMAC,
+			Router:             routerIntPortIPv4,
+			RecursiveDNSServer: uplinkGateways[4],
+			DomainName:         n.getDomainName(),
+			LeaseTime:          3600,
+			MTU:                n.getMTU(),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Failed adding DHCPv4 settings for internal switch")
+		}
+	}
+
+	// Add SNAT rules so that instances can reach the uplink network using the router's external addresses.
+	if routerIntPortIPv4Net != nil && uplinkGateways[4] != nil && shared.IsTrue(n.config["ipv4.nat"]) {
+		err = client.LogicalRouterSNATAdd(n.getRouterName(), routerIntPortIPv4Net, net.ParseIP(n.config[ovnVolatileUplinkIPv4]))
+		if err != nil {
+			return errors.Wrapf(err, "Failed adding IPv4 SNAT rule")
+		}
+	}
+
+	if routerIntPortIPv6Net != nil && uplinkGateways[6] != nil && shared.IsTrue(n.config["ipv6.nat"]) {
+		err = client.LogicalRouterSNATAdd(n.getRouterName(), routerIntPortIPv6Net, net.ParseIP(n.config[ovnVolatileUplinkIPv6]))
+		if err != nil {
+			return errors.Wrapf(err, "Failed adding IPv6 SNAT rule")
+		}
+	}
+
+	// Reconnect the peered routers as the router was recreated.
+	if update {
+		err = n.peerSetupAll(client)
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// startUplinkPort connects the uplink network to the OVS bridge mapped to its OVN provider name on this node.
+func (n *ovn) startUplinkPort() error {
+	uplinkNet, err := n.loadUplinkNetwork(n.config["network"])
+	if err != nil {
+		return err
+	}
+
+	ovs := openvswitch.NewOVS()
+	ovsBridge := n.getUplinkOVSBridgeName(uplinkNet)
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	if uplinkNet.Type() == "physical" {
+		// Physical uplink interfaces are attached to a dedicated OVS bridge directly.
+		uplinkConfig := uplinkNet.Config()
+		hostName := GetHostDevice(uplinkConfig["parent"], uplinkConfig["vlan"])
+
+		err = ovs.BridgeAdd(ovsBridge, true)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to create uplink OVS bridge %q", ovsBridge)
+		}
+
+		err = ovs.BridgePortAdd(ovsBridge, hostName, true)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to attach uplink interface %q to OVS bridge %q", hostName, ovsBridge)
+		}
+	} else if ovsBridge != uplinkNet.Name() {
+		// Native bridges are connected to a dedicated OVS bridge using a veth pair.
+		vethBridgeEnd := fmt.Sprintf("%sa", ovsBridge)
+		vethOVSEnd := fmt.Sprintf("%sb", ovsBridge)
+
+		if !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", vethBridgeEnd)) {
+			_, err = shared.RunCommand("ip", "link", "add", "dev", vethBridgeEnd, "type", "veth", "peer", "name", vethOVSEnd)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to create the uplink veth interfaces %q and %q", vethBridgeEnd, vethOVSEnd)
+			}
+
+			revert.Add(func() { shared.RunCommand("ip", "link", "delete", "dev", vethBridgeEnd) })
+		}
+
+		for _, devName := range []string{vethBridgeEnd, vethOVSEnd} {
+			_, err = shared.RunCommand("ip", "link", "set", "dev", devName, "up")
+			if err != nil {
+				return errors.Wrapf(err, "Failed to bring up uplink veth interface %q", devName)
+			}
+		}
+
+		err = AttachInterface(uplinkNet.Name(), vethBridgeEnd)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to attach uplink veth interface %q to uplink bridge %q", vethBridgeEnd, uplinkNet.Name())
+		}
+
+		err = ovs.BridgeAdd(ovsBridge, true)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to create uplink OVS bridge %q", ovsBridge)
+		}
+
+		err = ovs.BridgePortAdd(ovsBridge, vethOVSEnd, true)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to attach uplink veth interface %q to OVS bridge %q", vethOVSEnd, ovsBridge)
+		}
+	}
+
+	err = ovs.OVNBridgeMappingAdd(ovsBridge, uplinkNet.Name())
+	if err != nil {
+		return errors.Wrapf(err, "Failed to associate OVS bridge %q to OVN provider %q", ovsBridge, uplinkNet.Name())
+	}
+
+	revert.Success()
+	return nil
+}
+
+// deleteUplinkPort removes the connection to the uplink network on this node if no other OVN network uses it.
+func (n *ovn) deleteUplinkPort() error {
+	// Nothing to do if the uplink network has already been removed.
+	uplinkNet, err := n.loadUplinkNetwork(n.config["network"])
+	if err != nil {
+		return nil
+	}
+
+	configs, err := OVNNetworksUsingUplink(n.state, uplinkNet.Name())
+	if err != nil {
+		return err
+	}
+
+	delete(configs, n.id)
+	if len(configs) > 0 {
+		return nil
+	}
+
+	ovs := openvswitch.NewOVS()
+	ovsBridge := n.getUplinkOVSBridgeName(uplinkNet)
+
+	err = ovs.OVNBridgeMappingDelete(ovsBridge, uplinkNet.Name())
+	if err != nil {
+		return err
+	}
+
+	if ovsBridge != uplinkNet.Name() {
+		vethBridgeEnd := fmt.Sprintf("%sa", ovsBridge)
+		if shared.PathExists(fmt.Sprintf("/sys/class/net/%s", vethBridgeEnd)) {
+			_, err = shared.RunCommand("ip", "link", "delete", "dev", vethBridgeEnd)
+			if err != nil {
+				return err
+			}
+		}
+
+		exists, err := ovs.BridgeExists(ovsBridge)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			err = ovs.BridgeDelete(ovsBridge)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Start connects the uplink network on this node and adds the local chassis to the network's chassis group so
+// that it can host the router's external port.
+func (n *ovn) Start() error {
+	n.logger.Debug("Start")
+
+	if n.status == api.NetworkStatusPending {
+		return fmt.Errorf("Cannot start pending network")
+	}
+
+	ovs := openvswitch.NewOVS()
+	if !ovs.Installed() {
+		return fmt.Errorf("Open vSwitch isn't installed on this system")
+	}
+
+	err := n.startUplinkPort()
+	if err != nil {
+		return err
+	}
+
+	chassisID, err := ovs.ChassisID()
+	if err != nil {
+		return errors.Wrapf(err, "Failed getting OVS chassis ID")
+	}
+
+	client, err := n.getClient()
+	if err != nil {
+		return err
+	}
+
+	// Use a random priority so that the external ports of the OVN networks are spread across the chassis.
+	err = client.ChassisGroupChassisAdd(n.getChassisGroupName(), chassisID, uint(rand.Intn(ovnChassisPriorityMax+1)))
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding OVS chassis %q to chassis group %q", chassisID, n.getChassisGroupName())
+	}
+
+	err = n.bgpSetupPrefixes()
+	if err != nil {
+		return errors.Wrapf(err, "Failed announcing BGP prefixes")
+	}
+
+	return nil
+}
+
+// Stop removes the local chassis from the network's chassis group.
+func (n *ovn) Stop() error {
+	n.logger.Debug("Stop")
+
+	err := n.bgpClear(n.config)
+	if err != nil {
+		return err
+	}
+
+	ovs := openvswitch.NewOVS()
+	if !ovs.Installed() {
+		return nil
+	}
+
+	chassisID, err := ovs.ChassisID()
+	if err != nil {
+		return errors.Wrapf(err, "Failed getting OVS chassis ID")
+	}
+
+	client, err := n.getClient()
+	if err != nil {
+		return err
+	}
+
+	err = client.ChassisGroupChassisDelete(n.getChassisGroupName(), chassisID)
+	if err != nil {
+		return errors.Wrapf(err, "Failed deleting OVS chassis %q from chassis group %q", chassisID, n.getChassisGroupName())
+	}
+
+	return nil
+}
+
+// Delete deletes a network.
+func (n *ovn) Delete(clusterNotification bool) error {
+	n.logger.Debug("Delete", log.Ctx{"clusterNotification": clusterNotification})
+
+	if n.status != api.NetworkStatusPending {
+		// Don't fail if the chassis can't be removed, the chassis group is deleted below anyway.
+		err := n.Stop()
+		if err != nil {
+			n.logger.Warn("Failed stopping network", log.Ctx{"err": err})
+		}
+
+		// Only delete the logical objects once, as the northbound database is shared by all cluster members.
+		if !clusterNotification {
+			// Disconnect the peered routers first, leaving the target networks' peerings pending.
+			peerNames, err := n.state.Cluster.GetNetworkPeerNames(n.id)
+			if err != nil {
+				return err
+			}
+
+			for _, peerName := range peerNames {
+				err = n.PeerDelete(peerName)
+				if err != nil {
+					return err
+				}
+			}
+
+			client, err := n.getClient()
+			if err != nil {
+				return err
+			}
+
+			err = client.LogicalRouterDelete(n.getRouterName())
+			if err != nil {
+				return err
+			}
+
+			err = client.LogicalSwitchDelete(n.getExtSwitchName())
+			if err != nil {
+				return err
+			}
+
+			err = client.LogicalSwitchDelete(n.getIntSwitchName())
+			if err != nil {
+				return err
+			}
+
+			err = client.ChassisGroupDelete(n.getChassisGroupName())
+			if err != nil {
+				return err
+			}
+		}
+
+		err = n.deleteUplinkPort()
+		if err != nil {
+			return err
+		}
+	}
+
+	return n.common.delete(clusterNotification)
+}
+
+// Rename renames a network.
+func (n *ovn) Rename(newName string) error {
+	n.logger.Debug("Rename", log.Ctx{"newName": newName})
+
+	// Sanity checks.
+	inUse, err := n.IsUsed()
+	if err != nil {
+		return err
+	}
+
+	if inUse {
+		return fmt.Errorf("The network is currently in use")
+	}
+
+	// Rename common steps. The OVN logical objects are named after the network ID so don't need renaming.
+	err = n.common.rename(newName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Update updates the network. Accepts notification boolean indicating if this update request is coming from a
+// cluster notification, in which case do not update the database, just apply local changes needed.
+func (n *ovn) Update(newNetwork api.NetworkPut, targetNode string, clusterNotification bool) error {
+	n.logger.Debug("Update", log.Ctx{"clusterNotification": clusterNotification, "newNetwork": newNetwork})
+
+	if newNetwork.Config["network"] != n.config["network"] {
+		return fmt.Errorf("The uplink network of an OVN network cannot be changed")
+	}
+
+	// Keep the existing volatile keys so that the allocated MAC and external addresses don't change.
+	for k, v := range n.config {
+		if strings.HasPrefix(k, "volatile.") && newNetwork.Config[k] == "" {
+			newNetwork.Config[k] = v
+		}
+	}
+
+	// Populate default values if they are missing.
+	err := n.fillConfig(newNetwork.Config)
+	if err != nil {
+		return err
+	}
+
+	// Populate auto fields.
+	err = fillAuto(newNetwork.Config)
+	if err != nil {
+		return err
+	}
+
+	dbUpdateNeeeded, changedKeys, oldNetwork, err := n.common.configChanged(newNetwork)
+	if err != nil {
+		return err
+	}
+
+	if !dbUpdateNeeeded {
+		return nil // Nothing changed.
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Define a function which reverts everything.
+	revert.Add(func() {
+		// Reset changes to all nodes and database.
+		n.common.update(oldNetwork, targetNode, clusterNotification)
+
+		// Reset any change that was made to the logical network.
+		if !clusterNotification {
+			n.setup(true)
+		}
+	})
+
+	// Apply changes to database.
+	err = n.common.update(newNetwork, targetNode, clusterNotification)
+	if err != nil {
+		return err
+	}
+
+	// Reconfigure the logical network if needed (only once as the northbound database is shared).
+	if len(changedKeys) > 0 && !clusterNotification {
+		err = n.setup(true)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Update the announced prefixes, each cluster member runs its own BGP server.
+	if len(changedKeys) > 0 && n.status != api.NetworkStatusPending {
+		err = n.bgpSetupPrefixes()
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// bgpSetupPrefixes announces the subnets of the network which aren't NATed, using the external address of the
+// router on the uplink network as next-hop.
+func (n *ovn) bgpSetupPrefixes() error {
+	prefixes := []bgpPrefix{}
+
+	for _, ipVersion := range []uint{4, 6} {
+		address := n.config[fmt.Sprintf("ipv%d.address", ipVersion)]
+		if shared.StringInSlice(address, []string{"", "none"}) || shared.IsTrue(n.config[fmt.Sprintf("ipv%d.nat", ipVersion)]) {
+			continue
+		}
+
+		volatileKey := ovnVolatileUplinkIPv4
+		if ipVersion == 6 {
+			volatileKey = ovnVolatileUplinkIPv6
+		}
+
+		nexthop := net.ParseIP(n.config[volatileKey])
+		if nexthop == nil {
+			continue
+		}
+
+		_, subnet, err := net.ParseCIDR(address)
+		if err != nil {
+			return err
+		}
+
+		prefixes = append(prefixes, bgpPrefix{prefix: *subnet, nexthop: nexthop})
+	}
+
+	return n.bgpAnnounce(prefixes)
+}
+
+// InstanceDevicePortAdd adds an instance NIC port to the internal logical switch and returns its name.
+func (n *ovn) InstanceDevicePortAdd(instanceID int, deviceName string, mac net.HardwareAddr, ips []net.IP) (openvswitch.OVNSwitchPort, error) {
+	client, err := n.getClient()
+	if err != nil {
+		return "", err
+	}
+
+	dhcpv4OptsID, err := client.LogicalSwitchDHCPOptionsGet(n.getIntSwitchName())
+	if err != nil {
+		return "", err
+	}
+
+	portName := n.getInstanceDevicePortName(instanceID, deviceName)
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	err = client.LogicalSwitchPortAdd(n.getIntSwitchName(), portName, true)
+	if err != nil {
+		return "", err
+	}
+
+	revert.Add(func() { client.LogicalSwitchPortDelete(portName) })
+
+	err = client.LogicalSwitchPortSet(portName, &openvswitch.OVNSwitchPortOpts{
+		MAC:          mac,
+		IPs:          ips,
+		DHCPv4OptsID: dhcpv4OptsID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	revert.Success()
+	return portName, nil
+}
+
+// InstanceDevicePortDelete deletes an instance NIC port from the internal logical switch.
+func (n *ovn) InstanceDevicePortDelete(instanceID int, deviceName string) error {
+	client, err := n.getClient()
+	if err != nil {
+		return err
+	}
+
+	return client.LogicalSwitchPortDelete(n.getInstanceDevicePortName(instanceID, deviceName))
+}
+
+// MTU returns the MTU that instance NICs connected to the network should use.
+func (n *ovn) MTU() uint32 {
+	return n.getMTU()
+}
+
+// peerSubnets returns the internal subnets of the network along with the router's address in each of them as a
+// single host address, which is used as the address of the router's peer ports.
+func (n *ovn) peerSubnets() ([]*net.IPNet, []*net.IPNet, error) {
+	subnets := []*net.IPNet{}
+	routerIPs := []*net.IPNet{}
+
+	for _, key := range []string{"ipv4.address", "ipv6.address"} {
+		if shared.StringInSlice(n.config[key], []string{"", "none"}) {
+			continue
+		}
+
+		ip, subnet, err := net.ParseCIDR(n.config[key])
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "Failed parsing %q", key)
+		}
+
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+
+		subnets = append(subnets, subnet)
+		routerIPs = append(routerIPs, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+
+	return subnets, routerIPs, nil
+}
+
+// peerRouterPeering returns the OVN router peering between this network and the target network.
+func (n *ovn) peerRouterPeering(target *ovn) (*openvswitch.OVNRouterPeering, error) {
+	localSubnets, localRouterIPs, err := n.peerSubnets()
+	if err != nil {
+		return nil, err
+	}
+
+	targetSubnets, targetRouterIPs, err := target.peerSubnets()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, localSubnet := range localSubnets {
+		for _, targetSubnet := range targetSubnets {
+			if localSubnet.Contains(targetSubnet.IP) || targetSubnet.Contains(localSubnet.IP) {
+				return nil, fmt.Errorf("Subnet %q overlaps with subnet %q of network %q", localSubnet.String(), targetSubnet.String(), target.name)
+			}
+		}
+	}
+
+	localMAC, err := n.getRouterMAC()
+	if err != nil {
+		return nil, err
+	}
+
+	targetMAC, err := target.getRouterMAC()
+	if err != nil {
+		return nil, err
+	}
+
+	return &openvswitch.OVNRouterPeering{
+		LocalRouter:        n.getRouterName(),
+		LocalRouterPort:    n.getRouterPeerPortName(target.id),
+		LocalRouterPortMAC: localMAC,
+		LocalRouterPortIPs: localRouterIPs,
+		LocalRouterRoutes:  targetSubnets,
+
+		TargetRouter:        target.getRouterName(),
+		TargetRouterPort:    target.getRouterPeerPortName(n.id),
+		TargetRouterPortMAC: targetMAC,
+		TargetRouterPortIPs: targetRouterIPs,
+		TargetRouterRoutes:  localSubnets,
+	}, nil
+}
+
+// peerLoadTarget loads the target network of a peering, returning nil if it doesn't exist.
+func (n *ovn) peerLoadTarget(targetNetwork string) (*ovn, error) {
+	if targetNetwork == n.name {
+		return nil, fmt.Errorf("A network cannot be peered with itself")
+	}
+
+	targetNet, err := LoadByName(n.state, n.project, targetNetwork)
+	if err != nil {
+		if err == db.ErrNoSuchObject {
+			return nil, nil
+		}
+
+		return nil, errors.Wrapf(err, "Failed loading target network %q", targetNetwork)
+	}
+
+	target, ok := targetNet.(*ovn)
+	if !ok {
+		return nil, fmt.Errorf("Target network %q must be of type ovn", targetNetwork)
+	}
+
+	return target, nil
+}
+
+// peerValidate checks the supplied peering config is valid.
+func (n *ovn) peerValidate(config map[string]string) error {
+	for k := range config {
+		if !strings.HasPrefix(k, "user.") {
+			return fmt.Errorf("Invalid option %q", k)
+		}
+	}
+
+	return nil
+}
+
+// peerSetupAll re-applies all the mutual peerings of the network, for use after the router has been recreated.
+func (n *ovn) peerSetupAll(client *openvswitch.OVN) error {
+	peers, err := n.state.Cluster.GetNetworkPeers(n.id)
+	if err != nil {
+		return err
+	}
+
+	for _, peer := range peers {
+		if peer.Status != api.NetworkStatusCreated {
+			continue
+		}
+
+		target, err := n.peerLoadTarget(peer.TargetNetwork)
+		if err != nil {
+			return err
+		}
+
+		if target == nil {
+			continue
+		}
+
+		opts, err := n.peerRouterPeering(target)
+		if err != nil {
+			return err
+		}
+
+		err = client.LogicalRouterPeeringApply(*opts)
+		if err != nil {
+			return errors.Wrapf(err, "Failed applying peering %q", peer.Name)
+		}
+	}
+
+	return nil
+}
+
+// PeerCreate creates a network peering. The peering stays pending until the target network has a peering back to
+// this network, at which point the two logical routers are connected. As the northbound database is shared by all
+// cluster members, no notification is needed.
+func (n *ovn) PeerCreate(peer api.NetworkPeersPost) error {
+	err := validPeerName(peer.Name)
+	if err != nil {
+		return err
+	}
+
+	err = n.peerValidate(peer.Config)
+	if err != nil {
+		return err
+	}
+
+	target, err := n.peerLoadTarget(peer.TargetNetwork)
+	if err != nil {
+		return err
+	}
+
+	// Check for overlapping subnets early so that a peering that can never be set up isn't stored.
+	var opts *openvswitch.OVNRouterPeering
+	if target != nil {
+		opts, err = n.peerRouterPeering(target)
+		if err != nil {
+			return err
+		}
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	peerID, mutual, err := n.state.Cluster.CreateNetworkPeer(n.id, &peer)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { n.state.Cluster.DeleteNetworkPeer(peerID) })
+
+	if mutual && opts != nil {
+		client, err := n.getClient()
+		if err != nil {
+			return err
+		}
+
+		err = client.LogicalRouterPeeringApply(*opts)
+		if err != nil {
+			return errors.Wrapf(err, "Failed applying OVN router peering")
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// PeerUpdate updates a network peering.
+func (n *ovn) PeerUpdate(name string, newPeer api.NetworkPeerPut) error {
+	peerID, _, err := n.state.Cluster.GetNetworkPeer(n.id, name)
+	if err != nil {
+		return err
+	}
+
+	err = n.peerValidate(newPeer.Config)
+	if err != nil {
+		return err
+	}
+
+	return n.state.Cluster.UpdateNetworkPeer(peerID, &newPeer)
+}
+
+// PeerDelete deletes a network peering, disconnecting the logical routers if the peering was mutual. The peering
+// of the target network goes back to the pending state.
+func (n *ovn) PeerDelete(name string) error {
+	peerID, peer, err := n.state.Cluster.GetNetworkPeer(n.id, name)
+	if err != nil {
+		return err
+	}
+
+	if peer.Status == api.NetworkStatusCreated {
+		target, err := n.peerLoadTarget(peer.TargetNetwork)
+		if err != nil {
+			return err
+		}
+
+		if target != nil {
+			client, err := n.getClient()
+			if err != nil {
+				return err
+			}
+
+			localSubnets, localRouterIPs, err := n.peerSubnets()
+			if err != nil {
+				return err
+			}
+
+			targetSubnets, targetRouterIPs, err := target.peerSubnets()
+			if err != nil {
+				return err
+			}
+
+			err = client.LogicalRouterPeeringDelete(openvswitch.OVNRouterPeering{
+				LocalRouter:         n.getRouterName(),
+				LocalRouterPort:     n.getRouterPeerPortName(target.id),
+				LocalRouterPortIPs:  localRouterIPs,
+				LocalRouterRoutes:   targetSubnets,
+				TargetRouter:        target.getRouterName(),
+				TargetRouterPort:    target.getRouterPeerPortName(n.id),
+				TargetRouterPortIPs: targetRouterIPs,
+				TargetRouterRoutes:  localSubnets,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "Failed removing OVN router peering")
+			}
+		}
+	}
+
+	return n.state.Cluster.DeleteNetworkPeer(peerID)
+}
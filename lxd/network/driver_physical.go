@@ -0,0 +1,339 @@
+package network
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/validate"
+)
+
+// physical represents a LXD physical network.
+type physical struct {
+	common
+}
+
+// ValidateName validates network name.
+func (n *physical) ValidateName(name string) error {
+	return validVirtualNetworkName(name)
+}
+
+// Validate network config.
+func (n *physical) Validate(config map[string]string) error {
+	rules := map[string]func(value string) error{
+		"parent":                      validInterfaceName,
+		"mtu":                         validate.Optional(validate.IsInt64),
+		"vlan":                        validate.Optional(validate.IsNetworkVLAN),
+		"maas.subnet.ipv4":            validate.IsAny,
+		"maas.subnet.ipv6":            validate.IsAny,
+		"ipv4.gateway":                validate.Optional(validate.IsNetworkAddressCIDRV4),
+		"ipv6.gateway":                validate.Optional(validate.IsNetworkAddressCIDRV6),
+		"ipv4.ovn.ranges":             validate.Optional(validIPRanges(4)),
+		"ipv6.ovn.ranges":             validate.Optional(validIPRanges(6)),
+		"volatile.last_state.created": validate.Optional(validate.IsBool),
+	}
+
+	// Add the BGP peer validation rules, the network has no prefixes of its own to announce.
+	bgpRules, err := n.bgpValidationRules(config)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range bgpRules {
+		if strings.HasPrefix(k, "bgp.peers.") {
+			rules[k] = v
+		}
+	}
+
+	err = n.validate(config, rules)
+	if err != nil {
+		return err
+	}
+
+	// Check the host interface isn't already managed by another physical network on this node.
+	if n.state != nil && config["parent"] != "" {
+		err = n.checkParentUse(config)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkParentUse checks that no other physical network on this node uses the same host interface.
+func (n *physical) checkParentUse(config map[string]string) error {
+	hostName := GetHostDevice(config["parent"], config["vlan"])
+
+	// Physical networks are always part of the default project.
+	networks, err := n.state.Cluster.GetNetworks(project.Default)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range networks {
+		if name == n.name {
+			continue
+		}
+
+		_, netInfo, err := n.state.Cluster.GetNetworkInAnyState(project.Default, name)
+		if err != nil {
+			return err
+		}
+
+		if netInfo.Type != "physical" || netInfo.Config["parent"] == "" {
+			continue
+		}
+
+		if GetHostDevice(netInfo.Config["parent"], netInfo.Config["vlan"]) == hostName {
+			return fmt.Errorf("Parent interface %q in use by network %q", hostName, name)
+		}
+	}
+
+	return nil
+}
+
+// checkUsers returns an error if the network is used by instances, profiles or as uplink of OVN networks.
+func (n *physical) checkUsers() error {
+	inUse, err := n.IsUsed()
+	if err != nil {
+		return err
+	}
+
+	if inUse {
+		return fmt.Errorf("The network is currently in use")
+	}
+
+	ovnNetworks, err := OVNNetworksUsingUplink(n.state, n.name)
+	if err != nil {
+		return err
+	}
+
+	if len(ovnNetworks) > 0 {
+		return fmt.Errorf("The network is currently in use as uplink network")
+	}
+
+	return nil
+}
+
+// setCreatedState records in the node-specific volatile config whether the host interface was created by LXD.
+func (n *physical) setCreatedState(created bool) error {
+	config := make(map[string]string, len(n.config))
+	for k, v := range n.config {
+		config[k] = v
+	}
+
+	if created {
+		config["volatile.last_state.created"] = "true"
+	} else {
+		delete(config, "volatile.last_state.created")
+	}
+
+	err := n.state.Cluster.UpdateNetwork(n.project, n.name, n.description, config)
+	if err != nil {
+		return errors.Wrapf(err, "Failed saving volatile config")
+	}
+
+	n.config = config
+	return nil
+}
+
+// Delete deletes a network.
+func (n *physical) Delete(clusterNotification bool) error {
+	n.logger.Debug("Delete", log.Ctx{"clusterNotification": clusterNotification})
+
+	if n.status != api.NetworkStatusPending {
+		err := n.Stop()
+		if err != nil {
+			return err
+		}
+	}
+
+	return n.common.delete(clusterNotification)
+}
+
+// Rename renames a network.
+func (n *physical) Rename(newName string) error {
+	n.logger.Debug("Rename", log.Ctx{"newName": newName})
+
+	// Sanity checks.
+	err := n.checkUsers()
+	if err != nil {
+		return err
+	}
+
+	// Rename common steps.
+	err = n.common.rename(newName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Start creates the VLAN interface if needed, and applies the MTU to the host interface.
+func (n *physical) Start() error {
+	n.logger.Debug("Start")
+
+	if n.status == api.NetworkStatusPending {
+		return fmt.Errorf("Cannot start pending network")
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	hostName := GetHostDevice(n.config["parent"], n.config["vlan"])
+
+	if !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", hostName)) {
+		if n.config["vlan"] == "" {
+			return fmt.Errorf("Parent interface %q not found", n.config["parent"])
+		}
+
+		if !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", n.config["parent"])) {
+			return fmt.Errorf("Parent interface %q not found", n.config["parent"])
+		}
+
+		_, err := shared.RunCommand("ip", "link", "add", "link", n.config["parent"], "name", hostName, "type", "vlan", "id", n.config["vlan"])
+		if err != nil {
+			return errors.Wrapf(err, "Failed creating VLAN interface %q", hostName)
+		}
+
+		revert.Add(func() { shared.RunCommand("ip", "link", "delete", "dev", hostName) })
+
+		err = n.setCreatedState(true)
+		if err != nil {
+			return err
+		}
+	}
+
+	if n.config["mtu"] != "" {
+		_, err := shared.RunCommand("ip", "link", "set", "dev", hostName, "mtu", n.config["mtu"])
+		if err != nil {
+			return errors.Wrapf(err, "Failed setting MTU on interface %q", hostName)
+		}
+	}
+
+	_, err := shared.RunCommand("ip", "link", "set", "dev", hostName, "up")
+	if err != nil {
+		return errors.Wrapf(err, "Failed bringing up interface %q", hostName)
+	}
+
+	err = n.bgpSetupPeers(nil)
+	if err != nil {
+		return errors.Wrapf(err, "Failed setting up BGP peers")
+	}
+
+	revert.Success()
+	return nil
+}
+
+// Stop removes the VLAN interface if it was created by LXD.
+func (n *physical) Stop() error {
+	n.logger.Debug("Stop")
+
+	err := n.bgpClear(n.config)
+	if err != nil {
+		return err
+	}
+
+	if !shared.IsTrue(n.config["volatile.last_state.created"]) {
+		return nil
+	}
+
+	hostName := GetHostDevice(n.config["parent"], n.config["vlan"])
+	if shared.PathExists(fmt.Sprintf("/sys/class/net/%s", hostName)) {
+		_, err := shared.RunCommand("ip", "link", "delete", "dev", hostName)
+		if err != nil {
+			return errors.Wrapf(err, "Failed deleting VLAN interface %q", hostName)
+		}
+	}
+
+	return n.setCreatedState(false)
+}
+
+// Update updates the network. Accepts notification boolean indicating if this update request is coming from a
+// cluster notification, in which case do not update the database, just apply local changes needed.
+func (n *physical) Update(newNetwork api.NetworkPut, targetNode string, clusterNotification bool) error {
+	n.logger.Debug("Update", log.Ctx{"clusterNotification": clusterNotification, "newNetwork": newNetwork})
+
+	// Keep the volatile keys as they aren't user settable.
+	for k, v := range n.config {
+		if strings.HasPrefix(k, "volatile.") && newNetwork.Config[k] == "" {
+			newNetwork.Config[k] = v
+		}
+	}
+
+	dbUpdateNeeeded, changedKeys, oldNetwork, err := n.common.configChanged(newNetwork)
+	if err != nil {
+		return err
+	}
+
+	if !dbUpdateNeeeded {
+		return nil // Nothing changed.
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// The host interface needs recreating if it changed, which isn't possible while it is used.
+	hostChanged := false
+	for _, k := range changedKeys {
+		if shared.StringInSlice(k, []string{"parent", "vlan"}) {
+			hostChanged = true
+			break
+		}
+	}
+
+	if hostChanged && n.status != api.NetworkStatusPending {
+		err = n.checkUsers()
+		if err != nil {
+			return err
+		}
+
+		err = n.Stop()
+		if err != nil {
+			return err
+		}
+
+		// The created state only applies to the previous host interface.
+		delete(newNetwork.Config, "volatile.last_state.created")
+	}
+
+	// Define a function which reverts everything.
+	revert.Add(func() {
+		// Reset changes to all nodes and database.
+		n.common.update(oldNetwork, targetNode, clusterNotification)
+
+		if hostChanged {
+			n.Start()
+		}
+	})
+
+	// Apply changes to database.
+	err = n.common.update(newNetwork, targetNode, clusterNotification)
+	if err != nil {
+		return err
+	}
+
+	// Apply the new host interface or MTU.
+	if n.status != api.NetworkStatusPending && (hostChanged || shared.StringInSlice("mtu", changedKeys)) {
+		err = n.Start()
+		if err != nil {
+			return err
+		}
+	} else if n.status != api.NetworkStatusPending {
+		err = n.bgpSetupPeers(oldNetwork.Config)
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
@@ -12,13 +12,14 @@ import (
 // Network represents a LXD network.
 type Network interface {
 	// Load.
-	init(state *state.State, id int64, name string, netType string, description string, config map[string]string, status string)
+	init(state *state.State, id int64, projectName string, name string, netType string, description string, config map[string]string, status string)
 	fillConfig(config map[string]string) error
 
 	// Config.
 	ValidateName(name string) error
 	Validate(config map[string]string) error
 	ID() int64
+	Project() string
 	Name() string
 	Type() string
 	Status() string
@@ -36,5 +37,20 @@ type Network interface {
 	Rename(name string) error
 	Update(newNetwork api.NetworkPut, targetNode string, clusterNotification bool) error
 	HandleHeartbeat(heartbeatData *cluster.APIHeartbeat) error
+	RefreshPrefixDelegation() error
 	Delete(clusterNotification bool) error
+
+	// Address forwards.
+	ForwardCreate(forward api.NetworkForwardsPost, clusterNotification bool) error
+	ForwardUpdate(listenAddress string, newForward api.NetworkForwardPut, clusterNotification bool) error
+	ForwardDelete(listenAddress string, clusterNotification bool) error
+
+	// DHCP reservations.
+	ReservationCreate(reservation api.NetworkReservation, clusterNotification bool) error
+	ReservationDelete(hwaddr string, clusterNotification bool) error
+
+	// Peerings.
+	PeerCreate(peer api.NetworkPeersPost) error
+	PeerUpdate(name string, newPeer api.NetworkPeerPut) error
+	PeerDelete(name string) error
 }
@@ -1,19 +1,22 @@
 package network
 
 import (
+	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/shared/api"
 )
 
 var drivers = map[string]func() Network{
-	"bridge":  func() Network { return &bridge{} },
-	"macvlan": func() Network { return &macvlan{} },
-	"sriov":   func() Network { return &sriov{} },
+	"bridge":   func() Network { return &bridge{} },
+	"macvlan":  func() Network { return &macvlan{} },
+	"sriov":    func() Network { return &sriov{} },
+	"ovn":      func() Network { return &ovn{} },
+	"physical": func() Network { return &physical{} },
 }
 
-// LoadByName loads the network info from the database by name.
-func LoadByName(s *state.State, name string) (Network, error) {
-	id, netInfo, err := s.Cluster.GetNetworkInAnyState(name)
+// LoadByName loads the network info from the database by project and name.
+func LoadByName(s *state.State, projectName string, name string) (Network, error) {
+	id, netInfo, err := s.Cluster.GetNetworkInAnyState(projectName, name)
 	if err != nil {
 		return nil, err
 	}
@@ -24,7 +27,7 @@ func LoadByName(s *state.State, name string) (Network, error) {
 	}
 
 	n := driverFunc()
-	n.init(s, id, name, netInfo.Type, netInfo.Description, netInfo.Config, netInfo.Status)
+	n.init(s, id, projectName, name, netInfo.Type, netInfo.Description, netInfo.Config, netInfo.Status)
 
 	return n, nil
 }
@@ -37,20 +40,20 @@ func ValidateName(name string, netType string) error {
 	}
 
 	n := driverFunc()
-	n.init(nil, 0, name, netType, "", nil, "Unknown")
+	n.init(nil, 0, project.Default, name, netType, "", nil, "Unknown")
 
 	return n.ValidateName(name)
 }
 
 // Validate validates the supplied network name and configuration for the specified network type.
-func Validate(name string, netType string, config map[string]string) error {
+func Validate(s *state.State, projectName string, name string, netType string, config map[string]string) error {
 	driverFunc, ok := drivers[netType]
 	if !ok {
 		return ErrUnknownDriver
 	}
 
 	n := driverFunc()
-	n.init(nil, 0, name, netType, "", config, "Unknown")
+	n.init(s, 0, projectName, name, netType, "", config, "Unknown")
 
 	err := n.ValidateName(name)
 	if err != nil {
@@ -61,14 +64,14 @@ func Validate(name string, netType string, config map[string]string) error {
 }
 
 // FillConfig populates the supplied api.NetworkPost with automatically populated values.
-func FillConfig(req *api.NetworksPost) error {
+func FillConfig(s *state.State, projectName string, req *api.NetworksPost) error {
 	driverFunc, ok := drivers[req.Type]
 	if !ok {
 		return ErrUnknownDriver
 	}
 
 	n := driverFunc()
-	n.init(nil, 0, req.Name, req.Type, req.Description, req.Config, "Unknown")
+	n.init(s, 0, projectName, req.Name, req.Type, req.Description, req.Config, "Unknown")
 
 	err := n.fillConfig(req.Config)
 	if err != nil {
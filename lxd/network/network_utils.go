@@ -2,6 +2,8 @@ package network
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/hex"
 	"fmt"
 	"io/ioutil"
@@ -14,6 +16,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/insomniacslk/dhcp/dhcpv6"
+	"github.com/insomniacslk/dhcp/dhcpv6/nclient6"
 	"github.com/pkg/errors"
 
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
@@ -23,6 +27,7 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/network/openvswitch"
+	"github.com/lxc/lxd/lxd/network/zone"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/shared"
@@ -76,35 +81,79 @@ func networkValidPort(value string) error {
 	return nil
 }
 
+// parsePortSpec parses a comma separated list of ports and port ranges (e.g. "80,8000-8010").
+func parsePortSpec(spec string) ([]uint64, error) {
+	ports := []uint64{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		fields := strings.SplitN(entry, "-", 2)
+
+		start, err := strconv.ParseUint(fields[0], 10, 16)
+		if err != nil || start == 0 {
+			return nil, fmt.Errorf("Invalid port %q", entry)
+		}
+
+		end := start
+		if len(fields) > 1 {
+			end, err = strconv.ParseUint(fields[1], 10, 16)
+			if err != nil || end < start {
+				return nil, fmt.Errorf("Invalid port range %q", entry)
+			}
+		}
+
+		for port := start; port <= end; port++ {
+			ports = append(ports, port)
+		}
+	}
+
+	return ports, nil
+}
+
 // IsInUseByInstance indicates if network is referenced by an instance's NIC devices.
-// Checks if the device's parent or network properties match the network name.
-func IsInUseByInstance(s *state.State, c instance.Instance, networkName string) (bool, error) {
-	return isInUseByDevices(s, c.ExpandedDevices(), networkName)
+// Checks if the device's parent or network properties match the network name. The network property is only
+// matched if the instance's project uses the specified network project.
+func IsInUseByInstance(s *state.State, c instance.Instance, networkProjectName string, networkName string) (bool, error) {
+	return isInUseByDevices(s, c.Project(), c.ExpandedDevices(), networkProjectName, networkName)
 }
 
 // IsInUseByProfile indicates if network is referenced by a profile's NIC devices.
-// Checks if the device's parent or network properties match the network name.
-func IsInUseByProfile(s *state.State, profile api.Profile, networkName string) (bool, error) {
-	return isInUseByDevices(s, deviceConfig.NewDevices(profile.Devices), networkName)
+// Checks if the device's parent or network properties match the network name. The network property is only
+// matched if the profile's project uses the specified network project.
+func IsInUseByProfile(s *state.State, profile api.Profile, profileProjectName string, networkProjectName string, networkName string) (bool, error) {
+	return isInUseByDevices(s, profileProjectName, deviceConfig.NewDevices(profile.Devices), networkProjectName, networkName)
 }
 
-func isInUseByDevices(s *state.State, devices deviceConfig.Devices, networkName string) (bool, error) {
+func isInUseByDevices(s *state.State, projectName string, devices deviceConfig.Devices, networkProjectName string, networkName string) (bool, error) {
+	// Only resolve the network project of the devices if needed.
+	devicesNetworkProjectName := ""
+
 	for _, d := range devices {
 		if d["type"] != "nic" {
 			continue
 		}
 
-		nicType, err := nictype.NICType(s, d)
+		nicType, err := nictype.NICType(s, projectName, d)
 		if err != nil {
 			return false, err
 		}
 
-		if !shared.StringInSlice(nicType, []string{"bridged", "macvlan", "ipvlan", "physical", "sriov"}) {
+		if !shared.StringInSlice(nicType, []string{"bridged", "macvlan", "ipvlan", "physical", "sriov", "ovn"}) {
 			continue
 		}
 
 		if d["network"] != "" && d["network"] == networkName {
-			return true, nil
+			if devicesNetworkProjectName == "" {
+				devicesNetworkProjectName, err = project.NetworkProject(s.Cluster, projectName)
+				if err != nil {
+					return false, err
+				}
+			}
+
+			if devicesNetworkProjectName == networkProjectName {
+				return true, nil
+			}
+
+			continue
 		}
 
 		if d["parent"] == "" {
@@ -251,7 +300,8 @@ func UpdateDNSMasqStatic(s *state.State, networkName string) error {
 	var networks []string
 	if networkName == "" {
 		var err error
-		networks, err = s.Cluster.GetNetworks()
+		// Only bridge networks, which are part of the default project, use dnsmasq.
+		networks, err = s.Cluster.GetNetworks(project.Default)
 		if err != nil {
 			return err
 		}
@@ -275,7 +325,7 @@ func UpdateDNSMasqStatic(s *state.State, networkName string) error {
 				continue
 			}
 
-			nicType, err := nictype.NICType(s, d)
+			nicType, err := nictype.NICType(s, inst.Project(), d)
 			if err != nil || nicType != "bridged" {
 				continue
 			}
@@ -330,7 +380,7 @@ func UpdateDNSMasqStatic(s *state.State, networkName string) error {
 			continue
 		}
 
-		n, err := LoadByName(s, network)
+		n, err := LoadByName(s, project.Default, network)
 		if err != nil {
 			return err
 		}
@@ -398,6 +448,19 @@ func UpdateDNSMasqStatic(s *state.State, networkName string) error {
 			}
 		}
 
+		// Add the DHCP reservations of the network.
+		reservations, err := s.Cluster.GetNetworkReservations(n.ID())
+		if err != nil {
+			return err
+		}
+
+		for _, reservation := range reservations {
+			err = dnsmasq.UpdateReservationEntry(network, reservation.MAC, reservation.IPv4Address, reservation.IPv6Address)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Signal dnsmasq.
 		err = dnsmasq.Kill(network, true)
 		if err != nil {
@@ -731,7 +794,7 @@ func GetLeaseAddresses(s *state.State, networkName string, hwaddr string) ([]api
 		return addresses, nil
 	}
 
-	dbInfo, err := LoadByName(s, networkName)
+	dbInfo, err := LoadByName(s, project.Default, networkName)
 	if err != nil {
 		return nil, err
 	}
@@ -890,3 +953,195 @@ func BridgeVLANSetDefaultPVID(interfaceName string, vlanID string) error {
 
 	return nil
 }
+
+// bridgeMulticastSet sets a multicast option of a native bridge (such as "multicast_snooping").
+func bridgeMulticastSet(interfaceName string, option string, value string) error {
+	err := ioutil.WriteFile(fmt.Sprintf("/sys/class/net/%s/bridge/%s", interfaceName, option), []byte(value), 0)
+	if err != nil {
+		return errors.Wrapf(err, "Failed setting bridge %s for %q", option, interfaceName)
+	}
+
+	return nil
+}
+
+// ipRange represents a range of IP addresses from Start to End inclusive.
+type ipRange struct {
+	Start net.IP
+	End   net.IP
+}
+
+// contains returns whether the IP is within the range.
+func (r *ipRange) contains(ip net.IP) bool {
+	ip = ip.To16()
+	return bytes.Compare(ip, r.Start.To16()) >= 0 && bytes.Compare(ip, r.End.To16()) <= 0
+}
+
+// parseIPRanges parses a comma separated list of IP ranges ("<start>-<end>") of the specified IP version.
+func parseIPRanges(value string, ipVersion uint) ([]*ipRange, error) {
+	ipRanges := []*ipRange{}
+
+	for _, r := range strings.Split(value, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+
+		parts := strings.SplitN(r, "-", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("IP range %q must contain start and end IP addresses", r)
+		}
+
+		startIP := net.ParseIP(strings.TrimSpace(parts[0]))
+		endIP := net.ParseIP(strings.TrimSpace(parts[1]))
+		if startIP == nil || endIP == nil {
+			return nil, fmt.Errorf("IP range %q contains an invalid IP address", r)
+		}
+
+		if (ipVersion == 4) != (startIP.To4() != nil) || (ipVersion == 4) != (endIP.To4() != nil) {
+			return nil, fmt.Errorf("IP range %q must only contain IPv%d addresses", r, ipVersion)
+		}
+
+		if bytes.Compare(startIP.To16(), endIP.To16()) > 0 {
+			return nil, fmt.Errorf("IP range %q has a start address greater than its end address", r)
+		}
+
+		ipRanges = append(ipRanges, &ipRange{Start: startIP, End: endIP})
+	}
+
+	return ipRanges, nil
+}
+
+// validIPRanges returns a validator for a comma separated list of IP ranges of the specified IP version.
+func validIPRanges(ipVersion uint) func(value string) error {
+	return func(value string) error {
+		_, err := parseIPRanges(value, ipVersion)
+		return err
+	}
+}
+
+// nextIP returns the IP address following the supplied one.
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+
+	return next
+}
+
+// OVNNetworksUsingUplink returns the config of the OVN networks of all projects using the named network of the
+// default project as their uplink, indexed by network ID.
+func OVNNetworksUsingUplink(s *state.State, uplinkName string) (map[int64]map[string]string, error) {
+	projectNetworks, err := s.Cluster.GetNetworksAllProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	configs := map[int64]map[string]string{}
+	for networkProject, networks := range projectNetworks {
+		for _, name := range networks {
+			id, netInfo, err := s.Cluster.GetNetworkInAnyState(networkProject, name)
+			if err != nil {
+				return nil, err
+			}
+
+			if netInfo.Type == "ovn" && netInfo.Config["network"] == uplinkName {
+				configs[id] = netInfo.Config
+			}
+		}
+	}
+
+	return configs, nil
+}
+
+// validZone returns a validator that checks the network zone exists.
+func validZone(s *state.State) func(value string) error {
+	return func(value string) error {
+		return zone.Exists(s, value)
+	}
+}
+
+// validPeerName checks the network peering name is valid.
+func validPeerName(name string) error {
+	if name == "" {
+		return fmt.Errorf("Name is required")
+	}
+
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '-' && r != '_' {
+			return fmt.Errorf("Name can only contain alphanumeric, dash and underscore characters")
+		}
+	}
+
+	return nil
+}
+
+// prefixDelegationTimeout is how long to wait for the upstream DHCPv6 server when requesting a prefix.
+const prefixDelegationTimeout = 30 * time.Second
+
+// delegatedPrefix is a prefix delegated by an upstream DHCPv6 server.
+type delegatedPrefix struct {
+	prefix *net.IPNet
+	renew  time.Time
+}
+
+// requestDelegatedPrefix requests a prefix from the DHCPv6 servers reachable through the given interface. The
+// previously delegated prefix, if any, is hinted so that the server can keep delegating the same one.
+func requestDelegatedPrefix(ifName string, iaid [4]byte, hint *net.IPNet) (*delegatedPrefix, error) {
+	client, err := nclient6.New(ifName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed creating DHCPv6 client on %q", ifName)
+	}
+
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), prefixDelegationTimeout)
+	defer cancel()
+
+	hints := []*dhcpv6.OptIAPrefix{}
+	if hint != nil {
+		hints = append(hints, &dhcpv6.OptIAPrefix{Prefix: hint})
+	}
+
+	advertise, err := client.Solicit(ctx, dhcpv6.WithIAPD(iaid, hints...))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed soliciting DHCPv6 servers on %q", ifName)
+	}
+
+	// Request the advertised prefixes.
+	iapd := advertise.Options.OneIAPD()
+	if iapd == nil {
+		return nil, fmt.Errorf("No prefix advertised by the DHCPv6 server")
+	}
+
+	reply, err := client.Request(ctx, advertise, dhcpv6.WithIAPD(iaid, iapd.Options.Prefixes()...))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed requesting DHCPv6 prefix on %q", ifName)
+	}
+
+	iapd = reply.Options.OneIAPD()
+	if iapd == nil {
+		return nil, fmt.Errorf("No prefix delegated by the DHCPv6 server")
+	}
+
+	for _, prefix := range iapd.Options.Prefixes() {
+		if prefix.Prefix == nil || prefix.ValidLifetime == 0 {
+			continue
+		}
+
+		// Renew at T1, or half way through the preferred lifetime if the server didn't specify it.
+		renewIn := iapd.T1
+		if renewIn == 0 {
+			renewIn = prefix.PreferredLifetime / 2
+		}
+
+		return &delegatedPrefix{prefix: prefix.Prefix, renew: time.Now().Add(renewIn)}, nil
+	}
+
+	return nil, fmt.Errorf("No valid prefix delegated by the DHCPv6 server")
+}
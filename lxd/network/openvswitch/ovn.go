@@ -0,0 +1,562 @@
+package openvswitch
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// OVNRouter OVN router name.
+type OVNRouter string
+
+// OVNRouterPort OVN router port name.
+type OVNRouterPort string
+
+// OVNSwitch OVN switch name.
+type OVNSwitch string
+
+// OVNSwitchPort OVN switch port name.
+type OVNSwitchPort string
+
+// OVNChassisGroup OVN HA chassis group name.
+type OVNChassisGroup string
+
+// OVNDHCPOptsSet an existing DHCP options set in the northbound database.
+type OVNDHCPOptsSet string
+
+// ovnExtIDLXDSwitch external ID key used to identify the DHCP options set belonging to a logical switch.
+const ovnExtIDLXDSwitch = "lxd_switch"
+
+// OVNIPv6AddressMode IPv6 router advertisement address mode.
+type OVNIPv6AddressMode string
+
+// OVNIPv6AddressModeSLAAC IPv6 SLAAC mode.
+const OVNIPv6AddressModeSLAAC OVNIPv6AddressMode = "slaac"
+
+// OVNIPv6AddressModeDHCPStateful IPv6 DHCPv6 stateful mode.
+const OVNIPv6AddressModeDHCPStateful OVNIPv6AddressMode = "dhcpv6_stateful"
+
+// OVNDHCPv4Opts IPv4 DHCP option set that can be created (and then applied to a switch port by resulting ID).
+type OVNDHCPv4Opts struct {
+	ServerID           net.IP
+	ServerMAC          net.HardwareAddr
+	Router             net.IP
+	RecursiveDNSServer net.IP
+	DomainName         string
+	LeaseTime          uint64
+	MTU                uint32
+}
+
+// OVNSwitchPortOpts options that can be applied to a switch port.
+type OVNSwitchPortOpts struct {
+	MAC          net.HardwareAddr // Optional, if nil will be assigned dynamically.
+	IPs          []net.IP         // Optional, if empty IPs will be assigned dynamically.
+	DHCPv4OptsID OVNDHCPOptsSet   // Optional, if empty, no DHCP config will be applied.
+}
+
+// OVNRouterPeering represents a pair of logical router ports linking two logical routers directly, along with the
+// routes that each router sends over its side of the link.
+type OVNRouterPeering struct {
+	LocalRouter        OVNRouter
+	LocalRouterPort    OVNRouterPort
+	LocalRouterPortMAC net.HardwareAddr
+	LocalRouterPortIPs []*net.IPNet
+	LocalRouterRoutes  []*net.IPNet
+
+	TargetRouter        OVNRouter
+	TargetRouterPort    OVNRouterPort
+	TargetRouterPortMAC net.HardwareAddr
+	TargetRouterPortIPs []*net.IPNet
+	TargetRouterRoutes  []*net.IPNet
+}
+
+// NewOVN initialises new OVN wrapper.
+func NewOVN(dbAddr string) *OVN {
+	return &OVN{dbAddr: dbAddr}
+}
+
+// OVN command wrapper.
+type OVN struct {
+	dbAddr string
+}
+
+// nbctl executes ovn-nbctl with arguments to connect to the configured northbound database.
+func (o *OVN) nbctl(args ...string) (string, error) {
+	return shared.RunCommand("ovn-nbctl", append([]string{"--timeout=10", "--db", o.dbAddr}, args...)...)
+}
+
+// LogicalRouterAdd adds a named logical router.
+func (o *OVN) LogicalRouterAdd(routerName OVNRouter, mayExist bool) error {
+	args := []string{}
+
+	if mayExist {
+		args = append(args, "--may-exist")
+	}
+
+	_, err := o.nbctl(append(args, "lr-add", string(routerName))...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalRouterDelete deletes a named logical router.
+func (o *OVN) LogicalRouterDelete(routerName OVNRouter) error {
+	_, err := o.nbctl("--if-exists", "lr-del", string(routerName))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalRouterSNATAdd adds an SNAT rule to a logical router to translate packets from intNet to extIP.
+func (o *OVN) LogicalRouterSNATAdd(routerName OVNRouter, intNet *net.IPNet, extIP net.IP) error {
+	_, err := o.nbctl("--may-exist", "lr-nat-add", string(routerName), "snat", extIP.String(), intNet.String())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalRouterRouteAdd adds a static route to the logical router.
+func (o *OVN) LogicalRouterRouteAdd(routerName OVNRouter, destination *net.IPNet, nextHop net.IP) error {
+	_, err := o.nbctl("--may-exist", "lr-route-add", string(routerName), destination.String(), nextHop.String())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalRouterPortAdd adds a named logical router port to a logical router.
+// Any existing port of the same name is replaced so that the port's addresses reflect the supplied ones.
+func (o *OVN) LogicalRouterPortAdd(routerName OVNRouter, portName OVNRouterPort, mac net.HardwareAddr, ipAddr ...*net.IPNet) error {
+	args := []string{"--if-exists", "lrp-del", string(portName), "--", "lrp-add", string(routerName), string(portName), mac.String()}
+
+	for _, ipNet := range ipAddr {
+		args = append(args, ipNet.String())
+	}
+
+	_, err := o.nbctl(args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalRouterPeeringApply connects the two routers of the peering with a pair of linked router ports and adds
+// routes on each router towards the subnets of the other, using the other side's port address as next hop.
+// Any existing ports of the same names are replaced.
+func (o *OVN) LogicalRouterPeeringApply(opts OVNRouterPeering) error {
+	err := o.LogicalRouterPortAdd(opts.LocalRouter, opts.LocalRouterPort, opts.LocalRouterPortMAC, opts.LocalRouterPortIPs...)
+	if err != nil {
+		return err
+	}
+
+	err = o.LogicalRouterPortAdd(opts.TargetRouter, opts.TargetRouterPort, opts.TargetRouterPortMAC, opts.TargetRouterPortIPs...)
+	if err != nil {
+		return err
+	}
+
+	_, err = o.nbctl("set", "logical_router_port", string(opts.LocalRouterPort), fmt.Sprintf("peer=%s", opts.TargetRouterPort))
+	if err != nil {
+		return err
+	}
+
+	_, err = o.nbctl("set", "logical_router_port", string(opts.TargetRouterPort), fmt.Sprintf("peer=%s", opts.LocalRouterPort))
+	if err != nil {
+		return err
+	}
+
+	err = o.logicalRouterPeeringRoutesAdd(opts.LocalRouter, opts.LocalRouterPort, opts.LocalRouterRoutes, opts.TargetRouterPortIPs)
+	if err != nil {
+		return err
+	}
+
+	err = o.logicalRouterPeeringRoutesAdd(opts.TargetRouter, opts.TargetRouterPort, opts.TargetRouterRoutes, opts.LocalRouterPortIPs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// logicalRouterPeeringRoutesAdd adds routes for the destinations out of the peer port, using the next hop address
+// of the same family.
+func (o *OVN) logicalRouterPeeringRoutesAdd(routerName OVNRouter, portName OVNRouterPort, destinations []*net.IPNet, nextHops []*net.IPNet) error {
+	for _, dest := range destinations {
+		var nextHop net.IP
+		for _, hop := range nextHops {
+			if (hop.IP.To4() == nil) == (dest.IP.To4() == nil) {
+				nextHop = hop.IP
+				break
+			}
+		}
+
+		if nextHop == nil {
+			return fmt.Errorf("No next hop address available for route %q", dest.String())
+		}
+
+		_, err := o.nbctl("--may-exist", "lr-route-add", string(routerName), dest.String(), nextHop.String(), string(portName))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LogicalRouterPeeringDelete removes the peer ports and the routes of the peering from both routers.
+func (o *OVN) LogicalRouterPeeringDelete(opts OVNRouterPeering) error {
+	for _, dest := range opts.LocalRouterRoutes {
+		_, err := o.nbctl("--if-exists", "lr-route-del", string(opts.LocalRouter), dest.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, dest := range opts.TargetRouterRoutes {
+		_, err := o.nbctl("--if-exists", "lr-route-del", string(opts.TargetRouter), dest.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := o.nbctl("--if-exists", "lrp-del", string(opts.LocalRouterPort), "--", "--if-exists", "lrp-del", string(opts.TargetRouterPort))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalRouterPortSetIPv6Advertisements sets the IPv6 router advertisement options on a router port.
+func (o *OVN) LogicalRouterPortSetIPv6Advertisements(portName OVNRouterPort, addressMode OVNIPv6AddressMode) error {
+	_, err := o.nbctl("set", "logical_router_port", string(portName),
+		fmt.Sprintf("ipv6_ra_configs:address_mode=%s", addressMode),
+		"ipv6_ra_configs:send_periodic=true",
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalRouterPortLinkChassisGroup links a logical router port to a HA chassis group.
+func (o *OVN) LogicalRouterPortLinkChassisGroup(portName OVNRouterPort, haChassisGroupName OVNChassisGroup) error {
+	chassisGroupID, err := o.chassisGroupID(haChassisGroupName)
+	if err != nil {
+		return err
+	}
+
+	if chassisGroupID == "" {
+		return fmt.Errorf("HA chassis group %q not found", haChassisGroupName)
+	}
+
+	_, err = o.nbctl("set", "logical_router_port", string(portName), fmt.Sprintf("ha_chassis_group=%s", chassisGroupID))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalSwitchAdd adds a named logical switch.
+func (o *OVN) LogicalSwitchAdd(switchName OVNSwitch, mayExist bool) error {
+	args := []string{}
+
+	if mayExist {
+		args = append(args, "--may-exist")
+	}
+
+	_, err := o.nbctl(append(args, "ls-add", string(switchName))...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalSwitchDelete deletes a named logical switch and the DHCP options sets associated to it.
+func (o *OVN) LogicalSwitchDelete(switchName OVNSwitch) error {
+	_, err := o.nbctl("--if-exists", "ls-del", string(switchName))
+	if err != nil {
+		return err
+	}
+
+	dhcpOptsID, err := o.LogicalSwitchDHCPOptionsGet(switchName)
+	if err != nil {
+		return err
+	}
+
+	if dhcpOptsID != "" {
+		_, err = o.nbctl("dhcp-options-del", string(dhcpOptsID))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LogicalSwitchSetIPAllocation sets the dynamic IP allocation config on a logical switch.
+func (o *OVN) LogicalSwitchSetIPAllocation(switchName OVNSwitch, ipv4Subnet *net.IPNet, ipv4ExcludeIP net.IP, ipv6Prefix *net.IPNet) error {
+	args := []string{"set", "logical_switch", string(switchName)}
+
+	if ipv4Subnet != nil {
+		args = append(args, fmt.Sprintf("other_config:subnet=%s", ipv4Subnet.String()))
+
+		if ipv4ExcludeIP != nil {
+			args = append(args, fmt.Sprintf("other_config:exclude_ips=%s", ipv4ExcludeIP.String()))
+		}
+	}
+
+	if ipv6Prefix != nil {
+		args = append(args, fmt.Sprintf("other_config:ipv6_prefix=%s", ipv6Prefix.IP.String()))
+	}
+
+	if len(args) <= 3 {
+		return nil // Nothing to set.
+	}
+
+	_, err := o.nbctl(args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalSwitchDHCPOptionsGet returns the ID of the DHCP options set associated to the logical switch, if any.
+func (o *OVN) LogicalSwitchDHCPOptionsGet(switchName OVNSwitch) (OVNDHCPOptsSet, error) {
+	dhcpOptsID, err := o.nbctl("--format=csv", "--no-headings", "--data=bare", "--columns=_uuid", "find", "dhcp_options",
+		fmt.Sprintf("external_ids:%s=%s", ovnExtIDLXDSwitch, string(switchName)),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return OVNDHCPOptsSet(strings.TrimSpace(dhcpOptsID)), nil
+}
+
+// LogicalSwitchDHCPv4OptionsSet creates or updates the DHCPv4 options set associated to the logical switch.
+// Returns the ID of the DHCP options set so that it can be applied to the switch ports.
+func (o *OVN) LogicalSwitchDHCPv4OptionsSet(switchName OVNSwitch, subnet *net.IPNet, opts *OVNDHCPv4Opts) (OVNDHCPOptsSet, error) {
+	dhcpOptsID, err := o.LogicalSwitchDHCPOptionsGet(switchName)
+	if err != nil {
+		return "", err
+	}
+
+	if dhcpOptsID == "" {
+		newID, err := o.nbctl("create", "dhcp_options",
+			fmt.Sprintf("cidr=%s", subnet.String()),
+			fmt.Sprintf("external_ids:%s=%s", ovnExtIDLXDSwitch, string(switchName)),
+		)
+		if err != nil {
+			return "", err
+		}
+
+		dhcpOptsID = OVNDHCPOptsSet(strings.TrimSpace(newID))
+	} else {
+		_, err = o.nbctl("set", "dhcp_options", string(dhcpOptsID), fmt.Sprintf("cidr=%s", subnet.String()))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	args := []string{"dhcp-options-set-options", string(dhcpOptsID),
+		fmt.Sprintf("server_id=%s", opts.ServerID.String()),
+		fmt.Sprintf("server_mac=%s", opts.ServerMAC.String()),
+		fmt.Sprintf("lease_time=%d", opts.LeaseTime),
+	}
+
+	if opts.Router != nil {
+		args = append(args, fmt.Sprintf("router=%s", opts.Router.String()))
+	}
+
+	if opts.RecursiveDNSServer != nil {
+		args = append(args, fmt.Sprintf("dns_server=%s", opts.RecursiveDNSServer.String()))
+	}
+
+	if opts.DomainName != "" {
+		// Special quoting to allow domain names.
+		args = append(args, fmt.Sprintf(`domain_name="%s"`, opts.DomainName))
+	}
+
+	if opts.MTU > 0 {
+		args = append(args, fmt.Sprintf("mtu=%d", opts.MTU))
+	}
+
+	_, err = o.nbctl(args...)
+	if err != nil {
+		return "", err
+	}
+
+	return dhcpOptsID, nil
+}
+
+// LogicalSwitchPortAdd adds a named logical switch port to a logical switch.
+func (o *OVN) LogicalSwitchPortAdd(switchName OVNSwitch, portName OVNSwitchPort, mayExist bool) error {
+	args := []string{}
+
+	if mayExist {
+		args = append(args, "--may-exist")
+	}
+
+	_, err := o.nbctl(append(args, "lsp-add", string(switchName), string(portName))...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalSwitchPortSet sets the addresses and DHCP options of a logical switch port.
+func (o *OVN) LogicalSwitchPortSet(portName OVNSwitchPort, opts *OVNSwitchPortOpts) error {
+	addresses := []string{"dynamic"}
+	if opts.MAC != nil {
+		addresses = []string{opts.MAC.String()}
+
+		if len(opts.IPs) > 0 {
+			for _, ip := range opts.IPs {
+				addresses = append(addresses, ip.String())
+			}
+		} else {
+			addresses = append(addresses, "dynamic")
+		}
+	}
+
+	args := []string{"lsp-set-addresses", string(portName), strings.Join(addresses, " ")}
+
+	if opts.DHCPv4OptsID != "" {
+		args = append(args, "--", "lsp-set-dhcpv4-options", string(portName), string(opts.DHCPv4OptsID))
+	}
+
+	_, err := o.nbctl(args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalSwitchPortDelete deletes a named logical switch port.
+func (o *OVN) LogicalSwitchPortDelete(portName OVNSwitchPort) error {
+	_, err := o.nbctl("--if-exists", "lsp-del", string(portName))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalSwitchPortLinkRouter links a logical switch port to a logical router port.
+func (o *OVN) LogicalSwitchPortLinkRouter(switchPortName OVNSwitchPort, routerPortName OVNRouterPort) error {
+	// Connect logical router port to switch.
+	_, err := o.nbctl(
+		"lsp-set-type", string(switchPortName), "router", "--",
+		"lsp-set-addresses", string(switchPortName), "router", "--",
+		"lsp-set-options", string(switchPortName), fmt.Sprintf("router-port=%s", string(routerPortName)),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalSwitchPortLinkProviderNetwork links a logical switch port to a provider network.
+func (o *OVN) LogicalSwitchPortLinkProviderNetwork(switchPortName OVNSwitchPort, extNetworkName string) error {
+	// Forward any unknown MAC frames down this port.
+	_, err := o.nbctl(
+		"lsp-set-addresses", string(switchPortName), "unknown", "--",
+		"lsp-set-type", string(switchPortName), "localnet", "--",
+		"lsp-set-options", string(switchPortName), fmt.Sprintf("network_name=%s", extNetworkName),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// chassisGroupID returns the ID of the named HA chassis group, or empty string if it doesn't exist.
+func (o *OVN) chassisGroupID(haChassisGroupName OVNChassisGroup) (string, error) {
+	chassisGroupID, err := o.nbctl("--format=csv", "--no-headings", "--data=bare", "--columns=_uuid", "find", "ha_chassis_group",
+		fmt.Sprintf("name=%s", string(haChassisGroupName)),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(chassisGroupID), nil
+}
+
+// ChassisGroupAdd adds a new HA chassis group.
+// If mayExist is true, then an existing resource of the same name is not treated as an error.
+func (o *OVN) ChassisGroupAdd(haChassisGroupName OVNChassisGroup, mayExist bool) error {
+	if mayExist {
+		chassisGroupID, err := o.chassisGroupID(haChassisGroupName)
+		if err != nil {
+			return err
+		}
+
+		if chassisGroupID != "" {
+			return nil
+		}
+	}
+
+	_, err := o.nbctl("ha-chassis-group-add", string(haChassisGroupName))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ChassisGroupDelete deletes a HA chassis group (if it exists).
+func (o *OVN) ChassisGroupDelete(haChassisGroupName OVNChassisGroup) error {
+	chassisGroupID, err := o.chassisGroupID(haChassisGroupName)
+	if err != nil {
+		return err
+	}
+
+	if chassisGroupID == "" {
+		return nil
+	}
+
+	_, err = o.nbctl("ha-chassis-group-del", string(haChassisGroupName))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ChassisGroupChassisAdd adds a chassis ID to an HA chassis group with the specified priority.
+// If the chassis is already a member of the group, its priority is updated.
+func (o *OVN) ChassisGroupChassisAdd(haChassisGroupName OVNChassisGroup, chassisID string, priority uint) error {
+	_, err := o.nbctl("ha-chassis-group-add-chassis", string(haChassisGroupName), chassisID, fmt.Sprintf("%d", priority))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ChassisGroupChassisDelete deletes a chassis ID from an HA chassis group.
+func (o *OVN) ChassisGroupChassisDelete(haChassisGroupName OVNChassisGroup, chassisID string) error {
+	_, err := o.nbctl("ha-chassis-group-remove-chassis", string(haChassisGroupName), chassisID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -1,7 +1,10 @@
 package openvswitch
 
 import (
+	"fmt"
 	"os/exec"
+	"strconv"
+	"strings"
 
 	"github.com/lxc/lxd/shared"
 )
@@ -72,6 +75,26 @@ func (o *OVS) BridgeDelete(bridgeName string) error {
 	return nil
 }
 
+// BridgeSet sets bridge options.
+func (o *OVS) BridgeSet(bridgeName string, options ...string) error {
+	_, err := shared.RunCommand("ovs-vsctl", append([]string{"set", "bridge", bridgeName}, options...)...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// BridgePorts returns the names of the ports of the bridge.
+func (o *OVS) BridgePorts(bridgeName string) ([]string, error) {
+	output, err := shared.RunCommand("ovs-vsctl", "list-ports", bridgeName)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(output), nil
+}
+
 // BridgePortAdd adds a port to the bridge (if already attached does nothing).
 func (o *OVS) BridgePortAdd(bridgeName string, portName string, mayExist bool) error {
 	args := []string{}
@@ -109,3 +132,187 @@ func (o *OVS) BridgePortSet(portName string, options ...string) error {
 
 	return nil
 }
+
+// PortVLANs returns the access VLAN tag (0 if none) and the trunk VLANs of the port.
+func (o *OVS) PortVLANs(portName string) (uint64, []uint64, error) {
+	output, err := shared.RunCommand("ovs-vsctl", "get", "port", portName, "tag", "trunks")
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// The values are output one per line, empty values being "[]".
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		return 0, nil, fmt.Errorf("Unexpected output for VLANs of port %q", portName)
+	}
+
+	tag, _ := strconv.ParseUint(strings.TrimSpace(lines[0]), 10, 64)
+
+	trunks := []uint64{}
+	for _, value := range strings.Split(strings.Trim(strings.TrimSpace(lines[1]), "[]"), ",") {
+		vlan, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err == nil {
+			trunks = append(trunks, vlan)
+		}
+	}
+
+	return tag, trunks, nil
+}
+
+// InterfaceAssociateOVNSwitchPort sets the OVN logical switch port linked to OVS interface.
+func (o *OVS) InterfaceAssociateOVNSwitchPort(interfaceName string, ovnSwitchPortName OVNSwitchPort) error {
+	_, err := shared.RunCommand("ovs-vsctl", "set", "interface", interfaceName, fmt.Sprintf("external_ids:iface-id=%s", string(ovnSwitchPortName)))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InterfaceSetIngressPolicing limits the rate (in kbit/s) of the traffic OVS receives from the interface,
+// a rate of 0 removes the limit.
+func (o *OVS) InterfaceSetIngressPolicing(interfaceName string, rate uint64) error {
+	// Allow bursts of a tenth of a second of traffic, OVS' default burst size is used when the rate is 0.
+	burst := rate / 10
+
+	_, err := shared.RunCommand("ovs-vsctl", "set", "interface", interfaceName, fmt.Sprintf("ingress_policing_rate=%d", rate), fmt.Sprintf("ingress_policing_burst=%d", burst))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PortSetQoSMaxRate limits the rate (in bit/s) of the traffic OVS sends through the port using a linux-htb QoS,
+// a rate of 0 removes the limit.
+func (o *OVS) PortSetQoSMaxRate(portName string, rate uint64) error {
+	// Remove the existing QoS record of the port (if any), as QoS records aren't garbage collected.
+	qos, err := shared.RunCommand("ovs-vsctl", "get", "port", portName, "qos")
+	if err != nil {
+		return err
+	}
+
+	qos = strings.TrimSpace(qos)
+	if qos != "" && qos != "[]" {
+		_, err = shared.RunCommand("ovs-vsctl", "clear", "port", portName, "qos", "--", "destroy", "qos", qos)
+		if err != nil {
+			return err
+		}
+	}
+
+	if rate == 0 {
+		return nil
+	}
+
+	_, err = shared.RunCommand("ovs-vsctl", "set", "port", portName, "qos=@qos", "--", "--id=@qos", "create", "qos", "type=linux-htb", fmt.Sprintf("other-config:max-rate=%d", rate))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ChassisID returns the local chassis ID.
+func (o *OVS) ChassisID() (string, error) {
+	// ovs-vsctl's get command doesn't support its --format flag, so we always get the output quoted.
+	// However ovs-vsctl's find and list commands don't support retrieving a single column's map field.
+	// And ovs-vsctl's JSON output is unfriendly towards statically typed languages as it mixes data types
+	// in a slice. So stick with "get" command and use Go's strconv.Unquote to return the actual values.
+	chassisID, err := shared.RunCommand("ovs-vsctl", "get", "open_vswitch", ".", "external_ids:system-id")
+	if err != nil {
+		return "", err
+	}
+
+	chassisID = strings.TrimSpace(chassisID)
+	chassisID, err = strconv.Unquote(chassisID)
+	if err != nil {
+		return "", err
+	}
+
+	return chassisID, nil
+}
+
+// OVNBridgeMappings gets the current OVN bridge mappings.
+func (o *OVS) OVNBridgeMappings() ([]string, error) {
+	// ovs-vsctl's get command doesn't support its --format flag, so we always get the output quoted.
+	mappings, err := shared.RunCommand("ovs-vsctl", "--if-exists", "get", "open_vswitch", ".", "external-ids:ovn-bridge-mappings")
+	if err != nil {
+		return nil, err
+	}
+
+	mappings = strings.TrimSpace(mappings)
+	if mappings == "" {
+		return []string{}, nil
+	}
+
+	mappings, err = strconv.Unquote(mappings)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.SplitN(mappings, ",", -1), nil
+}
+
+// OVNBridgeMappingAdd appends an OVN bridge mapping between an OVS bridge and the logical provider name.
+func (o *OVS) OVNBridgeMappingAdd(bridgeName string, providerName string) error {
+	mappings, err := o.OVNBridgeMappings()
+	if err != nil {
+		return err
+	}
+
+	newMapping := fmt.Sprintf("%s:%s", providerName, bridgeName)
+	for _, mapping := range mappings {
+		if mapping == newMapping {
+			return nil // Mapping is already present, nothing to do.
+		}
+	}
+
+	mappings = append(mappings, newMapping)
+
+	// Set new mapping string back into OVS database.
+	_, err = shared.RunCommand("ovs-vsctl", "set", "open_vswitch", ".", fmt.Sprintf("external-ids:ovn-bridge-mappings=%s", strings.Join(mappings, ",")))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// OVNBridgeMappingDelete deletes an OVN bridge mapping between an OVS bridge and the logical provider name.
+func (o *OVS) OVNBridgeMappingDelete(bridgeName string, providerName string) error {
+	mappings, err := o.OVNBridgeMappings()
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	newMappings := make([]string, 0, len(mappings))
+	matchMapping := fmt.Sprintf("%s:%s", providerName, bridgeName)
+	for _, mapping := range mappings {
+		if mapping != matchMapping {
+			newMappings = append(newMappings, mapping)
+		} else {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil // Mapping is not present, nothing to do.
+	}
+
+	if len(newMappings) > 0 {
+		// Set new mapping string back into OVS database.
+		_, err = shared.RunCommand("ovs-vsctl", "set", "open_vswitch", ".", fmt.Sprintf("external-ids:ovn-bridge-mappings=%s", strings.Join(newMappings, ",")))
+		if err != nil {
+			return err
+		}
+	} else {
+		// Remove mapping key from OVS database.
+		_, err = shared.RunCommand("ovs-vsctl", "remove", "open_vswitch", ".", "external-ids", "ovn-bridge-mappings")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,537 @@
+package zone
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/dnsmasq"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/logging"
+	"github.com/lxc/lxd/shared/validate"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// networkZoneKeys are the network config keys that can reference a zone.
+var networkZoneKeys = []string{"dns.zone.forward", "dns.zone.reverse.ipv4", "dns.zone.reverse.ipv6"}
+
+// record represents a single DNS resource record of a zone.
+type record struct {
+	name  string
+	ttl   int
+	rType string
+	value string
+}
+
+// common represents a LXD network zone.
+type common struct {
+	logger logger.Logger
+	state  *state.State
+	id     int64
+	info   *api.NetworkZone
+}
+
+// init initialise internal variables.
+func (d *common) init(state *state.State, id int64, info *api.NetworkZone) {
+	d.logger = logging.AddContext(logger.Log, log.Ctx{"networkZone": info.Name})
+	d.state = state
+	d.id = id
+	d.info = info
+}
+
+// ID returns the network zone ID.
+func (d *common) ID() int64 {
+	return d.id
+}
+
+// Name returns the network zone name.
+func (d *common) Name() string {
+	return d.info.Name
+}
+
+// Info returns a copy of the network zone info.
+func (d *common) Info() *api.NetworkZone {
+	info := *d.info
+
+	return &info
+}
+
+// Etag returns the values used for etag generation.
+func (d *common) Etag() []interface{} {
+	return []interface{}{d.info.Name, d.info.Description, d.info.Config}
+}
+
+// usedByNetworks returns the configs of the networks referencing the zone, keyed by network name.
+// Only the networks of the default project can reference zones.
+func (d *common) usedByNetworks() (map[string]map[string]string, error) {
+	networks := map[string]map[string]string{}
+
+	networkNames, err := d.state.Cluster.GetNetworks(project.Default)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, networkName := range networkNames {
+		_, netInfo, err := d.state.Cluster.GetNetworkInAnyState(project.Default, networkName)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range networkZoneKeys {
+			if netInfo.Config[key] == d.info.Name {
+				networks[networkName] = netInfo.Config
+				break
+			}
+		}
+	}
+
+	return networks, nil
+}
+
+// UsedBy returns the URLs of the networks using the zone.
+func (d *common) UsedBy() ([]string, error) {
+	networks, err := d.usedByNetworks()
+	if err != nil {
+		return nil, err
+	}
+
+	usedBy := make([]string, 0, len(networks))
+	for networkName := range networks {
+		usedBy = append(usedBy, fmt.Sprintf("/%s/networks/%s", version.APIVersion, networkName))
+	}
+
+	sort.Strings(usedBy)
+
+	return usedBy, nil
+}
+
+// Content returns the zone in the standard zone file format (RFC 1035), including the SOA and NS records.
+func (d *common) Content() (string, error) {
+	records, err := d.records()
+	if err != nil {
+		return "", err
+	}
+
+	// Use the first name server as the primary one, falling back to the zone itself.
+	nameservers := []string{}
+	for _, nameserver := range splitList(d.info.Config["dns.nameservers"]) {
+		nameservers = append(nameservers, dns.Fqdn(nameserver))
+	}
+
+	primary := dns.Fqdn(d.info.Name)
+	if len(nameservers) > 0 {
+		primary = nameservers[0]
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "$ORIGIN %s\n", dns.Fqdn(d.info.Name))
+	fmt.Fprintf(&sb, "@ 3600 IN SOA %s hostmaster.%s %d 120 60 86400 30\n", primary, dns.Fqdn(d.info.Name), time.Now().Unix())
+
+	for _, nameserver := range nameservers {
+		fmt.Fprintf(&sb, "@ 300 IN NS %s\n", nameserver)
+	}
+
+	for _, r := range records {
+		fmt.Fprintf(&sb, "%s %d IN %s %s\n", r.name, r.ttl, r.rType, r.value)
+	}
+
+	return sb.String(), nil
+}
+
+// records generates the forward and reverse records of the networks using the zone.
+func (d *common) records() ([]record, error) {
+	networks, err := d.usedByNetworks()
+	if err != nil {
+		return nil, err
+	}
+
+	records := []record{}
+
+	// addReverse adds a PTR record for the address if it falls within the zone.
+	zoneSuffix := "." + dns.Fqdn(d.info.Name)
+	addReverse := func(ip net.IP, target string) {
+		reverseName, err := dns.ReverseAddr(ip.String())
+		if err != nil || !strings.HasSuffix(reverseName, zoneSuffix) {
+			return
+		}
+
+		records = append(records, record{name: strings.TrimSuffix(reverseName, zoneSuffix), ttl: 300, rType: "PTR", value: target})
+	}
+
+	for networkName, netConfig := range networks {
+		forwardZone := netConfig["dns.zone.forward"]
+
+		addresses, err := d.instanceAddresses(networkName)
+		if err != nil {
+			return nil, err
+		}
+
+		// Add the network gateway addresses.
+		gateways := []net.IP{}
+		for _, key := range []string{"ipv4.address", "ipv6.address"} {
+			ip, _, err := net.ParseCIDR(netConfig[key])
+			if err == nil {
+				gateways = append(gateways, ip)
+			}
+		}
+
+		addresses[fmt.Sprintf("%s.gw", networkName)] = gateways
+
+		for name, ips := range addresses {
+			for _, ip := range ips {
+				if forwardZone == d.info.Name {
+					rType := "A"
+					if ip.To4() == nil {
+						rType = "AAAA"
+					}
+
+					records = append(records, record{name: name, ttl: 300, rType: rType, value: ip.String()})
+				}
+
+				// Reverse records can only point to names within the network's forward zone.
+				if forwardZone == "" {
+					continue
+				}
+
+				target := fmt.Sprintf("%s.%s", name, dns.Fqdn(forwardZone))
+				if ip.To4() != nil && netConfig["dns.zone.reverse.ipv4"] == d.info.Name {
+					addReverse(ip, target)
+				} else if ip.To4() == nil && netConfig["dns.zone.reverse.ipv6"] == d.info.Name {
+					addReverse(ip, target)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].name < records[j].name
+	})
+
+	return records, nil
+}
+
+// memberLeasesExpiry is how long the leases retrieved from the other cluster members are cached, to avoid
+// querying all the members for each DNS query.
+const memberLeasesExpiry = 10 * time.Second
+
+// memberLeasesCache holds the dynamic leases of the other cluster members, keyed by network name.
+var memberLeasesCache = map[string]memberLeasesEntry{}
+var memberLeasesCacheLock sync.Mutex
+
+// memberLeasesEntry holds the dynamic leases of the other cluster members at a given time.
+type memberLeasesEntry struct {
+	leases    []api.NetworkLease
+	retrieved time.Time
+}
+
+// memberLeases returns the dynamic leases of the network on the other cluster members, which are only known to
+// the member the instance runs on.
+func (d *common) memberLeases(networkName string) ([]api.NetworkLease, error) {
+	memberLeasesCacheLock.Lock()
+	defer memberLeasesCacheLock.Unlock()
+
+	cached, ok := memberLeasesCache[networkName]
+	if ok && time.Since(cached.retrieved) < memberLeasesExpiry {
+		return cached.leases, nil
+	}
+
+	leases := []api.NetworkLease{}
+
+	notifier, err := cluster.NewNotifier(d.state, d.state.Endpoints.NetworkCert(), cluster.NotifyAlive)
+	if err != nil {
+		return nil, err
+	}
+
+	// Members only return their local dynamic leases to cluster notifications.
+	var mu sync.Mutex
+	err = notifier(func(client lxd.InstanceServer) error {
+		clientLeases, err := client.UseProject(project.Default).GetNetworkLeases(networkName)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, lease := range clientLeases {
+			if lease.Type == "dynamic" {
+				leases = append(leases, lease)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	memberLeasesCache[networkName] = memberLeasesEntry{leases: leases, retrieved: time.Now()}
+
+	return leases, nil
+}
+
+// instanceAddresses returns the addresses of the instance NICs connected to the network, keyed by record name.
+// Instances outside of the default project are named "<instance>.<project>". Static addresses are taken from
+// the NIC config and dynamic addresses from the DHCP leases of the network across all the cluster members.
+func (d *common) instanceAddresses(networkName string) (map[string][]net.IP, error) {
+	addresses := map[string][]net.IP{}
+
+	// Index the dynamic addresses by MAC address.
+	dynamic := map[string][]net.IP{}
+
+	if shared.PathExists(shared.VarPath("networks", networkName, "dnsmasq.leases")) {
+		leasesIPv4, leasesIPv6, err := dnsmasq.DHCPAllAllocations(networkName)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, lease := range leasesIPv4 {
+			if lease.MAC != nil {
+				dynamic[lease.MAC.String()] = append(dynamic[lease.MAC.String()], lease.IP)
+			}
+		}
+
+		for _, lease := range leasesIPv6 {
+			if lease.MAC != nil {
+				dynamic[lease.MAC.String()] = append(dynamic[lease.MAC.String()], lease.IP)
+			}
+		}
+	}
+
+	leases, err := d.memberLeases(networkName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, lease := range leases {
+		ip := net.ParseIP(lease.Address)
+		if ip != nil && lease.Hwaddr != "" {
+			dynamic[strings.ToLower(lease.Hwaddr)] = append(dynamic[strings.ToLower(lease.Hwaddr)], ip)
+		}
+	}
+
+	insts, err := instance.LoadFromAllProjects(d.state)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inst := range insts {
+		name := inst.Name()
+		if inst.Project() != project.Default {
+			name = fmt.Sprintf("%s.%s", inst.Name(), inst.Project())
+		}
+
+		for devName, devConfig := range inst.ExpandedDevices() {
+			if devConfig["type"] != "nic" {
+				continue
+			}
+
+			if devConfig["network"] != networkName && !(devConfig["nictype"] == "bridged" && devConfig["parent"] == networkName) {
+				continue
+			}
+
+			for _, key := range []string{"ipv4.address", "ipv6.address"} {
+				ip := net.ParseIP(devConfig[key])
+				if ip != nil {
+					addresses[name] = append(addresses[name], ip)
+				}
+			}
+
+			hwaddr := devConfig["hwaddr"]
+			if hwaddr == "" {
+				hwaddr = inst.LocalConfig()[fmt.Sprintf("volatile.%s.hwaddr", devName)]
+			}
+
+			// Add the dynamic addresses of the IP versions without a static address.
+			for _, ip := range dynamic[strings.ToLower(hwaddr)] {
+				if ip.To4() != nil && devConfig["ipv4.address"] != "" {
+					continue
+				}
+
+				if ip.To4() == nil && devConfig["ipv6.address"] != "" {
+					continue
+				}
+
+				addresses[name] = append(addresses[name], ip)
+			}
+		}
+	}
+
+	return addresses, nil
+}
+
+// Update applies the supplied config to the zone. If not a cluster notification, the config is stored in the
+// database and the other members are notified too.
+func (d *common) Update(config *api.NetworkZonePut, clusterNotification bool) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	if !clusterNotification {
+		err := validateConfig(config)
+		if err != nil {
+			return err
+		}
+
+		oldConfig := d.info.NetworkZonePut
+
+		err = d.state.Cluster.UpdateNetworkZone(d.id, config)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() {
+			d.state.Cluster.UpdateNetworkZone(d.id, &oldConfig)
+			d.info.NetworkZonePut = oldConfig
+		})
+	}
+
+	d.info.NetworkZonePut = *config
+
+	if !clusterNotification {
+		notifier, err := cluster.NewNotifier(d.state, d.state.Endpoints.NetworkCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client lxd.InstanceServer) error {
+			return client.UpdateNetworkZone(d.info.Name, *config, "")
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// Delete deletes the zone if it isn't in use.
+func (d *common) Delete() error {
+	usedBy, err := d.UsedBy()
+	if err != nil {
+		return err
+	}
+
+	if len(usedBy) > 0 {
+		return fmt.Errorf("Cannot delete a zone that is in use")
+	}
+
+	return d.state.Cluster.DeleteNetworkZone(d.id)
+}
+
+// ValidName checks the zone name is a valid DNS domain name.
+func ValidName(name string) error {
+	if name == "" {
+		return fmt.Errorf("Name is required")
+	}
+
+	if len(name) > 253 {
+		return fmt.Errorf("Name must be 253 characters or less")
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 {
+			return fmt.Errorf("Name labels must be between 1 and 63 characters long")
+		}
+
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return fmt.Errorf("Name labels cannot begin or end with a dash")
+		}
+
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '-' {
+				return fmt.Errorf("Name can only contain alphanumeric, dash and dot characters")
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateConfig checks the supplied zone config is valid.
+func validateConfig(config *api.NetworkZonePut) error {
+	rules := map[string]func(value string) error{
+		"dns.nameservers": validate.Optional(func(value string) error {
+			for _, nameserver := range splitList(value) {
+				err := ValidName(strings.TrimSuffix(nameserver, "."))
+				if err != nil {
+					return fmt.Errorf("Invalid name server %q: %v", nameserver, err)
+				}
+			}
+
+			return nil
+		}),
+	}
+
+	for k, v := range config.Config {
+		if strings.HasPrefix(k, "user.") {
+			continue
+		}
+
+		// Peer keys are in the form "peers.<name>.<field>".
+		if strings.HasPrefix(k, "peers.") {
+			fields := strings.SplitN(k, ".", 3)
+			if len(fields) != 3 || fields[1] == "" {
+				return fmt.Errorf("Invalid option %q", k)
+			}
+
+			switch fields[2] {
+			case "address":
+				err := validate.IsNetworkAddress(v)
+				if err != nil {
+					return fmt.Errorf("Invalid value for %q: %v", k, err)
+				}
+			case "key":
+				_, err := base64.StdEncoding.DecodeString(v)
+				if err != nil {
+					return fmt.Errorf("Invalid value for %q: TSIG key must be base64 encoded", k)
+				}
+			default:
+				return fmt.Errorf("Invalid option %q", k)
+			}
+
+			continue
+		}
+
+		validator, ok := rules[k]
+		if !ok {
+			return fmt.Errorf("Invalid option %q", k)
+		}
+
+		err := validator(v)
+		if err != nil {
+			return fmt.Errorf("Invalid value for %q: %v", k, err)
+		}
+	}
+
+	return nil
+}
+
+// splitList splits a comma separated list, ignoring empty entries.
+func splitList(value string) []string {
+	entries := []string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
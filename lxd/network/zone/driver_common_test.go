@@ -0,0 +1,44 @@
+package zone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestValidName(t *testing.T) {
+	assert.NoError(t, ValidName("lxd.example.net"))
+	assert.NoError(t, ValidName("2.0.192.in-addr.arpa"))
+	assert.Error(t, ValidName(""))
+	assert.Error(t, ValidName("lxd..example.net"))
+	assert.Error(t, ValidName("-lxd.example.net"))
+	assert.Error(t, ValidName("lxd_example.net"))
+}
+
+func TestValidateConfig(t *testing.T) {
+	valid := []map[string]string{
+		{},
+		{"user.foo": "bar"},
+		{"dns.nameservers": "ns1.example.net, ns2.example.net."},
+		{"peers.ns1.address": "192.0.2.1", "peers.ns1.key": "c2VjcmV0"},
+	}
+
+	for _, config := range valid {
+		assert.NoError(t, validateConfig(&api.NetworkZonePut{Config: config}), "%v", config)
+	}
+
+	invalid := []map[string]string{
+		{"foo": "bar"},
+		{"dns.nameservers": "ns1..example.net"},
+		{"peers.ns1.address": "ns1.example.net"},
+		{"peers.ns1.key": "not base64!"},
+		{"peers.ns1.port": "53"},
+		{"peers..address": "192.0.2.1"},
+	}
+
+	for _, config := range invalid {
+		assert.Error(t, validateConfig(&api.NetworkZonePut{Config: config}), "%v", config)
+	}
+}
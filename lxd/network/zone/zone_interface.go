@@ -0,0 +1,24 @@
+package zone
+
+import (
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// NetworkZone represents a LXD network zone.
+type NetworkZone interface {
+	// Load.
+	init(state *state.State, id int64, info *api.NetworkZone)
+
+	// Info.
+	ID() int64
+	Name() string
+	Info() *api.NetworkZone
+	Etag() []interface{}
+	UsedBy() ([]string, error)
+	Content() (string, error)
+
+	// Modifications.
+	Update(config *api.NetworkZonePut, clusterNotification bool) error
+	Delete() error
+}
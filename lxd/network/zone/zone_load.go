@@ -0,0 +1,56 @@
+package zone
+
+import (
+	"fmt"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// LoadByName loads and initialises a network zone from the database by name.
+func LoadByName(s *state.State, name string) (NetworkZone, error) {
+	id, zoneInfo, err := s.Cluster.GetNetworkZone(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var zone NetworkZone = &common{}
+	zone.init(s, id, zoneInfo)
+
+	return zone, nil
+}
+
+// Create validates the supplied zone and creates it in the database.
+func Create(s *state.State, zoneInfo *api.NetworkZonesPost) error {
+	err := ValidName(zoneInfo.Name)
+	if err != nil {
+		return err
+	}
+
+	err = validateConfig(&zoneInfo.NetworkZonePut)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Cluster.CreateNetworkZone(zoneInfo)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Exists checks that the zone with the given name exists.
+func Exists(s *state.State, name string) error {
+	_, _, err := s.Cluster.GetNetworkZone(name)
+	if err != nil {
+		if err == db.ErrNoSuchObject {
+			return fmt.Errorf("Network zone %q does not exist", name)
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/network/acl"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var networkACLsCmd = APIEndpoint{
+	Path: "network-acls",
+
+	Get:  APIEndpointAction{Handler: networkACLsGet, AccessHandler: allowAuthenticated},
+	Post: APIEndpointAction{Handler: networkACLsPost},
+}
+
+var networkACLCmd = APIEndpoint{
+	Path: "network-acls/{name}",
+
+	Delete: APIEndpointAction{Handler: networkACLDelete},
+	Get:    APIEndpointAction{Handler: networkACLGet, AccessHandler: allowAuthenticated},
+	Put:    APIEndpointAction{Handler: networkACLPut},
+	Patch:  APIEndpointAction{Handler: networkACLPut},
+	Post:   APIEndpointAction{Handler: networkACLPost},
+}
+
+// API endpoints
+func networkACLsGet(d *Daemon, r *http.Request) response.Response {
+	recursion := util.IsRecursionRequest(r)
+
+	names, err := d.cluster.GetNetworkACLs()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !recursion {
+		resultString := make([]string, 0, len(names))
+		for _, name := range names {
+			resultString = append(resultString, fmt.Sprintf("/%s/network-acls/%s", version.APIVersion, name))
+		}
+
+		return response.SyncResponse(true, resultString)
+	}
+
+	resultMap := make([]*api.NetworkACL, 0, len(names))
+	for _, name := range names {
+		netACL, err := acl.LoadByName(d.State(), name)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		info := netACL.Info()
+		info.UsedBy, err = netACL.UsedBy()
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		resultMap = append(resultMap, info)
+	}
+
+	return response.SyncResponse(true, resultMap)
+}
+
+func networkACLsPost(d *Daemon, r *http.Request) response.Response {
+	req := api.NetworkACLsPost{}
+
+	// Parse the request.
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	_, _, err = d.cluster.GetNetworkACL(req.Name)
+	if err == nil {
+		return response.Conflict(fmt.Errorf("A network ACL by that name exists already"))
+	} else if err != db.ErrNoSuchObject {
+		return response.SmartError(err)
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	err = acl.Create(d.State(), &req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	url := fmt.Sprintf("/%s/network-acls/%s", version.APIVersion, req.Name)
+	return response.SyncResponseLocation(true, nil, url)
+}
+
+func networkACLDelete(d *Daemon, r *http.Request) response.Response {
+	netACL, err := acl.LoadByName(d.State(), mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = netACL.Delete()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+func networkACLGet(d *Daemon, r *http.Request) response.Response {
+	netACL, err := acl.LoadByName(d.State(), mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	info := netACL.Info()
+	info.UsedBy, err = netACL.UsedBy()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseETag(true, info, netACL.Etag())
+}
+
+func networkACLPut(d *Daemon, r *http.Request) response.Response {
+	netACL, err := acl.LoadByName(d.State(), mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	err = util.EtagCheck(r, netACL.Etag())
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	// On PATCH, the request is merged into the existing ACL (config keys are merged, rules replaced).
+	req := api.NetworkACLPut{}
+	if r.Method == "PATCH" {
+		req = netACL.Info().Writable()
+	}
+
+	// Decode the request.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	err = netACL.Update(&req, isClusterNotification(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+func networkACLPost(d *Daemon, r *http.Request) response.Response {
+	netACL, err := acl.LoadByName(d.State(), mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.NetworkACLPost{}
+
+	// Parse the request.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	_, _, err = d.cluster.GetNetworkACL(req.Name)
+	if err == nil {
+		return response.Conflict(fmt.Errorf("A network ACL by that name exists already"))
+	} else if err != db.ErrNoSuchObject {
+		return response.SmartError(err)
+	}
+
+	err = netACL.Rename(req.Name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	url := fmt.Sprintf("/%s/network-acls/%s", version.APIVersion, req.Name)
+	return response.SyncResponseLocation(true, nil, url)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var networkForwardsCmd = APIEndpoint{
+	Path: "networks/{networkName}/forwards",
+
+	Get:  APIEndpointAction{Handler: networkForwardsGet, AccessHandler: allowAuthenticated},
+	Post: APIEndpointAction{Handler: networkForwardsPost},
+}
+
+var networkForwardCmd = APIEndpoint{
+	Path: "networks/{networkName}/forwards/{listenAddress}",
+
+	Delete: APIEndpointAction{Handler: networkForwardDelete},
+	Get:    APIEndpointAction{Handler: networkForwardGet, AccessHandler: allowAuthenticated},
+	Put:    APIEndpointAction{Handler: networkForwardPut},
+	Patch:  APIEndpointAction{Handler: networkForwardPut},
+}
+
+// networkForwardResponse converts errors returned by the network forward functions to responses.
+func networkForwardResponse(err error) response.Response {
+	if err == network.ErrNotImplemented {
+		return response.NotImplemented(fmt.Errorf("Network driver doesn't support address forwards"))
+	}
+
+	return response.SmartError(err)
+}
+
+// API endpoints
+func networkForwardsGet(d *Daemon, r *http.Request) response.Response {
+	recursion := util.IsRecursionRequest(r)
+	networkName := mux.Vars(r)["networkName"]
+
+	// Get the network project.
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !recursion {
+		addresses, err := d.cluster.GetNetworkForwardListenAddresses(n.ID())
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		resultString := make([]string, 0, len(addresses))
+		for _, address := range addresses {
+			resultString = append(resultString, fmt.Sprintf("/%s/networks/%s/forwards/%s", version.APIVersion, networkName, address))
+		}
+
+		return response.SyncResponse(true, resultString)
+	}
+
+	forwards, err := d.cluster.GetNetworkForwards(n.ID())
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, forwards)
+}
+
+func networkForwardsPost(d *Daemon, r *http.Request) response.Response {
+	networkName := mux.Vars(r)["networkName"]
+
+	// Get the network project.
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.NetworkForwardsPost{}
+
+	// Parse the request.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Sanity checks.
+	listenAddress := net.ParseIP(req.ListenAddress)
+	if listenAddress == nil {
+		return response.BadRequest(fmt.Errorf("Invalid listen address %q", req.ListenAddress))
+	}
+
+	if !isClusterNotification(r) {
+		_, _, err = d.cluster.GetNetworkForward(n.ID(), listenAddress.String())
+		if err == nil {
+			return response.Conflict(fmt.Errorf("A forward for listen address %q already exists", listenAddress.String()))
+		} else if err != db.ErrNoSuchObject {
+			return response.SmartError(err)
+		}
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	err = n.ForwardCreate(req, isClusterNotification(r))
+	if err != nil {
+		return networkForwardResponse(err)
+	}
+
+	url := fmt.Sprintf("/%s/networks/%s/forwards/%s", version.APIVersion, networkName, listenAddress.String())
+	return response.SyncResponseLocation(true, nil, url)
+}
+
+func networkForwardGet(d *Daemon, r *http.Request) response.Response {
+	networkName := mux.Vars(r)["networkName"]
+	listenAddress := mux.Vars(r)["listenAddress"]
+
+	// Get the network project.
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	_, forward, err := d.cluster.GetNetworkForward(n.ID(), listenAddress)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	etag := []interface{}{forward.ListenAddress, forward.Description, forward.Config, forward.Ports}
+
+	return response.SyncResponseETag(true, forward, etag)
+}
+
+func networkForwardPut(d *Daemon, r *http.Request) response.Response {
+	networkName := mux.Vars(r)["networkName"]
+	listenAddress := mux.Vars(r)["listenAddress"]
+
+	// Get the network project.
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Get the existing forward.
+	_, forward, err := d.cluster.GetNetworkForward(n.ID(), listenAddress)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	etag := []interface{}{forward.ListenAddress, forward.Description, forward.Config, forward.Ports}
+	err = util.EtagCheck(r, etag)
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	// On PATCH, the request is merged into the existing forward (config keys are merged, ports replaced).
+	req := api.NetworkForwardPut{}
+	if r.Method == "PATCH" {
+		req = forward.Writable()
+	}
+
+	// Decode the request.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	err = n.ForwardUpdate(listenAddress, req, isClusterNotification(r))
+	if err != nil {
+		return networkForwardResponse(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+func networkForwardDelete(d *Daemon, r *http.Request) response.Response {
+	networkName := mux.Vars(r)["networkName"]
+	listenAddress := mux.Vars(r)["listenAddress"]
+
+	// Get the network project.
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = n.ForwardDelete(listenAddress, isClusterNotification(r))
+	if err != nil {
+		return networkForwardResponse(err)
+	}
+
+	return response.EmptySyncResponse
+}
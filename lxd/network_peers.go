@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var networkPeersCmd = APIEndpoint{
+	Path: "networks/{networkName}/peers",
+
+	Get:  APIEndpointAction{Handler: networkPeersGet, AccessHandler: allowAuthenticated},
+	Post: APIEndpointAction{Handler: networkPeersPost},
+}
+
+var networkPeerCmd = APIEndpoint{
+	Path: "networks/{networkName}/peers/{peerName}",
+
+	Delete: APIEndpointAction{Handler: networkPeerDelete},
+	Get:    APIEndpointAction{Handler: networkPeerGet, AccessHandler: allowAuthenticated},
+	Put:    APIEndpointAction{Handler: networkPeerPut},
+	Patch:  APIEndpointAction{Handler: networkPeerPut},
+}
+
+// networkPeerResponse converts errors returned by the network peering functions to responses.
+func networkPeerResponse(err error) response.Response {
+	if err == network.ErrNotImplemented {
+		return response.NotImplemented(fmt.Errorf("Network driver doesn't support peering"))
+	}
+
+	return response.SmartError(err)
+}
+
+// API endpoints
+func networkPeersGet(d *Daemon, r *http.Request) response.Response {
+	recursion := util.IsRecursionRequest(r)
+	networkName := mux.Vars(r)["networkName"]
+
+	// Get the network project.
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !recursion {
+		names, err := d.cluster.GetNetworkPeerNames(n.ID())
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		resultString := make([]string, 0, len(names))
+		for _, name := range names {
+			resultString = append(resultString, fmt.Sprintf("/%s/networks/%s/peers/%s", version.APIVersion, networkName, name))
+		}
+
+		return response.SyncResponse(true, resultString)
+	}
+
+	peers, err := d.cluster.GetNetworkPeers(n.ID())
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, peers)
+}
+
+func networkPeersPost(d *Daemon, r *http.Request) response.Response {
+	networkName := mux.Vars(r)["networkName"]
+
+	// Get the network project.
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.NetworkPeersPost{}
+
+	// Parse the request.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	_, _, err = d.cluster.GetNetworkPeer(n.ID(), req.Name)
+	if err == nil {
+		return response.Conflict(fmt.Errorf("A peering by that name exists already"))
+	} else if err != db.ErrNoSuchObject {
+		return response.SmartError(err)
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	err = n.PeerCreate(req)
+	if err != nil {
+		return networkPeerResponse(err)
+	}
+
+	url := fmt.Sprintf("/%s/networks/%s/peers/%s", version.APIVersion, networkName, req.Name)
+	return response.SyncResponseLocation(true, nil, url)
+}
+
+func networkPeerGet(d *Daemon, r *http.Request) response.Response {
+	networkName := mux.Vars(r)["networkName"]
+	peerName := mux.Vars(r)["peerName"]
+
+	// Get the network project.
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	_, peer, err := d.cluster.GetNetworkPeer(n.ID(), peerName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	etag := []interface{}{peer.Name, peer.Description, peer.Config, peer.TargetNetwork}
+
+	return response.SyncResponseETag(true, peer, etag)
+}
+
+func networkPeerPut(d *Daemon, r *http.Request) response.Response {
+	networkName := mux.Vars(r)["networkName"]
+	peerName := mux.Vars(r)["peerName"]
+
+	// Get the network project.
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Get the existing peering.
+	_, peer, err := d.cluster.GetNetworkPeer(n.ID(), peerName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	etag := []interface{}{peer.Name, peer.Description, peer.Config, peer.TargetNetwork}
+	err = util.EtagCheck(r, etag)
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	req := api.NetworkPeerPut{}
+	if r.Method == "PATCH" {
+		req = peer.Writable()
+	}
+
+	// Decode the request.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	err = n.PeerUpdate(peerName, req)
+	if err != nil {
+		return networkPeerResponse(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+func networkPeerDelete(d *Daemon, r *http.Request) response.Response {
+	networkName := mux.Vars(r)["networkName"]
+	peerName := mux.Vars(r)["peerName"]
+
+	// Get the network project.
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = n.PeerDelete(peerName)
+	if err != nil {
+		return networkPeerResponse(err)
+	}
+
+	return response.EmptySyncResponse
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var networkReservationsCmd = APIEndpoint{
+	Path: "networks/{networkName}/reservations",
+
+	Get:  APIEndpointAction{Handler: networkReservationsGet, AccessHandler: allowAuthenticated},
+	Post: APIEndpointAction{Handler: networkReservationsPost},
+}
+
+var networkReservationCmd = APIEndpoint{
+	Path: "networks/{networkName}/reservations/{hwaddr}",
+
+	Delete: APIEndpointAction{Handler: networkReservationDelete},
+	Get:    APIEndpointAction{Handler: networkReservationGet, AccessHandler: allowAuthenticated},
+}
+
+// networkReservationResponse converts errors returned by the network reservation functions to responses.
+func networkReservationResponse(err error) response.Response {
+	if err == network.ErrNotImplemented {
+		return response.NotImplemented(fmt.Errorf("Network driver doesn't support DHCP reservations"))
+	}
+
+	return response.SmartError(err)
+}
+
+// networkReservationMAC returns the MAC address from the request URL in its canonical form.
+func networkReservationMAC(r *http.Request) (string, error) {
+	hwaddr := mux.Vars(r)["hwaddr"]
+
+	mac, err := net.ParseMAC(hwaddr)
+	if err != nil {
+		return "", fmt.Errorf("Invalid MAC address %q", hwaddr)
+	}
+
+	return mac.String(), nil
+}
+
+// API endpoints
+func networkReservationsGet(d *Daemon, r *http.Request) response.Response {
+	recursion := util.IsRecursionRequest(r)
+	networkName := mux.Vars(r)["networkName"]
+
+	// Get the network project.
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	reservations, err := d.cluster.GetNetworkReservations(n.ID())
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !recursion {
+		resultString := make([]string, 0, len(reservations))
+		for _, reservation := range reservations {
+			resultString = append(resultString, fmt.Sprintf("/%s/networks/%s/reservations/%s", version.APIVersion, networkName, reservation.MAC))
+		}
+
+		return response.SyncResponse(true, resultString)
+	}
+
+	return response.SyncResponse(true, reservations)
+}
+
+func networkReservationsPost(d *Daemon, r *http.Request) response.Response {
+	networkName := mux.Vars(r)["networkName"]
+
+	// Get the network project.
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.NetworkReservation{}
+
+	// Parse the request.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Sanity checks.
+	mac, err := net.ParseMAC(req.MAC)
+	if err != nil {
+		return response.BadRequest(fmt.Errorf("Invalid MAC address %q", req.MAC))
+	}
+
+	if !isClusterNotification(r) {
+		_, _, err = d.cluster.GetNetworkReservation(n.ID(), mac.String())
+		if err == nil {
+			return response.Conflict(fmt.Errorf("A reservation for MAC address %q already exists", mac.String()))
+		} else if err != db.ErrNoSuchObject {
+			return response.SmartError(err)
+		}
+	}
+
+	err = n.ReservationCreate(req, isClusterNotification(r))
+	if err != nil {
+		return networkReservationResponse(err)
+	}
+
+	url := fmt.Sprintf("/%s/networks/%s/reservations/%s", version.APIVersion, networkName, mac.String())
+	return response.SyncResponseLocation(true, nil, url)
+}
+
+func networkReservationGet(d *Daemon, r *http.Request) response.Response {
+	networkName := mux.Vars(r)["networkName"]
+
+	hwaddr, err := networkReservationMAC(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Get the network project.
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	_, reservation, err := d.cluster.GetNetworkReservation(n.ID(), hwaddr)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, reservation)
+}
+
+func networkReservationDelete(d *Daemon, r *http.Request) response.Response {
+	networkName := mux.Vars(r)["networkName"]
+
+	hwaddr, err := networkReservationMAC(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Get the network project.
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = n.ReservationDelete(hwaddr, isClusterNotification(r))
+	if err != nil {
+		return networkReservationResponse(err)
+	}
+
+	return response.EmptySyncResponse
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/dns"
+	"github.com/lxc/lxd/lxd/network/zone"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var networkZonesCmd = APIEndpoint{
+	Path: "network-zones",
+
+	Get:  APIEndpointAction{Handler: networkZonesGet, AccessHandler: allowAuthenticated},
+	Post: APIEndpointAction{Handler: networkZonesPost},
+}
+
+var networkZoneCmd = APIEndpoint{
+	Path: "network-zones/{name}",
+
+	Delete: APIEndpointAction{Handler: networkZoneDelete},
+	Get:    APIEndpointAction{Handler: networkZoneGet, AccessHandler: allowAuthenticated},
+	Put:    APIEndpointAction{Handler: networkZonePut},
+	Patch:  APIEndpointAction{Handler: networkZonePut},
+}
+
+// API endpoints
+func networkZonesGet(d *Daemon, r *http.Request) response.Response {
+	recursion := util.IsRecursionRequest(r)
+
+	names, err := d.cluster.GetNetworkZones()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !recursion {
+		resultString := make([]string, 0, len(names))
+		for _, name := range names {
+			resultString = append(resultString, fmt.Sprintf("/%s/network-zones/%s", version.APIVersion, name))
+		}
+
+		return response.SyncResponse(true, resultString)
+	}
+
+	resultMap := make([]*api.NetworkZone, 0, len(names))
+	for _, name := range names {
+		netZone, err := zone.LoadByName(d.State(), name)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		info := netZone.Info()
+		info.UsedBy, err = netZone.UsedBy()
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		resultMap = append(resultMap, info)
+	}
+
+	return response.SyncResponse(true, resultMap)
+}
+
+func networkZonesPost(d *Daemon, r *http.Request) response.Response {
+	req := api.NetworkZonesPost{}
+
+	// Parse the request.
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	_, _, err = d.cluster.GetNetworkZone(req.Name)
+	if err == nil {
+		return response.Conflict(fmt.Errorf("A network zone by that name exists already"))
+	} else if err != db.ErrNoSuchObject {
+		return response.SmartError(err)
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	err = zone.Create(d.State(), &req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Load the TSIG keys of the new zone on all members.
+	err = networkZoneReloadKeys(d, req.Name, req.NetworkZonePut)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	url := fmt.Sprintf("/%s/network-zones/%s", version.APIVersion, req.Name)
+	return response.SyncResponseLocation(true, nil, url)
+}
+
+func networkZoneDelete(d *Daemon, r *http.Request) response.Response {
+	netZone, err := zone.LoadByName(d.State(), mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = netZone.Delete()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// The other members drop the keys of the deleted zone on their next restart.
+	err = d.dns.UpdateTSIG()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+func networkZoneGet(d *Daemon, r *http.Request) response.Response {
+	netZone, err := zone.LoadByName(d.State(), mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	info := netZone.Info()
+	info.UsedBy, err = netZone.UsedBy()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseETag(true, info, netZone.Etag())
+}
+
+func networkZonePut(d *Daemon, r *http.Request) response.Response {
+	netZone, err := zone.LoadByName(d.State(), mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	err = util.EtagCheck(r, netZone.Etag())
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	req := api.NetworkZonePut{}
+	if r.Method == "PATCH" {
+		req = netZone.Info().Writable()
+	}
+
+	// Decode the request.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	err = netZone.Update(&req, isClusterNotification(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = d.dns.UpdateTSIG()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// networkZoneReloadKeys reloads the TSIG keys of the DNS server on this member and notifies the other members to
+// do the same.
+func networkZoneReloadKeys(d *Daemon, name string, config api.NetworkZonePut) error {
+	err := d.dns.UpdateTSIG()
+	if err != nil {
+		return err
+	}
+
+	notifier, err := cluster.NewNotifier(d.State(), d.endpoints.NetworkCert(), cluster.NotifyAll)
+	if err != nil {
+		return err
+	}
+
+	return notifier(func(client lxd.InstanceServer) error {
+		return client.UpdateNetworkZone(name, config, "")
+	})
+}
+
+// networkZoneDNS returns the zone with the given name and its generated content for the DNS server.
+func networkZoneDNS(s *state.State, name string) (*dns.Zone, error) {
+	netZone, err := zone.LoadByName(s, name)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := netZone.Content()
+	if err != nil {
+		return nil, err
+	}
+
+	return &dns.Zone{Info: *netZone.Info(), Content: content}, nil
+}
+
+// networkDNSForward returns the address of the dnsmasq instance to forward DNS queries for the given name to. Only
+// the bridge networks of the default project exposing their DNS server through the dns.expose key are considered,
+// for names within their DNS domain.
+func networkDNSForward(s *state.State, name string) (string, error) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+
+	networks, err := s.Cluster.GetNetworks(project.Default)
+	if err != nil {
+		return "", err
+	}
+
+	for _, networkName := range networks {
+		_, netInfo, err := s.Cluster.GetNetworkInAnyState(project.Default, networkName)
+		if err != nil {
+			return "", err
+		}
+
+		if netInfo.Type != "bridge" || netInfo.Status != api.NetworkStatusCreated {
+			continue
+		}
+
+		if !shared.IsTrue(netInfo.Config["dns.expose"]) || netInfo.Config["dns.mode"] == "none" {
+			continue
+		}
+
+		domain := netInfo.Config["dns.domain"]
+		if domain == "" {
+			domain = "lxd"
+		}
+
+		domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+		if name != domain && !strings.HasSuffix(name, "."+domain) {
+			continue
+		}
+
+		// dnsmasq listens on the addresses of the bridge.
+		for _, key := range []string{"ipv4.address", "ipv6.address"} {
+			ip, _, err := net.ParseCIDR(netInfo.Config[key])
+			if err == nil {
+				return net.JoinHostPort(ip.String(), "53"), nil
+			}
+		}
+	}
+
+	return "", nil
+}
@@ -7,8 +7,11 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	log "github.com/lxc/lxd/shared/log15"
@@ -17,17 +20,22 @@ import (
 	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/device"
 	"github.com/lxc/lxd/lxd/device/nictype"
 	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/network"
 	"github.com/lxc/lxd/lxd/network/openvswitch"
 	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/revert"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/filter"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/version"
 )
@@ -58,6 +66,12 @@ var networkLeasesCmd = APIEndpoint{
 	Get: APIEndpointAction{Handler: networkLeasesGet, AccessHandler: allowAuthenticated},
 }
 
+var networkMetricsCmd = APIEndpoint{
+	Path: "networks/{name}/metrics",
+
+	Get: APIEndpointAction{Handler: networkMetricsGet, AccessHandler: allowAuthenticated},
+}
+
 var networkStateCmd = APIEndpoint{
 	Path: "networks/{name}/state",
 
@@ -68,40 +82,191 @@ var networkStateCmd = APIEndpoint{
 func networksGet(d *Daemon, r *http.Request) response.Response {
 	recursion := util.IsRecursionRequest(r)
 
-	ifs, err := networkGetInterfaces(d.cluster)
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	ifs, err := networkGetInterfaces(d.cluster, projectName)
 	if err != nil {
 		return response.InternalError(err)
 	}
 
+	// Parse the filter.
+	var clauses []filter.Clause
+	filterStr := r.FormValue("filter")
+	if filterStr != "" {
+		clauses, err = filter.Parse(filterStr)
+		if err != nil {
+			return response.BadRequest(errors.Wrap(err, "Invalid filter"))
+		}
+	}
+
+	mustLoadObjects := recursion || clauses != nil
+	full := r.FormValue("recursion") == "2"
+
+	// The users of all the networks are found at once. They aren't needed when other cluster members gather
+	// the state of the networks.
+	var usedBy *networkUsedBy
+	if mustLoadObjects && !(full && isClusterNotification(r)) {
+		usedBy, err = networkUsedByIndex(d.State())
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
 	resultString := []string{}
 	resultMap := []api.Network{}
 	for _, iface := range ifs {
-		if !recursion {
-			resultString = append(resultString, fmt.Sprintf("/%s/networks/%s", version.APIVersion, iface))
-		} else {
-			net, err := doNetworkGet(d, iface)
+		if mustLoadObjects {
+			net, err := doNetworkGet(d, projectName, iface, usedBy)
 			if err != nil {
 				continue
 			}
-			resultMap = append(resultMap, net)
+
+			if clauses != nil && !filter.Match(net, clauses) {
+				continue
+			}
+
+			if recursion {
+				resultMap = append(resultMap, net)
+				continue
+			}
+		}
+
+		uri := fmt.Sprintf("/%s/networks/%s", version.APIVersion, iface)
+		if projectName != project.Default {
+			uri += fmt.Sprintf("?project=%s", projectName)
 		}
+
+		resultString = append(resultString, uri)
 	}
 
 	if !recursion {
-		return response.SyncResponse(true, resultString)
+		return response.SyncResponsePaginated(r, resultString)
 	}
 
-	return response.SyncResponse(true, resultMap)
+	if full {
+		resultFull, err := networksGetFull(d, r, projectName, resultMap, usedBy)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return response.SyncResponsePaginated(r, resultFull)
+	}
+
+	return response.SyncResponsePaginated(r, resultMap)
+}
+
+// networksGetFull returns the networks along with their state and a summary of their leases. Unless the request
+// comes from another cluster member, the state and dynamic leases of all the cluster members are gathered.
+func networksGetFull(d *Daemon, r *http.Request, projectName string, networks []api.Network, usedBy *networkUsedBy) ([]api.NetworkFull, error) {
+	var serverName string
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		serverName, err = tx.GetLocalNodeName()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]api.NetworkFull, 0, len(networks))
+	indices := map[string]int{}
+	for _, n := range networks {
+		entry := api.NetworkFull{
+			Network: n,
+			State:   map[string]api.NetworkState{},
+		}
+
+		osInfo, err := net.InterfaceByName(n.Name)
+		if err == nil {
+			entry.State[serverName] = networkGetState(*osInfo)
+		}
+
+		if n.Managed && n.Type == "bridge" {
+			entry.Leases.Dynamic = networkDynamicLeasesCount(n.Name)
+			if usedBy != nil {
+				entry.Leases.Static = usedBy.staticLeases(projectName, n.Name)
+			}
+		}
+
+		indices[n.Name] = len(result)
+		result = append(result, entry)
+	}
+
+	if isClusterNotification(r) {
+		return result, nil
+	}
+
+	notifier, err := cluster.NewNotifier(d.State(), d.endpoints.NetworkCert(), cluster.NotifyAlive)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	err = notifier(func(client lxd.InstanceServer) error {
+		memberNetworks, err := client.UseProject(projectParam(r)).GetNetworksFull()
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, memberNetwork := range memberNetworks {
+			i, ok := indices[memberNetwork.Name]
+			if !ok {
+				continue
+			}
+
+			for member, state := range memberNetwork.State {
+				result[i].State[member] = state
+			}
+
+			result[i].Leases.Dynamic += memberNetwork.Leases.Dynamic
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// networkDynamicLeasesCount returns the number of leases handed out by the dnsmasq instance of the bridge.
+func networkDynamicLeasesCount(name string) int {
+	content, err := ioutil.ReadFile(shared.VarPath("networks", name, "dnsmasq.leases"))
+	if err != nil {
+		return 0
+	}
+
+	// Lease lines have at least 5 fields, unlike the "duid <server DUID>" line.
+	count := 0
+	for _, lease := range strings.Split(string(content), "\n") {
+		if len(strings.Fields(lease)) >= 5 {
+			count++
+		}
+	}
+
+	return count
 }
 
 func networksPost(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	networkCreateLock.Lock()
 	defer networkCreateLock.Unlock()
 
 	req := api.NetworksPost{}
 
 	// Parse the request.
-	err := json.NewDecoder(r.Body).Decode(&req)
+	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		return response.BadRequest(err)
 	}
@@ -133,23 +298,54 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 		dbNetType = db.NetworkTypeMacvlan
 	case "sriov":
 		dbNetType = db.NetworkTypeSriov
+	case "ovn":
+		dbNetType = db.NetworkTypeOVN
+	case "physical":
+		dbNetType = db.NetworkTypePhysical
 	default:
 		return response.BadRequest(fmt.Errorf("Unrecognised network type"))
 	}
 
+	// Only OVN networks can be created in projects other than the default one.
+	if projectName != project.Default && req.Type != "ovn" {
+		return response.BadRequest(fmt.Errorf("Network type %q is only supported in the default project", req.Type))
+	}
+
 	url := fmt.Sprintf("/%s/networks/%s", version.APIVersion, req.Name)
+	if projectName != project.Default {
+		url += fmt.Sprintf("?project=%s", projectName)
+	}
+
 	resp := response.SyncResponseLocation(true, nil, url)
 
 	if isClusterNotification(r) {
 		// This is an internal request which triggers the actual creation of the network across all nodes
 		// after they have been previously defined.
-		err = doNetworksCreate(d, req, true)
+		err = doNetworksCreate(d, projectName, req, true)
 		if err != nil {
 			return response.SmartError(err)
 		}
 		return resp
 	}
 
+	// Check the project limits and restrictions, unless the network has already been defined on some nodes.
+	_, _, err = d.cluster.GetNetworkInAnyState(projectName, req.Name)
+	if err == db.ErrNoSuchObject {
+		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			if req.Type == "ovn" && req.Config["network"] != "" {
+				err := project.AllowNetworkUplink(tx, projectName, req.Config["network"])
+				if err != nil {
+					return err
+				}
+			}
+
+			return project.AllowNetworkCreation(tx, projectName)
+		})
+	}
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	targetNode := queryParam(r, "target")
 	if targetNode != "" {
 		// A targetNode was specified, let's just define the node's network without actually creating it.
@@ -161,7 +357,7 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 		}
 
 		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
-			return tx.CreatePendingNetwork(targetNode, req.Name, dbNetType, req.Config)
+			return tx.CreatePendingNetwork(targetNode, projectName, req.Name, dbNetType, req.Config)
 		})
 		if err != nil {
 			if err == db.ErrAlreadyDefined {
@@ -179,21 +375,46 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	if count > 1 {
-		err = networksPostCluster(d, req)
+		// OVN networks don't have any node-specific config, so define them on all nodes at once rather than
+		// requiring them to be defined on each node using the target parameter first.
+		if req.Type == "ovn" {
+			err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+				nodes, err := tx.GetNodes()
+				if err != nil {
+					return err
+				}
+
+				for _, node := range nodes {
+					err = tx.CreatePendingNetwork(node.Name, projectName, req.Name, dbNetType, map[string]string{})
+					if err != nil && err != db.ErrAlreadyDefined {
+						return err
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return response.SmartError(err)
+			}
+		}
+
+		err = networksPostCluster(d, projectName, req)
 		if err != nil {
 			return response.SmartError(err)
 		}
 
+		d.State().Events.SendLifecycle(projectName, lifecycle.NetworkCreated.Event(req.Name, request.CreateRequestor(r), nil))
+
 		return resp
 	}
 
 	// Non-clustered network creation.
-	err = network.FillConfig(&req)
+	err = network.FillConfig(d.State(), projectName, &req)
 	if err != nil {
 		return response.SmartError(err)
 	}
 
-	networks, err := networkGetInterfaces(d.cluster)
+	networks, err := networkGetInterfaces(d.cluster, projectName)
 	if err != nil {
 		return response.InternalError(err)
 	}
@@ -206,26 +427,28 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 	defer revert.Fail()
 
 	// Create the database entry.
-	_, err = d.cluster.CreateNetwork(req.Name, req.Description, dbNetType, req.Config)
+	_, err = d.cluster.CreateNetwork(projectName, req.Name, req.Description, dbNetType, req.Config)
 	if err != nil {
 		return response.SmartError(errors.Wrapf(err, "Error inserting %q into database", req.Name))
 	}
 
 	revert.Add(func() {
-		d.cluster.DeleteNetwork(req.Name)
+		d.cluster.DeleteNetwork(projectName, req.Name)
 	})
 
 	// Create network and pass false to clusterNotification so the database record is removed on error.
-	err = doNetworksCreate(d, req, false)
+	err = doNetworksCreate(d, projectName, req, false)
 	if err != nil {
 		return response.SmartError(err)
 	}
 
+	d.State().Events.SendLifecycle(projectName, lifecycle.NetworkCreated.Event(req.Name, request.CreateRequestor(r), nil))
+
 	revert.Success()
 	return resp
 }
 
-func networksPostCluster(d *Daemon, req api.NetworksPost) error {
+func networksPostCluster(d *Daemon, projectName string, req api.NetworksPost) error {
 	// Check that no node-specific config key has been defined.
 	for key := range req.Config {
 		if shared.StringInSlice(key, db.NodeSpecificNetworkConfig) {
@@ -235,7 +458,7 @@ func networksPostCluster(d *Daemon, req api.NetworksPost) error {
 
 	// Check that the requested network type matches the type created when adding the local node config.
 	// If network doesn't exist yet, ignore not found error, as this will be checked by NetworkNodeConfigs().
-	_, netInfo, err := d.cluster.GetNetworkInAnyState(req.Name)
+	_, netInfo, err := d.cluster.GetNetworkInAnyState(projectName, req.Name)
 	if err != nil && err != db.ErrNoSuchObject {
 		return err
 	}
@@ -245,7 +468,7 @@ func networksPostCluster(d *Daemon, req api.NetworksPost) error {
 	}
 
 	// Add default values.
-	err = network.FillConfig(&req)
+	err = network.FillConfig(d.State(), projectName, &req)
 	if err != nil {
 		return err
 	}
@@ -256,7 +479,7 @@ func networksPostCluster(d *Daemon, req api.NetworksPost) error {
 	var networkID int64
 	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
 		// Fetch the network ID.
-		networkID, err = tx.GetNetworkID(req.Name)
+		networkID, err = tx.GetNetworkID(projectName, req.Name)
 		if err != nil {
 			return err
 		}
@@ -301,20 +524,20 @@ func networksPostCluster(d *Daemon, req api.NetworksPost) error {
 
 	revert.Add(func() {
 		d.cluster.Transaction(func(tx *db.ClusterTx) error {
-			return tx.NetworkErrored(req.Name)
+			return tx.NetworkErrored(projectName, req.Name)
 		})
 	})
 
 	// We need to mark the network as created now, because the network.LoadByName call invoked by
 	// doNetworksCreate would fail with not-found otherwise.
 	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
-		return tx.NetworkCreated(req.Name)
+		return tx.NetworkCreated(projectName, req.Name)
 	})
 	if err != nil {
 		return err
 	}
 
-	err = doNetworksCreate(d, nodeReq, false)
+	err = doNetworksCreate(d, projectName, nodeReq, false)
 	if err != nil {
 		return err
 	}
@@ -330,7 +553,7 @@ func networksPostCluster(d *Daemon, req api.NetworksPost) error {
 			nodeReq.Config[key] = value
 		}
 
-		return client.CreateNetwork(nodeReq)
+		return client.UseProject(projectName).CreateNetwork(nodeReq)
 	})
 	if err != nil {
 		return err
@@ -342,9 +565,9 @@ func networksPostCluster(d *Daemon, req api.NetworksPost) error {
 
 // Create the network on the system. The clusterNotification flag is used to indicate whether creation request
 // is coming from a cluster notification (and if so we should not delete the database record on error).
-func doNetworksCreate(d *Daemon, req api.NetworksPost, clusterNotification bool) error {
+func doNetworksCreate(d *Daemon, projectName string, req api.NetworksPost, clusterNotification bool) error {
 	// Start the network.
-	n, err := network.LoadByName(d.State(), req.Name)
+	n, err := network.LoadByName(d.State(), projectName, req.Name)
 	if err != nil {
 		return err
 	}
@@ -377,9 +600,19 @@ func networkGet(d *Daemon, r *http.Request) response.Response {
 		return resp
 	}
 
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	name := mux.Vars(r)["name"]
 
-	n, err := doNetworkGet(d, name)
+	usedBy, err := networkUsedByIndex(d.State())
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := doNetworkGet(d, projectName, name, usedBy)
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -400,18 +633,24 @@ func networkGet(d *Daemon, r *http.Request) response.Response {
 
 	etag := []interface{}{n.Name, n.Managed, n.Type, n.Description, n.Config}
 
-	return response.SyncResponseETag(true, &n, etag)
+	return response.SyncResponseETagConditional(r, &n, etag)
 }
 
-func doNetworkGet(d *Daemon, name string) (api.Network, error) {
+// doNetworkGet returns the network of the given name, either managed or a host interface. The UsedBy field is
+// only populated when an index of the network users is supplied.
+func doNetworkGet(d *Daemon, projectName string, name string, usedBy *networkUsedBy) (api.Network, error) {
 	// Ignore veth pairs (for performance reasons)
 	if strings.HasPrefix(name, "veth") {
 		return api.Network{}, os.ErrNotExist
 	}
 
-	// Get some information
-	osInfo, _ := net.InterfaceByName(name)
-	_, dbInfo, _ := d.cluster.GetNetworkInAnyState(name)
+	// Get some information. Host interfaces are only visible from the default project.
+	var osInfo *net.Interface
+	if projectName == project.Default {
+		osInfo, _ = net.InterfaceByName(name)
+	}
+
+	_, dbInfo, _ := d.cluster.GetNetworkInAnyState(projectName, name)
 
 	// Sanity check
 	if osInfo == nil && dbInfo == nil {
@@ -449,78 +688,145 @@ func doNetworkGet(d *Daemon, name string) (api.Network, error) {
 		}
 	}
 
-	// Look for containers using the interface
-	if n.Type != "loopback" {
-		// Look at instances.
-		insts, err := instance.LoadFromAllProjects(d.State())
-		if err != nil {
-			return api.Network{}, err
-		}
+	// Look for instances and profiles using the interface.
+	if usedBy != nil && n.Type != "loopback" {
+		n.UsedBy = usedBy.get(projectName, n.Name)
+	}
 
-		for _, inst := range insts {
-			inUse, err := network.IsInUseByInstance(d.State(), inst, n.Name)
-			if err != nil {
-				return api.Network{}, err
+	if dbInfo != nil {
+		n.Status = dbInfo.Status
+		n.Locations = dbInfo.Locations
+	}
+
+	return n, nil
+}
+
+// networkUsedBy indexes the URLs of the instances and profiles using networks, so that the users of any number
+// of networks can be found from a single pass over the NIC devices.
+type networkUsedBy struct {
+	uris []string
+
+	// refs maps "<network project>/<network name>" for managed networks and "/<interface name>" for host
+	// interfaces to the indices of the users in uris.
+	refs map[string][]int
+
+	// static holds the number of static addresses of the instance NICs, keyed like refs.
+	static map[string]int
+}
+
+// networkUsedByIndex loads all the instances and profiles with NIC devices and indexes the networks they use.
+func networkUsedByIndex(s *state.State) (*networkUsedBy, error) {
+	var users []db.NetworkUser
+	err := s.Cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		users, err = tx.GetNetworkUsers()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	index := &networkUsedBy{
+		uris:   make([]string, 0, len(users)),
+		refs:   map[string][]int{},
+		static: map[string]int{},
+	}
+
+	for _, user := range users {
+		refs := map[string]bool{}
+		for _, d := range user.Devices {
+			deviceRefs := []string{}
+
+			// NICs connected to a managed network always have a nictype which uses the network.
+			if d["network"] != "" {
+				deviceRefs = append(deviceRefs, fmt.Sprintf("%s/%s", user.NetworkProject, d["network"]))
+			} else if !shared.StringInSlice(d["nictype"], []string{"bridged", "macvlan", "ipvlan", "physical", "sriov", "ovn"}) {
+				continue
 			}
 
-			if inUse {
-				uri := fmt.Sprintf("/%s/instances/%s", version.APIVersion, inst.Name())
-				if inst.Project() != project.Default {
-					uri += fmt.Sprintf("?project=%s", inst.Project())
+			if d["parent"] != "" {
+				deviceRefs = append(deviceRefs, fmt.Sprintf("/%s", network.GetHostDevice(d["parent"], d["vlan"])))
+			}
+
+			// The addresses of profile NICs only apply to the instances using them.
+			addresses := 0
+			if !user.Profile {
+				for _, key := range []string{"ipv4.address", "ipv6.address"} {
+					if d[key] != "" {
+						addresses++
+					}
 				}
-				n.UsedBy = append(n.UsedBy, uri)
 			}
-		}
 
-		// Look for profiles.
-		var profiles []db.Profile
-		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
-			profiles, err = tx.GetProfiles(db.ProfileFilter{})
-			if err != nil {
-				return err
+			for _, ref := range deviceRefs {
+				refs[ref] = true
+				index.static[ref] += addresses
 			}
+		}
 
-			return nil
-		})
-		if err != nil {
-			return api.Network{}, err
+		if len(refs) == 0 {
+			continue
 		}
 
-		for _, profile := range profiles {
-			inUse, err := network.IsInUseByProfile(d.State(), *db.ProfileToAPI(&profile), n.Name)
-			if err != nil {
-				return api.Network{}, err
-			}
+		uri := fmt.Sprintf("/%s/instances/%s", version.APIVersion, user.Name)
+		if user.Profile {
+			uri = fmt.Sprintf("/%s/profiles/%s", version.APIVersion, user.Name)
+		}
 
-			if inUse {
-				uri := fmt.Sprintf("/%s/profiles/%s", version.APIVersion, profile.Name)
-				if profile.Project != project.Default {
-					uri += fmt.Sprintf("?project=%s", profile.Project)
-				}
-				n.UsedBy = append(n.UsedBy, uri)
-			}
+		if user.Project != project.Default {
+			uri += fmt.Sprintf("?project=%s", user.Project)
 		}
+
+		for ref := range refs {
+			index.refs[ref] = append(index.refs[ref], len(index.uris))
+		}
+
+		index.uris = append(index.uris, uri)
 	}
 
-	if dbInfo != nil {
-		n.Status = dbInfo.Status
-		n.Locations = dbInfo.Locations
+	return index, nil
+}
+
+// get returns the URLs of the instances and profiles using the network, instances first. Both the managed network
+// of the given project and the host interface of the same name are matched.
+func (u *networkUsedBy) get(networkProjectName string, networkName string) []string {
+	indices := append([]int{}, u.refs[fmt.Sprintf("%s/%s", networkProjectName, networkName)]...)
+	indices = append(indices, u.refs[fmt.Sprintf("/%s", networkName)]...)
+	sort.Ints(indices)
+
+	usedBy := []string{}
+	for i, index := range indices {
+		if i > 0 && indices[i-1] == index {
+			continue
+		}
+
+		usedBy = append(usedBy, u.uris[index])
 	}
 
-	return n, nil
+	return usedBy
+}
+
+// staticLeases returns the number of static addresses of the instance NICs connected to the network.
+func (u *networkUsedBy) staticLeases(networkProjectName string, networkName string) int {
+	return u.static[fmt.Sprintf("%s/%s", networkProjectName, networkName)] + u.static[fmt.Sprintf("/%s", networkName)]
 }
 
 func networkDelete(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	name := mux.Vars(r)["name"]
 	state := d.State()
 
 	// Check if the network is pending, if so we just need to delete it from the database.
-	_, dbNetwork, err := d.cluster.GetNetworkInAnyState(name)
+	_, dbNetwork, err := d.cluster.GetNetworkInAnyState(projectName, name)
 	if err != nil {
 		return response.SmartError(err)
 	}
 	if dbNetwork.Status == api.NetworkStatusPending {
-		err := d.cluster.DeleteNetwork(name)
+		err := d.cluster.DeleteNetwork(projectName, name)
 		if err != nil {
 			return response.SmartError(err)
 		}
@@ -528,7 +834,7 @@ func networkDelete(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// Get the existing network.
-	n, err := network.LoadByName(state, name)
+	n, err := network.LoadByName(state, projectName, name)
 	if err != nil {
 		return response.NotFound(err)
 	}
@@ -553,7 +859,7 @@ func networkDelete(d *Daemon, r *http.Request) response.Response {
 			return response.SmartError(err)
 		}
 		err = notifier(func(client lxd.InstanceServer) error {
-			return client.DeleteNetwork(name)
+			return client.UseProject(projectName).DeleteNetwork(name)
 		})
 		if err != nil {
 			return response.SmartError(err)
@@ -566,11 +872,15 @@ func networkDelete(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
-	// Cleanup storage.
-	if shared.PathExists(shared.VarPath("networks", n.Name())) {
+	// Cleanup storage, only networks of the default project have a state directory.
+	if projectName == project.Default && shared.PathExists(shared.VarPath("networks", n.Name())) {
 		os.RemoveAll(shared.VarPath("networks", n.Name()))
 	}
 
+	if !clusterNotification {
+		d.State().Events.SendLifecycle(projectName, lifecycle.NetworkDeleted.Event(name, request.CreateRequestor(r), nil))
+	}
+
 	return response.EmptySyncResponse
 }
 
@@ -590,6 +900,11 @@ func networkPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(fmt.Errorf("Renaming a network not supported in LXD clusters"))
 	}
 
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	name := mux.Vars(r)["name"]
 	req := api.NetworkPost{}
 	state := d.State()
@@ -601,7 +916,7 @@ func networkPost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// Get the existing network
-	n, err := network.LoadByName(state, name)
+	n, err := network.LoadByName(state, projectName, name)
 	if err != nil {
 		return response.NotFound(err)
 	}
@@ -617,7 +932,7 @@ func networkPost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// Check that the name isn't already in use
-	networks, err := networkGetInterfaces(d.cluster)
+	networks, err := networkGetInterfaces(d.cluster, projectName)
 	if err != nil {
 		return response.InternalError(err)
 	}
@@ -632,7 +947,16 @@ func networkPost(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
-	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/networks/%s", version.APIVersion, req.Name))
+	d.State().Events.SendLifecycle(projectName, lifecycle.NetworkRenamed.Event(req.Name, request.CreateRequestor(r), map[string]interface{}{
+		"old_name": name,
+	}))
+
+	url := fmt.Sprintf("/%s/networks/%s", version.APIVersion, req.Name)
+	if projectName != project.Default {
+		url += fmt.Sprintf("?project=%s", projectName)
+	}
+
+	return response.SyncResponseLocation(true, nil, url)
 }
 
 func networkPut(d *Daemon, r *http.Request) response.Response {
@@ -642,10 +966,15 @@ func networkPut(d *Daemon, r *http.Request) response.Response {
 		return resp
 	}
 
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	name := mux.Vars(r)["name"]
 
 	// Get the existing network.
-	_, dbInfo, err := d.cluster.GetNetworkInAnyState(name)
+	_, dbInfo, err := d.cluster.GetNetworkInAnyState(projectName, name)
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -698,7 +1027,7 @@ func networkPut(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
-	return doNetworkUpdate(d, name, req, targetNode, isClusterNotification(r), r.Method, clustered)
+	return doNetworkUpdate(d, projectName, name, req, targetNode, isClusterNotification(r), r.Method, clustered, request.CreateRequestor(r))
 }
 
 func networkPatch(d *Daemon, r *http.Request) response.Response {
@@ -707,9 +1036,9 @@ func networkPatch(d *Daemon, r *http.Request) response.Response {
 
 // doNetworkUpdate loads the current local network config, merges with the requested network config, validates
 // and applies the changes. Will also notify other cluster nodes of non-node specific config if needed.
-func doNetworkUpdate(d *Daemon, name string, req api.NetworkPut, targetNode string, clusterNotification bool, httpMethod string, clustered bool) response.Response {
+func doNetworkUpdate(d *Daemon, projectName string, name string, req api.NetworkPut, targetNode string, clusterNotification bool, httpMethod string, clustered bool, requestor *api.EventLifecycleRequestor) response.Response {
 	// Load the local node-specific network.
-	n, err := network.LoadByName(d.State(), name)
+	n, err := network.LoadByName(d.State(), projectName, name)
 	if err != nil {
 		return response.NotFound(err)
 	}
@@ -741,26 +1070,56 @@ func doNetworkUpdate(d *Daemon, name string, req api.NetworkPut, targetNode stri
 	}
 
 	// Validate the merged configuration.
-	err = network.Validate(name, n.Type(), req.Config)
+	err = network.Validate(d.State(), projectName, name, n.Type(), req.Config)
 	if err != nil {
 		return response.BadRequest(err)
 	}
 
+	oldConfig := map[string]string{}
+	for k, v := range n.Config() {
+		oldConfig[k] = v
+	}
+
 	// Apply the new configuration (will also notify other cluster nodes if needed).
 	err = n.Update(req, targetNode, clusterNotification)
 	if err != nil {
 		return response.SmartError(err)
 	}
 
+	// Move the filtered instance NICs relying on dynamic allocations to the new addressing of the bridge.
+	if n.Type() == "bridge" {
+		for _, key := range []string{"ipv4.address", "ipv4.dhcp", "ipv4.dhcp.ranges", "ipv6.address", "ipv6.dhcp", "ipv6.dhcp.stateful", "ipv6.dhcp.ranges"} {
+			if oldConfig[key] == req.Config[key] {
+				continue
+			}
+
+			err = device.BridgedNICRefreshFilters(d.State(), name)
+			if err != nil {
+				logger.Error("Failed to refresh the filters of the instance NICs", log.Ctx{"network": name, "err": err})
+			}
+
+			break
+		}
+	}
+
+	if !clusterNotification {
+		d.State().Events.SendLifecycle(projectName, lifecycle.NetworkUpdated.Event(name, requestor, nil))
+	}
+
 	return response.EmptySyncResponse
 }
 
 func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 	name := mux.Vars(r)["name"]
-	project := projectParam(r)
+	instProjectName := projectParam(r)
+
+	projectName, err := project.NetworkProject(d.State().Cluster, instProjectName)
+	if err != nil {
+		return response.SmartError(err)
+	}
 
 	// Try to get the network
-	n, err := doNetworkGet(d, name)
+	n, err := doNetworkGet(d, projectName, name, nil)
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -773,10 +1132,14 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 	leases := []api.NetworkLease{}
 	projectMacs := []string{}
 
+	// Get the router addresses handed out to the clients as gateway.
+	gatewayIPv4 := networkLeaseGateway(n.Config["ipv4.address"])
+	gatewayIPv6 := networkLeaseGateway(n.Config["ipv6.address"])
+
 	// Get all static leases
 	if !isClusterNotification(r) {
 		// Get all the instances
-		instances, err := instance.LoadByProject(d.State(), project)
+		instances, err := instance.LoadByProject(d.State(), instProjectName)
 		if err != nil {
 			return response.SmartError(err)
 		}
@@ -789,7 +1152,7 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 					continue
 				}
 
-				nicType, err := nictype.NICType(d.State(), dev)
+				nicType, err := nictype.NICType(d.State(), instProjectName, dev)
 				if err != nil || nicType != "bridged" {
 					continue
 				}
@@ -821,6 +1184,8 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 						Hwaddr:   dev["hwaddr"],
 						Type:     "static",
 						Location: inst.Location(),
+						Source:   "dhcpv4",
+						Gateway:  gatewayIPv4,
 					})
 				}
 
@@ -831,6 +1196,8 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 						Hwaddr:   dev["hwaddr"],
 						Type:     "static",
 						Location: inst.Location(),
+						Source:   "dhcpv6",
+						Gateway:  gatewayIPv6,
 					})
 				}
 			}
@@ -858,6 +1225,8 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	// Lines are "<expiry> <MAC or IAID> <address> <hostname> <client-id or DUID>", with IPv6 leases following a
+	// "duid <server DUID>" line.
 	for _, lease := range strings.Split(string(content), "\n") {
 		fields := strings.Fields(lease)
 		if len(fields) >= 5 {
@@ -883,13 +1252,75 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 			}
 
 			// Add the lease to the list.
-			leases = append(leases, api.NetworkLease{
+			entry := api.NetworkLease{
 				Hostname: fields[3],
 				Address:  fields[2],
 				Hwaddr:   macStr,
 				Type:     "dynamic",
 				Location: serverName,
-			})
+				Source:   "dhcpv4",
+				Gateway:  gatewayIPv4,
+			}
+
+			ip := net.ParseIP(fields[2])
+			if ip != nil && ip.To4() == nil {
+				entry.Source = "dhcpv6"
+				entry.Gateway = gatewayIPv6
+			}
+
+			if fields[4] != "*" {
+				entry.ClientID = fields[4]
+			}
+
+			// An expiry of 0 means the lease never expires.
+			expiry, err := strconv.ParseInt(fields[0], 10, 64)
+			if err == nil && expiry > 0 {
+				entry.ExpiresAt = time.Unix(expiry, 0).UTC()
+			}
+
+			leases = append(leases, entry)
+		}
+	}
+
+	// Get the SLAAC addresses from the neighbour table, as they don't go through dnsmasq.
+	if gatewayIPv6 != "" {
+		out, err := shared.RunCommand("ip", "-6", "neigh", "show", "dev", name)
+		if err == nil {
+			for _, line := range strings.Split(out, "\n") {
+				// Lines are "<address> lladdr <MAC> <state>".
+				fields := strings.Fields(line)
+				if len(fields) != 4 || fields[1] != "lladdr" || strings.HasPrefix(fields[0], "fe80::") {
+					continue
+				}
+
+				entry := api.NetworkLease{
+					Address:  fields[0],
+					Hwaddr:   fields[2],
+					Type:     "dynamic",
+					Location: serverName,
+					Source:   "slaac",
+					Gateway:  gatewayIPv6,
+				}
+
+				// Skip addresses already known and reuse the hostname of the MAC's other leases.
+				found := false
+				for _, other := range leases {
+					if other.Address == entry.Address {
+						found = true
+						break
+					}
+
+					if other.Hwaddr == entry.Hwaddr && entry.Hostname == "" {
+						entry.Hostname = other.Hostname
+					}
+				}
+
+				if found {
+					continue
+				}
+
+				leases = append(leases, entry)
+			}
 		}
 	}
 
@@ -901,7 +1332,7 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 		}
 
 		err = notifier(func(client lxd.InstanceServer) error {
-			memberLeases, err := client.GetNetworkLeases(name)
+			memberLeases, err := client.UseProject(instProjectName).GetNetworkLeases(name)
 			if err != nil {
 				return err
 			}
@@ -929,55 +1360,303 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 	return response.SyncResponse(true, leases)
 }
 
+// networkLeaseGateway returns the router address of a network address setting in CIDR notation, or an empty
+// string if the setting is empty or "none".
+func networkLeaseGateway(address string) string {
+	ip, _, err := net.ParseCIDR(address)
+	if err != nil {
+		return ""
+	}
+
+	return ip.String()
+}
+
+// networkMetricsGet returns the usage metrics of the network. Unless a target is specified, the metrics of all
+// the cluster members are added up.
+func networkMetricsGet(d *Daemon, r *http.Request) response.Response {
+	// If a target was specified, forward the request to the relevant node.
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	name := mux.Vars(r)["name"]
+
+	_, _, err = d.cluster.GetNetworkInAnyState(projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	metrics, err := networkMetricsLocal(d.State(), name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Collect metrics from other servers.
+	if !isClusterNotification(r) && queryParam(r, "target") == "" {
+		notifier, err := cluster.NewNotifier(d.State(), d.endpoints.NetworkCert(), cluster.NotifyAlive)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		var mu sync.Mutex
+		err = notifier(func(client lxd.InstanceServer) error {
+			memberMetrics, err := client.UseProject(projectName).GetNetworkMetrics(name)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			metrics.Counters.BytesReceived += memberMetrics.Counters.BytesReceived
+			metrics.Counters.BytesSent += memberMetrics.Counters.BytesSent
+			metrics.Counters.PacketsReceived += memberMetrics.Counters.PacketsReceived
+			metrics.Counters.PacketsSent += memberMetrics.Counters.PacketsSent
+			metrics.Leases += memberMetrics.Leases
+			metrics.NICs += memberMetrics.NICs
+			metrics.FirewallRules = append(metrics.FirewallRules, memberMetrics.FirewallRules...)
+
+			return nil
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	return response.SyncResponse(true, metrics)
+}
+
+// networkMetricsLocal returns the usage metrics of the network on this member.
+func networkMetricsLocal(s *state.State, name string) (*api.NetworkMetrics, error) {
+	metrics := api.NetworkMetrics{
+		FirewallRules: []api.NetworkMetricsFirewallRule{},
+	}
+
+	// Local server name.
+	var serverName string
+	err := s.Cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		serverName, err = tx.GetLocalNodeName()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Interface counters.
+	if shared.PathExists(fmt.Sprintf("/sys/class/net/%s", name)) {
+		metrics.Counters = shared.NetworkGetCounters(name)
+	}
+
+	// Active DHCP leases, a lease expiry of 0 meaning it never expires.
+	content, err := ioutil.ReadFile(shared.VarPath("networks", name, "dnsmasq.leases"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	for _, lease := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(lease)
+		if len(fields) < 5 {
+			continue
+		}
+
+		expiry, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil || (expiry != 0 && expiry < now) {
+			continue
+		}
+
+		metrics.Leases++
+	}
+
+	// NICs of the running instances connected to the network.
+	insts, err := instance.LoadNodeAll(s, instancetype.Any)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inst := range insts {
+		if !inst.IsRunning() {
+			continue
+		}
+
+		for _, dev := range inst.ExpandedDevices() {
+			if dev["type"] != "nic" {
+				continue
+			}
+
+			if dev["network"] == name || (dev["network"] == "" && dev["parent"] == name) {
+				metrics.NICs++
+			}
+		}
+	}
+
+	// Firewall rule counters.
+	counters, err := s.Firewall.NetworkCounters(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, counter := range counters {
+		metrics.FirewallRules = append(metrics.FirewallRules, api.NetworkMetricsFirewallRule{
+			Location: serverName,
+			Chain:    counter.Chain,
+			Rule:     counter.Rule,
+			Packets:  int64(counter.Packets),
+			Bytes:    int64(counter.Bytes),
+		})
+	}
+
+	return &metrics, nil
+}
+
 func networkStartup(s *state.State) error {
 	// Get a list of managed networks.
-	networks, err := s.Cluster.GetNonPendingNetworks()
+	projectNetworks, err := s.Cluster.GetNonPendingNetworks()
+	if err != nil {
+		return err
+	}
+
+	// Start the networks of the default project first, as the networks of the other projects rely on them
+	// as uplinks.
+	err = networkStartupProject(s, project.Default, projectNetworks[project.Default])
 	if err != nil {
 		return err
 	}
 
-	// Bring them all up.
-	for _, name := range networks {
-		n, err := network.LoadByName(s, name)
+	for projectName, names := range projectNetworks {
+		if projectName == project.Default {
+			continue
+		}
+
+		err = networkStartupProject(s, projectName, names)
 		if err != nil {
 			return err
 		}
+	}
+
+	return nil
+}
 
-		err = n.Validate(n.Config())
+// networkStartupProject brings up the given managed networks of a project.
+func networkStartupProject(s *state.State, projectName string, names []string) error {
+	// Load them all first so that dependencies between them can be resolved.
+	networks := make(map[string]network.Network, len(names))
+	for _, name := range names {
+		n, err := network.LoadByName(s, projectName, name)
 		if err != nil {
-			// Don't cause LXD to fail to start entirely on network start up failure.
-			logger.Error("Failed to validate network", log.Ctx{"err": err, "name": name})
-			continue
+			return err
 		}
 
-		err = n.Start()
-		if err != nil {
-			// Don't cause LXD to fail to start entirely on network start up failure.
-			logger.Error("Failed to bring up network", log.Ctx{"err": err, "name": name})
-			continue
+		networks[name] = n
+	}
+
+	// Bring them all up, starting the networks whose dependencies are already up concurrently.
+	started := map[string]bool{}
+	for len(started) < len(names) {
+		ready := []network.Network{}
+		for _, name := range names {
+			if started[name] {
+				continue
+			}
+
+			if networkDependenciesStarted(networks[name], networks, started) {
+				ready = append(ready, networks[name])
+			}
+		}
+
+		// Dependency loop, start all the remaining networks.
+		if len(ready) == 0 {
+			for _, name := range names {
+				if !started[name] {
+					ready = append(ready, networks[name])
+				}
+			}
 		}
+
+		for _, n := range ready {
+			started[n.Name()] = true
+		}
+
+		util.ParallelRun(len(ready), 0, func(i int) {
+			n := ready[i]
+
+			err := n.Validate(n.Config())
+			if err != nil {
+				// Don't cause LXD to fail to start entirely on network start up failure.
+				logger.Error("Failed to validate network", log.Ctx{"err": err, "project": projectName, "name": n.Name()})
+				return
+			}
+
+			err = n.Start()
+			if err != nil {
+				// Don't cause LXD to fail to start entirely on network start up failure.
+				logger.Error("Failed to bring up network", log.Ctx{"err": err, "project": projectName, "name": n.Name()})
+				return
+			}
+		})
 	}
 
 	return nil
 }
 
+// networkDependenciesStarted returns whether all the managed networks the given network relies on (as parent
+// interface, external interface or OVN uplink network) have already been started.
+func networkDependenciesStarted(n network.Network, networks map[string]network.Network, started map[string]bool) bool {
+	config := n.Config()
+
+	dependencies := []string{}
+	if config["parent"] != "" {
+		dependencies = append(dependencies, config["parent"])
+	}
+
+	if config["bridge.external_interfaces"] != "" {
+		for _, entry := range strings.Split(config["bridge.external_interfaces"], ",") {
+			// Only keep the parent of the VLAN interface entries ("<parent>/<vlan>").
+			entry = strings.SplitN(strings.TrimSpace(entry), "/", 2)[0]
+			dependencies = append(dependencies, entry)
+		}
+	}
+
+	if n.Type() == "ovn" && config["network"] != "" {
+		dependencies = append(dependencies, config["network"])
+	}
+
+	for _, dependency := range dependencies {
+		_, managed := networks[dependency]
+		if managed && dependency != n.Name() && !started[dependency] {
+			return false
+		}
+	}
+
+	return true
+}
+
 func networkShutdown(s *state.State) error {
 	// Get a list of managed networks
-	networks, err := s.Cluster.GetNetworks()
+	projectNetworks, err := s.Cluster.GetNetworksAllProjects()
 	if err != nil {
 		return err
 	}
 
-	// Bring them all up
-	for _, name := range networks {
-		n, err := network.LoadByName(s, name)
-		if err != nil {
-			return err
-		}
+	// Bring them all down
+	for projectName, networks := range projectNetworks {
+		for _, name := range networks {
+			n, err := network.LoadByName(s, projectName, name)
+			if err != nil {
+				return err
+			}
 
-		err = n.Stop()
-		if err != nil {
-			logger.Error("Failed to bring down network", log.Ctx{"err": err, "name": name})
+			err = n.Stop()
+			if err != nil {
+				logger.Error("Failed to bring down network", log.Ctx{"err": err, "project": projectName, "name": name})
+			}
 		}
 	}
 
@@ -1001,5 +1680,17 @@ func networkStateGet(d *Daemon, r *http.Request) response.Response {
 		return response.NotFound(fmt.Errorf("Interface '%s' not found", name))
 	}
 
-	return response.SyncResponse(true, networkGetState(*osInfo))
+	state := networkGetState(*osInfo)
+
+	// Add the counter samples of the requested period.
+	if queryParam(r, "period") != "" {
+		period, err := time.ParseDuration(queryParam(r, "period"))
+		if err != nil || period <= 0 {
+			return response.BadRequest(fmt.Errorf("Invalid period %q", queryParam(r, "period")))
+		}
+
+		state.Samples, state.NICSamples = networkStatsGet(name, period)
+	}
+
+	return response.SyncResponse(true, state)
 }
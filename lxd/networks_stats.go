@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// networkStatsRetention is how long the counter samples are kept for.
+const networkStatsRetention = 24 * time.Hour
+
+// networkStatsRing is a fixed size ring buffer of counter samples, the oldest samples being overwritten first.
+type networkStatsRing struct {
+	samples []api.NetworkStateSample
+	start   int
+	count   int
+}
+
+// add records a new sample.
+func (r *networkStatsRing) add(sample api.NetworkStateSample) {
+	if r.count < len(r.samples) {
+		r.samples[(r.start+r.count)%len(r.samples)] = sample
+		r.count++
+		return
+	}
+
+	r.samples[r.start] = sample
+	r.start = (r.start + 1) % len(r.samples)
+}
+
+// since returns the samples taken after the given time, oldest first.
+func (r *networkStatsRing) since(t time.Time) []api.NetworkStateSample {
+	samples := []api.NetworkStateSample{}
+	for i := 0; i < r.count; i++ {
+		sample := r.samples[(r.start+i)%len(r.samples)]
+		if sample.Time.After(t) {
+			samples = append(samples, sample)
+		}
+	}
+
+	return samples
+}
+
+// networkStats holds the counter samples of the network interfaces, indexed by interface name, and of the
+// instance NICs, indexed by network name and then by "<instance>/<device>".
+var networkStats = map[string]*networkStatsRing{}
+var networkStatsNICs = map[string]map[string]*networkStatsRing{}
+var networkStatsLock sync.Mutex
+
+// networkStatsInterval returns the interval at which the counters are sampled, 0 if sampling is disabled.
+func networkStatsInterval(s *state.State) (time.Duration, error) {
+	interval, err := cluster.ConfigGetInt64(s.Cluster, "network.stats.interval")
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(interval) * time.Second, nil
+}
+
+// networkStatsRecord adds a sample to the ring buffer stored under the given key, (re)creating the buffer if its
+// size doesn't match the number of samples to retain.
+func networkStatsRecord(rings map[string]*networkStatsRing, key string, size int, sample api.NetworkStateSample) {
+	ring, ok := rings[key]
+	if !ok || len(ring.samples) != size {
+		ring = &networkStatsRing{samples: make([]api.NetworkStateSample, size)}
+		rings[key] = ring
+	}
+
+	ring.add(sample)
+}
+
+// networkStatsSample samples the counters of the managed network interfaces and of the NICs of the local
+// running instances.
+func networkStatsSample(s *state.State, interval time.Duration) error {
+	size := int(networkStatsRetention / interval)
+	if size < 1 {
+		size = 1
+	}
+
+	now := time.Now().UTC()
+
+	projectNetworks, err := s.Cluster.GetNonPendingNetworks()
+	if err != nil {
+		return err
+	}
+
+	insts, err := instance.LoadNodeAll(s, instancetype.Any)
+	if err != nil {
+		return err
+	}
+
+	networkStatsLock.Lock()
+	defer networkStatsLock.Unlock()
+
+	// Sample the network interfaces.
+	seen := map[string]bool{}
+	for _, networks := range projectNetworks {
+		for _, name := range networks {
+			_, err := net.InterfaceByName(name)
+			if err != nil {
+				continue
+			}
+
+			seen[name] = true
+			networkStatsRecord(networkStats, name, size, api.NetworkStateSample{Time: now, Counters: shared.NetworkGetCounters(name)})
+		}
+	}
+
+	for name := range networkStats {
+		if !seen[name] {
+			delete(networkStats, name)
+		}
+	}
+
+	// Sample the instance NICs through their host side interface.
+	seenNICs := map[string]map[string]bool{}
+	for _, inst := range insts {
+		if !inst.IsRunning() {
+			continue
+		}
+
+		for devName, devConfig := range inst.ExpandedDevices() {
+			if devConfig["type"] != "nic" {
+				continue
+			}
+
+			networkName := devConfig["network"]
+			if networkName == "" {
+				networkName = devConfig["parent"]
+			}
+
+			hostName := inst.LocalConfig()[fmt.Sprintf("volatile.%s.host_name", devName)]
+			if networkName == "" || hostName == "" || !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", hostName)) {
+				continue
+			}
+
+			// Report the counters from the point of view of the instance.
+			hostCounters := shared.NetworkGetCounters(hostName)
+			counters := api.NetworkStateCounters{
+				BytesReceived:   hostCounters.BytesSent,
+				BytesSent:       hostCounters.BytesReceived,
+				PacketsReceived: hostCounters.PacketsSent,
+				PacketsSent:     hostCounters.PacketsReceived,
+			}
+
+			if networkStatsNICs[networkName] == nil {
+				networkStatsNICs[networkName] = map[string]*networkStatsRing{}
+			}
+
+			if seenNICs[networkName] == nil {
+				seenNICs[networkName] = map[string]bool{}
+			}
+
+			key := fmt.Sprintf("%s/%s", project.Instance(inst.Project(), inst.Name()), devName)
+			seenNICs[networkName][key] = true
+			networkStatsRecord(networkStatsNICs[networkName], key, size, api.NetworkStateSample{Time: now, Counters: counters})
+		}
+	}
+
+	for networkName, rings := range networkStatsNICs {
+		for key := range rings {
+			if !seenNICs[networkName][key] {
+				delete(rings, key)
+			}
+		}
+
+		if len(rings) == 0 {
+			delete(networkStatsNICs, networkName)
+		}
+	}
+
+	return nil
+}
+
+// networkStatsGet returns the samples of the interface and of the instance NICs connected to the network of the
+// same name, taken during the given period.
+func networkStatsGet(name string, period time.Duration) ([]api.NetworkStateSample, map[string][]api.NetworkStateSample) {
+	networkStatsLock.Lock()
+	defer networkStatsLock.Unlock()
+
+	since := time.Now().Add(-period)
+
+	samples := []api.NetworkStateSample{}
+	ring, ok := networkStats[name]
+	if ok {
+		samples = ring.since(since)
+	}
+
+	nicSamples := map[string][]api.NetworkStateSample{}
+	for key, ring := range networkStatsNICs[name] {
+		nicSamples[key] = ring.since(since)
+	}
+
+	return samples, nicSamples
+}
+
+// networkStatsTask samples the counters of the networks and of the instance NICs at the interval set by
+// "network.stats.interval".
+func networkStatsTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		s := d.State()
+
+		interval, err := networkStatsInterval(s)
+		if err != nil || interval <= 0 {
+			return
+		}
+
+		err = networkStatsSample(s, interval)
+		if err != nil {
+			logger.Error("Failed to sample network counters", log.Ctx{"err": err})
+		}
+	}
+
+	schedule := func() (time.Duration, error) {
+		return networkStatsInterval(d.State())
+	}
+
+	return f, schedule
+}
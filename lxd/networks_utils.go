@@ -1,19 +1,29 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/network/openvswitch"
+	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/task"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
 	"github.com/lxc/lxd/shared/logger"
 )
 
@@ -41,12 +51,19 @@ func networkAutoAttach(cluster *db.Cluster, devName string) error {
 	return network.AttachInterface(dbInfo.Name, devName)
 }
 
-func networkGetInterfaces(cluster *db.Cluster) ([]string, error) {
-	networks, err := cluster.GetNetworks()
+// networkGetInterfaces returns the managed networks of the project, as well as the host interfaces for the
+// default project.
+func networkGetInterfaces(cluster *db.Cluster, projectName string) ([]string, error) {
+	networks, err := cluster.GetNetworks(projectName)
 	if err != nil {
 		return nil, err
 	}
 
+	// Host interfaces are only visible from the default project.
+	if projectName != project.Default {
+		return networks, nil
+	}
+
 	ifaces, err := net.Interfaces()
 	if err != nil {
 		return nil, err
@@ -75,8 +92,9 @@ func networkUpdateForkdnsServersTask(s *state.State, heartbeatData *cluster.APIH
 		return err
 	}
 
-	for _, name := range networks {
-		n, err := network.LoadByName(s, name)
+	// Fan bridges are only found in the default project.
+	for _, name := range networks[project.Default] {
+		n, err := network.LoadByName(s, project.Default, name)
 		if err != nil {
 			logger.Errorf("Failed to load network %q for heartbeat", name)
 			continue
@@ -93,6 +111,36 @@ func networkUpdateForkdnsServersTask(s *state.State, heartbeatData *cluster.APIH
 	return nil
 }
 
+// networkPrefixDelegationTask requests or renews the prefixes delegated to the bridge networks by their upstream
+// DHCPv6 servers, renumbering the networks when their prefix changes.
+func networkPrefixDelegationTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		s := d.State()
+
+		networks, err := s.Cluster.GetNonPendingNetworks()
+		if err != nil {
+			logger.Error("Failed to load networks for prefix delegation", log.Ctx{"err": err})
+			return
+		}
+
+		// Bridges are only found in the default project.
+		for _, name := range networks[project.Default] {
+			n, err := network.LoadByName(s, project.Default, name)
+			if err != nil {
+				logger.Error("Failed to load network for prefix delegation", log.Ctx{"network": name, "err": err})
+				continue
+			}
+
+			err = n.RefreshPrefixDelegation()
+			if err != nil {
+				logger.Warn("Failed to refresh delegated prefix", log.Ctx{"network": name, "err": err})
+			}
+		}
+	}
+
+	return f, task.Every(time.Minute)
+}
+
 func networkGetState(netIf net.Interface) api.NetworkState {
 	netState := "down"
 	netType := "unknown"
@@ -210,6 +258,15 @@ func networkGetState(netIf net.Interface) api.NetworkState {
 			bonding.LowerDevices = strings.Split(strings.TrimSpace(string(strValue)), " ")
 		}
 
+		// Lower devices MII state.
+		bonding.LowerDevicesMIIState = map[string]string{}
+		for _, lowerDevice := range bonding.LowerDevices {
+			strValue, err = ioutil.ReadFile(fmt.Sprintf("/sys/class/net/%s/bonding_slave/mii_status", lowerDevice))
+			if err == nil {
+				bonding.LowerDevicesMIIState[lowerDevice] = strings.TrimSpace(string(strValue))
+			}
+		}
+
 		network.Bond = &bonding
 	}
 
@@ -248,6 +305,12 @@ func networkGetState(netIf net.Interface) api.NetworkState {
 			bridge.VLANFiltering = uintValue == 1
 		}
 
+		// Bridge multicast snooping.
+		uintValue, err = readUint(filepath.Join(bridgePath, "multicast_snooping"))
+		if err == nil {
+			bridge.MulticastSnooping = uintValue == 1
+		}
+
 		// Upper devices.
 		bridgeIfPath := fmt.Sprintf("/sys/class/net/%s/brif", netIf.Name)
 		if shared.PathExists(bridgeIfPath) {
@@ -260,10 +323,125 @@ func networkGetState(netIf net.Interface) api.NetworkState {
 			}
 		}
 
+		// Upper devices VLANs.
+		if bridge.VLANFiltering {
+			vlans, err := networkGetBridgeVLANs(bridge.UpperDevices)
+			if err == nil {
+				bridge.UpperDevicesVLANs = vlans
+			}
+		}
+
 		network.Bridge = &bridge
 	}
 
+	// Populate VLAN details.
+	vlan, err := networkGetVLANState(netIf.Name)
+	if err == nil {
+		network.VLAN = vlan
+	}
+
+	// Populate Open vSwitch details.
+	ovs := openvswitch.NewOVS()
+	if ovs.Installed() {
+		exists, err := ovs.BridgeExists(netIf.Name)
+		if err == nil && exists {
+			ovsState, err := networkGetOVSState(netIf.Name)
+			if err == nil {
+				network.OVS = ovsState
+			}
+		}
+	}
+
 	// Get counters.
 	network.Counters = shared.NetworkGetCounters(netIf.Name)
 	return network
 }
+
+// networkGetBridgeVLANs returns the VLANs of the given bridge ports.
+func networkGetBridgeVLANs(ports []string) (map[string][]api.NetworkStateBridgeVLAN, error) {
+	output, err := shared.RunCommand("bridge", "-j", "vlan", "show")
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []struct {
+		IfName string `json:"ifname"`
+		VLANs  []struct {
+			VLAN  uint64   `json:"vlan"`
+			Flags []string `json:"flags"`
+		} `json:"vlans"`
+	}{}
+
+	err = json.Unmarshal([]byte(output), &entries)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed parsing bridge VLANs")
+	}
+
+	vlans := map[string][]api.NetworkStateBridgeVLAN{}
+	for _, entry := range entries {
+		if !shared.StringInSlice(entry.IfName, ports) {
+			continue
+		}
+
+		for _, v := range entry.VLANs {
+			vlans[entry.IfName] = append(vlans[entry.IfName], api.NetworkStateBridgeVLAN{
+				ID:       v.VLAN,
+				PVID:     shared.StringInSlice("PVID", v.Flags),
+				Untagged: shared.StringInSlice("Egress Untagged", v.Flags),
+			})
+		}
+	}
+
+	return vlans, nil
+}
+
+// networkGetVLANState returns the VLAN details of the interface, or nil if it isn't a VLAN interface.
+func networkGetVLANState(name string) (*api.NetworkStateVLAN, error) {
+	content, err := ioutil.ReadFile("/proc/net/vlan/config")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	// Entries are "<name> | <VLAN ID> | <lower device>".
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Split(line, "|")
+		if len(fields) != 3 || strings.TrimSpace(fields[0]) != name {
+			continue
+		}
+
+		vid, err := strconv.ParseUint(strings.TrimSpace(fields[1]), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		return &api.NetworkStateVLAN{LowerDevice: strings.TrimSpace(fields[2]), VID: vid}, nil
+	}
+
+	return nil, nil
+}
+
+// networkGetOVSState returns the details of an Open vSwitch bridge.
+func networkGetOVSState(name string) (*api.NetworkStateOVS, error) {
+	ovs := openvswitch.NewOVS()
+
+	ports, err := ovs.BridgePorts(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ovsState := api.NetworkStateOVS{Ports: []api.NetworkStateOVSPort{}}
+	for _, port := range ports {
+		tag, trunks, err := ovs.PortVLANs(port)
+		if err != nil {
+			return nil, err
+		}
+
+		ovsState.Ports = append(ovsState.Ports, api.NetworkStateOVSPort{Name: port, VLANTag: tag, VLANTrunks: trunks})
+	}
+
+	return &ovsState, nil
+}
@@ -3,10 +3,14 @@ package node
 import (
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
 
 	"github.com/lxc/lxd/lxd/config"
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/events"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/validate"
 	"github.com/pkg/errors"
 )
 
@@ -51,6 +55,27 @@ func (c *Config) DebugAddress() string {
 	return c.m.GetString("core.debug_address")
 }
 
+// BGPAddress returns the address and port to setup the BGP listener on
+func (c *Config) BGPAddress() string {
+	return c.m.GetString("core.bgp_address")
+}
+
+// BGPRouterID returns the BGP router ID of the node, if any.
+func (c *Config) BGPRouterID() string {
+	return c.m.GetString("core.bgp_routerid")
+}
+
+// DNSAddress returns the address and port to setup the DNS listener on
+func (c *Config) DNSAddress() string {
+	return c.m.GetString("core.dns_address")
+}
+
+// FirewallDriver returns the firewall driver to use, an empty value meaning
+// that it's detected automatically.
+func (c *Config) FirewallDriver() string {
+	return c.m.GetString("core.firewall_driver")
+}
+
 // MAASMachine returns the MAAS machine this instance is associated with, if
 // any.
 func (c *Config) MAASMachine() string {
@@ -67,6 +92,11 @@ func (c *Config) StorageImagesVolume() string {
 	return c.m.GetString("storage.images_volume")
 }
 
+// SyslogEvents returns the list of event types to mirror to syslog/journald.
+func (c *Config) SyslogEvents() []string {
+	return syslogEventTypes(c.m.GetString("core.syslog_events"))
+}
+
 // Dump current configuration keys and their values. Keys with values matching
 // their defaults are omitted.
 func (c *Config) Dump() map[string]interface{} {
@@ -136,6 +166,76 @@ func DebugAddress(node *db.Node) (string, error) {
 	return config.DebugAddress(), nil
 }
 
+// BGPAddress is a convenience for loading the node configuration and
+// returning the values of core.bgp_address and core.bgp_routerid.
+func BGPAddress(node *db.Node) (string, string, error) {
+	var config *Config
+	err := node.Transaction(func(tx *db.NodeTx) error {
+		var err error
+		config, err = ConfigLoad(tx)
+		return err
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return config.BGPAddress(), config.BGPRouterID(), nil
+}
+
+// DNSAddress is a convenience for loading the node configuration and
+// returning the value of core.dns_address.
+func DNSAddress(node *db.Node) (string, error) {
+	var config *Config
+	err := node.Transaction(func(tx *db.NodeTx) error {
+		var err error
+		config, err = ConfigLoad(tx)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return config.DNSAddress(), nil
+}
+
+// AutostartParallelism returns the maximum number of instances started
+// concurrently when the daemon starts, 0 meaning the number of CPUs.
+func (c *Config) AutostartParallelism() int64 {
+	return c.m.GetInt64("boot.autostart.parallelism")
+}
+
+// FirewallDriver is a convenience for loading the node configuration and
+// returning the value of core.firewall_driver.
+func FirewallDriver(node *db.Node) (string, error) {
+	var config *Config
+	err := node.Transaction(func(tx *db.NodeTx) error {
+		var err error
+		config, err = ConfigLoad(tx)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return config.FirewallDriver(), nil
+}
+
+// AutostartParallelism is a convenience for loading the node configuration and
+// returning the value of boot.autostart.parallelism.
+func AutostartParallelism(node *db.Node) (int64, error) {
+	var config *Config
+	err := node.Transaction(func(tx *db.NodeTx) error {
+		var err error
+		config, err = ConfigLoad(tx)
+		return err
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return config.AutostartParallelism(), nil
+}
+
 func (c *Config) update(values map[string]interface{}) (map[string]string, error) {
 	changed, err := c.m.Change(values)
 	if err != nil {
@@ -152,6 +252,9 @@ func (c *Config) update(values map[string]interface{}) (map[string]string, error
 
 // ConfigSchema defines available server configuration keys.
 var ConfigSchema = config.Schema{
+	// Maximum number of instances started concurrently on startup
+	"boot.autostart.parallelism": {Type: config.Int64, Default: "0", Validator: validateAutostartParallelism},
+
 	// Network address for this LXD server
 	"core.https_address": {},
 
@@ -161,6 +264,19 @@ var ConfigSchema = config.Schema{
 	// Network address for the debug server
 	"core.debug_address": {},
 
+	// Network address and router ID for the BGP server
+	"core.bgp_address":  {Validator: validateBGPAddress},
+	"core.bgp_routerid": {Validator: validate.Optional(validate.IsNetworkAddressV4)},
+
+	// Network address for the DNS server
+	"core.dns_address": {},
+
+	// Firewall driver to use instead of the detected one
+	"core.firewall_driver": {Validator: validateFirewallDriver},
+
+	// Event types to mirror to syslog/journald
+	"core.syslog_events": {Validator: validateSyslogEvents},
+
 	// MAAS machine this LXD instance is associated with
 	"maas.machine": {},
 
@@ -182,3 +298,55 @@ func validateClusterHTTPSAddress(value string) error {
 	}
 	return nil
 }
+
+func validateBGPAddress(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	_, _, err := net.SplitHostPort(value)
+	if err != nil {
+		return validate.IsNetworkAddress(strings.Trim(value, "[]"))
+	}
+
+	return nil
+}
+
+func validateAutostartParallelism(value string) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid integer")
+	}
+
+	if n < 0 {
+		return fmt.Errorf("Value must not be negative")
+	}
+
+	return nil
+}
+
+func validateFirewallDriver(value string) error {
+	return validate.IsOneOf(value, []string{"nftables", "xtables"})
+}
+
+func validateSyslogEvents(value string) error {
+	for _, eventType := range syslogEventTypes(value) {
+		if !shared.StringInSlice(eventType, events.SyslogEventTypes) {
+			return fmt.Errorf("Invalid event type %q (must be one of %s)", eventType, strings.Join(events.SyslogEventTypes, ", "))
+		}
+	}
+
+	return nil
+}
+
+func syslogEventTypes(value string) []string {
+	types := []string{}
+	for _, eventType := range strings.Split(value, ",") {
+		eventType = strings.TrimSpace(eventType)
+		if eventType != "" {
+			types = append(types, eventType)
+		}
+	}
+
+	return types
+}
@@ -142,3 +142,29 @@ func TestClusterAddress(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "127.0.0.1:666", address)
 }
+
+// The boot.autostart.parallelism config key is fetched from the db with a new
+// transaction, and must not be negative.
+func TestAutostartParallelism(t *testing.T) {
+	nodeDB, cleanup := db.NewTestNode(t)
+	defer cleanup()
+
+	parallelism, err := node.AutostartParallelism(nodeDB)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), parallelism)
+
+	err = nodeDB.Transaction(func(tx *db.NodeTx) error {
+		config, err := node.ConfigLoad(tx)
+		require.NoError(t, err)
+		_, err = config.Replace(map[string]interface{}{"boot.autostart.parallelism": "-1"})
+		assert.Error(t, err)
+		_, err = config.Replace(map[string]interface{}{"boot.autostart.parallelism": "4"})
+		require.NoError(t, err)
+		return nil
+	})
+	require.NoError(t, err)
+
+	parallelism, err = node.AutostartParallelism(nodeDB)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), parallelism)
+}
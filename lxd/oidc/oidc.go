@@ -0,0 +1,409 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	goidc "github.com/coreos/go-oidc/v3/oidc"
+	jose "github.com/go-jose/go-jose/v4"
+)
+
+// Headers used to tell clients how to authenticate against the OpenID Connect provider.
+const (
+	HeaderIssuer   = "X-LXD-OIDC-issuer"
+	HeaderClientID = "X-LXD-OIDC-clientid"
+	HeaderAudience = "X-LXD-OIDC-audience"
+)
+
+// Leeway allowed on the token validity dates to account for clock skew.
+const leeway = time.Minute
+
+// Minimum interval between two requests to the provider for its discovery document or its signing keys, so
+// that tokens signed with unknown keys can't be used to flood it.
+const refreshInterval = time.Minute
+
+// Token signing algorithms accepted by the verifier.
+var signingAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+}
+
+// AuthError is returned when a bearer token can't be verified. Clients should authenticate again.
+type AuthError struct {
+	Err error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("Failed OpenID Connect authentication: %v", e.Err)
+}
+
+// Verifier checks the bearer tokens sent by clients against an OpenID Connect provider.
+type Verifier struct {
+	issuer   string
+	clientID string
+	audience string
+
+	client *http.Client
+
+	// mu guards the fields below. It's never held while talking to the provider.
+	mu           sync.Mutex
+	verifier     *goidc.IDTokenVerifier
+	discoveredAt time.Time
+	discoveryErr error
+}
+
+// NewVerifier returns a Verifier for tokens from the given issuer. The tokens must be intended for the given
+// audience or, if no audience is set, for the given client ID.
+func NewVerifier(issuer string, clientID string, audience string) *Verifier {
+	return &Verifier{
+		issuer:   issuer,
+		clientID: clientID,
+		audience: audience,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// IsRequest returns whether the request carries a bearer token.
+func IsRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// WriteHeaders sets the headers clients need to authenticate against the provider.
+func (v *Verifier) WriteHeaders(w http.ResponseWriter) {
+	w.Header().Set(HeaderIssuer, v.issuer)
+	w.Header().Set(HeaderClientID, v.clientID)
+
+	if v.audience != "" {
+		w.Header().Set(HeaderAudience, v.audience)
+	}
+}
+
+// Auth verifies the bearer token of the request and returns the user it identifies.
+func (v *Verifier) Auth(ctx context.Context, r *http.Request) (string, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	claims, err := v.verify(ctx, token)
+	if err != nil {
+		return "", &AuthError{Err: err}
+	}
+
+	if claims.Email != "" {
+		return claims.Email, nil
+	}
+
+	return claims.Subject, nil
+}
+
+type claims struct {
+	Subject   string `json:"sub"`
+	Email     string `json:"email"`
+	AuthParty string `json:"azp"`
+}
+
+// verify checks the signature and the claims of the given JWT.
+func (v *Verifier) verify(ctx context.Context, token string) (*claims, error) {
+	verifier, err := v.tokenVerifier(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	c := claims{}
+	err = idToken.Claims(&c)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid token claims: %v", err)
+	}
+
+	if v.audience != "" {
+		if !contains(idToken.Audience, v.audience) {
+			return nil, fmt.Errorf("Token not intended for audience %q", v.audience)
+		}
+	} else if !contains(idToken.Audience, v.clientID) && c.AuthParty != v.clientID {
+		return nil, fmt.Errorf("Token not intended for client %q", v.clientID)
+	}
+
+	if c.Subject == "" {
+		return nil, fmt.Errorf("Token has no subject")
+	}
+
+	return &c, nil
+}
+
+// tokenVerifier returns the verifier for the tokens of the provider, looking up the provider through its
+// discovery document on first use. Failed lookups are only retried after refreshInterval.
+func (v *Verifier) tokenVerifier(ctx context.Context) (*goidc.IDTokenVerifier, error) {
+	v.mu.Lock()
+	if v.verifier != nil || time.Since(v.discoveredAt) < refreshInterval {
+		verifier, err := v.verifier, v.discoveryErr
+		v.mu.Unlock()
+
+		if verifier == nil && err == nil {
+			err = fmt.Errorf("The OpenID Connect provider isn't available yet")
+		}
+
+		return verifier, err
+	}
+
+	v.discoveredAt = time.Now()
+	v.mu.Unlock()
+
+	verifier, err := v.discover(ctx)
+
+	v.mu.Lock()
+	v.verifier, v.discoveryErr = verifier, err
+	v.mu.Unlock()
+
+	return verifier, err
+}
+
+// discover looks up the provider through its discovery document and returns a verifier for its tokens.
+func (v *Verifier) discover(ctx context.Context) (*goidc.IDTokenVerifier, error) {
+	provider, err := goidc.NewProvider(goidc.ClientContext(ctx, v.client), v.issuer)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the OpenID Connect discovery document: %v", err)
+	}
+
+	discovery := struct {
+		JWKSURI string `json:"jwks_uri"`
+	}{}
+
+	err = provider.Claims(&discovery)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the OpenID Connect discovery document: %v", err)
+	}
+
+	if discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("The OpenID Connect provider has no JWKS URI")
+	}
+
+	algorithms := make([]string, 0, len(signingAlgorithms))
+	for _, algorithm := range signingAlgorithms {
+		algorithms = append(algorithms, string(algorithm))
+	}
+
+	config := &goidc.Config{
+		// The audience is checked by verify, as it may be either the audience or the client ID.
+		SkipClientIDCheck:    true,
+		SupportedSigningAlgs: algorithms,
+		Now:                  func() time.Time { return time.Now().Add(-leeway) },
+	}
+
+	keys := &keySet{url: discovery.JWKSURI, client: v.client}
+
+	return goidc.NewVerifier(v.issuer, keys, config), nil
+}
+
+// keySet holds the signing keys of the provider. Tokens signed with an unknown key trigger a refresh of the
+// keys, at most once per refreshInterval.
+type keySet struct {
+	url    string
+	client *http.Client
+
+	// mu guards the fields below. It's never held while talking to the provider.
+	mu          sync.Mutex
+	keys        []jose.JSONWebKey
+	refreshedAt time.Time
+	refreshing  chan struct{}
+	refreshErr  error
+}
+
+// VerifySignature checks the signature of the given JWT and returns its payload.
+func (s *keySet) VerifySignature(ctx context.Context, token string) ([]byte, error) {
+	jws, err := jose.ParseSigned(token, signingAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("Malformed token: %v", err)
+	}
+
+	if len(jws.Signatures) != 1 {
+		return nil, fmt.Errorf("Tokens must have a single signature")
+	}
+
+	hdr := jws.Signatures[0].Header
+
+	keys, err := s.find(ctx, hdr.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		err = checkAlgorithm(hdr.Algorithm, key)
+		if err != nil {
+			continue
+		}
+
+		payload, verifyErr := jws.Verify(key.Key)
+		if verifyErr == nil {
+			return payload, nil
+		}
+
+		err = fmt.Errorf("Invalid token signature")
+	}
+
+	return nil, err
+}
+
+// find returns the keys with the given ID, refreshing the keys if there's none.
+func (s *keySet) find(ctx context.Context, keyID string) ([]jose.JSONWebKey, error) {
+	s.mu.Lock()
+	keys := s.lookup(keyID)
+	if len(keys) > 0 {
+		s.mu.Unlock()
+		return keys, nil
+	}
+
+	done := s.refreshing
+	if done == nil && time.Since(s.refreshedAt) >= refreshInterval {
+		done = make(chan struct{})
+		s.refreshing = done
+		s.refreshedAt = time.Now()
+		s.mu.Unlock()
+
+		s.refresh(ctx, done)
+	} else {
+		s.mu.Unlock()
+	}
+
+	// Wait for the ongoing refresh, if any.
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	s.mu.Lock()
+	keys = s.lookup(keyID)
+	err := s.refreshErr
+	s.mu.Unlock()
+
+	if len(keys) == 0 {
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("Unknown token signing key %q", keyID)
+	}
+
+	return keys, nil
+}
+
+// lookup returns the known keys with the given ID. The caller must hold mu.
+func (s *keySet) lookup(keyID string) []jose.JSONWebKey {
+	keys := []jose.JSONWebKey{}
+	for _, key := range s.keys {
+		if key.KeyID == keyID {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// refresh fetches the keys of the provider and closes the given channel once done.
+func (s *keySet) refresh(ctx context.Context, done chan struct{}) {
+	keys, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	if err == nil {
+		s.keys = keys
+	}
+
+	s.refreshErr = err
+	s.refreshing = nil
+	s.mu.Unlock()
+
+	close(done)
+}
+
+// fetch retrieves the signing keys of the provider.
+func (s *keySet) fetch(ctx context.Context) ([]jose.JSONWebKey, error) {
+	req, err := http.NewRequest("GET", s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the OpenID Connect signing keys: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to get the OpenID Connect signing keys: Unexpected status code %d", resp.StatusCode)
+	}
+
+	jwks := jose.JSONWebKeySet{}
+	err = json.NewDecoder(resp.Body).Decode(&jwks)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the OpenID Connect signing keys: %v", err)
+	}
+
+	keys := []jose.JSONWebKey{}
+	for _, key := range jwks.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		if !key.Valid() || !key.IsPublic() {
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// checkAlgorithm returns an error if the given token signing algorithm can't be used with the given key. In
+// particular, the curve of elliptic curve keys must match the algorithm.
+func checkAlgorithm(algorithm string, key jose.JSONWebKey) error {
+	if key.Algorithm != "" && key.Algorithm != algorithm {
+		return fmt.Errorf("Token signing algorithm %q doesn't match the key algorithm %q", algorithm, key.Algorithm)
+	}
+
+	switch pub := key.Key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(algorithm, "RS") && !strings.HasPrefix(algorithm, "PS") {
+			return fmt.Errorf("Token signing algorithm %q doesn't match the RSA key", algorithm)
+		}
+	case *ecdsa.PublicKey:
+		curves := map[string]elliptic.Curve{
+			string(jose.ES256): elliptic.P256(),
+			string(jose.ES384): elliptic.P384(),
+			string(jose.ES512): elliptic.P521(),
+		}
+
+		if curves[algorithm] != pub.Curve {
+			return fmt.Errorf("Token signing algorithm %q doesn't match the %s key", algorithm, pub.Curve.Params().Name)
+		}
+	default:
+		return fmt.Errorf("Unsupported token signing key")
+	}
+
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,243 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newProvider starts a fake OpenID Connect provider serving the public part of the given key. The number of
+// requests for the keys is counted in the given counter.
+func newProvider(t *testing.T, key *rsa.PrivateKey, keyRequests *int32) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   server.URL,
+			"jwks_uri": server.URL + "/keys",
+		})
+	})
+
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(keyRequests, 1)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+
+	return server
+}
+
+// newToken returns a JWT with the given claims, signed with RS256.
+func newToken(t *testing.T, key *rsa.PrivateKey, keyID string, claims map[string]interface{}) string {
+	hdr, err := json.Marshal(map[string]string{"alg": "RS256", "kid": keyID})
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(hdr) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifier_Auth(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var keyRequests int32
+	provider := newProvider(t, key, &keyRequests)
+	defer provider.Close()
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":   provider.URL,
+			"sub":   "1234",
+			"email": "user@example.com",
+			"aud":   []string{"lxd"},
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	cases := []struct {
+		name   string
+		keyID  string
+		modify func(claims map[string]interface{})
+		user   string
+		err    string
+	}{
+		{
+			name:   "valid",
+			keyID:  "key1",
+			modify: func(claims map[string]interface{}) {},
+			user:   "user@example.com",
+		},
+		{
+			name:   "subject without email",
+			keyID:  "key1",
+			modify: func(claims map[string]interface{}) { delete(claims, "email") },
+			user:   "1234",
+		},
+		{
+			name:   "expired",
+			keyID:  "key1",
+			modify: func(claims map[string]interface{}) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+			err:    "token is expired",
+		},
+		{
+			name:   "wrong audience",
+			keyID:  "key1",
+			modify: func(claims map[string]interface{}) { claims["aud"] = "other" },
+			err:    `Token not intended for audience "lxd"`,
+		},
+		{
+			name:   "wrong issuer",
+			keyID:  "key1",
+			modify: func(claims map[string]interface{}) { claims["iss"] = "https://example.com" },
+			err:    `id token issued by a different provider`,
+		},
+		{
+			name:   "unknown key",
+			keyID:  "key2",
+			modify: func(claims map[string]interface{}) {},
+			err:    `Unknown token signing key "key2"`,
+		},
+	}
+
+	verifier := NewVerifier(provider.URL, "client", "lxd")
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			claims := valid()
+			c.modify(claims)
+
+			r := httptest.NewRequest("GET", "/1.0", nil)
+			r.Header.Set("Authorization", fmt.Sprintf("Bearer %s", newToken(t, key, c.keyID, claims)))
+			assert.True(t, IsRequest(r))
+
+			user, err := verifier.Auth(context.Background(), r)
+			if c.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), c.err)
+				assert.IsType(t, &AuthError{}, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, c.user, user)
+		})
+	}
+
+	// Tokens signed with unknown keys don't trigger a new request for the keys within the refresh interval.
+	assert.Equal(t, int32(1), atomic.LoadInt32(&keyRequests))
+}
+
+func TestVerifier_AuthBadSignature(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var keyRequests int32
+	provider := newProvider(t, key, &keyRequests)
+	defer provider.Close()
+
+	token := newToken(t, other, "key1", map[string]interface{}{
+		"iss": provider.URL,
+		"sub": "1234",
+		"aud": "client",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	r := httptest.NewRequest("GET", "/1.0", nil)
+	r.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	verifier := NewVerifier(provider.URL, "client", "")
+	_, err = verifier.Auth(context.Background(), r)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid token signature")
+}
+
+func TestCheckAlgorithm(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	cases := []struct {
+		algorithm string
+		key       jose.JSONWebKey
+		err       string
+	}{
+		{algorithm: "RS256", key: jose.JSONWebKey{Key: &rsaKey.PublicKey}},
+		{algorithm: "PS384", key: jose.JSONWebKey{Key: &rsaKey.PublicKey}},
+		{algorithm: "ES256", key: jose.JSONWebKey{Key: &p256Key.PublicKey}},
+		{algorithm: "ES384", key: jose.JSONWebKey{Key: &p384Key.PublicKey}},
+		{
+			algorithm: "ES256",
+			key:       jose.JSONWebKey{Key: &p384Key.PublicKey},
+			err:       `Token signing algorithm "ES256" doesn't match the P-384 key`,
+		},
+		{
+			algorithm: "ES256",
+			key:       jose.JSONWebKey{Key: &rsaKey.PublicKey},
+			err:       `Token signing algorithm "ES256" doesn't match the RSA key`,
+		},
+		{
+			algorithm: "RS256",
+			key:       jose.JSONWebKey{Key: &p256Key.PublicKey},
+			err:       `Token signing algorithm "RS256" doesn't match the P-256 key`,
+		},
+		{
+			algorithm: "RS256",
+			key:       jose.JSONWebKey{Key: &rsaKey.PublicKey, Algorithm: "RS512"},
+			err:       `Token signing algorithm "RS256" doesn't match the key algorithm "RS512"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.algorithm, func(t *testing.T) {
+			err := checkAlgorithm(c.algorithm, c.key)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -48,10 +49,10 @@ var operationWebsocket = APIEndpoint{
 }
 
 // waitForOperations waits for operations to finish. There's a timeout for console/exec operations
-// that when reached will shut down the instances forcefully.
+// ("core.shutdown_timeout") that when reached will shut down the instances forcefully.
 // It also watches the cancel channel, and will return if it receives data.
 func waitForOperations(s *state.State, chCancel chan struct{}) {
-	timeout := time.After(5 * time.Minute)
+	timeout := time.After(shutdownTimeout(s))
 	tick := time.Tick(time.Second)
 	logTick := time.Tick(time.Minute)
 
@@ -91,7 +92,7 @@ func waitForOperations(s *state.State, chCancel chan struct{}) {
 
 		select {
 		case <-timeout:
-			// We wait up to 5 minutes for exec/console operations to finish.
+			// We wait up to "core.shutdown_timeout" for exec/console operations to finish.
 			// If there are still running operations, we shut down the instances
 			// which will terminate the operations.
 			if execConsoleOps > 0 {
@@ -111,6 +112,30 @@ func waitForOperations(s *state.State, chCancel chan struct{}) {
 	}
 }
 
+// operationVisible returns whether the requester can see the operations of the given project. Operations which
+// aren't part of any project, such as tokens, are only visible to administrators.
+func operationVisible(d *Daemon, r *http.Request, projectName string) bool {
+	if projectName == "" {
+		return d.userIsAdmin(r)
+	}
+
+	return d.userHasPermission(r, projectName, "view")
+}
+
+// operationRemote returns the operation with the given UUID from the database. Requests for operations running
+// on other members are forwarded with the cluster certificate, which has full access, so the permissions of the
+// requester must be checked against the returned operation before forwarding.
+func operationRemote(d *Daemon, id string) (db.Operation, error) {
+	var operation db.Operation
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		operation, err = tx.GetOperationByUUID(id)
+		return err
+	})
+
+	return operation, err
+}
+
 // API functions
 func operationGet(d *Daemon, r *http.Request) response.Response {
 	id := mux.Vars(r)["id"]
@@ -120,6 +145,10 @@ func operationGet(d *Daemon, r *http.Request) response.Response {
 	// First check if the query is for a local operation from this node
 	op, err := operations.OperationGetInternal(id)
 	if err == nil {
+		if !operationVisible(d, r, op.Project()) {
+			return response.NotFound(fmt.Errorf("Operation not found"))
+		}
+
 		_, body, err = op.Render()
 		if err != nil {
 			return response.SmartError(err)
@@ -129,22 +158,17 @@ func operationGet(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// Then check if the query is from an operation on another node, and, if so, forward it
-	var address string
-	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
-		operation, err := tx.GetOperationByUUID(id)
-		if err != nil {
-			return err
-		}
-
-		address = operation.NodeAddress
-		return nil
-	})
+	operation, err := operationRemote(d, id)
 	if err != nil {
 		return response.SmartError(err)
 	}
 
+	if !operationVisible(d, r, operation.Project) {
+		return response.NotFound(fmt.Errorf("Operation not found"))
+	}
+
 	cert := d.endpoints.NetworkCert()
-	client, err := cluster.Connect(address, cert, false)
+	client, err := cluster.Connect(operation.NodeAddress, cert, false)
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -158,6 +182,10 @@ func operationDelete(d *Daemon, r *http.Request) response.Response {
 	// First check if the query is for a local operation from this node
 	op, err := operations.OperationGetInternal(id)
 	if err == nil {
+		if !operationVisible(d, r, op.Project()) {
+			return response.NotFound(fmt.Errorf("Operation not found"))
+		}
+
 		if op.Permission() != "" {
 			projectName := op.Project()
 			if projectName == "" {
@@ -178,22 +206,28 @@ func operationDelete(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// Then check if the query is from an operation on another node, and, if so, forward it
-	var address string
-	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
-		operation, err := tx.GetOperationByUUID(id)
-		if err != nil {
-			return err
-		}
-
-		address = operation.NodeAddress
-		return nil
-	})
+	operation, err := operationRemote(d, id)
 	if err != nil {
 		return response.SmartError(err)
 	}
 
+	if !operationVisible(d, r, operation.Project) {
+		return response.NotFound(fmt.Errorf("Operation not found"))
+	}
+
+	if operation.Type.Permission() != "" {
+		projectName := operation.Project
+		if projectName == "" {
+			projectName = project.Default
+		}
+
+		if !d.userHasPermission(r, projectName, operation.Type.Permission()) {
+			return response.Forbidden(nil)
+		}
+	}
+
 	cert := d.endpoints.NetworkCert()
-	client, err := cluster.Connect(address, cert, false)
+	client, err := cluster.Connect(operation.NodeAddress, cert, false)
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -202,9 +236,21 @@ func operationDelete(d *Daemon, r *http.Request) response.Response {
 }
 
 func operationsGet(d *Daemon, r *http.Request) response.Response {
-	project := projectParam(r)
+	projectName := projectParam(r)
+	allProjects := shared.IsTrue(r.FormValue("all-projects"))
 	recursion := util.IsRecursionRequest(r)
 
+	if allProjects {
+		if !d.userIsAdmin(r) {
+			return response.Forbidden(fmt.Errorf("Only administrators can list the operations of all projects"))
+		}
+	} else if !d.userHasPermission(r, projectName, "view") {
+		return response.Forbidden(nil)
+	}
+
+	// Operations which aren't part of any project are only listed to administrators.
+	isAdmin := d.userIsAdmin(r)
+
 	localOperationURLs := func() (shared.Jmap, error) {
 		// Get all the operations
 		localOps := operations.Clone()
@@ -213,9 +259,14 @@ func operationsGet(d *Daemon, r *http.Request) response.Response {
 		body := shared.Jmap{}
 
 		for _, v := range localOps {
-			if v.Project() != "" && v.Project() != project {
+			if !allProjects && v.Project() != "" && v.Project() != projectName {
 				continue
 			}
+
+			if v.Project() == "" && !isAdmin {
+				continue
+			}
+
 			status := strings.ToLower(v.Status().String())
 			_, ok := body[status]
 			if !ok {
@@ -228,6 +279,8 @@ func operationsGet(d *Daemon, r *http.Request) response.Response {
 		return body, nil
 	}
 
+	fromClusterMember := isClusterNotification(r) && r.Context().Value("protocol") == "cluster"
+
 	localOperations := func() (shared.Jmap, error) {
 		// Get all the operations
 		localOps := operations.Clone()
@@ -236,16 +289,27 @@ func operationsGet(d *Daemon, r *http.Request) response.Response {
 		body := shared.Jmap{}
 
 		for _, v := range localOps {
-			if v.Project() != "" && v.Project() != project {
+			if !allProjects && v.Project() != "" && v.Project() != projectName {
 				continue
 			}
+
+			if v.Project() == "" && !isAdmin {
+				continue
+			}
+
 			status := strings.ToLower(v.Status().String())
 			_, ok := body[status]
 			if !ok {
 				body[status] = make([]*api.Operation, 0)
 			}
 
-			_, op, err := v.Render()
+			// Other cluster members get the token secrets, so that they can consume tokens created here.
+			render := v.Render
+			if fromClusterMember {
+				render = v.RenderWithSecrets
+			}
+
+			_, op, err := render()
 			if err != nil {
 				return nil, err
 			}
@@ -305,12 +369,16 @@ func operationsGet(d *Daemon, r *http.Request) response.Response {
 		return response.SyncResponse(true, md)
 	}
 
-	// Get all nodes with running operations in this project.
+	// Get all nodes with running operations in this project (or in any project).
 	var nodes []string
 	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
 		var err error
 
-		nodes, err = tx.GetNodesWithRunningOperations(project)
+		if allProjects {
+			nodes, err = tx.GetAllNodesWithRunningOperations()
+		} else {
+			nodes, err = tx.GetNodesWithRunningOperations(projectName)
+		}
 		if err != nil {
 			return err
 		}
@@ -340,13 +408,23 @@ func operationsGet(d *Daemon, r *http.Request) response.Response {
 		}
 
 		// Get operation data
-		ops, err := client.GetOperations()
+		var ops []api.Operation
+		if allProjects {
+			ops, err = client.GetOperationsAllProjects()
+		} else {
+			ops, err = client.UseProject(projectName).GetOperations()
+		}
 		if err != nil {
 			return response.SmartError(err)
 		}
 
 		// Merge with existing data
-		for _, op := range ops {
+		for i := range ops {
+			op := ops[i]
+			if op.Project == "" && !isAdmin {
+				continue
+			}
+
 			status := strings.ToLower(op.Status)
 
 			_, ok := md[status]
@@ -359,6 +437,8 @@ func operationsGet(d *Daemon, r *http.Request) response.Response {
 			}
 
 			if recursion {
+				// The other members return the token secrets, which are only meant for the cluster.
+				operations.RedactSecrets(&op)
 				md[status] = append(md[status].([]*api.Operation), &op)
 			} else {
 				md[status] = append(md[status].([]string), fmt.Sprintf("/1.0/operations/%s", op.ID))
@@ -390,7 +470,20 @@ func operationWaitGet(d *Daemon, r *http.Request) response.Response {
 			return response.Forbidden(nil)
 		}
 
-		_, err = op.WaitFinal(timeout)
+		// Untrusted callers are authenticated by the secret, trusted ones must be able to see the operation.
+		if trusted && !operationVisible(d, r, op.Project()) {
+			return response.NotFound(fmt.Errorf("Operation not found"))
+		}
+
+		// Stop waiting once the timeout is reached or the client goes away, leaving the operation running.
+		ctx := r.Context()
+		if timeout >= 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+			defer cancel()
+		}
+
+		_, err = op.Wait(ctx)
 		if err != nil {
 			return response.InternalError(err)
 		}
@@ -404,22 +497,17 @@ func operationWaitGet(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// Then check if the query is from an operation on another node, and, if so, forward it
-	var address string
-	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
-		operation, err := tx.GetOperationByUUID(id)
-		if err != nil {
-			return err
-		}
-
-		address = operation.NodeAddress
-		return nil
-	})
+	operation, err := operationRemote(d, id)
 	if err != nil {
 		return response.SmartError(err)
 	}
 
+	if trusted && !operationVisible(d, r, operation.Project) {
+		return response.NotFound(fmt.Errorf("Operation not found"))
+	}
+
 	cert := d.endpoints.NetworkCert()
-	client, err := cluster.Connect(address, cert, false)
+	client, err := cluster.Connect(operation.NodeAddress, cert, false)
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -473,9 +561,16 @@ func (r *forwardedOperationWebSocket) String() string {
 func operationWebsocketGet(d *Daemon, r *http.Request) response.Response {
 	id := mux.Vars(r)["id"]
 
+	// Untrusted callers are authenticated by the secret, trusted ones must be able to see the operation.
+	trusted, _, _, _ := d.Authenticate(r)
+
 	// First check if the query is for a local operation from this node
 	op, err := operations.OperationGetInternal(id)
 	if err == nil {
+		if trusted && !operationVisible(d, r, op.Project()) {
+			return response.NotFound(fmt.Errorf("Operation not found"))
+		}
+
 		return &operationWebSocket{r, op}
 	}
 
@@ -485,22 +580,17 @@ func operationWebsocketGet(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(fmt.Errorf("missing secret"))
 	}
 
-	var address string
-	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
-		operation, err := tx.GetOperationByUUID(id)
-		if err != nil {
-			return err
-		}
-
-		address = operation.NodeAddress
-		return nil
-	})
+	operation, err := operationRemote(d, id)
 	if err != nil {
 		return response.SmartError(err)
 	}
 
+	if trusted && !operationVisible(d, r, operation.Project) {
+		return response.NotFound(fmt.Errorf("Operation not found"))
+	}
+
 	cert := d.endpoints.NetworkCert()
-	client, err := cluster.Connect(address, cert, false)
+	client, err := cluster.Connect(operation.NodeAddress, cert, false)
 	if err != nil {
 		return response.SmartError(err)
 	}
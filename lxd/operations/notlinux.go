@@ -37,5 +37,5 @@ func (op *Operation) sendEvent(eventMessage interface{}) {
 		return
 	}
 
-	op.events.Send(op.project, "operation", eventMessage)
+	op.events.SendOperation(op.project, eventMessage)
 }
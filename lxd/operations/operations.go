@@ -11,6 +11,7 @@ import (
 
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/events"
+	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/shared"
@@ -103,6 +104,13 @@ type Operation struct {
 	description string
 	permission  string
 	dbOpType    db.OperationType
+	requestor   *api.EventLifecycleRequestor
+
+	// Context passed to the run hook, cancelled when the operation is cancelled or done.
+	ctx        context.Context
+	ctxCancel  context.CancelFunc
+	cancelable bool
+	chanCancel chan error
 
 	// Those functions are called at various points in the Operation lifecycle
 	onRun     func(*Operation) error
@@ -142,6 +150,7 @@ func OperationCreate(s *state.State, project string, opClass operationClass, opT
 	op.resources = opResources
 	op.chanDone = make(chan error)
 	op.state = s
+	op.ctx, op.ctxCancel = context.WithCancel(context.Background())
 
 	if s != nil {
 		op.SetEventServer(s.Events)
@@ -207,6 +216,7 @@ func (op *Operation) done() {
 	op.onCancel = nil
 	op.onConnect = nil
 	close(op.chanDone)
+	op.ctxCancel()
 	op.lock.Unlock()
 
 	time.AfterFunc(time.Second*5, func() {
@@ -246,6 +256,28 @@ func (op *Operation) Run() (chan error, error) {
 	if op.onRun != nil {
 		go func(op *Operation, chanRun chan error) {
 			err := op.onRun(op)
+
+			// The run hook returned after the operation got cancelled through its context.
+			op.lock.Lock()
+			cancelling := op.status == api.Cancelling && op.ctx.Err() != nil
+			if cancelling && err != nil {
+				op.status = api.Cancelled
+			}
+			op.lock.Unlock()
+
+			if cancelling && err == nil {
+				op.chanCancel <- fmt.Errorf("The operation completed before it could be cancelled")
+			} else if cancelling {
+				op.done()
+				chanRun <- err
+				op.chanCancel <- nil
+
+				logger.Debugf("Cancelled %s Operation: %s", op.class.String(), op.id)
+				_, md, _ := op.Render()
+				op.sendEvent(md)
+				return
+			}
+
 			if err != nil {
 				op.lock.Lock()
 				op.status = api.Failure
@@ -285,21 +317,45 @@ func (op *Operation) Run() (chan error, error) {
 // Cancel cancels a running operation. If the operation cannot be cancelled, it
 // returns an error.
 func (op *Operation) Cancel() (chan error, error) {
+	// Check and update the status at once, so that only one of concurrent callers cancels the operation.
+	op.lock.Lock()
 	if op.status != api.Running {
+		op.lock.Unlock()
 		return nil, fmt.Errorf("Only running operations can be cancelled")
 	}
 
 	if !op.mayCancel() {
+		op.lock.Unlock()
 		return nil, fmt.Errorf("This operation can't be cancelled")
 	}
 
 	chanCancel := make(chan error, 1)
 
-	op.lock.Lock()
 	oldStatus := op.status
 	op.status = api.Cancelling
 	op.lock.Unlock()
 
+	// Operations watching their context are reported as cancelled once their run hook has returned, so
+	// that any revert logic has completed by then.
+	if op.cancelable && op.onRun != nil {
+		op.lock.Lock()
+		op.chanCancel = chanCancel
+		op.lock.Unlock()
+
+		logger.Debugf("Cancelling %s Operation: %s", op.class.String(), op.id)
+		_, md, _ := op.Render()
+		op.sendEvent(md)
+
+		// Interrupt any ongoing download too.
+		if op.canceler != nil && op.canceler.Cancelable() {
+			op.canceler.Cancel()
+		}
+
+		op.ctxCancel()
+
+		return chanCancel, nil
+	}
+
 	hasOnCancel := op.onCancel != nil
 
 	if hasOnCancel {
@@ -395,7 +451,7 @@ func (op *Operation) mayCancel() bool {
 		return true
 	}
 
-	if op.onCancel != nil {
+	if op.onCancel != nil || op.cancelable {
 		return true
 	}
 
@@ -406,8 +462,40 @@ func (op *Operation) mayCancel() bool {
 	return false
 }
 
-// Render renders the operation structure.
+// Render renders the operation structure. The secrets of token operations are left out of the metadata, they're
+// only returned to the client which created the token and to the other cluster members.
 func (op *Operation) Render() (string, *api.Operation, error) {
+	url, md, err := op.RenderWithSecrets()
+	if err != nil {
+		return "", nil, err
+	}
+
+	RedactSecrets(md)
+
+	return url, md, nil
+}
+
+// RedactSecrets removes the secrets from the metadata of the given token operation, as rendered by this or
+// another cluster member.
+func RedactSecrets(md *api.Operation) {
+	if md.Class != OperationClassToken.String() {
+		return
+	}
+
+	metadata := make(map[string]interface{}, len(md.Metadata))
+	for k, v := range md.Metadata {
+		if k == "secret" || k == "token" {
+			continue
+		}
+
+		metadata[k] = v
+	}
+
+	md.Metadata = metadata
+}
+
+// RenderWithSecrets renders the operation structure, including the secrets of token operations.
+func (op *Operation) RenderWithSecrets() (string, *api.Operation, error) {
 	// Setup the resource URLs
 	resources := op.resources
 	if resources != nil {
@@ -442,36 +530,38 @@ func (op *Operation) Render() (string, *api.Operation, error) {
 		MayCancel:   op.mayCancel(),
 		Err:         op.err,
 		Location:    serverName,
+		Project:     op.project,
 	}, nil
 }
 
 // WaitFinal waits for the operation to be done. If timeout is -1, it will wait
 // indefinitely otherwise it will timeout after {timeout} seconds.
 func (op *Operation) WaitFinal(timeout int) (bool, error) {
-	// Check current state
-	if op.status.IsFinal() {
-		return true, nil
-	}
-
 	// Wait indefinitely
 	if timeout == -1 {
-		<-op.chanDone
-		return true, nil
+		return op.Wait(context.Background())
 	}
 
-	// Wait until timeout
-	if timeout > 0 {
-		timer := time.NewTimer(time.Duration(timeout) * time.Second)
-		select {
-		case <-op.chanDone:
-			return true, nil
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
 
-		case <-timer.C:
-			return false, nil
-		}
+	return op.Wait(ctx)
+}
+
+// Wait waits for the operation to be done or for the context to be done, whichever comes first. The
+// operation itself isn't affected by the context, it keeps running if the context is done first.
+func (op *Operation) Wait(ctx context.Context) (bool, error) {
+	// Check current state
+	if op.status.IsFinal() {
+		return true, nil
 	}
 
-	return false, nil
+	select {
+	case <-op.chanDone:
+		return true, nil
+	case <-ctx.Done():
+		return false, nil
+	}
 }
 
 // UpdateResources updates the resources of the operation. It returns an error
@@ -545,6 +635,21 @@ func (op *Operation) Resources() map[string][]string {
 	return op.resources
 }
 
+// Context returns the context of the operation, which is done once the operation is cancelled or done.
+func (op *Operation) Context() context.Context {
+	if op == nil {
+		return context.Background()
+	}
+
+	return op.ctx
+}
+
+// SetCancelable makes the operation cancellable through its context. The run hook is expected to return
+// once the context returned by Context() is done, after reverting any change it made.
+func (op *Operation) SetCancelable() {
+	op.cancelable = true
+}
+
 // SetCanceler sets a canceler.
 func (op *Operation) SetCanceler(canceler *cancel.Canceler) {
 	op.canceler = canceler
@@ -560,6 +665,20 @@ func (op *Operation) Project() string {
 	return op.project
 }
 
+// SetRequestor records the requestor of the operation from the request that initiated it.
+func (op *Operation) SetRequestor(r *http.Request) {
+	op.requestor = request.CreateRequestor(r)
+}
+
+// Requestor returns the requestor of the operation, if known.
+func (op *Operation) Requestor() *api.EventLifecycleRequestor {
+	if op == nil {
+		return nil
+	}
+
+	return op.requestor
+}
+
 // Status returns the operation status.
 func (op *Operation) Status() api.StatusCode {
 	return op.status
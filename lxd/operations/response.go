@@ -26,7 +26,8 @@ func (r *operationResponse) Render(w http.ResponseWriter) error {
 		return err
 	}
 
-	url, md, err := r.op.Render()
+	// The client creating a token gets its secret.
+	url, md, err := r.op.RenderWithSecrets()
 	if err != nil {
 		return err
 	}
@@ -17,7 +17,9 @@ import (
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
@@ -49,7 +51,7 @@ func profilesGet(d *Daemon, r *http.Request) response.Response {
 	recursion := util.IsRecursionRequest(r)
 
 	var result interface{}
-	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+	err := d.cluster.TransactionContext(r.Context(), func(tx *db.ClusterTx) error {
 		hasProfiles, err := tx.ProjectHasProfiles(projectName)
 		if err != nil {
 			return errors.Wrap(err, "Check project features")
@@ -82,7 +84,7 @@ func profilesGet(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
-	return response.SyncResponse(true, result)
+	return response.SyncResponseConditional(r, result)
 }
 
 func profilesPost(d *Daemon, r *http.Request) response.Response {
@@ -111,7 +113,7 @@ func profilesPost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// At this point we don't know the instance type, so just use instancetype.Any type for validation.
-	err = instance.ValidDevices(d.State(), d.cluster, instancetype.Any, deviceConfig.NewDevices(req.Devices), false)
+	err = instance.ValidDevices(d.State(), d.cluster, projectName, instancetype.Any, deviceConfig.NewDevices(req.Devices), false)
 	if err != nil {
 		return response.BadRequest(err)
 	}
@@ -147,6 +149,8 @@ func profilesPost(d *Daemon, r *http.Request) response.Response {
 			fmt.Errorf("Error inserting %s into database: %s", req.Name, err))
 	}
 
+	d.State().Events.SendLifecycle(projectName, lifecycle.ProfileCreated.Event(req.Name, request.CreateRequestor(r), nil))
+
 	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/profiles/%s", version.APIVersion, req.Name))
 }
 
@@ -180,7 +184,7 @@ func profileGet(d *Daemon, r *http.Request) response.Response {
 	}
 
 	etag := []interface{}{resp.Config, resp.Description, resp.Devices}
-	return response.SyncResponseETag(true, resp, etag)
+	return response.SyncResponseETagConditional(r, resp, etag)
 }
 
 func profilePut(d *Daemon, r *http.Request) response.Response {
@@ -258,6 +262,8 @@ func profilePut(d *Daemon, r *http.Request) response.Response {
 		if err != nil {
 			return response.SmartError(err)
 		}
+
+		d.State().Events.SendLifecycle(projectName, lifecycle.ProfileUpdated.Event(name, request.CreateRequestor(r), nil))
 	}
 
 	return response.SmartError(err)
@@ -352,7 +358,14 @@ func profilePatch(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
-	return response.SmartError(doProfileUpdate(d, projectName, name, id, profile, req))
+	err = doProfileUpdate(d, projectName, name, id, profile, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.ProfileUpdated.Event(name, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
 }
 
 // The handler for the post operation.
@@ -404,6 +417,10 @@ func profilePost(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	d.State().Events.SendLifecycle(projectName, lifecycle.ProfileRenamed.Event(req.Name, request.CreateRequestor(r), map[string]interface{}{
+		"old_name": name,
+	}))
+
 	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/profiles/%s", version.APIVersion, req.Name))
 }
 
@@ -440,5 +457,7 @@ func profileDelete(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	d.State().Events.SendLifecycle(projectName, lifecycle.ProfileDeleted.Event(name, request.CreateRequestor(r), nil))
+
 	return response.EmptySyncResponse
 }
@@ -2,6 +2,7 @@ package project
 
 import (
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -49,8 +50,10 @@ func AllowInstanceCreation(tx *db.ClusterTx, projectName string, req api.Instanc
 	// Add the instance being created.
 	info.Instances = append(info.Instances, db.Instance{
 		Name:     req.Name,
+		Type:     instanceType,
 		Profiles: req.Profiles,
 		Config:   req.Config,
+		Devices:  req.Devices,
 		Project:  projectName,
 	})
 
@@ -69,6 +72,56 @@ func AllowInstanceCreation(tx *db.ClusterTx, projectName string, req api.Instanc
 	return nil
 }
 
+// AllowNetworkCreation returns an error if any project-specific limit is
+// violated when creating a new network.
+func AllowNetworkCreation(tx *db.ClusterTx, projectName string) error {
+	project, err := tx.GetProject(projectName)
+	if err != nil {
+		return errors.Wrap(err, "Fetch project database object")
+	}
+
+	value := project.Config["limits.networks"]
+	if value == "" {
+		return nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return fmt.Errorf("Unexpected 'limits.networks' value: '%s'", value)
+	}
+
+	networks, err := tx.GetNetworkNames(projectName)
+	if err != nil {
+		return errors.Wrap(err, "Fetch networks from database")
+	}
+
+	if len(networks) >= limit {
+		return fmt.Errorf("Reached maximum number of networks (%d) in project %s", limit, projectName)
+	}
+
+	return nil
+}
+
+// AllowNetworkUplink returns an error if the project is restricted and the
+// given uplink network is not in its "restricted.networks.uplinks" list.
+func AllowNetworkUplink(tx *db.ClusterTx, projectName string, uplink string) error {
+	project, err := tx.GetProject(projectName)
+	if err != nil {
+		return errors.Wrap(err, "Fetch project database object")
+	}
+
+	if !shared.IsTrue(project.Config["restricted"]) {
+		return nil
+	}
+
+	uplinks := restrictionList(project.Config["restricted.networks.uplinks"])
+	if uplinks != nil && !shared.StringInSlice(uplink, uplinks) {
+		return fmt.Errorf("Uplink network %q is not allowed in project %q", uplink, projectName)
+	}
+
+	return nil
+}
+
 // Check that we have not reached the maximum number of instances for
 // this type.
 func checkInstanceCountLimit(project *api.Project, instanceCount int, instanceType instancetype.Type) error {
@@ -141,7 +194,7 @@ func checkRestrictionsOnVolatileConfig(project *api.Project, instanceType instan
 
 // AllowVolumeCreation returns an error if any project-specific limit or
 // restriction is violated when creating a new custom volume in a project.
-func AllowVolumeCreation(tx *db.ClusterTx, projectName string, req api.StorageVolumesPost) error {
+func AllowVolumeCreation(tx *db.ClusterTx, projectName string, poolName string, req api.StorageVolumesPost) error {
 	info, err := fetchProject(tx, projectName, true)
 	if err != nil {
 		return err
@@ -151,6 +204,13 @@ func AllowVolumeCreation(tx *db.ClusterTx, projectName string, req api.StorageVo
 		return nil
 	}
 
+	if shared.IsTrue(info.Project.Config["restricted"]) {
+		err = checkRestrictedStoragePool(info.Project, poolName)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid volume %q of project %q", req.Name, projectName)
+		}
+	}
+
 	// If "limits.disk" is not set, there's nothing to do.
 	if info.Project.Config["limits.disk"] == "" {
 		return nil
@@ -272,9 +332,10 @@ func checkAggregateLimits(info *projectInfo, aggregateKeys []string) error {
 		}
 
 		if totals[key] > max {
+			printer := aggregateLimitConfigValuePrinters[key]
 			return fmt.Errorf(
-				"Reached maximum aggregate value %s for %q in project %s",
-				info.Project.Config[key], key, info.Project.Name)
+				"Reached maximum aggregate value %s for %q in project %s (requested total is %s)",
+				info.Project.Config[key], key, info.Project.Name, printer(totals[key]))
 		}
 	}
 	return nil
@@ -387,10 +448,23 @@ func checkRestrictions(project *api.Project, instances []db.Instance, profiles [
 						return fmt.Errorf("Only managed network devices are allowed")
 					}
 				}
+
+				networks := restrictionList(project.Config["restricted.networks.access"])
+				if device["network"] != "" && networks != nil && !shared.StringInSlice(device["network"], networks) {
+					return fmt.Errorf("Network %q is not allowed", device["network"])
+				}
+
 				return nil
 			}
 		case "restricted.devices.disk":
 			devicesChecks["disk"] = func(device map[string]string) error {
+				if device["pool"] != "" {
+					err := checkRestrictedStoragePool(project, device["pool"])
+					if err != nil {
+						return err
+					}
+				}
+
 				// The root device is always allowed.
 				if device["path"] == "/" && device["pool"] != "" {
 					return nil
@@ -403,6 +477,11 @@ func checkRestrictions(project *api.Project, instances []db.Instance, profiles [
 					if device["pool"] == "" {
 						return fmt.Errorf("Attaching disks not backed by a pool is forbidden")
 					}
+				case "allow":
+					paths := restrictionList(project.Config["restricted.devices.disk.paths"])
+					if device["pool"] == "" && device["source"] != "" && paths != nil && !isPathUnder(device["source"], paths) {
+						return fmt.Errorf("Disk source path %q is not allowed", device["source"])
+					}
 				}
 				return nil
 			}
@@ -496,6 +575,49 @@ func checkRestrictions(project *api.Project, instances []db.Instance, profiles [
 	return nil
 }
 
+// Check that the given storage pool is in the project's
+// "restricted.storage.pools" list, if any.
+func checkRestrictedStoragePool(project *api.Project, pool string) error {
+	pools := restrictionList(project.Config["restricted.storage.pools"])
+	if pools != nil && !shared.StringInSlice(pool, pools) {
+		return fmt.Errorf("Storage pool %q is not allowed", pool)
+	}
+
+	return nil
+}
+
+// Return the items of a comma-separated restriction list, or nil if the list
+// is not set, meaning that there is no restriction.
+func restrictionList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// Return true if the given path is one of the given prefixes or is under one
+// of them.
+func isPathUnder(path string, prefixes []string) bool {
+	path = filepath.Clean(path)
+	for _, prefix := range prefixes {
+		prefix = filepath.Clean(prefix)
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
 var allAggregateLimits = []string{
 	"limits.cpu",
 	"limits.disk",
@@ -517,6 +639,10 @@ var AllRestrictions = []string{
 	"restricted.devices.usb",
 	"restricted.devices.nic",
 	"restricted.devices.disk",
+	"restricted.devices.disk.paths",
+	"restricted.networks.access",
+	"restricted.networks.uplinks",
+	"restricted.storage.pools",
 }
 
 var defaultRestrictionsValues = map[string]string{
@@ -708,6 +834,11 @@ func AllowProjectUpdate(tx *db.ClusterTx, projectName string, config map[string]
 			if err != nil {
 				return errors.Wrapf(err, "Can't change %q in project %q", key, projectName)
 			}
+		case "limits.networks":
+			err := validateNetworkCountLimit(tx, config[key], projectName)
+			if err != nil {
+				return errors.Wrapf(err, "Can't change %q in project %q", key, projectName)
+			}
 		case "limits.processes":
 			fallthrough
 		case "limits.cpu":
@@ -771,6 +902,31 @@ func validateInstanceCountLimit(instances []db.Instance, key, value, project str
 	return nil
 }
 
+// Check that limits.networks is equal or above the current count.
+func validateNetworkCountLimit(tx *db.ClusterTx, value, project string) error {
+	if value == "" {
+		return nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+
+	networks, err := tx.GetNetworkNames(project)
+	if err != nil {
+		return err
+	}
+
+	if limit < len(networks) {
+		return fmt.Errorf(
+			"'limits.networks' is too low: there currently are %d networks in project %s",
+			len(networks), project)
+	}
+
+	return nil
+}
+
 var countConfigInstanceType = map[string]api.InstanceType{
 	"limits.containers":       api.InstanceTypeContainer,
 	"limits.virtual-machines": api.InstanceTypeVM,
@@ -786,7 +942,7 @@ func validateAggregateLimit(totals map[string]int64, key, value string) error {
 	parser := aggregateLimitConfigValueParsers[key]
 	limit, err := parser(value)
 	if err != nil {
-		errors.Wrapf(err, "Invalid value '%s' for limit %s", value, key)
+		return errors.Wrapf(err, "Invalid value '%s' for limit %s", value, key)
 	}
 
 	total := totals[key]
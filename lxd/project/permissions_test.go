@@ -124,3 +124,62 @@ func TestAllowInstanceCreation_DifferentType(t *testing.T) {
 	err = project.AllowInstanceCreation(tx, "p1", req)
 	assert.NoError(t, err)
 }
+
+// If the number of networks has reached the limit, the check fails.
+func TestAllowNetworkCreation_Above(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	_, err := tx.CreateProject(api.ProjectsPost{
+		Name: "p1",
+		ProjectPut: api.ProjectPut{
+			Config: map[string]string{
+				"limits.networks": "1",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	err = project.AllowNetworkCreation(tx, "p1")
+	assert.NoError(t, err)
+
+	_, err = tx.Tx().Exec("INSERT INTO networks (name, description) VALUES ('lxdbr0', '')")
+	require.NoError(t, err)
+
+	err = project.AllowNetworkCreation(tx, "p1")
+	assert.EqualError(t, err, "Reached maximum number of networks (1) in project p1")
+}
+
+// If the project is restricted to some networks, instances can't use others.
+func TestAllowInstanceCreation_RestrictedNetworks(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	_, err := tx.CreateProject(api.ProjectsPost{
+		Name: "p1",
+		ProjectPut: api.ProjectPut{
+			Config: map[string]string{
+				"restricted":                 "true",
+				"restricted.networks.access": "lxdbr0, lxdbr1",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	req := api.InstancesPost{
+		Name: "c1",
+		Type: api.InstanceTypeContainer,
+		InstancePut: api.InstancePut{
+			Devices: map[string]map[string]string{
+				"eth0": {"type": "nic", "network": "lxdbr1"},
+			},
+		},
+	}
+
+	err = project.AllowInstanceCreation(tx, "p1", req)
+	assert.NoError(t, err)
+
+	req.Devices["eth0"]["network"] = "lxdbr2"
+	err = project.AllowInstanceCreation(tx, "p1", req)
+	assert.EqualError(t, err, `Invalid device "eth0" on instance "c1" of project "p1": Network "lxdbr2" is not allowed`)
+}
@@ -98,3 +98,40 @@ func StorageVolumeProject(c *db.Cluster, projectName string, volumeType int) (st
 
 	return Default, nil
 }
+
+// NetworkProject returns the project name to use for networks based on the requested project.
+// If the project specified has the "features.networks" flag enabled then the project name is returned,
+// otherwise the default project name is returned.
+func NetworkProject(c *db.Cluster, projectName string) (string, error) {
+	if projectName == Default {
+		return Default, nil
+	}
+
+	var project *api.Project
+	var err error
+
+	err = c.Transaction(func(tx *db.ClusterTx) error {
+		project, err = tx.GetProject(projectName)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to load project %q", projectName)
+	}
+
+	return NetworkProjectFromRecord(project), nil
+}
+
+// NetworkProjectFromRecord returns the project name to use for networks based on the supplied project.
+// Networks only live in a project other than the default one if it has the "features.networks" flag enabled.
+func NetworkProjectFromRecord(p *api.Project) string {
+	if p.Name != Default && shared.IsTrue(p.Config["features.networks"]) {
+		return p.Name
+	}
+
+	return Default
+}
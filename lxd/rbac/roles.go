@@ -0,0 +1,40 @@
+package rbac
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// Built-in roles which can be given to a trusted certificate on a project, as an alternative to an external
+// RBAC service.
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// Roles lists the built-in roles.
+var Roles = []string{RoleViewer, RoleOperator, RoleAdmin}
+
+// rolePermissions maps the built-in roles to the permissions they grant on their project.
+var rolePermissions = map[string][]string{
+	RoleViewer:   {"view"},
+	RoleOperator: {"view", "operate-containers"},
+	RoleAdmin:    {"view", "operate-containers", "manage-containers", "manage-images", "manage-networks", "manage-profiles", "manage-storage-volumes"},
+}
+
+// RoleHasPermission returns whether the given built-in role grants the given permission.
+func RoleHasPermission(role string, permission string) bool {
+	return shared.StringInSlice(permission, rolePermissions[role])
+}
+
+// ValidateRole checks whether the given role is a built-in role.
+func ValidateRole(role string) error {
+	if !shared.StringInSlice(role, Roles) {
+		return fmt.Errorf("Invalid role %q (not one of %s)", role, strings.Join(Roles, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleHasPermission(t *testing.T) {
+	assert.True(t, RoleHasPermission(RoleViewer, "view"))
+	assert.False(t, RoleHasPermission(RoleViewer, "operate-containers"))
+	assert.True(t, RoleHasPermission(RoleOperator, "operate-containers"))
+	assert.False(t, RoleHasPermission(RoleOperator, "manage-containers"))
+	assert.True(t, RoleHasPermission(RoleAdmin, "manage-containers"))
+	assert.False(t, RoleHasPermission(RoleAdmin, "manage-projects"))
+	assert.False(t, RoleHasPermission("", "view"))
+}
+
+func TestValidateRole(t *testing.T) {
+	assert.NoError(t, ValidateRole(RoleOperator))
+	assert.Error(t, ValidateRole("root"))
+}
@@ -0,0 +1,31 @@
+package request
+
+import (
+	"net/http"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// CreateRequestor extracts the lifecycle event requestor data from an http.Request context.
+func CreateRequestor(r *http.Request) *api.EventLifecycleRequestor {
+	if r == nil {
+		return nil
+	}
+
+	requestor := &api.EventLifecycleRequestor{
+		Address: r.RemoteAddr,
+	}
+
+	// Those values are set by the daemon once the request has been authenticated.
+	username, ok := r.Context().Value("username").(string)
+	if ok {
+		requestor.Username = username
+	}
+
+	protocol, ok := r.Context().Value("protocol").(string)
+	if ok {
+		requestor.Protocol = protocol
+	}
+
+	return requestor
+}
@@ -2,12 +2,19 @@ package main
 
 import (
 	"net/http"
+	"sync"
 
 	"github.com/gorilla/mux"
 
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/resources"
 	"github.com/lxc/lxd/lxd/response"
 	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 )
 
@@ -42,7 +49,8 @@ func api10ResourcesGet(d *Daemon, r *http.Request) response.Response {
 }
 
 // /1.0/storage-pools/{name}/resources
-// Get resources for a specific storage pool
+// Get resources for a specific storage pool. Unless a target is specified, the usage of all the cluster members
+// is gathered.
 func storagePoolResourcesGet(d *Daemon, r *http.Request) response.Response {
 	// If a target was specified, forward the request to the relevant node.
 	resp := forwardedResponseIfTargetIsRemote(d, r)
@@ -52,17 +60,138 @@ func storagePoolResourcesGet(d *Daemon, r *http.Request) response.Response {
 
 	// Get the existing storage pool
 	poolName := mux.Vars(r)["name"]
-	var res *api.ResourcesStoragePool
+	projectName := projectParam(r)
 
 	pool, err := storagePools.GetPoolByName(d.State(), poolName)
 	if err != nil {
-		return response.InternalError(err)
+		return response.SmartError(err)
 	}
 
-	res, err = pool.GetResources()
+	res, err := pool.GetResources()
 	if err != nil {
 		return response.InternalError(err)
 	}
 
+	res.Volumes, err = storagePoolResourcesVolumes(d, pool, projectName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Collect usage from other servers.
+	if !isClusterNotification(r) && queryParam(r, "target") == "" {
+		notifier, err := cluster.NewNotifier(d.State(), d.endpoints.NetworkCert(), cluster.NotifyAlive)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		// Remote pools are backed by the same storage on all members, so only their volumes are gathered.
+		remote := pool.Driver().Info().Remote
+
+		var mu sync.Mutex
+		err = notifier(func(client lxd.InstanceServer) error {
+			memberRes, err := client.UseProject(projectName).GetStoragePoolResources(poolName)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if !remote {
+				res.Space.Used += memberRes.Space.Used
+				res.Space.Total += memberRes.Space.Total
+				res.Inodes.Used += memberRes.Inodes.Used
+				res.Inodes.Total += memberRes.Inodes.Total
+				res.Volumes = append(res.Volumes, memberRes.Volumes...)
+
+				return nil
+			}
+
+			for _, memberVol := range memberRes.Volumes {
+				found := false
+				for _, vol := range res.Volumes {
+					if vol.Project == memberVol.Project && vol.Type == memberVol.Type && vol.Name == memberVol.Name {
+						found = true
+						break
+					}
+				}
+
+				if !found {
+					res.Volumes = append(res.Volumes, memberVol)
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
 	return response.SyncResponse(true, res)
 }
+
+// storagePoolResourcesVolumes returns the space used by the instance and custom volumes of the project on the
+// storage pool of this member. Volumes whose usage can't be determined by the storage driver are skipped.
+func storagePoolResourcesVolumes(d *Daemon, pool storagePools.Pool, projectName string) ([]api.ResourcesStoragePoolVolume, error) {
+	var serverName string
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		serverName, err = tx.GetLocalNodeName()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	customProjectName, err := project.StorageVolumeProject(d.cluster, projectName, db.StoragePoolVolumeTypeCustom)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := []api.ResourcesStoragePoolVolume{}
+
+	volumeTypes := map[int]string{
+		db.StoragePoolVolumeTypeContainer: projectName,
+		db.StoragePoolVolumeTypeVM:        projectName,
+		db.StoragePoolVolumeTypeCustom:    customProjectName,
+	}
+
+	for volumeType, volumeProjectName := range volumeTypes {
+		dbVolumes, err := d.cluster.GetLocalStoragePoolVolumes(volumeProjectName, pool.ID(), []int{volumeType})
+		if err != nil && err != db.ErrNoSuchObject {
+			return nil, err
+		}
+
+		for _, dbVol := range dbVolumes {
+			if shared.IsSnapshot(dbVol.Name) {
+				continue
+			}
+
+			var used int64
+			if volumeType == db.StoragePoolVolumeTypeCustom {
+				used, err = pool.GetCustomVolumeUsage(volumeProjectName, dbVol.Name)
+			} else {
+				var inst instance.Instance
+				inst, err = instance.LoadByProjectAndName(d.State(), volumeProjectName, dbVol.Name)
+				if err == nil {
+					used, err = pool.GetInstanceUsage(inst)
+				}
+			}
+
+			if err != nil || used < 0 {
+				continue
+			}
+
+			volumes = append(volumes, api.ResourcesStoragePoolVolume{
+				Name:     dbVol.Name,
+				Type:     dbVol.Type,
+				Project:  volumeProjectName,
+				Location: serverName,
+				Used:     uint64(used),
+			})
+		}
+	}
+
+	return volumes, nil
+}
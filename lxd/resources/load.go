@@ -0,0 +1,57 @@
+package resources
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+var procLoadavg = "/proc/loadavg"
+
+// GetLoad returns a filled api.ResourcesLoad struct ready for use by LXD
+func GetLoad() (*api.ResourcesLoad, error) {
+	content, err := ioutil.ReadFile(procLoadavg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read %q", procLoadavg)
+	}
+
+	return parseLoadavg(string(content))
+}
+
+// parseLoadavg parses the content of /proc/loadavg.
+func parseLoadavg(content string) (*api.ResourcesLoad, error) {
+	fields := strings.Fields(content)
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("Unexpected load average format %q", content)
+	}
+
+	load := api.ResourcesLoad{}
+	for i, value := range []*float64{&load.Average1, &load.Average5, &load.Average15} {
+		n, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse load average %q", fields[i])
+		}
+
+		*value = n
+	}
+
+	// The fourth field is "<runnable>/<total>" scheduling entities.
+	processes := strings.SplitN(fields[3], "/", 2)
+	if len(processes) != 2 {
+		return nil, fmt.Errorf("Unexpected processes count format %q", fields[3])
+	}
+
+	n, err := strconv.ParseUint(processes[1], 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse processes count %q", processes[1])
+	}
+
+	load.Processes = n
+
+	return &load, nil
+}
@@ -56,6 +56,12 @@ func GetResources() (*api.Resources, error) {
 		return nil, errors.Wrap(err, "Failed to retrieve system information")
 	}
 
+	// Get load information
+	load, err := GetLoad()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve load information")
+	}
+
 	// Build the final struct
 	resources := api.Resources{
 		CPU:     *cpu,
@@ -66,6 +72,7 @@ func GetResources() (*api.Resources, error) {
 		USB:     *usb,
 		PCI:     *pci,
 		System:  *system,
+		Load:    *load,
 	}
 
 	return &resources, nil
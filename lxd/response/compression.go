@@ -0,0 +1,140 @@
+package response
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressionEncoding returns the preferred content encoding supported by both the client (as advertised
+// through its Accept-Encoding header) and the server, or an empty string if none.
+func CompressionEncoding(r *http.Request) string {
+	accepted := map[string]bool{}
+	for _, entry := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		fields := strings.Split(entry, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" {
+			continue
+		}
+
+		// Honor explicit refusals (q=0).
+		refused := false
+		for _, param := range fields[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) != 2 || kv[0] != "q" {
+				continue
+			}
+
+			q, err := strconv.ParseFloat(kv[1], 64)
+			if err == nil && q == 0 {
+				refused = true
+			}
+		}
+
+		accepted[name] = !refused
+	}
+
+	for _, encoding := range []string{"zstd", "gzip"} {
+		if accepted[encoding] {
+			return encoding
+		}
+	}
+
+	return ""
+}
+
+// compressedWriter compresses JSON responses on the fly. Any other content (file transfers, websocket
+// upgrades, ...) is passed through untouched.
+type compressedWriter struct {
+	http.ResponseWriter
+
+	encoding    string
+	writer      io.WriteCloser
+	wroteHeader bool
+}
+
+// NewCompressedWriter wraps the given ResponseWriter so that JSON responses get compressed using the given
+// content encoding. The returned function must be called once the response has been rendered.
+func NewCompressedWriter(w http.ResponseWriter, encoding string) (http.ResponseWriter, func() error) {
+	cw := &compressedWriter{ResponseWriter: w, encoding: encoding}
+	return cw, cw.close
+}
+
+func (w *compressedWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+
+	header := w.Header()
+	if code != http.StatusNoContent && code != http.StatusNotModified && header.Get("Content-Encoding") == "" && strings.HasPrefix(header.Get("Content-Type"), "application/json") {
+		switch w.encoding {
+		case "gzip":
+			w.writer = gzip.NewWriter(w.ResponseWriter)
+		case "zstd":
+			encoder, err := zstd.NewWriter(w.ResponseWriter)
+			if err == nil {
+				w.writer = encoder
+			}
+		}
+
+		if w.writer != nil {
+			header.Set("Content-Encoding", w.encoding)
+			header.Add("Vary", "Accept-Encoding")
+			header.Del("Content-Length")
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *compressedWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.writer != nil {
+		return w.writer.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends any buffered compressed data to the client.
+func (w *compressedWriter) Flush() {
+	flusher, ok := w.writer.(interface{ Flush() error })
+	if ok {
+		flusher.Flush()
+	}
+
+	httpFlusher, ok := w.ResponseWriter.(http.Flusher)
+	if ok {
+		httpFlusher.Flush()
+	}
+}
+
+// Hijack allows websocket upgrades through the compressed writer.
+func (w *compressedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("Response writer doesn't support hijacking")
+	}
+
+	return hijacker.Hijack()
+}
+
+func (w *compressedWriter) close() error {
+	if w.writer == nil {
+		return nil
+	}
+
+	return w.writer.Close()
+}
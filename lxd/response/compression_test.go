@@ -0,0 +1,65 @@
+package response_test
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressionEncoding(t *testing.T) {
+	cases := map[string]string{
+		"":                      "",
+		"identity":              "",
+		"gzip":                  "gzip",
+		"deflate, gzip":         "gzip",
+		"gzip, zstd":            "zstd",
+		"zstd;q=0, gzip;q=0.5":  "gzip",
+		"gzip;q=0.0, br":        "",
+		"GZIP":                  "gzip",
+		"zstd, gzip;q=0.000, *": "zstd",
+	}
+
+	for in, out := range cases {
+		t.Run(in, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/1.0", nil)
+			r.Header.Set("Accept-Encoding", in)
+			assert.Equal(t, out, response.CompressionEncoding(r))
+		})
+	}
+}
+
+// JSON responses get compressed, anything else is passed through untouched.
+func TestCompressedWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w, finish := response.NewCompressedWriter(rec, "gzip")
+	w.Header().Set("Content-Type", "application/json")
+
+	err := response.SyncResponse(true, []string{"/1.0"}).Render(w)
+	require.NoError(t, err)
+	require.NoError(t, finish())
+
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+
+	reader, err := gzip.NewReader(rec.Body)
+	require.NoError(t, err)
+
+	body, err := ioutil.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"metadata":["/1.0"]`)
+
+	rec = httptest.NewRecorder()
+	w, finish = response.NewCompressedWriter(rec, "gzip")
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("raw"))
+	require.NoError(t, finish())
+
+	assert.Equal(t, "", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "raw", rec.Body.String())
+}
@@ -0,0 +1,97 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/util"
+)
+
+// Not modified response
+type notModifiedResponse struct {
+	etag    string
+	headers map[string]string
+}
+
+func (r *notModifiedResponse) Render(w http.ResponseWriter) error {
+	for h, v := range r.headers {
+		w.Header().Set(h, v)
+	}
+
+	w.Header().Set("ETag", fmt.Sprintf("\"%s\"", r.etag))
+	w.WriteHeader(http.StatusNotModified)
+
+	return nil
+}
+
+func (r *notModifiedResponse) String() string {
+	return "not modified"
+}
+
+// etagNoneMatch returns whether the If-None-Match header of the request holds the given etag (or "*").
+func etagNoneMatch(r *http.Request, etag string) bool {
+	if r == nil || (r.Method != "GET" && r.Method != "HEAD") {
+		return false
+	}
+
+	for _, match := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		match = strings.TrimSpace(match)
+		if match == "*" {
+			return true
+		}
+
+		// Weak comparison is used, as done for conditional GET requests.
+		match = strings.Trim(strings.TrimPrefix(match, "W/"), "\"")
+		if match != "" && match == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// conditionalResponse returns a 304 Not Modified response if the client already holds the given etag, and a
+// syncResponse with the ETag header set otherwise.
+func conditionalResponse(r *http.Request, metadata interface{}, etag string, headers map[string]string) Response {
+	if headers == nil {
+		headers = map[string]string{}
+	}
+
+	if etagNoneMatch(r, etag) {
+		return &notModifiedResponse{etag: etag, headers: headers}
+	}
+
+	headers["ETag"] = fmt.Sprintf("\"%s\"", etag)
+
+	return &syncResponse{success: true, metadata: metadata, headers: headers}
+}
+
+// SyncResponseConditional returns a new syncResponse with an etag computed from the whole metadata, or a 304
+// Not Modified response if the If-None-Match header of the request holds that etag.
+func SyncResponseConditional(r *http.Request, metadata interface{}) Response {
+	hash, err := util.EtagHash(metadata)
+	if err != nil {
+		return SyncResponse(true, metadata)
+	}
+
+	return conditionalResponse(r, metadata, hash, nil)
+}
+
+// SyncResponseETagConditional is like SyncResponseETag, except that the etag also covers the whole metadata so
+// that any change to the object is reflected, and that a 304 Not Modified response is returned if the
+// If-None-Match header of the request holds that etag. The etag data remains what If-Match is checked against
+// when updating the object (see util.EtagCheck).
+func SyncResponseETagConditional(r *http.Request, metadata interface{}, etag interface{}) Response {
+	etagHash, err := util.EtagHash(etag)
+	if err != nil {
+		return SyncResponse(true, metadata)
+	}
+
+	metadataHash, err := util.EtagHash(metadata)
+	if err != nil {
+		return SyncResponseETag(true, metadata, etag)
+	}
+
+	return conditionalResponse(r, metadata, fmt.Sprintf("%s.%s", etagHash, metadataHash), nil)
+}
@@ -0,0 +1,80 @@
+package response_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+)
+
+func TestSyncResponseETagConditional(t *testing.T) {
+	object := map[string]string{"name": "foo", "status": "Running"}
+	etag := []interface{}{"foo"}
+
+	rec := httptest.NewRecorder()
+	err := response.SyncResponseETagConditional(httptest.NewRequest("GET", "/1.0/instances/foo", nil), object, etag).Render(rec)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	header := rec.Header().Get("ETag")
+	require.NotEmpty(t, header)
+
+	// The etag is still accepted by If-Match checks.
+	req := httptest.NewRequest("PUT", "/1.0/instances/foo", nil)
+	req.Header.Set("If-Match", header)
+	assert.NoError(t, util.EtagCheck(req, etag))
+
+	// Unchanged objects aren't transferred again.
+	req = httptest.NewRequest("GET", "/1.0/instances/foo", nil)
+	req.Header.Set("If-None-Match", header)
+	rec = httptest.NewRecorder()
+	err = response.SyncResponseETagConditional(req, object, etag).Render(rec)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, header, rec.Header().Get("ETag"))
+	assert.Empty(t, rec.Body.Bytes())
+
+	// Changes outside of the etag data are reflected.
+	object["status"] = "Stopped"
+	rec = httptest.NewRecorder()
+	err = response.SyncResponseETagConditional(req, object, etag).Render(rec)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, header, rec.Header().Get("ETag"))
+}
+
+func TestSyncResponsePaginated_NotModified(t *testing.T) {
+	items := []string{"a", "b", "c"}
+
+	rec := httptest.NewRecorder()
+	err := response.SyncResponsePaginated(httptest.NewRequest("GET", "/1.0/networks?limit=2", nil), items).Render(rec)
+	require.NoError(t, err)
+
+	header := rec.Header().Get("ETag")
+	require.NotEmpty(t, header)
+
+	req := httptest.NewRequest("GET", "/1.0/networks?limit=2", nil)
+	req.Header.Set("If-None-Match", "W/"+header)
+	rec = httptest.NewRecorder()
+	err = response.SyncResponsePaginated(req, items).Render(rec)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, "3", rec.Header().Get("X-LXD-total"))
+
+	// A different window has a different etag.
+	req = httptest.NewRequest("GET", "/1.0/networks?offset=2", nil)
+	req.Header.Set("If-None-Match", header)
+	rec = httptest.NewRecorder()
+	err = response.SyncResponsePaginated(req, items).Render(rec)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,90 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/lxc/lxd/lxd/util"
+)
+
+// Pagination represents the window of a collection requested by a client through the "limit" and
+// "offset" query parameters.
+type Pagination struct {
+	Limit  int
+	Offset int
+}
+
+// PaginationFromRequest parses the "limit" and "offset" query parameters of the request.
+// A zero limit means no limit.
+func PaginationFromRequest(r *http.Request) (Pagination, error) {
+	p := Pagination{}
+
+	for key, dest := range map[string]*int{"limit": &p.Limit, "offset": &p.Offset} {
+		value := r.FormValue(key)
+		if value == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return Pagination{}, fmt.Errorf("Invalid %q value %q", key, value)
+		}
+
+		*dest = n
+	}
+
+	return p, nil
+}
+
+// Enabled returns true if the client requested a window of the collection.
+func (p Pagination) Enabled() bool {
+	return p.Limit > 0 || p.Offset > 0
+}
+
+// Bounds returns the start and end indexes of the window within a collection of the given length.
+func (p Pagination) Bounds(length int) (int, int) {
+	start := p.Offset
+	if start > length {
+		start = length
+	}
+
+	end := length
+	if p.Limit > 0 && start+p.Limit < length {
+		end = start + p.Limit
+	}
+
+	return start, end
+}
+
+// SyncResponsePaginated returns a new syncResponse holding the window of the metadata slice requested
+// through the "limit" and "offset" query parameters, along with a header indicating the total size of
+// the collection. Metadata which isn't a slice is returned untouched.
+// The window is returned with an etag, or as a 304 Not Modified response if the If-None-Match header of the
+// request holds that etag.
+func SyncResponsePaginated(r *http.Request, metadata interface{}) Response {
+	p, err := PaginationFromRequest(r)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	value := reflect.ValueOf(metadata)
+	if value.Kind() != reflect.Slice {
+		return SyncResponse(true, metadata)
+	}
+
+	headers := map[string]string{"X-LXD-total": strconv.Itoa(value.Len())}
+
+	if p.Enabled() {
+		start, end := p.Bounds(value.Len())
+		metadata = value.Slice(start, end).Interface()
+	}
+
+	etag, err := util.EtagHash([]interface{}{value.Len(), metadata})
+	if err != nil {
+		return SyncResponseHeaders(true, metadata, headers)
+	}
+
+	return conditionalResponse(r, metadata, etag, headers)
+}
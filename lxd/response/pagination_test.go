@@ -0,0 +1,52 @@
+package response_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncResponsePaginated(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+
+	cases := map[string][]string{
+		"/1.0/networks":                   {"a", "b", "c", "d", "e"},
+		"/1.0/networks?limit=2":           {"a", "b"},
+		"/1.0/networks?offset=3":          {"d", "e"},
+		"/1.0/networks?offset=1&limit=3":  {"b", "c", "d"},
+		"/1.0/networks?offset=4&limit=10": {"e"},
+		"/1.0/networks?offset=10":         {},
+	}
+
+	for url, expected := range cases {
+		t.Run(url, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			err := response.SyncResponsePaginated(httptest.NewRequest("GET", url, nil), items).Render(rec)
+			require.NoError(t, err)
+
+			assert.Equal(t, "5", rec.Header().Get("X-LXD-total"))
+
+			resp := api.Response{}
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+
+			result := []string{}
+			require.NoError(t, resp.MetadataAsStruct(&result))
+			assert.Equal(t, expected, result)
+		})
+	}
+}
+
+func TestSyncResponsePaginated_Invalid(t *testing.T) {
+	for _, url := range []string{"/1.0/networks?limit=-1", "/1.0/networks?offset=foo"} {
+		rec := httptest.NewRecorder()
+		err := response.SyncResponsePaginated(httptest.NewRequest("GET", url, nil), []string{}).Render(rec)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	}
+}
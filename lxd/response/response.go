@@ -117,6 +117,24 @@ func (r *syncResponse) String() string {
 	return "failure"
 }
 
+// Manual response
+type manualResponse struct {
+	hook func(w http.ResponseWriter) error
+}
+
+// ManualResponse creates a new manual response responder, leaving the rendering to the given hook.
+func ManualResponse(hook func(w http.ResponseWriter) error) Response {
+	return &manualResponse{hook: hook}
+}
+
+func (r *manualResponse) Render(w http.ResponseWriter) error {
+	return r.hook(w)
+}
+
+func (r *manualResponse) String() string {
+	return "unknown"
+}
+
 // Error response
 type errorResponse struct {
 	code int
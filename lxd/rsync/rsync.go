@@ -1,6 +1,7 @@
 package rsync
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -23,6 +24,11 @@ var Debug bool
 
 // LocalCopy copies a directory using rsync (with the --devices option).
 func LocalCopy(source string, dest string, bwlimit string, xattrs bool, rsyncArgs ...string) (string, error) {
+	return LocalCopyContext(context.Background(), source, dest, bwlimit, xattrs, rsyncArgs...)
+}
+
+// LocalCopyContext is like LocalCopy but stops the copy if the context is done before it completes.
+func LocalCopyContext(ctx context.Context, source string, dest string, bwlimit string, xattrs bool, rsyncArgs ...string) (string, error) {
 	err := os.MkdirAll(dest, 0755)
 	if err != nil {
 		return "", err
@@ -67,7 +73,7 @@ func LocalCopy(source string, dest string, bwlimit string, xattrs bool, rsyncArg
 		shared.AddSlash(source),
 		dest)
 
-	msg, err := shared.RunCommand("rsync", args...)
+	msg, err := shared.RunCommandContext(ctx, "rsync", args...)
 	if err != nil {
 		runError, ok := err.(shared.RunError)
 		if ok {
@@ -0,0 +1,218 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// Placement policies.
+const (
+	PolicyBalanced       = "balanced"
+	PolicyLeastInstances = "least-instances"
+	PolicyScriptlet      = "scriptlet"
+)
+
+// Policies lists the supported placement policies.
+var Policies = []string{PolicyBalanced, PolicyLeastInstances, PolicyScriptlet}
+
+// scriptletTimeout is how long a placement scriptlet is allowed to run.
+var scriptletTimeout = 10 * time.Second
+
+// Candidate represents a cluster member an instance can be placed on.
+type Candidate struct {
+	Name         string   `json:"name"`
+	Architecture string   `json:"architecture"`
+	Groups       []string `json:"groups"`
+	Instances    int      `json:"instances"`
+
+	// Resources is false if the usage of the member couldn't be retrieved, in which case the fields below
+	// are not set.
+	Resources   bool    `json:"resources"`
+	CPUs        uint64  `json:"cpus"`
+	Load        float64 `json:"load"`
+	MemoryTotal uint64  `json:"memory_total"`
+	MemoryUsed  uint64  `json:"memory_used"`
+	PoolTotal   uint64  `json:"pool_total"`
+	PoolUsed    uint64  `json:"pool_used"`
+}
+
+// Request represents the instance being placed.
+type Request struct {
+	Project string `json:"project"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Memory  uint64 `json:"memory"`
+	Pool    string `json:"pool"`
+}
+
+// Select returns the name of the candidate the instance should be placed on according to the given policy.
+// The scriptlet is only used with the scriptlet policy.
+func Select(policy string, scriptlet string, req Request, candidates []Candidate) (string, error) {
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("No cluster member available")
+	}
+
+	switch policy {
+	case PolicyLeastInstances:
+		return selectLeastInstances(candidates), nil
+	case PolicyBalanced, "":
+		return selectBalanced(req, candidates)
+	case PolicyScriptlet:
+		return selectScriptlet(scriptlet, req, candidates)
+	}
+
+	return "", fmt.Errorf("Unknown placement policy %q", policy)
+}
+
+// selectLeastInstances picks the candidate with the least instances.
+func selectLeastInstances(candidates []Candidate) string {
+	best := candidates[0]
+	for _, candidate := range candidates[1:] {
+		if candidate.Instances < best.Instances {
+			best = candidate
+		}
+	}
+
+	return best.Name
+}
+
+// selectBalanced picks the candidate with the most free memory, CPU and storage, skipping those without
+// enough free memory for the instance. Candidates whose usage is unknown score as if half used.
+func selectBalanced(req Request, candidates []Candidate) (string, error) {
+	type scored struct {
+		candidate Candidate
+		score     float64
+	}
+
+	results := []scored{}
+	for _, candidate := range candidates {
+		if candidate.Resources && req.Memory > 0 && candidate.MemoryTotal-candidate.MemoryUsed < req.Memory {
+			continue
+		}
+
+		results = append(results, scored{candidate: candidate, score: balancedScore(candidate)})
+	}
+
+	if len(results) == 0 {
+		return "", fmt.Errorf("No cluster member with enough free memory")
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+
+		return results[i].candidate.Instances < results[j].candidate.Instances
+	})
+
+	return results[0].candidate.Name, nil
+}
+
+// balancedScore returns a score between 0 and 3 for the candidate, higher is better.
+func balancedScore(candidate Candidate) float64 {
+	if !candidate.Resources {
+		return 1.5
+	}
+
+	score := 0.0
+
+	// Free memory.
+	if candidate.MemoryTotal > 0 {
+		score += float64(candidate.MemoryTotal-candidate.MemoryUsed) / float64(candidate.MemoryTotal)
+	}
+
+	// Idle CPU.
+	if candidate.CPUs > 0 {
+		load := candidate.Load / float64(candidate.CPUs)
+		if load < 1 {
+			score += 1 - load
+		}
+	}
+
+	// Free space in the instance's storage pool.
+	if candidate.PoolTotal > 0 {
+		score += float64(candidate.PoolTotal-candidate.PoolUsed) / float64(candidate.PoolTotal)
+	} else {
+		score += 0.5
+	}
+
+	return score
+}
+
+// selectScriptlet runs the scriptlet, passing it the request and the candidates as JSON on stdin, and
+// returns the candidate name it prints on stdout.
+func selectScriptlet(scriptlet string, req Request, candidates []Candidate) (string, error) {
+	if scriptlet == "" {
+		return "", fmt.Errorf("No placement scriptlet configured")
+	}
+
+	f, err := ioutil.TempFile("", "lxd_placement_")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(scriptlet)
+	f.Close()
+	if err != nil {
+		return "", err
+	}
+
+	err = os.Chmod(f.Name(), 0700)
+	if err != nil {
+		return "", err
+	}
+
+	input, err := json.Marshal(map[string]interface{}{
+		"request":    req,
+		"candidates": candidates,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), scriptletTimeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, f.Name())
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return "", errors.Wrapf(err, "Placement scriptlet failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	name := strings.TrimSpace(stdout.String())
+	for _, candidate := range candidates {
+		if candidate.Name == name {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("Placement scriptlet returned invalid cluster member %q", name)
+}
+
+// ValidatePolicy checks whether the given placement policy is supported.
+func ValidatePolicy(value string) error {
+	if !shared.StringInSlice(value, Policies) {
+		return fmt.Errorf("Invalid placement policy %q (not one of %s)", value, strings.Join(Policies, ", "))
+	}
+
+	return nil
+}
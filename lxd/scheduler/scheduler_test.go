@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelect_LeastInstances(t *testing.T) {
+	candidates := []Candidate{
+		{Name: "node1", Instances: 3},
+		{Name: "node2", Instances: 1},
+		{Name: "node3", Instances: 2},
+	}
+
+	name, err := Select(PolicyLeastInstances, "", Request{}, candidates)
+	require.NoError(t, err)
+	assert.Equal(t, "node2", name)
+}
+
+func TestSelect_Balanced(t *testing.T) {
+	candidates := []Candidate{
+		{Name: "busy", Resources: true, CPUs: 4, Load: 4, MemoryTotal: 100, MemoryUsed: 90},
+		{Name: "idle", Resources: true, CPUs: 4, Load: 0.5, MemoryTotal: 100, MemoryUsed: 20, Instances: 5},
+		{Name: "unknown"},
+	}
+
+	name, err := Select(PolicyBalanced, "", Request{}, candidates)
+	require.NoError(t, err)
+	assert.Equal(t, "idle", name)
+}
+
+// Candidates without enough free memory for the instance are skipped.
+func TestSelect_BalancedMemory(t *testing.T) {
+	candidates := []Candidate{
+		{Name: "small", Resources: true, CPUs: 4, MemoryTotal: 100, MemoryUsed: 10},
+		{Name: "large", Resources: true, CPUs: 4, Load: 3, MemoryTotal: 1000, MemoryUsed: 800},
+	}
+
+	name, err := Select(PolicyBalanced, "", Request{Memory: 150}, candidates)
+	require.NoError(t, err)
+	assert.Equal(t, "large", name)
+
+	_, err = Select(PolicyBalanced, "", Request{Memory: 500}, candidates)
+	assert.Error(t, err)
+}
+
+func TestSelect_Scriptlet(t *testing.T) {
+	candidates := []Candidate{
+		{Name: "node1"},
+		{Name: "node2"},
+	}
+
+	name, err := Select(PolicyScriptlet, "#!/bin/sh\necho node2\n", Request{}, candidates)
+	require.NoError(t, err)
+	assert.Equal(t, "node2", name)
+
+	_, err = Select(PolicyScriptlet, "#!/bin/sh\necho node3\n", Request{}, candidates)
+	assert.Error(t, err)
+}
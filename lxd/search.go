@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var searchCmd = APIEndpoint{
+	Path: "search",
+
+	Get: APIEndpointAction{Handler: searchGet, AccessHandler: allowAuthenticated},
+}
+
+// searchTypes lists the entity types that can be searched.
+var searchTypes = []string{"instance", "image", "network", "profile", "storage-volume"}
+
+// searchGet returns all entities matching the q parameter across all projects the user can view.
+func searchGet(d *Daemon, r *http.Request) response.Response {
+	query := strings.ToLower(strings.TrimSpace(queryParam(r, "q")))
+	if query == "" {
+		return response.BadRequest(fmt.Errorf("Missing search query"))
+	}
+
+	types := searchTypes
+	if queryParam(r, "type") != "" {
+		types = strings.Split(queryParam(r, "type"), ",")
+		for _, entityType := range types {
+			if !shared.StringInSlice(entityType, searchTypes) {
+				return response.BadRequest(fmt.Errorf("Invalid entity type %q", entityType))
+			}
+		}
+	}
+
+	var projects []string
+	err := d.cluster.TransactionContext(r.Context(), func(tx *db.ClusterTx) error {
+		var err error
+		projects, err = tx.GetProjectNames()
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Only search the projects the user is allowed to look at.
+	visible := []string{}
+	for _, projectName := range projects {
+		if d.userHasPermission(r, projectName, "view") {
+			visible = append(visible, projectName)
+		}
+	}
+
+	results := []api.SearchResult{}
+	for _, entityType := range types {
+		var entries []api.SearchResult
+		switch entityType {
+		case "instance":
+			entries, err = searchInstances(r.Context(), d, visible, query)
+		case "image":
+			entries, err = searchImages(d, visible, query)
+		case "network":
+			entries, err = searchNetworks(d, visible, query)
+		case "profile":
+			entries, err = searchProfiles(r.Context(), d, visible, query)
+		case "storage-volume":
+			entries, err = searchStorageVolumes(d, visible, query)
+		}
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		results = append(results, entries...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Type != results[j].Type {
+			return results[i].Type < results[j].Type
+		}
+
+		if results[i].Project != results[j].Project {
+			return results[i].Project < results[j].Project
+		}
+
+		return results[i].Name < results[j].Name
+	})
+
+	return response.SyncResponse(true, results)
+}
+
+// searchMatches returns the fields of an entity containing the (lower case) query.
+// Only user.* config keys are looked at, either by key or by value.
+func searchMatches(query string, name string, description string, config map[string]string) []string {
+	matches := []string{}
+
+	if strings.Contains(strings.ToLower(name), query) {
+		matches = append(matches, "name")
+	}
+
+	if strings.Contains(strings.ToLower(description), query) {
+		matches = append(matches, "description")
+	}
+
+	keys := []string{}
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !strings.HasPrefix(key, "user.") {
+			continue
+		}
+
+		if strings.Contains(strings.ToLower(key), query) || strings.Contains(strings.ToLower(config[key]), query) {
+			matches = append(matches, fmt.Sprintf("config.%s", key))
+		}
+	}
+
+	return matches
+}
+
+// searchURL builds an entity URL, including the project when not the default one.
+func searchURL(projectName string, path ...string) string {
+	escaped := make([]string, 0, len(path))
+	for _, entry := range path {
+		escaped = append(escaped, url.PathEscape(entry))
+	}
+
+	u := fmt.Sprintf("/%s/%s", version.APIVersion, strings.Join(escaped, "/"))
+	if projectName != "" && projectName != project.Default {
+		u = fmt.Sprintf("%s?project=%s", u, url.QueryEscape(projectName))
+	}
+
+	return u
+}
+
+func searchInstances(ctx context.Context, d *Daemon, projects []string, query string) ([]api.SearchResult, error) {
+	results := []api.SearchResult{}
+
+	err := d.cluster.TransactionContext(ctx, func(tx *db.ClusterTx) error {
+		for _, projectName := range projects {
+			instances, err := tx.GetInstances(db.InstanceFilter{Project: projectName, Type: instancetype.Any})
+			if err != nil {
+				return err
+			}
+
+			for _, inst := range instances {
+				matches := searchMatches(query, inst.Name, inst.Description, inst.Config)
+				if len(matches) == 0 {
+					continue
+				}
+
+				results = append(results, api.SearchResult{
+					Type:        "instance",
+					Name:        inst.Name,
+					Project:     projectName,
+					URL:         searchURL(projectName, "instances", inst.Name),
+					Description: inst.Description,
+					Matches:     matches,
+				})
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func searchProfiles(ctx context.Context, d *Daemon, projects []string, query string) ([]api.SearchResult, error) {
+	results := []api.SearchResult{}
+
+	err := d.cluster.TransactionContext(ctx, func(tx *db.ClusterTx) error {
+		for _, projectName := range projects {
+			// Projects without their own profiles use the ones from the default project.
+			hasProfiles, err := tx.ProjectHasProfiles(projectName)
+			if err != nil {
+				return err
+			}
+
+			if !hasProfiles && projectName != project.Default {
+				continue
+			}
+
+			profiles, err := tx.GetProfiles(db.ProfileFilter{Project: projectName})
+			if err != nil {
+				return err
+			}
+
+			for _, profile := range profiles {
+				matches := searchMatches(query, profile.Name, profile.Description, profile.Config)
+				if len(matches) == 0 {
+					continue
+				}
+
+				results = append(results, api.SearchResult{
+					Type:        "profile",
+					Name:        profile.Name,
+					Project:     projectName,
+					URL:         searchURL(projectName, "profiles", profile.Name),
+					Description: profile.Description,
+					Matches:     matches,
+				})
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func searchImages(d *Daemon, projects []string, query string) ([]api.SearchResult, error) {
+	results := []api.SearchResult{}
+
+	for _, projectName := range projects {
+		// Projects without their own images use the ones from the default project.
+		var hasImages bool
+		err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			var err error
+			hasImages, err = tx.ProjectHasImages(projectName)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if !hasImages && projectName != project.Default {
+			continue
+		}
+
+		fingerprints, err := d.cluster.GetImagesFingerprints(projectName, false)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, fingerprint := range fingerprints {
+			_, image, err := d.cluster.GetImage(projectName, fingerprint, false)
+			if err != nil {
+				return nil, err
+			}
+
+			// Images are known by their fingerprint and aliases, their description is a property.
+			matches := searchMatches(query, image.Fingerprint, image.Properties["description"], nil)
+			for _, alias := range image.Aliases {
+				if strings.Contains(strings.ToLower(alias.Name), query) {
+					matches = append(matches, fmt.Sprintf("aliases.%s", alias.Name))
+				}
+			}
+
+			if len(matches) == 0 {
+				continue
+			}
+
+			results = append(results, api.SearchResult{
+				Type:        "image",
+				Name:        image.Fingerprint,
+				Project:     projectName,
+				URL:         searchURL(projectName, "images", image.Fingerprint),
+				Description: image.Properties["description"],
+				Matches:     matches,
+			})
+		}
+	}
+
+	return results, nil
+}
+
+func searchNetworks(d *Daemon, projects []string, query string) ([]api.SearchResult, error) {
+	results := []api.SearchResult{}
+
+	projectNetworks, err := d.cluster.GetNetworksAllProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, projectName := range projects {
+		for _, name := range projectNetworks[projectName] {
+			_, network, err := d.cluster.GetNetworkInAnyState(projectName, name)
+			if err != nil {
+				return nil, err
+			}
+
+			matches := searchMatches(query, network.Name, network.Description, network.Config)
+			if len(matches) == 0 {
+				continue
+			}
+
+			results = append(results, api.SearchResult{
+				Type:        "network",
+				Name:        network.Name,
+				Project:     projectName,
+				URL:         searchURL(projectName, "networks", network.Name),
+				Description: network.Description,
+				Matches:     matches,
+			})
+		}
+	}
+
+	return results, nil
+}
+
+func searchStorageVolumes(d *Daemon, projects []string, query string) ([]api.SearchResult, error) {
+	results := []api.SearchResult{}
+
+	pools, err := d.cluster.GetStoragePoolNames()
+	if err != nil && err != db.ErrNoSuchObject {
+		return nil, err
+	}
+
+	for _, pool := range pools {
+		poolID, err := d.cluster.GetStoragePoolID(pool)
+		if err != nil {
+			return nil, err
+		}
+
+		// Custom volumes on remote pools are reported once per cluster member, only keep one of them.
+		seen := map[string]bool{}
+		for _, projectName := range projects {
+			volumes, err := d.cluster.GetStoragePoolVolumes(projectName, poolID, []int{db.StoragePoolVolumeTypeCustom})
+			if err == db.ErrNoSuchObject {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			for _, volume := range volumes {
+				key := fmt.Sprintf("%s/%s", projectName, volume.Name)
+				if seen[key] {
+					continue
+				}
+
+				seen[key] = true
+
+				matches := searchMatches(query, volume.Name, volume.Description, volume.Config)
+				if len(matches) == 0 {
+					continue
+				}
+
+				results = append(results, api.SearchResult{
+					Type:        "storage-volume",
+					Name:        volume.Name,
+					Project:     projectName,
+					URL:         searchURL(projectName, "storage-pools", pool, "volumes", "custom", volume.Name),
+					Description: volume.Description,
+					Matches:     matches,
+				})
+			}
+		}
+	}
+
+	return results, nil
+}
@@ -477,7 +477,7 @@ func (b *lxdBackend) CreateInstance(inst instance.Instance, op *operations.Opera
 // created in the database to run any storage layer finalisations, and a revert hook that can be
 // run if the instance database load process fails that will remove anything created thus far.
 func (b *lxdBackend) CreateInstanceFromBackup(srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) (func(instance.Instance) error, func(), error) {
-	logger := logging.AddContext(b.logger, log.Ctx{"project": srcBackup.Project, "instance": srcBackup.Name, "snapshots": srcBackup.Snapshots, "optimizedStorage": *srcBackup.OptimizedStorage})
+	logger := logging.AddContext(b.logger, log.Ctx{"project": srcBackup.Project, "instance": srcBackup.Name, "snapshots": srcBackup.Snapshots, "optimizedStorage": *srcBackup.OptimizedStorage, "incrementalFrom": srcBackup.IncrementalFrom})
 	logger.Debug("CreateInstanceFromBackup started")
 	defer logger.Debug("CreateInstanceFromBackup finished")
 
@@ -523,9 +523,12 @@ func (b *lxdBackend) CreateInstanceFromBackup(srcBackup backup.Info, srcData io.
 		return nil, nil, err
 	}
 
-	revert.Add(func() {
-		b.removeInstanceSymlink(instanceType, srcBackup.Project, srcBackup.Name)
-	})
+	// Incremental backups are restored onto an existing instance, so its symlinks must be kept on failure.
+	if srcBackup.IncrementalFrom == "" {
+		revert.Add(func() {
+			b.removeInstanceSymlink(instanceType, srcBackup.Project, srcBackup.Name)
+		})
+	}
 
 	if len(srcBackup.Snapshots) > 0 {
 		err = b.ensureInstanceSnapshotSymlink(instanceType, srcBackup.Project, srcBackup.Name)
@@ -533,9 +536,11 @@ func (b *lxdBackend) CreateInstanceFromBackup(srcBackup backup.Info, srcData io.
 			return nil, nil, err
 		}
 
-		revert.Add(func() {
-			b.removeInstanceSnapshotSymlinkIfUnused(instanceType, srcBackup.Project, srcBackup.Name)
-		})
+		if srcBackup.IncrementalFrom == "" {
+			revert.Add(func() {
+				b.removeInstanceSnapshotSymlinkIfUnused(instanceType, srcBackup.Project, srcBackup.Name)
+			})
+		}
 	}
 
 	// Update pool information in the backup.yaml file.
@@ -1497,9 +1502,10 @@ func (b *lxdBackend) MigrateInstance(inst instance.Instance, conn io.ReadWriteCl
 	return nil
 }
 
-// BackupInstance creates an instance backup.
-func (b *lxdBackend) BackupInstance(inst instance.Instance, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
-	logger := logging.AddContext(b.logger, log.Ctx{"project": inst.Project(), "instance": inst.Name(), "optimized": optimized, "snapshots": snapshots})
+// BackupInstance creates an instance backup including the given snapshots (by name, oldest first).
+// If incrementalFrom is set, the snapshots and instance are exported as changes since that snapshot.
+func (b *lxdBackend) BackupInstance(inst instance.Instance, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, incrementalFrom string, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": inst.Project(), "instance": inst.Name(), "optimized": optimized, "snapshots": snapshots, "incrementalFrom": incrementalFrom})
 	logger.Debug("BackupInstance started")
 	defer logger.Debug("BackupInstance finished")
 
@@ -1510,6 +1516,16 @@ func (b *lxdBackend) BackupInstance(inst instance.Instance, tarWriter *instancew
 
 	contentType := InstanceContentType(inst)
 
+	if incrementalFrom != "" {
+		if !optimized {
+			return fmt.Errorf("Incremental backups require optimized storage")
+		}
+
+		if !b.driver.Info().IncrementalBackups {
+			return ErrNotImplemented
+		}
+	}
+
 	// Get the root disk device config.
 	rootDiskConf, err := b.instanceRootVolumeConfig(inst)
 	if err != nil {
@@ -1526,7 +1542,7 @@ func (b *lxdBackend) BackupInstance(inst instance.Instance, tarWriter *instancew
 	}
 
 	vol := b.newVolume(volType, contentType, volStorageName, rootDiskConf)
-	err = b.driver.BackupVolume(vol, tarWriter, optimized, snapshots, op)
+	err = b.driver.BackupVolume(vol, tarWriter, optimized, snapshots, incrementalFrom, op)
 	if err != nil {
 		return err
 	}
@@ -2853,12 +2869,18 @@ func (b *lxdBackend) DeleteCustomVolume(projectName string, volName string, op *
 		}
 	}
 
-	// Finally, remove the volume record from the database.
+	// Finally, remove the volume record from the database (this also removes its backup records).
 	err = b.state.Cluster.RemoveStoragePoolVolume(projectName, volName, db.StoragePoolVolumeTypeCustom, b.ID())
 	if err != nil {
 		return err
 	}
 
+	// Remove the volume's backup tarballs.
+	err = os.RemoveAll(backup.VolumeBackupsPath(projectName, b.name, volName))
+	if err != nil {
+		return errors.Wrapf(err, "Failed to remove custom volume backups")
+	}
+
 	return nil
 }
 
@@ -3171,6 +3193,112 @@ func (b *lxdBackend) RestoreCustomVolume(projectName, volName string, snapshotNa
 	return nil
 }
 
+// BackupCustomVolume creates a custom volume backup including the given snapshots (by name, oldest first).
+func (b *lxdBackend) BackupCustomVolume(projectName string, volName string, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": projectName, "volName": volName, "optimized": optimized, "snapshots": snapshots})
+	logger.Debug("BackupCustomVolume started")
+	defer logger.Debug("BackupCustomVolume finished")
+
+	// Get the volume.
+	_, dbVol, err := b.state.Cluster.GetLocalStoragePoolVolume(projectName, volName, db.StoragePoolVolumeTypeCustom, b.ID())
+	if err != nil {
+		return err
+	}
+
+	dbContentType, err := VolumeContentTypeNameToContentType(dbVol.ContentType)
+	if err != nil {
+		return err
+	}
+
+	contentType, err := VolumeDBContentTypeToContentType(dbContentType)
+	if err != nil {
+		return err
+	}
+
+	if contentType != drivers.ContentTypeFS {
+		return fmt.Errorf("Backups of block custom volumes are not supported")
+	}
+
+	// Get the volume name on storage.
+	volStorageName := project.StorageVolume(projectName, volName)
+
+	vol := b.newVolume(drivers.VolumeTypeCustom, contentType, volStorageName, dbVol.Config)
+	err = b.driver.BackupVolume(vol, tarWriter, optimized, snapshots, "", op)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CreateCustomVolumeFromBackup creates a custom volume and its snapshots from a backup tarball.
+func (b *lxdBackend) CreateCustomVolumeFromBackup(projectName string, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": projectName, "volName": srcBackup.Name, "snapshots": srcBackup.Snapshots, "optimizedStorage": *srcBackup.OptimizedStorage})
+	logger.Debug("CreateCustomVolumeFromBackup started")
+	defer logger.Debug("CreateCustomVolumeFromBackup finished")
+
+	if srcBackup.Volume == nil {
+		return fmt.Errorf("Backup is not a custom volume backup")
+	}
+
+	// Get the volume name on storage.
+	volStorageName := project.StorageVolume(projectName, srcBackup.Name)
+
+	// Validate config.
+	vol := b.newVolume(drivers.VolumeTypeCustom, drivers.ContentTypeFS, volStorageName, srcBackup.Volume.Config)
+	err := b.driver.ValidateVolume(vol, false)
+	if err != nil {
+		return err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Create database entries for the new storage volume and its snapshots.
+	err = VolumeDBCreate(b.state, projectName, b.name, srcBackup.Name, srcBackup.Volume.Description, db.StoragePoolVolumeTypeNameCustom, false, vol.Config(), time.Time{}, string(drivers.ContentTypeFS))
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		b.state.Cluster.RemoveStoragePoolVolume(projectName, srcBackup.Name, db.StoragePoolVolumeTypeCustom, b.ID())
+	})
+
+	for _, snapName := range srcBackup.Snapshots {
+		fullSnapName := drivers.GetSnapshotVolumeName(srcBackup.Name, snapName)
+
+		err = VolumeDBCreate(b.state, projectName, b.name, fullSnapName, srcBackup.Volume.Description, db.StoragePoolVolumeTypeNameCustom, true, vol.Config(), time.Time{}, string(drivers.ContentTypeFS))
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() {
+			b.state.Cluster.RemoveStoragePoolVolume(projectName, fullSnapName, db.StoragePoolVolumeTypeCustom, b.ID())
+		})
+	}
+
+	// Unpack the backup into the new storage volume(s).
+	volPostHook, revertHook, err := b.driver.CreateVolumeFromBackup(vol, srcBackup, srcData, op)
+	if err != nil {
+		return err
+	}
+
+	if revertHook != nil {
+		revert.Add(revertHook)
+	}
+
+	// If the driver returned a post hook, run it now.
+	if volPostHook != nil {
+		err = volPostHook(vol)
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
 func (b *lxdBackend) createStorageStructure(path string) error {
 	for _, volType := range b.driver.Info().VolumeTypes {
 		for _, name := range drivers.BaseDirectories[volType] {
@@ -76,6 +76,7 @@ func (d *btrfs) Info() Info {
 		OptimizedImages:       true,
 		OptimizedBackups:      true,
 		OptimizedBackupHeader: true,
+		IncrementalBackups:    true,
 		PreservesInodes:       !d.state.OS.RunningInUserNS,
 		Remote:                false,
 		VolumeTypes:           []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM},
@@ -450,7 +450,7 @@ func (d *btrfs) loadOptimizedBackupHeader(r io.ReadSeeker) (*BTRFSMetaDataHeader
 
 // receiveSubVolume receives a subvolume from an io.Reader into the receivePath, then sets it writable and returns
 // the path to the received subvolume.
-func (d *btrfs) receiveSubVolume(r io.Reader, receivePath string) (string, error) {
+func (d *btrfs) receiveSubVolume(ctx context.Context, r io.Reader, receivePath string) (string, error) {
 	// Check target path is empty before receive.
 	files, err := ioutil.ReadDir(receivePath)
 	if err != nil {
@@ -460,7 +460,7 @@ func (d *btrfs) receiveSubVolume(r io.Reader, receivePath string) (string, error
 		return "", fmt.Errorf("Target path is not empty %q", receivePath)
 	}
 
-	err = shared.RunCommandWithFds(r, nil, "btrfs", "receive", "-e", receivePath)
+	err = shared.RunCommandWithFdsContext(ctx, r, nil, "btrfs", "receive", "-e", receivePath)
 	if err != nil {
 		return "", err
 	}
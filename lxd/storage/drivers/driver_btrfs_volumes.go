@@ -115,7 +115,13 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 		return genericVFSBackupUnpack(d, vol, srcBackup.Snapshots, srcData, op)
 	}
 
-	if d.HasVolume(vol) {
+	if srcBackup.IncrementalFrom != "" {
+		// Incremental backups are applied on top of the existing volume which must have the base snapshot.
+		baseVol, _ := vol.NewSnapshot(srcBackup.IncrementalFrom)
+		if !btrfsIsSubVolume(baseVol.MountPath()) {
+			return nil, nil, fmt.Errorf("Cannot restore incremental backup, base snapshot %q missing on target", srcBackup.IncrementalFrom)
+		}
+	} else if d.HasVolume(vol) {
 		return nil, nil, fmt.Errorf("Cannot restore volume, already exists on target")
 	}
 
@@ -131,8 +137,10 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 			d.DeleteVolumeSnapshot(snapVol, op)
 		}
 
-		// And lastly the main volume.
-		d.DeleteVolume(vol, op)
+		// And lastly the main volume (unless it existed before an incremental restore).
+		if srcBackup.IncrementalFrom == "" {
+			d.DeleteVolume(vol, op)
+		}
 	}
 	// Only execute the revert function if we have had an error internally.
 	revert.Add(revertHook)
@@ -202,7 +210,7 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 			}
 
 			if hdr.Name == srcFile {
-				subVolRecvPath, err := d.receiveSubVolume(tr, targetPath)
+				subVolRecvPath, err := d.receiveSubVolume(op.Context(), tr, targetPath)
 				if err != nil {
 					return "", err
 				}
@@ -243,6 +251,14 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 				return err
 			}
 
+			// When applying an incremental backup, replace the existing subvolume with the received one.
+			if srcBackup.IncrementalFrom != "" && btrfsIsSubVolume(subVolTargetPath) {
+				err = d.deleteSubvolume(subVolTargetPath, true)
+				if err != nil {
+					return err
+				}
+			}
+
 			// Clear the target for the subvol to use.
 			os.Remove(subVolTargetPath)
 
@@ -466,7 +482,7 @@ func (d *btrfs) CreateVolumeFromMigration(vol Volume, conn io.ReadWriteCloser, v
 
 			subVolTargetPath := filepath.Join(v.MountPath(), subVol.Path)
 			d.logger.Debug("Receiving volume", log.Ctx{"name": v.name, "receivePath": receivePath, "path": subVolTargetPath})
-			subVolRecvPath, err := d.receiveSubVolume(conn, receivePath)
+			subVolRecvPath, err := d.receiveSubVolume(op.Context(), conn, receivePath)
 			if err != nil {
 				return err
 			}
@@ -937,7 +953,7 @@ func (d *btrfs) MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *m
 
 // BackupVolume copies a volume (and optionally its snapshots) to a specified target path.
 // This driver does not support optimized backups.
-func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
+func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, incrementalFrom string, op *operations.Operation) error {
 	// Handle the non-optimized tarballs through the generic packer.
 	if !optimized {
 		// Because the generic backup method will not take a consistent backup if files are being modified
@@ -981,19 +997,15 @@ func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWr
 	}
 
 	// Optimized backup.
-	var err error
-	var volSnapshots []string
-
-	// Retrieve the snapshots if requested.
-	if snapshots {
-		volSnapshots, err = d.VolumeSnapshots(vol, op)
-		if err != nil {
-			return err
-		}
+	// For incremental backups the snapshots are chained from the base snapshot.
+	lastVolPath := "" // Used as parent for differential exports.
+	if incrementalFrom != "" {
+		baseVol, _ := vol.NewSnapshot(incrementalFrom)
+		lastVolPath = baseVol.MountPath()
 	}
 
 	// Generate driver restoration header.
-	optimizedHeader, err := d.restorationHeader(vol, volSnapshots)
+	optimizedHeader, err := d.restorationHeader(vol, snapshots)
 	if err != nil {
 		return err
 	}
@@ -1038,7 +1050,7 @@ func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWr
 
 		// Write the subvolume to the file.
 		d.logger.Debug("Generating optimized volume file", log.Ctx{"sourcePath": path, "parent": parent, "file": tmpFile.Name(), "name": fileName})
-		err = shared.RunCommandWithFds(nil, tmpFile, "btrfs", args...)
+		err = shared.RunCommandWithFdsContext(op.Context(), nil, tmpFile, "btrfs", args...)
 		if err != nil {
 			return err
 		}
@@ -1128,8 +1140,7 @@ func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWr
 	}
 
 	// Backup snapshots if populated.
-	lastVolPath := "" // Used as parent for differential exports.
-	for _, snapName := range volSnapshots {
+	for _, snapName := range snapshots {
 		snapVol, _ := vol.NewSnapshot(snapName)
 
 		// Make a binary btrfs backup.
@@ -123,6 +123,14 @@ func (d *ceph) Create() error {
 		d.config["source"] = d.name
 	}
 
+	// Check that the data pool is usable before creating anything.
+	if d.config["ceph.osd.data_pool_name"] != "" {
+		err := d.osdCheckDataPool()
+		if err != nil {
+			return err
+		}
+	}
+
 	dummyVol := NewVolume(d, d.name, VolumeType("lxd"), ContentTypeFS, d.config["ceph.osd.pool_name"], nil, nil)
 
 	if !d.osdPoolExists() {
@@ -1,6 +1,7 @@
 package drivers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -38,6 +39,51 @@ func (d *ceph) osdPoolExists() bool {
 	return err == nil
 }
 
+// osdCheckDataPool checks that the OSD data pool exists and, if it is an erasure-coded pool, that it allows
+// overwrites. RBD images can only store their data in an erasure-coded pool when overwrites are enabled.
+func (d *ceph) osdCheckDataPool() error {
+	poolName := d.config["ceph.osd.data_pool_name"]
+
+	_, err := shared.RunCommand(
+		"ceph",
+		"--name", fmt.Sprintf("client.%s", d.config["ceph.user.name"]),
+		"--cluster", d.config["ceph.cluster_name"],
+		"osd",
+		"pool",
+		"get",
+		poolName,
+		"size")
+	if err != nil {
+		return fmt.Errorf("OSD data pool %q doesn't exist in cluster %q", poolName, d.config["ceph.cluster_name"])
+	}
+
+	// Replicated pools don't have the allow_ec_overwrites property, so failing to get it means there is
+	// nothing more to check.
+	msg, err := shared.RunCommand(
+		"ceph",
+		"--name", fmt.Sprintf("client.%s", d.config["ceph.user.name"]),
+		"--cluster", d.config["ceph.cluster_name"],
+		"osd",
+		"pool",
+		"get",
+		poolName,
+		"allow_ec_overwrites")
+	if err != nil {
+		return nil
+	}
+
+	idx := strings.Index(msg, "allow_ec_overwrites:")
+	if idx == -1 {
+		return fmt.Errorf("Failed to parse erasure coding overwrites setting for pool: %s", msg)
+	}
+
+	if !shared.IsTrue(strings.TrimSpace(msg[(idx + len("allow_ec_overwrites:")):])) {
+		return fmt.Errorf("Erasure-coded OSD data pool %q must have overwrites enabled (ceph osd pool set %s allow_ec_overwrites true)", poolName, poolName)
+	}
+
+	return nil
+}
+
 // osdDeletePool destroys an OSD pool.
 // - A call to osdDeletePool will destroy a pool including any storage
 //   volumes that still exist in the pool.
@@ -527,7 +573,7 @@ func (d *ceph) rbdListVolumeSnapshots(vol Volume) ([]string, error) {
 // copyWithSnapshots creates a non-sparse copy of a container including its snapshots.
 // This does not introduce a dependency relation between the source RBD storage
 // volume and the target RBD storage volume.
-func (d *ceph) copyWithSnapshots(sourceVolumeName string, targetVolumeName string, sourceParentSnapshot string) error {
+func (d *ceph) copyWithSnapshots(ctx context.Context, sourceVolumeName string, targetVolumeName string, sourceParentSnapshot string) error {
 	args := []string{
 		"export-diff",
 		"--id", d.config["ceph.user.name"],
@@ -542,8 +588,9 @@ func (d *ceph) copyWithSnapshots(sourceVolumeName string, targetVolumeName strin
 	// Redirect output to stdout.
 	args = append(args, "-")
 
-	rbdSendCmd := exec.Command("rbd", args...)
-	rbdRecvCmd := exec.Command(
+	rbdSendCmd := exec.CommandContext(ctx, "rbd", args...)
+	rbdRecvCmd := exec.CommandContext(
+		ctx,
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"import-diff",
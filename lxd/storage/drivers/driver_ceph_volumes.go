@@ -349,14 +349,22 @@ func (d *ceph) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots boo
 	if !copySnapshots || len(snapshots) == 0 {
 		// If lightweight clone mode isn't enabled, perform a full copy of the volume.
 		if d.config["ceph.rbd.clone_copy"] != "" && !shared.IsTrue(d.config["ceph.rbd.clone_copy"]) {
-			_, err = shared.RunCommand(
-				"rbd",
+			args := []string{
 				"--id", d.config["ceph.user.name"],
 				"--cluster", d.config["ceph.cluster_name"],
+			}
+
+			if d.config["ceph.osd.data_pool_name"] != "" {
+				args = append(args, "--data-pool", d.config["ceph.osd.data_pool_name"])
+			}
+
+			args = append(args,
 				"cp",
 				d.getRBDVolumeName(srcVol, "", false, true),
 				d.getRBDVolumeName(vol, "", false, true),
 			)
+
+			_, err = shared.RunCommand("rbd", args...)
 			if err != nil {
 				return err
 			}
@@ -454,7 +462,7 @@ func (d *ceph) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots boo
 
 		lastSnap = fmt.Sprintf("snapshot_%s", snap)
 		sourceVolumeName := d.getRBDVolumeName(srcVol, lastSnap, false, true)
-		err = d.copyWithSnapshots(sourceVolumeName, targetVolumeName, prev)
+		err = d.copyWithSnapshots(op.Context(), sourceVolumeName, targetVolumeName, prev)
 		if err != nil {
 			return err
 		}
@@ -475,7 +483,7 @@ func (d *ceph) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots boo
 	// Copy snapshot.
 	sourceVolumeName := d.getRBDVolumeName(srcVol, "", false, true)
 
-	err = d.copyWithSnapshots(sourceVolumeName, targetVolumeName, lastSnap)
+	err = d.copyWithSnapshots(op.Context(), sourceVolumeName, targetVolumeName, lastSnap)
 	if err != nil {
 		return err
 	}
@@ -1146,7 +1154,7 @@ func (d *ceph) MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *mi
 }
 
 // BackupVolume creates an exported version of a volume.
-func (d *ceph) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
+func (d *ceph) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, incrementalFrom string, op *operations.Operation) error {
 	return genericVFSBackupVolume(d, vol, tarWriter, snapshots, op)
 }
 
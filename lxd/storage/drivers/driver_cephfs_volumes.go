@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -44,6 +45,12 @@ func (d *cephfs) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.O
 		}
 	}()
 
+	// Apply the volume quota if specified.
+	err = d.SetVolumeQuota(vol, vol.ExpandedConfig("size"), op)
+	if err != nil {
+		return err
+	}
+
 	// Fill the volume.
 	err = d.runFiller(vol, "", filler)
 	if err != nil {
@@ -56,7 +63,7 @@ func (d *cephfs) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.O
 
 // CreateVolumeFromBackup re-creates a volume from its exported state.
 func (d *cephfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) (func(vol Volume) error, func(), error) {
-	return nil, nil, ErrNotImplemented
+	return genericVFSBackupUnpack(d, vol, srcBackup.Snapshots, srcData, op)
 }
 
 // CreateVolumeFromCopy copies an existing storage volume (with or without snapshots) into a new volume.
@@ -104,7 +111,7 @@ func (d *cephfs) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots b
 				// Mount the source snapshot.
 				err = srcSnapshot.MountTask(func(srcMountPath string, op *operations.Operation) error {
 					// Copy the snapshot.
-					_, err = rsync.LocalCopy(srcMountPath, mountPath, bwlimit, false)
+					_, err = rsync.LocalCopyContext(op.Context(), srcMountPath, mountPath, bwlimit, false)
 					return err
 				}, op)
 
@@ -127,7 +134,7 @@ func (d *cephfs) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots b
 
 		// Copy source to destination (mounting each volume if needed).
 		err = srcVol.MountTask(func(srcMountPath string, op *operations.Operation) error {
-			_, err := rsync.LocalCopy(srcMountPath, mountPath, bwlimit, false)
+			_, err := rsync.LocalCopyContext(op.Context(), srcMountPath, mountPath, bwlimit, false)
 			return err
 		}, op)
 		if err != nil {
@@ -297,12 +304,13 @@ func (d *cephfs) GetVolumeUsage(vol Volume) (int64, error) {
 		return -1, ErrNotSupported
 	}
 
-	out, err := shared.RunCommand("getfattr", "-n", "ceph.quota.max_bytes", "--only-values", GetVolumeMountPath(d.name, vol.volType, vol.name))
+	// The recursive size of a CephFS directory is exposed by the MDS as a virtual extended attribute.
+	out, err := shared.RunCommand("getfattr", "-n", "ceph.dir.rbytes", "--only-values", GetVolumeMountPath(d.name, vol.volType, vol.name))
 	if err != nil {
 		return -1, err
 	}
 
-	size, err := strconv.ParseInt(out, 10, 64)
+	size, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
 	if err != nil {
 		return -1, err
 	}
@@ -443,8 +451,8 @@ func (d *cephfs) MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *
 }
 
 // BackupVolume creates an exported version of a volume.
-func (d *cephfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
-	return ErrNotImplemented
+func (d *cephfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, incrementalFrom string, op *operations.Operation) error {
+	return genericVFSBackupVolume(d, vol, tarWriter, snapshots, op)
 }
 
 // CreateVolumeSnapshot creates a new snapshot.
@@ -521,7 +529,7 @@ func (d *cephfs) RestoreVolume(vol Volume, snapshotName string, op *operations.O
 
 	// Restore using rsync.
 	bwlimit := d.config["rsync.bwlimit"]
-	output, err := rsync.LocalCopy(cephSnapPath, vol.MountPath(), bwlimit, false)
+	output, err := rsync.LocalCopyContext(op.Context(), cephSnapPath, vol.MountPath(), bwlimit, false)
 	if err != nil {
 		return errors.Wrapf(err, "Failed to rsync volume: %s", string(output))
 	}
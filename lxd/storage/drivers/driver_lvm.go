@@ -551,7 +551,9 @@ func (d *lvm) GetResources() (*api.ResourcesStoragePool, error) {
 		res.Space.Total = totalSize
 		res.Space.Used = usedSize
 	} else {
-		// If thinpools are not in use, calculate used space in volume group.
+		// If thinpools are not in use, calculate used space in volume group. As the volume group may be
+		// shared with other users, only count our own logical volumes as used and the rest of the free
+		// space of the volume group as available to us.
 		args := []string{
 			d.config["lvm.vg_name"],
 			"--noheadings",
@@ -571,18 +573,18 @@ func (d *lvm) GetResources() (*api.ResourcesStoragePool, error) {
 			return nil, fmt.Errorf("Unexpected output from vgs command")
 		}
 
-		total, err := strconv.ParseUint(parts[0], 10, 64)
+		free, err := strconv.ParseUint(parts[1], 10, 64)
 		if err != nil {
 			return nil, err
 		}
 
-		res.Space.Total = total
-
-		free, err := strconv.ParseUint(parts[1], 10, 64)
+		used, err := d.ownedLogicalVolumesSize(d.config["lvm.vg_name"])
 		if err != nil {
 			return nil, err
 		}
-		res.Space.Used = total - free
+
+		res.Space.Used = used
+		res.Space.Total = used + free
 	}
 
 	return &res, nil
@@ -433,7 +433,12 @@ func (d *lvm) createLogicalVolumeSnapshot(vgName string, srcVol Volume, snapVol
 }
 
 // removeLogicalVolume removes a logical volume.
+// As the volume group may be shared with other users, only logical volumes created by LXD can be removed.
 func (d *lvm) removeLogicalVolume(volDevPath string) error {
+	if !d.isOwnedLogicalVolume(filepath.Base(volDevPath)) {
+		return fmt.Errorf("Refusing to remove LVM logical volume %q not created by LXD", volDevPath)
+	}
+
 	_, err := shared.TryRunCommand("lvremove", "-f", volDevPath)
 	if err != nil {
 		return err
@@ -492,6 +497,22 @@ func (d *lvm) lvmFullVolumeName(volType VolumeType, contentType ContentType, vol
 	return fmt.Sprintf("%s_%s%s", volTypePrefix, lvName, contentTypeSuffix)
 }
 
+// isOwnedLogicalVolume returns whether the logical volume name is one that LXD uses for its own volumes (or for
+// its thin pool).
+func (d *lvm) isOwnedLogicalVolume(lvName string) bool {
+	if d.usesThinpool() && lvName == d.thinpoolName() {
+		return true
+	}
+
+	for _, prefix := range []string{"containers_", "virtual-machines_", "images_", "custom_"} {
+		if strings.HasPrefix(lvName, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // lvmDevPath returns the path to the LVM volume device. Empty string is returned if invalid volType supplied.
 func (d *lvm) lvmDevPath(vgName string, volType VolumeType, contentType ContentType, volName string) string {
 	fullVolName := d.lvmFullVolumeName(volType, contentType, volName)
@@ -664,6 +685,31 @@ func (d *lvm) logicalVolumeSize(volDevPath string) (int64, error) {
 	return strconv.ParseInt(output, 10, 64)
 }
 
+// ownedLogicalVolumesSize gets the total size in bytes of the logical volumes created by LXD in a volume group.
+func (d *lvm) ownedLogicalVolumesSize(vgName string) (uint64, error) {
+	output, err := shared.RunCommand("lvs", "--noheadings", "--nosuffix", "--units", "b", "--separator", ",", "-o", "lv_name,lv_size", vgName)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Error listing logical volumes in LVM volume group %q", vgName)
+	}
+
+	var total uint64
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(strings.TrimSpace(line), ",")
+		if len(parts) < 2 || !d.isOwnedLogicalVolume(parts[0]) {
+			continue
+		}
+
+		size, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "Failed to parse size of LVM logical volume %q", parts[0])
+		}
+
+		total += size
+	}
+
+	return total, nil
+}
+
 func (d *lvm) thinPoolVolumeUsage(volDevPath string) (uint64, uint64, error) {
 	args := []string{
 		volDevPath,
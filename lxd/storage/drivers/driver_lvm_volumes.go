@@ -602,7 +602,7 @@ func (d *lvm) MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *mig
 
 // BackupVolume copies a volume (and optionally its snapshots) to a specified target path.
 // This driver does not support optimized backups.
-func (d *lvm) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, _, snapshots bool, op *operations.Operation) error {
+func (d *lvm) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, _ bool, snapshots []string, _ string, op *operations.Operation) error {
 	return genericVFSBackupVolume(d, vol, tarWriter, snapshots, op)
 }
 
@@ -990,7 +990,7 @@ func (d *lvm) RestoreVolume(vol Volume, snapshotName string, op *operations.Oper
 		// Copy source to destination (mounting each volume if needed).
 		err = snapVol.MountTask(func(srcMountPath string, op *operations.Operation) error {
 			bwlimit := d.config["rsync.bwlimit"]
-			_, err := rsync.LocalCopy(srcMountPath, mountPath, bwlimit, true)
+			_, err := rsync.LocalCopyContext(op.Context(), srcMountPath, mountPath, bwlimit, true)
 			return err
 		}, op)
 		if err != nil {
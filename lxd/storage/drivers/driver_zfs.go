@@ -107,6 +107,7 @@ func (d *zfs) Info() Info {
 		Version:               zfsVersion,
 		OptimizedImages:       true,
 		OptimizedBackups:      true,
+		IncrementalBackups:    true,
 		PreservesInodes:       true,
 		Remote:                false,
 		VolumeTypes:           []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM},
@@ -1,9 +1,11 @@
 package drivers
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -38,6 +40,27 @@ func (d *zfs) dataset(vol Volume, deleted bool) string {
 	return filepath.Join(d.config["zfs.pool_name"], string(vol.volType), name)
 }
 
+// backupParents returns the datasets the given snapshots of the volume are sent relative to in an optimized
+// backup, along with the one the volume itself is sent relative to. Incremental backups chain the first of
+// those from the base snapshot, even when no snapshot was taken after it.
+func (d *zfs) backupParents(vol Volume, snapshots []string, incrementalFrom string) ([]string, string) {
+	parent := ""
+	if incrementalFrom != "" {
+		baseSnapshot, _ := vol.NewSnapshot(incrementalFrom)
+		parent = d.dataset(baseSnapshot, false)
+	}
+
+	parents := make([]string, 0, len(snapshots))
+	for _, snapName := range snapshots {
+		parents = append(parents, parent)
+
+		snapshot, _ := vol.NewSnapshot(snapName)
+		parent = d.dataset(snapshot, false)
+	}
+
+	return parents, parent
+}
+
 func (d *zfs) createDataset(dataset string, options ...string) error {
 	args := []string{"create"}
 	for _, option := range options {
@@ -122,6 +145,33 @@ func (d *zfs) getDatasets(dataset string) ([]string, error) {
 	return children, nil
 }
 
+// sendIncremental sends the changes between two snapshots of a dataset to another dataset of the pool, rolling
+// it back to its latest snapshot first.
+func (d *zfs) sendIncremental(ctx context.Context, parent string, snapshot string, dataset string) error {
+	sender := exec.CommandContext(ctx, "zfs", "send", "-i", parent, snapshot)
+	receiver := exec.CommandContext(ctx, "zfs", "receive", "-F", dataset)
+
+	// Configure the pipes.
+	receiver.Stdin, _ = sender.StdoutPipe()
+	receiver.Stdout = os.Stdout
+	receiver.Stderr = os.Stderr
+
+	// Run the transfer.
+	err := receiver.Start()
+	if err != nil {
+		return err
+	}
+
+	err = sender.Run()
+	if err != nil {
+		receiver.Process.Kill()
+		receiver.Wait()
+		return err
+	}
+
+	return receiver.Wait()
+}
+
 func (d *zfs) setDatasetProperties(dataset string, options ...string) error {
 	if len(zfsVersion) >= 3 && zfsVersion[0:3] == "0.6" {
 		// Slow path for ZFS 0.6
@@ -0,0 +1,29 @@
+package drivers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZFSBackupParents(t *testing.T) {
+	d := &zfs{}
+	d.config = map[string]string{"zfs.pool_name": "pool"}
+
+	vol := NewVolume(d, "pool", VolumeTypeContainer, ContentTypeFS, "c1", nil, nil)
+
+	// Full backup.
+	parents, final := d.backupParents(vol, []string{"snap0", "snap1"}, "")
+	assert.Equal(t, []string{"", "pool/containers/c1@snapshot-snap0"}, parents)
+	assert.Equal(t, "pool/containers/c1@snapshot-snap1", final)
+
+	// Incremental backup with snapshots after the base.
+	parents, final = d.backupParents(vol, []string{"snap1", "snap2"}, "snap0")
+	assert.Equal(t, []string{"pool/containers/c1@snapshot-snap0", "pool/containers/c1@snapshot-snap1"}, parents)
+	assert.Equal(t, "pool/containers/c1@snapshot-snap2", final)
+
+	// Incremental backup without any snapshot after the base, the volume is sent relative to the base.
+	parents, final = d.backupParents(vol, nil, "snap0")
+	assert.Empty(t, parents)
+	assert.Equal(t, "pool/containers/c1@snapshot-snap0", final)
+}
@@ -253,7 +253,12 @@ func (d *zfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData
 		return genericVFSBackupUnpack(d, vol, srcBackup.Snapshots, srcData, op)
 	}
 
-	if d.HasVolume(vol) {
+	if srcBackup.IncrementalFrom != "" {
+		// Incremental backups are applied on top of the existing volume which must have the base snapshot.
+		if !d.checkDataset(fmt.Sprintf("%s@snapshot-%s", d.dataset(vol, false), srcBackup.IncrementalFrom)) {
+			return nil, nil, fmt.Errorf("Cannot restore incremental backup, base snapshot %q missing on target", srcBackup.IncrementalFrom)
+		}
+	} else if d.HasVolume(vol) {
 		return nil, nil, fmt.Errorf("Cannot restore volume, already exists on target")
 	}
 
@@ -280,8 +285,10 @@ func (d *zfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData
 			d.DeleteVolumeSnapshot(snapVol, op)
 		}
 
-		// And lastly the main volume.
-		d.DeleteVolume(vol, op)
+		// And lastly the main volume (unless it existed before an incremental restore).
+		if srcBackup.IncrementalFrom == "" {
+			d.DeleteVolume(vol, op)
+		}
 	}
 
 	// Only execute the revert function if we have had an error internally.
@@ -307,7 +314,7 @@ func (d *zfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData
 
 			if hdr.Name == srcFile {
 				// Extract the backup.
-				err = shared.RunCommandWithFds(tr, nil, "zfs", "receive", "-F", target)
+				err = shared.RunCommandWithFdsContext(op.Context(), tr, nil, "zfs", "receive", "-F", target)
 
 				if err != nil {
 					return err
@@ -487,13 +494,13 @@ func (d *zfs) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots bool
 
 		// Send/receive the snapshot.
 		var sender *exec.Cmd
-		receiver := exec.Command("zfs", "receive", d.dataset(vol, false))
+		receiver := exec.CommandContext(op.Context(), "zfs", "receive", d.dataset(vol, false))
 
 		// Handle transferring snapshots.
 		if len(snapshots) > 0 {
-			sender = exec.Command("zfs", "send", "-R", srcSnapshot)
+			sender = exec.CommandContext(op.Context(), "zfs", "send", "-R", srcSnapshot)
 		} else {
-			sender = exec.Command("zfs", "send", srcSnapshot)
+			sender = exec.CommandContext(op.Context(), "zfs", "send", srcSnapshot)
 		}
 
 		// Configure the pipes.
@@ -696,9 +703,153 @@ func (d *zfs) CreateVolumeFromMigration(vol Volume, conn io.ReadWriteCloser, vol
 	return nil
 }
 
-// RefreshVolume updates an existing volume to match the state of another.
+// RefreshVolume updates an existing volume to match the state of another. When the latest snapshot of the volume
+// also exists on the source, only the changes made since that snapshot are sent using incremental streams,
+// otherwise the volumes are synced with rsync.
 func (d *zfs) RefreshVolume(vol Volume, srcVol Volume, srcSnapshots []Volume, op *operations.Operation) error {
-	return genericVFSCopyVolume(d, nil, vol, srcVol, srcSnapshots, true, op)
+	// Collect the datasets to refresh, VMs also have a filesystem dataset.
+	vols := []Volume{vol}
+	srcVols := []Volume{srcVol}
+	if vol.volType == VolumeTypeVM && vol.contentType == ContentTypeBlock {
+		vols = append(vols, NewVolume(d, d.name, vol.volType, ContentTypeFS, vol.name, vol.config, vol.poolConfig))
+		srcVols = append(srcVols, NewVolume(d, d.name, srcVol.volType, ContentTypeFS, srcVol.name, srcVol.config, srcVol.poolConfig))
+	}
+
+	snapshotNames := []string{}
+	for _, srcSnapshot := range srcSnapshots {
+		_, snapName, _ := shared.InstanceGetParentAndSnapshotName(srcSnapshot.name)
+		snapshotNames = append(snapshotNames, snapName)
+	}
+
+	bases := []string{}
+	for i := range vols {
+		base, err := d.incrementalRefreshBase(vols[i], srcVols[i], snapshotNames)
+		if err != nil {
+			return err
+		}
+
+		if base == "" {
+			d.logger.Debug("No common snapshot with the source, refreshing with rsync", log.Ctx{"volume": vol.name, "source": srcVol.name})
+			return genericVFSCopyVolume(d, nil, vol, srcVol, srcSnapshots, true, op)
+		}
+
+		bases = append(bases, base)
+	}
+
+	for i := range vols {
+		err := d.refreshDatasetIncremental(op.Context(), vols[i], srcVols[i], bases[i], snapshotNames)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// incrementalRefreshBase returns the latest snapshot of the volume if it's also a snapshot of the source volume
+// preceding all the snapshots to copy, which allows refreshing the volume from incremental streams. An empty
+// string is returned otherwise.
+func (d *zfs) incrementalRefreshBase(vol Volume, srcVol Volume, snapshotNames []string) (string, error) {
+	snapshots, err := d.VolumeSnapshots(vol, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if len(snapshots) == 0 {
+		return "", nil
+	}
+
+	base := snapshots[len(snapshots)-1]
+
+	srcSnapshots, err := d.VolumeSnapshots(srcVol, nil)
+	if err != nil {
+		return "", err
+	}
+
+	idx := -1
+	for i, name := range srcSnapshots {
+		if name == base {
+			idx = i
+			break
+		}
+	}
+
+	if idx < 0 {
+		return "", nil
+	}
+
+	// All the snapshots to copy must be more recent than the base.
+	for _, name := range snapshotNames {
+		if !shared.StringInSlice(name, srcSnapshots[idx+1:]) {
+			return "", nil
+		}
+	}
+
+	// Both snapshots must hold the same data, which is the case if one was received from the other.
+	guid, err := d.getDatasetProperty(fmt.Sprintf("%s@snapshot-%s", d.dataset(vol, false), base), "guid")
+	if err != nil {
+		return "", err
+	}
+
+	srcGUID, err := d.getDatasetProperty(fmt.Sprintf("%s@snapshot-%s", d.dataset(srcVol, false), base), "guid")
+	if err != nil {
+		return "", err
+	}
+
+	if guid != srcGUID {
+		return "", nil
+	}
+
+	return base, nil
+}
+
+// refreshDatasetIncremental brings the dataset of the volume up to date with the source one, sending the
+// requested snapshots and then the current state of the source, each as an incremental stream from the previous
+// one.
+func (d *zfs) refreshDatasetIncremental(ctx context.Context, vol Volume, srcVol Volume, base string, snapshotNames []string) error {
+	srcDataset := d.dataset(srcVol, false)
+	dataset := d.dataset(vol, false)
+
+	srcSnapshots, err := d.VolumeSnapshots(srcVol, nil)
+	if err != nil {
+		return err
+	}
+
+	// Send the snapshots in the order they were taken.
+	parent := fmt.Sprintf("%s@snapshot-%s", srcDataset, base)
+	for _, name := range srcSnapshots {
+		if !shared.StringInSlice(name, snapshotNames) {
+			continue
+		}
+
+		snapshot := fmt.Sprintf("%s@snapshot-%s", srcDataset, name)
+		err = d.sendIncremental(ctx, parent, snapshot, dataset)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to refresh snapshot %q", name)
+		}
+
+		parent = snapshot
+	}
+
+	// Then send the current state of the source through a temporary snapshot.
+	copyName := fmt.Sprintf("copy-%s", uuid.NewRandom().String())
+	_, err = shared.RunCommand("zfs", "snapshot", fmt.Sprintf("%s@%s", srcDataset, copyName))
+	if err != nil {
+		return err
+	}
+	defer shared.RunCommand("zfs", "destroy", fmt.Sprintf("%s@%s", srcDataset, copyName))
+
+	err = d.sendIncremental(ctx, parent, fmt.Sprintf("%s@%s", srcDataset, copyName), dataset)
+	if err != nil {
+		return errors.Wrap(err, "Failed to refresh volume")
+	}
+
+	_, err = shared.RunCommand("zfs", "destroy", fmt.Sprintf("%s@%s", dataset, copyName))
+	if err != nil {
+		return err
+	}
+
+	return nil
 }
 
 // DeleteVolume deletes a volume of the storage device. If any snapshots of the volume remain then
@@ -1285,12 +1436,12 @@ func (d *zfs) MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *mig
 }
 
 // BackupVolume creates an exported version of a volume.
-func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
+func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, incrementalFrom string, op *operations.Operation) error {
 	// Handle the non-optimized tarballs through the generic packer.
 	if !optimized {
 		// For block volumes that are exporting snapshots, we need to activate parent volume first so that
 		// the snapshot volumes can have their devices accessible.
-		if vol.contentType == ContentTypeBlock && snapshots {
+		if vol.contentType == ContentTypeBlock && len(snapshots) > 0 {
 			parent, _, _ := shared.InstanceGetParentAndSnapshotName(vol.Name())
 			parentVol := NewVolume(d, d.Name(), vol.volType, vol.contentType, parent, vol.config, vol.poolConfig)
 			ourMount, err := d.MountVolume(parentVol, op)
@@ -1358,7 +1509,7 @@ func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWrit
 	// Backup VM config volumes first.
 	if vol.IsVMBlock() {
 		fsVol := vol.NewVMBlockFilesystemVolume()
-		err := d.BackupVolume(fsVol, tarWriter, optimized, snapshots, op)
+		err := d.BackupVolume(fsVol, tarWriter, optimized, snapshots, incrementalFrom, op)
 		if err != nil {
 			return err
 		}
@@ -1386,7 +1537,7 @@ func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWrit
 		d.logger.Debug("Generating optimized volume file", log.Ctx{"sourcePath": path, "file": tmpFile.Name(), "name": fileName})
 
 		// Write the subvolume to the file.
-		err = shared.RunCommandWithFds(nil, tmpFile, "zfs", args...)
+		err = shared.RunCommandWithFdsContext(op.Context(), nil, tmpFile, "zfs", args...)
 		if err != nil {
 			return err
 		}
@@ -1406,41 +1557,27 @@ func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWrit
 	}
 
 	// Handle snapshots.
-	finalParent := ""
-	if snapshots {
-		// Retrieve the snapshots.
-		volSnapshots, err := d.VolumeSnapshots(vol, op)
-		if err != nil {
-			return err
-		}
+	parents, finalParent := d.backupParents(vol, snapshots, incrementalFrom)
+	for i, snapName := range snapshots {
+		snapshot, _ := vol.NewSnapshot(snapName)
 
-		for i, snapName := range volSnapshots {
-			snapshot, _ := vol.NewSnapshot(snapName)
-
-			// Figure out parent and current subvolumes.
-			parent := ""
-			if i > 0 {
-				oldSnapshot, _ := vol.NewSnapshot(volSnapshots[i-1])
-				parent = d.dataset(oldSnapshot, false)
-			}
-
-			// Make a binary zfs backup.
-			prefix := "snapshots"
-			fileName := fmt.Sprintf("%s.bin", snapName)
-			if vol.volType == VolumeTypeVM {
-				prefix = "virtual-machine-snapshots"
-				if vol.contentType == ContentTypeFS {
-					fileName = fmt.Sprintf("%s-config.bin", snapName)
-				}
-			}
+		// Send the differences from the previous snapshot (or incremental base) if any.
+		parent := parents[i]
 
-			target := fmt.Sprintf("backup/%s/%s", prefix, fileName)
-			err := sendToFile(d.dataset(snapshot, false), parent, target)
-			if err != nil {
-				return err
+		// Make a binary zfs backup.
+		prefix := "snapshots"
+		fileName := fmt.Sprintf("%s.bin", snapName)
+		if vol.volType == VolumeTypeVM {
+			prefix = "virtual-machine-snapshots"
+			if vol.contentType == ContentTypeFS {
+				fileName = fmt.Sprintf("%s-config.bin", snapName)
 			}
+		}
 
-			finalParent = d.dataset(snapshot, false)
+		target := fmt.Sprintf("backup/%s/%s", prefix, fileName)
+		err := sendToFile(d.dataset(snapshot, false), parent, target)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -449,7 +449,7 @@ func genericVFSGetVolumeDiskPath(vol Volume) (string, error) {
 }
 
 // genericVFSBackupVolume is a generic BackupVolume implementation for VFS-only drivers.
-func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.InstanceTarWriter, snapshots bool, op *operations.Operation) error {
+func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.InstanceTarWriter, snapshots []string, op *operations.Operation) error {
 	// Define a function that can copy a volume into the backup target location.
 	backupVolume := func(v Volume, prefix string) error {
 		return v.MountTask(func(mountPath string, op *operations.Operation) error {
@@ -483,6 +483,12 @@ func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.Inst
 						return err
 					}
 
+					// Stop if the backup got cancelled.
+					err = op.Context().Err()
+					if err != nil {
+						return err
+					}
+
 					// Skip any exluded files.
 					if shared.StringInSlice(srcPath, exclude) {
 						return nil
@@ -515,7 +521,7 @@ func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.Inst
 					FileModTime: time.Now(),
 				}
 
-				err = tarWriter.WriteFileFromReader(from, &fi)
+				err = tarWriter.WriteFileFromReader(&contextReader{ctx: op.Context(), reader: from}, &fi)
 				if err != nil {
 					return errors.Wrapf(err, "Error copying %q as %q to tarball", blockPath, name)
 				}
@@ -531,6 +537,12 @@ func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.Inst
 						return errors.Wrapf(err, "Error walking file during export: %q", srcPath)
 					}
 
+					// Stop if the backup got cancelled.
+					err = op.Context().Err()
+					if err != nil {
+						return err
+					}
+
 					name := filepath.Join(prefix, strings.TrimPrefix(srcPath, mountPath))
 
 					// Write the file to the tarball with ignoreGrowth enabled so that if the
@@ -550,22 +562,20 @@ func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.Inst
 	}
 
 	// Handle snapshots.
-	if snapshots {
+	if len(snapshots) > 0 {
 		snapshotsPrefix := "backup/snapshots"
 		if vol.IsVMBlock() {
 			snapshotsPrefix = "backup/virtual-machine-snapshots"
 		}
 
-		// List the snapshots.
-		snapshots, err := vol.Snapshots(op)
-		if err != nil {
-			return err
-		}
+		for _, snapName := range snapshots {
+			snapshot, err := vol.NewSnapshot(snapName)
+			if err != nil {
+				return err
+			}
 
-		for _, snapshot := range snapshots {
-			_, snapName, _ := shared.InstanceGetParentAndSnapshotName(snapshot.Name())
 			prefix := filepath.Join(snapshotsPrefix, snapName)
-			err := backupVolume(snapshot, prefix)
+			err = backupVolume(snapshot, prefix)
 			if err != nil {
 				return err
 			}
@@ -616,7 +626,7 @@ func genericVFSBackupUnpack(d Driver, vol Volume, snapshots []string, srcData io
 		// Extract filesystem volume.
 		d.Logger().Debug(fmt.Sprintf("Unpacking %s filesystem volume", volTypeName), log.Ctx{"source": srcPrefix, "target": mountPath})
 		srcData.Seek(0, 0)
-		err = shared.RunCommandWithFds(r, nil, "tar", args...)
+		err = shared.RunCommandWithFdsContext(op.Context(), r, nil, "tar", args...)
 		if err != nil {
 			return errors.Wrapf(err, "Error starting unpack")
 		}
@@ -809,7 +819,7 @@ func genericVFSCopyVolume(d Driver, initVolume func(vol Volume) (func(), error),
 				// Mount the source snapshot.
 				err := srcSnapshot.MountTask(func(srcMountPath string, op *operations.Operation) error {
 					// Copy the snapshot.
-					_, err := rsync.LocalCopy(srcMountPath, mountPath, bwlimit, true)
+					_, err := rsync.LocalCopyContext(op.Context(), srcMountPath, mountPath, bwlimit, true)
 					if err != nil {
 						return err
 					}
@@ -825,7 +835,7 @@ func genericVFSCopyVolume(d Driver, initVolume func(vol Volume) (func(), error),
 							return err
 						}
 
-						err = copyDevice(srcDevPath, targetDevPath)
+						err = copyDevice(op.Context(), srcDevPath, targetDevPath)
 						if err != nil {
 							return err
 						}
@@ -864,7 +874,7 @@ func genericVFSCopyVolume(d Driver, initVolume func(vol Volume) (func(), error),
 
 		// Copy source to destination (mounting each volume if needed).
 		err := srcVol.MountTask(func(srcMountPath string, op *operations.Operation) error {
-			_, err := rsync.LocalCopy(srcMountPath, mountPath, bwlimit, true)
+			_, err := rsync.LocalCopyContext(op.Context(), srcMountPath, mountPath, bwlimit, true)
 			if err != nil {
 				return err
 			}
@@ -880,7 +890,7 @@ func genericVFSCopyVolume(d Driver, initVolume func(vol Volume) (func(), error),
 					return err
 				}
 
-				err = copyDevice(srcDevPath, targetDevPath)
+				err = copyDevice(op.Context(), srcDevPath, targetDevPath)
 				if err != nil {
 					return err
 				}
@@ -1,6 +1,7 @@
 package drivers
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -595,7 +596,7 @@ func regenerateFilesystemXFSUUID(devPath string) error {
 }
 
 // copyDevice copies one device path to another.
-func copyDevice(inputPath, outputPath string) error {
+func copyDevice(ctx context.Context, inputPath, outputPath string) error {
 	from, err := os.Open(inputPath)
 	if err != nil {
 		return errors.Wrapf(err, "Error opening file for reading %q", inputPath)
@@ -608,7 +609,7 @@ func copyDevice(inputPath, outputPath string) error {
 	}
 	defer to.Close()
 
-	_, err = io.Copy(to, from)
+	_, err = io.Copy(to, &contextReader{ctx: ctx, reader: from})
 	if err != nil {
 		return errors.Wrapf(err, "Error copying file %q to %q", inputPath, outputPath)
 	}
@@ -616,6 +617,21 @@ func copyDevice(inputPath, outputPath string) error {
 	return nil
 }
 
+// contextReader fails the reads once its context is done, to interrupt long copies.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	err := r.ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	return r.reader.Read(p)
+}
+
 // loopFilePath returns the loop file path for a storage pool.
 func loopFilePath(poolName string) string {
 	return filepath.Join(shared.VarPath("disks"), fmt.Sprintf("%s.img", poolName))
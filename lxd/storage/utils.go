@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
-	"gopkg.in/robfig/cron.v2"
 
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/instance"
@@ -442,23 +441,8 @@ func validateVolumeCommonRules(vol drivers.Volume) map[string]func(string) error
 			_, err := shared.GetSnapshotExpiry(time.Time{}, value)
 			return err
 		},
-		"snapshots.schedule": func(value string) error {
-			if value == "" {
-				return nil
-			}
-
-			if len(strings.Split(value, " ")) != 5 {
-				return fmt.Errorf("Schedule must be of the form: <minute> <hour> <day-of-month> <month> <day-of-week>")
-			}
-
-			_, err := cron.Parse(fmt.Sprintf("* %s", value))
-			if err != nil {
-				return errors.Wrap(err, "Error parsing schedule")
-			}
-
-			return nil
-		},
-		"snapshots.pattern": validate.IsAny,
+		"snapshots.schedule": shared.IsSchedule,
+		"snapshots.pattern":  validate.IsAny,
 	}
 
 	// block.mount_options is only relevant for drivers that are block backed and when there
@@ -475,6 +459,20 @@ func validateVolumeCommonRules(vol drivers.Volume) map[string]func(string) error
 	if vol.Type() == drivers.VolumeTypeCustom {
 		rules["security.shifted"] = validate.Optional(validate.IsBool)
 		rules["security.unmapped"] = validate.Optional(validate.IsBool)
+
+		// Scheduled backups are only available for custom volumes.
+		rules["backups.optimized_storage"] = validate.Optional(validate.IsBool)
+		rules["backups.retention"] = validate.Optional(validate.IsUint32)
+		rules["backups.schedule"] = shared.IsSchedule
+		rules["backups.target"] = func(value string) error {
+			return validate.IsOneOf(value, []string{"local", "s3"})
+		}
+
+		// security.shared is only relevant for block custom volumes, filesystem volumes can always be
+		// attached to multiple instances.
+		if vol.ContentType() == drivers.ContentTypeBlock {
+			rules["security.shared"] = validate.Optional(validate.IsBool)
+		}
 	}
 
 	// volatile.rootfs.size is only used for image volumes.
@@ -13,6 +13,9 @@ import (
 	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
 	storagePools "github.com/lxc/lxd/lxd/storage"
 	"github.com/lxc/lxd/lxd/util"
@@ -77,10 +80,93 @@ func storagePoolsGet(d *Daemon, r *http.Request) response.Response {
 	}
 
 	if !recursion {
-		return response.SyncResponse(true, resultString)
+		return response.SyncResponseConditional(r, resultString)
 	}
 
-	return response.SyncResponse(true, resultMap)
+	if r.FormValue("recursion") == "2" {
+		resultFull, err := storagePoolsGetFull(d, r, resultMap)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return response.SyncResponseConditional(r, resultFull)
+	}
+
+	return response.SyncResponseConditional(r, resultMap)
+}
+
+// storagePoolsGetFull returns the storage pools along with their resources. Unless the request comes from
+// another cluster member, the resources of all the cluster members are gathered.
+func storagePoolsGetFull(d *Daemon, r *http.Request, pools []api.StoragePool) ([]api.StoragePoolFull, error) {
+	result := make([]api.StoragePoolFull, 0, len(pools))
+	remote := map[string]bool{}
+	indices := map[string]int{}
+	for _, pl := range pools {
+		entry := api.StoragePoolFull{StoragePool: pl}
+
+		// Pools which aren't available on this member have no resources.
+		pool, err := storagePools.GetPoolByName(d.State(), pl.Name)
+		if err == nil {
+			remote[pl.Name] = pool.Driver().Info().Remote
+
+			res, err := pool.GetResources()
+			if err == nil {
+				entry.Resources = res
+			}
+		}
+
+		indices[pl.Name] = len(result)
+		result = append(result, entry)
+	}
+
+	if isClusterNotification(r) {
+		return result, nil
+	}
+
+	notifier, err := cluster.NewNotifier(d.State(), d.endpoints.NetworkCert(), cluster.NotifyAlive)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	err = notifier(func(client lxd.InstanceServer) error {
+		memberPools, err := client.UseProject(projectParam(r)).GetStoragePoolsFull()
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, memberPool := range memberPools {
+			i, ok := indices[memberPool.Name]
+			if !ok || memberPool.Resources == nil {
+				continue
+			}
+
+			// Remote pools are backed by the same storage on all members.
+			if result[i].Resources == nil {
+				result[i].Resources = memberPool.Resources
+				continue
+			}
+
+			if remote[memberPool.Name] {
+				continue
+			}
+
+			result[i].Resources.Space.Used += memberPool.Resources.Space.Used
+			result[i].Resources.Space.Total += memberPool.Resources.Space.Total
+			result[i].Resources.Inodes.Used += memberPool.Resources.Inodes.Used
+			result[i].Resources.Inodes.Total += memberPool.Resources.Inodes.Total
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
 // /1.0/storage-pools
@@ -157,6 +243,9 @@ func storagePoolsPost(d *Daemon, r *http.Request) response.Response {
 				if err != nil {
 					return response.InternalError(err)
 				}
+
+				d.State().Events.SendLifecycle(project.Default, lifecycle.StoragePoolCreated.Event(req.Name, request.CreateRequestor(r), nil))
+
 				return resp
 			}
 		}
@@ -168,6 +257,8 @@ func storagePoolsPost(d *Daemon, r *http.Request) response.Response {
 			return response.InternalError(err)
 		}
 
+		d.State().Events.SendLifecycle(project.Default, lifecycle.StoragePoolCreated.Event(req.Name, request.CreateRequestor(r), nil))
+
 		return resp
 	}
 
@@ -345,7 +436,7 @@ func storagePoolGet(d *Daemon, r *http.Request) response.Response {
 
 	etag := []interface{}{pool.Name, pool.Driver, pool.Config}
 
-	return response.SyncResponseETag(true, &pool, etag)
+	return response.SyncResponseETagConditional(r, &pool, etag)
 }
 
 // /1.0/storage-pools/{name}
@@ -420,6 +511,10 @@ func storagePoolPut(d *Daemon, r *http.Request) response.Response {
 		return response.InternalError(err)
 	}
 
+	if withDB {
+		d.State().Events.SendLifecycle(project.Default, lifecycle.StoragePoolUpdated.Event(poolName, request.CreateRequestor(r), nil))
+	}
+
 	return response.EmptySyncResponse
 }
 
@@ -507,6 +602,10 @@ func storagePoolPatch(d *Daemon, r *http.Request) response.Response {
 		return response.InternalError(err)
 	}
 
+	if withDB {
+		d.State().Events.SendLifecycle(project.Default, lifecycle.StoragePoolUpdated.Event(poolName, request.CreateRequestor(r), nil))
+	}
+
 	return response.EmptySyncResponse
 }
 
@@ -662,5 +761,7 @@ func storagePoolDelete(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	d.State().Events.SendLifecycle(project.Default, lifecycle.StoragePoolDeleted.Event(poolName, request.CreateRequestor(r), nil))
+
 	return response.EmptySyncResponse
 }
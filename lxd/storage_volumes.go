@@ -11,16 +11,23 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/state"
 	storagePools "github.com/lxc/lxd/lxd/storage"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/filter"
 	log "github.com/lxc/lxd/shared/log15"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/version"
@@ -80,6 +87,21 @@ var storagePoolVolumeTypeImageCmd = APIEndpoint{
 	Put:    APIEndpointAction{Handler: storagePoolVolumeTypeImagePut, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
 }
 
+// storagePoolVolumesFilter parses the filter of a storage volume listing request, returning nil if none.
+func storagePoolVolumesFilter(r *http.Request) ([]filter.Clause, error) {
+	filterStr := r.FormValue("filter")
+	if filterStr == "" {
+		return nil, nil
+	}
+
+	clauses, err := filter.Parse(filterStr)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid filter")
+	}
+
+	return clauses, nil
+}
+
 // /1.0/storage-pools/{name}/volumes
 // List all storage volumes attached to a given storage pool.
 func storagePoolVolumesGet(d *Daemon, r *http.Request) response.Response {
@@ -89,6 +111,11 @@ func storagePoolVolumesGet(d *Daemon, r *http.Request) response.Response {
 
 	recursion := util.IsRecursionRequest(r)
 
+	clauses, err := storagePoolVolumesFilter(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	// Retrieve ID of the storage pool (and check if the storage pool exists).
 	poolID, err := d.cluster.GetStoragePoolID(poolName)
 	if err != nil {
@@ -137,6 +164,17 @@ func storagePoolVolumesGet(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
+	if clauses != nil {
+		filtered := volumes[:0]
+		for _, volume := range volumes {
+			if filter.Match(*volume, clauses) {
+				filtered = append(filtered, volume)
+			}
+		}
+
+		volumes = filtered
+	}
+
 	resultString := []string{}
 	for _, volume := range volumes {
 		apiEndpoint, err := storagePoolVolumeTypeNameToAPIEndpoint(volume.Type)
@@ -185,10 +223,10 @@ func storagePoolVolumesGet(d *Daemon, r *http.Request) response.Response {
 	}
 
 	if !recursion {
-		return response.SyncResponse(true, resultString)
+		return response.SyncResponseConditional(r, resultString)
 	}
 
-	return response.SyncResponse(true, volumes)
+	return response.SyncResponseConditional(r, volumes)
 }
 
 // /1.0/storage-pools/{name}/volumes/{type}
@@ -202,6 +240,11 @@ func storagePoolVolumesTypeGet(d *Daemon, r *http.Request) response.Response {
 
 	recursion := util.IsRecursionRequest(r)
 
+	clauses, err := storagePoolVolumesFilter(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	// Convert the volume type name to our internal integer representation.
 	volumeType, err := storagePools.VolumeTypeNameToType(volumeTypeName)
 	if err != nil {
@@ -233,6 +276,18 @@ func storagePoolVolumesTypeGet(d *Daemon, r *http.Request) response.Response {
 	resultString := []string{}
 	resultMap := []*api.StorageVolume{}
 	for _, volume := range volumes {
+		var vol *api.StorageVolume
+		if recursion || clauses != nil {
+			_, vol, err = d.cluster.GetLocalStoragePoolVolume(projectName, volume, volumeType, poolID)
+			if err != nil {
+				continue
+			}
+
+			if clauses != nil && !filter.Match(*vol, clauses) {
+				continue
+			}
+		}
+
 		if !recursion {
 			apiEndpoint, err := storagePoolVolumeTypeToAPIEndpoint(volumeType)
 			if err != nil {
@@ -249,11 +304,6 @@ func storagePoolVolumesTypeGet(d *Daemon, r *http.Request) response.Response {
 
 			resultString = append(resultString, fmt.Sprintf("/%s/storage-pools/%s/volumes/%s/%s", version.APIVersion, poolName, apiEndpoint, volume))
 		} else {
-			_, vol, err := d.cluster.GetLocalStoragePoolVolume(projectName, volume, volumeType, poolID)
-			if err != nil {
-				continue
-			}
-
 			volumeUsedBy, err := storagePoolVolumeUsedByGet(d.State(), projectName, poolName, vol.Name, vol.Type)
 			if err != nil {
 				return response.SmartError(err)
@@ -265,10 +315,10 @@ func storagePoolVolumesTypeGet(d *Daemon, r *http.Request) response.Response {
 	}
 
 	if !recursion {
-		return response.SyncResponse(true, resultString)
+		return response.SyncResponseConditional(r, resultString)
 	}
 
-	return response.SyncResponse(true, resultMap)
+	return response.SyncResponseConditional(r, resultMap)
 }
 
 // /1.0/storage-pools/{name}/volumes/{type}
@@ -279,6 +329,20 @@ func storagePoolVolumesTypePost(d *Daemon, r *http.Request) response.Response {
 		return resp
 	}
 
+	// If we're getting binary content, process separately.
+	if r.Header.Get("Content-Type") == "application/octet-stream" {
+		if mux.Vars(r)["type"] != db.StoragePoolVolumeTypeNameCustom {
+			return response.BadRequest(fmt.Errorf("Only custom volumes can be created from a backup"))
+		}
+
+		projectName, err := project.StorageVolumeProject(d.State().Cluster, projectParam(r), db.StoragePoolVolumeTypeCustom)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return storagePoolVolumeCreateFromBackup(d, r, projectName, mux.Vars(r)["name"])
+	}
+
 	req := api.StorageVolumesPost{}
 
 	// Parse the request.
@@ -336,7 +400,7 @@ func storagePoolVolumesTypePost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
-		return project.AllowVolumeCreation(tx, projectName, req)
+		return project.AllowVolumeCreation(tx, projectName, poolName, req)
 	})
 	if err != nil {
 		return response.SmartError(err)
@@ -344,9 +408,9 @@ func storagePoolVolumesTypePost(d *Daemon, r *http.Request) response.Response {
 
 	switch req.Source.Type {
 	case "":
-		return doVolumeCreateOrCopy(d, projectName, poolName, &req)
+		return doVolumeCreateOrCopy(d, r, projectName, poolName, &req)
 	case "copy":
-		return doVolumeCreateOrCopy(d, projectName, poolName, &req)
+		return doVolumeCreateOrCopy(d, r, projectName, poolName, &req)
 	case "migration":
 		return doVolumeMigration(d, projectName, poolName, &req)
 	default:
@@ -354,7 +418,7 @@ func storagePoolVolumesTypePost(d *Daemon, r *http.Request) response.Response {
 	}
 }
 
-func doVolumeCreateOrCopy(d *Daemon, projectName, poolName string, req *api.StorageVolumesPost) response.Response {
+func doVolumeCreateOrCopy(d *Daemon, r *http.Request, projectName, poolName string, req *api.StorageVolumesPost) response.Response {
 	var run func(op *operations.Operation) error
 
 	pool, err := storagePools.GetPoolByName(d.State(), poolName)
@@ -372,12 +436,21 @@ func doVolumeCreateOrCopy(d *Daemon, projectName, poolName string, req *api.Stor
 		return response.SmartError(err)
 	}
 
+	requestor := request.CreateRequestor(r)
+
 	run = func(op *operations.Operation) error {
 		if req.Source.Name == "" {
-			return pool.CreateCustomVolume(projectName, req.Name, req.Description, req.Config, contentType, op)
+			err = pool.CreateCustomVolume(projectName, req.Name, req.Description, req.Config, contentType, op)
+		} else {
+			err = pool.CreateCustomVolumeFromCopy(projectName, req.Name, req.Description, req.Config, req.Source.Pool, req.Source.Name, req.Source.VolumeOnly, op)
+		}
+		if err != nil {
+			return err
 		}
 
-		return pool.CreateCustomVolumeFromCopy(projectName, req.Name, req.Description, req.Config, req.Source.Pool, req.Source.Name, req.Source.VolumeOnly, op)
+		d.State().Events.SendLifecycle(projectName, lifecycle.StorageVolumeCreated.Event(poolName, db.StoragePoolVolumeTypeNameCustom, req.Name, requestor, nil))
+
+		return nil
 	}
 
 	// If no source name supplied then this a volume create operation.
@@ -391,11 +464,13 @@ func doVolumeCreateOrCopy(d *Daemon, projectName, poolName string, req *api.Stor
 	}
 
 	// Volume copy operations potentially take a long time, so run as an async operation.
-	op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationVolumeCopy, nil, nil, run, nil, nil)
+	op, err := operations.OperationCreate(d.State(), projectParam(r), operations.OperationClassTask, db.OperationVolumeCopy, nil, nil, run, nil, nil)
 	if err != nil {
 		return response.InternalError(err)
 	}
 
+	op.SetCancelable()
+
 	return operations.OperationResponse(op)
 }
 
@@ -459,9 +534,9 @@ func storagePoolVolumesPost(d *Daemon, r *http.Request) response.Response {
 
 	switch req.Source.Type {
 	case "":
-		return doVolumeCreateOrCopy(d, projectName, poolName, &req)
+		return doVolumeCreateOrCopy(d, r, projectName, poolName, &req)
 	case "copy":
-		return doVolumeCreateOrCopy(d, projectName, poolName, &req)
+		return doVolumeCreateOrCopy(d, r, projectName, poolName, &req)
 	case "migration":
 		return doVolumeMigration(d, projectName, poolName, &req)
 	default:
@@ -534,15 +609,19 @@ func doVolumeMigration(d *Daemon, projectName string, poolName string, req *api.
 
 	var op *operations.Operation
 	if push {
-		op, err = operations.OperationCreate(d.State(), "", operations.OperationClassWebsocket, db.OperationVolumeCreate, resources, sink.Metadata(), run, nil, sink.Connect)
+		op, err = operations.OperationCreate(d.State(), projectName, operations.OperationClassWebsocket, db.OperationVolumeCreate, resources, sink.Metadata(), run, nil, sink.Connect)
 		if err != nil {
 			return response.InternalError(err)
 		}
+
+		op.SetCancelable()
 	} else {
-		op, err = operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationVolumeCopy, resources, nil, run, nil, nil)
+		op, err = operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationVolumeCopy, resources, nil, run, nil, nil)
 		if err != nil {
 			return response.InternalError(err)
 		}
+
+		op.SetCancelable()
 	}
 
 	return operations.OperationResponse(op)
@@ -611,6 +690,20 @@ func storagePoolVolumeTypePost(d *Daemon, r *http.Request, volumeTypeName string
 	}
 	r.Body = shared.BytesReadCloser{Buf: &buf}
 
+	// A "?target=<member>" naming a member which doesn't have the volume requests to move the volume
+	// to that member. Such requests are handled by the member the volume is currently on.
+	targetNode := queryParam(r, "target")
+	if targetNode != "" && !req.Migration {
+		resp, err := storagePoolVolumeTypePostClusteringMove(d, r, projectName, poolName, volumeName, targetNode, req)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		if resp != nil {
+			return resp
+		}
+	}
+
 	resp := forwardedResponseIfTargetIsRemote(d, r)
 	if resp != nil {
 		return resp
@@ -664,7 +757,7 @@ func storagePoolVolumeTypePost(d *Daemon, r *http.Request, volumeTypeName string
 
 	// Detect a rename request.
 	if req.Pool == "" || req.Pool == poolName {
-		return storagePoolVolumeTypePostRename(d, projectName, poolName, volumeName, volumeType, req)
+		return storagePoolVolumeTypePostRename(d, r, projectName, poolName, volumeName, volumeType, req)
 	}
 
 	// Otherwise this is a move request.
@@ -692,25 +785,29 @@ func storagePoolVolumeTypePostMigration(state *state.State, projectName, poolNam
 			return response.InternalError(err)
 		}
 
-		op, err := operations.OperationCreate(state, "", operations.OperationClassTask, db.OperationVolumeMigrate, resources, nil, run, nil, nil)
+		op, err := operations.OperationCreate(state, projectName, operations.OperationClassTask, db.OperationVolumeMigrate, resources, nil, run, nil, nil)
 		if err != nil {
 			return response.InternalError(err)
 		}
 
+		op.SetCancelable()
+
 		return operations.OperationResponse(op)
 	}
 
 	// Pull mode
-	op, err := operations.OperationCreate(state, "", operations.OperationClassWebsocket, db.OperationVolumeMigrate, resources, ws.Metadata(), run, nil, ws.Connect)
+	op, err := operations.OperationCreate(state, projectName, operations.OperationClassWebsocket, db.OperationVolumeMigrate, resources, ws.Metadata(), run, nil, ws.Connect)
 	if err != nil {
 		return response.InternalError(err)
 	}
 
+	op.SetCancelable()
+
 	return operations.OperationResponse(op)
 }
 
 // storagePoolVolumeTypePostRename handles volume rename type POST requests.
-func storagePoolVolumeTypePostRename(d *Daemon, projectName, poolName, volumeName string, volumeType int, req api.StorageVolumePost) response.Response {
+func storagePoolVolumeTypePostRename(d *Daemon, r *http.Request, projectName, poolName, volumeName string, volumeType int, req api.StorageVolumePost) response.Response {
 	// Notify users of the volume that it's name is changing.
 	err := storagePoolVolumeUpdateUsers(d, projectName, poolName, volumeName, req.Pool, req.Name)
 	if err != nil {
@@ -729,6 +826,8 @@ func storagePoolVolumeTypePostRename(d *Daemon, projectName, poolName, volumeNam
 		return response.SmartError(err)
 	}
 
+	d.State().Events.SendLifecycle(projectName, lifecycle.StorageVolumeRenamed.Event(poolName, db.StoragePoolVolumeTypeNameCustom, req.Name, request.CreateRequestor(r), map[string]interface{}{"old_name": volumeName}))
+
 	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/storage-pools/%s/volumes/%s", version.APIVersion, poolName, storagePoolVolumeAPIEndpointCustom))
 }
 
@@ -765,7 +864,7 @@ func storagePoolVolumeTypePostMove(d *Daemon, projectName, poolName, volumeName
 		return srcPool.DeleteCustomVolume(projectName, volumeName, op)
 	}
 
-	op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationVolumeMove, nil, nil, run, nil, nil)
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationVolumeMove, nil, nil, run, nil, nil)
 	if err != nil {
 		return response.InternalError(err)
 	}
@@ -773,6 +872,145 @@ func storagePoolVolumeTypePostMove(d *Daemon, projectName, poolName, volumeName
 	return operations.OperationResponse(op)
 }
 
+// storagePoolVolumeTypePostClusteringMove moves a custom volume to the given cluster member, optionally into
+// another pool and under another name, if the volume isn't already on that member. The request is forwarded to
+// the member the volume is on if needed. A nil response means the volume is already on the target member (for
+// instance if it's on shared storage) and the request should be handled as a regular rename or move there.
+func storagePoolVolumeTypePostClusteringMove(d *Daemon, r *http.Request, projectName, poolName, volumeName, targetNode string, req api.StorageVolumePost) (response.Response, error) {
+	poolID, err := d.cluster.GetStoragePoolID(poolName)
+	if err != nil {
+		return nil, err
+	}
+
+	targetAddress, err := cluster.ResolveTarget(d.cluster, targetNode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the members the volume is on.
+	var addresses []string
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		addresses, err = tx.GetStorageVolumeNodeAddresses(poolID, projectName, volumeName, db.StoragePoolVolumeTypeCustom)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if shared.StringInSlice(targetAddress, addresses) {
+		return nil, nil
+	}
+
+	if len(addresses) > 1 {
+		return response.BadRequest(fmt.Errorf("More than one cluster member has a volume named %q", volumeName)), nil
+	}
+
+	cert := d.endpoints.NetworkCert()
+
+	// Forward the request to the member the volume is on.
+	if addresses[0] != "" {
+		client, err := cluster.Connect(addresses[0], cert, false)
+		if err != nil {
+			return nil, err
+		}
+
+		return response.ForwardedResponse(client, r), nil
+	}
+
+	// Check if the daemon itself is using it.
+	used, err := storagePools.VolumeUsedByDaemon(d.State(), poolName, volumeName)
+	if err != nil {
+		return nil, err
+	}
+
+	if used {
+		return response.BadRequest(fmt.Errorf("Volume is used by LXD itself and cannot be moved")), nil
+	}
+
+	// The volume isn't on shared storage so it can't stay attached to running instances while moving.
+	instsUsingVolume, err := storagePools.VolumeUsedByRunningInstancesWithProfilesGet(d.State(), projectName, poolName, volumeName, db.StoragePoolVolumeTypeNameCustom, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(instsUsingVolume) > 0 {
+		return response.BadRequest(fmt.Errorf("Volume is still in use by running instances")), nil
+	}
+
+	srcPool, err := storagePools.GetPoolByName(d.State(), poolName)
+	if err != nil {
+		return nil, err
+	}
+
+	_, vol, err := d.cluster.GetLocalStoragePoolVolume(projectName, volumeName, db.StoragePoolVolumeTypeCustom, srcPool.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	dstPoolName := req.Pool
+	if dstPoolName == "" {
+		dstPoolName = poolName
+	}
+
+	var sourceAddress string
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		sourceAddress, err = tx.GetLocalNodeAddress()
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get local member address")
+	}
+
+	requestProject := projectParam(r)
+
+	run := func(op *operations.Operation) error {
+		// Connect to the source member, i.e. ourselves.
+		source, err := cluster.Connect(sourceAddress, cert, true)
+		if err != nil {
+			return errors.Wrap(err, "Failed to connect to source server")
+		}
+		source = source.UseProject(requestProject)
+
+		// Connect to the member to move the volume to.
+		dest, err := cluster.Connect(targetAddress, cert, false)
+		if err != nil {
+			return errors.Wrap(err, "Failed to connect to destination server")
+		}
+		dest = dest.UseProject(requestProject).UseTarget(targetNode)
+
+		// Copy the volume and its snapshots over using the migration protocol.
+		args := lxd.StoragePoolVolumeCopyArgs{
+			Name: req.Name,
+			Mode: "pull",
+		}
+
+		copyOp, err := dest.CopyStoragePoolVolume(dstPoolName, source, poolName, *vol, &args)
+		if err != nil {
+			return errors.Wrap(err, "Failed to issue copy storage volume API request")
+		}
+
+		err = copyOp.Wait()
+		if err != nil {
+			return errors.Wrap(err, "Copy storage volume operation failed")
+		}
+
+		// Delete the volume on this member.
+		return srcPool.DeleteCustomVolume(projectName, volumeName, op)
+	}
+
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{fmt.Sprintf("%s/volumes/custom/%s", poolName, volumeName)}
+
+	op, err := operations.OperationCreate(d.State(), requestProject, operations.OperationClassTask, db.OperationVolumeMove, resources, nil, run, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return operations.OperationResponse(op), nil
+}
+
 func storagePoolVolumeTypeContainerPost(d *Daemon, r *http.Request) response.Response {
 	return storagePoolVolumeTypePost(d, r, "container")
 }
@@ -864,7 +1102,7 @@ func storagePoolVolumeTypeGet(d *Daemon, r *http.Request, volumeTypeName string)
 
 	etag := []interface{}{volumeName, volume.Type, volume.Config}
 
-	return response.SyncResponseETag(true, volume, etag)
+	return response.SyncResponseETagConditional(r, volume, etag)
 }
 
 func storagePoolVolumeTypeContainerGet(d *Daemon, r *http.Request) response.Response {
@@ -1005,6 +1243,8 @@ func storagePoolVolumeTypePut(d *Daemon, r *http.Request, volumeTypeName string)
 		return response.SmartError(fmt.Errorf("Invalid volume type"))
 	}
 
+	d.State().Events.SendLifecycle(projectName, lifecycle.StorageVolumeUpdated.Event(poolName, volumeTypeName, volumeName, request.CreateRequestor(r), nil))
+
 	return response.EmptySyncResponse
 }
 
@@ -1103,6 +1343,8 @@ func storagePoolVolumeTypePatch(d *Daemon, r *http.Request, volumeTypeName strin
 		return response.SmartError(err)
 	}
 
+	d.State().Events.SendLifecycle(projectName, lifecycle.StorageVolumeUpdated.Event(poolName, volumeTypeName, volumeName, request.CreateRequestor(r), nil))
+
 	return response.EmptySyncResponse
 }
 
@@ -1197,6 +1439,8 @@ func storagePoolVolumeTypeDelete(d *Daemon, r *http.Request, volumeTypeName stri
 		return response.SmartError(err)
 	}
 
+	d.State().Events.SendLifecycle(projectName, lifecycle.StorageVolumeDeleted.Event(poolName, volumeTypeName, volumeName, request.CreateRequestor(r), nil))
+
 	return response.EmptySyncResponse
 }
 
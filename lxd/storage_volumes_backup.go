@@ -0,0 +1,858 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/lxd/backup"
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/state"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/cancel"
+	"github.com/lxc/lxd/shared/instancewriter"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/logging"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var storagePoolVolumeTypeCustomBackupsCmd = APIEndpoint{
+	Path: "storage-pools/{pool}/volumes/{type}/{name}/backups",
+
+	Get:  APIEndpointAction{Handler: storagePoolVolumeTypeCustomBackupsGet, AccessHandler: allowProjectPermission("storage-volumes", "view")},
+	Post: APIEndpointAction{Handler: storagePoolVolumeTypeCustomBackupsPost, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+}
+
+var storagePoolVolumeTypeCustomBackupCmd = APIEndpoint{
+	Path: "storage-pools/{pool}/volumes/{type}/{name}/backups/{backupName}",
+
+	Get:    APIEndpointAction{Handler: storagePoolVolumeTypeCustomBackupGet, AccessHandler: allowProjectPermission("storage-volumes", "view")},
+	Post:   APIEndpointAction{Handler: storagePoolVolumeTypeCustomBackupPost, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+	Delete: APIEndpointAction{Handler: storagePoolVolumeTypeCustomBackupDelete, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+}
+
+var storagePoolVolumeTypeCustomBackupExportCmd = APIEndpoint{
+	Path: "storage-pools/{pool}/volumes/{type}/{name}/backups/{backupName}/export",
+
+	Get: APIEndpointAction{Handler: storagePoolVolumeTypeCustomBackupExportGet, AccessHandler: allowProjectPermission("storage-volumes", "view")},
+}
+
+// storagePoolVolumeBackupVolume resolves the custom volume targeted by a backup request. A non-nil response
+// is returned if the request must be forwarded to another member or is invalid.
+func storagePoolVolumeBackupVolume(d *Daemon, r *http.Request) (string, string, string, int64, response.Response) {
+	poolName := mux.Vars(r)["pool"]
+	volumeTypeName := mux.Vars(r)["type"]
+	volumeName := mux.Vars(r)["name"]
+
+	// Convert the volume type name to our internal integer representation.
+	volumeType, err := storagePools.VolumeTypeNameToType(volumeTypeName)
+	if err != nil {
+		return "", "", "", -1, response.BadRequest(err)
+	}
+
+	// Check that the storage volume type is valid.
+	if volumeType != db.StoragePoolVolumeTypeCustom {
+		return "", "", "", -1, response.BadRequest(fmt.Errorf("Invalid storage volume type %q", volumeTypeName))
+	}
+
+	projectName, err := project.StorageVolumeProject(d.State().Cluster, projectParam(r), volumeType)
+	if err != nil {
+		return "", "", "", -1, response.SmartError(err)
+	}
+
+	// Retrieve ID of the storage pool (and check if the storage pool exists).
+	poolID, err := d.cluster.GetStoragePoolID(poolName)
+	if err != nil {
+		return "", "", "", -1, response.SmartError(err)
+	}
+
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return "", "", "", -1, resp
+	}
+
+	resp = forwardedResponseIfVolumeIsRemote(d, r, poolID, volumeName, volumeType)
+	if resp != nil {
+		return "", "", "", -1, resp
+	}
+
+	volumeID, _, err := d.cluster.GetLocalStoragePoolVolume(projectName, volumeName, volumeType, poolID)
+	if err != nil {
+		return "", "", "", -1, response.SmartError(err)
+	}
+
+	return projectName, poolName, volumeName, volumeID, nil
+}
+
+// storagePoolVolumeBackupLoad loads the backup with the given name of the custom volume.
+func storagePoolVolumeBackupLoad(s *state.State, projectName string, poolName string, volumeName string, volumeID int64, backupName string) (*backup.VolumeBackup, error) {
+	args, err := s.Cluster.GetStoragePoolVolumeBackup(volumeID, volumeName+shared.SnapshotDelimiter+backupName)
+	if err != nil {
+		return nil, err
+	}
+
+	return backup.NewVolumeBackup(s, projectName, poolName, volumeName, args.ID, args.Name, args.CreationDate, args.ExpiryDate, args.VolumeOnly, args.OptimizedStorage), nil
+}
+
+func storagePoolVolumeTypeCustomBackupsGet(d *Daemon, r *http.Request) response.Response {
+	projectName, poolName, volumeName, volumeID, resp := storagePoolVolumeBackupVolume(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	recursion := util.IsRecursionRequest(r)
+
+	backups, err := d.cluster.GetStoragePoolVolumeBackups(volumeID)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resultString := []string{}
+	resultMap := []*api.StoragePoolVolumeBackup{}
+
+	for _, args := range backups {
+		if !recursion {
+			_, backupName, _ := shared.InstanceGetParentAndSnapshotName(args.Name)
+			url := fmt.Sprintf("/%s/storage-pools/%s/volumes/custom/%s/backups/%s", version.APIVersion, poolName, volumeName, backupName)
+			resultString = append(resultString, url)
+		} else {
+			b := backup.NewVolumeBackup(d.State(), projectName, poolName, volumeName, args.ID, args.Name, args.CreationDate, args.ExpiryDate, args.VolumeOnly, args.OptimizedStorage)
+			resultMap = append(resultMap, b.Render())
+		}
+	}
+
+	if !recursion {
+		return response.SyncResponse(true, resultString)
+	}
+
+	return response.SyncResponse(true, resultMap)
+}
+
+func storagePoolVolumeTypeCustomBackupsPost(d *Daemon, r *http.Request) response.Response {
+	projectName, poolName, volumeName, volumeID, resp := storagePoolVolumeBackupVolume(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	req := api.StoragePoolVolumeBackupsPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.Name == "" {
+		// come up with a name
+		backups, err := d.cluster.GetStoragePoolVolumeBackups(volumeID)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		base := volumeName + shared.SnapshotDelimiter + "backup"
+		length := len(base)
+		max := 0
+
+		for _, b := range backups {
+			// Ignore backups not containing base
+			if !strings.HasPrefix(b.Name, base) {
+				continue
+			}
+
+			substr := b.Name[length:]
+			var num int
+			count, err := fmt.Sscanf(substr, "%d", &num)
+			if err != nil || count != 1 {
+				continue
+			}
+			if num >= max {
+				max = num + 1
+			}
+		}
+
+		req.Name = fmt.Sprintf("backup%d", max)
+	}
+
+	// Validate the name
+	if strings.Contains(req.Name, "/") {
+		return response.BadRequest(fmt.Errorf("Backup names may not contain slashes"))
+	}
+
+	// Validate the upload target.
+	if req.Target != nil {
+		err = backup.ValidateTarget(*req.Target)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
+	fullName := volumeName + shared.SnapshotDelimiter + req.Name
+
+	run := func(op *operations.Operation) error {
+		args := db.StoragePoolVolumeBackup{
+			Name:                 fullName,
+			VolumeID:             volumeID,
+			CreationDate:         time.Now(),
+			ExpiryDate:           req.ExpiresAt,
+			VolumeOnly:           req.VolumeOnly,
+			OptimizedStorage:     req.OptimizedStorage,
+			CompressionAlgorithm: req.CompressionAlgorithm,
+		}
+
+		err := volumeBackupCreate(op.Context(), d.State(), args, projectName, poolName, volumeName, req.Target)
+		if err != nil {
+			return errors.Wrap(err, "Create volume backup")
+		}
+
+		return nil
+	}
+
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{volumeName}
+	if req.Target == nil {
+		resources["backups"] = []string{req.Name}
+	}
+
+	op, err := operations.OperationCreate(d.State(), projectParam(r), operations.OperationClassTask, db.OperationCustomVolumeBackupCreate, resources, nil, run, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	op.SetCancelable()
+
+	return operations.OperationResponse(op)
+}
+
+func storagePoolVolumeTypeCustomBackupGet(d *Daemon, r *http.Request) response.Response {
+	projectName, poolName, volumeName, volumeID, resp := storagePoolVolumeBackupVolume(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	b, err := storagePoolVolumeBackupLoad(d.State(), projectName, poolName, volumeName, volumeID, mux.Vars(r)["backupName"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, b.Render())
+}
+
+func storagePoolVolumeTypeCustomBackupPost(d *Daemon, r *http.Request) response.Response {
+	projectName, poolName, volumeName, volumeID, resp := storagePoolVolumeBackupVolume(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	req := api.StoragePoolVolumeBackupPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Validate the name
+	if req.Name == "" || strings.Contains(req.Name, "/") {
+		return response.BadRequest(fmt.Errorf("Backup names may not be empty or contain slashes"))
+	}
+
+	b, err := storagePoolVolumeBackupLoad(d.State(), projectName, poolName, volumeName, volumeID, mux.Vars(r)["backupName"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	newName := volumeName + shared.SnapshotDelimiter + req.Name
+
+	rename := func(op *operations.Operation) error {
+		return b.Rename(newName)
+	}
+
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{volumeName}
+
+	op, err := operations.OperationCreate(d.State(), projectParam(r), operations.OperationClassTask, db.OperationCustomVolumeBackupRename, resources, nil, rename, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+func storagePoolVolumeTypeCustomBackupDelete(d *Daemon, r *http.Request) response.Response {
+	projectName, poolName, volumeName, volumeID, resp := storagePoolVolumeBackupVolume(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	b, err := storagePoolVolumeBackupLoad(d.State(), projectName, poolName, volumeName, volumeID, mux.Vars(r)["backupName"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	remove := func(op *operations.Operation) error {
+		return b.Delete()
+	}
+
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{volumeName}
+
+	op, err := operations.OperationCreate(d.State(), projectParam(r), operations.OperationClassTask, db.OperationCustomVolumeBackupRemove, resources, nil, remove, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+func storagePoolVolumeTypeCustomBackupExportGet(d *Daemon, r *http.Request) response.Response {
+	projectName, poolName, volumeName, volumeID, resp := storagePoolVolumeBackupVolume(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	b, err := storagePoolVolumeBackupLoad(d.State(), projectName, poolName, volumeName, volumeID, mux.Vars(r)["backupName"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	ent := response.FileResponseEntry{
+		Path: b.Path(),
+	}
+
+	return response.FileResponse(r, []response.FileResponseEntry{ent}, nil, false)
+}
+
+// volumeBackupCreate creates a backup tarball of the custom volume and records it in the database. If a target
+// is provided, the backup is streamed to it rather than being stored locally. The backup is interrupted and
+// reverted if ctx is done before it completes.
+func volumeBackupCreate(ctx context.Context, s *state.State, args db.StoragePoolVolumeBackup, projectName string, poolName string, volumeName string, target *api.BackupTarget) error {
+	logger := logging.AddContext(logger.Log, log.Ctx{"project": projectName, "pool": poolName, "volume": volumeName, "name": args.Name})
+	logger.Debug("Volume backup started")
+	defer logger.Debug("Volume backup finished")
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Get storage pool.
+	pool, err := storagePools.GetPoolByName(s, poolName)
+	if err != nil {
+		return errors.Wrap(err, "Load storage pool")
+	}
+
+	_, vol, err := s.Cluster.GetLocalStoragePoolVolume(projectName, volumeName, db.StoragePoolVolumeTypeCustom, pool.ID())
+	if err != nil {
+		return errors.Wrap(err, "Load storage volume")
+	}
+
+	// Ignore requests for optimized backups when pool driver doesn't support it.
+	if args.OptimizedStorage && !pool.Driver().Info().OptimizedBackups {
+		args.OptimizedStorage = false
+	}
+
+	// Detect compression method.
+	compress := args.CompressionAlgorithm
+	if compress == "" {
+		compress, err = cluster.ConfigGetString(s.Cluster, "backups.compression_algorithm")
+		if err != nil {
+			return err
+		}
+	}
+
+	// Figure out which snapshots to include.
+	snapshots := []string{}
+	if !args.VolumeOnly {
+		snaps, err := s.Cluster.GetLocalStoragePoolVolumeSnapshotsWithType(projectName, volumeName, db.StoragePoolVolumeTypeCustom, pool.ID())
+		if err != nil {
+			return err
+		}
+
+		for _, snap := range snaps {
+			_, snapName, _ := shared.InstanceGetParentAndSnapshotName(snap.Name)
+			snapshots = append(snapshots, snapName)
+		}
+	}
+
+	var tarFileWriter io.WriteCloser
+	if target != nil {
+		// Stream the tarball to the target, nothing gets recorded locally.
+		logger.Debug("Opening backup target for writing", log.Ctx{"url": target.URL, "bucket": target.BucketName, "path": target.Path})
+		uploader, err := backup.NewTargetWriter(ctx, *target)
+		if err != nil {
+			return errors.Wrap(err, "Error opening backup target for writing")
+		}
+
+		revert.Add(func() { uploader.CloseWithError(fmt.Errorf("Backup failed")) })
+		tarFileWriter = uploader
+	} else {
+		// Create the database entry.
+		err = s.Cluster.CreateStoragePoolVolumeBackup(args)
+		if err != nil {
+			if err == db.ErrAlreadyDefined {
+				return fmt.Errorf("Backup %q already exists", args.Name)
+			}
+
+			return errors.Wrap(err, "Insert backup info into database")
+		}
+
+		revert.Add(func() {
+			b, err := s.Cluster.GetStoragePoolVolumeBackup(args.VolumeID, args.Name)
+			if err == nil {
+				s.Cluster.DeleteStoragePoolVolumeBackup(b.ID)
+			}
+		})
+
+		// Create the target path if needed.
+		backupsPath := backup.VolumeBackupsPath(projectName, poolName, volumeName)
+		if !shared.PathExists(backupsPath) {
+			err := os.MkdirAll(backupsPath, 0700)
+			if err != nil {
+				return err
+			}
+
+			revert.Add(func() { os.Remove(backupsPath) })
+		}
+
+		tarPath := backup.VolumeBackupPath(projectName, poolName, args.Name)
+
+		// Setup the tarball writer.
+		logger.Debug("Opening backup tarball for writing", log.Ctx{"path": tarPath})
+		tarFile, err := os.OpenFile(tarPath, os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return errors.Wrapf(err, "Error opening backup tarball for writing %q", tarPath)
+		}
+		defer tarFile.Close()
+		revert.Add(func() { os.Remove(tarPath) })
+
+		tarFileWriter = tarFile
+	}
+
+	// Stop writing the backup once the context is done.
+	backupWriter := cancel.NewContextWriter(ctx, tarFileWriter)
+
+	// Create the tarball. Custom volumes aren't shifted so no IDMAP is needed.
+	tarPipeReader, tarPipeWriter := io.Pipe()
+	defer tarPipeWriter.Close() // Ensure that go routine below always ends.
+	tarWriter := instancewriter.NewInstanceTarWriter(tarPipeWriter, nil)
+
+	// Setup tar writer go routine, with optional compression.
+	tarWriterRes := make(chan error, 0)
+	var compressErr error
+
+	go func(resCh chan<- error) {
+		logger.Debug("Started backup tarball writer")
+		defer logger.Debug("Finished backup tarball writer")
+		if compress != "none" {
+			compressErr = compressFile(compress, tarPipeReader, backupWriter)
+
+			// If a compression error occurred, close the tarPipeWriter to end the export.
+			if compressErr != nil {
+				tarPipeWriter.Close()
+			}
+		} else {
+			_, err = io.Copy(backupWriter, tarPipeReader)
+
+			// If a write error occurred, close the tarPipeWriter to end the export.
+			if err != nil {
+				tarPipeWriter.Close()
+			}
+		}
+		resCh <- err
+	}(tarWriterRes)
+
+	// Write index file.
+	logger.Debug("Adding backup index file")
+	err = volumeBackupWriteIndex(volumeName, vol, pool, args.OptimizedStorage, snapshots, tarWriter)
+
+	// Check compression errors.
+	if compressErr != nil {
+		return compressErr
+	}
+
+	// Check volumeBackupWriteIndex for errors.
+	if err != nil {
+		return errors.Wrapf(err, "Error writing backup index file")
+	}
+
+	err = pool.BackupCustomVolume(projectName, volumeName, tarWriter, args.OptimizedStorage, snapshots, nil)
+	if err != nil {
+		return errors.Wrap(err, "Backup create")
+	}
+
+	// Close off the tarball file.
+	err = tarWriter.Close()
+	if err != nil {
+		return errors.Wrap(err, "Error closing tarball writer")
+	}
+
+	// Close off the tarball pipe writer (this will end the go routine above).
+	err = tarPipeWriter.Close()
+	if err != nil {
+		return errors.Wrap(err, "Error closing tarball pipe writer")
+	}
+
+	err = <-tarWriterRes
+	if err != nil {
+		return errors.Wrap(err, "Error writing tarball")
+	}
+
+	// Complete the upload when streaming to a target.
+	if target != nil {
+		err = tarFileWriter.Close()
+		if err != nil {
+			return errors.Wrap(err, "Error uploading backup")
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// volumeBackupWriteIndex generates an index.yaml file and then writes it to the root of the backup tarball.
+func volumeBackupWriteIndex(volumeName string, vol *api.StorageVolume, pool storagePools.Pool, optimized bool, snapshots []string, tarWriter *instancewriter.InstanceTarWriter) error {
+	// Indicate whether the driver will include a driver-specific optimized header.
+	poolDriverOptimizedHeader := false
+	if optimized {
+		poolDriverOptimizedHeader = pool.Driver().Info().OptimizedBackupHeader
+	}
+
+	indexInfo := backup.Info{
+		Version:          backup.FormatVersion,
+		Name:             volumeName,
+		Pool:             pool.Name(),
+		Snapshots:        snapshots,
+		Backend:          pool.Driver().Info().Name,
+		OptimizedStorage: &optimized,
+		OptimizedHeader:  &poolDriverOptimizedHeader,
+		Volume: &backup.VolumeInfo{
+			Description: vol.Description,
+			Config:      vol.Config,
+		},
+	}
+
+	// Convert to YAML.
+	indexData, err := yaml.Marshal(&indexInfo)
+	if err != nil {
+		return err
+	}
+	r := bytes.NewReader(indexData)
+
+	indexFileInfo := instancewriter.FileInfo{
+		FileName:    "backup/index.yaml",
+		FileSize:    int64(len(indexData)),
+		FileMode:    0644,
+		FileModTime: time.Now(),
+	}
+
+	// Write to tarball.
+	return tarWriter.WriteFileFromReader(r, &indexFileInfo)
+}
+
+// storagePoolVolumeCreateFromBackup creates a new custom volume from the backup tarball uploaded in the request
+// body. The volume name is taken from the X-LXD-name header, or from the backup if not set.
+func storagePoolVolumeCreateFromBackup(d *Daemon, r *http.Request, projectName string, poolName string) response.Response {
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Create temporary file to store uploaded backup data.
+	backupFile, err := ioutil.TempFile(shared.VarPath("backups"), "lxd_backup_")
+	if err != nil {
+		return response.InternalError(err)
+	}
+	defer os.Remove(backupFile.Name())
+	revert.Add(func() { backupFile.Close() })
+
+	// Stream uploaded backup data into temporary file.
+	_, err = io.Copy(backupFile, r.Body)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	// Parse the backup information.
+	backupFile.Seek(0, 0)
+	logger.Debug("Reading backup file info")
+	bInfo, err := backup.GetInfo(backupFile)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if bInfo.Volume == nil {
+		return response.BadRequest(fmt.Errorf("Backup is not a custom volume backup"))
+	}
+
+	bInfo.Project = projectName
+	bInfo.Pool = poolName
+
+	// Override volume name.
+	volumeName := r.Header.Get("X-LXD-name")
+	if volumeName != "" {
+		bInfo.Name = volumeName
+	}
+
+	if strings.Contains(bInfo.Name, "/") {
+		return response.BadRequest(fmt.Errorf("Storage volume names may not contain slashes"))
+	}
+
+	pool, err := storagePools.GetPoolByName(d.State(), bInfo.Pool)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Check that the backup format is supported and, if the backup is optimized, that the source pool driver
+	// matches the target pool driver.
+	err = bInfo.CheckCompatibility(pool.Driver().Info().Name)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Check if destination volume exists.
+	_, _, err = d.cluster.GetLocalStoragePoolVolume(projectName, bInfo.Name, db.StoragePoolVolumeTypeCustom, pool.ID())
+	if err != db.ErrNoSuchObject {
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return response.Conflict(fmt.Errorf("Volume by that name already exists"))
+	}
+
+	// Copy reverter so far so we can use it inside run after this function has finished.
+	runRevert := revert.Clone()
+
+	run := func(op *operations.Operation) error {
+		defer backupFile.Close()
+		defer runRevert.Fail()
+
+		backupFile.Seek(0, 0)
+		err := pool.CreateCustomVolumeFromBackup(projectName, *bInfo, backupFile, op)
+		if err != nil {
+			return errors.Wrap(err, "Create custom volume from backup")
+		}
+
+		runRevert.Success()
+		return nil
+	}
+
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{bInfo.Name}
+
+	op, err := operations.OperationCreate(d.State(), projectParam(r), operations.OperationClassTask, db.OperationCustomVolumeBackupRestore, resources, nil, run, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	op.SetCancelable()
+
+	revert.Success()
+	return operations.OperationResponse(op)
+}
+
+func pruneExpiredStoragePoolVolumeBackups(ctx context.Context, d *Daemon) error {
+	// Get the list of expired backups.
+	backups, err := d.cluster.GetExpiredStoragePoolVolumeBackups()
+	if err != nil {
+		return errors.Wrap(err, "Unable to retrieve the list of expired custom volume backups")
+	}
+
+	for _, args := range backups {
+		volumeName, _, _ := shared.InstanceGetParentAndSnapshotName(args.Name)
+		b := backup.NewVolumeBackup(d.State(), args.ProjectName, args.PoolName, volumeName, args.ID, args.Name, args.CreationDate, args.ExpiryDate, args.VolumeOnly, args.OptimizedStorage)
+
+		err = b.Delete()
+		if err != nil {
+			return errors.Wrapf(err, "Error deleting custom volume backup %s", args.Name)
+		}
+	}
+
+	return nil
+}
+
+func autoCreateCustomVolumeBackupsTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		// Only consider the volumes this member is responsible for, so that each volume is backed up once
+		// in a cluster.
+		allVolumes, err := d.cluster.GetResponsibleStoragePoolVolumesWithType(db.StoragePoolVolumeTypeCustom)
+		if err != nil {
+			logger.Error("Failed to load volumes for scheduled backups", log.Ctx{"err": err})
+			return
+		}
+
+		// Figure out which need a backup (if any).
+		volumes := []db.StorageVolumeArgs{}
+		for _, v := range allVolumes {
+			schedule := v.Config["backups.schedule"]
+			if schedule == "" {
+				continue
+			}
+
+			// Check if it's time for a backup.
+			due, err := shared.ScheduleDue(schedule, fmt.Sprintf("%s/%s/%s", v.ProjectName, v.PoolName, v.Name), time.Now())
+			if err != nil || !due {
+				continue
+			}
+
+			volumes = append(volumes, v)
+		}
+
+		if len(volumes) == 0 {
+			return
+		}
+
+		opRun := func(op *operations.Operation) error {
+			return autoCreateCustomVolumeBackups(ctx, d, volumes)
+		}
+
+		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationCustomVolumeBackupCreate, nil, nil, opRun, nil, nil)
+		if err != nil {
+			logger.Error("Failed to start scheduled volume backups operation", log.Ctx{"err": err})
+			return
+		}
+
+		logger.Info("Creating scheduled volume backups")
+		_, err = op.Run()
+		if err != nil {
+			logger.Error("Failed to create scheduled volume backups", log.Ctx{"err": err})
+		}
+		logger.Info("Done creating scheduled volume backups")
+	}
+
+	first := true
+	schedule := func() (time.Duration, error) {
+		interval := time.Minute
+
+		if first {
+			first = false
+			return interval, task.ErrSkip
+		}
+
+		return interval, nil
+	}
+
+	return f, schedule
+}
+
+func autoCreateCustomVolumeBackups(ctx context.Context, d *Daemon, volumes []db.StorageVolumeArgs) error {
+	failed := []string{}
+
+	for _, v := range volumes {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		err := autoCreateCustomVolumeBackup(ctx, d, v)
+		warningUpdateLocalNode(d, v.ProjectName, db.WarningEntityTypeStorageVolume, int(v.ID), db.WarningTypeScheduledBackupFailed, err)
+		if err != nil {
+			logger.Warn("Failed creating scheduled volume backup", log.Ctx{"project": v.ProjectName, "pool": v.PoolName, "volume": v.Name, "err": err})
+			failed = append(failed, fmt.Sprintf("%s/%s", v.PoolName, project.StorageVolume(v.ProjectName, v.Name)))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("Failed creating scheduled backups of: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// autoCreateCustomVolumeBackup creates a scheduled backup of the custom volume and then applies its retention
+// policy.
+func autoCreateCustomVolumeBackup(ctx context.Context, d *Daemon, v db.StorageVolumeArgs) error {
+	name := fmt.Sprintf("scheduled-%s", time.Now().UTC().Format("20060102-150405"))
+
+	args := db.StoragePoolVolumeBackup{
+		Name:             v.Name + shared.SnapshotDelimiter + name,
+		VolumeID:         v.ID,
+		CreationDate:     time.Now(),
+		ExpiryDate:       time.Time{}, // Retention is handled below.
+		VolumeOnly:       false,
+		OptimizedStorage: shared.IsTrue(v.Config["backups.optimized_storage"]),
+	}
+
+	// Scheduled backups uploaded to S3 use the server wide storage settings.
+	var target *api.BackupTarget
+	if v.Config["backups.target"] == "s3" {
+		target = &api.BackupTarget{Protocol: "s3"}
+		err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			clusterConfig, err := cluster.ConfigLoad(tx)
+			if err != nil {
+				return err
+			}
+
+			target.URL, target.BucketName, target.AccessKey, target.SecretKey = clusterConfig.BackupsS3()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		target.Path = path.Join(v.ProjectName, "volumes", v.PoolName, v.Name, name)
+	}
+
+	err := volumeBackupCreate(ctx, d.State(), args, v.ProjectName, v.PoolName, v.Name, target)
+	if err != nil {
+		return err
+	}
+
+	keep, _ := strconv.Atoi(v.Config["backups.retention"])
+	if keep <= 0 {
+		return nil
+	}
+
+	// Remove the oldest scheduled backups beyond the retention count.
+	if target != nil {
+		target.Path = path.Join(v.ProjectName, "volumes", v.PoolName, v.Name, "scheduled-")
+		return backup.PruneTarget(ctx, *target, keep)
+	}
+
+	backups, err := d.cluster.GetStoragePoolVolumeBackups(v.ID)
+	if err != nil {
+		return err
+	}
+
+	scheduled := []string{}
+	for _, b := range backups {
+		if strings.HasPrefix(b.Name, v.Name+shared.SnapshotDelimiter+"scheduled-") {
+			scheduled = append(scheduled, b.Name)
+		}
+	}
+
+	if len(scheduled) <= keep {
+		return nil
+	}
+
+	// The names embed the creation time so sorting them puts the oldest first.
+	sort.Strings(scheduled)
+	for _, backupName := range scheduled[:len(scheduled)-keep] {
+		b, err := storagePoolVolumeBackupLoad(d.State(), v.ProjectName, v.PoolName, v.Name, v.ID, strings.TrimPrefix(backupName, v.Name+shared.SnapshotDelimiter))
+		if err != nil {
+			return err
+		}
+
+		err = b.Delete()
+		if err != nil {
+			return errors.Wrapf(err, "Error deleting volume backup %s", backupName)
+		}
+	}
+
+	return nil
+}
@@ -12,9 +12,9 @@ import (
 	"github.com/flosch/pongo2"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
-	cron "gopkg.in/robfig/cron.v2"
 
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/response"
@@ -148,17 +148,26 @@ func storagePoolVolumeSnapshotsTypePost(d *Daemon, r *http.Request) response.Res
 			return err
 		}
 
-		return pool.CreateCustomVolumeSnapshot(projectName, volumeName, req.Name, expiry, op)
+		err = pool.CreateCustomVolumeSnapshot(projectName, volumeName, req.Name, expiry, op)
+		if err != nil {
+			return err
+		}
+
+		d.State().Events.SendLifecycle(projectName, lifecycle.StorageVolumeSnapshotCreated.Event(poolName, volumeTypeName, fmt.Sprintf("%s/%s", volumeName, req.Name), op.Requestor(), nil))
+
+		return nil
 	}
 
 	resources := map[string][]string{}
 	resources["storage_volumes"] = []string{volumeName}
 
-	op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationVolumeSnapshotCreate, resources, nil, snapshot, nil, nil)
+	op, err := operations.OperationCreate(d.State(), projectParam(r), operations.OperationClassTask, db.OperationVolumeSnapshotCreate, resources, nil, snapshot, nil, nil)
 	if err != nil {
 		return response.InternalError(err)
 	}
 
+	op.SetRequestor(r)
+
 	return operations.OperationResponse(op)
 }
 
@@ -235,10 +244,10 @@ func storagePoolVolumeSnapshotsTypeGet(d *Daemon, r *http.Request) response.Resp
 	}
 
 	if !recursion {
-		return response.SyncResponse(true, resultString)
+		return response.SyncResponseConditional(r, resultString)
 	}
 
-	return response.SyncResponse(true, resultMap)
+	return response.SyncResponseConditional(r, resultMap)
 }
 
 func storagePoolVolumeSnapshotTypePost(d *Daemon, r *http.Request) response.Response {
@@ -309,17 +318,26 @@ func storagePoolVolumeSnapshotTypePost(d *Daemon, r *http.Request) response.Resp
 			return err
 		}
 
-		return pool.RenameCustomVolumeSnapshot(projectName, fullSnapshotName, req.Name, op)
+		err = pool.RenameCustomVolumeSnapshot(projectName, fullSnapshotName, req.Name, op)
+		if err != nil {
+			return err
+		}
+
+		d.State().Events.SendLifecycle(projectName, lifecycle.StorageVolumeSnapshotRenamed.Event(poolName, volumeTypeName, fmt.Sprintf("%s/%s", volumeName, req.Name), op.Requestor(), map[string]interface{}{"old_name": snapshotName}))
+
+		return nil
 	}
 
 	resources := map[string][]string{}
 	resources["storage_volume_snapshots"] = []string{volumeName}
 
-	op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationVolumeSnapshotDelete, resources, nil, snapshotRename, nil, nil)
+	op, err := operations.OperationCreate(d.State(), projectParam(r), operations.OperationClassTask, db.OperationVolumeSnapshotDelete, resources, nil, snapshotRename, nil, nil)
 	if err != nil {
 		return response.InternalError(err)
 	}
 
+	op.SetRequestor(r)
+
 	return operations.OperationResponse(op)
 }
 
@@ -388,7 +406,7 @@ func storagePoolVolumeSnapshotTypeGet(d *Daemon, r *http.Request) response.Respo
 
 	etag := []interface{}{snapshot.Name, snapshot.Description, snapshot.Config, expiry}
 
-	return response.SyncResponseETag(true, &snapshot, etag)
+	return response.SyncResponseETagConditional(r, &snapshot, etag)
 }
 
 // storagePoolVolumeSnapshotTypePut allows a snapshot's description to be changed.
@@ -475,17 +493,26 @@ func storagePoolVolumeSnapshotTypePut(d *Daemon, r *http.Request) response.Respo
 		}
 
 		// Handle custom volume update requests.
-		return pool.UpdateCustomVolumeSnapshot(projectName, vol.Name, req.Description, nil, expiry, op)
+		err = pool.UpdateCustomVolumeSnapshot(projectName, vol.Name, req.Description, nil, expiry, op)
+		if err != nil {
+			return err
+		}
+
+		d.State().Events.SendLifecycle(projectName, lifecycle.StorageVolumeSnapshotUpdated.Event(poolName, volumeTypeName, fullSnapshotName, op.Requestor(), nil))
+
+		return nil
 	}
 
 	resources := map[string][]string{}
 	resources["storage_volume_snapshots"] = []string{volumeName}
 
-	op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationVolumeSnapshotUpdate, resources, nil, do, nil, nil)
+	op, err := operations.OperationCreate(d.State(), projectParam(r), operations.OperationClassTask, db.OperationVolumeSnapshotUpdate, resources, nil, do, nil, nil)
 	if err != nil {
 		return response.InternalError(err)
 	}
 
+	op.SetRequestor(r)
+
 	return operations.OperationResponse(op)
 }
 
@@ -540,17 +567,26 @@ func storagePoolVolumeSnapshotTypeDelete(d *Daemon, r *http.Request) response.Re
 			return err
 		}
 
-		return pool.DeleteCustomVolumeSnapshot(projectName, fullSnapshotName, op)
+		err = pool.DeleteCustomVolumeSnapshot(projectName, fullSnapshotName, op)
+		if err != nil {
+			return err
+		}
+
+		d.State().Events.SendLifecycle(projectName, lifecycle.StorageVolumeSnapshotDeleted.Event(poolName, volumeTypeName, fullSnapshotName, op.Requestor(), nil))
+
+		return nil
 	}
 
 	resources := map[string][]string{}
 	resources["storage_volume_snapshots"] = []string{volumeName}
 
-	op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationVolumeSnapshotDelete, resources, nil, snapshotDelete, nil, nil)
+	op, err := operations.OperationCreate(d.State(), projectParam(r), operations.OperationClassTask, db.OperationVolumeSnapshotDelete, resources, nil, snapshotDelete, nil, nil)
 	if err != nil {
 		return response.InternalError(err)
 	}
 
+	op.SetRequestor(r)
+
 	return operations.OperationResponse(op)
 }
 
@@ -620,7 +656,9 @@ func pruneExpiredCustomVolumeSnapshots(ctx context.Context, d *Daemon, expiredSn
 
 func autoCreateCustomVolumeSnapshotsTask(d *Daemon) (task.Func, task.Schedule) {
 	f := func(ctx context.Context) {
-		allVolumes, err := d.cluster.GetStoragePoolVolumesWithType(db.StoragePoolVolumeTypeCustom)
+		// Only consider the volumes this member is responsible for, so that each volume is snapshotted
+		// once in a cluster.
+		allVolumes, err := d.cluster.GetResponsibleStoragePoolVolumesWithType(db.StoragePoolVolumeTypeCustom)
 		if err != nil {
 			return
 		}
@@ -634,29 +672,9 @@ func autoCreateCustomVolumeSnapshotsTask(d *Daemon) (task.Func, task.Schedule) {
 				continue
 			}
 
-			// Extend our schedule to one that is accepted by the used cron parser
-			sched, err := cron.Parse(fmt.Sprintf("* %s", schedule))
-			if err != nil {
-				continue
-			}
-
 			// Check if it's time to snapshot
-			now := time.Now()
-
-			// Truncate the time now back to the start of the minute, before passing to
-			// the cron scheduler, as it will add 1s to the scheduled time and we don't
-			// want the next scheduled time to roll over to the next minute and break
-			// the time comparison below.
-			now = now.Truncate(time.Minute)
-
-			// Calculate the next scheduled time based on the snapshots.schedule
-			// pattern and the time now.
-			next := sched.Next(now)
-
-			// Ignore everything that is more precise than minutes.
-			next = next.Truncate(time.Minute)
-
-			if !now.Equal(next) {
+			due, err := shared.ScheduleDue(schedule, fmt.Sprintf("%s/%s/%s", v.ProjectName, v.PoolName, v.Name), time.Now())
+			if err != nil || !due {
 				continue
 			}
 
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"time"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+
+	log "github.com/lxc/lxd/shared/log15"
+)
+
+// tokenCreate creates a single-use token operation of the given type, holding the given metadata. The metadata
+// must include the token secret. The token expires according to the given expiry expression, if any.
+func tokenCreate(d *Daemon, opType db.OperationType, expiry string, metadata map[string]interface{}) (*operations.Operation, error) {
+	expiresAt, err := shared.GetSnapshotExpiry(time.Now(), expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	if !expiresAt.IsZero() {
+		metadata["expires_at"] = expiresAt
+	}
+
+	return operations.OperationCreate(d.State(), "", operations.OperationClassToken, opType, nil, metadata, nil, nil, nil)
+}
+
+// tokenExpired returns whether the token with the given metadata is past its expiry date. The expiry date is
+// a string when the metadata comes from another cluster member.
+func tokenExpired(metadata map[string]interface{}) bool {
+	var expiresAt time.Time
+
+	switch v := metadata["expires_at"].(type) {
+	case time.Time:
+		expiresAt = v
+	case string:
+		var err error
+		expiresAt, err = time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return true
+		}
+	default:
+		return false
+	}
+
+	return time.Now().After(expiresAt)
+}
+
+// tokenSecretMatches returns whether the token with the given metadata has the given secret.
+func tokenSecretMatches(metadata map[string]interface{}, secret string) bool {
+	opSecret, ok := metadata["secret"].(string)
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(opSecret), []byte(secret)) == 1
+}
+
+// tokenRoles returns the roles held in the given token metadata.
+func tokenRoles(metadata map[string]interface{}) map[string]string {
+	switch v := metadata["roles"].(type) {
+	case map[string]string:
+		return v
+	case map[string]interface{}:
+		roles := make(map[string]string, len(v))
+		for k, role := range v {
+			roles[k], _ = role.(string)
+		}
+
+		return roles
+	}
+
+	return nil
+}
+
+// tokenConsume looks for a pending token operation of the given type with the given secret, on this member
+// first and then on the rest of the cluster. If found, the token is cancelled so that it can't be used again,
+// and its metadata is returned.
+func tokenConsume(d *Daemon, opType db.OperationType, secret string) map[string]interface{} {
+	if secret == "" {
+		return nil
+	}
+
+	for _, op := range operations.Clone() {
+		if op.Type() != opType || op.Status() != api.Running {
+			continue
+		}
+
+		metadata := op.Metadata()
+		if !tokenSecretMatches(metadata, secret) {
+			continue
+		}
+
+		if tokenExpired(metadata) {
+			return nil
+		}
+
+		// Token is single-use, so cancel it now. This fails if someone else consumed it first.
+		_, err := op.Cancel()
+		if err != nil {
+			return nil
+		}
+
+		return metadata
+	}
+
+	metadata, err := tokenConsumeRemote(d, opType, secret)
+	if err != nil {
+		logger.Warn("Failed to look up token on other cluster members", log.Ctx{"type": opType.Description(), "err": err})
+		return nil
+	}
+
+	return metadata
+}
+
+// tokenConsumeRemote looks for a pending token operation of the given type with the given secret on the other
+// cluster members, and cancels it if found.
+func tokenConsumeRemote(d *Daemon, opType db.OperationType, secret string) (map[string]interface{}, error) {
+	clustered, err := cluster.Enabled(d.db)
+	if err != nil {
+		return nil, err
+	}
+
+	if !clustered {
+		return nil, nil
+	}
+
+	// Tokens aren't part of any project.
+	var nodes []string
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		nodes, err = tx.GetNodesWithRunningOperations(project.Default)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	localAddress, err := node.HTTPSAddress(d.db)
+	if err != nil {
+		return nil, err
+	}
+
+	cert := d.endpoints.NetworkCert()
+	for _, address := range nodes {
+		if address == localAddress {
+			continue
+		}
+
+		client, err := cluster.Connect(address, cert, true)
+		if err != nil {
+			return nil, err
+		}
+
+		ops, err := client.GetOperations()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, op := range ops {
+			if op.Description != opType.Description() || op.StatusCode != api.Running {
+				continue
+			}
+
+			if !tokenSecretMatches(op.Metadata, secret) {
+				continue
+			}
+
+			if tokenExpired(op.Metadata) {
+				return nil, nil
+			}
+
+			// Token is single-use, so cancel it now. This fails if someone else consumed it first.
+			err = client.DeleteOperation(op.ID)
+			if err != nil {
+				return nil, nil
+			}
+
+			return op.Metadata, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// pruneExpiredTokensTask cancels the certificate add and cluster join tokens past their expiry date.
+func pruneExpiredTokensTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		for _, op := range operations.Clone() {
+			if op.Type() != db.OperationCertificateAddToken && op.Type() != db.OperationClusterJoinToken {
+				continue
+			}
+
+			if op.Status() != api.Running || !tokenExpired(op.Metadata()) {
+				continue
+			}
+
+			_, err := op.Cancel()
+			if err != nil {
+				logger.Warn("Failed to cancel expired token", log.Ctx{"operation": op.ID(), "err": err})
+			}
+		}
+	}
+
+	return f, task.Every(time.Minute)
+}
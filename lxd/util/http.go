@@ -66,7 +66,9 @@ func EtagCheck(r *http.Request, data interface{}) error {
 		return nil
 	}
 
-	match = strings.Trim(match, "\"")
+	// ETags of conditional GET responses are followed by the hash of the whole object, which is
+	// irrelevant here.
+	match = strings.SplitN(strings.Trim(match, "\""), ".", 2)[0]
 
 	hash, err := EtagHash(data)
 	if err != nil {
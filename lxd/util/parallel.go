@@ -0,0 +1,41 @@
+package util
+
+import (
+	"runtime"
+	"sync"
+)
+
+// ParallelRun calls f for each index in [0, count) using at most the given
+// number of concurrent workers, and waits for all the calls to be done.
+//
+// If workers is zero or negative, the number of CPUs is used instead.
+func ParallelRun(count int, workers int, f func(i int)) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	if workers > count {
+		workers = count
+	}
+
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				f(i)
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+
+	wg.Wait()
+}
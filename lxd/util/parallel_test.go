@@ -0,0 +1,37 @@
+package util_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// All indexes are processed and no more than the given number of workers run
+// at the same time.
+func TestParallelRun(t *testing.T) {
+	var running int32
+	var max int32
+	done := make([]bool, 20)
+
+	util.ParallelRun(len(done), 3, func(i int) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+
+		time.Sleep(time.Millisecond)
+		done[i] = true
+		atomic.AddInt32(&running, -1)
+	})
+
+	assert.True(t, max <= 3)
+	for i := range done {
+		assert.True(t, done[i], "index %d not processed", i)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/version"
+
+	log "github.com/lxc/lxd/shared/log15"
+)
+
+var warningsCmd = APIEndpoint{
+	Path: "warnings",
+
+	Get: APIEndpointAction{Handler: warningsGet},
+}
+
+var warningCmd = APIEndpoint{
+	Path: "warnings/{uuid}",
+
+	Delete: APIEndpointAction{Handler: warningDelete},
+	Get:    APIEndpointAction{Handler: warningGet},
+	Patch:  APIEndpointAction{Handler: warningPatch},
+	Put:    APIEndpointAction{Handler: warningPut},
+}
+
+func warningsGet(d *Daemon, r *http.Request) response.Response {
+	recursion := util.IsRecursionRequest(r)
+
+	// Only filter by project if one was explicitly requested.
+	projectName := queryParam(r, "project")
+
+	var warnings []api.Warning
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		dbWarnings, err := tx.GetWarnings(projectName)
+		if err != nil {
+			return err
+		}
+
+		warnings = make([]api.Warning, 0, len(dbWarnings))
+		for _, dbWarning := range dbWarnings {
+			warning, err := tx.WarningToAPI(dbWarning)
+			if err != nil {
+				return err
+			}
+
+			warnings = append(warnings, *warning)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !recursion {
+		urls := make([]string, 0, len(warnings))
+		for _, warning := range warnings {
+			urls = append(urls, fmt.Sprintf("/%s/warnings/%s", version.APIVersion, warning.UUID))
+		}
+
+		return response.SyncResponse(true, urls)
+	}
+
+	return response.SyncResponse(true, warnings)
+}
+
+// doWarningGet returns the API representation of the warning with the given UUID.
+func doWarningGet(d *Daemon, UUID string) (*api.Warning, error) {
+	var warning *api.Warning
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		dbWarning, err := tx.GetWarning(UUID)
+		if err != nil {
+			return err
+		}
+
+		warning, err = tx.WarningToAPI(*dbWarning)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return warning, nil
+}
+
+func warningGet(d *Daemon, r *http.Request) response.Response {
+	warning, err := doWarningGet(d, mux.Vars(r)["uuid"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseETag(true, warning, warning.Writable())
+}
+
+func warningPatch(d *Daemon, r *http.Request) response.Response {
+	return warningUpdate(d, r, true)
+}
+
+func warningPut(d *Daemon, r *http.Request) response.Response {
+	return warningUpdate(d, r, false)
+}
+
+// warningUpdate updates the status of a warning, keeping the current one when patching without a status.
+func warningUpdate(d *Daemon, r *http.Request, patch bool) response.Response {
+	UUID := mux.Vars(r)["uuid"]
+
+	warning, err := doWarningGet(d, UUID)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	err = util.EtagCheck(r, warning.Writable())
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	req := api.WarningPut{}
+	if patch {
+		req = warning.Writable()
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	status, err := db.WarningStatusFromString(req.Status)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		return tx.UpdateWarningStatus(UUID, status)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+func warningDelete(d *Daemon, r *http.Request) response.Response {
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		return tx.DeleteWarning(mux.Vars(r)["uuid"])
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// warningUpdateLocalNode records a warning of the given type about an entity of the local member if err isn't
+// nil, and resolves it otherwise.
+func warningUpdateLocalNode(d *Daemon, projectName string, entityType string, entityID int, typeName string, err error) {
+	dbErr := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		if err != nil {
+			return tx.UpsertWarningLocalNode(projectName, entityType, entityID, typeName, err.Error())
+		}
+
+		return tx.ResolveWarningsLocalNode(projectName, entityType, entityID, typeName)
+	})
+	if dbErr != nil {
+		logger.Warn("Failed to update warning", log.Ctx{"project": projectName, "type": typeName, "err": dbErr})
+	}
+}
@@ -0,0 +1,32 @@
+package api
+
+// BatchPost represents an ordered list of API requests to run server-side
+//
+// API extension: batch
+type BatchPost struct {
+	Requests []BatchRequest `json:"requests" yaml:"requests"`
+
+	// Attempt to undo the changes made by earlier requests if one fails
+	Atomic bool `json:"atomic" yaml:"atomic"`
+}
+
+// BatchRequest represents a single API request in a batch
+//
+// API extension: batch
+type BatchRequest struct {
+	Method string      `json:"method" yaml:"method"`
+	URL    string      `json:"url" yaml:"url"`
+	Body   interface{} `json:"body" yaml:"body"`
+}
+
+// BatchResult represents the outcome of a single API request in a batch
+//
+// API extension: batch
+type BatchResult struct {
+	Method     string      `json:"method" yaml:"method"`
+	URL        string      `json:"url" yaml:"url"`
+	StatusCode int         `json:"status_code" yaml:"status_code"`
+	Error      string      `json:"error" yaml:"error"`
+	Metadata   interface{} `json:"metadata" yaml:"metadata"`
+	Reverted   bool        `json:"reverted" yaml:"reverted"`
+}
@@ -1,5 +1,10 @@
 package api
 
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
 // Cluster represents high-level information about a LXD cluster.
 //
 // API extension: clustering
@@ -41,6 +46,33 @@ type ClusterPut struct {
 	ClusterPassword string `json:"cluster_password" yaml:"cluster_password"`
 }
 
+// ClusterMembersPost represents the fields required to request a join token for a new cluster member.
+//
+// API extension: clustering_join_token
+type ClusterMembersPost struct {
+	ServerName string `json:"server_name" yaml:"server_name"`
+}
+
+// ClusterMemberJoinToken represents the fields contained within an encoded cluster member join token.
+//
+// API extension: clustering_join_token
+type ClusterMemberJoinToken struct {
+	ServerName  string   `json:"server_name" yaml:"server_name"`
+	Fingerprint string   `json:"fingerprint" yaml:"fingerprint"`
+	Addresses   []string `json:"addresses" yaml:"addresses"`
+	Secret      string   `json:"secret" yaml:"secret"`
+}
+
+// String encodes the cluster member join token as JSON and then base64.
+func (t *ClusterMemberJoinToken) String() string {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return ""
+	}
+
+	return base64.StdEncoding.EncodeToString(data)
+}
+
 // ClusterMemberPost represents the fields required to rename a LXD node.
 //
 // API extension: clustering
@@ -78,4 +110,53 @@ type ClusterMemberPut struct {
 
 	// API extension: clustering_failure_domains
 	FailureDomain string `json:"failure_domain" yaml:"failure_domain"`
+
+	// API extension: clustering_groups
+	Groups []string `json:"groups" yaml:"groups"`
+}
+
+// ClusterMemberStatePost represents the fields required to evacuate or
+// restore a LXD cluster member.
+//
+// API extension: clustering_evacuation
+type ClusterMemberStatePost struct {
+	Action string `json:"action" yaml:"action"`
+}
+
+// ClusterGroupsPost represents the fields available for a new cluster group.
+//
+// API extension: clustering_groups
+type ClusterGroupsPost struct {
+	ClusterGroupPut `yaml:",inline"`
+
+	Name string `json:"name" yaml:"name"`
+}
+
+// ClusterGroupPost represents the fields required to rename a cluster group.
+//
+// API extension: clustering_groups
+type ClusterGroupPost struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+// ClusterGroupPut represents the modifiable fields of a cluster group.
+//
+// API extension: clustering_groups
+type ClusterGroupPut struct {
+	Description string   `json:"description" yaml:"description"`
+	Members     []string `json:"members" yaml:"members"`
+}
+
+// ClusterGroup represents a cluster group.
+//
+// API extension: clustering_groups
+type ClusterGroup struct {
+	ClusterGroupPut `yaml:",inline"`
+
+	Name string `json:"name" yaml:"name"`
+}
+
+// Writable converts a full ClusterGroup struct into a ClusterGroupPut struct (filters read-only fields).
+func (group *ClusterGroup) Writable() ClusterGroupPut {
+	return group.ClusterGroupPut
 }
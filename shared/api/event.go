@@ -13,6 +13,9 @@ type Event struct {
 
 	// API extension: event_location
 	Location string `yaml:"location,omitempty" json:"location,omitempty"`
+
+	// API extension: event_lifecycle_requestor
+	Project string `yaml:"project,omitempty" json:"project,omitempty"`
 }
 
 // EventLogging represents a logging type event entry (admin only)
@@ -29,4 +32,16 @@ type EventLifecycle struct {
 	Action  string                 `yaml:"action" json:"action"`
 	Source  string                 `yaml:"source" json:"source"`
 	Context map[string]interface{} `yaml:"context,omitempty" json:"context,omitempty"`
+
+	// API extension: event_lifecycle_requestor
+	Requestor *EventLifecycleRequestor `yaml:"requestor,omitempty" json:"requestor,omitempty"`
+}
+
+// EventLifecycleRequestor represents the initial requestor for an event
+//
+// API extension: event_lifecycle_requestor
+type EventLifecycleRequestor struct {
+	Username string `yaml:"username" json:"username"`
+	Protocol string `yaml:"protocol" json:"protocol"`
+	Address  string `yaml:"address" json:"address"`
 }
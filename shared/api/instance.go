@@ -28,6 +28,14 @@ type InstancesPost struct {
 	Type         InstanceType   `json:"type" yaml:"type"`
 }
 
+// InstancesPut represents a state change applied to several LXD instances at once.
+//
+// API extension: instances_bulk_state
+type InstancesPut struct {
+	Names []string         `json:"names" yaml:"names"`
+	State InstanceStatePut `json:"state" yaml:"state"`
+}
+
 // InstancePost represents the fields required to rename/move a LXD instance.
 //
 // API extension: instances
@@ -49,6 +57,16 @@ type InstancePostTarget struct {
 	Websockets  map[string]string `json:"secrets,omitempty" yaml:"secrets,omitempty"`
 }
 
+// InstanceRebuildPost represents the fields required to rebuild a LXD instance from an image.
+//
+// API extension: instance_rebuild
+type InstanceRebuildPost struct {
+	Source InstanceSource `json:"source" yaml:"source"`
+
+	// Stop the instance if running and start it again once rebuilt
+	Force bool `json:"force" yaml:"force"`
+}
+
 // InstancePut represents the modifiable fields of a LXD instance.
 //
 // API extension: instances
@@ -134,4 +152,7 @@ type InstanceSource struct {
 	ContainerOnly bool              `json:"container_only,omitempty" yaml:"container_only,omitempty"` // Deprecated, use InstanceOnly.
 	Refresh       bool              `json:"refresh,omitempty" yaml:"refresh,omitempty"`
 	Project       string            `json:"project,omitempty" yaml:"project,omitempty"`
+
+	// API extension: backup_url_import
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
 }
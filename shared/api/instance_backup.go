@@ -14,6 +14,22 @@ type InstanceBackupsPost struct {
 
 	// API extension: backup_compression_algorithm
 	CompressionAlgorithm string `json:"compression_algorithm" yaml:"compression_algorithm"`
+
+	// API extension: backup_s3_upload
+	Target *BackupTarget `json:"target,omitempty" yaml:"target,omitempty"`
+
+	// API extension: backup_incremental
+	IncrementalFrom string `json:"incremental_from" yaml:"incremental_from"`
+
+	// API extension: backup_encryption
+	Encryption *BackupEncryption `json:"encryption,omitempty" yaml:"encryption,omitempty"`
+
+	// API extension: backup_snapshot_selection
+	Snapshots       []string `json:"snapshots,omitempty" yaml:"snapshots,omitempty"`
+	SnapshotsMaxAge string   `json:"snapshots_max_age" yaml:"snapshots_max_age"`
+
+	// API extension: backup_vm_export
+	Format string `json:"format" yaml:"format"`
 }
 
 // InstanceBackup represents a LXD instance backup.
@@ -26,6 +42,10 @@ type InstanceBackup struct {
 	InstanceOnly     bool      `json:"instance_only" yaml:"instance_only"`
 	ContainerOnly    bool      `json:"container_only" yaml:"container_only"` // Deprecated, use InstanceOnly.
 	OptimizedStorage bool      `json:"optimized_storage" yaml:"optimized_storage"`
+
+	// API extension: backup_cluster
+	Instance string `json:"instance" yaml:"instance"`
+	Location string `json:"location" yaml:"location"`
 }
 
 // InstanceBackupPost represents the fields available for the renaming of a instance backup.
@@ -34,3 +54,23 @@ type InstanceBackup struct {
 type InstanceBackupPost struct {
 	Name string `json:"name" yaml:"name"`
 }
+
+// BackupTarget represents the remote storage a backup is directly uploaded to.
+//
+// API extension: backup_s3_upload
+type BackupTarget struct {
+	Protocol   string `json:"protocol" yaml:"protocol"`
+	URL        string `json:"url" yaml:"url"`
+	BucketName string `json:"bucket_name" yaml:"bucket_name"`
+	Path       string `json:"path" yaml:"path"`
+	AccessKey  string `json:"access_key" yaml:"access_key"`
+	SecretKey  string `json:"secret_key" yaml:"secret_key"`
+}
+
+// BackupEncryption represents the server-side encryption applied to a backup tarball.
+//
+// API extension: backup_encryption
+type BackupEncryption struct {
+	Method     string   `json:"method" yaml:"method"`
+	Recipients []string `json:"recipients" yaml:"recipients"`
+}
@@ -59,6 +59,12 @@ type InstanceStateNetwork struct {
 	Mtu       int                           `json:"mtu" yaml:"mtu"`
 	State     string                        `json:"state" yaml:"state"`
 	Type      string                        `json:"type" yaml:"type"`
+
+	// Rate limits (in bit/s) applied to the traffic received and sent by the instance, 0 when unlimited
+	//
+	// API extension: instance_nic_limits_state
+	LimitsIngress int64 `json:"limits_ingress" yaml:"limits_ingress"`
+	LimitsEgress  int64 `json:"limits_egress" yaml:"limits_egress"`
 }
 
 // InstanceStateNetworkAddress represents a network address as part of the network section of a LXD
@@ -1,5 +1,9 @@
 package api
 
+import (
+	"time"
+)
+
 // NetworksPost represents the fields of a new LXD network
 //
 // API extension: network
@@ -60,6 +64,27 @@ func (network *Network) Writable() NetworkPut {
 	return network.NetworkPut
 }
 
+// NetworkFull is a combination of a network, its state on the cluster members and a summary of its leases
+//
+// API extension: network_full
+type NetworkFull struct {
+	Network `yaml:",inline"`
+
+	// State of the network interface, indexed by cluster member name
+	State map[string]NetworkState `json:"state" yaml:"state"`
+
+	// Summary of the leases of managed bridges
+	Leases NetworkLeasesSummary `json:"leases" yaml:"leases"`
+}
+
+// NetworkLeasesSummary represents the number of leases of a network
+//
+// API extension: network_full
+type NetworkLeasesSummary struct {
+	Static  int `json:"static" yaml:"static"`
+	Dynamic int `json:"dynamic" yaml:"dynamic"`
+}
+
 // NetworkLease represents a DHCP lease
 //
 // API extension: network_leases
@@ -71,6 +96,12 @@ type NetworkLease struct {
 
 	// API extension: network_leases_location
 	Location string `json:"location" yaml:"location"`
+
+	// API extension: network_leases_expiry
+	Source    string    `json:"source" yaml:"source"`
+	ClientID  string    `json:"client_id" yaml:"client_id"`
+	ExpiresAt time.Time `json:"expires_at" yaml:"expires_at"`
+	Gateway   string    `json:"gateway" yaml:"gateway"`
 }
 
 // NetworkState represents the network state
@@ -85,6 +116,23 @@ type NetworkState struct {
 	// API extension: network_state_bond_bridge
 	Bond   *NetworkStateBond   `json:"bond" yaml:"bond"`
 	Bridge *NetworkStateBridge `json:"bridge" yaml:"bridge"`
+
+	// API extension: network_state_details
+	VLAN *NetworkStateVLAN `json:"vlan" yaml:"vlan"`
+	OVS  *NetworkStateOVS  `json:"ovs" yaml:"ovs"`
+
+	// Counter samples of the interface and of the instance NICs (keyed by "<instance>/<device>")
+	// API extension: network_state_history
+	Samples    []NetworkStateSample            `json:"samples,omitempty" yaml:"samples,omitempty"`
+	NICSamples map[string][]NetworkStateSample `json:"nic_samples,omitempty" yaml:"nic_samples,omitempty"`
+}
+
+// NetworkStateSample represents the counters of an interface at a given time
+//
+// API extension: network_state_history
+type NetworkStateSample struct {
+	Time     time.Time            `json:"time" yaml:"time"`
+	Counters NetworkStateCounters `json:"counters" yaml:"counters"`
 }
 
 // NetworkStateAddress represents a network address
@@ -95,6 +143,27 @@ type NetworkStateAddress struct {
 	Scope   string `json:"scope" yaml:"scope"`
 }
 
+// NetworkMetrics represents the usage metrics of a network
+//
+// API extension: network_metrics
+type NetworkMetrics struct {
+	Counters      NetworkStateCounters         `json:"counters" yaml:"counters"`
+	Leases        int64                        `json:"leases" yaml:"leases"`
+	NICs          int64                        `json:"nics" yaml:"nics"`
+	FirewallRules []NetworkMetricsFirewallRule `json:"firewall_rules" yaml:"firewall_rules"`
+}
+
+// NetworkMetricsFirewallRule represents the hit counters of a firewall rule of a network
+//
+// API extension: network_metrics
+type NetworkMetricsFirewallRule struct {
+	Location string `json:"location" yaml:"location"`
+	Chain    string `json:"chain" yaml:"chain"`
+	Rule     string `json:"rule" yaml:"rule"`
+	Packets  int64  `json:"packets" yaml:"packets"`
+	Bytes    int64  `json:"bytes" yaml:"bytes"`
+}
+
 // NetworkStateCounters represents packet counters
 type NetworkStateCounters struct {
 	BytesReceived   int64 `json:"bytes_received" yaml:"bytes_received"`
@@ -115,6 +184,10 @@ type NetworkStateBond struct {
 	MIIState     string `json:"mii_state" yaml:"mii_state"`
 
 	LowerDevices []string `json:"lower_devices" yaml:"lower_devices"`
+
+	// MII state of each lower device
+	// API extension: network_state_details
+	LowerDevicesMIIState map[string]string `json:"lower_devices_mii_state" yaml:"lower_devices_mii_state"`
 }
 
 // NetworkStateBridge represents bond specific state
@@ -128,4 +201,37 @@ type NetworkStateBridge struct {
 	VLANFiltering bool   `json:"vlan_filtering" yaml:"vlan_filtering"`
 
 	UpperDevices []string `json:"upper_devices" yaml:"upper_devices"`
+
+	// API extension: network_state_details
+	MulticastSnooping bool                                `json:"multicast_snooping" yaml:"multicast_snooping"`
+	UpperDevicesVLANs map[string][]NetworkStateBridgeVLAN `json:"upper_devices_vlans" yaml:"upper_devices_vlans"`
+}
+
+// NetworkStateBridgeVLAN represents a VLAN of a bridge port
+// API extension: network_state_details
+type NetworkStateBridgeVLAN struct {
+	ID       uint64 `json:"id" yaml:"id"`
+	PVID     bool   `json:"pvid" yaml:"pvid"`
+	Untagged bool   `json:"untagged" yaml:"untagged"`
+}
+
+// NetworkStateVLAN represents VLAN interface specific state
+// API extension: network_state_details
+type NetworkStateVLAN struct {
+	LowerDevice string `json:"lower_device" yaml:"lower_device"`
+	VID         uint64 `json:"vid" yaml:"vid"`
+}
+
+// NetworkStateOVS represents Open vSwitch bridge specific state
+// API extension: network_state_details
+type NetworkStateOVS struct {
+	Ports []NetworkStateOVSPort `json:"ports" yaml:"ports"`
+}
+
+// NetworkStateOVSPort represents a port of an Open vSwitch bridge
+// API extension: network_state_details
+type NetworkStateOVSPort struct {
+	Name       string   `json:"name" yaml:"name"`
+	VLANTag    uint64   `json:"vlan_tag" yaml:"vlan_tag"`
+	VLANTrunks []uint64 `json:"vlan_trunks" yaml:"vlan_trunks"`
 }
@@ -0,0 +1,57 @@
+package api
+
+// NetworkACLRule represents a single rule in a LXD network ACL
+//
+// API extension: network_acl
+type NetworkACLRule struct {
+	Action          string `json:"action" yaml:"action"`
+	Source          string `json:"source,omitempty" yaml:"source,omitempty"`
+	Destination     string `json:"destination,omitempty" yaml:"destination,omitempty"`
+	Protocol        string `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+	SourcePort      string `json:"source_port,omitempty" yaml:"source_port,omitempty"`
+	DestinationPort string `json:"destination_port,omitempty" yaml:"destination_port,omitempty"`
+	ICMPType        string `json:"icmp_type,omitempty" yaml:"icmp_type,omitempty"`
+	ICMPCode        string `json:"icmp_code,omitempty" yaml:"icmp_code,omitempty"`
+	Description     string `json:"description,omitempty" yaml:"description,omitempty"`
+	State           string `json:"state" yaml:"state"`
+}
+
+// NetworkACLPost used for renaming an ACL
+//
+// API extension: network_acl
+type NetworkACLPost struct {
+	Name string `json:"name" yaml:"name"` // Name of ACL.
+}
+
+// NetworkACLPut used for updating an ACL
+//
+// API extension: network_acl
+type NetworkACLPut struct {
+	Description string            `json:"description" yaml:"description"`
+	Egress      []NetworkACLRule  `json:"egress" yaml:"egress"`
+	Ingress     []NetworkACLRule  `json:"ingress" yaml:"ingress"`
+	Config      map[string]string `json:"config" yaml:"config"`
+}
+
+// NetworkACL used for displaying an ACL
+//
+// API extension: network_acl
+type NetworkACL struct {
+	NetworkACLPost `yaml:",inline"`
+	NetworkACLPut  `yaml:",inline"`
+
+	UsedBy []string `json:"used_by" yaml:"used_by"`
+}
+
+// Writable converts a full NetworkACL struct into a NetworkACLPut struct (filters read-only fields)
+func (acl *NetworkACL) Writable() NetworkACLPut {
+	return acl.NetworkACLPut
+}
+
+// NetworkACLsPost used for creating an ACL
+//
+// API extension: network_acl
+type NetworkACLsPost struct {
+	NetworkACLPost `yaml:",inline"`
+	NetworkACLPut  `yaml:",inline"`
+}
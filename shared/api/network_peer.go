@@ -0,0 +1,35 @@
+package api
+
+// NetworkPeerPut represents the modifiable fields of a LXD network peering
+//
+// API extension: network_peer
+type NetworkPeerPut struct {
+	Description string            `json:"description" yaml:"description"`
+	Config      map[string]string `json:"config" yaml:"config"`
+}
+
+// NetworkPeersPost represents the fields of a new LXD network peering
+//
+// API extension: network_peer
+type NetworkPeersPost struct {
+	NetworkPeerPut `yaml:",inline"`
+
+	Name          string `json:"name" yaml:"name"`
+	TargetNetwork string `json:"target_network" yaml:"target_network"`
+}
+
+// NetworkPeer represents a LXD network peering
+//
+// API extension: network_peer
+type NetworkPeer struct {
+	NetworkPeerPut `yaml:",inline"`
+
+	Name          string `json:"name" yaml:"name"`
+	TargetNetwork string `json:"target_network" yaml:"target_network"`
+	Status        string `json:"status" yaml:"status"`
+}
+
+// Writable converts a full NetworkPeer struct into a NetworkPeerPut struct (filters read-only fields)
+func (p *NetworkPeer) Writable() NetworkPeerPut {
+	return p.NetworkPeerPut
+}
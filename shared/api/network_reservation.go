@@ -0,0 +1,11 @@
+package api
+
+// NetworkReservation represents a static DHCP reservation on a LXD network
+//
+// API extension: network_reservations
+type NetworkReservation struct {
+	Description string `json:"description" yaml:"description"`
+	MAC         string `json:"mac" yaml:"mac"`
+	IPv4Address string `json:"ipv4_address" yaml:"ipv4_address"`
+	IPv6Address string `json:"ipv6_address" yaml:"ipv6_address"`
+}
@@ -0,0 +1,33 @@
+package api
+
+// NetworkZonesPost used for creating a network zone
+//
+// API extension: network_dns
+type NetworkZonesPost struct {
+	NetworkZonePut `yaml:",inline"`
+
+	Name string `json:"name" yaml:"name"` // Name of the DNS zone.
+}
+
+// NetworkZonePut used for updating a network zone
+//
+// API extension: network_dns
+type NetworkZonePut struct {
+	Description string            `json:"description" yaml:"description"`
+	Config      map[string]string `json:"config" yaml:"config"`
+}
+
+// NetworkZone used for displaying a network zone
+//
+// API extension: network_dns
+type NetworkZone struct {
+	NetworkZonePut `yaml:",inline"`
+
+	Name   string   `json:"name" yaml:"name"`
+	UsedBy []string `json:"used_by" yaml:"used_by"`
+}
+
+// Writable converts a full NetworkZone struct into a NetworkZonePut struct (filters read-only fields)
+func (zone *NetworkZone) Writable() NetworkZonePut {
+	return zone.NetworkZonePut
+}
@@ -20,4 +20,7 @@ type Operation struct {
 
 	// API extension: operation_location
 	Location string `json:"location" yaml:"location"`
+
+	// API extension: operations_events_all_projects
+	Project string `json:"project" yaml:"project"`
 }
@@ -19,6 +19,9 @@ type Resources struct {
 
 	// API extension: resources_system
 	System ResourcesSystem `json:"system" yaml:"system"`
+
+	// API extension: resources_load
+	Load ResourcesLoad `json:"load" yaml:"load"`
 }
 
 // ResourcesCPU represents the cpu resources available on the system
@@ -305,6 +308,9 @@ type ResourcesMemoryNode struct {
 type ResourcesStoragePool struct {
 	Space  ResourcesStoragePoolSpace  `json:"space,omitempty" yaml:"space,omitempty"`
 	Inodes ResourcesStoragePoolInodes `json:"inodes,omitempty" yaml:"inodes,omitempty"`
+
+	// API extension: storage_pool_resources_volumes
+	Volumes []ResourcesStoragePoolVolume `json:"volumes,omitempty" yaml:"volumes,omitempty"`
 }
 
 // ResourcesStoragePoolSpace represents the space available to a given storage pool
@@ -321,6 +327,16 @@ type ResourcesStoragePoolInodes struct {
 	Total uint64 `json:"total" yaml:"total"`
 }
 
+// ResourcesStoragePoolVolume represents the space used by a volume of a given storage pool
+// API extension: storage_pool_resources_volumes
+type ResourcesStoragePoolVolume struct {
+	Name     string `json:"name" yaml:"name"`
+	Type     string `json:"type" yaml:"type"`
+	Project  string `json:"project" yaml:"project"`
+	Location string `json:"location" yaml:"location"`
+	Used     uint64 `json:"used" yaml:"used"`
+}
+
 // ResourcesUSB represents the USB devices available on the system
 // API extension: resources_usb_pci
 type ResourcesUSB struct {
@@ -415,3 +431,12 @@ type ResourcesSystemMotherboard struct {
 	Serial  string `json:"serial" yaml:"serial"`
 	Version string `json:"version" yaml:"version"`
 }
+
+// ResourcesLoad represents the load of the system
+// API extension: resources_load
+type ResourcesLoad struct {
+	Average1  float64 `json:"average_1" yaml:"average_1"`
+	Average5  float64 `json:"average_5" yaml:"average_5"`
+	Average15 float64 `json:"average_15" yaml:"average_15"`
+	Processes uint64  `json:"processes" yaml:"processes"`
+}
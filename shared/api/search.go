@@ -0,0 +1,15 @@
+package api
+
+// SearchResult represents an entity matching a search query
+//
+// API extension: search
+type SearchResult struct {
+	Type        string `json:"type" yaml:"type"`
+	Name        string `json:"name" yaml:"name"`
+	Project     string `json:"project" yaml:"project"`
+	URL         string `json:"url" yaml:"url"`
+	Description string `json:"description" yaml:"description"`
+
+	// Fields that matched the query (name, description or config key)
+	Matches []string `json:"matches" yaml:"matches"`
+}
@@ -25,6 +25,16 @@ type StoragePool struct {
 	Locations []string `json:"locations" yaml:"locations"`
 }
 
+// StoragePoolFull is a combination of a storage pool and of its resources on the cluster members.
+//
+// API extension: storage_pool_full
+type StoragePoolFull struct {
+	StoragePool `yaml:",inline"`
+
+	// Space and inodes of the pool, added up across the cluster members unless backed by remote storage
+	Resources *ResourcesStoragePool `json:"resources" yaml:"resources"`
+}
+
 // StoragePoolPut represents the modifiable fields of a LXD storage pool.
 //
 // API extension: storage
@@ -0,0 +1,28 @@
+package cancel
+
+import (
+	"context"
+	"io"
+)
+
+// contextWriter is an io.Writer which stops writing once its context is done.
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+// NewContextWriter returns a writer passing writes through to w until ctx is done, after which writes fail
+// with the context's error.
+func NewContextWriter(ctx context.Context, w io.Writer) io.Writer {
+	return &contextWriter{ctx: ctx, w: w}
+}
+
+// Write writes p to the underlying writer, unless the context is done.
+func (cw *contextWriter) Write(p []byte) (int, error) {
+	err := cw.ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	return cw.w.Write(p)
+}
@@ -1,12 +1,18 @@
 package filter
 
+import (
+	"fmt"
+)
+
 // Match returns true if the given object matches the given filter.
 func Match(obj interface{}, clauses []Clause) bool {
 	match := true
 
 	for _, clause := range clauses {
 		value := ValueOf(obj, clause.Field)
-		clauseMatch := value == clause.Value
+
+		// Non-string fields (e.g. booleans) are compared through their string representation.
+		clauseMatch := value != nil && fmt.Sprintf("%v", value) == clause.Value
 
 		if clause.Operator == "ne" {
 			clauseMatch = !clauseMatch
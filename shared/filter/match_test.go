@@ -4,8 +4,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/lxc/lxd/lxd/filter"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/filter"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -76,3 +76,31 @@ func TestMatch_Image(t *testing.T) {
 	}
 
 }
+
+func TestMatch_Network(t *testing.T) {
+	network := api.Network{
+		NetworkPut: api.NetworkPut{
+			Config: map[string]string{
+				"ipv4.nat": "true",
+			},
+		},
+		Name:    "lxdbr0",
+		Type:    "bridge",
+		Managed: true,
+	}
+	cases := map[string]interface{}{
+		"type eq bridge and managed eq true": true,
+		"managed eq false":                   false,
+		"config.ipv4.nat eq true":            true,
+		"config.ipv6.nat eq true":            false,
+		"not name eq lxdbr0":                 false,
+	}
+	for s := range cases {
+		t.Run(s, func(t *testing.T) {
+			f, err := filter.Parse(s)
+			require.NoError(t, err)
+			match := filter.Match(network, f)
+			assert.Equal(t, cases[s], match)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package shared
 
 import (
 	"fmt"
+	"net"
 	"regexp"
 	"strconv"
 	"strings"
@@ -71,12 +72,50 @@ var HugePageSizeSuffix = [...]string{"64KB", "1MB", "2MB", "1GB"}
 // to an appropriate checker function, which validates whether or not a
 // given value is syntactically legal.
 var KnownInstanceConfigKeys = map[string]func(value string) error{
+	"backups.optimized_storage": validate.Optional(validate.IsBool),
+	"backups.retention":         validate.Optional(validate.IsUint32),
+	"backups.schedule":          IsSchedule,
+	"backups.schedule.stopped":  validate.Optional(validate.IsBool),
+	"backups.target": func(value string) error {
+		if value == "" {
+			return nil
+		}
+
+		return validate.IsOneOf(value, []string{"local", "s3"})
+	},
+
+	"boot.autorestart":           validate.Optional(validate.IsBool),
 	"boot.autostart":             validate.Optional(validate.IsBool),
 	"boot.autostart.delay":       validate.Optional(validate.IsInt64),
 	"boot.autostart.priority":    validate.Optional(validate.IsInt64),
+	"boot.health_check.command":  validate.IsAny,
+	"boot.health_check.failures": validate.Optional(validate.IsUint32),
+	"boot.health_check.interval": validate.Optional(validate.IsUint32),
+	"boot.health_check.tcp": func(value string) error {
+		if value == "" {
+			return nil
+		}
+
+		// Either a port of the instance or an address and port.
+		_, port, err := net.SplitHostPort(value)
+		if err != nil {
+			port = value
+		}
+
+		n, err := strconv.ParseUint(port, 10, 16)
+		if err != nil || n == 0 {
+			return fmt.Errorf("Invalid port %q", port)
+		}
+
+		return nil
+	},
 	"boot.stop.priority":         validate.Optional(validate.IsInt64),
 	"boot.host_shutdown_timeout": validate.Optional(validate.IsInt64),
 
+	"cluster.evacuate": func(value string) error {
+		return validate.IsOneOf(value, []string{"auto", "migrate", "live-migrate", "stop"})
+	},
+
 	"limits.cpu": func(value string) error {
 		if value == "" {
 			return nil
@@ -179,6 +218,7 @@ var KnownInstanceConfigKeys = map[string]func(value string) error{
 	"migration.incremental.memory":            validate.Optional(validate.IsBool),
 	"migration.incremental.memory.iterations": validate.Optional(validate.IsUint32),
 	"migration.incremental.memory.goal":       validate.Optional(validate.IsUint32),
+	"migration.stateful":                      validate.Optional(validate.IsBool),
 
 	"nvidia.runtime":             validate.Optional(validate.IsBool),
 	"nvidia.driver.capabilities": validate.IsAny,
@@ -214,22 +254,7 @@ var KnownInstanceConfigKeys = map[string]func(value string) error{
 	"security.syscalls.intercept.setxattr":      validate.Optional(validate.IsBool),
 	"security.syscalls.whitelist":               validate.IsAny,
 
-	"snapshots.schedule": func(value string) error {
-		if value == "" {
-			return nil
-		}
-
-		if len(strings.Split(value, " ")) != 5 {
-			return fmt.Errorf("Schedule must be of the form: <minute> <hour> <day-of-month> <month> <day-of-week>")
-		}
-
-		_, err := cron.Parse(fmt.Sprintf("* %s", value))
-		if err != nil {
-			return errors.Wrap(err, "Error parsing schedule")
-		}
-
-		return nil
-	},
+	"snapshots.schedule":         IsSchedule,
 	"snapshots.schedule.stopped": validate.Optional(validate.IsBool),
 	"snapshots.pattern":          validate.IsAny,
 	"snapshots.expiry": func(value string) error {
@@ -245,14 +270,99 @@ var KnownInstanceConfigKeys = map[string]func(value string) error{
 	"raw.qemu":     validate.IsAny,
 	"raw.seccomp":  validate.IsAny,
 
-	"volatile.apply_template":   validate.IsAny,
-	"volatile.base_image":       validate.IsAny,
-	"volatile.last_state.idmap": validate.IsAny,
-	"volatile.last_state.power": validate.IsAny,
-	"volatile.idmap.base":       validate.IsAny,
-	"volatile.idmap.current":    validate.IsAny,
-	"volatile.idmap.next":       validate.IsAny,
-	"volatile.apply_quota":      validate.IsAny,
+	"volatile.apply_template":      validate.IsAny,
+	"volatile.autorestart.pending": validate.IsAny,
+	"volatile.base_image":          validate.IsAny,
+	"volatile.last_state.idmap":    validate.IsAny,
+	"volatile.last_state.power":    validate.IsAny,
+	"volatile.idmap.base":          validate.IsAny,
+	"volatile.idmap.current":       validate.IsAny,
+	"volatile.idmap.next":          validate.IsAny,
+	"volatile.apply_quota":         validate.IsAny,
+	"volatile.evacuate.origin":     validate.IsAny,
+	"volatile.evacuate.running":    validate.IsAny,
+}
+
+// IsSchedule validates a cron-like schedule of the form "<minute> <hour> <day-of-month> <month> <day-of-week>".
+// Several schedules can be given, separated by commas, and the @hourly, @daily, @midnight, @weekly, @monthly,
+// @annually and @yearly aliases are supported.
+func IsSchedule(value string) error {
+	_, err := parseSchedule(value, "")
+	return err
+}
+
+// ScheduleDue returns whether the given schedule is due at the given time, ignoring anything more precise than
+// minutes. The minute and hour at which aliases are due are derived from the given subject (e.g. the instance
+// name), spreading the work of many instances over time.
+func ScheduleDue(schedule string, subject string, now time.Time) (bool, error) {
+	scheds, err := parseSchedule(schedule, subject)
+	if err != nil {
+		return false, err
+	}
+
+	// Truncate the time now back to the start of the minute, before passing to the cron scheduler, as it
+	// will add 1s to the scheduled time and we don't want the next scheduled time to roll over to the next
+	// minute and break the time comparison below.
+	now = now.Truncate(time.Minute)
+
+	for _, sched := range scheds {
+		if now.Equal(sched.Next(now).Truncate(time.Minute)) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// parseSchedule parses a comma-separated list of cron expressions and aliases.
+func parseSchedule(value string, subject string) ([]cron.Schedule, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	// Derive a stable minute and hour from the subject for the aliases.
+	sum := 0
+	for _, r := range subject {
+		sum = sum*31 + int(r)
+		sum = sum % 1440
+	}
+
+	minute := sum % 60
+	hour := sum / 60
+
+	scheds := []cron.Schedule{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+
+		switch entry {
+		case "@hourly":
+			entry = fmt.Sprintf("%d * * * *", minute)
+		case "@daily":
+			entry = fmt.Sprintf("%d %d * * *", minute, hour)
+		case "@midnight":
+			entry = fmt.Sprintf("%d 0 * * *", minute)
+		case "@weekly":
+			entry = fmt.Sprintf("%d %d * * 0", minute, hour)
+		case "@monthly":
+			entry = fmt.Sprintf("%d %d 1 * *", minute, hour)
+		case "@annually", "@yearly":
+			entry = fmt.Sprintf("%d %d 1 1 *", minute, hour)
+		}
+
+		if len(strings.Fields(entry)) != 5 {
+			return nil, fmt.Errorf("Schedule must be of the form: <minute> <hour> <day-of-month> <month> <day-of-week>")
+		}
+
+		// Extend the schedule to one that is accepted by the used cron parser.
+		sched, err := cron.Parse(fmt.Sprintf("* %s", entry))
+		if err != nil {
+			return nil, errors.Wrap(err, "Error parsing schedule")
+		}
+
+		scheds = append(scheds, sched)
+	}
+
+	return scheds, nil
 }
 
 // ConfigKeyChecker returns a function that will check whether or not
@@ -0,0 +1,82 @@
+package shared
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSchedule(t *testing.T) {
+	valid := []string{"", "0 * * * *", "*/5 * * * 1-5", "0 6 * * *, 0 18 * * *", "@daily", "@hourly,@weekly"}
+	for _, value := range valid {
+		assert.NoError(t, IsSchedule(value), value)
+	}
+
+	invalid := []string{"0 * * *", "@fortnightly", "0 25 * * *", "0 * * * *,"}
+	for _, value := range invalid {
+		assert.Error(t, IsSchedule(value), value)
+	}
+}
+
+func TestHealthCheckTCP(t *testing.T) {
+	check := KnownInstanceConfigKeys["boot.health_check.tcp"]
+
+	valid := []string{"", "80", "10.0.0.1:8080", "[fd42::1]:443", "localhost:22"}
+	for _, value := range valid {
+		assert.NoError(t, check(value), value)
+	}
+
+	invalid := []string{"0", "http", "65536", "10.0.0.1:", "10.0.0.1:foo"}
+	for _, value := range invalid {
+		assert.Error(t, check(value), value)
+	}
+}
+
+func TestScheduleDue(t *testing.T) {
+	now := time.Date(2021, 3, 1, 18, 0, 30, 0, time.UTC)
+
+	due, err := ScheduleDue("0 18 * * *", "default/c1", now)
+	require.NoError(t, err)
+	assert.True(t, due)
+
+	due, err = ScheduleDue("0 6 * * *", "default/c1", now)
+	require.NoError(t, err)
+	assert.False(t, due)
+
+	due, err = ScheduleDue("0 6 * * *, 0 18 * * *", "default/c1", now)
+	require.NoError(t, err)
+	assert.True(t, due)
+}
+
+func TestScheduleDue_Aliases(t *testing.T) {
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// A daily schedule is due exactly once a day, always at the same time for a given subject.
+	matches := []time.Time{}
+	for now := start; now.Before(start.Add(48 * time.Hour)); now = now.Add(time.Minute) {
+		due, err := ScheduleDue("@daily", "default/c1", now)
+		require.NoError(t, err)
+
+		if due {
+			matches = append(matches, now)
+		}
+	}
+
+	require.Len(t, matches, 2)
+	assert.Equal(t, 24*time.Hour, matches[1].Sub(matches[0]))
+
+	// An hourly schedule is due once an hour.
+	count := 0
+	for now := start; now.Before(start.Add(24 * time.Hour)); now = now.Add(time.Minute) {
+		due, err := ScheduleDue("@hourly", "default/c2", now)
+		require.NoError(t, err)
+
+		if due {
+			count++
+		}
+	}
+
+	assert.Equal(t, 24, count)
+}
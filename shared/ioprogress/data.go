@@ -13,4 +13,10 @@ type ProgressData struct {
 
 	// Total number of bytes (for files)
 	TotalBytes int64
+
+	// Transfer speed in bytes per second
+	BytesPerSecond int64
+
+	// Stage of the operation the progress applies to (for operations)
+	Stage string
 }
@@ -0,0 +1,50 @@
+package shared
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// CertificateAddTokenDecode decodes a base64 and JSON encoded certificate add token.
+func CertificateAddTokenDecode(input string) (*api.CertificateAddToken, error) {
+	token := api.CertificateAddToken{}
+
+	err := tokenDecode(input, &token)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.ClientName == "" || token.Fingerprint == "" || token.Secret == "" || len(token.Addresses) == 0 {
+		return nil, fmt.Errorf("Invalid certificate add token")
+	}
+
+	return &token, nil
+}
+
+// ClusterJoinTokenDecode decodes a base64 and JSON encoded cluster member join token.
+func ClusterJoinTokenDecode(input string) (*api.ClusterMemberJoinToken, error) {
+	token := api.ClusterMemberJoinToken{}
+
+	err := tokenDecode(input, &token)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.ServerName == "" || token.Fingerprint == "" || token.Secret == "" || len(token.Addresses) == 0 {
+		return nil, fmt.Errorf("Invalid cluster join token")
+	}
+
+	return &token, nil
+}
+
+func tokenDecode(input string, token interface{}) error {
+	data, err := base64.StdEncoding.DecodeString(input)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, token)
+}
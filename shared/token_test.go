@@ -0,0 +1,35 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestCertificateAddTokenDecode(t *testing.T) {
+	token := api.CertificateAddToken{
+		ClientName:  "foo",
+		Fingerprint: "abcd",
+		Addresses:   []string{"10.0.0.1:8443"},
+		Secret:      "secret",
+	}
+
+	decoded, err := CertificateAddTokenDecode(token.String())
+	require.NoError(t, err)
+	assert.Equal(t, token, *decoded)
+
+	_, err = CertificateAddTokenDecode("foo")
+	assert.Error(t, err)
+
+	// A cluster join token isn't a valid certificate add token.
+	joinToken := api.ClusterMemberJoinToken{ServerName: "node1", Fingerprint: "abcd", Addresses: []string{"10.0.0.1:8443"}, Secret: "secret"}
+	_, err = CertificateAddTokenDecode(joinToken.String())
+	assert.Error(t, err)
+
+	decodedJoin, err := ClusterJoinTokenDecode(joinToken.String())
+	require.NoError(t, err)
+	assert.Equal(t, joinToken, *decodedJoin)
+}
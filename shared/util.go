@@ -3,6 +3,7 @@ package shared
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/gob"
 	"encoding/hex"
@@ -880,7 +881,13 @@ func (e RunError) Error() string {
 // the default environment is used. If the command fails to start or returns a non-zero exit code
 // then an error is returned containing the output of stderr too.
 func RunCommandSplit(env []string, filesInherit []*os.File, name string, arg ...string) (string, string, error) {
-	cmd := exec.Command(name, arg...)
+	return RunCommandSplitContext(context.Background(), env, filesInherit, name, arg...)
+}
+
+// RunCommandSplitContext is like RunCommandSplit but kills the command if the context is done before it
+// completes.
+func RunCommandSplitContext(ctx context.Context, env []string, filesInherit []*os.File, name string, arg ...string) (string, string, error) {
+	cmd := exec.CommandContext(ctx, name, arg...)
 
 	if env != nil {
 		cmd.Env = env
@@ -916,6 +923,14 @@ func RunCommand(name string, arg ...string) (string, error) {
 	return stdout, err
 }
 
+// RunCommandContext runs a command with optional arguments and returns stdout, killing the command if the
+// context is done before it completes. If the command fails to start or returns a non-zero exit code then an
+// error is returned containing the output of stderr.
+func RunCommandContext(ctx context.Context, name string, arg ...string) (string, error) {
+	stdout, _, err := RunCommandSplitContext(ctx, nil, nil, name, arg...)
+	return stdout, err
+}
+
 // RunCommandInheritFds runs a command with optional arguments and passes a set
 // of file descriptors to the newly created process, returning stdout. If the
 // command fails to start or returns a non-zero exit code then an error is
@@ -934,7 +949,13 @@ func RunCommandCLocale(name string, arg ...string) (string, error) {
 }
 
 func RunCommandWithFds(stdin io.Reader, stdout io.Writer, name string, arg ...string) error {
-	cmd := exec.Command(name, arg...)
+	return RunCommandWithFdsContext(context.Background(), stdin, stdout, name, arg...)
+}
+
+// RunCommandWithFdsContext is like RunCommandWithFds but kills the command if the context is done before it
+// completes.
+func RunCommandWithFdsContext(ctx context.Context, stdin io.Reader, stdout io.Writer, name string, arg ...string) error {
+	cmd := exec.CommandContext(ctx, name, arg...)
 
 	if stdin != nil {
 		cmd.Stdin = stdin
@@ -1073,12 +1094,12 @@ func DownloadFileHash(httpClient *http.Client, useragent string, progress func(p
 			ReadCloser: r.Body,
 			Tracker: &ioprogress.ProgressTracker{
 				Length: r.ContentLength,
-				Handler: func(percent int64, speed int64) {
+				DataHandler: func(data ioprogress.ProgressData) {
 					if filename != "" {
-						progress(ioprogress.ProgressData{Text: fmt.Sprintf("%s: %d%% (%s/s)", filename, percent, units.GetByteSizeString(speed, 2))})
-					} else {
-						progress(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
+						data.Text = fmt.Sprintf("%s: %s", filename, data.Text)
 					}
+
+					progress(data)
 				},
 			},
 		}
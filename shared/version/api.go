@@ -222,6 +222,93 @@ var APIExtensions = []string{
 	"projects_limits_disk",
 	"network_type_macvlan",
 	"network_type_sriov",
+	"batch",
+	"https_compression",
+	"syslog_events",
+	"search",
+	"backup_s3_upload",
+	"backup_incremental",
+	"backup_schedule",
+	"backup_encryption",
+	"backup_snapshot_selection",
+	"backup_cluster",
+	"backup_vm_export",
+	"backup_verify",
+	"operation_progress",
+	"instance_rebuild",
+	"offline_mode",
+	"network_forward",
+	"network_acl",
+	"network_type_ovn",
+	"network_type_physical",
+	"network_dns",
+	"network_peer",
+	"network_reservations",
+	"network_leases_expiry",
+	"network_metrics",
+	"api_pagination",
+	"instances_bulk_state",
+	"vm_live_migration",
+	"clustering_evacuation",
+	"clustering_groups",
+	"resources_load",
+	"clustering_instance_placement",
+	"projects_limits_networks",
+	"projects_restricted_resources",
+	"projects_networks",
+	"metrics",
+	"certificate_roles",
+	"certificate_token",
+	"clustering_join_token",
+	"oidc",
+	"snapshot_schedule_aliases",
+	"backup_schedule_projects",
+	"backup_url_import",
+	"backup_format_version",
+	"custom_volume_backup",
+	"storage_volume_cluster_move",
+	"storage_lvm_foreign_volumes",
+	"storage_volume_block_shared",
+	"storage_pool_resources_volumes",
+	"vm_device_hotplug",
+	"device_hotplug_events",
+	"gpu_mig_sriov",
+	"vm_disk_virtiofs",
+	"vm_agent_file_exec",
+	"vm_console_log",
+	"clustering_event_hubs",
+	"event_lifecycle_requestor",
+	"events_webhook",
+	"operation_cancel_context",
+	"operations_events_all_projects",
+	"projects_images_policies",
+	"image_export_ranges",
+	"images_simplestreams_server",
+	"storage_zfs_incremental_refresh",
+	"container_migration_stateful",
+	"firewall_driver_config",
+	"instance_nic_limits_state",
+	"nic_routed_ipvlan_network_config",
+	"network_bgp",
+	"network_dns_cluster_records",
+	"network_bridge_external_vlan",
+	"network_dhcpv6_pd",
+	"network_bridge_multicast",
+	"network_state_details",
+	"network_state_history",
+	"networks_recursion_2",
+	"etag_conditional_get",
+	"api_filtering_extended",
+	"network_full",
+	"storage_pool_full",
+	"shutdown_timeout",
+	"autostart_parallelism",
+	"instance_autorestart",
+	"clustering_database_roles",
+	"clustering_heartbeat_deltas",
+	"warnings",
+	"custom_volume_backup_schedule",
+	"preseed",
 }
 
 // APIExtensionsCount returns the number of available API extensions.
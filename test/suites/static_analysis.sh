@@ -82,7 +82,6 @@ test_static_analysis() {
       golint -set_exit_status lxd/dnsmasq/...
       golint -set_exit_status lxd/endpoints/...
       golint -set_exit_status lxd/events/...
-      golint -set_exit_status lxd/filter/...
       golint -set_exit_status lxd/firewall/...
       golint -set_exit_status lxd/instance/...
       golint -set_exit_status lxd/maas/...
@@ -112,6 +111,7 @@ test_static_analysis() {
       golint -set_exit_status shared/instancewriter/...
       golint -set_exit_status shared/dnsutil/...
       golint -set_exit_status shared/eagain/...
+      golint -set_exit_status shared/filter/...
       golint -set_exit_status shared/generate/...
       golint -set_exit_status shared/i18n/...
 #      golint -set_exit_status shared/idmap/...